@@ -54,12 +54,16 @@ arbitrary scenarios (i.e. staking, governance).`,
 )
 
 func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
+	startedAt := time.Now()
 	if Config.Construction == nil {
 		return results.ExitConstruction(
 			Config,
 			nil,
 			nil,
+			nil,
+			nil,
 			errors.New("construction configuration is missing"),
+			startedAt,
 		)
 	}
 
@@ -81,7 +85,10 @@ func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
 			Config,
 			nil,
 			nil,
+			nil,
+			nil,
 			fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err),
+			startedAt,
 		)
 	}
 
@@ -92,7 +99,10 @@ func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
 			Config,
 			nil,
 			nil,
+			nil,
+			nil,
 			fmt.Errorf("%w: unable to confirm network is supported", err),
+			startedAt,
 		)
 	}
 
@@ -109,7 +119,10 @@ func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
 			Config,
 			nil,
 			nil,
+			nil,
+			nil,
 			fmt.Errorf("%w: unable to initialize construction tester", err),
+			startedAt,
 		)
 	}
 
@@ -120,7 +133,10 @@ func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
 			Config,
 			nil,
 			nil,
+			nil,
+			nil,
 			fmt.Errorf("%w: unable to perform broadcasts", err),
+			startedAt,
 		)
 	}
 
@@ -142,21 +158,26 @@ func runCheckConstructionCmd(cmd *cobra.Command, args []string) error {
 	})
 
 	g.Go(func() error {
-		return tester.LogMemoryLoop(ctx)
+		return constructionTester.WatchMempool(ctx)
 	})
 
 	g.Go(func() error {
-		return tester.StartServer(
-			ctx,
-			"check:construction status",
-			constructionTester,
-			Config.Construction.StatusPort,
-		)
+		return tester.LogMemoryLoop(ctx)
 	})
 
+	// The status server is only shut down once
+	// the results have been saved.
+	statusServer := tester.NewStatusServer(nil, constructionTester)
+	statusServer.Start("check:construction status", Config.Construction.StatusPort)
+	defer statusServer.Shutdown()
+
 	sigListeners := []context.CancelFunc{cancel}
-	go handleSignals(&sigListeners)
+	defer handleSignals(&sigListeners)()
 
 	err = g.Wait()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		statusServer.RecordFatalError(err)
+	}
+
 	return constructionTester.HandleErr(err, &sigListeners)
 }
@@ -16,16 +16,24 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
 	"time"
 
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/processor"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 	"github.com/coinbase/rosetta-cli/pkg/tester"
 
+	"github.com/coinbase/rosetta-sdk-go/client"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -65,33 +73,284 @@ If your blockchain has a genesis allocation of funds and you set
 historical balance disabled to true, you must provide an
 absolute path to a JSON file containing initial balances with the
 bootstrap balance config. You can look at the examples folder for an example
-of what one of these files looks like.`,
+of what one of these files looks like.
+
+When first testing an implementation, you can set assertion_soft_fail
+to true to catalog (instead of halting on) any transactions that fail
+response assertion. These transactions are skipped while syncing and
+all findings are included in the check:data results.
+
+By default, all requests include a "Cache-Control: no-cache" header. If
+you suspect a caching layer in front of your implementation is returning
+stale balances, you can set cache_probe_interval to periodically compare
+a balance request with a cache-busting request for the same account.
+
+If you suspect your implementation returns different responses to
+simultaneous identical requests (ex: a race in its cache layer), you can
+set consistency_probe_interval to periodically make identical /block and
+/account/balance requests for an already synced block in parallel. Any
+responses that differ (and the fields that differ) are reported in the
+check:data results but never cause check:data to fail.
+
+If your implementation supports historical balance lookup, you can set
+historical_balance_check_interval to periodically compare the balance
+returned by /account/balance at a random past block with the balance
+computed at that block (for a random sample of the accounts changed in
+processed blocks). Any mismatch fails the historical balance tracking
+test. If historical balance lookup is not supported, the test is not run.
+
+When migrating to a new node version, you can set secondary_online_url
+to the URL of a second implementation of the same network. Each live
+balance fetched during reconciliation is also fetched from the secondary
+implementation and any disagreements are reported in the check:data
+results (separately from reconciliation failures). Errors fetching from
+the secondary implementation never cause check:data to fail.
+
+To pipe results to other tools, run with --results-format ndjson. If
+results_output_file is not populated, the results are written to stdout
+as a single line of JSON (all other output is written to stderr).
+
+When running inside orchestration, run with --quiet to suppress periodic
+stats, colored messages, and the results tables. The results are written
+to stdout as a single JSON document (in addition to being saved to
+results_output_file, if populated) and errors are written to stderr.
+
+When running interactively, run with --progress-bar to render the percent
+completed, sync rate, and time remaining as a progress bar that is updated
+in place. If the console is not a terminal, progress is logged line by
+line as usual.
+
+To validate your configuration, network connectivity, and asserter
+setup before starting a long run, run with --dry-run. This fetches
+the network status and options once, initializes the asserter, and
+exits with the request/response and response assertion results
+without syncing any blocks.
+
+To bound the runtime of check:data (ex: in CI), run with --max-duration
+(ex: --max-duration 2h). check:data stops gracefully once that much
+wall-clock time has elapsed and saves its results as if it had reached
+any other end condition. This overrides the duration end condition in
+the configuration file.
+
+To monitor an implementation continuously, run with --watch (ex:
+--watch 1h). Each time check:data exits, it waits that long and
+runs again (starting where the previous run left off), appending
+the results of each run (with the iteration and a timestamp) to
+results_history_file. check:data only stops watching when it
+receives a signal.`,
 		RunE: runCheckDataCmd,
 	}
+
+	resultsFormat string
+	quiet         bool
+	progressBar   bool
+	dryRun        bool
+	maxDuration   time.Duration
+	watch         time.Duration
 )
 
 func runCheckDataCmd(cmd *cobra.Command, args []string) error {
-	ensureDataDirectoryExists()
+	startedAt := time.Now()
+	if len(resultsFormat) > 0 {
+		format := configuration.ResultsOutputFormat(resultsFormat)
+		if err := configuration.AssertResultsOutputFormat(format); err != nil {
+			return fmt.Errorf("%w: invalid --results-format", err)
+		}
+
+		Config.Data.ResultsOutputFormat = format
+	}
+
+	if quiet {
+		Config.Data.Quiet = true
+	}
+
+	if progressBar {
+		Config.Data.ProgressBar = true
+	}
+
+	if cmd.Flags().Changed("max-duration") {
+		if maxDuration <= 0 {
+			return fmt.Errorf("--max-duration %s must be positive", maxDuration)
+		}
+
+		// The duration end condition is tracked in seconds, so
+		// partial seconds are rounded up.
+		seconds := uint64((maxDuration + time.Second - 1) / time.Second)
+		if Config.Data.EndConditions == nil {
+			Config.Data.EndConditions = &configuration.DataEndConditions{}
+		}
+		Config.Data.EndConditions.Duration = &seconds
+	}
+
+	// When results are written to stdout, we write everything
+	// else to stderr so that stdout only contains results. In
+	// quiet mode, nothing else is written at all.
+	switch {
+	case Config.Data.Quiet:
+		color.Output = ioutil.Discard
+	case Config.Data.ResultsOutputFormat == configuration.NDJSONResultsOutputFormat &&
+		len(Config.Data.ResultsOutputFile) == 0:
+		color.Output = os.Stderr
+	}
+
+	// A dry run never syncs, so there is
+	// no need to create a data directory.
+	if !dryRun {
+		ensureDataDirectoryExists()
+	}
+
+	if cmd.Flags().Changed("watch") {
+		if watch <= 0 {
+			return fmt.Errorf("--watch %s must be positive", watch)
+		}
+
+		if dryRun {
+			return errors.New("--watch cannot be used with --dry-run")
+		}
+
+		if len(Config.Data.ResultsHistoryFile) == 0 {
+			return errors.New("--watch requires results_history_file to be populated")
+		}
+
+		return watchCheckData()
+	}
+
+	sigs, stopSignals := notifySignals()
+	defer stopSignals()
+
+	return runCheckData(startedAt, 1, sigs)
+}
+
+// watchCheckData runs check:data again each time the
+// previous run exits (after waiting the --watch interval)
+// until a signal is received. A run that fails is reported
+// but does not stop the watch. Because every run uses the
+// same data directory, each run starts where the previous
+// run left off.
+//
+// Signals are relayed to a single channel for the whole
+// watch. Each run listens on it until it returns and the
+// watch itself receives from it between runs, so there is
+// never more than one reader at a time.
+func watchCheckData() error {
+	sigs, stopSignals := notifySignals()
+	defer stopSignals()
+
+	for iteration := 1; ; iteration++ {
+		err := runCheckData(time.Now(), iteration, sigs)
+		if SignalReceived {
+			return err
+		}
+
+		if err != nil {
+			color.Red("check:data iteration %d failed: %s", iteration, err.Error())
+		}
+
+		color.Cyan("Running check:data iteration %d in %s", iteration+1, watch)
+		select {
+		case sig := <-sigs:
+			color.Red("Received signal: %s", sig)
+			return nil
+		case <-time.After(watch):
+		}
+	}
+}
+
+// runCheckData runs check:data once (starting at
+// startedAt) and returns the error from exiting it.
+// The run is recorded as iteration in the results
+// history and is cancelled by any signal received
+// on sigs before it returns.
+func runCheckData(
+	startedAt time.Time,
+	iteration int,
+	sigs <-chan os.Signal,
+) error {
 	ctx, cancel := context.WithCancel(context.Background())
+	sigListeners := []context.CancelFunc{cancel}
+	defer listenForSignals(sigs, &sigListeners)()
 
-	fetcher := fetcher.New(
+	// We provide our own client so that we can add default
+	// headers, record the latency of each endpoint, the size
+	// of each /block response, and the number of failed and
+	// retried requests, and, when soft failing assertions,
+	// filter /block responses before they are asserted by the
+	// fetcher.
+	blockPayloads := processor.NewBlockPayloads(nil, Config.Data.MaxBlockPayloadBytes)
+	fetchRetries := processor.NewFetchRetries(blockPayloads)
+	endpointLatency := processor.NewEndpointLatency(fetchRetries)
+	clientCfg := client.NewConfiguration(
 		Config.OnlineURL,
+		fetcher.DefaultUserAgent,
+		&http.Client{Transport: endpointLatency},
+	)
+	if !Config.Data.CacheControlDisabled {
+		clientCfg.AddDefaultHeader("Cache-Control", "no-cache")
+	}
+	apiClient := client.NewAPIClient(clientCfg)
+
+	var assertionCatalog *results.AssertionCatalog
+	if Config.Data.AssertionSoftFail {
+		assertionCatalog = results.NewAssertionCatalog(Config.Data.AssertionSoftFailLimit)
+	}
+
+	fetcherOpts := []fetcher.Option{
+		fetcher.WithClient(apiClient),
 		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime)*time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
+		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
+		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
 		fetcher.WithMaxRetries(Config.MaxRetries),
+	}
+
+	fetcher := fetcher.New(
+		Config.OnlineURL,
+		fetcherOpts...,
 	)
 
-	_, _, fetchErr := fetcher.InitializeAsserter(ctx, Config.Network)
+	if assertionCatalog != nil {
+		httpClient := apiClient.GetConfig().HTTPClient
+		httpClient.Transport = processor.NewAssertionFilter(
+			httpClient.Transport,
+			fetcher,
+			assertionCatalog,
+			Config.Data.TrustedCheckpoint,
+		)
+	}
+
+	_, initialStatus, fetchErr := fetcher.InitializeAsserter(ctx, Config.Network)
 	if fetchErr != nil {
 		cancel()
 		return results.ExitData(
 			Config,
-			nil,
-			nil,
 			fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err),
-			"",
-			"",
+			&results.CheckDataInputs{
+				AssertionCatalog: assertionCatalog,
+				EndpointLatency:  endpointLatency.Results(),
+				BlockPayloads:    blockPayloads.Results(),
+				StartedAt:        startedAt,
+				Iteration:        iteration,
+			},
+		)
+	}
+
+	// InitializeAsserter fetches the network status and
+	// options once, so there is nothing left to check
+	// in a dry run.
+	if dryRun {
+		cancel()
+		return results.ExitData(
+			Config,
+			nil,
+			&results.CheckDataInputs{
+				NetworkAsserter:    fetcher.Asserter,
+				AssertionCatalog:   assertionCatalog,
+				EndpointLatency:    endpointLatency.Results(),
+				BlockPayloads:      blockPayloads.Results(),
+				EndCondition:       configuration.DryRunEndCondition,
+				EndConditionDetail: fmt.Sprintf("tip at index %d", initialStatus.CurrentBlockIdentifier.Index),
+				StartedAt:          startedAt,
+				Iteration:          iteration,
+			},
 		)
 	}
 
@@ -100,65 +359,49 @@ func runCheckDataCmd(cmd *cobra.Command, args []string) error {
 		cancel()
 		return results.ExitData(
 			Config,
-			nil,
-			nil,
 			fmt.Errorf("%w: unable to confirm network", err),
-			"",
-			"",
+			&results.CheckDataInputs{
+				AssertionCatalog: assertionCatalog,
+				EndpointLatency:  endpointLatency.Results(),
+				BlockPayloads:    blockPayloads.Results(),
+				StartedAt:        startedAt,
+				Iteration:        iteration,
+			},
 		)
 	}
 
+	negativeRequests := tester.NegativeRequestTest(
+		ctx,
+		Config,
+		fetcher,
+		Config.Network,
+		networkStatus.CurrentBlockIdentifier,
+	)
+
 	dataTester := tester.InitializeData(
 		ctx,
 		Config,
 		Config.Network,
 		fetcher,
+		assertionCatalog,
+		negativeRequests,
+		endpointLatency,
+		blockPayloads,
+		fetchRetries,
 		cancel,
 		networkStatus.GenesisBlockIdentifier,
 		nil, // only populated when doing recursive search
 		&SignalReceived,
+		iteration,
 	)
 
-	defer dataTester.CloseDatabase(ctx)
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.Go(func() error {
-		return dataTester.StartPeriodicLogger(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartReconciler(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartSyncing(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartPruning(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.WatchEndConditions(ctx)
-	})
-
-	g.Go(func() error {
-		return tester.LogMemoryLoop(ctx)
-	})
-
-	g.Go(func() error {
-		return tester.StartServer(
-			ctx,
-			"check:data status",
-			dataTester,
-			Config.Data.StatusPort,
-		)
-	})
-
-	sigListeners := []context.CancelFunc{cancel}
-	go handleSignals(&sigListeners)
+	defer func() {
+		if err := dataTester.Close(context.Background()); err != nil {
+			log.Printf("%s: error closing data tester\n", err.Error())
+		}
+	}()
 
-	err = g.Wait()
+	err = dataTester.Run(ctx)
 
 	// Initialize new context because calling context
 	// will no longer be usable when after termination.
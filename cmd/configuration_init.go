@@ -0,0 +1,276 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// syncFromGenesis starts syncing at
+	// the genesis block.
+	syncFromGenesis = "genesis"
+
+	// syncFromRecent starts syncing at the
+	// current tip of the network.
+	syncFromRecent = "recent"
+)
+
+var (
+	configurationInitCmd = &cobra.Command{
+		Use:   "configuration:init",
+		Short: "Create a configuration file at the provided path from a live implementation",
+		Long: `Create a configuration file at the provided path with defaults
+derived from a running Rosetta implementation. The network list, options, and
+status are fetched from the --online-url and a few questions are asked to
+populate the configuration (which network to test, whether to sync from genesis
+or from the current tip, whether to reconcile balances, and where to store data).
+
+Each question can also be answered with a flag. Run with --non-interactive
+to use the defaults for any question not answered by a flag.
+
+The generated configuration always passes configuration:validate. An existing
+file is never replaced unless --overwrite is provided.`,
+		RunE: runConfigurationInitCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	initOnlineURL      string
+	initBlockchain     string
+	initNetwork        string
+	initSyncFrom       string
+	initReconciliation bool
+	initDataDirectory  string
+	initNonInteractive bool
+	initOverwrite      bool
+)
+
+// initPrompter asks the questions needed to populate
+// a configuration file. When interactive is false (or
+// the answer was provided with a flag), the default is
+// used without asking.
+type initPrompter struct {
+	cmd         *cobra.Command
+	reader      *bufio.Reader
+	interactive bool
+}
+
+func (p *initPrompter) ask(flag string, question string, defaultValue string) (string, error) {
+	if !p.interactive || p.cmd.Flags().Changed(flag) {
+		return defaultValue, nil
+	}
+
+	fmt.Printf("%s [%s]: ", question, defaultValue)
+	answer, err := p.reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("%w: unable to read answer", err)
+	}
+
+	answer = strings.TrimSpace(answer)
+	if len(answer) == 0 {
+		return defaultValue, nil
+	}
+
+	return answer, nil
+}
+
+func (p *initPrompter) askBool(flag string, question string, defaultValue bool) (bool, error) {
+	answer, err := p.ask(flag, question+" (true/false)", strconv.FormatBool(defaultValue))
+	if err != nil {
+		return false, err
+	}
+
+	value, err := strconv.ParseBool(answer)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s is not a valid answer", err, answer)
+	}
+
+	return value, nil
+}
+
+// selectNetwork returns the network in networks matching the
+// provided blockchain and network. If neither is populated
+// and there is more than one network, the user is asked to pick
+// one.
+func (p *initPrompter) selectNetwork(
+	networks []*types.NetworkIdentifier,
+	blockchain string,
+	network string,
+) (*types.NetworkIdentifier, error) {
+	if len(blockchain) > 0 || len(network) > 0 {
+		for _, n := range networks {
+			if n.Blockchain == blockchain && n.Network == network {
+				return n, nil
+			}
+		}
+
+		return nil, fmt.Errorf(
+			"%s:%s is not in %s",
+			blockchain,
+			network,
+			types.PrintStruct(networks),
+		)
+	}
+
+	if len(networks) == 1 || !p.interactive {
+		return networks[0], nil
+	}
+
+	for i, n := range networks {
+		fmt.Printf("%d: %s\n", i, types.PrintStruct(n))
+	}
+
+	answer, err := p.ask("network", "Which network should be tested?", "0")
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := strconv.Atoi(answer)
+	if err != nil || i < 0 || i >= len(networks) {
+		return nil, fmt.Errorf("%s is not a valid network", answer)
+	}
+
+	return networks[i], nil
+}
+
+func runConfigurationInitCmd(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	if _, err := os.Stat(path); err == nil && !initOverwrite {
+		return fmt.Errorf("%s already exists (run with --overwrite to replace it)", path)
+	}
+
+	ctx := context.Background()
+	defaults := configuration.DefaultConfiguration()
+	f := fetcher.New(
+		initOnlineURL,
+		fetcher.WithRetryElapsedTime(time.Duration(defaults.RetryElapsedTime)*time.Second),
+		fetcher.WithTimeout(time.Duration(defaults.HTTPTimeout)*time.Second),
+		fetcher.WithMaxRetries(defaults.MaxRetries),
+	)
+
+	networkList, fetchErr := f.NetworkListRetry(ctx, nil)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch network list", fetchErr.Err)
+	}
+
+	if len(networkList.NetworkIdentifiers) == 0 {
+		return errors.New("no networks available")
+	}
+
+	prompter := &initPrompter{
+		cmd:         cmd,
+		reader:      bufio.NewReader(os.Stdin),
+		interactive: !initNonInteractive,
+	}
+
+	network, err := prompter.selectNetwork(
+		networkList.NetworkIdentifiers,
+		initBlockchain,
+		initNetwork,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to select network", err)
+	}
+
+	networkOptions, fetchErr := f.NetworkOptionsRetry(ctx, network, nil)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch network options", fetchErr.Err)
+	}
+
+	networkStatus, fetchErr := f.NetworkStatusRetry(ctx, network, nil)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch network status", fetchErr.Err)
+	}
+
+	syncFrom, err := prompter.ask(
+		"sync-from",
+		fmt.Sprintf("Sync from %s or %s?", syncFromGenesis, syncFromRecent),
+		initSyncFrom,
+	)
+	if err != nil {
+		return err
+	}
+
+	reconciliation, err := prompter.askBool(
+		"reconciliation",
+		"Reconcile balances?",
+		initReconciliation,
+	)
+	if err != nil {
+		return err
+	}
+
+	dataDirectory, err := prompter.ask(
+		"data-directory",
+		"Data directory (empty for a temporary directory)?",
+		initDataDirectory,
+	)
+	if err != nil {
+		return err
+	}
+
+	config := defaults
+	config.OnlineURL = initOnlineURL
+	config.Network = network
+	config.DataDirectory = dataDirectory
+	config.Data.ReconciliationDisabled = !reconciliation
+	config.Data.HistoricalBalanceEnabled = &networkOptions.Allow.HistoricalBalanceLookup
+
+	// Exit once the implementation has been
+	// synced to tip.
+	tip := true
+	config.Data.EndConditions = &configuration.DataEndConditions{Tip: &tip}
+
+	switch syncFrom {
+	case syncFromGenesis:
+	case syncFromRecent:
+		startIndex := networkStatus.CurrentBlockIdentifier.Index
+		config.Data.StartIndex = &startIndex
+	default:
+		return fmt.Errorf(
+			"%s is not a valid sync start (%s or %s)",
+			syncFrom,
+			syncFromGenesis,
+			syncFromRecent,
+		)
+	}
+
+	if err := utils.SerializeAndWrite(path, config); err != nil {
+		return fmt.Errorf("%w: unable to save configuration file to %s", err, path)
+	}
+
+	if _, err := configuration.LoadConfiguration(path); err != nil {
+		return fmt.Errorf("%w: generated configuration file %s is invalid", err, path)
+	}
+
+	color.Green("Configuration file saved to %s!", path)
+	return nil
+}
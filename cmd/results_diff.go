@@ -0,0 +1,63 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	resultsDiffArgs = 2
+)
+
+var (
+	resultsDiffCmd = &cobra.Command{
+		Use:     "results:diff",
+		Aliases: []string{"utils:diff-results"},
+		Short:   "Compare the check:data results files at the provided paths",
+		Long: `Compare two results files saved by check:data (in JSON or ndjson),
+where the first path is the old run and the second path is the new run.
+This prints the status of each test in both runs (tests that regressed
+are highlighted in red), the change in each stat (ex: orphans, reconciliation
+failures, and reconciliation coverage), and whether the end condition changed.
+
+If any test that did not fail in the old run fails in the new run,
+this command exits with a non-zero exit code. Changes in stats or
+in the end condition do not cause a non-zero exit code.`,
+		RunE: runResultsDiffCmd,
+		Args: cobra.ExactArgs(resultsDiffArgs),
+	}
+)
+
+func runResultsDiffCmd(cmd *cobra.Command, args []string) error {
+	oldResults, err := results.LoadCheckDataResultsFile(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: unable to load results file %s", err, args[0])
+	}
+
+	newResults, err := results.LoadCheckDataResultsFile(args[1])
+	if err != nil {
+		return fmt.Errorf("%w: unable to load results file %s", err, args[1])
+	}
+
+	diff := results.DiffCheckDataResults(oldResults, newResults)
+	diff.Print()
+
+	return diff.Regressed()
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resultsViewCmd = &cobra.Command{
+		Use:   "results:view",
+		Short: "Print the results file at the provided path",
+		Long: `Print the tables for a results file saved by check:data or
+check:construction (in JSON or ndjson), as they are printed when the
+run ends. The kind of results is detected from the fields in the file.
+
+If any test in the results file failed (or the run ended with an error),
+this command exits with a non-zero exit code (the same exit code as the
+check:data run, for check:data results). This makes it possible to gate
+CI on a results file produced elsewhere.`,
+		RunE: runResultsViewCmd,
+		Args: cobra.ExactArgs(1),
+	}
+)
+
+func runResultsViewCmd(cmd *cobra.Command, args []string) error {
+	file, err := results.LoadResultsFile(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: unable to load results file %s", err, args[0])
+	}
+
+	file.Print()
+
+	return file.Failure()
+}
@@ -25,6 +25,10 @@ import (
 	"syscall"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+	"github.com/coinbase/rosetta-cli/pkg/version"
 
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
@@ -41,6 +45,10 @@ var (
 	configurationFile string
 	cpuProfile        string
 	memProfile        string
+	noColor           bool
+	resultsColumns    []string
+	logLevel          string
+	logJSON           bool
 
 	// Config is the populated *configuration.Configuration from
 	// the configurationFile. If none is provided, this is set
@@ -56,11 +64,33 @@ var (
 	profileCleanup func()
 )
 
-// rootPreRun is executed before the root command runs and sets up cpu
-// profiling.
+// rootPreRun is executed before the root command runs, disables
+// colored output and table borders (if requested), selects the
+// results columns, configures logging, and sets up cpu profiling.
 //
 // Bassed on https://golang.org/pkg/runtime/pprof/#hdr-Profiling_a_Go_program
 func rootPreRun(*cobra.Command, []string) error {
+	results.ConfigureOutput(noColor || Config.PlainOutput)
+
+	columns := Config.ResultsColumns
+	if len(resultsColumns) > 0 {
+		columns = make([]configuration.ResultsColumn, len(resultsColumns))
+		for i, column := range resultsColumns {
+			columns[i] = configuration.ResultsColumn(column)
+		}
+
+		if err := configuration.AssertResultsColumns(columns); err != nil {
+			return fmt.Errorf("%w: invalid --results-columns", err)
+		}
+	}
+	results.ConfigureColumns(columns)
+
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return fmt.Errorf("%w: invalid --log-level", err)
+	}
+	logging.SetLogger(logging.NewStandardLogger(os.Stderr, level, logJSON))
+
 	if cpuProfile == "" {
 		return nil
 	}
@@ -148,13 +178,136 @@ default values.`,
 		"",
 		`Save the pprof mem profile in the specified file`,
 	)
+	rootFlags.BoolVar(
+		&noColor,
+		"no-color",
+		false,
+		`Disable colored output and print tables in a plain style (the
+same as setting NO_COLOR or plain_output in the configuration file).
+Colored output is always disabled when stdout is not a terminal.`,
+	)
+	rootFlags.StringSliceVar(
+		&resultsColumns,
+		"results-columns",
+		[]string{},
+		`Columns printed in the check:data stats and tests tables
+("name", "description", "value", "status", and "detail"). The name
+column is always printed (ex: "name,value,status" omits descriptions).
+This overrides results_columns in the configuration file.`,
+	)
+	rootFlags.StringVar(
+		&logLevel,
+		"log-level",
+		logging.InfoLevel.String(),
+		`Minimum level of diagnostic messages written to stderr
+("debug", "info", "warn", or "error")`,
+	)
+	rootFlags.BoolVar(
+		&logJSON,
+		"log-json",
+		false,
+		`Write diagnostic messages to stderr as JSON (one message per line)`,
+	)
 	rootCmd.AddCommand(versionCmd)
 
 	// Configuration Commands
 	rootCmd.AddCommand(configurationCreateCmd)
+
+	initFlags := configurationInitCmd.Flags()
+	initFlags.StringVar(
+		&initOnlineURL,
+		"online-url",
+		configuration.DefaultURL,
+		"URL of the Rosetta implementation to derive the configuration from",
+	)
+	initFlags.StringVar(
+		&initBlockchain,
+		"blockchain",
+		"",
+		"Blockchain of the network to test (required if there are multiple networks)",
+	)
+	initFlags.StringVar(
+		&initNetwork,
+		"network",
+		"",
+		"Network of the network to test (required if there are multiple networks)",
+	)
+	initFlags.StringVar(
+		&initSyncFrom,
+		"sync-from",
+		syncFromGenesis,
+		`Block to start syncing from ("genesis" or "recent" for the current tip)`,
+	)
+	initFlags.BoolVar(
+		&initReconciliation,
+		"reconciliation",
+		true,
+		"Reconcile computed balances with balances returned by the implementation",
+	)
+	initFlags.StringVar(
+		&initDataDirectory,
+		"data-directory",
+		"",
+		"Directory to store data in (a temporary directory is used if empty)",
+	)
+	initFlags.BoolVar(
+		&initNonInteractive,
+		"non-interactive",
+		false,
+		"Use the defaults for any question not answered by a flag",
+	)
+	initFlags.BoolVar(
+		&initOverwrite,
+		"overwrite",
+		false,
+		"Replace the configuration file if it already exists",
+	)
+	rootCmd.AddCommand(configurationInitCmd)
 	rootCmd.AddCommand(configurationValidateCmd)
 
 	// Check commands
+	checkDataCmd.Flags().StringVar(
+		&resultsFormat,
+		"results-format",
+		"",
+		`Format used to save check:data results ("json", "junit", or "ndjson").
+This overrides results_output_format in the configuration file.`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&quiet,
+		"quiet",
+		false,
+		`Only write the check:data results to stdout (as a single JSON
+document). This overrides quiet in the configuration file.`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&progressBar,
+		"progress-bar",
+		false,
+		`Render progress as a progress bar that is updated in place (if the
+console is a terminal). This overrides progress_bar in the configuration file.`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Validate the configuration, network connectivity, and asserter setup without syncing",
+	)
+	checkDataCmd.Flags().DurationVar(
+		&maxDuration,
+		"max-duration",
+		0,
+		`Stop check:data after this much wall-clock time (ex: "90m").
+This overrides the duration end condition in the configuration file.`,
+	)
+	checkDataCmd.Flags().DurationVar(
+		&watch,
+		"watch",
+		0,
+		`Run check:data again this long after each run exits (ex: "1h"),
+appending the results of each run to results_history_file, until
+a signal is received.`,
+	)
 	rootCmd.AddCommand(checkDataCmd)
 	rootCmd.AddCommand(checkConstructionCmd)
 
@@ -163,9 +316,50 @@ default values.`,
 	rootCmd.AddCommand(viewAccountCmd)
 	rootCmd.AddCommand(viewNetworksCmd)
 
+	viewStatusCmd.Flags().StringArrayVar(
+		&statusHeaders,
+		"header",
+		[]string{},
+		`Header to send with the status request (ex: "Authorization: Bearer token").
+Can be provided multiple times.`,
+	)
+	viewStatusCmd.Flags().DurationVar(
+		&statusTimeout,
+		"timeout",
+		results.DefaultJSONFetchTimeout,
+		"Most time to wait for the status",
+	)
+	viewStatusCmd.Flags().BoolVar(
+		&statusConstruction,
+		"construction",
+		false,
+		"Fetch the status of a running check:construction (instead of check:data)",
+	)
+	rootCmd.AddCommand(viewStatusCmd)
+
 	// Utils
 	rootCmd.AddCommand(utilsAsserterConfigurationCmd)
 	rootCmd.AddCommand(utilsTrainZstdCmd)
+
+	utilsValidateResultsCmd.Flags().BoolVar(
+		&allowPartial,
+		"allow-partial",
+		false,
+		"Validate partial results written by check:data while it runs",
+	)
+	rootCmd.AddCommand(utilsValidateResultsCmd)
+	rootCmd.AddCommand(resultsViewCmd)
+	rootCmd.AddCommand(resultsDiffCmd)
+
+	utilsMetricsDashboardCmd.Flags().StringVar(
+		&datasourceUID,
+		"datasource-uid",
+		metrics.DefaultDatasourceUID,
+		"Grafana datasource UID to use in the generated dashboard",
+	)
+	rootCmd.AddCommand(utilsMetricsDashboardCmd)
+	rootCmd.AddCommand(utilsExportCheckpointCmd)
+	rootCmd.AddCommand(utilsImportCheckpointCmd)
 }
 
 func initConfig() {
@@ -193,26 +387,65 @@ func ensureDataDirectoryExists() {
 	}
 }
 
-// handleSignals handles OS signals so we can ensure we close database
-// correctly. We call multiple sigListeners because we
-// may need to cancel more than 1 context.
-func handleSignals(listeners *[]context.CancelFunc) {
+// notifySignals returns a channel that receives the OS
+// signals we handle and a function that stops
+// relaying signals to it.
+func notifySignals() (<-chan os.Signal, func()) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	return sigs, func() {
+		signal.Stop(sigs)
+	}
+}
+
+// listenForSignals cancels listeners when a signal is
+// received on sigs so we can ensure we close database
+// correctly. We call multiple listeners because we
+// may need to cancel more than 1 context. The returned
+// function stops listening and only returns once the
+// listening goroutine has exited, so SignalReceived
+// can be read safely afterwards.
+func listenForSignals(sigs <-chan os.Signal, listeners *[]context.CancelFunc) func() {
+	done := make(chan struct{})
+	exited := make(chan struct{})
 	go func() {
-		sig := <-sigs
-		color.Red("Received signal: %s", sig)
-		SignalReceived = true
-		for _, listener := range *listeners {
-			listener()
+		defer close(exited)
+
+		select {
+		case sig := <-sigs:
+			color.Red("Received signal: %s", sig)
+			SignalReceived = true
+			for _, listener := range *listeners {
+				listener()
+			}
+		case <-done:
 		}
 	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// handleSignals handles OS signals by cancelling
+// listeners (see listenForSignals). The returned
+// function stops handling signals.
+func handleSignals(listeners *[]context.CancelFunc) func() {
+	sigs, stopNotify := notifySignals()
+	stopListening := listenForSignals(sigs, listeners)
+
+	return func() {
+		stopListening()
+		stopNotify()
+	}
 }
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print rosetta-cli version",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("v0.5.7")
+		fmt.Println(version.Version)
 	},
 }
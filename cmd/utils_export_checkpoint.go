@@ -0,0 +1,70 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsExportCheckpointCmd = &cobra.Command{
+		Use:   "utils:export-checkpoint",
+		Short: "Export the check:data sync state to a checkpoint file at the provided path",
+		Long: `check:data persists its sync state in the data directory, but the
+data directory is tied to the machine it was created on. This command
+exports the sync state for the configured network (the last synced block
+identifier, every computed balance including the block each balance
+was last reconciled at, and, unless coin tracking is disabled, the unspent
+coins of each account) into a single portable JSON file.
+
+The checkpoint can be imported into an empty data directory (on any machine)
+with utils:import-checkpoint, after which check:data resumes syncing
+at the block after the checkpoint block.
+
+The data_directory must be populated in the configuration file. The
+checkpoint is exported from a read-only snapshot of the data directory,
+so check:data can keep running on it while the checkpoint is exported.`,
+		RunE: runExportCheckpointCmd,
+		Args: cobra.ExactArgs(1),
+	}
+)
+
+func runExportCheckpointCmd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	checkpoint, err := tester.ExportCheckpoint(ctx, Config, Config.Network)
+	if err != nil {
+		return fmt.Errorf("%w: unable to export checkpoint", err)
+	}
+
+	if err := utils.SerializeAndWrite(args[0], checkpoint); err != nil {
+		return fmt.Errorf("%w: unable to write checkpoint to %s", err, args[0])
+	}
+
+	color.Green(
+		"Exported checkpoint at block %s with %d balances to %s",
+		types.PrintStruct(checkpoint.BlockIdentifier),
+		len(checkpoint.Balances),
+		args[0],
+	)
+	return nil
+}
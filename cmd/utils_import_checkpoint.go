@@ -0,0 +1,107 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsImportCheckpointCmd = &cobra.Command{
+		Use:   "utils:import-checkpoint",
+		Short: "Import a checkpoint file at the provided path into the check:data data directory",
+		Long: `Import a checkpoint exported with utils:export-checkpoint into the
+data directory for the configured network. After importing, check:data resumes
+syncing at the block after the checkpoint block (instead of at genesis). This
+makes it possible to snapshot a long historical sync and replay it against
+a new implementation build.
+
+Before anything is imported, the checkpoint is validated:
+1. The checkpoint network must match the configured network
+2. The checkpoint block must match the block the configured implementation
+returns at the checkpoint index
+
+If either check fails, the checkpoint is not imported.
+
+The data_directory must be populated in the configuration file and must
+not contain any synced blocks for the configured network.`,
+		RunE: runImportCheckpointCmd,
+		Args: cobra.ExactArgs(1),
+	}
+)
+
+func runImportCheckpointCmd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	var checkpoint tester.Checkpoint
+	if err := utils.LoadAndParse(args[0], &checkpoint); err != nil {
+		return fmt.Errorf("%w: unable to load checkpoint %s", err, args[0])
+	}
+
+	if checkpoint.Network == nil || checkpoint.BlockIdentifier == nil {
+		return fmt.Errorf("checkpoint %s is missing a network or block identifier", args[0])
+	}
+
+	// Create a new fetcher
+	newFetcher := fetcher.New(
+		Config.OnlineURL,
+		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime)*time.Second),
+		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
+		fetcher.WithMaxRetries(Config.MaxRetries),
+	)
+
+	_, _, fetchErr := newFetcher.InitializeAsserter(ctx, Config.Network)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
+	}
+
+	_, err := utils.CheckNetworkSupported(ctx, Config.Network, newFetcher)
+	if err != nil {
+		return fmt.Errorf("%w: unable to confirm network is supported", err)
+	}
+
+	// The checkpoint block is stored as the head block,
+	// so we fetch the entire block (not just its identifier).
+	block, fetchErr := newFetcher.BlockRetry(
+		ctx,
+		Config.Network,
+		&types.PartialBlockIdentifier{
+			Index: &checkpoint.BlockIdentifier.Index,
+		},
+	)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch checkpoint block", fetchErr.Err)
+	}
+
+	if err := tester.ImportCheckpoint(ctx, Config, Config.Network, &checkpoint, block); err != nil {
+		return fmt.Errorf("%w: unable to import checkpoint %s", err, args[0])
+	}
+
+	color.Green(
+		"Imported checkpoint at block %s with %d balances",
+		types.PrintStruct(checkpoint.BlockIdentifier),
+		len(checkpoint.Balances),
+	)
+	return nil
+}
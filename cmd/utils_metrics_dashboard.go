@@ -0,0 +1,64 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	metricsDashboardArgs = 2
+)
+
+var (
+	utilsMetricsDashboardCmd = &cobra.Command{
+		Use:   "utils:metrics-dashboard",
+		Short: "Generate a Grafana dashboard and Prometheus rules for check:data metrics",
+		Long: `This command generates a Grafana dashboard (in JSON) and a Prometheus
+recording and alerting rules file for the metrics exposed by check:data. Both
+files are generated from the same metric definitions used by check:data, so
+they always match the metrics that are exposed.
+
+The arguments for this command are:
+<dashboard path> <rules path>
+
+By default, the dashboard uses the datasource UID placeholder ${DS_PROMETHEUS}
+(Grafana will prompt for a Prometheus datasource when it is imported). You can
+provide a different datasource UID with the --datasource-uid flag.`,
+		RunE: runMetricsDashboardCmd,
+		Args: cobra.ExactArgs(metricsDashboardArgs),
+	}
+
+	datasourceUID string
+)
+
+func runMetricsDashboardCmd(cmd *cobra.Command, args []string) error {
+	if err := utils.SerializeAndWrite(args[0], metrics.Dashboard(datasourceUID)); err != nil {
+		return fmt.Errorf("%w: unable to save dashboard", err)
+	}
+
+	if err := utils.SerializeAndWrite(args[1], metrics.Rules()); err != nil {
+		return fmt.Errorf("%w: unable to save rules", err)
+	}
+
+	color.Green("Dashboard and rules saved!")
+	return nil
+}
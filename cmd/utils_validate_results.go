@@ -0,0 +1,76 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsValidateResultsCmd = &cobra.Command{
+		Use:   "utils:validate-results",
+		Short: "Ensure a results file at the provided path matches this version's results format",
+		Long: `Results files saved by check:data and check:construction (in JSON
+or ndjson) can change shape between rosetta-cli versions. This command
+ensures a results file contains every field this version of rosetta-cli
+writes (except fields omitted when empty), contains no unknown fields, and
+that each field has the correct type.
+
+If the results file doesn't match, the path of each offending field is
+printed and the command exits with a non-zero exit code.
+
+Partial results (written by check:data while it runs) are rejected
+unless --allow-partial is provided.`,
+		RunE: runValidateResultsCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	allowPartial bool
+)
+
+func runValidateResultsCmd(cmd *cobra.Command, args []string) error {
+	validation, err := results.ValidateResultsFile(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: unable to validate results file %s", err, args[0])
+	}
+
+	if validation.Partial && !allowPartial {
+		return fmt.Errorf(
+			"results file %s contains partial results (use --allow-partial to validate it)",
+			args[0],
+		)
+	}
+
+	if len(validation.Problems) > 0 {
+		for _, problem := range validation.Problems {
+			color.Red("%s", problem)
+		}
+
+		return fmt.Errorf(
+			"%s results file %s has %d problems",
+			validation.Kind,
+			args[0],
+			len(validation.Problems),
+		)
+	}
+
+	color.Green("%s results file validated!", validation.Kind)
+	return nil
+}
@@ -16,9 +16,13 @@ package configuration
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+	"text/template"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/constructor/job"
@@ -28,6 +32,59 @@ import (
 	"github.com/fatih/color"
 )
 
+// EventType is a type of event sent to
+// the event sinks of a check:data run.
+type EventType string
+
+const (
+	// RunStartedEvent is sent when a check:data run starts.
+	RunStartedEvent EventType = "run_started"
+
+	// DegradedPeriodEvent is sent when syncing has not made
+	// progress (while behind tip) for the degraded period.
+	DegradedPeriodEvent EventType = "degraded_period"
+
+	// ReconciliationFailureEvent is sent each time a
+	// reconciliation fails.
+	ReconciliationFailureEvent EventType = "reconciliation_failure"
+
+	// ReorgBeyondDepthEvent is sent when a reorg removes
+	// more blocks than the reorg depth.
+	ReorgBeyondDepthEvent EventType = "reorg_beyond_depth"
+
+	// EndConditionEvent is sent when an end condition is met.
+	EndConditionEvent EventType = "end_condition"
+
+	// FatalErrorEvent is sent when a check:data run fails.
+	FatalErrorEvent EventType = "fatal_error"
+)
+
+// EventTypes are all supported EventTypes.
+var EventTypes = []EventType{
+	RunStartedEvent,
+	DegradedPeriodEvent,
+	ReconciliationFailureEvent,
+	ReorgBeyondDepthEvent,
+	EndConditionEvent,
+	FatalErrorEvent,
+}
+
+// EventSinkType is a kind of event sink.
+type EventSinkType string
+
+const (
+	// WebhookEventSink POSTs each event to a URL.
+	WebhookEventSink EventSinkType = "webhook"
+
+	// ExecEventSink runs a command with each
+	// event (as JSON) on stdin.
+	ExecEventSink EventSinkType = "exec"
+
+	// FileEventSink appends each event (as a
+	// line of JSON) to a file.
+	FileEventSink EventSinkType = "file"
+)
+
 // CheckDataEndCondition is a type of "successful" end
 // for the "check:data" method.
 type CheckDataEndCondition string
@@ -37,6 +94,11 @@ const (
 	// has been met.
 	IndexEndCondition CheckDataEndCondition = "Index End Condition"
 
+	// BlockEndCondition is used to indicate that the block end
+	// condition has been met (the block synced at the index of
+	// EndBlockIdentifier has its hash).
+	BlockEndCondition CheckDataEndCondition = "Block End Condition"
+
 	// DurationEndCondition is used to indicate that the duration
 	// end condition has been met.
 	DurationEndCondition CheckDataEndCondition = "Duration End Condition"
@@ -48,8 +110,141 @@ const (
 	// ReconciliationCoverageEndCondition is used to indicate that the reconciliation
 	// coverage end condition has been met.
 	ReconciliationCoverageEndCondition CheckDataEndCondition = "Reconciliation Coverage End Condition"
+
+	// HaltEndCondition is used to indicate that the chain halted
+	// at the expected halt index.
+	HaltEndCondition CheckDataEndCondition = "Halt End Condition"
+
+	// DryRunEndCondition is used to indicate that check:data
+	// exited after validating its configuration (--dry-run).
+	DryRunEndCondition CheckDataEndCondition = "Dry Run End Condition"
+
+	// TipLagEndCondition is used to indicate that the syncer
+	// fell too far behind the tip for a sustained period.
+	TipLagEndCondition CheckDataEndCondition = "Tip Lag End Condition"
+
+	// InterruptedEndCondition is used to indicate that check:data
+	// was interrupted by a signal (ex: SIGINT) and stopped after
+	// committing all blocks being processed.
+	InterruptedEndCondition CheckDataEndCondition = "Interrupted End Condition"
+
+	// ReconciliationFailuresEndCondition is used to indicate that
+	// check:data stopped because the reconciliation failure budget
+	// (MaxReconciliationFailures) was exhausted. Unlike the other
+	// end conditions, the run fails.
+	ReconciliationFailuresEndCondition CheckDataEndCondition = "Reconciliation Failures End Condition"
+)
+
+// ResultsOutputFormat is the format used to save the
+// results of a check:data or check:construction run.
+type ResultsOutputFormat string
+
+const (
+	// JSONResultsOutputFormat saves results as JSON.
+	JSONResultsOutputFormat ResultsOutputFormat = "json"
+
+	// JUnitResultsOutputFormat saves results as a JUnit XML
+	// testsuite so that they can be consumed by CI.
+	JUnitResultsOutputFormat ResultsOutputFormat = "junit"
+
+	// NDJSONResultsOutputFormat saves results as a single line
+	// of JSON (newline-delimited) so that they can be piped to
+	// other tools. If no results output file is provided, results
+	// are written to stdout.
+	NDJSONResultsOutputFormat ResultsOutputFormat = "ndjson"
+)
+
+// ResultsColumn is a column of the tables used to
+// print the check:data stats and tests.
+type ResultsColumn string
+
+const (
+	// NameResultsColumn is the name of each stat or test.
+	// It is always printed.
+	NameResultsColumn ResultsColumn = "name"
+
+	// DescriptionResultsColumn describes each stat or test.
+	DescriptionResultsColumn ResultsColumn = "description"
+
+	// ValueResultsColumn is the value of each stat.
+	ValueResultsColumn ResultsColumn = "value"
+
+	// StatusResultsColumn is the status of each test.
+	StatusResultsColumn ResultsColumn = "status"
+
+	// DetailResultsColumn explains the status of each test.
+	DetailResultsColumn ResultsColumn = "detail"
+)
+
+// ResultsColumns are all supported ResultsColumn.
+var ResultsColumns = []ResultsColumn{
+	NameResultsColumn,
+	DescriptionResultsColumn,
+	ValueResultsColumn,
+	StatusResultsColumn,
+	DetailResultsColumn,
+}
+
+// ErrorCategory is a class of error that can stop
+// a check:data run.
+type ErrorCategory string
+
+const (
+	// FetchErrorCategory is an error fetching from
+	// the implementation (ex: a 502 response).
+	FetchErrorCategory ErrorCategory = "fetch"
+
+	// AssertionErrorCategory is a response that
+	// is not correctly formatted.
+	AssertionErrorCategory ErrorCategory = "assertion"
+
+	// StorageErrorCategory is an error storing a
+	// synced block (ex: a coin spent twice).
+	StorageErrorCategory ErrorCategory = "storage"
+
+	// ReconciliationErrorCategory is a balance discrepancy
+	// between computed and live balances.
+	ReconciliationErrorCategory ErrorCategory = "reconciliation"
+
+	// NegativeBalanceErrorCategory is a computed
+	// balance that went negative.
+	NegativeBalanceErrorCategory ErrorCategory = "negative_balance"
 )
 
+// ErrorCategories are all supported ErrorCategories.
+var ErrorCategories = []ErrorCategory{
+	FetchErrorCategory,
+	AssertionErrorCategory,
+	StorageErrorCategory,
+	ReconciliationErrorCategory,
+	NegativeBalanceErrorCategory,
+}
+
+// ErrorAction is how check:data handles an error
+// of an ErrorCategory once it has reached tip.
+type ErrorAction string
+
+const (
+	// AbortErrorAction stops check:data with the error.
+	// This is the action for any category not in the
+	// error policy (and for all errors before tip).
+	AbortErrorAction ErrorAction = "abort"
+
+	// RetryErrorAction records the error and resumes
+	// syncing from the last synced block after an
+	// exponential backoff.
+	RetryErrorAction ErrorAction = "retry-with-backoff"
+
+	// RecordErrorAction records the error and continues
+	// (resuming syncing from the last synced block if
+	// the error stopped syncing).
+	RecordErrorAction ErrorAction = "record-and-continue"
+)
+
+// RedactedValue replaces any secret in a
+// configuration returned by SanitizeConfiguration.
+const RedactedValue = "REDACTED"
+
 // Default Configuration Values
 const (
 	DefaultURL                               = "http://localhost:8080"
@@ -67,6 +262,23 @@ const (
 	DefaultTipDelay                          = 300
 	DefaultBlockBroadcastLimit               = 5
 	DefaultStatusPort                        = 9090
+	DefaultBlockResultsFlushInterval         = 10
+	DefaultAssertionSoftFailLimit            = 1000
+	DefaultResultsOutputFormat               = JSONResultsOutputFormat
+	DefaultHaltConfirmationPeriod            = 600
+	DefaultTipLagPeriod                      = 300
+	DefaultSyncRateWindow                    = 300
+	DefaultOrphanRateWindow                  = 1000
+	DefaultResultsWebhookTimeout             = 10
+	DefaultResultsSinkTimeout                = 60
+	DefaultCompletionWebhookTimeout          = 10
+	DefaultReconciliationFailureLimit        = 100
+	DefaultEventSinkTimeout                  = 10
+	DefaultDegradedPeriod                    = 600
+	DefaultReorgEventDepth                   = 10
+	DefaultMempoolVisibilityTimeout          = 600
+	DefaultConsistencyProbeRequests          = 4
+	DefaultReconciliationSkipRatioThreshold  = 0.05
 
 	// ETH Defaults
 	EthereumIDBlockchain = "Ethereum"
@@ -120,6 +332,20 @@ type ConstructionConfiguration struct {
 	// rebroadcast from BroadcastStorage on restart.
 	RebroadcastAll bool `json:"rebroadcast_all"`
 
+	// RebroadcastLost indicates if transactions that were in flight
+	// when check:construction last stopped, and that are no longer
+	// known to the node, should be rebroadcast on restart. Otherwise,
+	// they are rebroadcast once they are considered stale.
+	RebroadcastLost bool `json:"rebroadcast_lost,omitempty"`
+
+	// HashMismatchSearchDepth is the number of most recent blocks
+	// searched for a transaction matching the intent of a stale
+	// broadcast (one not found on-chain by the hash returned by
+	// /construction/hash). If a matching transaction is found under
+	// a different hash, check:construction fails with a hash mismatch.
+	// If not populated, StaleDepth is used.
+	HashMismatchSearchDepth int64 `json:"hash_mismatch_search_depth,omitempty"`
+
 	// PrefundedAccounts is an array of prefunded accounts
 	// to use while testing.
 	PrefundedAccounts []*storage.PrefundedAccount `json:"prefunded_accounts,omitempty"`
@@ -139,15 +365,39 @@ type ConstructionConfiguration struct {
 	// StatusPort allows the caller to query a running check:construction
 	// test to get stats about progress. This can be used instead
 	// of parsing logs to populate some sort of status dashboard.
+	// The status is served at /construction/status and /healthz returns 200
+	// until the test fails with a fatal error (and 503 after).
 	StatusPort uint `json:"status_port,omitempty"`
 
 	// ResultsOutputFile is the absolute filepath of where to save
 	// the results of a check:construction run.
 	ResultsOutputFile string `json:"results_output_file,omitempty"`
 
+	// ResultsOutputFormat is the format used to save the results
+	// of a check:construction run ("json", "junit", or "ndjson").
+	ResultsOutputFormat ResultsOutputFormat `json:"results_output_format,omitempty"`
+
+	// CompletionWebhook is a URL the results and summary of a
+	// check:construction run are POSTed to when it exits (whether it
+	// succeeded or failed). The payload includes a text field, so it
+	// can be a Slack incoming webhook. If the webhook is unreachable,
+	// the error is only logged.
+	CompletionWebhook string `json:"completion_webhook,omitempty"`
+
+	// CompletionWebhookTimeout is the number of seconds to wait for
+	// the request to CompletionWebhook. If CompletionWebhookTimeout
+	// is not populated, DefaultCompletionWebhookTimeout is used.
+	CompletionWebhookTimeout uint64 `json:"completion_webhook_timeout,omitempty"`
+
 	// Quiet is a boolean indicating if all request and response
 	// logging should be silenced.
 	Quiet bool `json:"quiet,omitempty"`
+
+	// MempoolVisibilityTimeout is the number of seconds a transaction
+	// accepted by /construction/submit has to appear in the mempool
+	// (or in a block) before check:construction fails. This is only
+	// checked if the Rosetta implementation supports /mempool.
+	MempoolVisibilityTimeout uint64 `json:"mempool_visibility_timeout,omitempty"`
 }
 
 // DefaultDataConfiguration returns the default *DataConfiguration
@@ -158,6 +408,12 @@ func DefaultDataConfiguration() *DataConfiguration {
 		InactiveReconciliationConcurrency: DefaultInactiveReconciliationConcurrency,
 		InactiveReconciliationFrequency:   DefaultInactiveReconciliationFrequency,
 		StatusPort:                        DefaultStatusPort,
+		BlockResultsFlushInterval:         DefaultBlockResultsFlushInterval,
+		AssertionSoftFailLimit:            DefaultAssertionSoftFailLimit,
+		ResultsOutputFormat:               DefaultResultsOutputFormat,
+		SyncRateWindow:                    DefaultSyncRateWindow,
+		OrphanRateWindow:                  DefaultOrphanRateWindow,
+		ReconciliationFailureLimit:        DefaultReconciliationFailureLimit,
 	}
 }
 
@@ -183,11 +439,27 @@ type DataEndConditions struct {
 	// Index configures the syncer to stop once reaching a particular block height.
 	Index *int64 `json:"index,omitempty"`
 
+	// EndBlockIdentifier configures the syncer to stop once reaching
+	// the block at a particular height and to check that the synced
+	// block at that height has a particular hash (so that runs of
+	// different versions stop at exactly the same block, even on
+	// chains with reorgs). If the synced block has a different hash,
+	// check:data exits with an error. This cannot be combined with
+	// Index.
+	EndBlockIdentifier *types.BlockIdentifier `json:"end_block_identifier,omitempty"`
+
 	// Tip configures the syncer to stop once it reached the tip.
 	// Make sure to configure `tip_delay` if you use this end
 	// condition.
 	Tip *bool `json:"tip,omitempty"`
 
+	// TipHoldSeconds configures the Tip end condition to only
+	// stop once the syncer has remained at tip (within
+	// `tip_delay`) for TipHoldSeconds seconds, processing new
+	// blocks as they arrive. Falling behind tip restarts the
+	// hold period. This requires Tip to be true.
+	TipHoldSeconds *uint64 `json:"tip_hold_seconds,omitempty"`
+
 	// Duration configures the syncer to stop after running
 	// for Duration seconds.
 	Duration *uint64 `json:"duration,omitempty"`
@@ -198,6 +470,39 @@ type DataEndConditions struct {
 	// to when tip was first reached. The range of inputs
 	// for this condition are [0.0, 1.0].
 	ReconciliationCoverage *float64 `json:"reconciliation_coverage,omitempty"`
+
+	// ExpectedHaltIndex configures the syncer to stop once it
+	// has synced the block at ExpectedHaltIndex and the tip of
+	// the node has remained at ExpectedHaltIndex for
+	// HaltConfirmationPeriod seconds (i.e. the chain halted as
+	// planned). If a block after ExpectedHaltIndex is synced or
+	// reported as the tip, check:data exits with an error.
+	ExpectedHaltIndex *int64 `json:"expected_halt_index,omitempty"`
+
+	// HaltConfirmationPeriod is the number of seconds the tip must
+	// remain at ExpectedHaltIndex before the chain is considered
+	// halted. If this is not populated, DefaultHaltConfirmationPeriod
+	// is used.
+	HaltConfirmationPeriod *uint64 `json:"halt_confirmation_period,omitempty"`
+
+	// TipLagBlocks configures the syncer to stop once the last
+	// synced block has been more than TipLagBlocks behind the
+	// tip of the node for TipLagPeriod seconds. This is useful
+	// when running check:data as a long-running monitor.
+	TipLagBlocks *int64 `json:"tip_lag_blocks,omitempty"`
+
+	// TipLagPeriod is the number of seconds the syncer must
+	// remain more than TipLagBlocks behind the tip before
+	// stopping. If this is not populated, DefaultTipLagPeriod
+	// is used.
+	TipLagPeriod *uint64 `json:"tip_lag_period,omitempty"`
+
+	// MaxReconciliationFailures configures check:data to log
+	// reconciliation failures instead of exiting on the first
+	// one, and to stop once MaxReconciliationFailures failures
+	// have occurred. Tolerated failures still fail the
+	// reconciliation test.
+	MaxReconciliationFailures *int64 `json:"max_reconciliation_failures,omitempty"`
 }
 
 // DataConfiguration contains all configurations to run check:data.
@@ -214,6 +519,12 @@ type DataConfiguration struct {
 	// inactive reconiliations on each account.
 	InactiveReconciliationFrequency uint64 `json:"inactive_reconciliation_frequency"`
 
+	// InactiveReconciliationSeed seeds the order in which previously
+	// seen accounts are sampled by inactive reconciliation, so that a
+	// run can be replayed exactly (it is recorded in the results). If
+	// it is not populated, previously seen accounts are not shuffled.
+	InactiveReconciliationSeed int64 `json:"inactive_reconciliation_seed,omitempty"`
+
 	// LogBlocks is a boolean indicating whether to log processed blocks.
 	LogBlocks bool `json:"log_blocks"`
 
@@ -231,6 +542,22 @@ type DataConfiguration struct {
 	// reconciliation errors during development.
 	IgnoreReconciliationError bool `json:"ignore_reconciliation_error"`
 
+	// ReconciliationFailFast determines if check:data should exit as soon as the
+	// first reconciliation error is found (with the offending account) instead of
+	// first searching for the block missing operations. This is ignored if
+	// IgnoreReconciliationError is true and cannot be used with the
+	// MaxReconciliationFailures end condition.
+	ReconciliationFailFast bool `json:"reconciliation_fail_fast,omitempty"`
+
+	// ErrorPolicy maps error categories to the action taken when an error
+	// of the category occurs once check:data has reached tip (before tip,
+	// every error aborts the run). Categories that are not populated abort.
+	// Errors that stop syncing (fetch, assertion, storage, and
+	// negative_balance) are continued by resuming syncing from the last
+	// synced block, so an error caused by a block (ex: a malformed block)
+	// recurs until it is fixed. Reconciliation errors cannot be retried.
+	ErrorPolicy map[ErrorCategory]ErrorAction `json:"error_policy,omitempty"`
+
 	// ExemptAccounts is a path to a file listing all accounts to exempt from balance
 	// tracking and reconciliation. Look at the examples directory for an example of
 	// how to structure this file.
@@ -285,17 +612,427 @@ type DataConfiguration struct {
 	// StatusPort allows the caller to query a running check:data
 	// test to get stats about progress. This can be used instead
 	// of parsing logs to populate some sort of status dashboard.
+	// The status is served at /data/status and /healthz returns 200
+	// until the test fails with a fatal error (and 503 after).
 	StatusPort uint `json:"status_port,omitempty"`
 
+	// MetricsPort is the port to serve check:data stats and
+	// progress on in the Prometheus text exposition format
+	// (labeled with the blockchain and network). Metrics are
+	// updated each time stats are logged to the terminal. If
+	// MetricsPort is not populated, no metrics are served.
+	MetricsPort uint `json:"metrics_port,omitempty"`
+
 	// ResultsOutputFile is the absolute filepath of where to save
 	// the results of a check:data run.
 	ResultsOutputFile string `json:"results_output_file"`
 
+	// ResultsOutputFiles are additional destinations to save the
+	// results of a check:data run to (in ResultsOutputFormat). Each
+	// destination is either a filepath or a URL with a scheme that
+	// has a results sink (ex: s3://bucket/key). Unlike
+	// ResultsOutputFile, partial results are not saved to these
+	// destinations while check:data runs.
+	ResultsOutputFiles []string `json:"results_output_files,omitempty"`
+
+	// ResultsSinkTimeout is the number of seconds to wait for results
+	// to be saved to each of the ResultsOutputFiles that is not a
+	// filepath (ex: the upload to s3://bucket/key) before giving up.
+	// If ResultsSinkTimeout is not populated, DefaultResultsSinkTimeout
+	// is used.
+	ResultsSinkTimeout uint64 `json:"results_sink_timeout,omitempty"`
+
+	// ResultsOutputCSV is the absolute filepath of where to save the
+	// tests and stats of a check:data run as a CSV with a header and
+	// a single row (useful for collecting the results of many runs in
+	// a spreadsheet). Columns are only ever added to the end of the
+	// row, so the rows of different versions can be combined.
+	ResultsOutputCSV string `json:"results_output_csv,omitempty"`
+
+	// ResultsWebhookURL is a URL the results of a check:data run are
+	// POSTed to (as JSON, with the network, rosetta-cli version, and
+	// hostname of the run) when check:data exits. Failed requests are
+	// retried with exponential backoff. If ResultsWebhookURL is not
+	// populated, results are not POSTed.
+	ResultsWebhookURL string `json:"results_webhook_url,omitempty"`
+
+	// ResultsWebhookToken is sent as a bearer token in the
+	// Authorization header of requests to ResultsWebhookURL.
+	ResultsWebhookToken string `json:"results_webhook_token,omitempty"`
+
+	// ResultsWebhookTimeout is the number of seconds to wait for
+	// each request to ResultsWebhookURL. If ResultsWebhookTimeout
+	// is not populated, DefaultResultsWebhookTimeout is used.
+	ResultsWebhookTimeout uint64 `json:"results_webhook_timeout,omitempty"`
+
+	// ResultsWebhookRequired causes check:data to fail if its results
+	// cannot be POSTed to ResultsWebhookURL. Otherwise, the error is
+	// only logged.
+	ResultsWebhookRequired bool `json:"results_webhook_required,omitempty"`
+
+	// CompletionWebhook is a URL the results and summary of a
+	// check:data run are POSTed to when it exits (whether it
+	// succeeded or failed). Unlike ResultsWebhookURL, the request
+	// is not retried and the payload includes a text field, so it
+	// can be a Slack incoming webhook. If the webhook is unreachable,
+	// the error is only logged.
+	CompletionWebhook string `json:"completion_webhook,omitempty"`
+
+	// CompletionWebhookTimeout is the number of seconds to wait for
+	// the request to CompletionWebhook. If CompletionWebhookTimeout
+	// is not populated, DefaultCompletionWebhookTimeout is used.
+	CompletionWebhookTimeout uint64 `json:"completion_webhook_timeout,omitempty"`
+
+	// ResultsSnapshotInterval is the frequency (in seconds) to append
+	// a timestamped snapshot of the stats and tests of a check:data run
+	// to ResultsSnapshotFile. This provides a point-in-time record of
+	// long runs (instead of only the results at exit). If
+	// ResultsSnapshotInterval is not populated, no snapshots are saved.
+	ResultsSnapshotInterval uint64 `json:"results_snapshot_interval,omitempty"`
+
+	// ResultsSnapshotFile is the absolute filepath of the JSON document
+	// that snapshots are appended to (in its snapshots array). It must
+	// be populated if ResultsSnapshotInterval is populated.
+	ResultsSnapshotFile string `json:"results_snapshot_file,omitempty"`
+
+	// ResultsOutputFormat is the format used to save the results
+	// of a check:data run ("json", "junit", or "ndjson").
+	ResultsOutputFormat ResultsOutputFormat `json:"results_output_format"`
+
+	// FailOnOutputError is a boolean that indicates if a check:data
+	// run fails when its results cannot be saved (even if the run
+	// otherwise succeeded). If it is not populated, it is true.
+	FailOnOutputError *bool `json:"fail_on_output_error,omitempty"`
+
+	// LogResultsTo is the filepath of a log file to append the
+	// results tables of a check:data run to (without colors), in
+	// addition to printing them to the console. This is useful
+	// when stdout is used for machine-readable output (ex: ndjson
+	// results). If it is not populated, the tables are only
+	// printed to the console.
+	LogResultsTo string `json:"log_results_to,omitempty"`
+
+	// ResultsHistoryFile is the filepath of a file to append the
+	// results of each check:data run to (as a single line of JSON
+	// with the iteration and a timestamp). When running with
+	// --watch, this is the rolling history of all iterations. If
+	// it is not populated, no history is kept.
+	ResultsHistoryFile string `json:"results_history_file,omitempty"`
+
+	// Quiet is a boolean indicating if check:data should only write
+	// its results to stdout (as a single JSON document). Periodic
+	// stats, colored messages, and the results tables are not
+	// printed and errors are still written to stderr. Results are
+	// still saved to ResultsOutputFile (if populated).
+	Quiet bool `json:"quiet,omitempty"`
+
+	// ProgressBar is a boolean indicating if progress should be
+	// rendered as a progress bar (with the percent completed, sync
+	// rate, and time remaining) that is updated in place. If the
+	// console is not a terminal, progress is still logged line
+	// by line.
+	ProgressBar bool `json:"progress_bar,omitempty"`
+
 	// PruningDisabled is a bolean that indicates storage pruning should
 	// not be attempted. This should really only ever be set to true if you
 	// wish to use `start_index` at a later point to restart from some
 	// previously synced block.
 	PruningDisabled bool `json:"pruning_disabled"`
+
+	// BlockResultsFile is the absolute filepath of where to stream
+	// per-block results (as JSON Lines) during a check:data run. If
+	// this is not populated, no per-block results are written.
+	BlockResultsFile string `json:"block_results_file"`
+
+	// BlockResultsFlushInterval is the number of seconds to wait
+	// between flushes of buffered per-block results to BlockResultsFile.
+	BlockResultsFlushInterval uint64 `json:"block_results_flush_interval,omitempty"`
+
+	// ResultsDatabaseFile is the absolute filepath of a SQLite database
+	// where run metadata, periodic progress samples, reconciliation
+	// failures, and final results are recorded (in the runs,
+	// progress_samples, and reconciliation_failures tables). If this is
+	// not populated (or the database can't be opened), nothing is recorded.
+	// The results database is only supported in builds with the sqlite
+	// build tag.
+	ResultsDatabaseFile string `json:"results_database_file"`
+
+	// AssertionSoftFail is a boolean indicating if transactions that fail
+	// response assertion should be cataloged and skipped instead of halting
+	// check:data. Balance changes in skipped transactions are not applied
+	// and any accounts they touch are not reconciled. When first testing an
+	// implementation, this can be used to collect all assertion errors
+	// in a single run.
+	AssertionSoftFail bool `json:"assertion_soft_fail"`
+
+	// AssertionSoftFailLimit is the maximum number of assertion findings
+	// to record when AssertionSoftFail is enabled. Findings beyond this
+	// limit are still counted by rule.
+	AssertionSoftFailLimit int `json:"assertion_soft_fail_limit,omitempty"`
+
+	// NegativeRequestDisabled is a boolean indicating if we should skip
+	// requesting invalid blocks at startup to check that the implementation
+	// rejects them with a Rosetta error.
+	NegativeRequestDisabled bool `json:"negative_request_disabled"`
+
+	// NegativeRequestProbes are the invalid block identifiers to request at
+	// startup. If no probes are provided, we request a block far beyond
+	// the current tip and a block with a hash that cannot exist.
+	NegativeRequestProbes []*types.PartialBlockIdentifier `json:"negative_request_probes,omitempty"`
+
+	// OrphanedOperationsExcluded is a boolean indicating if transactions
+	// and operations in orphaned blocks should be excluded from the
+	// transaction and operation totals in check:data stats. Transactions
+	// and operations in orphaned blocks are always counted separately.
+	OrphanedOperationsExcluded bool `json:"orphaned_operations_excluded"`
+
+	// CacheControlDisabled is a boolean indicating if we should not add
+	// a "Cache-Control: no-cache" header to all requests. This header
+	// prevents intermediate proxies from serving stale responses.
+	CacheControlDisabled bool `json:"cache_control_disabled"`
+
+	// CacheProbeInterval is the number of seconds between cache probes.
+	// Each probe requests the balance of a recently reconciled account
+	// with and without cache-busting and reports any response that was
+	// staler than the cache-busting response. If CacheProbeInterval is
+	// not populated, no probes are performed.
+	CacheProbeInterval uint64 `json:"cache_probe_interval,omitempty"`
+
+	// ConsistencyProbeInterval is the number of seconds between consistency
+	// probes. Each probe picks an already synced block and makes
+	// ConsistencyProbeRequests identical /block requests in parallel (and
+	// then identical /account/balance requests for an account in that block)
+	// and reports any responses that differ, which indicates a race in the
+	// implementation. Probe requests are made with the same fetcher as all
+	// other requests, so they count against max_online_connections. If
+	// ConsistencyProbeInterval is not populated, no probes are performed.
+	ConsistencyProbeInterval uint64 `json:"consistency_probe_interval,omitempty"`
+
+	// ConsistencyProbeRequests is the number of identical requests made in
+	// parallel by each consistency probe. If this is not populated,
+	// DefaultConsistencyProbeRequests is used.
+	ConsistencyProbeRequests int `json:"consistency_probe_requests,omitempty"`
+
+	// HistoricalBalanceCheckInterval is the number of seconds between
+	// historical balance checks. A uniform sample of the balance changes
+	// in processed blocks is kept (with the balance computed at each
+	// block) and each check compares a random sample with the balance
+	// returned by /account/balance at its block. Checks are only
+	// performed if historical balance lookup is supported. If
+	// HistoricalBalanceCheckInterval is not populated, no checks
+	// are performed.
+	HistoricalBalanceCheckInterval uint64 `json:"historical_balance_check_interval,omitempty"`
+
+	// SecondaryOnlineURL is the URL of another Rosetta API implementation
+	// for the same network (ex: a node running a newer version). If
+	// populated, each live balance fetched during reconciliation is also
+	// fetched from SecondaryOnlineURL and any disagreement between the
+	// two endpoints is reported separately from reconciliation failures.
+	// Failures fetching from SecondaryOnlineURL never fail check:data.
+	SecondaryOnlineURL string `json:"secondary_online_url,omitempty"`
+
+	// IncludeConfiguration is a boolean indicating if a sanitized copy
+	// of the configuration (with all private keys and credentials
+	// redacted) should be included in the check:data results.
+	IncludeConfiguration bool `json:"include_configuration"`
+
+	// AccountTagsFile is a path to a file that tags accounts (ex: exchange,
+	// contract, validator) so that reconciliation outcomes are reported for
+	// each tag. Each line contains an address (or an address prefix ending
+	// with "*") and a tag separated by whitespace. Accounts that don't match
+	// any entry are tagged "untagged".
+	AccountTagsFile string `json:"account_tags_file,omitempty"`
+
+	// ReconciliationCoverageMinIndex is the minimum block index an account
+	// must be reconciled at to count towards the reconciliation coverage
+	// reported in check:data stats. If the reconciliation coverage end
+	// condition is used, the larger of this index and the index where
+	// tip was first reached is used. If ReconciliationCoverageMinIndex
+	// is not populated, reconciliations at any index are counted.
+	ReconciliationCoverageMinIndex int64 `json:"reconciliation_coverage_min_index,omitempty"`
+
+	// CoverageLookbackBlocks is the number of blocks before the head
+	// block an account must be reconciled in to count towards the
+	// recent reconciliation coverage reported in check:data stats. If
+	// CoverageLookbackBlocks is populated, the reconciliation coverage
+	// end condition also only counts these reconciliations (so an
+	// account reconciled long ago in a reused data directory does not
+	// count as covered).
+	CoverageLookbackBlocks int64 `json:"coverage_lookback_blocks,omitempty"`
+
+	// SyncRateWindow is the number of seconds of syncing used to
+	// compute the recent sync rate (and the time remaining to sync
+	// to tip) reported in check:data progress. Until check:data has
+	// synced for this long, the average rate since the start of the
+	// run is used.
+	SyncRateWindow uint64 `json:"sync_rate_window,omitempty"`
+
+	// OrphanRateWindow is the number of most recently synced indices
+	// used to compute the orphan rate (orphans per 1000 blocks)
+	// reported in check:data stats. If OrphanRateWindow is not
+	// populated, DefaultOrphanRateWindow is used.
+	OrphanRateWindow int64 `json:"orphan_rate_window,omitempty"`
+
+	// OrphanRateThreshold is the orphan rate (orphans per 1000 blocks
+	// over OrphanRateWindow) above which a warning is printed with
+	// the check:data results. If OrphanRateThreshold is not populated,
+	// no warning is printed.
+	OrphanRateThreshold float64 `json:"orphan_rate_threshold,omitempty"`
+
+	// ReconciliationQueueDepthThreshold is the number of balance
+	// changes waiting to be reconciled above which a warning is
+	// logged with the check:data progress (indicating that
+	// reconciliation can't keep up with syncing). If
+	// ReconciliationQueueDepthThreshold is not populated, no
+	// warning is logged.
+	ReconciliationQueueDepthThreshold int64 `json:"reconciliation_queue_depth_threshold,omitempty"`
+
+	// ReconciliationSkipRatioThreshold is the fraction of balance
+	// changes that were never reconciled (because the reconciler's
+	// backlog was full or the block of the live balance was pruned)
+	// above which a warning is logged with the check:data stats and
+	// printed with the results. Skipped reconciliations quietly inflate
+	// the confidence placed in the reconciliation coverage. If this is
+	// not populated, DefaultReconciliationSkipRatioThreshold is used.
+	ReconciliationSkipRatioThreshold float64 `json:"reconciliation_skip_ratio_threshold,omitempty"`
+
+	// ReconciliationFailureLimit is the maximum number of reconciliation
+	// failures (with the account, currency, computed and live balances,
+	// and block) to record in the check:data results. Failures beyond
+	// this limit are still counted in the stats.
+	ReconciliationFailureLimit int `json:"reconciliation_failure_limit,omitempty"`
+
+	// TrustedCheckpoint is a block trusted to be canonical. Blocks at or
+	// below its index are synced (and their balance changes applied)
+	// without asserting them, the block at its index must have its hash
+	// (or check:data fails), and blocks after it are fully checked. This
+	// speeds up syncing long chains at the cost of only checking the
+	// responses of blocks after the checkpoint.
+	TrustedCheckpoint *TrustedCheckpoint `json:"trusted_checkpoint,omitempty"`
+
+	// Events configures where notable events of a check:data run
+	// (ex: a reconciliation failure) are sent. If Events is not
+	// populated, no events are sent.
+	Events *EventsConfiguration `json:"events,omitempty"`
+
+	// MaxBlockPayloadBytes is the maximum size (in bytes, after any
+	// gzip decompression) of a /block response. Each larger response
+	// is recorded as a violation in the check:data results. If
+	// MaxBlockPayloadBytes is not populated, there is no maximum.
+	MaxBlockPayloadBytes int64 `json:"max_block_payload_bytes,omitempty"`
+
+	// FailOnBlockPayloadViolation causes check:data to fail if any
+	// /block response is larger than MaxBlockPayloadBytes.
+	FailOnBlockPayloadViolation bool `json:"fail_on_block_payload_violation,omitempty"`
+
+	// Currencies restricts balance tracking and reconciliation to
+	// balances in these currencies. Operations in other currencies
+	// are still synced and asserted but their balance changes are
+	// not tracked (or reconciled). This is useful on networks with
+	// many currencies (ex: tokens) where only a few are of interest.
+	// If Currencies is not populated, all currencies are tracked.
+	Currencies []*types.Currency `json:"currencies,omitempty"`
+
+	// FeeOperationTypes are the operation types that pay fees on the
+	// network. This is the only place fee operations are configured,
+	// so any check that treats fees differently should use this list.
+	// If FeeOperationTypes is populated, the amounts debited by these
+	// operations are summed per block and currency and reported in the
+	// check:data results (along with the number of blocks with
+	// transactions but no fees).
+	FeeOperationTypes []string `json:"fee_operation_types,omitempty"`
+
+	// TransferSymmetry configures a check that the debits and credits
+	// in each transaction are balanced. If TransferSymmetry is not
+	// populated, the check is not performed.
+	TransferSymmetry *TransferSymmetryConfiguration `json:"transfer_symmetry,omitempty"`
+
+	// TransferPairsEnabled determines if the sender and recipient
+	// pairs of transfers are sketched and reported in the check:data
+	// results. This is disabled by default because every transfer
+	// updates the sketch stored with the rest of the data.
+	TransferPairsEnabled bool `json:"transfer_pairs_enabled,omitempty"`
+}
+
+// EventsConfiguration configures the event sinks of a
+// check:data run. Each event is sent to every sink that
+// accepts its type.
+type EventsConfiguration struct {
+	Sinks []*EventSinkConfiguration `json:"sinks"`
+
+	// DegradedPeriod is the number of seconds syncing must make
+	// no progress (while behind tip) before a DegradedPeriodEvent
+	// is sent.
+	DegradedPeriod uint64 `json:"degraded_period,omitempty"`
+
+	// ReorgDepth is the number of blocks a reorg must remove
+	// before a ReorgBeyondDepthEvent is sent.
+	ReorgDepth int64 `json:"reorg_depth,omitempty"`
+}
+
+// EventSinkConfiguration configures a single event sink.
+// Events are sent to each sink asynchronously, so a slow
+// or broken sink never delays the run or the other sinks.
+type EventSinkConfiguration struct {
+	// Name identifies the sink in the check:data results. If
+	// Name is not populated, the sink type and its position
+	// in Sinks are used (ex: "webhook-0").
+	Name string        `json:"name,omitempty"`
+	Type EventSinkType `json:"type"`
+
+	// URL and Headers are used by webhook sinks. Template is a
+	// Go text/template executed with the event to create the
+	// request body (ex: a Slack message). If Template is not
+	// populated, the event is sent as JSON.
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Template string            `json:"template,omitempty"`
+
+	// Command is the command (and its arguments)
+	// run by exec sinks.
+	Command []string `json:"command,omitempty"`
+
+	// Path is the file appended to by file sinks.
+	Path string `json:"path,omitempty"`
+
+	// Timeout is the number of seconds to wait for an
+	// event to be delivered before it is considered failed.
+	Timeout uint64 `json:"timeout,omitempty"`
+
+	// Events are the types of events sent to the sink. If
+	// Events is not populated, all events are sent.
+	Events []EventType `json:"events,omitempty"`
+}
+
+// TransferSymmetryConfiguration configures the check that the
+// sum of the debits in each transaction equals the sum of its
+// credits (per currency). This catches implementations that
+// credit a recipient without debiting the sender (or vice versa),
+// which reconciliation may take a long time to find if only one
+// of the accounts is reconciled. Operations of the FeeOperationTypes
+// are never checked.
+type TransferSymmetryConfiguration struct {
+	// OperationTypes are the operation types whose debits and
+	// credits must be balanced. If OperationTypes is empty, all
+	// operation types are checked.
+	OperationTypes []string `json:"operation_types,omitempty"`
+
+	// ExcludedOperationTypes are operation types that are never
+	// checked because they are legitimately unbalanced (ex: mint
+	// and burn operations).
+	ExcludedOperationTypes []string `json:"excluded_operation_types,omitempty"`
+
+	// Fatal causes check:data to exit on the first unbalanced
+	// transaction (failing the transfer symmetry test). Otherwise,
+	// unbalanced transactions are only recorded in the results.
+	Fatal bool `json:"fatal,omitempty"`
+}
+
+// TrustedCheckpoint is the index and hash of a block
+// that is trusted to be canonical.
+type TrustedCheckpoint struct {
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
 }
 
 // Configuration contains all configuration settings for running
@@ -339,6 +1076,18 @@ type Configuration struct {
 	// should be printed to the console when a file is loaded.
 	LogConfiguration bool `json:"log_configuration"`
 
+	// PlainOutput disables colored output and prints tables in a
+	// plain ASCII style (without borders or row separators). This
+	// is the same as providing --no-color or setting NO_COLOR.
+	PlainOutput bool `json:"plain_output,omitempty"`
+
+	// ResultsColumns are the columns printed in the check:data
+	// stats and tests tables (ex: ["name", "value", "status"]
+	// to omit the descriptions). The name column is always
+	// printed and all columns are printed if this is empty.
+	// This is overridden by --results-columns.
+	ResultsColumns []ResultsColumn `json:"results_columns,omitempty"`
+
 	Construction *ConstructionConfiguration `json:"construction"`
 	Data         *DataConfiguration         `json:"data"`
 }
@@ -374,6 +1123,19 @@ func populateConstructionMissingFields(
 		constructionConfig.StatusPort = DefaultStatusPort
 	}
 
+	if len(constructionConfig.ResultsOutputFormat) == 0 {
+		constructionConfig.ResultsOutputFormat = DefaultResultsOutputFormat
+	}
+
+	if constructionConfig.MempoolVisibilityTimeout == 0 {
+		constructionConfig.MempoolVisibilityTimeout = DefaultMempoolVisibilityTimeout
+	}
+
+	if len(constructionConfig.CompletionWebhook) > 0 &&
+		constructionConfig.CompletionWebhookTimeout == 0 {
+		constructionConfig.CompletionWebhookTimeout = DefaultCompletionWebhookTimeout
+	}
+
 	return constructionConfig
 }
 
@@ -398,6 +1160,66 @@ func populateDataMissingFields(dataConfig *DataConfiguration) *DataConfiguration
 		dataConfig.StatusPort = DefaultStatusPort
 	}
 
+	if dataConfig.BlockResultsFlushInterval == 0 {
+		dataConfig.BlockResultsFlushInterval = DefaultBlockResultsFlushInterval
+	}
+
+	if dataConfig.AssertionSoftFailLimit == 0 {
+		dataConfig.AssertionSoftFailLimit = DefaultAssertionSoftFailLimit
+	}
+
+	if dataConfig.ReconciliationFailureLimit == 0 {
+		dataConfig.ReconciliationFailureLimit = DefaultReconciliationFailureLimit
+	}
+
+	if len(dataConfig.ResultsOutputFormat) == 0 {
+		dataConfig.ResultsOutputFormat = DefaultResultsOutputFormat
+	}
+
+	if dataConfig.SyncRateWindow == 0 {
+		dataConfig.SyncRateWindow = DefaultSyncRateWindow
+	}
+
+	if dataConfig.OrphanRateWindow == 0 {
+		dataConfig.OrphanRateWindow = DefaultOrphanRateWindow
+	}
+
+	if len(dataConfig.ResultsWebhookURL) > 0 && dataConfig.ResultsWebhookTimeout == 0 {
+		dataConfig.ResultsWebhookTimeout = DefaultResultsWebhookTimeout
+	}
+
+	if len(dataConfig.CompletionWebhook) > 0 && dataConfig.CompletionWebhookTimeout == 0 {
+		dataConfig.CompletionWebhookTimeout = DefaultCompletionWebhookTimeout
+	}
+
+	if len(dataConfig.ResultsOutputFiles) > 0 && dataConfig.ResultsSinkTimeout == 0 {
+		dataConfig.ResultsSinkTimeout = DefaultResultsSinkTimeout
+	}
+
+	if dataConfig.Events != nil {
+		if dataConfig.Events.DegradedPeriod == 0 {
+			dataConfig.Events.DegradedPeriod = DefaultDegradedPeriod
+		}
+
+		if dataConfig.Events.ReorgDepth == 0 {
+			dataConfig.Events.ReorgDepth = DefaultReorgEventDepth
+		}
+
+		for i, sink := range dataConfig.Events.Sinks {
+			if sink == nil {
+				continue
+			}
+
+			if len(sink.Name) == 0 {
+				sink.Name = fmt.Sprintf("%s-%d", sink.Type, i)
+			}
+
+			if sink.Timeout == 0 {
+				sink.Timeout = DefaultEventSinkTimeout
+			}
+		}
+	}
+
 	return dataConfig
 }
 
@@ -440,11 +1262,122 @@ func populateMissingFields(config *Configuration) *Configuration {
 	return config
 }
 
+// AssertResultsOutputFormat returns an error if
+// format is not a supported ResultsOutputFormat.
+func AssertResultsOutputFormat(format ResultsOutputFormat) error {
+	switch format {
+	case JSONResultsOutputFormat, JUnitResultsOutputFormat, NDJSONResultsOutputFormat:
+		return nil
+	default:
+		return fmt.Errorf("results output format %s is not supported", format)
+	}
+}
+
+// assertTransferSymmetryConfiguration returns an error if an
+// operation type is empty or both checked and excluded.
+func assertTransferSymmetryConfiguration(config *TransferSymmetryConfiguration) error {
+	if config == nil {
+		return nil
+	}
+
+	checked := map[string]struct{}{}
+	for _, operationType := range config.OperationTypes {
+		if len(operationType) == 0 {
+			return errors.New("operation types cannot be empty")
+		}
+
+		checked[operationType] = struct{}{}
+	}
+
+	for _, operationType := range config.ExcludedOperationTypes {
+		if len(operationType) == 0 {
+			return errors.New("excluded operation types cannot be empty")
+		}
+
+		if _, ok := checked[operationType]; ok {
+			return fmt.Errorf("operation type %s cannot be checked and excluded", operationType)
+		}
+	}
+
+	return nil
+}
+
+// AssertResultsColumns returns an error if columns
+// contains an unsupported ResultsColumn.
+func AssertResultsColumns(columns []ResultsColumn) error {
+	for _, column := range columns {
+		supported := false
+		for _, resultsColumn := range ResultsColumns {
+			if column == resultsColumn {
+				supported = true
+				break
+			}
+		}
+
+		if !supported {
+			return fmt.Errorf("results column %s is not supported", column)
+		}
+	}
+
+	return nil
+}
+
+// AssertErrorPolicy returns an error if policy
+// contains an unsupported category or action.
+func AssertErrorPolicy(policy map[ErrorCategory]ErrorAction) error {
+	for category, action := range policy {
+		supported := false
+		for _, errorCategory := range ErrorCategories {
+			if category == errorCategory {
+				supported = true
+				break
+			}
+		}
+
+		if !supported {
+			return fmt.Errorf("error policy category %s is not supported", category)
+		}
+
+		switch action {
+		case AbortErrorAction, RecordErrorAction:
+		case RetryErrorAction:
+			if category == ReconciliationErrorCategory {
+				return fmt.Errorf(
+					"error policy action %s is not supported for %s errors",
+					action,
+					category,
+				)
+			}
+		default:
+			return fmt.Errorf("error policy action %s is not supported", action)
+		}
+	}
+
+	return nil
+}
+
 func assertConstructionConfiguration(config *ConstructionConfiguration) error {
 	if config == nil {
 		return nil
 	}
 
+	if err := AssertResultsOutputFormat(config.ResultsOutputFormat); err != nil {
+		return err
+	}
+
+	if len(config.CompletionWebhook) > 0 {
+		if _, err := url.ParseRequestURI(config.CompletionWebhook); err != nil {
+			return fmt.Errorf("%w: invalid completion webhook url", err)
+		}
+	}
+
+	if config.HashMismatchSearchDepth < 0 {
+		return fmt.Errorf(
+			"hash mismatch search depth %d must be non-negative",
+			config.HashMismatchSearchDepth,
+		)
+	}
+
 	seenCreateAccount := false
 	seenRequestFunds := false
 	for _, workflow := range config.Workflows {
@@ -508,6 +1441,147 @@ func assertDataConfiguration(config *DataConfiguration) error {
 		return fmt.Errorf("start index %d cannot be negative", *config.StartIndex)
 	}
 
+	if err := AssertResultsOutputFormat(config.ResultsOutputFormat); err != nil {
+		return err
+	}
+
+	if err := AssertErrorPolicy(config.ErrorPolicy); err != nil {
+		return err
+	}
+
+	// Partial results are saved next to ResultsOutputFile,
+	// so it must be a filepath.
+	if strings.Contains(config.ResultsOutputFile, "://") {
+		return fmt.Errorf(
+			"results output file %s must be a filepath (use results_output_files for other destinations)",
+			config.ResultsOutputFile,
+		)
+	}
+
+	for _, destination := range config.ResultsOutputFiles {
+		if len(destination) == 0 {
+			return errors.New("results output files cannot be empty")
+		}
+	}
+
+	if len(config.ResultsWebhookURL) > 0 {
+		if _, err := url.ParseRequestURI(config.ResultsWebhookURL); err != nil {
+			return fmt.Errorf("%w: invalid results webhook url", err)
+		}
+	}
+
+	if config.ResultsWebhookRequired && len(config.ResultsWebhookURL) == 0 {
+		return errors.New("results webhook url must be populated if the results webhook is required")
+	}
+
+	if len(config.CompletionWebhook) > 0 {
+		if _, err := url.ParseRequestURI(config.CompletionWebhook); err != nil {
+			return fmt.Errorf("%w: invalid completion webhook url", err)
+		}
+	}
+
+	if config.ResultsSnapshotInterval > 0 && len(config.ResultsSnapshotFile) == 0 {
+		return errors.New("results snapshot file must be populated to save snapshots")
+	}
+
+	if config.CoverageLookbackBlocks < 0 {
+		return fmt.Errorf(
+			"coverage lookback blocks %d cannot be negative",
+			config.CoverageLookbackBlocks,
+		)
+	}
+
+	if config.ReconciliationCoverageMinIndex < 0 {
+		return fmt.Errorf(
+			"reconciliation coverage min index %d cannot be negative",
+			config.ReconciliationCoverageMinIndex,
+		)
+	}
+
+	if config.AssertionSoftFailLimit < 0 {
+		return fmt.Errorf(
+			"assertion soft fail limit %d cannot be negative",
+			config.AssertionSoftFailLimit,
+		)
+	}
+
+	for _, currency := range config.Currencies {
+		if err := asserter.Currency(currency); err != nil {
+			return fmt.Errorf("%w: invalid currency", err)
+		}
+	}
+
+	if config.OrphanRateWindow < 0 {
+		return fmt.Errorf("orphan rate window %d cannot be negative", config.OrphanRateWindow)
+	}
+
+	if config.OrphanRateThreshold < 0 {
+		return fmt.Errorf(
+			"orphan rate threshold %f cannot be negative",
+			config.OrphanRateThreshold,
+		)
+	}
+
+	if config.ReconciliationQueueDepthThreshold < 0 {
+		return fmt.Errorf(
+			"reconciliation queue depth threshold %d cannot be negative",
+			config.ReconciliationQueueDepthThreshold,
+		)
+	}
+
+	if config.ReconciliationSkipRatioThreshold < 0 || config.ReconciliationSkipRatioThreshold > 1 {
+		return fmt.Errorf(
+			"reconciliation skip ratio threshold %f must be between 0 and 1",
+			config.ReconciliationSkipRatioThreshold,
+		)
+	}
+
+	if config.ReconciliationFailureLimit < 0 {
+		return fmt.Errorf(
+			"reconciliation failure limit %d cannot be negative",
+			config.ReconciliationFailureLimit,
+		)
+	}
+
+	if config.ConsistencyProbeRequests < 0 || config.ConsistencyProbeRequests == 1 {
+		return fmt.Errorf(
+			"consistency probe requests %d must be at least 2",
+			config.ConsistencyProbeRequests,
+		)
+	}
+
+	if config.MaxBlockPayloadBytes < 0 {
+		return fmt.Errorf(
+			"max block payload bytes %d cannot be negative",
+			config.MaxBlockPayloadBytes,
+		)
+	}
+
+	if config.FailOnBlockPayloadViolation && config.MaxBlockPayloadBytes == 0 {
+		return errors.New("fail on block payload violation requires max block payload bytes")
+	}
+
+	if config.TrustedCheckpoint != nil {
+		if config.TrustedCheckpoint.Index < 0 {
+			return fmt.Errorf(
+				"trusted checkpoint index %d cannot be negative",
+				config.TrustedCheckpoint.Index,
+			)
+		}
+
+		if len(config.TrustedCheckpoint.Hash) == 0 {
+			return errors.New("trusted checkpoint hash must be populated")
+		}
+	}
+
+	if err := assertEventsConfiguration(config.Events); err != nil {
+		return fmt.Errorf("%w: invalid events configuration", err)
+	}
+
+	if err := assertTransferSymmetryConfiguration(config.TransferSymmetry); err != nil {
+		return fmt.Errorf("%w: invalid transfer symmetry configuration", err)
+	}
+
 	if config.EndConditions == nil {
 		return nil
 	}
@@ -518,6 +1592,74 @@ func assertDataConfiguration(config *DataConfiguration) error {
 		}
 	}
 
+	if config.EndConditions.EndBlockIdentifier != nil {
+		if err := asserter.BlockIdentifier(config.EndConditions.EndBlockIdentifier); err != nil {
+			return fmt.Errorf("%w: invalid end block identifier", err)
+		}
+
+		if config.EndConditions.Index != nil {
+			return errors.New("end block identifier cannot be combined with the index end condition")
+		}
+	}
+
+	if config.EndConditions.ExpectedHaltIndex != nil {
+		if *config.EndConditions.ExpectedHaltIndex < 0 {
+			return fmt.Errorf(
+				"expected halt index %d cannot be negative",
+				*config.EndConditions.ExpectedHaltIndex,
+			)
+		}
+	}
+
+	if config.EndConditions.HaltConfirmationPeriod != nil &&
+		config.EndConditions.ExpectedHaltIndex == nil {
+		return errors.New("halt confirmation period requires an expected halt index")
+	}
+
+	if config.EndConditions.TipHoldSeconds != nil &&
+		(config.EndConditions.Tip == nil || !*config.EndConditions.Tip) {
+		return errors.New("tip hold seconds requires the tip end condition")
+	}
+
+	if config.EndConditions.TipLagBlocks != nil && *config.EndConditions.TipLagBlocks <= 0 {
+		return fmt.Errorf(
+			"tip lag blocks %d must be positive",
+			*config.EndConditions.TipLagBlocks,
+		)
+	}
+
+	if config.EndConditions.TipLagPeriod != nil &&
+		config.EndConditions.TipLagBlocks == nil {
+		return errors.New("tip lag period requires tip lag blocks")
+	}
+
+	if config.EndConditions.MaxReconciliationFailures != nil {
+		if *config.EndConditions.MaxReconciliationFailures <= 0 {
+			return fmt.Errorf(
+				"max reconciliation failures %d must be positive",
+				*config.EndConditions.MaxReconciliationFailures,
+			)
+		}
+
+		if config.IgnoreReconciliationError {
+			return errors.New(
+				"reconciliation errors cannot be ignored for max reconciliation failures end condition",
+			)
+		}
+
+		if config.ReconciliationFailFast {
+			return errors.New(
+				"reconciliation cannot fail fast with max reconciliation failures end condition",
+			)
+		}
+
+		if config.BalanceTrackingDisabled || config.ReconciliationDisabled {
+			return errors.New(
+				"reconciliation must be enabled for max reconciliation failures end condition",
+			)
+		}
+	}
+
 	if config.EndConditions.ReconciliationCoverage != nil {
 		coverage := *config.EndConditions.ReconciliationCoverage
 		if coverage < 0 || coverage > 1 {
@@ -551,6 +1693,10 @@ func assertConfiguration(config *Configuration) error {
 		return fmt.Errorf("%w: invalid network identifier", err)
 	}
 
+	if err := AssertResultsColumns(config.ResultsColumns); err != nil {
+		return err
+	}
+
 	if err := assertDataConfiguration(config.Data); err != nil {
 		return fmt.Errorf("%w: invalid data configuration", err)
 	}
@@ -587,3 +1733,155 @@ func LoadConfiguration(filePath string) (*Configuration, error) {
 
 	return config, nil
 }
+
+// assertEventsConfiguration returns an error if any
+// sink in config is not valid for its type.
+func assertEventsConfiguration(config *EventsConfiguration) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.ReorgDepth < 0 {
+		return fmt.Errorf("reorg depth %d cannot be negative", config.ReorgDepth)
+	}
+
+	names := map[string]struct{}{}
+	for _, sink := range config.Sinks {
+		if sink == nil {
+			return errors.New("event sink cannot be empty")
+		}
+
+		if _, ok := names[sink.Name]; ok {
+			return fmt.Errorf("duplicate event sink name %s", sink.Name)
+		}
+		names[sink.Name] = struct{}{}
+
+		switch sink.Type {
+		case WebhookEventSink:
+			if len(sink.URL) == 0 {
+				return fmt.Errorf("event sink %s must populate url", sink.Name)
+			}
+
+			if _, err := template.New(sink.Name).Parse(sink.Template); err != nil {
+				return fmt.Errorf("%w: invalid template for event sink %s", err, sink.Name)
+			}
+		case ExecEventSink:
+			if len(sink.Command) == 0 {
+				return fmt.Errorf("event sink %s must populate command", sink.Name)
+			}
+		case FileEventSink:
+			if len(sink.Path) == 0 {
+				return fmt.Errorf("event sink %s must populate path", sink.Name)
+			}
+		default:
+			return fmt.Errorf("event sink %s has unsupported type %s", sink.Name, sink.Type)
+		}
+
+		for _, eventType := range sink.Events {
+			if !validEventType(eventType) {
+				return fmt.Errorf("event sink %s has unsupported event %s", sink.Name, eventType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validEventType returns a boolean indicating
+// if eventType is one of EventTypes.
+func validEventType(eventType EventType) bool {
+	for _, supported := range EventTypes {
+		if eventType == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SanitizeConfiguration returns a deep copy of config with
+// the private keys of all prefunded accounts and any
+// credentials in URLs replaced by RedactedValue. The
+// provided config is not modified.
+func SanitizeConfiguration(config *Configuration) (*Configuration, error) {
+	// Copying through JSON ensures the copy shares no
+	// pointers (ex: prefunded accounts) with config.
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to encode configuration", err)
+	}
+
+	var sanitized Configuration
+	if err := json.Unmarshal(configJSON, &sanitized); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode configuration", err)
+	}
+
+	sanitized.OnlineURL = redactURL(sanitized.OnlineURL)
+
+	if sanitized.Construction != nil {
+		sanitized.Construction.OfflineURL = redactURL(sanitized.Construction.OfflineURL)
+		for _, account := range sanitized.Construction.PrefundedAccounts {
+			account.PrivateKeyHex = RedactedValue
+		}
+
+		if len(sanitized.Construction.CompletionWebhook) > 0 {
+			sanitized.Construction.CompletionWebhook = RedactedValue
+		}
+	}
+
+	if sanitized.Data != nil {
+		sanitized.Data.SecondaryOnlineURL = redactURL(sanitized.Data.SecondaryOnlineURL)
+		if len(sanitized.Data.ResultsWebhookURL) > 0 {
+			sanitized.Data.ResultsWebhookURL = RedactedValue
+		}
+
+		if len(sanitized.Data.ResultsWebhookToken) > 0 {
+			sanitized.Data.ResultsWebhookToken = RedactedValue
+		}
+
+		if len(sanitized.Data.CompletionWebhook) > 0 {
+			sanitized.Data.CompletionWebhook = RedactedValue
+		}
+
+		// Webhook URLs (ex: Slack incoming webhooks) and headers
+		// often contain credentials outside of the user information.
+		if sanitized.Data.Events != nil {
+			for _, sink := range sanitized.Data.Events.Sinks {
+				if sink == nil {
+					continue
+				}
+
+				if len(sink.URL) > 0 {
+					sink.URL = RedactedValue
+				}
+
+				for header := range sink.Headers {
+					sink.Headers[header] = RedactedValue
+				}
+			}
+		}
+	}
+
+	return &sanitized, nil
+}
+
+// redactURL replaces any user information (ex: basic auth
+// credentials) in rawURL with RedactedValue. If rawURL
+// cannot be parsed, RedactedValue is returned because it
+// could contain credentials we can't find.
+func redactURL(rawURL string) string {
+	if len(rawURL) == 0 {
+		return rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return RedactedValue
+	}
+
+	if parsed.User != nil {
+		parsed.User = url.User(RedactedValue)
+	}
+
+	return parsed.String()
+}
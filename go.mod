@@ -4,8 +4,10 @@ go 1.13
 
 require (
 	github.com/coinbase/rosetta-sdk-go v0.4.9
+	github.com/dgraph-io/badger/v2 v2.2007.2
 	github.com/fatih/color v1.9.0
 	github.com/jinzhu/copier v0.0.0-20190924061706-b57f9002281a
+	github.com/mattn/go-sqlite3 v1.14.4
 	github.com/olekukonko/tablewriter v0.0.2-0.20190409134802-7e037d187b0c
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5 // indirect
@@ -15,9 +15,11 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/coinbase/rosetta-cli/cmd"
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/fatih/color"
 )
@@ -25,7 +27,17 @@ import (
 func main() {
 	err := cmd.Execute()
 	if err != nil {
-		color.Red("Command Failed: %s", err.Error())
+		// Errors are always written to stderr (even
+		// if all other output is suppressed).
+		_, _ = color.New(color.FgRed).Fprintf(os.Stderr, "Command Failed: %s\n", err.Error())
+
+		// check:data exits with a different code
+		// for each test that can fail.
+		var exitErr *results.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,257 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// sinkQueueSize is the number of events that can wait
+	// to be delivered to a sink. Events emitted while the
+	// queue of a sink is full are dropped (for that sink).
+	sinkQueueSize = 100
+)
+
+// Event is a notable occurrence during a check:data run.
+type Event struct {
+	Type    configuration.EventType  `json:"type"`
+	Time    string                   `json:"time"`
+	Network *types.NetworkIdentifier `json:"network_identifier"`
+	Message string                   `json:"message"`
+	Fields  logging.Fields           `json:"fields,omitempty"`
+}
+
+// Sink delivers events (ex: to a webhook). Send is
+// never called concurrently for the same Sink.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// sinkRunner delivers the events queued for a
+// single Sink and counts the outcome of each.
+type sinkRunner struct {
+	config *configuration.EventSinkConfiguration
+	sink   Sink
+	queue  chan *Event
+
+	delivered int64
+	failed    int64
+	dropped   int64
+}
+
+// accepts returns a boolean indicating if
+// the sink should receive events of eventType.
+func (r *sinkRunner) accepts(eventType configuration.EventType) bool {
+	if len(r.config.Events) == 0 {
+		return true
+	}
+
+	for _, accepted := range r.config.Events {
+		if accepted == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run delivers queued events until the queue is closed.
+// Each delivery is bounded by the timeout of the sink.
+func (r *sinkRunner) run() {
+	timeout := time.Duration(r.config.Timeout) * time.Second
+	for event := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := r.sink.Send(ctx, event)
+		cancel()
+
+		if err != nil {
+			atomic.AddInt64(&r.failed, 1)
+			logging.Warn("unable to deliver event", logging.Fields{
+				"sink":  r.config.Name,
+				"event": event.Type,
+				"error": err,
+			})
+			continue
+		}
+
+		atomic.AddInt64(&r.delivered, 1)
+	}
+}
+
+// Dispatcher sends events to each configured sink. Each
+// sink has its own queue and goroutine, so a slow or
+// broken sink never delays the caller or the other sinks.
+// All methods may be called on a nil *Dispatcher (in which
+// case no events are sent).
+type Dispatcher struct {
+	network *types.NetworkIdentifier
+	runners []*sinkRunner
+
+	closed  bool
+	mutex   sync.RWMutex
+	running sync.WaitGroup
+}
+
+// NewDispatcher returns a new *Dispatcher that sends events
+// to the sinks in config (or nil if config is nil).
+func NewDispatcher(
+	config *configuration.EventsConfiguration,
+	network *types.NetworkIdentifier,
+) (*Dispatcher, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	sinks := make([]Sink, len(config.Sinks))
+	for i, sinkConfig := range config.Sinks {
+		sink, err := NewSink(sinkConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to create event sink %s", err, sinkConfig.Name)
+		}
+
+		sinks[i] = sink
+	}
+
+	return newDispatcher(config.Sinks, sinks, network), nil
+}
+
+// newDispatcher returns a new *Dispatcher that sends events
+// to sinks (configured by the corresponding entry in configs).
+func newDispatcher(
+	configs []*configuration.EventSinkConfiguration,
+	sinks []Sink,
+	network *types.NetworkIdentifier,
+) *Dispatcher {
+	d := &Dispatcher{network: network}
+	for i, sink := range sinks {
+		runner := &sinkRunner{
+			config: configs[i],
+			sink:   sink,
+			queue:  make(chan *Event, sinkQueueSize),
+		}
+		d.runners = append(d.runners, runner)
+
+		d.running.Add(1)
+		go func() {
+			defer d.running.Done()
+			runner.run()
+		}()
+	}
+
+	return d
+}
+
+// Emit queues an event of eventType for every sink that
+// accepts it. Emit never blocks: if the queue of a sink
+// is full, the event is dropped for that sink.
+func (d *Dispatcher) Emit(
+	eventType configuration.EventType,
+	message string,
+	fields logging.Fields,
+) {
+	if d == nil {
+		return
+	}
+
+	event := &Event{
+		Type:    eventType,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Network: d.network,
+		Message: message,
+		Fields:  fields,
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.closed {
+		return
+	}
+
+	for _, runner := range d.runners {
+		if !runner.accepts(eventType) {
+			continue
+		}
+
+		select {
+		case runner.queue <- event:
+		default:
+			atomic.AddInt64(&runner.dropped, 1)
+		}
+	}
+}
+
+// Close stops accepting events and waits for all queued
+// events to be delivered (or for ctx to be done).
+func (d *Dispatcher) Close(ctx context.Context) {
+	if d == nil {
+		return
+	}
+
+	d.mutex.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, runner := range d.runners {
+			close(runner.queue)
+		}
+	}
+	d.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logging.Warn("unable to deliver all events before closing", logging.Fields{
+			"error": ctx.Err(),
+		})
+	}
+}
+
+// Results returns the delivery counts of each
+// sink (or nil if there are no sinks).
+func (d *Dispatcher) Results() []*results.EventSinkResults {
+	if d == nil || len(d.runners) == 0 {
+		return nil
+	}
+
+	sinkResults := make([]*results.EventSinkResults, len(d.runners))
+	for i, runner := range d.runners {
+		sinkResults[i] = &results.EventSinkResults{
+			Name:      runner.config.Name,
+			Type:      string(runner.config.Type),
+			Delivered: atomic.LoadInt64(&runner.delivered),
+			Failed:    atomic.LoadInt64(&runner.failed),
+			Dropped:   atomic.LoadInt64(&runner.dropped),
+		}
+	}
+
+	return sinkResults
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNetwork = &types.NetworkIdentifier{
+	Blockchain: "bitcoin",
+	Network:    "mainnet",
+}
+
+// recordingSink records each event it receives and
+// fails if err is set.
+type recordingSink struct {
+	err    error
+	events []*Event
+	mutex  sync.Mutex
+}
+
+func (s *recordingSink) Send(ctx context.Context, event *Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *recordingSink) received() []*Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.events
+}
+
+// blockingSink blocks until its delivery times out.
+type blockingSink struct{}
+
+func (s *blockingSink) Send(ctx context.Context, event *Event) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestDispatcher(t *testing.T) {
+	all := &recordingSink{}
+	failures := &recordingSink{}
+	broken := &recordingSink{err: errors.New("broken")}
+	configs := []*configuration.EventSinkConfiguration{
+		{Name: "all", Type: configuration.FileEventSink, Timeout: 1},
+		{
+			Name:    "failures",
+			Type:    configuration.WebhookEventSink,
+			Timeout: 1,
+			Events:  []configuration.EventType{configuration.FatalErrorEvent},
+		},
+		{Name: "broken", Type: configuration.ExecEventSink, Timeout: 1},
+		{Name: "blocking", Type: configuration.ExecEventSink, Timeout: 1},
+	}
+	d := newDispatcher(configs, []Sink{all, failures, broken, &blockingSink{}}, testNetwork)
+
+	// The blocking sink never delays emitting (or the other sinks).
+	start := time.Now()
+	d.Emit(configuration.RunStartedEvent, "started", nil)
+	d.Emit(configuration.FatalErrorEvent, "failed", logging.Fields{"index": 10})
+	assert.True(t, time.Since(start) < time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	d.Close(ctx)
+
+	// Events emitted after closing are ignored.
+	d.Emit(configuration.RunStartedEvent, "ignored", nil)
+
+	assert.Len(t, all.received(), 2)
+	assert.Equal(t, configuration.RunStartedEvent, all.received()[0].Type)
+	assert.Equal(t, testNetwork, all.received()[0].Network)
+	assert.Len(t, failures.received(), 1)
+	assert.Equal(t, "failed", failures.received()[0].Message)
+	assert.Equal(t, logging.Fields{"index": 10}, failures.received()[0].Fields)
+
+	assert.Equal(t, []*results.EventSinkResults{
+		{Name: "all", Type: "file", Delivered: 2},
+		{Name: "failures", Type: "webhook", Delivered: 1},
+		{Name: "broken", Type: "exec", Failed: 2},
+		{Name: "blocking", Type: "exec", Failed: 2},
+	}, d.Results())
+}
+
+func TestDispatcherDropsEvents(t *testing.T) {
+	configs := []*configuration.EventSinkConfiguration{
+		{Name: "blocking", Type: configuration.ExecEventSink, Timeout: 1},
+	}
+	d := newDispatcher(configs, []Sink{&blockingSink{}}, testNetwork)
+
+	// One event is being delivered and sinkQueueSize are queued.
+	for i := 0; i < sinkQueueSize+10; i++ {
+		d.Emit(configuration.ReconciliationFailureEvent, "failed", nil)
+	}
+
+	// Close returns when ctx is done, even
+	// if events are still queued.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d.Close(ctx)
+
+	sinkResults := d.Results()
+	assert.Len(t, sinkResults, 1)
+	assert.True(t, sinkResults[0].Dropped >= 9)
+}
+
+func TestNilDispatcher(t *testing.T) {
+	d, err := NewDispatcher(nil, testNetwork)
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	d.Emit(configuration.RunStartedEvent, "started", nil)
+	d.Close(context.Background())
+	assert.Nil(t, d.Results())
+}
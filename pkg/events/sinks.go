@@ -0,0 +1,217 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"text/template"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+const (
+	// maxErrorOutput is the most bytes of a failed
+	// webhook response or command output included
+	// in the delivery error.
+	maxErrorOutput = 512
+)
+
+var (
+	// ErrUnsupportedSinkType is returned when an
+	// event sink has an unsupported type.
+	ErrUnsupportedSinkType = errors.New("unsupported event sink type")
+
+	_ Sink = (*WebhookSink)(nil)
+	_ Sink = (*ExecSink)(nil)
+	_ Sink = (*FileSink)(nil)
+)
+
+// NewSink returns the Sink described by config.
+func NewSink(config *configuration.EventSinkConfiguration) (Sink, error) {
+	switch config.Type {
+	case configuration.WebhookEventSink:
+		return NewWebhookSink(config.URL, config.Headers, config.Template)
+	case configuration.ExecEventSink:
+		return NewExecSink(config.Command), nil
+	case configuration.FileEventSink:
+		return NewFileSink(config.Path), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSinkType, config.Type)
+	}
+}
+
+// truncate returns at most maxErrorOutput bytes of output.
+func truncate(output []byte) string {
+	if len(output) > maxErrorOutput {
+		output = output[:maxErrorOutput]
+	}
+
+	return string(bytes.TrimSpace(output))
+}
+
+// WebhookSink POSTs each event to a URL. If a template is
+// provided, the request body is the template executed with
+// the event. Otherwise, the event is sent as JSON.
+type WebhookSink struct {
+	url      string
+	headers  map[string]string
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhookSink returns a new *WebhookSink.
+func NewWebhookSink(
+	url string,
+	headers map[string]string,
+	bodyTemplate string,
+) (*WebhookSink, error) {
+	sink := &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{},
+	}
+
+	if len(bodyTemplate) > 0 {
+		parsed, err := template.New("webhook").Parse(bodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse webhook template", err)
+		}
+
+		sink.template = parsed
+	}
+
+	return sink, nil
+}
+
+// body returns the request body for event.
+func (s *WebhookSink) body(event *Event) ([]byte, error) {
+	if s.template == nil {
+		return json.Marshal(event)
+	}
+
+	var body bytes.Buffer
+	if err := s.template.Execute(&body, event); err != nil {
+		return nil, fmt.Errorf("%w: unable to execute webhook template", err)
+	}
+
+	return body.Bytes(), nil
+}
+
+// Send POSTs event to the webhook. Any response
+// without a 2xx status code is an error.
+func (s *WebhookSink) Send(ctx context.Context, event *Event) error {
+	body, err := s.body(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: unable to create webhook request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for header, value := range s.headers {
+		req.Header.Set(header, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: unable to send webhook request", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, truncate(respBody))
+	}
+
+	return nil
+}
+
+// ExecSink runs a command for each event with
+// the event (as JSON) on stdin.
+type ExecSink struct {
+	command []string
+}
+
+// NewExecSink returns a new *ExecSink.
+func NewExecSink(command []string) *ExecSink {
+	return &ExecSink{command: command}
+}
+
+// Send runs the command with event on stdin. The
+// command is killed if ctx is done before it exits.
+func (s *ExecSink) Send(ctx context.Context, event *Event) error {
+	input, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal event", err)
+	}
+
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: command failed: %s", err, truncate(output))
+	}
+
+	return nil
+}
+
+// FileSink appends each event (as a
+// line of JSON) to a file.
+type FileSink struct {
+	path string
+}
+
+// NewFileSink returns a new *FileSink.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Send appends event to the file (creating it
+// if it does not exist).
+func (s *FileSink) Send(ctx context.Context, event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal event", err)
+	}
+
+	f, err := os.OpenFile( // #nosec G304
+		s.path,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open %s", err, s.path)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("%w: unable to write to %s", err, s.path)
+	}
+
+	return f.Close()
+}
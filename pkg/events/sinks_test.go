@@ -0,0 +1,156 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testEvent = &Event{
+	Type:    configuration.ReconciliationFailureEvent,
+	Time:    "2020-01-01T00:00:00Z",
+	Network: testNetwork,
+	Message: "active reconciliation failed for addr1 at 10",
+}
+
+func TestWebhookSink(t *testing.T) {
+	var tests = map[string]struct {
+		template string
+		status   int
+
+		expectedBody string
+		err          bool
+	}{
+		"json": {
+			status: http.StatusOK,
+			expectedBody: `{"type":"reconciliation_failure","time":"2020-01-01T00:00:00Z",` +
+				`"network_identifier":{"blockchain":"bitcoin","network":"mainnet"},` +
+				`"message":"active reconciliation failed for addr1 at 10"}`,
+		},
+		"template": {
+			template:     `{"text": "[{{.Network.Network}}] {{.Message}}"}`,
+			status:       http.StatusNoContent,
+			expectedBody: `{"text": "[mainnet] active reconciliation failed for addr1 at 10"}`,
+		},
+		"error status": {
+			status: http.StatusInternalServerError,
+			err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				if len(test.expectedBody) > 0 {
+					assert.Equal(t, test.expectedBody, string(body))
+				}
+
+				w.WriteHeader(test.status)
+			}))
+			defer ts.Close()
+
+			sink, err := NewWebhookSink(
+				ts.URL,
+				map[string]string{"Authorization": "Bearer token"},
+				test.template,
+			)
+			assert.NoError(t, err)
+
+			err = sink.Send(context.Background(), testEvent)
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExecSink(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// The event is provided on stdin.
+	outputPath := path.Join(dir, "event.json")
+	sink := NewExecSink([]string{"sh", "-c", "cat > " + outputPath})
+	assert.NoError(t, sink.Send(context.Background(), testEvent))
+
+	output, err := ioutil.ReadFile(outputPath)
+	assert.NoError(t, err)
+	var event Event
+	assert.NoError(t, json.Unmarshal(output, &event))
+	assert.Equal(t, testEvent, &event)
+
+	// Failures include the command output.
+	sink = NewExecSink([]string{"sh", "-c", "echo unreachable; exit 1"})
+	err = sink.Send(context.Background(), testEvent)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unreachable")
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	eventsPath := path.Join(dir, "events.jsonl")
+	sink, err := NewSink(&configuration.EventSinkConfiguration{
+		Type: configuration.FileEventSink,
+		Path: eventsPath,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Send(context.Background(), testEvent))
+	assert.NoError(t, sink.Send(context.Background(), testEvent))
+
+	output, err := ioutil.ReadFile(eventsPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		var event Event
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, testEvent, &event)
+	}
+
+	// The directory of the file must exist.
+	sink = NewFileSink(path.Join(dir, "missing", "events.jsonl"))
+	assert.Error(t, sink.Send(context.Background(), testEvent))
+}
+
+func TestNewSinkUnsupported(t *testing.T) {
+	sink, err := NewSink(&configuration.EventSinkConfiguration{Type: "email"})
+	assert.Nil(t, sink)
+	assert.True(t, errors.Is(err, ErrUnsupportedSinkType))
+}
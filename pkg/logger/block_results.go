@@ -0,0 +1,294 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// ErrBlockResultsWriterClosed is returned when a BlockResult
+// is written after the BlockResultsWriter is closed.
+var ErrBlockResultsWriterClosed = errors.New("block results writer is closed")
+
+var _ storage.BlockWorker = (*BlockResultsWriter)(nil)
+
+// BlockResult is a summary of a single processed block
+// that is streamed (as a JSON Line) to the block results
+// file.
+type BlockResult struct {
+	Index        int64  `json:"index"`
+	Hash         string `json:"hash"`
+	Transactions int64  `json:"transactions"`
+	Operations   int64  `json:"operations"`
+	Orphaned     bool   `json:"orphaned"`
+
+	// ProcessingMilliseconds is the time spent processing the
+	// block, from when BlockStorage started adding it until it
+	// was recorded. It is not populated for orphaned blocks or
+	// if the start of processing was not observed.
+	ProcessingMilliseconds *int64 `json:"processing_ms,omitempty"`
+}
+
+// BlockResultsWriter buffers BlockResults and periodically
+// flushes them to a file as JSON Lines. It implements the
+// storage.BlockWorker interface to observe when BlockStorage
+// starts adding each block, so it should be the first
+// registered block worker.
+type BlockResultsWriter struct {
+	flushInterval time.Duration
+
+	file   *os.File
+	writer *bufio.Writer
+	closed bool
+
+	processing      *types.BlockIdentifier
+	processingStart time.Time
+	mutex           sync.Mutex
+}
+
+// NewBlockResultsWriter opens (or creates) the file at path
+// for streaming BlockResults. Any existing results at an index
+// >= startIndex are discarded because they will be emitted again
+// when syncing resumes from startIndex.
+func NewBlockResultsWriter(
+	path string,
+	flushInterval time.Duration,
+	startIndex int64,
+) (*BlockResultsWriter, error) {
+	offset, err := blockResultsResumeOffset(path, startIndex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load existing block results", err)
+	}
+
+	if offset >= 0 {
+		if err := os.Truncate(path, offset); err != nil {
+			return nil, fmt.Errorf("%w: unable to truncate block results file", err)
+		}
+	}
+
+	file, err := os.OpenFile(
+		path,
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open block results file", err)
+	}
+
+	return &BlockResultsWriter{
+		flushInterval: flushInterval,
+		file:          file,
+		writer:        bufio.NewWriter(file),
+	}, nil
+}
+
+// blockResultsResumeOffset returns the byte offset of the first
+// line in an existing block results file with an index >= startIndex
+// (or of a torn final line). If the file does not exist or no line
+// needs to be discarded, -1 is returned.
+func blockResultsResumeOffset(path string, startIndex int64) (int64, error) {
+	f, err := os.Open(path) // #nosec G304
+	if os.IsNotExist(err) {
+		return -1, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	defer closeFile(f)
+
+	reader := bufio.NewReader(f)
+	offset := int64(0)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return -1, nil
+		}
+		if err != nil && err != io.EOF {
+			return -1, err
+		}
+
+		// A final line without a newline was only partially
+		// written (we always terminate lines when writing),
+		// so we discard it.
+		if err == io.EOF {
+			return offset, nil
+		}
+
+		var result BlockResult
+		if jsonErr := json.Unmarshal(line, &result); jsonErr != nil {
+			// A line that cannot be parsed is only tolerated
+			// at the end of the file (where it could have been
+			// torn by an unclean exit).
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				return offset, nil
+			}
+
+			return -1, fmt.Errorf(
+				"%w: unable to parse block result on line %d",
+				jsonErr,
+				lineNumber,
+			)
+		}
+
+		if result.Index >= startIndex {
+			return offset, nil
+		}
+
+		offset += int64(len(line))
+	}
+}
+
+func (w *BlockResultsWriter) write(result *BlockResult) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrBlockResultsWriterClosed
+	}
+
+	if !result.Orphaned && w.processing != nil &&
+		w.processing.Index == result.Index && w.processing.Hash == result.Hash {
+		processing := time.Since(w.processingStart).Milliseconds()
+		result.ProcessingMilliseconds = &processing
+		w.processing = nil
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// It marks the start of processing the block.
+func (w *BlockResultsWriter) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.processing = block.BlockIdentifier
+	w.processingStart = time.Now()
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+func (w *BlockResultsWriter) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
+
+// AddBlock records a BlockResult for an added block.
+func (w *BlockResultsWriter) AddBlock(block *types.Block) error {
+	opCount := int64(0)
+	for _, tx := range block.Transactions {
+		opCount += int64(len(tx.Operations))
+	}
+
+	return w.write(&BlockResult{
+		Index:        block.BlockIdentifier.Index,
+		Hash:         block.BlockIdentifier.Hash,
+		Transactions: int64(len(block.Transactions)),
+		Operations:   opCount,
+	})
+}
+
+// RemoveBlock records a BlockResult for an orphaned block.
+func (w *BlockResultsWriter) RemoveBlock(block *types.BlockIdentifier) error {
+	return w.write(&BlockResult{
+		Index:    block.Index,
+		Hash:     block.Hash,
+		Orphaned: true,
+	})
+}
+
+// Flush writes all buffered BlockResults to disk. Calling
+// Flush after Close is a no-op.
+func (w *BlockResultsWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
+	return w.writer.Flush()
+}
+
+// FlushLoop flushes buffered BlockResults every
+// flushInterval until the context is canceled.
+func (w *BlockResultsWriter) FlushLoop(ctx context.Context) error {
+	tc := time.NewTicker(w.flushInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("%w: unable to flush block results", err)
+			}
+
+			return ctx.Err()
+		case <-tc.C:
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("%w: unable to flush block results", err)
+			}
+		}
+	}
+}
+
+// Close flushes any buffered BlockResults and closes
+// the underlying file. It is safe to call Close more
+// than once.
+func (w *BlockResultsWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err := w.writer.Flush(); err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("%w: unable to flush block results", err)
+	}
+
+	return w.file.Close()
+}
@@ -0,0 +1,225 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func readBlockResults(t *testing.T, filePath string) []*BlockResult {
+	f, err := os.Open(filePath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	results := []*BlockResult{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var result BlockResult
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, &result)
+	}
+	assert.NoError(t, scanner.Err())
+
+	return results
+}
+
+func testBlock(index int64) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		Transactions: []*types.Transaction{
+			{
+				Operations: []*types.Operation{{}, {}},
+			},
+		},
+	}
+}
+
+func TestBlockResultsWriter(t *testing.T) {
+	var tests = map[string]struct {
+		existing   string
+		startIndex int64
+		orphan     bool
+
+		expectedIndexes []int64
+		expectedErr     bool
+	}{
+		"new file": {
+			startIndex:      0,
+			orphan:          true,
+			expectedIndexes: []int64{0, 1, 2, 3, 3},
+		},
+		"resume discards results at or after start index": {
+			existing: `{"index":0,"hash":"block 0","transactions":1,"operations":2,"orphaned":false}
+{"index":1,"hash":"block 1","transactions":1,"operations":2,"orphaned":false}
+{"index":2,"hash":"block 2","transactions":1,"operations":2,"orphaned":false}
+{"index":3,"hash":"block 3","transactions":1,"operations":2,"orphaned":false}
+`,
+			startIndex:      2,
+			expectedIndexes: []int64{0, 1, 2, 3},
+		},
+		"resume discards torn final line": {
+			existing: `{"index":0,"hash":"block 0","transactions":1,"operations":2,"orphaned":false}
+{"index":1,"hash":"block 1","transactions":1,"operations":2,"orphaned":false}
+{"index":2,"hash":"bl`,
+			startIndex:      2,
+			expectedIndexes: []int64{0, 1, 2, 3},
+		},
+		"resume discards unparseable final line": {
+			existing: `{"index":0,"hash":"block 0","transactions":1,"operations":2,"orphaned":false}
+{"index":1,"hash":"block 1","transactions":1,"operations":2,"orphaned":false}
+{"index":2,"hash":"bl
+`,
+			startIndex:      2,
+			expectedIndexes: []int64{0, 1, 2, 3},
+		},
+		"resume errors on corrupt line in middle of file": {
+			existing: `{"index":0,"hash":"block 0","transactions":1,"operations":2,"orphaned":false}
+{"index":1,"hash":"bl
+{"index":2,"hash":"block 2","transactions":1,"operations":2,"orphaned":false}
+`,
+			startIndex:  2,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "block_results.jsonl")
+			if len(test.existing) > 0 {
+				assert.NoError(t, ioutil.WriteFile(
+					filePath,
+					[]byte(test.existing),
+					os.FileMode(utils.DefaultFilePermissions),
+				))
+			}
+
+			w, err := NewBlockResultsWriter(filePath, time.Second, test.startIndex)
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, w)
+
+				// The existing file should be left untouched.
+				contents, err := ioutil.ReadFile(filePath)
+				assert.NoError(t, err)
+				assert.Equal(t, test.existing, string(contents))
+				return
+			}
+			assert.NoError(t, err)
+
+			// The start of processing the first block
+			// is not observed.
+			for i := test.startIndex; i < 4; i++ {
+				if i > test.startIndex {
+					_, err := w.AddingBlock(context.Background(), testBlock(i), nil)
+					assert.NoError(t, err)
+				}
+				assert.NoError(t, w.AddBlock(testBlock(i)))
+			}
+			if test.orphan {
+				assert.NoError(t, w.RemoveBlock(testBlock(3).BlockIdentifier))
+			}
+			assert.NoError(t, w.Close())
+			assert.NoError(t, w.Close())
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, ErrBlockResultsWriterClosed, w.AddBlock(testBlock(4)))
+
+			results := readBlockResults(t, filePath)
+			indexes := []int64{}
+			for _, result := range results {
+				indexes = append(indexes, result.Index)
+
+				// Orphaned blocks are recorded with only
+				// their identifier.
+				if result.Orphaned {
+					continue
+				}
+
+				assert.Equal(t, int64(1), result.Transactions)
+				assert.Equal(t, int64(2), result.Operations)
+			}
+			assert.Equal(t, test.expectedIndexes, indexes)
+
+			// Only blocks whose processing start was
+			// observed have a processing latency.
+			firstAdded := len(results) - int(4-test.startIndex)
+			if test.orphan {
+				firstAdded--
+			}
+			for i := firstAdded; i < len(results); i++ {
+				switch {
+				case results[i].Orphaned:
+					assert.Nil(t, results[i].ProcessingMilliseconds)
+				case i == firstAdded:
+					assert.Nil(t, results[i].ProcessingMilliseconds)
+				default:
+					assert.NotNil(t, results[i].ProcessingMilliseconds)
+				}
+			}
+
+			if test.orphan {
+				assert.True(t, results[len(results)-1].Orphaned)
+			}
+		})
+	}
+}
+
+func TestBlockResultsWriter_FlushLoop(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "block_results.jsonl")
+	w, err := NewBlockResultsWriter(filePath, 10*time.Millisecond, 0)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- w.FlushLoop(ctx)
+	}()
+
+	assert.NoError(t, w.AddBlock(testBlock(0)))
+	assert.Eventually(t, func() bool {
+		return len(readBlockResults(t, filePath)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Results added right before cancellation are flushed
+	// when the loop exits.
+	assert.NoError(t, w.AddBlock(testBlock(1)))
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+	assert.Len(t, readBlockResults(t, filePath), 2)
+
+	assert.NoError(t, w.Close())
+}
@@ -17,6 +17,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
@@ -69,8 +70,15 @@ type Logger struct {
 	logBalanceChanges bool
 	logReconciliation bool
 
+	blockResults    *BlockResultsWriter
+	resultsDatabase *ResultsDatabase
+
 	lastStatsMessage    string
 	lastProgressMessage string
+
+	// progressBar is only populated if progress
+	// should be rendered as a progress bar.
+	progressBar *ProgressBar
 }
 
 // NewLogger constructs a new Logger.
@@ -80,6 +88,8 @@ func NewLogger(
 	logTransactions bool,
 	logBalanceChanges bool,
 	logReconciliation bool,
+	blockResults *BlockResultsWriter,
+	resultsDatabase *ResultsDatabase,
 ) *Logger {
 	return &Logger{
 		logDir:            logDir,
@@ -87,15 +97,42 @@ func NewLogger(
 		logTransactions:   logTransactions,
 		logBalanceChanges: logBalanceChanges,
 		logReconciliation: logReconciliation,
+		blockResults:      blockResults,
+		resultsDatabase:   resultsDatabase,
 	}
 }
 
+// EnableProgressBar renders the progress logged by LogDataStatus
+// as a progress bar on console. If console is not a terminal,
+// progress is still logged line by line.
+func (l *Logger) EnableProgressBar(console io.Writer) {
+	if !IsTerminal(console) {
+		return
+	}
+
+	l.progressBar = NewProgressBar(console)
+}
+
+// ClearProgressBar erases the progress bar (if it is drawn)
+// so that it is not followed by any other output.
+func (l *Logger) ClearProgressBar() {
+	if l.progressBar == nil {
+		return
+	}
+
+	l.progressBar.Clear()
+}
+
 // LogDataStatus logs results.CheckDataStatus.
 func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataStatus) {
 	if status.Stats.Blocks == 0 { // wait for at least 1 block to be processed
 		return
 	}
 
+	if err := l.resultsDatabase.AddProgressSample(ctx, status); err != nil {
+		log.Printf("%s: unable to record progress sample\n", err.Error())
+	}
+
 	statsMessage := fmt.Sprintf(
 		"[STATS] Blocks: %d (Orphaned: %d) Transactions: %d Operations: %d Reconciliations: %d (Inactive: %d, Coverage: %f%%)", // nolint:lll
 		status.Stats.Blocks,
@@ -113,20 +150,30 @@ func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataSta
 	}
 
 	l.lastStatsMessage = statsMessage
+	l.ClearProgressBar()
 	color.Cyan(statsMessage)
+	logReconciliationsSkipped(status.Stats)
 
 	// If Progress is nil, it means we're already done.
 	if status.Progress == nil {
 		return
 	}
 
+	if l.progressBar != nil {
+		logReconciliationFallingBehind(status.Progress)
+		l.progressBar.Render(status.Progress)
+		return
+	}
+
 	progressMessage := fmt.Sprintf(
-		"[PROGRESS] Blocks Synced: %d/%d (Completed: %f%%, Rate: %f/second) Time Remaining: %s",
+		"[PROGRESS] Blocks Synced: %d/%d (Completed: %f%%, Rate: %f/second, Recent Rate: %f/second) Time Remaining: %s Reconciliation Queue: %d", // nolint:lll
 		status.Progress.Blocks,
 		status.Progress.Tip,
 		status.Progress.Completed,
 		status.Progress.Rate,
-		status.Progress.TimeRemaining,
+		status.Progress.RecentRate,
+		status.Progress.HumanTimeRemaining(),
+		status.Progress.ReconciliationQueueDepth,
 	)
 
 	// Don't print out the same progress message twice.
@@ -136,6 +183,37 @@ func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataSta
 
 	l.lastProgressMessage = progressMessage
 	color.Cyan(progressMessage)
+	logReconciliationFallingBehind(status.Progress)
+}
+
+// logReconciliationFallingBehind logs a warning if the
+// reconciliation queue depth is above its threshold.
+func logReconciliationFallingBehind(progress *results.CheckDataProgress) {
+	if !progress.ReconciliationFallingBehind() {
+		return
+	}
+
+	color.Yellow(
+		"[WARNING] Reconciliation Queue Depth: %d (Threshold: %d) reconciliation is falling behind syncing (consider increasing reconciliation concurrency)", // nolint:lll
+		progress.ReconciliationQueueDepth,
+		progress.ReconciliationQueueDepthThreshold,
+	)
+}
+
+// logReconciliationsSkipped logs a warning if the
+// reconciliation skip ratio is above its threshold.
+func logReconciliationsSkipped(stats *results.CheckDataStats) {
+	if !stats.HighReconciliationSkipRatio() {
+		return
+	}
+
+	color.Yellow(
+		"[WARNING] Skipped Reconciliations: %f%% (Backlog: %d, Pruned: %d, Threshold: %f%%) reconciliation coverage may overstate how much was checked", // nolint:lll
+		stats.ReconciliationSkipRatio()*utils.OneHundred,
+		stats.ReconciliationsSkippedBacklog,
+		stats.ReconciliationsSkippedPruned,
+		stats.ReconciliationSkipRatioThreshold*utils.OneHundred,
+	)
 }
 
 // LogConstructionStatus logs results.CheckConstructionStatus.
@@ -177,6 +255,16 @@ func (l *Logger) AddBlockStream(
 	ctx context.Context,
 	block *types.Block,
 ) error {
+	// The statefulsyncer ignores any error returned by the
+	// Logger (and skips updating its counters), so we only
+	// log write failures here. A failed write is surfaced
+	// by the next flush in BlockResultsWriter.FlushLoop.
+	if l.blockResults != nil {
+		if err := l.blockResults.AddBlock(block); err != nil {
+			log.Printf("%s: unable to record block result\n", err.Error())
+		}
+	}
+
 	if !l.logBlocks {
 		return nil
 	}
@@ -213,6 +301,13 @@ func (l *Logger) RemoveBlockStream(
 	ctx context.Context,
 	block *types.BlockIdentifier,
 ) error {
+	// See AddBlockStream for why errors are only logged.
+	if l.blockResults != nil {
+		if err := l.blockResults.RemoveBlock(block); err != nil {
+			log.Printf("%s: unable to record block result\n", err.Error())
+		}
+	}
+
 	if !l.logBlocks {
 		return nil
 	}
@@ -428,6 +523,19 @@ func (l *Logger) ReconcileFailureStream(
 		)
 	}
 
+	err := l.resultsDatabase.AddReconciliationFailure(
+		ctx,
+		reconciliationType,
+		account,
+		currency,
+		computedBalance,
+		nodeBalance,
+		block,
+	)
+	if err != nil {
+		log.Printf("%s: unable to record reconciliation failure\n", err.Error())
+	}
+
 	if !l.logReconciliation {
 		return nil
 	}
@@ -0,0 +1,110 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+const (
+	// progressBarWidth is the number of
+	// characters between the brackets of
+	// the progress bar.
+	progressBarWidth = 30
+
+	// clearLine returns the cursor to the start of
+	// the line and erases the line (so the progress
+	// bar can be redrawn in place).
+	clearLine = "\r\033[K"
+)
+
+// IsTerminal returns a boolean indicating if w is a
+// terminal that supports redrawing a line in place.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ProgressBar renders *results.CheckDataProgress as
+// a progress bar that is redrawn in place. Anything
+// else written to the console must be written after
+// calling Clear (so it doesn't follow the bar).
+type ProgressBar struct {
+	w     io.Writer
+	drawn bool
+}
+
+// NewProgressBar returns a new *ProgressBar
+// that is drawn on w.
+func NewProgressBar(w io.Writer) *ProgressBar {
+	return &ProgressBar{w: w}
+}
+
+// renderProgressBar returns progress as a single line.
+func renderProgressBar(progress *results.CheckDataProgress) string {
+	completed := math.Max(0, math.Min(progress.Completed, utils.OneHundred))
+	filled := int(completed / utils.OneHundred * progressBarWidth)
+
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+
+	return fmt.Sprintf(
+		"[PROGRESS] [%s] %6.2f%% %.2f blocks/second ETA %s (%d/%d)",
+		bar,
+		completed,
+		progress.RecentRate,
+		progress.HumanTimeRemaining(),
+		progress.Blocks,
+		progress.Tip,
+	)
+}
+
+// Render redraws the progress bar with progress.
+func (b *ProgressBar) Render(progress *results.CheckDataProgress) {
+	fmt.Fprint(b.w, clearLine)
+	color.New(color.FgCyan).Fprint(b.w, renderProgressBar(progress))
+	b.drawn = true
+}
+
+// Clear erases the progress bar (if it is drawn) so
+// that the line can be reused by other output.
+func (b *ProgressBar) Clear() {
+	if !b.drawn {
+		return
+	}
+
+	fmt.Fprint(b.w, clearLine)
+	b.drawn = false
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderProgressBar(t *testing.T) {
+	var tests = map[string]struct {
+		progress *results.CheckDataProgress
+
+		expected string
+	}{
+		"started": {
+			progress: &results.CheckDataProgress{
+				Blocks:        0,
+				Tip:           100,
+				RecentRate:    2,
+				TimeRemaining: 50,
+			},
+			expected: "[PROGRESS] [>                             ]   0.00% 2.00 blocks/second ETA 50s (0/100)", // nolint:lll
+		},
+		"halfway": {
+			progress: &results.CheckDataProgress{
+				Blocks:        500,
+				Tip:           1000,
+				Completed:     50,
+				RecentRate:    3.5,
+				TimeRemaining: 150,
+			},
+			expected: "[PROGRESS] [===============>              ]  50.00% 3.50 blocks/second ETA 2m 30s (500/1000)", // nolint:lll
+		},
+		"completed past tip": {
+			progress: &results.CheckDataProgress{
+				Blocks:     1001,
+				Tip:        1000,
+				Completed:  100.1,
+				RecentRate: 10,
+			},
+			expected: "[PROGRESS] [==============================] 100.00% 10.00 blocks/second ETA 0s (1001/1000)", // nolint:lll
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, renderProgressBar(test.progress))
+		})
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	var b bytes.Buffer
+	bar := NewProgressBar(&b)
+
+	// Nothing is cleared before the bar is drawn.
+	bar.Clear()
+	assert.Equal(t, "", b.String())
+
+	progress := &results.CheckDataProgress{Blocks: 500, Tip: 1000, Completed: 50}
+	bar.Render(progress)
+	bar.Render(progress)
+	assert.Equal(t, 2, bytes.Count(b.Bytes(), []byte(clearLine)))
+	assert.Contains(t, b.String(), renderProgressBar(progress))
+
+	bar.Clear()
+	bar.Clear()
+	assert.Equal(t, 3, bytes.Count(b.Bytes(), []byte(clearLine)))
+}
+
+func TestEnableProgressBar(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	f, err := os.Create(path.Join(dir, "console.txt"))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	// Progress is logged line by line if the
+	// console is not a terminal.
+	for _, console := range []io.Writer{f, &bytes.Buffer{}, ioutil.Discard} {
+		l := NewLogger(dir, false, false, false, false, nil, nil)
+		l.EnableProgressBar(console)
+		assert.Nil(t, l.progressBar)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// sqliteDriver is the database/sql driver used to
+	// open a results database.
+	sqliteDriver = "sqlite3"
+
+	// resultsDatabaseSchema creates the tables of a results
+	// database if they don't already exist. Each check:data
+	// run is a row in runs and is referenced by its progress
+	// samples and reconciliation failures.
+	resultsDatabaseSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	blockchain TEXT NOT NULL,
+	network TEXT NOT NULL,
+	started_at INTEGER NOT NULL,
+	finished_at INTEGER,
+	configuration TEXT NOT NULL,
+	error TEXT,
+	end_condition TEXT,
+	end_condition_detail TEXT,
+	results TEXT
+);
+
+CREATE TABLE IF NOT EXISTS progress_samples (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	sampled_at INTEGER NOT NULL,
+	blocks INTEGER NOT NULL,
+	orphans INTEGER NOT NULL,
+	transactions INTEGER NOT NULL,
+	operations INTEGER NOT NULL,
+	active_reconciliations INTEGER NOT NULL,
+	inactive_reconciliations INTEGER NOT NULL,
+	reconciliation_failures INTEGER NOT NULL,
+	reconciliation_coverage REAL NOT NULL,
+	tip INTEGER,
+	completed REAL,
+	rate REAL,
+	time_remaining REAL
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_failures (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	observed_at INTEGER NOT NULL,
+	reconciliation_type TEXT NOT NULL,
+	account TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	block_index INTEGER NOT NULL,
+	block_hash TEXT NOT NULL,
+	computed_balance TEXT NOT NULL,
+	node_balance TEXT NOT NULL
+);
+`
+)
+
+// ErrResultsDatabaseUnsupported is returned by NewResultsDatabase
+// when rosetta-cli was built without the sqlite build tag (the
+// SQLite driver requires cgo).
+var ErrResultsDatabaseUnsupported = errors.New(
+	"results database requires building with -tags sqlite",
+)
+
+// ResultsDatabase records a check:data run, periodic progress
+// samples, and reconciliation failures in a SQLite database so
+// that many runs can be queried with SQL. All methods are safe
+// to call on a nil *ResultsDatabase.
+type ResultsDatabase struct {
+	db    *sql.DB
+	runID int64
+	mutex sync.Mutex
+}
+
+// NewResultsDatabase opens (or creates) the SQLite database at
+// path, creates its schema, and inserts a new run for network.
+func NewResultsDatabase(
+	ctx context.Context,
+	path string,
+	network *types.NetworkIdentifier,
+	config *configuration.Configuration,
+) (*ResultsDatabase, error) {
+	if !sqliteSupported {
+		return nil, ErrResultsDatabaseUnsupported
+	}
+
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open results database", err)
+	}
+
+	// SQLite only supports a single writer.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, resultsDatabaseSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("%w: unable to create results database schema", err)
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("%w: unable to encode configuration", err)
+	}
+
+	res, err := db.ExecContext(
+		ctx,
+		`INSERT INTO runs (blockchain, network, started_at, configuration) VALUES (?, ?, ?, ?)`,
+		network.Blockchain,
+		network.Network,
+		time.Now().Unix(),
+		string(configJSON),
+	)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("%w: unable to insert run", err)
+	}
+
+	runID, err := res.LastInsertId()
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("%w: unable to get run id", err)
+	}
+
+	return &ResultsDatabase{
+		db:    db,
+		runID: runID,
+	}, nil
+}
+
+// RunID returns the id of the run recorded by ResultsDatabase.
+func (r *ResultsDatabase) RunID() int64 {
+	if r == nil {
+		return 0
+	}
+
+	return r.runID
+}
+
+// AddProgressSample records a sample of status.
+func (r *ResultsDatabase) AddProgressSample(
+	ctx context.Context,
+	status *results.CheckDataStatus,
+) error {
+	if r == nil || status == nil || status.Stats == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var tip, completed, rate, timeRemaining interface{}
+	if status.Progress != nil {
+		tip = status.Progress.Tip
+		completed = status.Progress.Completed
+		rate = status.Progress.Rate
+		timeRemaining = status.Progress.TimeRemaining
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO progress_samples (
+			run_id, sampled_at, blocks, orphans, transactions, operations,
+			active_reconciliations, inactive_reconciliations,
+			reconciliation_failures, reconciliation_coverage,
+			tip, completed, rate, time_remaining
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.runID,
+		time.Now().Unix(),
+		status.Stats.Blocks,
+		status.Stats.Orphans,
+		status.Stats.Transactions,
+		status.Stats.Operations,
+		status.Stats.ActiveReconciliations,
+		status.Stats.InactiveReconciliations,
+		status.Stats.ReconciliationFailures,
+		status.Stats.ReconciliationCoverage,
+		tip,
+		completed,
+		rate,
+		timeRemaining,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to insert progress sample", err)
+	}
+
+	return nil
+}
+
+// AddReconciliationFailure records a reconciliation failure.
+func (r *ResultsDatabase) AddReconciliationFailure(
+	ctx context.Context,
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	nodeBalance string,
+	block *types.BlockIdentifier,
+) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO reconciliation_failures (
+			run_id, observed_at, reconciliation_type, account, currency,
+			block_index, block_hash, computed_balance, node_balance
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.runID,
+		time.Now().Unix(),
+		reconciliationType,
+		types.AccountString(account),
+		types.CurrencyString(currency),
+		block.Index,
+		block.Hash,
+		computedBalance,
+		nodeBalance,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to insert reconciliation failure", err)
+	}
+
+	return nil
+}
+
+// FinishRun records the final results of the run.
+func (r *ResultsDatabase) FinishRun(
+	ctx context.Context,
+	checkDataResults *results.CheckDataResults,
+) error {
+	if r == nil || checkDataResults == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	resultsJSON, err := json.Marshal(checkDataResults)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode results", err)
+	}
+
+	var runErr, endCondition, endConditionDetail interface{}
+	if len(checkDataResults.Error) > 0 {
+		runErr = checkDataResults.Error
+	}
+
+	if checkDataResults.EndCondition != nil {
+		endCondition = string(checkDataResults.EndCondition.Type)
+		endConditionDetail = checkDataResults.EndCondition.Detail
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE runs SET
+			finished_at = ?, error = ?, end_condition = ?,
+			end_condition_detail = ?, results = ?
+		WHERE id = ?`,
+		time.Now().Unix(),
+		runErr,
+		endCondition,
+		endConditionDetail,
+		string(resultsJSON),
+		r.runID,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to update run", err)
+	}
+
+	return nil
+}
+
+// Close closes the results database.
+func (r *ResultsDatabase) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Close()
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultsDatabase(t *testing.T) {
+	if !sqliteSupported {
+		t.Skip("built without the sqlite build tag")
+	}
+
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	dbPath := path.Join(dir, "results.db")
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	config := configuration.DefaultConfiguration()
+
+	// Each run of check:data is recorded separately.
+	for run := int64(1); run <= 2; run++ {
+		r, err := NewResultsDatabase(ctx, dbPath, network, config)
+		assert.NoError(t, err)
+		assert.Equal(t, run, r.RunID())
+
+		assert.NoError(t, r.AddProgressSample(ctx, &results.CheckDataStatus{
+			Stats: &results.CheckDataStats{Blocks: 10, Operations: 20},
+			Progress: &results.CheckDataProgress{
+				Blocks:        10,
+				Tip:           100,
+				Completed:     10,
+				Rate:          1,
+				TimeRemaining: 90,
+			},
+		}))
+		assert.NoError(t, r.AddProgressSample(ctx, &results.CheckDataStatus{
+			Stats: &results.CheckDataStats{Blocks: 100, Operations: 200},
+		}))
+		assert.NoError(t, r.AddReconciliationFailure(
+			ctx,
+			"ACTIVE",
+			&types.AccountIdentifier{Address: "addr"},
+			&types.Currency{Symbol: "BTC", Decimals: 8},
+			"100",
+			"200",
+			&types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		))
+		assert.NoError(t, r.FinishRun(ctx, &results.CheckDataResults{
+			Error: "reconciliation failure",
+		}))
+		assert.NoError(t, r.Close())
+	}
+
+	db, err := sql.Open(sqliteDriver, dbPath)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var runs int
+	assert.NoError(t, db.QueryRow(
+		`SELECT COUNT(*) FROM runs WHERE finished_at IS NOT NULL AND error = ?`,
+		"reconciliation failure",
+	).Scan(&runs))
+	assert.Equal(t, 2, runs)
+
+	var samples, withProgress int
+	assert.NoError(t, db.QueryRow(
+		`SELECT COUNT(*), COUNT(tip) FROM progress_samples WHERE run_id = 2`,
+	).Scan(&samples, &withProgress))
+	assert.Equal(t, 2, samples)
+	assert.Equal(t, 1, withProgress)
+
+	var account, currency string
+	var blockIndex int64
+	assert.NoError(t, db.QueryRow(
+		`SELECT account, currency, block_index FROM reconciliation_failures WHERE run_id = 1`,
+	).Scan(&account, &currency, &blockIndex))
+	assert.Equal(t, types.AccountString(&types.AccountIdentifier{Address: "addr"}), account)
+	assert.Equal(t, types.CurrencyString(&types.Currency{Symbol: "BTC", Decimals: 8}), currency)
+	assert.Equal(t, int64(10), blockIndex)
+}
+
+func TestResultsDatabase_Nil(t *testing.T) {
+	ctx := context.Background()
+
+	var r *ResultsDatabase
+	assert.Equal(t, int64(0), r.RunID())
+	assert.NoError(t, r.AddProgressSample(ctx, &results.CheckDataStatus{}))
+	assert.NoError(t, r.AddReconciliationFailure(ctx, "", nil, nil, "", "", nil))
+	assert.NoError(t, r.FinishRun(ctx, &results.CheckDataResults{}))
+	assert.NoError(t, r.Close())
+}
+
+func TestResultsDatabase_OpenFailure(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	r, err := NewResultsDatabase(
+		context.Background(),
+		path.Join(dir, "missing", "results.db"),
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		configuration.DefaultConfiguration(),
+	)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
+func TestResultsDatabase_Unsupported(t *testing.T) {
+	if sqliteSupported {
+		t.Skip("built with the sqlite build tag")
+	}
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	r, err := NewResultsDatabase(
+		context.Background(),
+		path.Join(dir, "results.db"),
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		configuration.DefaultConfiguration(),
+	)
+	assert.True(t, errors.Is(err, ErrResultsDatabaseUnsupported))
+	assert.Nil(t, r)
+}
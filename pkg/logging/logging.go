@@ -0,0 +1,215 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	// DebugLevel messages are only useful when
+	// debugging the rosetta-cli.
+	DebugLevel Level = iota
+
+	// InfoLevel messages describe normal operation.
+	InfoLevel
+
+	// WarnLevel messages describe failures that
+	// do not stop the command (ex: a stat that
+	// could not be computed).
+	WarnLevel
+
+	// ErrorLevel messages describe failures that
+	// prevent the command from completing some work.
+	ErrorLevel
+)
+
+var (
+	// ErrInvalidLevel is returned when a log
+	// level cannot be parsed.
+	ErrInvalidLevel = errors.New("invalid log level")
+
+	levelNames = map[Level]string{
+		DebugLevel: "debug",
+		InfoLevel:  "info",
+		WarnLevel:  "warn",
+		ErrorLevel: "error",
+	}
+)
+
+// String returns the name of a Level.
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel returns the Level with the provided
+// name ("debug", "info", "warn", or "error").
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrInvalidLevel, name)
+}
+
+// Fields are the structured context of a log message.
+// Any error values are logged as their message.
+type Fields map[string]interface{}
+
+// Logger writes log messages. Implement Logger
+// (and call SetLogger) to redirect the log messages
+// of the rosetta-cli.
+type Logger interface {
+	Log(level Level, msg string, fields Fields)
+}
+
+// StandardLogger is a Logger that writes messages
+// at or above a Level to an io.Writer as text or as
+// a single line of JSON.
+type StandardLogger struct {
+	mutex sync.Mutex
+
+	writer io.Writer
+	level  Level
+	json   bool
+}
+
+// NewStandardLogger returns a new *StandardLogger.
+func NewStandardLogger(writer io.Writer, level Level, jsonFormat bool) *StandardLogger {
+	return &StandardLogger{
+		writer: writer,
+		level:  level,
+		json:   jsonFormat,
+	}
+}
+
+// fieldValue returns the value of a field to log.
+func fieldValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+
+	return value
+}
+
+// Log writes msg and fields if level is at
+// or above the level of the logger.
+func (l *StandardLogger) Log(level Level, msg string, fields Fields) {
+	if level < l.level {
+		return
+	}
+
+	now := time.Now()
+
+	var line string
+	if l.json {
+		entry := map[string]interface{}{}
+		for key, value := range fields {
+			entry[key] = fieldValue(value)
+		}
+		entry["time"] = now.UTC().Format(time.RFC3339)
+		entry["level"] = level.String()
+		entry["msg"] = msg
+
+		output, err := json.Marshal(entry)
+		if err != nil {
+			output, _ = json.Marshal(map[string]interface{}{
+				"time":  entry["time"],
+				"level": ErrorLevel.String(),
+				"msg":   fmt.Sprintf("%s: unable to encode log message %s", err.Error(), msg),
+			})
+		}
+		line = string(output)
+	} else {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := []string{
+			now.Format("2006/01/02 15:04:05"),
+			strings.ToUpper(level.String()),
+			msg,
+		}
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%q", key, fmt.Sprint(fieldValue(fields[key]))))
+		}
+		line = strings.Join(parts, " ")
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	fmt.Fprintln(l.writer, line)
+}
+
+var (
+	loggerMutex sync.RWMutex
+	logger      Logger = NewStandardLogger(os.Stderr, InfoLevel, false)
+)
+
+// SetLogger sets the Logger used by Debug, Info,
+// Warn, and Error. By default, messages at or above
+// InfoLevel are written to stderr as text.
+func SetLogger(l Logger) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+
+	logger = l
+}
+
+func log(level Level, msg string, fields Fields) {
+	loggerMutex.RLock()
+	defer loggerMutex.RUnlock()
+
+	logger.Log(level, msg, fields)
+}
+
+// Debug logs msg at DebugLevel.
+func Debug(msg string, fields Fields) {
+	log(DebugLevel, msg, fields)
+}
+
+// Info logs msg at InfoLevel.
+func Info(msg string, fields Fields) {
+	log(InfoLevel, msg, fields)
+}
+
+// Warn logs msg at WarnLevel.
+func Warn(msg string, fields Fields) {
+	log(WarnLevel, msg, fields)
+}
+
+// Error logs msg at ErrorLevel.
+func Error(msg string, fields Fields) {
+	log(ErrorLevel, msg, fields)
+}
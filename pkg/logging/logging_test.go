@@ -0,0 +1,96 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+		parsed, err := ParseLevel(level.String())
+		assert.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	parsed, err := ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, WarnLevel, parsed)
+
+	_, err = ParseLevel("verbose")
+	assert.True(t, errors.Is(err, ErrInvalidLevel))
+}
+
+func TestStandardLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStandardLogger(&buf, WarnLevel, false)
+
+	logger.Log(InfoLevel, "filtered", nil)
+	assert.Empty(t, buf.String())
+
+	logger.Log(WarnLevel, "cannot get counter", Fields{
+		"error":   errors.New("counter unavailable"),
+		"counter": "block",
+	})
+	assert.True(t, strings.HasSuffix(
+		buf.String(),
+		" WARN cannot get counter counter=\"block\" error=\"counter unavailable\"\n",
+	))
+
+	buf.Reset()
+	logger = NewStandardLogger(&buf, DebugLevel, true)
+	logger.Log(DebugLevel, "cannot get counter", Fields{
+		"error":   errors.New("counter unavailable"),
+		"counter": "block",
+	})
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotEmpty(t, entry["time"])
+	delete(entry, "time")
+	assert.Equal(t, map[string]interface{}{
+		"level":   "debug",
+		"msg":     "cannot get counter",
+		"counter": "block",
+		"error":   "counter unavailable",
+	}, entry)
+}
+
+type recordingLogger struct {
+	levels []Level
+}
+
+func (r *recordingLogger) Log(level Level, msg string, fields Fields) {
+	r.levels = append(r.levels, level)
+}
+
+func TestSetLogger(t *testing.T) {
+	recorder := &recordingLogger{}
+	SetLogger(recorder)
+	defer SetLogger(NewStandardLogger(os.Stderr, InfoLevel, false))
+
+	Debug("debug", nil)
+	Info("info", nil)
+	Warn("warn", nil)
+	Error("error", nil)
+	assert.Equal(t, []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}, recorder.levels)
+}
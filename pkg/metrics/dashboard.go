@@ -0,0 +1,291 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultDatasourceUID is the Grafana datasource UID
+	// placeholder used in a generated dashboard. Grafana prompts
+	// for a Prometheus datasource when a dashboard using this
+	// placeholder is imported.
+	DefaultDatasourceUID = "${DS_PROMETHEUS}"
+
+	// DashboardUID is the UID of the generated Grafana dashboard.
+	DashboardUID = "rosetta-cli-check-data"
+
+	// dashboardSchemaVersion is the Grafana dashboard schema
+	// version of the generated dashboard.
+	dashboardSchemaVersion = 27
+
+	// panelWidth and panelHeight are the dimensions of each
+	// panel (a Grafana dashboard is 24 units wide).
+	panelWidth  = 12
+	panelHeight = 8
+
+	// stalledDuration is how long syncing must make no
+	// progress before it is considered degraded.
+	stalledDuration = "10m"
+)
+
+const (
+	// BlocksSyncedRate is the recording rule for the
+	// per-second rate of blocks synced.
+	BlocksSyncedRate = "rosetta_cli:blocks_synced:rate5m"
+
+	// ReconciliationFailuresIncrease is the recording rule for
+	// the number of reconciliation failures in the last hour.
+	ReconciliationFailuresIncrease = "rosetta_cli:reconciliation_failures:increase1h"
+
+	// SyncDegraded is the recording rule that is 1 when syncing
+	// has not made progress while behind tip and 0 otherwise.
+	SyncDegraded = "rosetta_cli:sync_degraded"
+)
+
+// GrafanaDatasource is a reference to a Grafana datasource.
+type GrafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// GrafanaGridPos is the position of a panel on a Grafana dashboard.
+type GrafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// GrafanaTarget is a query rendered by a Grafana panel.
+type GrafanaTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// GrafanaPanel is a single panel on a Grafana dashboard.
+type GrafanaPanel struct {
+	ID         int                `json:"id"`
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Datasource *GrafanaDatasource `json:"datasource"`
+	GridPos    *GrafanaGridPos    `json:"gridPos"`
+	Targets    []*GrafanaTarget   `json:"targets"`
+}
+
+// GrafanaTime is the default time range of a Grafana dashboard.
+type GrafanaTime struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GrafanaDashboard is a Grafana dashboard definition that
+// can be imported into Grafana.
+type GrafanaDashboard struct {
+	UID           string          `json:"uid"`
+	Title         string          `json:"title"`
+	Tags          []string        `json:"tags"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Refresh       string          `json:"refresh"`
+	Time          *GrafanaTime    `json:"time"`
+	Panels        []*GrafanaPanel `json:"panels"`
+}
+
+// panelDefinition is the title and queries of a panel.
+type panelDefinition struct {
+	title   string
+	queries []string
+}
+
+// Dashboard returns a *GrafanaDashboard with panels for
+// each metric exposed by check:data. All panels query
+// the datasource with datasourceUID.
+func Dashboard(datasourceUID string) *GrafanaDashboard {
+	definitions := []*panelDefinition{
+		{
+			title:   "Sync Progress",
+			queries: []string{CompletedPercent.Name},
+		},
+		{
+			title:   "Time Remaining (sec)",
+			queries: []string{TimeRemainingSeconds.Name},
+		},
+		{
+			title:   "Blocks Synced / Tip",
+			queries: []string{BlocksSynced.Name, CanonicalBlocks.Name, TipIndex.Name},
+		},
+		{
+			title:   "Sync Rate (blocks/sec)",
+			queries: []string{SyncRate.Name, BlocksSyncedRate},
+		},
+		{
+			title:   "Reconciliation Coverage",
+			queries: []string{ReconciliationCoverage.Name},
+		},
+		{
+			title: "Reconciliations",
+			queries: []string{
+				fmt.Sprintf("rate(%s[5m])", ActiveReconciliationsTotal.Name),
+				fmt.Sprintf("rate(%s[5m])", InactiveReconciliationsTotal.Name),
+			},
+		},
+		{
+			title: "Reconciliation Failures",
+			queries: []string{
+				ReconciliationFailuresTotal.Name,
+				ReconciliationFailuresIncrease,
+			},
+		},
+		{
+			title: "Transactions and Operations",
+			queries: []string{
+				fmt.Sprintf("rate(%s[5m])", TransactionsTotal.Name),
+				fmt.Sprintf("rate(%s[5m])", OperationsTotal.Name),
+			},
+		},
+		{
+			title: "Orphans",
+			queries: []string{
+				OrphansTotal.Name,
+				OrphanedTransactionsTotal.Name,
+				OrphanedOperationsTotal.Name,
+			},
+		},
+		{
+			title:   "Degraded Periods",
+			queries: []string{SyncDegraded},
+		},
+	}
+
+	datasource := &GrafanaDatasource{
+		Type: "prometheus",
+		UID:  datasourceUID,
+	}
+
+	panels := make([]*GrafanaPanel, len(definitions))
+	for i, definition := range definitions {
+		targets := make([]*GrafanaTarget, len(definition.queries))
+		for j, query := range definition.queries {
+			targets[j] = &GrafanaTarget{
+				Expr:         query,
+				LegendFormat: query,
+				RefID:        string(rune('A' + j)),
+			}
+		}
+
+		panels[i] = &GrafanaPanel{
+			ID:         i + 1,
+			Type:       "timeseries",
+			Title:      definition.title,
+			Datasource: datasource,
+			GridPos: &GrafanaGridPos{
+				H: panelHeight,
+				W: panelWidth,
+				X: (i % 2) * panelWidth,
+				Y: (i / 2) * panelHeight,
+			},
+			Targets: targets,
+		}
+	}
+
+	return &GrafanaDashboard{
+		UID:           DashboardUID,
+		Title:         "rosetta-cli check:data",
+		Tags:          []string{"rosetta"},
+		SchemaVersion: dashboardSchemaVersion,
+		Refresh:       "30s",
+		Time: &GrafanaTime{
+			From: "now-6h",
+			To:   "now",
+		},
+		Panels: panels,
+	}
+}
+
+// Rule is a Prometheus recording or alerting rule.
+type Rule struct {
+	Record      string            `json:"record,omitempty"`
+	Alert       string            `json:"alert,omitempty"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RuleGroup is a named group of Prometheus rules.
+type RuleGroup struct {
+	Name  string  `json:"name"`
+	Rules []*Rule `json:"rules"`
+}
+
+// RuleGroups is a Prometheus rules file. JSON is a subset
+// of YAML, so the serialized RuleGroups can be loaded by
+// Prometheus directly.
+type RuleGroups struct {
+	Groups []*RuleGroup `json:"groups"`
+}
+
+// Rules returns the Prometheus recording and alerting
+// rules for the metrics exposed by check:data.
+func Rules() *RuleGroups {
+	return &RuleGroups{
+		Groups: []*RuleGroup{
+			{
+				Name: "rosetta-cli",
+				Rules: []*Rule{
+					{
+						Record: BlocksSyncedRate,
+						Expr:   fmt.Sprintf("rate(%s[5m])", BlocksSynced.Name),
+					},
+					{
+						Record: ReconciliationFailuresIncrease,
+						Expr:   fmt.Sprintf("increase(%s[1h])", ReconciliationFailuresTotal.Name),
+					},
+					{
+						Record: SyncDegraded,
+						Expr: fmt.Sprintf(
+							"(%s == bool 0) * (%s < bool 100)",
+							BlocksSyncedRate,
+							CompletedPercent.Name,
+						),
+					},
+					{
+						Alert: "RosettaCLISyncStalled",
+						Expr:  fmt.Sprintf("%s == 1", SyncDegraded),
+						For:   stalledDuration,
+						Labels: map[string]string{
+							"severity": "warning",
+						},
+						Annotations: map[string]string{
+							"summary": "check:data has not synced a block while behind tip",
+						},
+					},
+					{
+						Alert: "RosettaCLIReconciliationFailures",
+						Expr:  fmt.Sprintf("%s > 0", ReconciliationFailuresIncrease),
+						Labels: map[string]string{
+							"severity": "critical",
+						},
+						Annotations: map[string]string{
+							"summary": "check:data observed reconciliation failures in the last hour",
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metricNameRegex matches any metric or recording rule
+// name in a PromQL expression.
+var metricNameRegex = regexp.MustCompile(`rosetta_cli[a-z0-9_:]*`)
+
+// knownNames returns all metric and recording rule names.
+func knownNames() map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, metric := range All {
+		names[metric.Name] = struct{}{}
+	}
+
+	for _, group := range Rules().Groups {
+		for _, rule := range group.Rules {
+			if len(rule.Record) > 0 {
+				names[rule.Record] = struct{}{}
+			}
+		}
+	}
+
+	return names
+}
+
+// TestDashboard validates the serialized dashboard against the
+// subset of the Grafana dashboard schema required for import.
+func TestDashboard(t *testing.T) {
+	names := knownNames()
+
+	for _, uid := range []string{DefaultDatasourceUID, "my-prometheus"} {
+		t.Run(uid, func(t *testing.T) {
+			raw, err := json.Marshal(Dashboard(uid))
+			assert.NoError(t, err)
+
+			var dashboard map[string]interface{}
+			assert.NoError(t, json.Unmarshal(raw, &dashboard))
+			for _, field := range []string{"uid", "title", "schemaVersion", "panels"} {
+				assert.Contains(t, dashboard, field)
+			}
+			assert.NotEmpty(t, dashboard["title"])
+
+			panels, ok := dashboard["panels"].([]interface{})
+			assert.True(t, ok)
+			assert.NotEmpty(t, panels)
+
+			seenIDs := map[float64]struct{}{}
+			seenMetrics := map[string]struct{}{}
+			for _, rawPanel := range panels {
+				panel := rawPanel.(map[string]interface{})
+				for _, field := range []string{"id", "type", "title", "datasource", "gridPos", "targets"} {
+					assert.Contains(t, panel, field)
+				}
+
+				id := panel["id"].(float64)
+				assert.NotContains(t, seenIDs, id)
+				seenIDs[id] = struct{}{}
+
+				datasource := panel["datasource"].(map[string]interface{})
+				assert.Equal(t, "prometheus", datasource["type"])
+				assert.Equal(t, uid, datasource["uid"])
+
+				gridPos := panel["gridPos"].(map[string]interface{})
+				assert.True(t, gridPos["x"].(float64)+gridPos["w"].(float64) <= 24)
+
+				targets := panel["targets"].([]interface{})
+				assert.NotEmpty(t, targets)
+				seenRefIDs := map[string]struct{}{}
+				for _, rawTarget := range targets {
+					target := rawTarget.(map[string]interface{})
+					refID := target["refId"].(string)
+					assert.NotContains(t, seenRefIDs, refID)
+					seenRefIDs[refID] = struct{}{}
+
+					expr := target["expr"].(string)
+					referenced := metricNameRegex.FindAllString(expr, -1)
+					assert.NotEmpty(t, referenced)
+					for _, name := range referenced {
+						assert.Contains(t, names, name)
+						seenMetrics[name] = struct{}{}
+					}
+				}
+			}
+
+			// Every exposed metric should be on the dashboard.
+			for _, metric := range All {
+				assert.Contains(t, seenMetrics, metric.Name)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	names := knownNames()
+
+	raw, err := json.Marshal(Rules())
+	assert.NoError(t, err)
+
+	var rules RuleGroups
+	assert.NoError(t, json.Unmarshal(raw, &rules))
+	assert.NotEmpty(t, rules.Groups)
+
+	for _, group := range rules.Groups {
+		assert.NotEmpty(t, group.Name)
+		assert.NotEmpty(t, group.Rules)
+
+		for _, rule := range group.Rules {
+			// A rule is either a recording rule or an alerting rule.
+			assert.True(t, (len(rule.Record) > 0) != (len(rule.Alert) > 0))
+			assert.NotEmpty(t, rule.Expr)
+
+			for _, name := range metricNameRegex.FindAllString(rule.Expr, -1) {
+				assert.Contains(t, names, name)
+			}
+		}
+	}
+}
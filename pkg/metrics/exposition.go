@@ -0,0 +1,114 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// ContentType is the Content-Type of the Prometheus
+	// text exposition format.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Values returns the value of each Metric populated
+// in a *results.CheckDataStatus. Metrics that cannot be
+// computed yet (ex: progress before the first block is
+// synced) are omitted.
+func Values(status *results.CheckDataStatus) map[*Metric]float64 {
+	values := map[*Metric]float64{}
+	if status == nil {
+		return values
+	}
+
+	if stats := status.Stats; stats != nil {
+		values[BlocksSynced] = float64(stats.Blocks)
+		values[OrphansTotal] = float64(stats.Orphans)
+		values[TransactionsTotal] = float64(stats.Transactions)
+		values[OperationsTotal] = float64(stats.Operations)
+		values[OrphanedTransactionsTotal] = float64(stats.OrphanedTransactions)
+		values[OrphanedOperationsTotal] = float64(stats.OrphanedOperations)
+		values[ActiveReconciliationsTotal] = float64(stats.ActiveReconciliations)
+		values[InactiveReconciliationsTotal] = float64(stats.InactiveReconciliations)
+		values[ReconciliationFailuresTotal] = float64(stats.ReconciliationFailures)
+		values[ReconciliationCoverage] = stats.ReconciliationCoverage
+	}
+
+	if progress := status.Progress; progress != nil {
+		values[CanonicalBlocks] = float64(progress.Blocks)
+		values[TipIndex] = float64(progress.Tip)
+		values[CompletedPercent] = progress.Completed
+		values[SyncRate] = progress.Rate
+
+		values[TimeRemainingSeconds] = progress.TimeRemaining
+	}
+
+	return values
+}
+
+// labelValueReplacer escapes a Prometheus label value.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels returns the Prometheus labels identifying
+// network (or an empty string if network is nil).
+func labels(network *types.NetworkIdentifier) string {
+	if network == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		`{blockchain="%s",network="%s"}`,
+		labelValueReplacer.Replace(network.Blockchain),
+		labelValueReplacer.Replace(network.Network),
+	)
+}
+
+// Write writes values to w in the Prometheus text
+// exposition format, in the order of All. Each value
+// is labeled with the blockchain and network of
+// the provided network.
+func Write(w io.Writer, network *types.NetworkIdentifier, values map[*Metric]float64) error {
+	networkLabels := labels(network)
+	for _, metric := range All {
+		value, ok := values[metric]
+		if !ok {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"# HELP %s %s\n# TYPE %s %s\n%s%s %s\n",
+			metric.Name,
+			metric.Help,
+			metric.Name,
+			metric.Type,
+			metric.Name,
+			networkLabels,
+			strconv.FormatFloat(value, 'g', -1, 64),
+		); err != nil {
+			return fmt.Errorf("%w: unable to write metric %s", err, metric.Name)
+		}
+	}
+
+	return nil
+}
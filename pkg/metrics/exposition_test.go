@@ -0,0 +1,146 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+
+	var tests = map[string]struct {
+		status  *results.CheckDataStatus
+		network *types.NetworkIdentifier
+
+		expected string
+	}{
+		"nil status": {
+			expected: "",
+		},
+		"stats only": {
+			network: network,
+			status: &results.CheckDataStatus{
+				Stats: &results.CheckDataStats{
+					Blocks:                 10,
+					Orphans:                1,
+					Transactions:           20,
+					Operations:             40,
+					OrphanedTransactions:   2,
+					OrphanedOperations:     3,
+					ReconciliationFailures: 2,
+					ReconciliationCoverage: 0.5,
+				},
+			},
+			expected: `# HELP rosetta_cli_blocks_synced Number of blocks synced
+# TYPE rosetta_cli_blocks_synced counter
+rosetta_cli_blocks_synced{blockchain="bitcoin",network="mainnet"} 10
+# HELP rosetta_cli_orphans_total Number of blocks orphaned
+# TYPE rosetta_cli_orphans_total counter
+rosetta_cli_orphans_total{blockchain="bitcoin",network="mainnet"} 1
+# HELP rosetta_cli_transactions_total Number of transactions processed
+# TYPE rosetta_cli_transactions_total counter
+rosetta_cli_transactions_total{blockchain="bitcoin",network="mainnet"} 20
+# HELP rosetta_cli_operations_total Number of operations processed
+# TYPE rosetta_cli_operations_total counter
+rosetta_cli_operations_total{blockchain="bitcoin",network="mainnet"} 40
+# HELP rosetta_cli_orphaned_transactions_total Number of transactions in orphaned blocks
+# TYPE rosetta_cli_orphaned_transactions_total counter
+rosetta_cli_orphaned_transactions_total{blockchain="bitcoin",network="mainnet"} 2
+# HELP rosetta_cli_orphaned_operations_total Number of operations in orphaned blocks
+# TYPE rosetta_cli_orphaned_operations_total counter
+rosetta_cli_orphaned_operations_total{blockchain="bitcoin",network="mainnet"} 3
+# HELP rosetta_cli_active_reconciliations_total Number of reconciliations performed after seeing an account in a block
+# TYPE rosetta_cli_active_reconciliations_total counter
+rosetta_cli_active_reconciliations_total{blockchain="bitcoin",network="mainnet"} 0
+# HELP rosetta_cli_inactive_reconciliations_total Number of reconciliations performed on randomly selected accounts
+# TYPE rosetta_cli_inactive_reconciliations_total counter
+rosetta_cli_inactive_reconciliations_total{blockchain="bitcoin",network="mainnet"} 0
+# HELP rosetta_cli_reconciliation_failures_total Number of failed reconciliations
+# TYPE rosetta_cli_reconciliation_failures_total counter
+rosetta_cli_reconciliation_failures_total{blockchain="bitcoin",network="mainnet"} 2
+# HELP rosetta_cli_reconciliation_coverage Proportion of accounts that have been reconciled [0.0,1.0]
+# TYPE rosetta_cli_reconciliation_coverage gauge
+rosetta_cli_reconciliation_coverage{blockchain="bitcoin",network="mainnet"} 0.5
+`,
+		},
+		"progress only": {
+			network: network,
+			status: &results.CheckDataStatus{
+				Progress: &results.CheckDataProgress{
+					Blocks:        10,
+					Tip:           1000,
+					Completed:     1,
+					Rate:          2.5,
+					TimeRemaining: 396,
+				},
+			},
+			expected: `# HELP rosetta_cli_canonical_blocks Number of blocks synced that have not been orphaned
+# TYPE rosetta_cli_canonical_blocks gauge
+rosetta_cli_canonical_blocks{blockchain="bitcoin",network="mainnet"} 10
+# HELP rosetta_cli_tip_index Index of the current network tip
+# TYPE rosetta_cli_tip_index gauge
+rosetta_cli_tip_index{blockchain="bitcoin",network="mainnet"} 1000
+# HELP rosetta_cli_completed_percent Percentage of blocks synced to the current network tip
+# TYPE rosetta_cli_completed_percent gauge
+rosetta_cli_completed_percent{blockchain="bitcoin",network="mainnet"} 1
+# HELP rosetta_cli_sync_rate Number of blocks synced per second
+# TYPE rosetta_cli_sync_rate gauge
+rosetta_cli_sync_rate{blockchain="bitcoin",network="mainnet"} 2.5
+# HELP rosetta_cli_time_remaining_seconds Estimated number of seconds until the current network tip is synced
+# TYPE rosetta_cli_time_remaining_seconds gauge
+rosetta_cli_time_remaining_seconds{blockchain="bitcoin",network="mainnet"} 396
+`,
+		},
+		"escaped labels": {
+			network: &types.NetworkIdentifier{Blockchain: `a"b`, Network: `c\d`},
+			status: &results.CheckDataStatus{
+				Progress: &results.CheckDataProgress{
+					Tip: 1,
+				},
+			},
+			expected: `# HELP rosetta_cli_canonical_blocks Number of blocks synced that have not been orphaned
+# TYPE rosetta_cli_canonical_blocks gauge
+rosetta_cli_canonical_blocks{blockchain="a\"b",network="c\\d"} 0
+# HELP rosetta_cli_tip_index Index of the current network tip
+# TYPE rosetta_cli_tip_index gauge
+rosetta_cli_tip_index{blockchain="a\"b",network="c\\d"} 1
+# HELP rosetta_cli_completed_percent Percentage of blocks synced to the current network tip
+# TYPE rosetta_cli_completed_percent gauge
+rosetta_cli_completed_percent{blockchain="a\"b",network="c\\d"} 0
+# HELP rosetta_cli_sync_rate Number of blocks synced per second
+# TYPE rosetta_cli_sync_rate gauge
+rosetta_cli_sync_rate{blockchain="a\"b",network="c\\d"} 0
+# HELP rosetta_cli_time_remaining_seconds Estimated number of seconds until the current network tip is synced
+# TYPE rosetta_cli_time_remaining_seconds gauge
+rosetta_cli_time_remaining_seconds{blockchain="a\"b",network="c\\d"} 0
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, Write(&buf, test.network, Values(test.status)))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+// Type is the Prometheus type of a Metric.
+type Type string
+
+const (
+	// Gauge is a metric that can go up or down.
+	Gauge Type = "gauge"
+
+	// Counter is a metric that only goes up.
+	Counter Type = "counter"
+)
+
+// Metric describes a metric exposed by check:data.
+type Metric struct {
+	Name string
+	Help string
+	Type Type
+}
+
+var (
+	// BlocksSynced is the number of blocks synced.
+	BlocksSynced = &Metric{
+		Name: "rosetta_cli_blocks_synced",
+		Help: "Number of blocks synced",
+		Type: Counter,
+	}
+
+	// OrphansTotal is the number of blocks orphaned.
+	OrphansTotal = &Metric{
+		Name: "rosetta_cli_orphans_total",
+		Help: "Number of blocks orphaned",
+		Type: Counter,
+	}
+
+	// TransactionsTotal is the number of transactions processed.
+	TransactionsTotal = &Metric{
+		Name: "rosetta_cli_transactions_total",
+		Help: "Number of transactions processed",
+		Type: Counter,
+	}
+
+	// OperationsTotal is the number of operations processed.
+	OperationsTotal = &Metric{
+		Name: "rosetta_cli_operations_total",
+		Help: "Number of operations processed",
+		Type: Counter,
+	}
+
+	// OrphanedTransactionsTotal is the number of transactions
+	// in orphaned blocks.
+	OrphanedTransactionsTotal = &Metric{
+		Name: "rosetta_cli_orphaned_transactions_total",
+		Help: "Number of transactions in orphaned blocks",
+		Type: Counter,
+	}
+
+	// OrphanedOperationsTotal is the number of operations
+	// in orphaned blocks.
+	OrphanedOperationsTotal = &Metric{
+		Name: "rosetta_cli_orphaned_operations_total",
+		Help: "Number of operations in orphaned blocks",
+		Type: Counter,
+	}
+
+	// ActiveReconciliationsTotal is the number of reconciliations
+	// performed after seeing an account in a block.
+	ActiveReconciliationsTotal = &Metric{
+		Name: "rosetta_cli_active_reconciliations_total",
+		Help: "Number of reconciliations performed after seeing an account in a block",
+		Type: Counter,
+	}
+
+	// InactiveReconciliationsTotal is the number of reconciliations
+	// performed on randomly selected accounts.
+	InactiveReconciliationsTotal = &Metric{
+		Name: "rosetta_cli_inactive_reconciliations_total",
+		Help: "Number of reconciliations performed on randomly selected accounts",
+		Type: Counter,
+	}
+
+	// ReconciliationFailuresTotal is the number of failed
+	// reconciliations.
+	ReconciliationFailuresTotal = &Metric{
+		Name: "rosetta_cli_reconciliation_failures_total",
+		Help: "Number of failed reconciliations",
+		Type: Counter,
+	}
+
+	// ReconciliationCoverage is the proportion of accounts
+	// that have been reconciled.
+	ReconciliationCoverage = &Metric{
+		Name: "rosetta_cli_reconciliation_coverage",
+		Help: "Proportion of accounts that have been reconciled [0.0,1.0]",
+		Type: Gauge,
+	}
+
+	// CanonicalBlocks is the number of blocks synced that
+	// have not been orphaned.
+	CanonicalBlocks = &Metric{
+		Name: "rosetta_cli_canonical_blocks",
+		Help: "Number of blocks synced that have not been orphaned",
+		Type: Gauge,
+	}
+
+	// TipIndex is the index of the current network tip.
+	TipIndex = &Metric{
+		Name: "rosetta_cli_tip_index",
+		Help: "Index of the current network tip",
+		Type: Gauge,
+	}
+
+	// CompletedPercent is the percentage of blocks synced
+	// to the current network tip.
+	CompletedPercent = &Metric{
+		Name: "rosetta_cli_completed_percent",
+		Help: "Percentage of blocks synced to the current network tip",
+		Type: Gauge,
+	}
+
+	// SyncRate is the number of blocks synced per second.
+	SyncRate = &Metric{
+		Name: "rosetta_cli_sync_rate",
+		Help: "Number of blocks synced per second",
+		Type: Gauge,
+	}
+
+	// TimeRemainingSeconds is the estimated number of seconds
+	// until the current network tip is synced.
+	TimeRemainingSeconds = &Metric{
+		Name: "rosetta_cli_time_remaining_seconds",
+		Help: "Estimated number of seconds until the current network tip is synced",
+		Type: Gauge,
+	}
+
+	// All contains every Metric exposed by check:data.
+	All = []*Metric{
+		BlocksSynced,
+		OrphansTotal,
+		TransactionsTotal,
+		OperationsTotal,
+		OrphanedTransactionsTotal,
+		OrphanedOperationsTotal,
+		ActiveReconciliationsTotal,
+		InactiveReconciliationsTotal,
+		ReconciliationFailuresTotal,
+		ReconciliationCoverage,
+		CanonicalBlocks,
+		TipIndex,
+		CompletedPercent,
+		SyncRate,
+		TimeRemainingSeconds,
+	}
+)
@@ -0,0 +1,299 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// UntaggedTag is the tag of any account
+	// that doesn't match an entry in the
+	// account tags file.
+	UntaggedTag = "untagged"
+
+	// accountTagsPrefixSuffix is appended to an address
+	// in the account tags file to tag every address
+	// starting with it.
+	accountTagsPrefixSuffix = "*"
+
+	// accountTagsComment starts a line that is
+	// ignored in the account tags file.
+	accountTagsComment = "#"
+)
+
+var (
+	// ErrInvalidAccountTag is returned when a line
+	// in the account tags file can't be parsed.
+	ErrInvalidAccountTag = errors.New("invalid account tag")
+)
+
+// tagTrieNode is a node in a trie of
+// address prefixes.
+type tagTrieNode struct {
+	children map[byte]*tagTrieNode
+	tag      string
+}
+
+// AccountTags maps addresses (or address prefixes)
+// to tags. Looking up an address is O(1) for exact
+// matches and O(len(address)) for prefixes.
+type AccountTags struct {
+	addresses map[string]string
+	prefixes  *tagTrieNode
+}
+
+// LoadAccountTags parses the account tags file at filePath.
+// Each line contains an address (or an address prefix ending
+// with "*") and a tag separated by whitespace. Blank lines
+// and lines starting with "#" are ignored. If an address
+// matches multiple prefixes, the longest prefix is used.
+func LoadAccountTags(filePath string) (*AccountTags, error) {
+	file, err := os.Open(filePath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open account tags file", err)
+	}
+	defer file.Close()
+
+	tags := &AccountTags{
+		addresses: map[string]string{},
+		prefixes:  &tagTrieNode{},
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, accountTagsComment) {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf(
+				"%w: line %d: expected an address and a tag but found %q",
+				ErrInvalidAccountTag,
+				line,
+				text,
+			)
+		}
+
+		address, tag := fields[0], fields[1]
+		if !strings.HasSuffix(address, accountTagsPrefixSuffix) {
+			if existing, ok := tags.addresses[address]; ok && existing != tag {
+				return nil, fmt.Errorf(
+					"%w: line %d: %s is already tagged %s",
+					ErrInvalidAccountTag,
+					line,
+					address,
+					existing,
+				)
+			}
+
+			tags.addresses[address] = tag
+			continue
+		}
+
+		prefix := strings.TrimSuffix(address, accountTagsPrefixSuffix)
+		if len(prefix) == 0 {
+			return nil, fmt.Errorf("%w: line %d: prefix is empty", ErrInvalidAccountTag, line)
+		}
+
+		node := tags.prefixes
+		for i := 0; i < len(prefix); i++ {
+			if node.children == nil {
+				node.children = map[byte]*tagTrieNode{}
+			}
+
+			child, ok := node.children[prefix[i]]
+			if !ok {
+				child = &tagTrieNode{}
+				node.children[prefix[i]] = child
+			}
+			node = child
+		}
+
+		if len(node.tag) > 0 && node.tag != tag {
+			return nil, fmt.Errorf(
+				"%w: line %d: prefix %s is already tagged %s",
+				ErrInvalidAccountTag,
+				line,
+				prefix,
+				node.tag,
+			)
+		}
+		node.tag = tag
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: unable to read account tags file", err)
+	}
+
+	return tags, nil
+}
+
+// Tag returns the tag of address or
+// UntaggedTag if it isn't tagged.
+func (a *AccountTags) Tag(address string) string {
+	if tag, ok := a.addresses[address]; ok {
+		return tag
+	}
+
+	tag := UntaggedTag
+	node := a.prefixes
+	for i := 0; i < len(address); i++ {
+		child, ok := node.children[address[i]]
+		if !ok {
+			break
+		}
+
+		node = child
+		if len(node.tag) > 0 {
+			tag = node.tag
+		}
+	}
+
+	return tag
+}
+
+// tagCounts are the reconciliation
+// outcomes of accounts with a tag.
+type tagCounts struct {
+	reconciliations int64
+	failures        int64
+	reconciled      map[string]struct{}
+}
+
+// TagReconciliation records reconciliation
+// outcomes for each account tag.
+type TagReconciliation struct {
+	tags *AccountTags
+
+	counts map[string]*tagCounts
+	mutex  sync.Mutex
+}
+
+// NewTagReconciliation returns a new
+// *TagReconciliation using tags.
+func NewTagReconciliation(tags *AccountTags) *TagReconciliation {
+	return &TagReconciliation{
+		tags:   tags,
+		counts: map[string]*tagCounts{},
+	}
+}
+
+// Reconciled records the outcome of a
+// reconciliation of an account and currency.
+func (r *TagReconciliation) Reconciled(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	success bool,
+) {
+	if r == nil {
+		return
+	}
+
+	tag := r.tags.Tag(account.Address)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	counts, ok := r.counts[tag]
+	if !ok {
+		counts = &tagCounts{reconciled: map[string]struct{}{}}
+		r.counts[tag] = counts
+	}
+
+	counts.reconciliations++
+	if !success {
+		counts.failures++
+		return
+	}
+
+	counts.reconciled[types.Hash(&reconciler.AccountCurrency{
+		Account:  account,
+		Currency: currency,
+	})] = struct{}{}
+}
+
+// Results returns the reconciliation outcomes of
+// each tag (sorted by tag). The number of accounts
+// with each tag is counted from balanceStorage.
+func (r *TagReconciliation) Results(
+	ctx context.Context,
+	balanceStorage *storage.BalanceStorage,
+) (results.TagReconciliationResults, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	accounts := map[string]int64{}
+	if balanceStorage != nil {
+		accountCurrencies, err := balanceStorage.GetAllAccountCurrency(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get accounts", err)
+		}
+
+		for _, accountCurrency := range accountCurrencies {
+			accounts[r.tags.Tag(accountCurrency.Account.Address)]++
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tags := make([]string, 0, len(accounts))
+	for tag := range accounts {
+		tags = append(tags, tag)
+	}
+	for tag := range r.counts {
+		if _, ok := accounts[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	tagResults := make(results.TagReconciliationResults, len(tags))
+	for i, tag := range tags {
+		tagResult := &results.TagReconciliationResult{
+			Tag:      tag,
+			Accounts: accounts[tag],
+		}
+
+		if counts, ok := r.counts[tag]; ok {
+			tagResult.Reconciliations = counts.reconciliations
+			tagResult.Failures = counts.failures
+			if tagResult.Accounts > 0 {
+				tagResult.Coverage = float64(len(counts.reconciled)) / float64(tagResult.Accounts)
+			}
+		}
+
+		tagResults[i] = tagResult
+	}
+
+	return tagResults, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadAccountTags(t *testing.T) {
+	var tests = map[string]struct {
+		contents string
+
+		tags map[string]string
+		err  string
+	}{
+		"addresses and prefixes": {
+			contents: `# exchanges
+addr1 exchange
+addr2	exchange
+
+0xc* contract
+0xcafe* validator
+`,
+			tags: map[string]string{
+				"addr1":    "exchange",
+				"addr2":    "exchange",
+				"addr3":    UntaggedTag,
+				"0xc123":   "contract",
+				"0xcafe12": "validator",
+				"0xcaf":    "contract",
+				"0x":       UntaggedTag,
+			},
+		},
+		"exact match before prefix": {
+			contents: "0x* contract\n0x1 user\n",
+			tags: map[string]string{
+				"0x1":  "user",
+				"0x12": "contract",
+			},
+		},
+		"duplicate entry with same tag": {
+			contents: "addr1 exchange\naddr1 exchange\n",
+			tags: map[string]string{
+				"addr1": "exchange",
+			},
+		},
+		"missing tag": {
+			contents: "addr1 exchange\n\naddr2\n",
+			err:      "line 3: expected an address and a tag",
+		},
+		"too many fields": {
+			contents: "addr1 exchange user\n",
+			err:      "line 1: expected an address and a tag",
+		},
+		"empty prefix": {
+			contents: "# everything\n* user\n",
+			err:      "line 2: prefix is empty",
+		},
+		"conflicting address": {
+			contents: "addr1 exchange\naddr1 user\n",
+			err:      "line 2: addr1 is already tagged exchange",
+		},
+		"conflicting prefix": {
+			contents: "0x* contract\n0x* user\n",
+			err:      "line 2: prefix 0x is already tagged contract",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "tags.txt")
+			assert.NoError(t, ioutil.WriteFile(
+				filePath,
+				[]byte(test.contents),
+				os.FileMode(utils.DefaultFilePermissions),
+			))
+
+			tags, err := LoadAccountTags(filePath)
+			if len(test.err) > 0 {
+				assert.Nil(t, tags)
+				assert.True(t, errors.Is(err, ErrInvalidAccountTag))
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			for address, tag := range test.tags {
+				assert.Equal(t, tag, tags.Tag(address), address)
+			}
+		})
+	}
+}
+
+func TestLoadAccountTags_MissingFile(t *testing.T) {
+	tags, err := LoadAccountTags(path.Join("not", "a", "file"))
+	assert.Nil(t, tags)
+	assert.Error(t, err)
+}
+
+func TestTagReconciliation(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	tagsPath := path.Join(dir, "tags.txt")
+	assert.NoError(t, ioutil.WriteFile(
+		tagsPath,
+		[]byte("exchange1 exchange\n0xc* contract\n"),
+		os.FileMode(utils.DefaultFilePermissions),
+	))
+	tags, err := LoadAccountTags(tagsPath)
+	assert.NoError(t, err)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		path.Join(dir, "db"),
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	balanceStorage := storage.NewBalanceStorage(localStore)
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	block := &types.BlockIdentifier{Index: 0, Hash: "block 0"}
+	accounts := []*types.AccountIdentifier{
+		{Address: "exchange1"},
+		{Address: "0xc1"},
+		{Address: "0xc2"},
+		{Address: "user1"},
+	}
+	for _, account := range accounts {
+		dbTx := localStore.NewDatabaseTransaction(ctx, true)
+		assert.NoError(t, balanceStorage.SetBalance(
+			ctx,
+			dbTx,
+			account,
+			&types.Amount{Value: "1", Currency: currency},
+			block,
+		))
+		assert.NoError(t, dbTx.Commit(ctx))
+	}
+
+	var nilTags *TagReconciliation
+	nilTags.Reconciled(accounts[0], currency, true)
+	nilResults, err := nilTags.Results(ctx, balanceStorage)
+	assert.NoError(t, err)
+	assert.Nil(t, nilResults)
+
+	r := NewTagReconciliation(tags)
+	r.Reconciled(accounts[0], currency, true)
+	r.Reconciled(accounts[0], currency, true)
+	r.Reconciled(accounts[1], currency, true)
+	r.Reconciled(accounts[2], currency, false)
+	r.Reconciled(&types.AccountIdentifier{Address: "0xc3"}, currency, false)
+
+	tagResults, err := r.Results(ctx, balanceStorage)
+	assert.NoError(t, err)
+	assert.Equal(t, results.TagReconciliationResults{
+		{
+			Tag:             "contract",
+			Accounts:        2,
+			Reconciliations: 3,
+			Failures:        2,
+			Coverage:        0.5,
+		},
+		{
+			Tag:             "exchange",
+			Accounts:        1,
+			Reconciliations: 2,
+			Coverage:        1,
+		},
+		{
+			Tag:      UntaggedTag,
+			Accounts: 1,
+		},
+	}, tagResults)
+	tagResults.Print() // make sure doesn't panic
+}
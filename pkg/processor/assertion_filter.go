@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// blockEndpoint is the path of the /block endpoint.
+	blockEndpoint = "/block"
+)
+
+var _ http.RoundTripper = (*AssertionFilter)(nil)
+
+// AssertionFilter is an http.RoundTripper that removes
+// any transactions that fail assertion from /block responses
+// (recording each failure in a *results.AssertionCatalog) so
+// that syncing can continue past them. Failures at the block
+// level (ex: an invalid block identifier) are not filtered.
+// Blocks at or below the trusted checkpoint (if provided)
+// are not asserted, so they are not filtered.
+type AssertionFilter struct {
+	transport  http.RoundTripper
+	fetcher    *fetcher.Fetcher
+	catalog    *results.AssertionCatalog
+	checkpoint *configuration.TrustedCheckpoint
+}
+
+// NewAssertionFilter returns a new *AssertionFilter that
+// wraps transport. Responses are not filtered until the
+// fetcher's asserter is initialized.
+func NewAssertionFilter(
+	transport http.RoundTripper,
+	fetcher *fetcher.Fetcher,
+	catalog *results.AssertionCatalog,
+	checkpoint *configuration.TrustedCheckpoint,
+) *AssertionFilter {
+	return &AssertionFilter{
+		transport:  transport,
+		fetcher:    fetcher,
+		catalog:    catalog,
+		checkpoint: checkpoint,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (a *AssertionFilter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := a.transport.RoundTrip(req)
+	if err != nil ||
+		resp.StatusCode != http.StatusOK ||
+		!strings.HasSuffix(req.URL.Path, blockEndpoint) ||
+		a.fetcher.Asserter == nil {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// We leave any response we can't parse to the fetcher
+	// to reject.
+	var blockResponse types.BlockResponse
+	if err := json.Unmarshal(body, &blockResponse); err == nil &&
+		a.filterBlock(blockResponse.Block) {
+		filtered, err := json.Marshal(blockResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		body = filtered
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return resp, nil
+}
+
+// filterBlock removes any transactions from block that
+// fail assertion and returns a boolean indicating if any
+// transactions were removed.
+func (a *AssertionFilter) filterBlock(block *types.Block) bool {
+	if block == nil || block.BlockIdentifier == nil {
+		return false
+	}
+
+	if a.checkpoint != nil && block.BlockIdentifier.Index <= a.checkpoint.Index {
+		return false
+	}
+
+	filtered := false
+	transactions := []*types.Transaction{}
+	for _, transaction := range block.Transactions {
+		err := a.fetcher.Asserter.Transaction(transaction)
+		if err == nil {
+			transactions = append(transactions, transaction)
+			continue
+		}
+
+		// A nil transaction is a block-level problem that
+		// should still be surfaced by the fetcher.
+		if transaction == nil {
+			transactions = append(transactions, transaction)
+			continue
+		}
+
+		accounts := []*types.AccountIdentifier{}
+		for _, op := range transaction.Operations {
+			if op != nil && op.Account != nil {
+				accounts = append(accounts, op.Account)
+			}
+		}
+
+		a.catalog.Add(block.BlockIdentifier, transaction.TransactionIdentifier, err, accounts)
+		filtered = true
+	}
+
+	block.Transactions = transactions
+	return filtered
+}
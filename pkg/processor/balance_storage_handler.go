@@ -16,8 +16,11 @@ package processor
 
 import (
 	"context"
+	"log"
+	"math/big"
 
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
@@ -31,8 +34,12 @@ var _ storage.BalanceStorageHandler = (*BalanceStorageHandler)(nil)
 // or removed from block storage so that balance changes
 // can be sent to other functions (ex: reconciler).
 type BalanceStorageHandler struct {
-	logger     *logger.Logger
-	reconciler *reconciler.Reconciler
+	logger             *logger.Logger
+	reconciler         *reconciler.Reconciler
+	reconcilerHelper   *ReconcilerHelper
+	counterStorage     *storage.CounterStorage
+	trackedAccounts    *TrackedAccounts
+	historicalBalances *HistoricalBalanceChecker
 
 	reconcile          bool
 	interestingAccount *reconciler.AccountCurrency
@@ -42,12 +49,20 @@ type BalanceStorageHandler struct {
 func NewBalanceStorageHandler(
 	logger *logger.Logger,
 	reconciler *reconciler.Reconciler,
+	reconcilerHelper *ReconcilerHelper,
+	counterStorage *storage.CounterStorage,
+	trackedAccounts *TrackedAccounts,
+	historicalBalances *HistoricalBalanceChecker,
 	reconcile bool,
 	interestingAccount *reconciler.AccountCurrency,
 ) *BalanceStorageHandler {
 	return &BalanceStorageHandler{
 		logger:             logger,
 		reconciler:         reconciler,
+		reconcilerHelper:   reconcilerHelper,
+		counterStorage:     counterStorage,
+		trackedAccounts:    trackedAccounts,
+		historicalBalances: historicalBalances,
 		reconcile:          reconcile,
 		interestingAccount: interestingAccount,
 	}
@@ -61,6 +76,27 @@ func (h *BalanceStorageHandler) BlockAdded(
 ) error {
 	_ = h.logger.BalanceStream(ctx, changes)
 
+	// Balance changes are counted so that balance tracking is only
+	// considered tested if balances in a tracked currency changed.
+	if h.counterStorage != nil && len(changes) > 0 {
+		_, err := h.counterStorage.Update(
+			ctx,
+			results.TrackedBalanceChangeCounter,
+			big.NewInt(int64(len(changes))),
+		)
+		if err != nil {
+			log.Printf("%s: unable to update tracked balance change counter\n", err.Error())
+		}
+	}
+
+	if err := h.trackedAccounts.Add(ctx, changes); err != nil {
+		log.Printf("%s: unable to update tracked accounts\n", err.Error())
+	}
+
+	if err := h.historicalBalances.Sample(ctx, block.BlockIdentifier, changes); err != nil {
+		log.Printf("%s: unable to sample historical balances\n", err.Error())
+	}
+
 	// When testing, it can be useful to not run any reconciliations to just check
 	// if blocks are well formatted and balances don't go negative.
 	if !h.reconcile {
@@ -91,7 +127,7 @@ func (h *BalanceStorageHandler) BlockAdded(
 
 	// Mark accounts for reconciliation...this may be
 	// blocking
-	return h.reconciler.QueueChanges(ctx, block.BlockIdentifier, changes)
+	return h.reconcilerHelper.QueueChanges(ctx, h.reconciler, block.BlockIdentifier, changes)
 }
 
 // BlockRemoved is called whenever a block is removed from BlockStorage.
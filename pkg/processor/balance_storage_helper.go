@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/parser"
@@ -39,9 +41,17 @@ type BalanceStorageHelper struct {
 	lookupBalanceByBlock bool
 	exemptAccounts       map[string]struct{}
 
+	// trackedCurrencies is empty if balances
+	// in all currencies are tracked.
+	trackedCurrencies map[string]struct{}
+
 	// Interesting-only Parsing
 	interestingOnly      bool
 	interestingAddresses map[string]struct{}
+
+	// Accounts tainted by transactions that failed
+	// assertion are not tracked.
+	assertionCatalog *results.AssertionCatalog
 }
 
 // NewBalanceStorageHelper returns a new BalanceStorageHelper.
@@ -50,7 +60,9 @@ func NewBalanceStorageHelper(
 	fetcher *fetcher.Fetcher,
 	lookupBalanceByBlock bool,
 	exemptAccounts []*reconciler.AccountCurrency,
+	currencies []*types.Currency,
 	interestingOnly bool,
+	assertionCatalog *results.AssertionCatalog,
 ) *BalanceStorageHelper {
 	exemptMap := map[string]struct{}{}
 
@@ -60,13 +72,20 @@ func NewBalanceStorageHelper(
 		exemptMap[types.Hash(account)] = struct{}{}
 	}
 
+	trackedCurrencies := map[string]struct{}{}
+	for _, currency := range currencies {
+		trackedCurrencies[types.Hash(currency)] = struct{}{}
+	}
+
 	return &BalanceStorageHelper{
 		network:              network,
 		fetcher:              fetcher,
 		lookupBalanceByBlock: lookupBalanceByBlock,
 		exemptAccounts:       exemptMap,
+		trackedCurrencies:    trackedCurrencies,
 		interestingAddresses: map[string]struct{}{},
 		interestingOnly:      interestingOnly,
+		assertionCatalog:     assertionCatalog,
 	}
 }
 
@@ -128,12 +147,23 @@ func (h *BalanceStorageHelper) ExemptFunc() parser.ExemptOperation {
 			}
 		}
 
+		// Operations in currencies that are not tracked
+		// are skipped (so they are never reconciled).
+		if len(h.trackedCurrencies) > 0 {
+			if _, exists := h.trackedCurrencies[types.Hash(op.Amount.Currency)]; !exists {
+				return true
+			}
+		}
+
 		thisAcct := types.Hash(&reconciler.AccountCurrency{
 			Account:  op.Account,
 			Currency: op.Amount.Currency,
 		})
 
-		_, exists := h.exemptAccounts[thisAcct]
-		return exists
+		if _, exists := h.exemptAccounts[thisAcct]; exists {
+			return true
+		}
+
+		return h.assertionCatalog.Tainted(op.Account)
 	}
 }
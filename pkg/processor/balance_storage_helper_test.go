@@ -83,7 +83,9 @@ func TestExemptFuncExemptAccounts(t *testing.T) {
 				nil,
 				false,
 				test.exemptAccounts,
+				nil,
 				false,
+				nil,
 			)
 
 			result := helper.ExemptFunc()(&types.Operation{
@@ -124,7 +126,9 @@ func TestExemptFuncInterestingParsing(t *testing.T) {
 				nil,
 				false,
 				nil,
+				nil,
 				true,
+				nil,
 			)
 
 			for _, addr := range test.interestingAddresses {
@@ -143,3 +147,54 @@ func TestExemptFuncInterestingParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestExemptFuncCurrencies(t *testing.T) {
+	var tests = map[string]struct {
+		currencies []*types.Currency
+		exempt     bool
+	}{
+		"no currencies": {},
+		"currency tracked": {
+			currencies: []*types.Currency{
+				{Symbol: "ETH", Decimals: 18},
+				opAmountCurrency.Currency,
+			},
+		},
+		"currency not tracked": {
+			currencies: []*types.Currency{
+				{Symbol: "ETH", Decimals: 18},
+			},
+			exempt: true,
+		},
+		"decimals differ": {
+			currencies: []*types.Currency{
+				{Symbol: "BTC", Decimals: 6},
+			},
+			exempt: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			helper := NewBalanceStorageHelper(
+				nil,
+				nil,
+				false,
+				nil,
+				test.currencies,
+				false,
+				nil,
+			)
+
+			result := helper.ExemptFunc()(&types.Operation{
+				Account: opAmountCurrency.Account,
+				Amount: &types.Amount{
+					Value:    "100",
+					Currency: opAmountCurrency.Currency,
+				},
+			})
+
+			assert.Equal(t, test.exempt, result)
+		})
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// payloadSamples is the most payload sizes kept to
+	// estimate the p99 payload size. Once exceeded, a
+	// uniform sample of all sizes is kept (reservoir
+	// sampling).
+	payloadSamples = 10000
+
+	// payloadPercentile is the percentile of
+	// payload sizes reported.
+	payloadPercentile = 0.99
+
+	// largestPayloads is the number of largest
+	// responses reported.
+	largestPayloads = 5
+
+	// payloadViolationLimit is the most violations
+	// of max_block_payload_bytes reported (all
+	// violations are counted).
+	payloadViolationLimit = 100
+
+	// gzipEncoding is the content encoding
+	// of gzip compressed responses.
+	gzipEncoding = "gzip"
+)
+
+var _ http.RoundTripper = (*BlockPayloads)(nil)
+
+// BlockPayloads is an http.RoundTripper that records the size
+// of each /block response as it is read from the connection.
+// If a request does not specify an Accept-Encoding, gzip is
+// requested (as http.Transport does by default) and responses
+// are decompressed here, so both the bytes on the wire and the
+// decompressed size are known.
+type BlockPayloads struct {
+	transport http.RoundTripper
+	maxBytes  int64
+
+	count          int64
+	total          int64
+	wireTotal      int64
+	max            int64
+	samples        []int64
+	largest        []*results.BlockPayload
+	violationCount int64
+	violations     []*results.BlockPayload
+	random         *rand.Rand
+	mutex          sync.Mutex
+}
+
+// NewBlockPayloads returns a new *BlockPayloads that
+// wraps transport. If transport is nil,
+// http.DefaultTransport is used. Responses larger than
+// maxBytes are recorded as violations (if maxBytes is 0,
+// there is no maximum).
+func NewBlockPayloads(transport http.RoundTripper, maxBytes int64) *BlockPayloads {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &BlockPayloads{
+		transport: transport,
+		maxBytes:  maxBytes,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (b *BlockPayloads) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, blockEndpoint) {
+		return b.transport.RoundTrip(req)
+	}
+
+	block := requestedBlock(req)
+
+	// http.Transport only decompresses responses transparently
+	// (hiding the bytes on the wire) if it requested gzip itself.
+	requestedGzip := false
+	if len(req.Header.Get("Accept-Encoding")) == 0 {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", gzipEncoding)
+		requestedGzip = true
+	}
+
+	resp, err := b.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	wire := &countingReader{reader: resp.Body}
+	body := &payloadBody{
+		body:    resp.Body,
+		wire:    wire,
+		decoded: wire,
+		record: func(bytes int64, wireBytes int64) {
+			b.record(&results.BlockPayload{
+				Block:     block,
+				Bytes:     bytes,
+				WireBytes: wireBytes,
+			})
+		},
+	}
+
+	if requestedGzip && resp.Header.Get("Content-Encoding") == gzipEncoding {
+		gzipReader, err := gzip.NewReader(wire)
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+
+		body.decoded = &countingReader{reader: gzipReader}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+
+	resp.Body = body
+	return resp, nil
+}
+
+// requestedBlock returns the block requested in a /block
+// request (or nil if the request can't be parsed).
+func requestedBlock(req *http.Request) *types.PartialBlockIdentifier {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	var blockRequest types.BlockRequest
+	if err := json.NewDecoder(body).Decode(&blockRequest); err != nil {
+		return nil
+	}
+
+	return blockRequest.BlockIdentifier
+}
+
+// largerPayload returns a boolean indicating if a should be
+// listed before b in the largest payloads. Payloads of the same
+// size are ordered by block index so that the largest payloads
+// do not depend on the order blocks were fetched in.
+func largerPayload(a *results.BlockPayload, b *results.BlockPayload) bool {
+	if a.Bytes != b.Bytes {
+		return a.Bytes > b.Bytes
+	}
+
+	return payloadIndex(a) < payloadIndex(b)
+}
+
+// payloadIndex returns the index of the block
+// requested by payload (or -1 if it is unknown).
+func payloadIndex(payload *results.BlockPayload) int64 {
+	if payload.Block == nil || payload.Block.Index == nil {
+		return -1
+	}
+
+	return *payload.Block.Index
+}
+
+// record adds a /block response of payload.Bytes
+// (payload.WireBytes on the wire).
+func (b *BlockPayloads) record(payload *results.BlockPayload) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.count++
+	b.total += payload.Bytes
+	b.wireTotal += payload.WireBytes
+	if payload.Bytes > b.max {
+		b.max = payload.Bytes
+	}
+
+	if len(b.samples) < payloadSamples {
+		b.samples = append(b.samples, payload.Bytes)
+	} else if i := b.random.Int63n(b.count); i < payloadSamples {
+		b.samples[i] = payload.Bytes
+	}
+
+	if len(b.largest) < largestPayloads ||
+		largerPayload(payload, b.largest[len(b.largest)-1]) {
+		b.largest = append(b.largest, payload)
+		sort.Slice(b.largest, func(i, j int) bool {
+			return largerPayload(b.largest[i], b.largest[j])
+		})
+		if len(b.largest) > largestPayloads {
+			b.largest = b.largest[:largestPayloads]
+		}
+	}
+
+	if b.maxBytes == 0 || payload.Bytes <= b.maxBytes {
+		return
+	}
+
+	b.violationCount++
+	if len(b.violations) < payloadViolationLimit {
+		b.violations = append(b.violations, payload)
+		logging.Warn(
+			"block payload exceeds max_block_payload_bytes",
+			logging.Fields{
+				"block":                   payload.Block,
+				"bytes":                   payload.Bytes,
+				"max_block_payload_bytes": b.maxBytes,
+			},
+		)
+	}
+}
+
+// Results returns the *results.BlockPayloadStats of all
+// /block responses read (or nil if none were read).
+func (b *BlockPayloads) Results() *results.BlockPayloadStats {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.count == 0 {
+		return nil
+	}
+
+	samples := make([]int64, len(b.samples))
+	copy(samples, b.samples)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	// We use the nearest-rank method to
+	// select the percentile.
+	rank := int(math.Ceil(float64(len(samples))*payloadPercentile)) - 1
+
+	largest := make([]*results.BlockPayload, len(b.largest))
+	copy(largest, b.largest)
+
+	var violations []*results.BlockPayload
+	if len(b.violations) > 0 {
+		violations = make([]*results.BlockPayload, len(b.violations))
+		copy(violations, b.violations)
+	}
+
+	return &results.BlockPayloadStats{
+		Count:                b.count,
+		Mean:                 float64(b.total) / float64(b.count),
+		P99:                  samples[rank],
+		Max:                  b.max,
+		WireBytes:            b.wireTotal,
+		Largest:              largest,
+		MaxBlockPayloadBytes: b.maxBytes,
+		ViolationCount:       b.violationCount,
+		Violations:           violations,
+	}
+}
+
+// countingReader counts the bytes read from reader.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read implements the io.Reader interface.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// payloadBody is the body of a /block response. Once the
+// entire body is read, its size is recorded (a body that is
+// closed before it is entirely read is not recorded).
+type payloadBody struct {
+	body    io.ReadCloser
+	wire    *countingReader
+	decoded *countingReader
+	record  func(bytes int64, wireBytes int64)
+	once    sync.Once
+}
+
+// Read implements the io.Reader interface.
+func (p *payloadBody) Read(b []byte) (int, error) {
+	n, err := p.decoded.Read(b)
+	if err == io.EOF {
+		p.once.Do(func() {
+			p.record(p.decoded.n, p.wire.n)
+		})
+	}
+
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (p *payloadBody) Close() error {
+	return p.body.Close()
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockPayloads(t *testing.T) {
+	var tests = map[string]struct {
+		gzip bool
+	}{
+		"uncompressed": {},
+		"gzip":         {gzip: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/block" {
+					return
+				}
+
+				var request types.BlockRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+				// The size of each block grows with its index.
+				index := *request.BlockIdentifier.Index
+				body, err := json.Marshal(&types.BlockResponse{
+					Block: &types.Block{
+						BlockIdentifier: &types.BlockIdentifier{
+							Index: index,
+							Hash:  strings.Repeat("a", int(index)*1000),
+						},
+					},
+				})
+				assert.NoError(t, err)
+
+				if !test.gzip {
+					_, err = w.Write(body)
+					assert.NoError(t, err)
+					return
+				}
+
+				assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+				w.Header().Set("Content-Encoding", "gzip")
+				writer := gzip.NewWriter(w)
+				_, err = writer.Write(body)
+				assert.NoError(t, err)
+				assert.NoError(t, writer.Close())
+			}))
+			defer server.Close()
+
+			var nilPayloads *BlockPayloads
+			assert.Nil(t, nilPayloads.Results())
+
+			b := NewBlockPayloads(nil, 4500)
+			assert.Nil(t, b.Results())
+
+			client := &http.Client{Transport: b}
+			sizes := map[int64]int64{}
+			for i := int64(1); i <= 10; i++ {
+				request, err := json.Marshal(&types.BlockRequest{
+					BlockIdentifier: &types.PartialBlockIdentifier{Index: &i},
+				})
+				assert.NoError(t, err)
+
+				resp, err := client.Post(server.URL+"/block", "application/json", bytes.NewReader(request))
+				assert.NoError(t, err)
+				body, err := ioutil.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, resp.Body.Close())
+
+				// The response is always decompressed.
+				var blockResponse types.BlockResponse
+				assert.NoError(t, json.Unmarshal(body, &blockResponse))
+				assert.Equal(t, i, blockResponse.Block.BlockIdentifier.Index)
+				sizes[i] = int64(len(body))
+			}
+
+			// Other endpoints are not recorded
+			resp, err := client.Post(server.URL+"/block/transaction", "application/json", nil)
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+
+			stats := b.Results()
+			assert.Equal(t, int64(10), stats.Count)
+			assert.Equal(t, sizes[10], stats.Max)
+			assert.Equal(t, sizes[10], stats.P99)
+			assert.Len(t, stats.Largest, largestPayloads)
+			for j, payload := range stats.Largest {
+				index := int64(10 - j)
+				assert.Equal(t, index, *payload.Block.Index)
+				assert.Equal(t, sizes[index], payload.Bytes)
+				if test.gzip {
+					assert.Less(t, payload.WireBytes, payload.Bytes)
+				} else {
+					assert.Equal(t, payload.Bytes, payload.WireBytes)
+				}
+			}
+
+			// Blocks 5-10 are larger than 4500 bytes
+			assert.Equal(t, int64(4500), stats.MaxBlockPayloadBytes)
+			assert.Equal(t, int64(6), stats.ViolationCount)
+			assert.Len(t, stats.Violations, 6)
+
+			(&results.CheckDataStats{BlockPayloads: stats}).Print() // make sure doesn't panic
+		})
+	}
+}
+
+func TestBlockPayloads_Sampling(t *testing.T) {
+	b := NewBlockPayloads(nil, 0)
+	requests := 2 * payloadSamples
+	for i := 1; i <= requests; i++ {
+		b.record(&results.BlockPayload{Bytes: int64(i), WireBytes: int64(i)})
+	}
+
+	assert.Len(t, b.samples, payloadSamples)
+
+	stats := b.Results()
+	assert.Equal(t, int64(requests), stats.Count)
+	assert.Equal(t, int64(requests), stats.Max)
+	assert.InDelta(t, float64(requests)/2, stats.Mean, 1)
+	assert.Equal(t, int64(0), stats.ViolationCount)
+	assert.Nil(t, stats.Violations)
+
+	// The p99 size is estimated from a
+	// uniform sample of all sizes.
+	assert.InDelta(t, 0.99*float64(requests), float64(stats.P99), 0.01*float64(requests))
+
+	assert.Len(t, stats.Largest, largestPayloads)
+	for j, payload := range stats.Largest {
+		assert.Equal(t, int64(requests-j), payload.Bytes)
+	}
+}
+
+func TestBlockPayloads_LargestTies(t *testing.T) {
+	indexes := []int64{7, 3, 9, 1, 5, 8, 2}
+	payloads := func(order []int64) []*results.BlockPayload {
+		b := NewBlockPayloads(nil, 0)
+		for _, index := range order {
+			index := index
+			b.record(&results.BlockPayload{
+				Block: &types.PartialBlockIdentifier{Index: &index},
+				Bytes: 100,
+			})
+		}
+
+		return b.Results().Largest
+	}
+
+	// Payloads of the same size are ordered by block index,
+	// regardless of the order they were fetched in.
+	largest := payloads(indexes)
+	assert.Len(t, largest, largestPayloads)
+	for j, index := range []int64{1, 2, 3, 5, 7} {
+		assert.Equal(t, index, *largest[j].Block.Index)
+	}
+
+	reversed := make([]int64, len(indexes))
+	for i, index := range indexes {
+		reversed[len(indexes)-1-i] = index
+	}
+	assert.Equal(t, largest, payloads(reversed))
+}
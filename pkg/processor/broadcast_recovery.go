@@ -0,0 +1,362 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// inflightBroadcastNamespace is prepended to
+// the job identifier of each InflightBroadcast.
+const inflightBroadcastNamespace = "inflight-broadcast"
+
+func getInflightBroadcastKey(identifier string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", inflightBroadcastNamespace, identifier))
+}
+
+// InflightBroadcast is a transaction that was queued
+// for broadcast by a job and has not yet been confirmed
+// (or failed).
+type InflightBroadcast struct {
+	Identifier            string                       `json:"identifier"`
+	Workflow              string                       `json:"workflow"`
+	Stage                 int                          `json:"stage"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Senders               []*types.AccountIdentifier   `json:"senders"`
+	Coins                 []*types.CoinIdentifier      `json:"coins,omitempty"`
+
+	// Submitted is the unix timestamp (in milliseconds)
+	// of the last time /construction/submit accepted the
+	// transaction (or 0 if it was never accepted).
+	Submitted int64 `json:"submitted,omitempty"`
+}
+
+// BroadcastCompleter is called to complete the job that
+// created a broadcast. coordinator.Coordinator implements
+// this interface.
+type BroadcastCompleter interface {
+	BroadcastComplete(
+		ctx context.Context,
+		dbTx storage.DatabaseTransaction,
+		jobIdentifier string,
+		transaction *types.Transaction,
+	) error
+}
+
+// BroadcastRecovery records each transaction queued for
+// broadcast by check:construction so that transactions
+// that were in flight when a run stopped can be reconciled
+// against the chain when the next run starts.
+type BroadcastRecovery struct {
+	network          *types.NetworkIdentifier
+	fetcher          *fetcher.Fetcher
+	database         storage.Database
+	blockStorage     *storage.BlockStorage
+	broadcastStorage *storage.BroadcastStorage
+	jobStorage       *storage.JobStorage
+}
+
+// NewBroadcastRecovery returns a new *BroadcastRecovery.
+func NewBroadcastRecovery(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	database storage.Database,
+	blockStorage *storage.BlockStorage,
+	broadcastStorage *storage.BroadcastStorage,
+	jobStorage *storage.JobStorage,
+) *BroadcastRecovery {
+	return &BroadcastRecovery{
+		network:          network,
+		fetcher:          fetcher,
+		database:         database,
+		blockStorage:     blockStorage,
+		broadcastStorage: broadcastStorage,
+		jobStorage:       jobStorage,
+	}
+}
+
+// Record stores an *InflightBroadcast for the job identifier
+// in the same database transaction that queues the broadcast.
+func (r *BroadcastRecovery) Record(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+	intent []*types.Operation,
+	transactionIdentifier *types.TransactionIdentifier,
+) error {
+	j, err := r.jobStorage.Get(ctx, dbTx, identifier)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get job %s", err, identifier)
+	}
+
+	inflight := &InflightBroadcast{
+		Identifier:            identifier,
+		Workflow:              j.Workflow,
+		Stage:                 j.Index,
+		TransactionIdentifier: transactionIdentifier,
+		Senders:               []*types.AccountIdentifier{},
+	}
+
+	senders := map[string]struct{}{}
+	for _, op := range intent {
+		if op.CoinChange != nil && op.CoinChange.CoinAction == types.CoinSpent {
+			inflight.Coins = append(inflight.Coins, op.CoinChange.CoinIdentifier)
+		}
+
+		if op.Account == nil || op.Amount == nil || !strings.HasPrefix(op.Amount.Value, "-") {
+			continue
+		}
+
+		key := types.Hash(op.Account)
+		if _, ok := senders[key]; ok {
+			continue
+		}
+
+		senders[key] = struct{}{}
+		inflight.Senders = append(inflight.Senders, op.Account)
+	}
+
+	return r.set(ctx, dbTx, inflight)
+}
+
+// Submitted records when /construction/submit accepted the
+// transaction with the provided hash. Failing to record the
+// submit time does not fail the broadcast.
+func (r *BroadcastRecovery) Submitted(ctx context.Context, hash string, submitted time.Time) {
+	dbTx := r.database.NewDatabaseTransaction(ctx, true)
+	defer dbTx.Discard(ctx)
+
+	inflights, err := r.getAll(ctx, dbTx)
+	if err != nil {
+		log.Printf("%s: unable to record submission of %s\n", err.Error(), hash)
+		return
+	}
+
+	for _, inflight := range inflights {
+		if inflight.TransactionIdentifier.Hash != hash {
+			continue
+		}
+
+		inflight.Submitted = submitted.UnixNano() / int64(time.Millisecond)
+		if err := r.set(ctx, dbTx, inflight); err != nil {
+			log.Printf("%s: unable to record submission of %s\n", err.Error(), hash)
+			return
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		log.Printf("%s: unable to record submission of %s\n", err.Error(), hash)
+	}
+}
+
+// Remove deletes the *InflightBroadcast for the job
+// identifier once its transaction is confirmed or
+// its broadcast fails.
+func (r *BroadcastRecovery) Remove(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+) error {
+	if err := dbTx.Delete(ctx, getInflightBroadcastKey(identifier)); err != nil {
+		return fmt.Errorf("%w: unable to delete in-flight broadcast %s", err, identifier)
+	}
+
+	return nil
+}
+
+// Inflight returns all transactions that have been queued
+// for broadcast but not yet confirmed (or failed).
+func (r *BroadcastRecovery) Inflight(ctx context.Context) ([]*InflightBroadcast, error) {
+	dbTx := r.database.NewDatabaseTransaction(ctx, false)
+	defer dbTx.Discard(ctx)
+
+	return r.getAll(ctx, dbTx)
+}
+
+// Recover reconciles all in-flight transactions against
+// the chain (synced blocks and the mempool). Transactions
+// in a synced block are confirmed (if they are no longer
+// tracked by BroadcastStorage, their jobs are completed
+// with the transaction). Transactions the node lost are
+// rebroadcast (if rebroadcast is true) if they are still
+// tracked by BroadcastStorage and are otherwise released
+// by failing their jobs. Recover returns nil if there
+// were no in-flight transactions.
+func (r *BroadcastRecovery) Recover(
+	ctx context.Context,
+	completer BroadcastCompleter,
+	rebroadcast bool,
+) (*results.BroadcastRecoveryResults, error) {
+	inflights, err := r.Inflight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inflights) == 0 {
+		return nil, nil
+	}
+
+	broadcasts, err := r.broadcastStorage.GetAllBroadcasts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get all broadcasts", err)
+	}
+
+	tracked := make(map[string]struct{}, len(broadcasts))
+	for _, broadcast := range broadcasts {
+		tracked[broadcast.Identifier] = struct{}{}
+	}
+
+	recovery := &results.BroadcastRecoveryResults{}
+	dbTx := r.database.NewDatabaseTransaction(ctx, true)
+	defer dbTx.Discard(ctx)
+
+	for _, inflight := range inflights {
+		_, isTracked := tracked[inflight.Identifier]
+		block, transaction, err := r.blockStorage.FindTransaction(
+			ctx,
+			inflight.TransactionIdentifier,
+			dbTx,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to find transaction %s",
+				err,
+				inflight.TransactionIdentifier.Hash,
+			)
+		}
+
+		switch {
+		case block != nil:
+			recovery.Confirmed++
+
+			// BroadcastStorage confirms tracked transactions
+			// once they reach their confirmation depth.
+			if isTracked {
+				continue
+			}
+
+			if err := r.complete(ctx, dbTx, completer, inflight, transaction); err != nil {
+				return nil, err
+			}
+		case r.inMempool(ctx, inflight.TransactionIdentifier):
+			recovery.Pending++
+		case isTracked && rebroadcast:
+			recovery.Rebroadcast++
+		case isTracked:
+			recovery.Pending++
+		default:
+			recovery.Released++
+			if err := r.complete(ctx, dbTx, completer, inflight, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: unable to commit recovered broadcasts", err)
+	}
+
+	// Rebroadcasting only submits the signed payloads that were
+	// queued before the restart, so no new transactions are
+	// created (and no funds are spent twice).
+	if recovery.Rebroadcast > 0 {
+		if err := r.broadcastStorage.BroadcastAll(ctx, false); err != nil {
+			return nil, fmt.Errorf("%w: unable to rebroadcast lost transactions", err)
+		}
+	}
+
+	return recovery, nil
+}
+
+// inMempool returns a boolean indicating if the node
+// returns the transaction from /mempool/transaction.
+func (r *BroadcastRecovery) inMempool(
+	ctx context.Context,
+	transactionIdentifier *types.TransactionIdentifier,
+) bool {
+	_, _, fetchErr := r.fetcher.MempoolTransaction(ctx, r.network, transactionIdentifier)
+	return fetchErr == nil
+}
+
+// complete completes the job that created an in-flight
+// transaction and removes the *InflightBroadcast. If
+// transaction is nil, the job fails.
+func (r *BroadcastRecovery) complete(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	completer BroadcastCompleter,
+	inflight *InflightBroadcast,
+	transaction *types.Transaction,
+) error {
+	if err := completer.BroadcastComplete(ctx, dbTx, inflight.Identifier, transaction); err != nil {
+		return fmt.Errorf("%w: unable to complete job %s", err, inflight.Identifier)
+	}
+
+	return r.Remove(ctx, dbTx, inflight.Identifier)
+}
+
+func (r *BroadcastRecovery) set(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	inflight *InflightBroadcast,
+) error {
+	val, err := json.Marshal(inflight)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode in-flight broadcast %s", err, inflight.Identifier)
+	}
+
+	if err := dbTx.Set(ctx, getInflightBroadcastKey(inflight.Identifier), val, true); err != nil {
+		return fmt.Errorf("%w: unable to store in-flight broadcast %s", err, inflight.Identifier)
+	}
+
+	return nil
+}
+
+func (r *BroadcastRecovery) getAll(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+) ([]*InflightBroadcast, error) {
+	inflights := []*InflightBroadcast{}
+	_, err := dbTx.Scan(
+		ctx,
+		[]byte(inflightBroadcastNamespace+"/"),
+		func(k []byte, v []byte) error {
+			var inflight InflightBroadcast
+			if err := json.Unmarshal(v, &inflight); err != nil {
+				return fmt.Errorf("%w: unable to decode in-flight broadcast", err)
+			}
+
+			inflights = append(inflights, &inflight)
+			return nil
+		},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan in-flight broadcasts", err)
+	}
+
+	return inflights, nil
+}
@@ -0,0 +1,362 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const signedPrefix = "signed "
+
+// recoveryTestNode accepts each transaction submitted to
+// /construction/submit (the hash of a payload is the payload
+// without signedPrefix) and returns a transaction from
+// /mempool/transaction if its hash is in mempool.
+type recoveryTestNode struct {
+	mempool map[string]struct{}
+
+	submitted []string
+	mutex     sync.Mutex
+}
+
+func (n *recoveryTestNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	switch r.URL.Path {
+	case "/construction/submit":
+		var request types.ConstructionSubmitRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		n.mutex.Lock()
+		n.submitted = append(n.submitted, request.SignedTransaction)
+		n.mutex.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(&types.TransactionIdentifierResponse{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: strings.TrimPrefix(request.SignedTransaction, signedPrefix),
+			},
+		})
+	case "/mempool/transaction":
+		var request types.MempoolTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err == nil {
+			if _, ok := n.mempool[request.TransactionIdentifier.Hash]; ok {
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(&types.MempoolTransactionResponse{
+					Transaction: &types.Transaction{
+						TransactionIdentifier: request.TransactionIdentifier,
+						Operations:            []*types.Operation{},
+					},
+				})
+				return
+			}
+		}
+
+		fallthrough
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(&types.Error{Code: 1, Message: "not found"})
+	}
+}
+
+// takeSubmitted returns (and resets) all payloads
+// submitted to /construction/submit.
+func (n *recoveryTestNode) takeSubmitted() []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	submitted := n.submitted
+	n.submitted = nil
+	return submitted
+}
+
+type recoveryTestHandler struct{}
+
+func (h *recoveryTestHandler) TransactionConfirmed(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+	blockIdentifier *types.BlockIdentifier,
+	transaction *types.Transaction,
+	intent []*types.Operation,
+) error {
+	return nil
+}
+
+func (h *recoveryTestHandler) TransactionStale(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+	transactionIdentifier *types.TransactionIdentifier,
+) error {
+	return nil
+}
+
+func (h *recoveryTestHandler) BroadcastFailed(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+	transactionIdentifier *types.TransactionIdentifier,
+	intent []*types.Operation,
+) error {
+	return nil
+}
+
+type recoveryTestCompleter struct {
+	completed map[string]*types.Transaction
+}
+
+func (c *recoveryTestCompleter) BroadcastComplete(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	jobIdentifier string,
+	transaction *types.Transaction,
+) error {
+	c.completed[jobIdentifier] = transaction
+	return nil
+}
+
+// recoveryTestRun is the storage used by a single
+// run of check:construction.
+type recoveryTestRun struct {
+	database         storage.Database
+	blockStorage     *storage.BlockStorage
+	broadcastStorage *storage.BroadcastStorage
+	jobStorage       *storage.JobStorage
+	recovery         *BroadcastRecovery
+}
+
+func newRecoveryTestRun(
+	ctx context.Context,
+	t *testing.T,
+	dir string,
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+) *recoveryTestRun {
+	database, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+
+	blockStorage := storage.NewBlockStorage(database)
+	blockStorage.Initialize([]storage.BlockWorker{})
+	broadcastStorage := storage.NewBroadcastStorage(database, 10, 3, 0, true, 0)
+	jobStorage := storage.NewJobStorage(database)
+	recovery := NewBroadcastRecovery(
+		network,
+		fetcher,
+		database,
+		blockStorage,
+		broadcastStorage,
+		jobStorage,
+	)
+	broadcastStorage.Initialize(
+		NewBroadcastStorageHelper(blockStorage, fetcher, nil, recovery),
+		&recoveryTestHandler{},
+	)
+
+	return &recoveryTestRun{
+		database:         database,
+		blockStorage:     blockStorage,
+		broadcastStorage: broadcastStorage,
+		jobStorage:       jobStorage,
+		recovery:         recovery,
+	}
+}
+
+// queue creates a job that broadcasts hash from sender and records
+// the in-flight broadcast. If tracked is false, the broadcast
+// is not queued in BroadcastStorage (as if it was cleared).
+func (r *recoveryTestRun) queue(
+	ctx context.Context,
+	t *testing.T,
+	network *types.NetworkIdentifier,
+	hash string,
+	sender string,
+	tracked bool,
+) string {
+	dbTx := r.database.NewDatabaseTransaction(ctx, true)
+	defer dbTx.Discard(ctx)
+
+	identifier, err := r.jobStorage.Update(ctx, dbTx, job.New(&job.Workflow{Name: "transfer"}))
+	assert.NoError(t, err)
+
+	intent := []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "Transfer",
+			Account:             &types.AccountIdentifier{Address: sender},
+			Amount: &types.Amount{
+				Value:    "-10",
+				Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			},
+			CoinChange: &types.CoinChange{
+				CoinIdentifier: &types.CoinIdentifier{Identifier: sender + " coin"},
+				CoinAction:     types.CoinSpent,
+			},
+		},
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 1},
+			Type:                "Transfer",
+			Account:             &types.AccountIdentifier{Address: "recipient"},
+			Amount: &types.Amount{
+				Value:    "10",
+				Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			},
+		},
+	}
+	transactionIdentifier := &types.TransactionIdentifier{Hash: hash}
+
+	if tracked {
+		assert.NoError(t, r.broadcastStorage.Broadcast(
+			ctx,
+			dbTx,
+			identifier,
+			network,
+			intent,
+			transactionIdentifier,
+			signedPrefix+hash,
+			1,
+		))
+	}
+
+	assert.NoError(t, r.recovery.Record(ctx, dbTx, identifier, intent, transactionIdentifier))
+	assert.NoError(t, dbTx.Commit(ctx))
+
+	return identifier
+}
+
+func TestBroadcastRecovery(t *testing.T) {
+	ctx := context.Background()
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	node := &recoveryTestNode{mempool: map[string]struct{}{"in mempool": {}}}
+	server := httptest.NewServer(node)
+	defer server.Close()
+	f := fetcher.New(server.URL)
+
+	// Queue and submit 3 transactions and record a fourth
+	// transaction whose broadcast was cleared.
+	run := newRecoveryTestRun(ctx, t, dir, network, f)
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, orphanTestBlock(0, "0", "0")))
+
+	landed := run.queue(ctx, t, network, "1 tx 0", "addr 1", true)
+	run.queue(ctx, t, network, "in mempool", "addr 2", true)
+	run.queue(ctx, t, network, "lost", "addr 3", true)
+	gone := run.queue(ctx, t, network, "gone", "addr 4", false)
+
+	assert.NoError(t, run.broadcastStorage.BroadcastAll(ctx, false))
+	submittedBeforeCrash := node.takeSubmitted()
+	assert.ElementsMatch(t, []string{
+		signedPrefix + "1 tx 0",
+		signedPrefix + "in mempool",
+		signedPrefix + "lost",
+	}, submittedBeforeCrash)
+
+	inflight, err := run.recovery.Inflight(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, inflight, 4)
+	for _, broadcast := range inflight {
+		assert.Equal(t, "transfer", broadcast.Workflow)
+		assert.Len(t, broadcast.Senders, 1)
+		assert.Len(t, broadcast.Coins, 1)
+		assert.Equal(t, broadcast.Senders[0].Address+" coin", broadcast.Coins[0].Identifier)
+
+		if broadcast.TransactionIdentifier.Hash == "gone" {
+			assert.Zero(t, broadcast.Submitted)
+		} else {
+			assert.NotZero(t, broadcast.Submitted)
+		}
+	}
+
+	// Crash before any transaction is confirmed. While
+	// check:construction is stopped, "1 tx 0" lands on-chain.
+	assert.NoError(t, run.database.Close(ctx))
+
+	run = newRecoveryTestRun(ctx, t, dir, network, f)
+	defer run.database.Close(ctx)
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, orphanTestBlock(1, "1", "0", 1)))
+
+	// Senders of pending broadcasts remain locked, so the
+	// coordinator cannot fund new transactions from them.
+	dbTx := run.database.NewDatabaseTransaction(ctx, false)
+	locked, err := run.broadcastStorage.LockedAccounts(ctx, dbTx)
+	dbTx.Discard(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, locked, 3)
+
+	completer := &recoveryTestCompleter{completed: map[string]*types.Transaction{}}
+	recovered, err := run.recovery.Recover(ctx, completer, true)
+	assert.NoError(t, err)
+	assert.Equal(t, &results.BroadcastRecoveryResults{
+		Confirmed:   1,
+		Pending:     1,
+		Rebroadcast: 1,
+		Released:    1,
+	}, recovered)
+
+	// Only payloads signed before the crash are rebroadcast,
+	// so no funds are spent by a new transaction.
+	submittedAfterCrash := node.takeSubmitted()
+	assert.Contains(t, submittedAfterCrash, signedPrefix+"lost")
+	for _, payload := range submittedAfterCrash {
+		assert.Contains(t, submittedBeforeCrash, payload)
+	}
+
+	// Only the transaction that is definitively gone is
+	// released (the landed transaction is confirmed by
+	// BroadcastStorage once it reaches its confirmation depth).
+	assert.Equal(t, map[string]*types.Transaction{gone: nil}, completer.completed)
+	assert.NotContains(t, completer.completed, landed)
+
+	inflight, err = run.recovery.Inflight(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, inflight, 3)
+
+	// Recovering again does not release anything else.
+	completer.completed = map[string]*types.Transaction{}
+	recovered, err = run.recovery.Recover(ctx, completer, false)
+	assert.NoError(t, err)
+	assert.Equal(t, &results.BroadcastRecoveryResults{
+		Confirmed: 1,
+		Pending:   2,
+	}, recovered)
+	assert.Empty(t, completer.completed)
+	assert.Empty(t, node.takeSubmitted())
+}
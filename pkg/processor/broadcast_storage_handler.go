@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/constructor/coordinator"
 	"github.com/coinbase/rosetta-sdk-go/parser"
@@ -37,23 +38,48 @@ type BroadcastStorageHandler struct {
 	counterStorage *storage.CounterStorage
 	coordinator    *coordinator.Coordinator
 	parser         *parser.Parser
+
+	broadcastRecovery  *BroadcastRecovery
+	hashMismatchFinder *HashMismatchFinder
 }
 
 // NewBroadcastStorageHandler returns a new *BroadcastStorageHandler.
+// If broadcastRecovery is not nil, the in-flight record of each
+// transaction is removed once it is confirmed or its broadcast fails.
+// If hashMismatchFinder is not nil, recent blocks are searched for
+// each stale transaction under a different hash.
 func NewBroadcastStorageHandler(
 	config *configuration.Configuration,
 	counterStorage *storage.CounterStorage,
 	coordinator *coordinator.Coordinator,
 	parser *parser.Parser,
+	broadcastRecovery *BroadcastRecovery,
+	hashMismatchFinder *HashMismatchFinder,
 ) *BroadcastStorageHandler {
 	return &BroadcastStorageHandler{
-		config:         config,
-		counterStorage: counterStorage,
-		coordinator:    coordinator,
-		parser:         parser,
+		config:             config,
+		counterStorage:     counterStorage,
+		coordinator:        coordinator,
+		parser:             parser,
+		broadcastRecovery:  broadcastRecovery,
+		hashMismatchFinder: hashMismatchFinder,
 	}
 }
 
+// removeInflight removes the in-flight record of
+// the transaction broadcast by the job identifier.
+func (h *BroadcastStorageHandler) removeInflight(
+	ctx context.Context,
+	dbTx storage.DatabaseTransaction,
+	identifier string,
+) error {
+	if h.broadcastRecovery == nil {
+		return nil
+	}
+
+	return h.broadcastRecovery.Remove(ctx, dbTx, identifier)
+}
+
 // TransactionConfirmed is called when a transaction is observed on-chain for the
 // last time at a block height < current block height - confirmationDepth.
 func (h *BroadcastStorageHandler) TransactionConfirmed(
@@ -84,7 +110,7 @@ func (h *BroadcastStorageHandler) TransactionConfirmed(
 		return fmt.Errorf("%w: coordinator could not handle transaction", err)
 	}
 
-	return nil
+	return h.removeInflight(ctx, dbTx, identifier)
 }
 
 // TransactionStale is called when a transaction has not yet been
@@ -103,7 +129,42 @@ func (h *BroadcastStorageHandler) TransactionStale(
 		big.NewInt(1),
 	)
 
-	return nil
+	return h.checkHashMismatch(ctx, identifier, transactionIdentifier)
+}
+
+// checkHashMismatch returns an error if a transaction matching
+// the intent of a stale broadcast is found in a recent block
+// under a different hash (which indicates that /construction/hash
+// does not return the on-chain transaction hash).
+func (h *BroadcastStorageHandler) checkHashMismatch(
+	ctx context.Context,
+	identifier string,
+	transactionIdentifier *types.TransactionIdentifier,
+) error {
+	if h.hashMismatchFinder == nil {
+		return nil
+	}
+
+	block, found, err := h.hashMismatchFinder.Find(ctx, identifier, transactionIdentifier)
+	if err != nil {
+		return fmt.Errorf("%w: unable to search for stale transaction", err)
+	}
+
+	if found == nil {
+		return nil
+	}
+
+	// The database transaction is discarded when an error
+	// is returned, so the mismatch is counted separately.
+	_, _ = h.counterStorage.Update(ctx, results.HashMismatchCounter, big.NewInt(1))
+
+	return fmt.Errorf(
+		"%w: /construction/hash returned %s but the transaction was included in block %d as %s",
+		ErrHashMismatch,
+		transactionIdentifier.Hash,
+		block.Index,
+		found.Hash,
+	)
 }
 
 // BroadcastFailed is called when another transaction broadcast would
@@ -131,6 +192,10 @@ func (h *BroadcastStorageHandler) BroadcastFailed(
 		return fmt.Errorf("%w: coordinator could not handle transaction", err)
 	}
 
+	if err := h.removeInflight(ctx, dbTx, identifier); err != nil {
+		return err
+	}
+
 	if h.config.Construction.IgnoreBroadcastFailures {
 		return nil
 	}
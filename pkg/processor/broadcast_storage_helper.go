@@ -17,6 +17,7 @@ package processor
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/storage"
@@ -28,18 +29,29 @@ var _ storage.BroadcastStorageHelper = (*BroadcastStorageHelper)(nil)
 // BroadcastStorageHelper implements the storage.Helper
 // interface.
 type BroadcastStorageHelper struct {
-	blockStorage *storage.BlockStorage
-	fetcher      *fetcher.Fetcher
+	blockStorage   *storage.BlockStorage
+	fetcher        *fetcher.Fetcher
+	mempoolWatcher *MempoolWatcher
+
+	broadcastRecovery *BroadcastRecovery
 }
 
 // NewBroadcastStorageHelper returns a new BroadcastStorageHelper.
+// If mempoolWatcher is not nil, it is notified of each
+// transaction accepted by /construction/submit. If
+// broadcastRecovery is not nil, it records when each
+// transaction was accepted.
 func NewBroadcastStorageHelper(
 	blockStorage *storage.BlockStorage,
 	fetcher *fetcher.Fetcher,
+	mempoolWatcher *MempoolWatcher,
+	broadcastRecovery *BroadcastRecovery,
 ) *BroadcastStorageHelper {
 	return &BroadcastStorageHelper{
-		blockStorage: blockStorage,
-		fetcher:      fetcher,
+		blockStorage:      blockStorage,
+		fetcher:           fetcher,
+		mempoolWatcher:    mempoolWatcher,
+		broadcastRecovery: broadcastRecovery,
 	}
 }
 
@@ -92,7 +104,7 @@ func (h *BroadcastStorageHelper) BroadcastTransaction(
 	networkIdentifier *types.NetworkIdentifier,
 	networkTransaction string,
 ) (*types.TransactionIdentifier, error) {
-	transactionIdentifier, _, fetchErr := h.fetcher.ConstructionSubmit(
+	transactionIdentifier, metadata, fetchErr := h.fetcher.ConstructionSubmit(
 		ctx,
 		networkIdentifier,
 		networkTransaction,
@@ -101,5 +113,16 @@ func (h *BroadcastStorageHelper) BroadcastTransaction(
 		return nil, fmt.Errorf("%w: unable to broadcast transaction", fetchErr.Err)
 	}
 
+	if h.mempoolWatcher != nil {
+		h.mempoolWatcher.Submitted(&types.TransactionIdentifierResponse{
+			TransactionIdentifier: transactionIdentifier,
+			Metadata:              metadata,
+		})
+	}
+
+	if h.broadcastRecovery != nil {
+		h.broadcastRecovery.Submitted(ctx, transactionIdentifier.Hash, time.Now())
+	}
+
 	return transactionIdentifier, nil
 }
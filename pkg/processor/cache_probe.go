@@ -0,0 +1,269 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// accountBalanceEndpoint is the path of the
+	// /account/balance endpoint.
+	accountBalanceEndpoint = "/account/balance"
+
+	// cacheBustingParam is the query parameter populated
+	// with a unique value on cache-busting requests.
+	cacheBustingParam = "rosetta_cli_cache_bust"
+
+	// maxCacheProbeFindings is the maximum number of
+	// findings to record (all stale responses are
+	// still counted).
+	maxCacheProbeFindings = 100
+)
+
+// CacheProbe periodically requests the balance of the most
+// recently reconciled account twice (first with cache-busting
+// and then normally) to detect a caching layer in front of
+// the implementation that returns stale responses.
+type CacheProbe struct {
+	url        string
+	network    *types.NetworkIdentifier
+	httpClient *http.Client
+	interval   time.Duration
+
+	account *types.AccountIdentifier
+	results *results.CacheProbeResults
+	mutex   sync.Mutex
+}
+
+// NewCacheProbe returns a new *CacheProbe that probes
+// the implementation at url every interval.
+func NewCacheProbe(
+	url string,
+	network *types.NetworkIdentifier,
+	timeout time.Duration,
+	interval time.Duration,
+) *CacheProbe {
+	return &CacheProbe{
+		url:     url,
+		network: network,
+		httpClient: &http.Client{
+			// The probe uses its own transport so that its
+			// idle connections can be closed in Close.
+			Transport: http.DefaultTransport.(*http.Transport).Clone(),
+			Timeout:   timeout,
+		},
+		interval: interval,
+		results: &results.CacheProbeResults{
+			Findings: []*results.CacheProbeFinding{},
+		},
+	}
+}
+
+// Close closes any idle connections held by the probe.
+func (p *CacheProbe) Close() {
+	if p == nil {
+		return
+	}
+
+	p.httpClient.CloseIdleConnections()
+}
+
+// Observe sets the account to use in the next probe.
+func (p *CacheProbe) Observe(account *types.AccountIdentifier) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.account = account
+}
+
+// Results returns a copy of the *results.CacheProbeResults
+// or nil if no probes were performed.
+func (p *CacheProbe) Results() *results.CacheProbeResults {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.results.Probes == 0 {
+		return nil
+	}
+
+	return &results.CacheProbeResults{
+		Probes:         p.results.Probes,
+		StaleResponses: p.results.StaleResponses,
+		MaxStaleness:   p.results.MaxStaleness,
+		Findings:       append([]*results.CacheProbeFinding{}, p.results.Findings...),
+	}
+}
+
+// Loop probes the implementation every interval
+// until the context is canceled.
+func (p *CacheProbe) Loop(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			p.mutex.Lock()
+			account := p.account
+			p.mutex.Unlock()
+
+			if account == nil {
+				continue
+			}
+
+			// Probe failures are not fatal because the same
+			// request failing during reconciliation will be.
+			if err := p.Probe(ctx, account); err != nil {
+				log.Printf("%s: unable to probe cache\n", err.Error())
+			}
+		}
+	}
+}
+
+// Probe requests the balance of account with cache-busting
+// and then normally. Because the normal request is made
+// second, any response at an older block (or with a different
+// balance at the same block) must have been served from a cache.
+func (p *CacheProbe) Probe(ctx context.Context, account *types.AccountIdentifier) error {
+	fresh, err := p.accountBalance(ctx, account, true)
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch cache-busting balance", err)
+	}
+
+	cached, err := p.accountBalance(ctx, account, false)
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch balance", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.results.Probes++
+
+	staleness := fresh.BlockIdentifier.Index - cached.BlockIdentifier.Index
+	balancesDiffer := types.Hash(fresh.BlockIdentifier) == types.Hash(cached.BlockIdentifier) &&
+		types.Hash(fresh.Balances) != types.Hash(cached.Balances)
+	if staleness <= 0 && !balancesDiffer {
+		return nil
+	}
+
+	p.results.StaleResponses++
+	if staleness > p.results.MaxStaleness {
+		p.results.MaxStaleness = staleness
+	}
+
+	if len(p.results.Findings) >= maxCacheProbeFindings {
+		return nil
+	}
+
+	p.results.Findings = append(p.results.Findings, &results.CacheProbeFinding{
+		Account:        account,
+		CachedBlock:    cached.BlockIdentifier,
+		FreshBlock:     fresh.BlockIdentifier,
+		Staleness:      staleness,
+		BalancesDiffer: balancesDiffer,
+	})
+
+	return nil
+}
+
+// accountBalance makes an /account/balance request for the
+// current balance of account. We make the request directly
+// (instead of with the fetcher) so that no default headers
+// are added to requests that are not cache-busting.
+func (p *CacheProbe) accountBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	cacheBusting bool,
+) (*types.AccountBalanceResponse, error) {
+	body, err := json.Marshal(&types.AccountBalanceRequest{
+		NetworkIdentifier: p.network,
+		AccountIdentifier: account,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal request", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		p.url+accountBalanceEndpoint,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create request", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	if cacheBusting {
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+
+		query := req.URL.Query()
+		query.Set(cacheBustingParam, strconv.FormatInt(time.Now().UnixNano(), 10))
+		req.URL.RawQuery = query.Encode()
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to make request", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read response", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %d status with body %s", resp.StatusCode, respBody)
+	}
+
+	var response types.AccountBalanceResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal response", err)
+	}
+
+	if response.BlockIdentifier == nil {
+		return nil, errors.New("response is missing block identifier")
+	}
+
+	return &response, nil
+}
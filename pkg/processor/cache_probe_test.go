@@ -0,0 +1,130 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheProbe(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	account := &types.AccountIdentifier{Address: "addr"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	fresh := &types.AccountBalanceResponse{
+		BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		Balances:        []*types.Amount{{Value: "100", Currency: currency}},
+	}
+
+	var tests = map[string]struct {
+		cached *types.AccountBalanceResponse
+
+		expected *results.CacheProbeResults
+	}{
+		"not cached": {
+			cached: fresh,
+			expected: &results.CacheProbeResults{
+				Probes:   1,
+				Findings: []*results.CacheProbeFinding{},
+			},
+		},
+		"stale block": {
+			cached: &types.AccountBalanceResponse{
+				BlockIdentifier: &types.BlockIdentifier{Index: 7, Hash: "block 7"},
+				Balances:        []*types.Amount{{Value: "90", Currency: currency}},
+			},
+			expected: &results.CacheProbeResults{
+				Probes:         1,
+				StaleResponses: 1,
+				MaxStaleness:   3,
+				Findings: []*results.CacheProbeFinding{
+					{
+						Account:     account,
+						CachedBlock: &types.BlockIdentifier{Index: 7, Hash: "block 7"},
+						FreshBlock:  fresh.BlockIdentifier,
+						Staleness:   3,
+					},
+				},
+			},
+		},
+		"stale balance": {
+			cached: &types.AccountBalanceResponse{
+				BlockIdentifier: fresh.BlockIdentifier,
+				Balances:        []*types.Amount{{Value: "90", Currency: currency}},
+			},
+			expected: &results.CacheProbeResults{
+				Probes:         1,
+				StaleResponses: 1,
+				Findings: []*results.CacheProbeFinding{
+					{
+						Account:        account,
+						CachedBlock:    fresh.BlockIdentifier,
+						FreshBlock:     fresh.BlockIdentifier,
+						BalancesDiffer: true,
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// The server responds with the cached response to
+			// any request that is not cache-busting.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, accountBalanceEndpoint, r.URL.Path)
+
+				var request types.AccountBalanceRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				assert.Equal(t, network, request.NetworkIdentifier)
+				assert.Equal(t, account, request.AccountIdentifier)
+
+				response := test.cached
+				if len(r.URL.Query().Get(cacheBustingParam)) > 0 {
+					assert.Equal(t, "no-cache", r.Header.Get("Cache-Control"))
+					response = fresh
+				} else {
+					assert.Empty(t, r.Header.Get("Cache-Control"))
+				}
+
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.WriteHeader(http.StatusOK)
+				assert.NoError(t, json.NewEncoder(w).Encode(response))
+			}))
+			defer server.Close()
+
+			probe := NewCacheProbe(server.URL, network, time.Second, time.Second)
+			assert.Nil(t, probe.Results())
+
+			assert.NoError(t, probe.Probe(context.Background(), account))
+			assert.Equal(t, test.expected, probe.Results())
+		})
+	}
+}
+
+func TestCacheProbe_Nil(t *testing.T) {
+	var probe *CacheProbe
+	probe.Observe(&types.AccountIdentifier{Address: "addr"})
+	assert.Nil(t, probe.Results())
+}
@@ -0,0 +1,439 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// chainActivityNamespace is prepended to the
+	// key of each chainActivityRecord.
+	chainActivityNamespace = "chain-activity"
+)
+
+var _ storage.BlockWorker = (*ChainActivityWorker)(nil)
+
+// chainActivityRecord is stored for each canonical block so
+// that the chain activity counters can be restored when the
+// block is orphaned.
+//
+// Blocks with a timestamp that is not after the timestamp
+// of their parent are merged into the interval ending at the
+// next block with a later timestamp (so identical timestamps
+// never result in an interval with no duration).
+type chainActivityRecord struct {
+	// Timestamp is the latest timestamp of any
+	// block up to and including this block.
+	Timestamp int64 `json:"timestamp"`
+
+	Transactions int64 `json:"transactions"`
+	Operations   int64 `json:"operations"`
+
+	// Open indicates that the interval containing this
+	// block has not yet ended. IntervalStart is the index
+	// of the first block in the interval and
+	// IntervalTransactions is the number of transactions in
+	// the interval (up to and including this block).
+	Open                 bool  `json:"open"`
+	IntervalStart        int64 `json:"interval_start"`
+	IntervalTransactions int64 `json:"interval_transactions"`
+
+	// IntervalDuration is the duration (in milliseconds) of the
+	// interval ending at this block (or 0 if it is still open).
+	IntervalDuration int64 `json:"interval_duration"`
+}
+
+// chainActivityPeak is the busiest interval
+// between block timestamps.
+type chainActivityPeak struct {
+	transactions int64
+	duration     int64
+	start        int64
+	end          int64
+}
+
+// busier returns a boolean indicating if the interval ending
+// at end is busier than peak. Ties are broken by the earlier
+// interval, so the peak is the same regardless of the order
+// intervals are considered.
+func (p *chainActivityPeak) busier(transactions int64, duration int64, end int64) bool {
+	if p.duration == 0 {
+		return true
+	}
+
+	current := new(big.Int).Mul(big.NewInt(p.transactions), big.NewInt(duration))
+	candidate := new(big.Int).Mul(big.NewInt(transactions), big.NewInt(p.duration))
+	switch candidate.Cmp(current) {
+	case 1:
+		return true
+	case 0:
+		return end < p.end
+	default:
+		return false
+	}
+}
+
+// ChainActivityWorker implements the storage.BlockWorker
+// interface. It tracks the transactions and operations in
+// each canonical block against block timestamps (instead of
+// wall clock time) to compute the activity of the chain.
+type ChainActivityWorker struct {
+	counterStorage *storage.CounterStorage
+}
+
+// NewChainActivityWorker returns a new *ChainActivityWorker.
+func NewChainActivityWorker(counterStorage *storage.CounterStorage) *ChainActivityWorker {
+	return &ChainActivityWorker{counterStorage: counterStorage}
+}
+
+func getChainActivityKey(index int64) []byte {
+	return []byte(fmt.Sprintf("%s/%d", chainActivityNamespace, index))
+}
+
+func (w *ChainActivityWorker) getRecord(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	index int64,
+) (*chainActivityRecord, error) {
+	exists, val, err := transaction.Get(ctx, getChainActivityKey(index))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get chain activity for block %d", err, index)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	var record chainActivityRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode chain activity for block %d", err, index)
+	}
+
+	return &record, nil
+}
+
+// setCounter sets counter to value.
+func (w *ChainActivityWorker) setCounter(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	counter string,
+	value int64,
+) error {
+	current, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, big.NewInt(0))
+	if err != nil {
+		return fmt.Errorf("%w: unable to get %s counter", err, counter)
+	}
+
+	_, err = w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		counter,
+		new(big.Int).Sub(big.NewInt(value), current),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to update %s counter", err, counter)
+	}
+
+	return nil
+}
+
+// updateCounter adds amount to counter.
+func (w *ChainActivityWorker) updateCounter(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	counter string,
+	amount int64,
+) error {
+	_, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, big.NewInt(amount))
+	if err != nil {
+		return fmt.Errorf("%w: unable to update %s counter", err, counter)
+	}
+
+	return nil
+}
+
+func (w *ChainActivityWorker) getPeak(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+) (*chainActivityPeak, error) {
+	values := map[string]*big.Int{}
+	for _, counter := range []string{
+		results.ChainPeakTransactionCounter,
+		results.ChainPeakDurationCounter,
+		results.ChainPeakStartCounter,
+		results.ChainPeakEndCounter,
+	} {
+		value, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, big.NewInt(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get %s counter", err, counter)
+		}
+
+		values[counter] = value
+	}
+
+	return &chainActivityPeak{
+		transactions: values[results.ChainPeakTransactionCounter].Int64(),
+		duration:     values[results.ChainPeakDurationCounter].Int64(),
+		start:        values[results.ChainPeakStartCounter].Int64(),
+		end:          values[results.ChainPeakEndCounter].Int64(),
+	}, nil
+}
+
+func (w *ChainActivityWorker) setPeak(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	peak *chainActivityPeak,
+) error {
+	for counter, value := range map[string]int64{
+		results.ChainPeakTransactionCounter: peak.transactions,
+		results.ChainPeakDurationCounter:    peak.duration,
+		results.ChainPeakStartCounter:       peak.start,
+		results.ChainPeakEndCounter:         peak.end,
+	} {
+		if err := w.setCounter(ctx, transaction, counter, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// The counters are updated in the same database transaction
+// as the block is added.
+func (w *ChainActivityWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	index := block.BlockIdentifier.Index
+	if err := w.setCounter(ctx, transaction, results.ChainHeadIndexCounter, index); err != nil {
+		return nil, err
+	}
+
+	record := &chainActivityRecord{
+		Timestamp:    block.Timestamp,
+		Transactions: int64(len(block.Transactions)),
+	}
+	for _, txn := range block.Transactions {
+		record.Operations += int64(len(txn.Operations))
+	}
+
+	var parent *chainActivityRecord
+	if block.ParentBlockIdentifier.Index != index {
+		var err error
+		parent, err = w.getRecord(ctx, transaction, block.ParentBlockIdentifier.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case parent == nil:
+		// The activity of the first block synced is not
+		// counted because there is no interval before it.
+		if err := w.setCounter(ctx, transaction, results.ChainStartTimestampCounter, block.Timestamp); err != nil {
+			return nil, err
+		}
+	case block.Timestamp > parent.Timestamp:
+		record.IntervalStart = index
+		record.IntervalTransactions = record.Transactions
+		if parent.Open {
+			record.IntervalStart = parent.IntervalStart
+			record.IntervalTransactions += parent.IntervalTransactions
+		}
+		record.IntervalDuration = block.Timestamp - parent.Timestamp
+
+		peak, err := w.getPeak(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		if peak.busier(record.IntervalTransactions, record.IntervalDuration, index) {
+			if err := w.setPeak(ctx, transaction, &chainActivityPeak{
+				transactions: record.IntervalTransactions,
+				duration:     record.IntervalDuration,
+				start:        record.IntervalStart,
+				end:          index,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		record.Timestamp = parent.Timestamp
+		record.Open = true
+		record.IntervalStart = index
+		record.IntervalTransactions = record.Transactions
+		if parent.Open {
+			record.IntervalStart = parent.IntervalStart
+			record.IntervalTransactions += parent.IntervalTransactions
+		}
+	}
+
+	if parent != nil {
+		if err := w.updateCounter(ctx, transaction, results.ChainTransactionCounter, record.Transactions); err != nil {
+			return nil, err
+		}
+
+		if err := w.updateCounter(ctx, transaction, results.ChainOperationCounter, record.Operations); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.setCounter(ctx, transaction, results.ChainEndTimestampCounter, record.Timestamp); err != nil {
+		return nil, err
+	}
+
+	val, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to encode chain activity for block %d", err, index)
+	}
+
+	if err := transaction.Set(ctx, getChainActivityKey(index), val, false); err != nil {
+		return nil, fmt.Errorf("%w: unable to store chain activity for block %d", err, index)
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// The counters are restored to their values before the block
+// was added in the same database transaction as the removal.
+func (w *ChainActivityWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	index := block.BlockIdentifier.Index
+	if err := w.setCounter(
+		ctx,
+		transaction,
+		results.ChainHeadIndexCounter,
+		block.ParentBlockIdentifier.Index,
+	); err != nil {
+		return nil, err
+	}
+
+	record, err := w.getRecord(ctx, transaction, index)
+	if err != nil {
+		return nil, err
+	}
+
+	// The block was added before chain activity was tracked.
+	if record == nil {
+		return nil, nil
+	}
+
+	if err := transaction.Delete(ctx, getChainActivityKey(index)); err != nil {
+		return nil, fmt.Errorf("%w: unable to delete chain activity for block %d", err, index)
+	}
+
+	var parent *chainActivityRecord
+	if block.ParentBlockIdentifier.Index != index {
+		parent, err = w.getRecord(ctx, transaction, block.ParentBlockIdentifier.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// If the first block synced is removed,
+	// there is no chain activity left.
+	if parent == nil {
+		for _, counter := range []string{
+			results.ChainStartTimestampCounter,
+			results.ChainEndTimestampCounter,
+		} {
+			if err := w.setCounter(ctx, transaction, counter, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	if err := w.updateCounter(ctx, transaction, results.ChainTransactionCounter, -record.Transactions); err != nil {
+		return nil, err
+	}
+
+	if err := w.updateCounter(ctx, transaction, results.ChainOperationCounter, -record.Operations); err != nil {
+		return nil, err
+	}
+
+	if err := w.setCounter(ctx, transaction, results.ChainEndTimestampCounter, parent.Timestamp); err != nil {
+		return nil, err
+	}
+
+	peak, err := w.getPeak(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if peak.end != index || peak.duration == 0 {
+		return nil, nil
+	}
+
+	// The busiest interval ended at the removed block,
+	// so we find the busiest remaining interval.
+	newPeak := &chainActivityPeak{}
+	_, err = transaction.Scan(
+		ctx,
+		[]byte(chainActivityNamespace+"/"),
+		func(k []byte, v []byte) error {
+			var r chainActivityRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("%w: unable to decode chain activity", err)
+			}
+
+			var end int64
+			if _, err := fmt.Sscanf(string(k), chainActivityNamespace+"/%d", &end); err != nil {
+				return fmt.Errorf("%w: unable to parse chain activity key %s", err, string(k))
+			}
+
+			if end == index || r.IntervalDuration == 0 {
+				return nil
+			}
+
+			if newPeak.busier(r.IntervalTransactions, r.IntervalDuration, end) {
+				newPeak = &chainActivityPeak{
+					transactions: r.IntervalTransactions,
+					duration:     r.IntervalDuration,
+					start:        r.IntervalStart,
+					end:          end,
+				}
+			}
+
+			return nil
+		},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan chain activity", err)
+	}
+
+	if err := w.setPeak(ctx, transaction, newPeak); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
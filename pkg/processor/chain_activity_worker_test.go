@@ -0,0 +1,133 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func chainActivityTestBlock(
+	index int64,
+	hash string,
+	parentHash string,
+	timestamp int64,
+	opCounts ...int,
+) *types.Block {
+	block := orphanTestBlock(index, hash, parentHash, opCounts...)
+	block.Timestamp = timestamp
+
+	return block
+}
+
+func TestChainActivityWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{NewChainActivityWorker(counterStorage)})
+
+	cfg := configuration.DefaultConfiguration()
+	chainActivity := func() *results.ChainActivity {
+		return results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil).ChainActivity
+	}
+	headIndex := func() int64 {
+		head, err := counterStorage.Get(ctx, results.ChainHeadIndexCounter)
+		assert.NoError(t, err)
+
+		return head.Int64()
+	}
+
+	// The first block synced has no interval before it.
+	assert.NoError(t, blockStorage.AddBlock(ctx, chainActivityTestBlock(0, "0", "0", 1000, 1, 1)))
+	assert.Nil(t, chainActivity())
+
+	// 1 transaction in 1 second
+	assert.NoError(t, blockStorage.AddBlock(ctx, chainActivityTestBlock(1, "1", "0", 2000, 2)))
+	assert.Equal(t, &results.ChainActivity{
+		TransactionsPerSecond:     1,
+		OperationsPerSecond:       2,
+		PeakTransactionsPerSecond: 1,
+		PeakStartIndex:            1,
+		PeakEndIndex:              1,
+	}, chainActivity())
+
+	// Blocks with the same timestamp are combined with the
+	// next block with a later timestamp (4 transactions in
+	// 1 second).
+	assert.NoError(t, blockStorage.AddBlock(ctx, chainActivityTestBlock(2, "2", "1", 2000, 1, 1, 1)))
+	assert.NoError(t, blockStorage.AddBlock(ctx, chainActivityTestBlock(3, "3", "2", 3000, 1)))
+	assert.Equal(t, &results.ChainActivity{
+		TransactionsPerSecond:     2.5,
+		OperationsPerSecond:       3,
+		PeakTransactionsPerSecond: 4,
+		PeakStartIndex:            2,
+		PeakEndIndex:              3,
+	}, chainActivity())
+
+	// 3 transactions in half a second
+	block4a := chainActivityTestBlock(4, "4a", "3", 3500, 1, 1, 1)
+	assert.NoError(t, blockStorage.AddBlock(ctx, block4a))
+	assert.Equal(t, &results.ChainActivity{
+		TransactionsPerSecond:     3.2,
+		OperationsPerSecond:       3.6,
+		PeakTransactionsPerSecond: 6,
+		PeakStartIndex:            4,
+		PeakEndIndex:              4,
+	}, chainActivity())
+
+	assert.Equal(t, int64(4), headIndex())
+
+	// Orphaning the peak restores the previous peak.
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block4a.BlockIdentifier))
+	assert.Equal(t, int64(3), headIndex())
+	assert.Equal(t, &results.ChainActivity{
+		TransactionsPerSecond:     2.5,
+		OperationsPerSecond:       3,
+		PeakTransactionsPerSecond: 4,
+		PeakStartIndex:            2,
+		PeakEndIndex:              3,
+	}, chainActivity())
+
+	// 2 transactions in 1 second
+	assert.NoError(t, blockStorage.AddBlock(ctx, chainActivityTestBlock(4, "4b", "3", 4000, 1, 1)))
+	assert.Equal(t, &results.ChainActivity{
+		TransactionsPerSecond:     7.0 / 3,
+		OperationsPerSecond:       8.0 / 3,
+		PeakTransactionsPerSecond: 4,
+		PeakStartIndex:            2,
+		PeakEndIndex:              3,
+	}, chainActivity())
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*CoinReorgWorker)(nil)
+
+// CoinReorgWorker implements the storage.BlockWorker
+// interface. When a block is orphaned, it verifies that
+// every coin created in the block is no longer spendable
+// and that every coin spent in the block (that was not
+// also created in it) is spendable again. It returns
+// results.ErrOrphanedCoinNotReversed (naming the coin and
+// the block) if either is not the case.
+//
+// CoinReorgWorker must run after *storage.CoinStorage
+// so that the block has already been reversed in the
+// coin index.
+type CoinReorgWorker struct {
+	counterStorage *storage.CounterStorage
+	coinStorage    *storage.CoinStorage
+	asserter       *asserter.Asserter
+}
+
+// NewCoinReorgWorker returns a new *CoinReorgWorker.
+func NewCoinReorgWorker(
+	counterStorage *storage.CounterStorage,
+	coinStorage *storage.CoinStorage,
+	asserter *asserter.Asserter,
+) *CoinReorgWorker {
+	return &CoinReorgWorker{
+		counterStorage: counterStorage,
+		coinStorage:    coinStorage,
+		asserter:       asserter,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+func (w *CoinReorgWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// Coins are verified and counted in the same database transaction
+// as the removal.
+func (w *CoinReorgWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	ops, err := coinOperations(w.asserter, block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	// Coins created and spent in the same block
+	// must not be spendable once it is removed.
+	created := map[string]struct{}{}
+	for _, op := range ops {
+		if op.CoinChange.CoinAction == types.CoinCreated {
+			created[op.CoinChange.CoinIdentifier.Identifier] = struct{}{}
+		}
+	}
+
+	for _, op := range ops {
+		identifier := op.CoinChange.CoinIdentifier
+		_, _, err := w.coinStorage.GetCoinTransactional(ctx, transaction, identifier)
+		if err != nil && !errors.Is(err, storage.ErrCoinNotFound) {
+			return nil, fmt.Errorf("%w: unable to get coin %s", err, identifier.Identifier)
+		}
+
+		spendable := err == nil
+		_, createdInBlock := created[identifier.Identifier]
+		switch {
+		case createdInBlock && spendable:
+			return nil, fmt.Errorf(
+				"%w: coin %s created in orphaned block %d (%s) is still spendable",
+				results.ErrOrphanedCoinNotReversed,
+				identifier.Identifier,
+				block.BlockIdentifier.Index,
+				block.BlockIdentifier.Hash,
+			)
+		case !createdInBlock && !spendable:
+			return nil, fmt.Errorf(
+				"%w: coin %s spent in orphaned block %d (%s) was not restored",
+				results.ErrOrphanedCoinNotReversed,
+				identifier.Identifier,
+				block.BlockIdentifier.Index,
+				block.BlockIdentifier.Hash,
+			)
+		}
+	}
+
+	_, err = w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		results.ReversedCoinCounter,
+		big.NewInt(int64(len(ops))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to update reversed coins counter", err)
+	}
+
+	return nil, nil
+}
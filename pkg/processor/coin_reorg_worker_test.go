@@ -0,0 +1,248 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// addOnlyCoinStorage applies added blocks to
+// *storage.CoinStorage but never reverses
+// removed blocks (like a broken coin index).
+type addOnlyCoinStorage struct {
+	*storage.CoinStorage
+}
+
+func (s *addOnlyCoinStorage) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
+
+// coinReorgTestBlock returns a coinTestBlock with
+// a hash that distinguishes it from blocks at the
+// same index on other forks.
+func coinReorgTestBlock(index int64, fork string, parentFork string, ops ...*types.Operation) *types.Block {
+	block := coinTestBlock(index, ops...)
+	block.BlockIdentifier.Hash = fmt.Sprintf("%d%s", index, fork)
+	block.ParentBlockIdentifier.Hash = fmt.Sprintf("%d%s", block.ParentBlockIdentifier.Index, parentFork)
+	block.Transactions[0].TransactionIdentifier.Hash = fmt.Sprintf("tx %d%s", index, fork)
+
+	return block
+}
+
+func coinReorgTestStorage(
+	ctx context.Context,
+	t *testing.T,
+	dir string,
+	broken bool,
+) (*storage.BlockStorage, *storage.CoinStorage, *storage.CounterStorage, func()) {
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+
+	networkAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "0"},
+		[]string{"Transfer"},
+		[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	coinStorage := storage.NewCoinStorage(
+		localStore,
+		NewCoinStorageHelper(blockStorage),
+		networkAsserter,
+	)
+
+	var coinWorker storage.BlockWorker = coinStorage
+	if broken {
+		coinWorker = &addOnlyCoinStorage{coinStorage}
+	}
+
+	blockStorage.Initialize([]storage.BlockWorker{
+		coinWorker,
+		NewCoinReorgWorker(counterStorage, coinStorage, networkAsserter),
+	})
+
+	return blockStorage, coinStorage, counterStorage, func() { localStore.Close(ctx) }
+}
+
+func TestCoinReorgWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	blockStorage, coinStorage, counterStorage, closeStorage := coinReorgTestStorage(ctx, t, dir, false)
+	defer closeStorage()
+
+	spendable := func(coins ...string) {
+		for _, coin := range coins {
+			_, _, err := coinStorage.GetCoin(ctx, &types.CoinIdentifier{Identifier: coin})
+			assert.NoError(t, err, coin)
+		}
+	}
+	spent := func(coins ...string) {
+		for _, coin := range coins {
+			_, _, err := coinStorage.GetCoin(ctx, &types.CoinIdentifier{Identifier: coin})
+			assert.True(t, errors.Is(err, storage.ErrCoinNotFound), coin)
+		}
+	}
+	reversed := func() int64 {
+		count, err := counterStorage.Get(ctx, results.ReversedCoinCounter)
+		assert.NoError(t, err)
+
+		return count.Int64()
+	}
+
+	// Each block spends coins created in the block before
+	// it, and block 2 creates and spends coin 4.
+	blocks := []*types.Block{
+		coinReorgTestBlock(0, "", "", coinOperation(0, types.CoinCreated, "coin 1", "100")),
+		coinReorgTestBlock(
+			1, "a", "",
+			coinOperation(0, types.CoinSpent, "coin 1", "-100"),
+			coinOperation(1, types.CoinCreated, "coin 2", "90"),
+		),
+		coinReorgTestBlock(
+			2, "a", "a",
+			coinOperation(0, types.CoinSpent, "coin 2", "-90"),
+			coinOperation(1, types.CoinCreated, "coin 3", "50"),
+			coinOperation(2, types.CoinCreated, "coin 4", "30"),
+			coinOperation(3, types.CoinSpent, "coin 4", "-30"),
+		),
+		coinReorgTestBlock(
+			3, "a", "a",
+			coinOperation(0, types.CoinSpent, "coin 3", "-50"),
+			coinOperation(1, types.CoinCreated, "coin 5", "40"),
+		),
+	}
+	for _, block := range blocks {
+		assert.NoError(t, blockStorage.AddBlock(ctx, block))
+	}
+	spendable("coin 5")
+	spent("coin 1", "coin 2", "coin 3", "coin 4")
+	assert.Equal(t, int64(0), reversed())
+
+	// Orphan blocks 3, 2, and 1 (one at a time).
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, blocks[3].BlockIdentifier))
+	spendable("coin 3")
+	spent("coin 5")
+	assert.Equal(t, int64(2), reversed())
+
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, blocks[2].BlockIdentifier))
+	spendable("coin 2")
+	spent("coin 3", "coin 4")
+	assert.Equal(t, int64(6), reversed())
+
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, blocks[1].BlockIdentifier))
+	spendable("coin 1")
+	spent("coin 2")
+	assert.Equal(t, int64(8), reversed())
+
+	// The new fork spends coin 1 again and can be orphaned.
+	block1b := coinReorgTestBlock(
+		1, "b", "",
+		coinOperation(0, types.CoinSpent, "coin 1", "-100"),
+		coinOperation(1, types.CoinCreated, "coin 6", "100"),
+	)
+	block2b := coinReorgTestBlock(
+		2, "b", "b",
+		coinOperation(0, types.CoinSpent, "coin 6", "-100"),
+		coinOperation(1, types.CoinCreated, "coin 7", "100"),
+	)
+	assert.NoError(t, blockStorage.AddBlock(ctx, block1b))
+	assert.NoError(t, blockStorage.AddBlock(ctx, block2b))
+	spendable("coin 7")
+	spent("coin 1", "coin 6")
+
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block2b.BlockIdentifier))
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block1b.BlockIdentifier))
+	spendable("coin 1")
+	spent("coin 6", "coin 7")
+	assert.Equal(t, int64(12), reversed())
+}
+
+func TestCoinReorgWorkerNotReversed(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	blockStorage, _, counterStorage, closeStorage := coinReorgTestStorage(ctx, t, dir, true)
+	defer closeStorage()
+
+	assert.NoError(t, blockStorage.AddBlock(
+		ctx,
+		coinReorgTestBlock(0, "", "", coinOperation(0, types.CoinCreated, "coin 1", "100")),
+	))
+	block1 := coinReorgTestBlock(
+		1, "a", "",
+		coinOperation(0, types.CoinCreated, "coin 2", "90"),
+	)
+	assert.NoError(t, blockStorage.AddBlock(ctx, block1))
+
+	err = blockStorage.RemoveBlock(ctx, block1.BlockIdentifier)
+	assert.True(t, errors.Is(err, results.ErrOrphanedCoinNotReversed))
+	assert.Contains(t, err.Error(), "coin 2 created in orphaned block 1 (1a)")
+
+	// Coins are only counted once reversals are verified.
+	count, err := counterStorage.Get(ctx, results.ReversedCoinCounter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count.Int64())
+
+	block1b := coinReorgTestBlock(
+		1, "b", "",
+		coinOperation(0, types.CoinSpent, "coin 1", "-100"),
+	)
+	dir2, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir2)
+
+	blockStorage, _, _, closeStorage2 := coinReorgTestStorage(ctx, t, dir2, true)
+	defer closeStorage2()
+
+	assert.NoError(t, blockStorage.AddBlock(
+		ctx,
+		coinReorgTestBlock(0, "", "", coinOperation(0, types.CoinCreated, "coin 1", "100")),
+	))
+	assert.NoError(t, blockStorage.AddBlock(ctx, block1b))
+
+	err = blockStorage.RemoveBlock(ctx, block1b.BlockIdentifier)
+	assert.True(t, errors.Is(err, results.ErrOrphanedCoinNotReversed))
+	assert.Contains(t, err.Error(), "coin 1 spent in orphaned block 1 (1b) was not restored")
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*CoinTrackingWorker)(nil)
+
+// CoinTrackingWorker implements the storage.BlockWorker
+// interface. It counts the coins created or spent in each
+// added block (so that the coin tracking test is only
+// reported when an implementation returns coins) and
+// returns an error if a coin is created with a negative
+// amount, spent with a positive amount, or spent without
+// ever being created.
+//
+// CoinTrackingWorker must run before *storage.CoinStorage
+// so that coins spent in a block have not been removed yet.
+type CoinTrackingWorker struct {
+	counterStorage *storage.CounterStorage
+	coinStorage    *storage.CoinStorage
+	asserter       *asserter.Asserter
+
+	// spendsVerified indicates if syncing started at genesis,
+	// in which case every spent coin must have been created.
+	spendsVerified bool
+}
+
+// NewCoinTrackingWorker returns a new *CoinTrackingWorker.
+func NewCoinTrackingWorker(
+	counterStorage *storage.CounterStorage,
+	coinStorage *storage.CoinStorage,
+	asserter *asserter.Asserter,
+	spendsVerified bool,
+) *CoinTrackingWorker {
+	return &CoinTrackingWorker{
+		counterStorage: counterStorage,
+		coinStorage:    coinStorage,
+		asserter:       asserter,
+		spendsVerified: spendsVerified,
+	}
+}
+
+// coinOperations returns all successful operations with
+// a coin change and an amount in block (the same operations
+// *storage.CoinStorage applies).
+func coinOperations(asserter *asserter.Asserter, block *types.Block) ([]*types.Operation, error) {
+	ops := []*types.Operation{}
+	for _, txn := range block.Transactions {
+		for _, op := range txn.Operations {
+			if op.CoinChange == nil || op.Amount == nil {
+				continue
+			}
+
+			success, err := asserter.OperationSuccessful(op)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to determine if operation is successful", err)
+			}
+
+			if success {
+				ops = append(ops, op)
+			}
+		}
+	}
+
+	return ops, nil
+}
+
+// verifyCoin returns an error if op has an amount with the
+// wrong sign for its coin action or spends a coin that was
+// never created (in an earlier block or in created).
+func (w *CoinTrackingWorker) verifyCoin(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	op *types.Operation,
+	created map[string]struct{},
+) error {
+	amount, err := types.AmountValue(op.Amount)
+	if err != nil {
+		return fmt.Errorf("%w: unable to parse amount", err)
+	}
+
+	identifier := op.CoinChange.CoinIdentifier
+	switch op.CoinChange.CoinAction {
+	case types.CoinCreated:
+		if amount.Sign() == -1 {
+			return fmt.Errorf(
+				"%w: coin %s created with amount %s",
+				results.ErrCoinAmountInvalid,
+				identifier.Identifier,
+				op.Amount.Value,
+			)
+		}
+	case types.CoinSpent:
+		if amount.Sign() == 1 {
+			return fmt.Errorf(
+				"%w: coin %s spent with amount %s",
+				results.ErrCoinAmountInvalid,
+				identifier.Identifier,
+				op.Amount.Value,
+			)
+		}
+
+		if _, ok := created[identifier.Identifier]; ok || !w.spendsVerified {
+			return nil
+		}
+
+		_, _, err := w.coinStorage.GetCoinTransactional(ctx, transaction, identifier)
+		if errors.Is(err, storage.ErrCoinNotFound) {
+			return fmt.Errorf("%w: %s", results.ErrCoinSpentBeforeCreated, identifier.Identifier)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: unable to get coin %s", err, identifier.Identifier)
+		}
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// The counter is updated in the same database transaction
+// as the addition.
+func (w *CoinTrackingWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	ops, err := coinOperations(w.asserter, block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	// Coins created in this block have not been stored
+	// yet, so they may be spent without being found in
+	// *storage.CoinStorage.
+	created := map[string]struct{}{}
+	for _, op := range ops {
+		if op.CoinChange.CoinAction == types.CoinCreated {
+			created[op.CoinChange.CoinIdentifier.Identifier] = struct{}{}
+		}
+	}
+
+	for _, op := range ops {
+		if err := w.verifyCoin(ctx, transaction, op, created); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		results.CoinCounter,
+		big.NewInt(int64(len(ops))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to update coin counter", err)
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// Coins in orphaned blocks are still counted because they were
+// seen while syncing.
+func (w *CoinTrackingWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func coinOperation(index int64, action types.CoinAction, coin string, value string) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{Index: index},
+		Type:                "Transfer",
+		Status:              "SUCCESS",
+		Account:             &types.AccountIdentifier{Address: "addr"},
+		Amount: &types.Amount{
+			Value:    value,
+			Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+		},
+		CoinChange: &types.CoinChange{
+			CoinIdentifier: &types.CoinIdentifier{Identifier: coin},
+			CoinAction:     action,
+		},
+	}
+}
+
+func coinTestBlock(index int64, ops ...*types.Operation) *types.Block {
+	parentIndex := index - 1
+	if parentIndex < 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("%d", index)},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parentIndex,
+			Hash:  fmt.Sprintf("%d", parentIndex),
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: ops,
+			},
+		},
+	}
+}
+
+func TestCoinTrackingWorker(t *testing.T) {
+	var tests = map[string]struct {
+		spendsVerified bool
+		blocks         []*types.Block
+
+		expectedCoins int64
+		expectedErr   error
+	}{
+		"no coins": {
+			spendsVerified: true,
+			blocks:         []*types.Block{orphanTestBlock(0, "0", "0", 2)},
+		},
+		"coins created and spent": {
+			spendsVerified: true,
+			blocks: []*types.Block{
+				coinTestBlock(0, coinOperation(0, types.CoinCreated, "coin 1", "100")),
+				coinTestBlock(
+					1,
+					coinOperation(0, types.CoinSpent, "coin 1", "-100"),
+					coinOperation(1, types.CoinCreated, "coin 2", "90"),
+					coinOperation(2, types.CoinSpent, "coin 2", "-90"),
+				),
+			},
+			expectedCoins: 4,
+		},
+		"coin created with negative amount": {
+			spendsVerified: true,
+			blocks: []*types.Block{
+				coinTestBlock(0, coinOperation(0, types.CoinCreated, "coin 1", "-100")),
+			},
+			expectedErr: results.ErrCoinAmountInvalid,
+		},
+		"coin spent with positive amount": {
+			spendsVerified: true,
+			blocks: []*types.Block{
+				coinTestBlock(0, coinOperation(0, types.CoinCreated, "coin 1", "100")),
+				coinTestBlock(1, coinOperation(0, types.CoinSpent, "coin 1", "100")),
+			},
+			expectedCoins: 1,
+			expectedErr:   results.ErrCoinAmountInvalid,
+		},
+		"coin spent before created": {
+			spendsVerified: true,
+			blocks: []*types.Block{
+				coinTestBlock(0, coinOperation(0, types.CoinSpent, "coin 1", "-100")),
+			},
+			expectedErr: results.ErrCoinSpentBeforeCreated,
+		},
+		"unknown coin spent without spend verification": {
+			blocks: []*types.Block{
+				coinTestBlock(0, coinOperation(0, types.CoinSpent, "coin 1", "-100")),
+			},
+			expectedCoins: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			localStore, err := storage.NewBadgerStorage(
+				ctx,
+				dir,
+				storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+			)
+			assert.NoError(t, err)
+			defer localStore.Close(ctx)
+
+			networkAsserter, err := asserter.NewClientWithOptions(
+				&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+				&types.BlockIdentifier{Index: 0, Hash: "0"},
+				[]string{"Transfer"},
+				[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+				[]*types.Error{},
+			)
+			assert.NoError(t, err)
+
+			counterStorage := storage.NewCounterStorage(localStore)
+			blockStorage := storage.NewBlockStorage(localStore)
+			coinStorage := storage.NewCoinStorage(
+				localStore,
+				NewCoinStorageHelper(blockStorage),
+				networkAsserter,
+			)
+			blockStorage.Initialize([]storage.BlockWorker{
+				NewCoinTrackingWorker(
+					counterStorage,
+					coinStorage,
+					networkAsserter,
+					test.spendsVerified,
+				),
+				coinStorage,
+			})
+
+			var addErr error
+			for _, block := range test.blocks {
+				if addErr = blockStorage.AddBlock(ctx, block); addErr != nil {
+					break
+				}
+			}
+
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(addErr, test.expectedErr))
+			} else {
+				assert.NoError(t, addErr)
+			}
+
+			coins, err := counterStorage.Get(ctx, results.CoinCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCoins, coins.Int64())
+		})
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// consistencyProbeLookback is the number of blocks
+	// below the head block a probed block is picked from.
+	consistencyProbeLookback = 1000
+
+	// maxConsistencyProbeFindings is the maximum number
+	// of divergences to record (all divergences are
+	// still counted).
+	maxConsistencyProbeFindings = 100
+
+	// maxDivergentFields is the maximum number of
+	// differing fields recorded for each divergence.
+	maxDivergentFields = 20
+)
+
+// ConsistencyProbe periodically picks an already synced block
+// and makes identical /block (and /account/balance) requests
+// in parallel to detect races in the implementation that cause
+// it to return different responses to the same request.
+//
+// Requests are made with the fetcher used to sync, so they
+// count against max_online_connections. Probe failures are
+// never fatal.
+type ConsistencyProbe struct {
+	network      *types.NetworkIdentifier
+	fetcher      *fetcher.Fetcher
+	blockStorage *storage.BlockStorage
+	requests     int
+	interval     time.Duration
+
+	// historicalBalanceEnabled determines if balances are
+	// requested at the probed block (otherwise, they are
+	// requested at the current block and only compared if
+	// all responses are at the same block).
+	historicalBalanceEnabled bool
+
+	results *results.ConsistencyProbeResults
+	mutex   sync.Mutex
+}
+
+// NewConsistencyProbe returns a new *ConsistencyProbe that
+// makes a number of identical requests (requests) in parallel
+// every interval.
+func NewConsistencyProbe(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	blockStorage *storage.BlockStorage,
+	requests int,
+	interval time.Duration,
+	historicalBalanceEnabled bool,
+) *ConsistencyProbe {
+	return &ConsistencyProbe{
+		network:                  network,
+		fetcher:                  fetcher,
+		blockStorage:             blockStorage,
+		requests:                 requests,
+		interval:                 interval,
+		historicalBalanceEnabled: historicalBalanceEnabled,
+		results: &results.ConsistencyProbeResults{
+			Findings: []*results.ConsistencyDivergence{},
+		},
+	}
+}
+
+// Results returns a copy of the *results.ConsistencyProbeResults
+// or nil if no probes were performed.
+func (p *ConsistencyProbe) Results() *results.ConsistencyProbeResults {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.results.Probes == 0 {
+		return nil
+	}
+
+	return &results.ConsistencyProbeResults{
+		Probes:      p.results.Probes,
+		Requests:    p.results.Requests,
+		Divergences: p.results.Divergences,
+		Findings:    append([]*results.ConsistencyDivergence{}, p.results.Findings...),
+	}
+}
+
+// Loop probes the implementation every interval
+// until the context is canceled.
+func (p *ConsistencyProbe) Loop(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := p.Probe(ctx); err != nil {
+				log.Printf("%s: unable to probe consistency\n", err.Error())
+			}
+		}
+	}
+}
+
+// Probe picks a synced block and compares the responses of
+// concurrent identical /block requests for it and then of
+// concurrent identical /account/balance requests for an
+// account in it.
+func (p *ConsistencyProbe) Probe(ctx context.Context) error {
+	head, err := p.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	lookback := head.Index + 1
+	if lookback > consistencyProbeLookback {
+		lookback = consistencyProbeLookback
+	}
+	index := head.Index - rand.Int63n(lookback) // nolint:gosec
+	block, err := p.blockStorage.GetBlock(ctx, &types.PartialBlockIdentifier{Index: &index})
+	if err != nil {
+		return fmt.Errorf("%w: unable to get block %d", err, index)
+	}
+
+	lookup := types.ConstructPartialBlockIdentifier(block.BlockIdentifier)
+	blocks, err := p.parallel(ctx, func(ctx context.Context) (interface{}, error) {
+		response, fetchErr := p.fetcher.Block(ctx, p.network, lookup)
+		if fetchErr != nil {
+			return nil, fetchErr.Err
+		}
+
+		return response, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch block %d", err, index)
+	}
+	p.compare(blockEndpoint, block.BlockIdentifier, nil, blocks)
+
+	account := probeAccount(block)
+	if account == nil {
+		return nil
+	}
+
+	var balanceLookup *types.PartialBlockIdentifier
+	if p.historicalBalanceEnabled {
+		balanceLookup = lookup
+	}
+
+	balances, err := p.parallel(ctx, func(ctx context.Context) (interface{}, error) {
+		balanceBlock, amounts, coins, metadata, fetchErr := p.fetcher.AccountBalance(
+			ctx,
+			p.network,
+			account,
+			balanceLookup,
+		)
+		if fetchErr != nil {
+			return nil, fetchErr.Err
+		}
+
+		return &types.AccountBalanceResponse{
+			BlockIdentifier: balanceBlock,
+			Balances:        amounts,
+			Coins:           coins,
+			Metadata:        metadata,
+		}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch balance of %s", err, types.PrintStruct(account))
+	}
+
+	// Balances at the current block can only be
+	// compared if the tip did not change between
+	// the requests.
+	balanceBlock := balances[0].(*types.AccountBalanceResponse).BlockIdentifier
+	for _, balance := range balances[1:] {
+		otherBlock := balance.(*types.AccountBalanceResponse).BlockIdentifier
+		if types.Hash(otherBlock) != types.Hash(balanceBlock) {
+			return nil
+		}
+	}
+	p.compare(accountBalanceEndpoint, balanceBlock, account, balances)
+
+	return nil
+}
+
+// parallel calls fetch p.requests times in parallel
+// and returns all responses (or the first error).
+func (p *ConsistencyProbe) parallel(
+	ctx context.Context,
+	fetch func(context.Context) (interface{}, error),
+) ([]interface{}, error) {
+	responses := make([]interface{}, p.requests)
+	errs := make([]error, p.requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = fetch(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return responses, nil
+}
+
+// compare records the outcome of a probe and
+// any divergence between responses.
+func (p *ConsistencyProbe) compare(
+	endpoint string,
+	block *types.BlockIdentifier,
+	account *types.AccountIdentifier,
+	responses []interface{},
+) {
+	distinct := map[string]struct{}{}
+	for _, response := range responses {
+		distinct[types.Hash(response)] = struct{}{}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.results.Probes++
+	p.results.Requests += int64(len(responses))
+	if len(distinct) == 1 {
+		return
+	}
+
+	p.results.Divergences++
+	fields := divergentFields(responses)
+	log.Printf(
+		"%d identical %s requests for block %d returned %d different responses (differing fields: %s)\n",
+		len(responses),
+		endpoint,
+		block.Index,
+		len(distinct),
+		strings.Join(fields, ", "),
+	)
+
+	if len(p.results.Findings) >= maxConsistencyProbeFindings {
+		return
+	}
+
+	p.results.Findings = append(p.results.Findings, &results.ConsistencyDivergence{
+		Endpoint:          endpoint,
+		Block:             block,
+		Account:           account,
+		DistinctResponses: len(distinct),
+		Fields:            fields,
+	})
+}
+
+// probeAccount returns the account of the first
+// operation in block with an account (or nil if
+// there is no such operation).
+func probeAccount(block *types.Block) *types.AccountIdentifier {
+	for _, txn := range block.Transactions {
+		for _, op := range txn.Operations {
+			if op.Account != nil {
+				return op.Account
+			}
+		}
+	}
+
+	return nil
+}
+
+// divergentFields returns the paths of the fields
+// that differ between the first response and any
+// other response (at most maxDivergentFields).
+func divergentFields(responses []interface{}) []string {
+	generic := make([]interface{}, len(responses))
+	for i, response := range responses {
+		// Responses are compared as JSON so that
+		// the paths match the response bodies.
+		b, err := json.Marshal(response)
+		if err != nil {
+			return []string{}
+		}
+
+		if err := json.Unmarshal(b, &generic[i]); err != nil {
+			return []string{}
+		}
+	}
+
+	seen := map[string]struct{}{}
+	fields := []string{}
+	for _, other := range generic[1:] {
+		diffFields("", generic[0], other, seen, &fields)
+	}
+
+	return fields
+}
+
+// diffFields appends the path of each field that
+// differs between a and b (that is not already in
+// seen) to fields.
+func diffFields(
+	path string,
+	a interface{},
+	b interface{},
+	seen map[string]struct{},
+	fields *[]string,
+) {
+	if len(*fields) >= maxDivergentFields {
+		return
+	}
+
+	appendField := func() {
+		field := path
+		if len(field) == 0 {
+			field = "(response)"
+		}
+
+		if _, ok := seen[field]; ok {
+			return
+		}
+
+		seen[field] = struct{}{}
+		*fields = append(*fields, field)
+	}
+
+	switch aValue := a.(type) {
+	case map[string]interface{}:
+		bValue, ok := b.(map[string]interface{})
+		if !ok {
+			appendField()
+			return
+		}
+
+		keys := []string{}
+		for key := range aValue {
+			keys = append(keys, key)
+		}
+		for key := range bValue {
+			if _, ok := aValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := key
+			if len(path) > 0 {
+				keyPath = path + "." + key
+			}
+
+			diffFields(keyPath, aValue[key], bValue[key], seen, fields)
+		}
+	case []interface{}:
+		bValue, ok := b.([]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			appendField()
+			return
+		}
+
+		for i := range aValue {
+			diffFields(fmt.Sprintf("%s[%d]", path, i), aValue[i], bValue[i], seen, fields)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			appendField()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDivergentFields(t *testing.T) {
+	block := orphanTestBlock(10, "10", "9", 2)
+	amount := &types.Amount{Value: "100", Currency: &types.Currency{Symbol: "BTC", Decimals: 8}}
+	block.Transactions[0].Operations[1].Amount = amount
+
+	differentAmount := orphanTestBlock(10, "10", "9", 2)
+	differentAmount.Transactions[0].Operations[1].Amount = &types.Amount{
+		Value:    "90",
+		Currency: amount.Currency,
+	}
+
+	missingOperation := orphanTestBlock(10, "10", "9", 1)
+
+	var tests = map[string]struct {
+		responses []interface{}
+
+		expected []string
+	}{
+		"identical": {
+			responses: []interface{}{block, block, block},
+			expected:  []string{},
+		},
+		"different value": {
+			responses: []interface{}{block, block, differentAmount},
+			expected:  []string{"transactions[0].operations[1].amount.value"},
+		},
+		"different lengths": {
+			responses: []interface{}{block, missingOperation, differentAmount},
+			expected: []string{
+				"transactions[0].operations",
+				"transactions[0].operations[1].amount.value",
+			},
+		},
+		"different types": {
+			responses: []interface{}{block, "block"},
+			expected:  []string{"(response)"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, divergentFields(test.responses))
+		})
+	}
+}
+
+func TestConsistencyProbeCompare(t *testing.T) {
+	probe := NewConsistencyProbe(nil, nil, nil, 3, time.Second, true)
+	assert.Nil(t, probe.Results())
+
+	block := orphanTestBlock(10, "10", "9", 1)
+	account := &types.AccountIdentifier{Address: "addr1"}
+	same := &types.AccountBalanceResponse{
+		BlockIdentifier: block.BlockIdentifier,
+		Balances: []*types.Amount{
+			{Value: "100", Currency: &types.Currency{Symbol: "BTC", Decimals: 8}},
+		},
+	}
+	different := &types.AccountBalanceResponse{
+		BlockIdentifier: block.BlockIdentifier,
+		Balances: []*types.Amount{
+			{Value: "90", Currency: &types.Currency{Symbol: "BTC", Decimals: 8}},
+		},
+	}
+
+	probe.compare(blockEndpoint, block.BlockIdentifier, nil, []interface{}{block, block, block})
+	probe.compare(
+		accountBalanceEndpoint,
+		block.BlockIdentifier,
+		account,
+		[]interface{}{same, different, same},
+	)
+
+	assert.Equal(t, &results.ConsistencyProbeResults{
+		Probes:      2,
+		Requests:    6,
+		Divergences: 1,
+		Findings: []*results.ConsistencyDivergence{
+			{
+				Endpoint:          accountBalanceEndpoint,
+				Block:             block.BlockIdentifier,
+				Account:           account,
+				DistinctResponses: 2,
+				Fields:            []string{"balances[0].value"},
+			},
+		},
+	}, probe.Results())
+
+	var nilProbe *ConsistencyProbe
+	assert.Nil(t, nilProbe.Results())
+}
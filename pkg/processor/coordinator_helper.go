@@ -70,6 +70,7 @@ type CoordinatorHelper struct {
 	counterStorage   *storage.CounterStorage
 
 	balanceStorageHelper *BalanceStorageHelper
+	broadcastRecovery    *BroadcastRecovery
 
 	// quiet determines if requests/responses logging
 	// should be silenced.
@@ -88,6 +89,7 @@ func NewCoordinatorHelper(
 	broadcastStorage *storage.BroadcastStorage,
 	balanceStorageHelper *BalanceStorageHelper,
 	counterStorage *storage.CounterStorage,
+	broadcastRecovery *BroadcastRecovery,
 	quiet bool,
 ) *CoordinatorHelper {
 	return &CoordinatorHelper{
@@ -101,6 +103,7 @@ func NewCoordinatorHelper(
 		broadcastStorage:     broadcastStorage,
 		counterStorage:       counterStorage,
 		balanceStorageHelper: balanceStorageHelper,
+		broadcastRecovery:    broadcastRecovery,
 		quiet:                quiet,
 	}
 }
@@ -470,7 +473,7 @@ func (c *CoordinatorHelper) Broadcast(
 		arg{argTransactionIdentifier, transactionIdentifier},
 		arg{argNetworkTransaction, payload},
 	)
-	return c.broadcastStorage.Broadcast(
+	if err := c.broadcastStorage.Broadcast(
 		ctx,
 		dbTx,
 		identifier,
@@ -479,7 +482,18 @@ func (c *CoordinatorHelper) Broadcast(
 		transactionIdentifier,
 		payload,
 		confirmationDepth,
-	)
+	); err != nil {
+		return err
+	}
+
+	// The broadcast is recorded in the same database
+	// transaction so that it can be recovered if
+	// check:construction stops before it is confirmed.
+	if c.broadcastRecovery == nil {
+		return nil
+	}
+
+	return c.broadcastRecovery.Record(ctx, dbTx, identifier, intent, transactionIdentifier)
 }
 
 // BroadcastAll attempts to broadcast all ready transactions.
@@ -0,0 +1,160 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+const (
+	// latencySamples is the most latencies kept for
+	// each endpoint to estimate the p95 latency. Once
+	// exceeded, a uniform sample of all latencies
+	// is kept (reservoir sampling).
+	latencySamples = 10000
+
+	// latencyPercentile is the percentile of
+	// latencies reported for each endpoint.
+	latencyPercentile = 0.95
+)
+
+var _ http.RoundTripper = (*EndpointLatency)(nil)
+
+// endpointLatencies are the latencies
+// recorded for a single endpoint.
+type endpointLatencies struct {
+	count   int64
+	errors  int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration
+}
+
+// EndpointLatency is an http.RoundTripper that records
+// the latency of each request by endpoint (the path of the
+// request). Requests are passed to the wrapped transport
+// unmodified, so retries (which are performed by the
+// fetcher) are each recorded as a separate request.
+type EndpointLatency struct {
+	transport http.RoundTripper
+
+	endpoints map[string]*endpointLatencies
+	random    *rand.Rand
+	mutex     sync.Mutex
+}
+
+// NewEndpointLatency returns a new *EndpointLatency
+// that wraps transport. If transport is nil,
+// http.DefaultTransport is used.
+func NewEndpointLatency(transport http.RoundTripper) *EndpointLatency {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &EndpointLatency{
+		transport: transport,
+		endpoints: map[string]*endpointLatencies{},
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (e *EndpointLatency) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := e.transport.RoundTrip(req)
+	e.record(req.URL.Path, time.Since(start), err != nil)
+
+	return resp, err
+}
+
+// record adds a request to endpoint
+// that took latency.
+func (e *EndpointLatency) record(endpoint string, latency time.Duration, failed bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	l, ok := e.endpoints[endpoint]
+	if !ok {
+		l = &endpointLatencies{}
+		e.endpoints[endpoint] = l
+	}
+
+	l.count++
+	l.total += latency
+	if failed {
+		l.errors++
+	}
+	if latency > l.max {
+		l.max = latency
+	}
+
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, latency)
+		return
+	}
+
+	if i := e.random.Int63n(l.count); i < latencySamples {
+		l.samples[i] = latency
+	}
+}
+
+// Results returns the *results.EndpointStats of each
+// endpoint requested (or nil if no requests were made).
+func (e *EndpointLatency) Results() map[string]*results.EndpointStats {
+	if e == nil {
+		return nil
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.endpoints) == 0 {
+		return nil
+	}
+
+	stats := make(map[string]*results.EndpointStats, len(e.endpoints))
+	for endpoint, l := range e.endpoints {
+		samples := make([]time.Duration, len(l.samples))
+		copy(samples, l.samples)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		// We use the nearest-rank method to
+		// select the percentile.
+		rank := int(math.Ceil(float64(len(samples))*latencyPercentile)) - 1
+
+		stats[endpoint] = &results.EndpointStats{
+			Count:  l.count,
+			Errors: l.errors,
+			Mean:   milliseconds(l.total / time.Duration(l.count)),
+			P95:    milliseconds(samples[rank]),
+			Max:    milliseconds(l.max),
+		}
+	}
+
+	return stats
+}
+
+// milliseconds returns d as a
+// fractional number of milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
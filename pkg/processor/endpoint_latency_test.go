@@ -0,0 +1,100 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingTransport struct{}
+
+func (f *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestEndpointLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var nilLatency *EndpointLatency
+	assert.Nil(t, nilLatency.Results())
+
+	e := NewEndpointLatency(nil)
+	assert.Nil(t, e.Results())
+
+	client := &http.Client{Transport: e}
+	for _, endpoint := range []string{"/block", "/block", "/account/balance"} {
+		resp, err := client.Post(server.URL+endpoint, "application/json", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	stats := e.Results()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, int64(2), stats["/block"].Count)
+	assert.Equal(t, int64(0), stats["/block"].Errors)
+	assert.GreaterOrEqual(t, stats["/block"].Mean, float64(10))
+	assert.GreaterOrEqual(t, stats["/block"].Max, stats["/block"].P95)
+	assert.GreaterOrEqual(t, stats["/block"].P95, stats["/block"].Mean)
+	assert.Equal(t, int64(1), stats["/account/balance"].Count)
+
+	(&results.CheckDataStats{EndpointLatency: stats}).Print() // make sure doesn't panic
+}
+
+func TestEndpointLatency_Errors(t *testing.T) {
+	e := NewEndpointLatency(&failingTransport{})
+	client := &http.Client{Transport: e}
+
+	_, err := client.Post("http://localhost/network/status", "application/json", nil)
+	assert.Error(t, err)
+
+	stats := e.Results()
+	assert.Equal(t, int64(1), stats["/network/status"].Count)
+	assert.Equal(t, int64(1), stats["/network/status"].Errors)
+}
+
+func TestEndpointLatency_Sampling(t *testing.T) {
+	e := NewEndpointLatency(nil)
+	requests := 2 * latencySamples
+	for i := 1; i <= requests; i++ {
+		e.record("/block", time.Duration(i)*time.Millisecond, false)
+	}
+
+	l := e.endpoints["/block"]
+	assert.Len(t, l.samples, latencySamples)
+
+	stats := e.Results()["/block"]
+	assert.Equal(t, int64(requests), stats.Count)
+	assert.Equal(t, float64(requests), stats.Max)
+	assert.InDelta(t, float64(requests)/2, stats.Mean, 1)
+
+	// The p95 latency is estimated from
+	// a uniform sample of all latencies.
+	assert.InDelta(t, 0.95*float64(requests), stats.P95, 0.02*float64(requests))
+}
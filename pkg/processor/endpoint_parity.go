@@ -0,0 +1,208 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// maxEndpointParityFindings is the maximum number
+// of findings to record (all disagreements are
+// still counted).
+const maxEndpointParityFindings = 100
+
+// EndpointParity compares each live balance fetched from
+// the primary Rosetta endpoint during reconciliation with
+// the live balance fetched from a secondary endpoint of the
+// same network (ex: an upgraded node run side by side).
+//
+// Secondary endpoint failures are only recorded, so they
+// never impact reconciliation against the primary endpoint.
+type EndpointParity struct {
+	network        *types.NetworkIdentifier
+	fetcher        *fetcher.Fetcher
+	counterStorage *storage.CounterStorage
+
+	results *results.EndpointParityResults
+
+	// unreconciled contains findings that are waiting
+	// for the computed balance from reconciliation.
+	unreconciled map[string]*results.EndpointParityFinding
+	mutex        sync.Mutex
+}
+
+// NewEndpointParity returns a new *EndpointParity that
+// fetches secondary live balances with fetcher.
+func NewEndpointParity(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	counterStorage *storage.CounterStorage,
+) *EndpointParity {
+	return &EndpointParity{
+		network:        network,
+		fetcher:        fetcher,
+		counterStorage: counterStorage,
+		results: &results.EndpointParityResults{
+			Findings: []*results.EndpointParityFinding{},
+		},
+		unreconciled: map[string]*results.EndpointParityFinding{},
+	}
+}
+
+// findingKey returns a unique key for a live
+// balance of an account at a block.
+func findingKey(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) string {
+	return fmt.Sprintf("%s:%s:%s", types.Hash(account), types.Hash(currency), types.Hash(block))
+}
+
+// Check fetches the live balance of account from the secondary
+// endpoint (at the same lookup block used for the primary
+// endpoint) and records any disagreement with primaryBalance.
+func (e *EndpointParity) Check(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	lookupBlock *types.BlockIdentifier,
+	primaryBalance *types.Amount,
+	primaryBlock *types.BlockIdentifier,
+) {
+	if e == nil {
+		return
+	}
+
+	secondaryBalance, secondaryBlock, _, err := utils.CurrencyBalance(
+		ctx,
+		e.network,
+		e.fetcher,
+		account,
+		currency,
+		lookupBlock,
+	)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err != nil {
+		log.Printf(
+			"%s: unable to fetch secondary balance for %s\n",
+			err.Error(),
+			types.PrintStruct(account),
+		)
+
+		e.results.SecondaryFailures++
+		return
+	}
+
+	// Without a lookup block, the endpoints may
+	// return balances at different blocks that
+	// can't be compared.
+	if types.Hash(secondaryBlock) != types.Hash(primaryBlock) {
+		e.results.Skipped++
+		return
+	}
+
+	e.results.Checks++
+	if secondaryBalance.Value == primaryBalance.Value {
+		return
+	}
+
+	e.results.Disagreements++
+	_, _ = e.counterStorage.Update(ctx, results.EndpointDisagreementCounter, big.NewInt(1))
+
+	if len(e.results.Findings) >= maxEndpointParityFindings {
+		return
+	}
+
+	finding := &results.EndpointParityFinding{
+		Account:          account,
+		Currency:         currency,
+		Block:            primaryBlock,
+		PrimaryBalance:   primaryBalance.Value,
+		SecondaryBalance: secondaryBalance.Value,
+	}
+	e.results.Findings = append(e.results.Findings, finding)
+	e.unreconciled[findingKey(account, currency, primaryBlock)] = finding
+}
+
+// Reconciled populates the computed balance of any finding
+// for account at block. This is called once the primary
+// live balance has been reconciled (whether or not the
+// reconciliation succeeded).
+func (e *EndpointParity) Reconciled(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+	computedBalance string,
+) {
+	if e == nil {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	key := findingKey(account, currency, block)
+	finding, ok := e.unreconciled[key]
+	if !ok {
+		return
+	}
+
+	finding.ComputedBalance = computedBalance
+	delete(e.unreconciled, key)
+}
+
+// Results returns a copy of the *results.EndpointParityResults
+// or nil if no secondary balances were fetched.
+func (e *EndpointParity) Results() *results.EndpointParityResults {
+	if e == nil {
+		return nil
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.results.Checks == 0 && e.results.Skipped == 0 && e.results.SecondaryFailures == 0 {
+		return nil
+	}
+
+	findings := make([]*results.EndpointParityFinding, len(e.results.Findings))
+	for i, finding := range e.results.Findings {
+		findingCopy := *finding
+		findings[i] = &findingCopy
+	}
+
+	return &results.EndpointParityResults{
+		Checks:            e.results.Checks,
+		Disagreements:     e.results.Disagreements,
+		Skipped:           e.results.Skipped,
+		SecondaryFailures: e.results.SecondaryFailures,
+		Findings:          findings,
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointParity(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	account := &types.AccountIdentifier{Address: "addr"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	block := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+	primaryBalance := &types.Amount{Value: "100", Currency: currency}
+
+	var tests = map[string]struct {
+		lookupBlock *types.BlockIdentifier
+		secondary   *types.AccountBalanceResponse
+
+		expected              *results.EndpointParityResults
+		endpointDisagreements int64
+	}{
+		"agree": {
+			lookupBlock: block,
+			secondary: &types.AccountBalanceResponse{
+				BlockIdentifier: block,
+				Balances:        []*types.Amount{primaryBalance},
+			},
+			expected: &results.EndpointParityResults{
+				Checks:   1,
+				Findings: []*results.EndpointParityFinding{},
+			},
+		},
+		"disagree": {
+			lookupBlock: block,
+			secondary: &types.AccountBalanceResponse{
+				BlockIdentifier: block,
+				Balances:        []*types.Amount{{Value: "90", Currency: currency}},
+			},
+			expected: &results.EndpointParityResults{
+				Checks:        1,
+				Disagreements: 1,
+				Findings: []*results.EndpointParityFinding{
+					{
+						Account:          account,
+						Currency:         currency,
+						Block:            block,
+						ComputedBalance:  "100",
+						PrimaryBalance:   "100",
+						SecondaryBalance: "90",
+					},
+				},
+			},
+			endpointDisagreements: 1,
+		},
+		"different block": {
+			secondary: &types.AccountBalanceResponse{
+				BlockIdentifier: &types.BlockIdentifier{Index: 11, Hash: "block 11"},
+				Balances:        []*types.Amount{{Value: "90", Currency: currency}},
+			},
+			expected: &results.EndpointParityResults{
+				Skipped:  1,
+				Findings: []*results.EndpointParityFinding{},
+			},
+		},
+		"secondary failure": {
+			lookupBlock: block,
+			secondary: &types.AccountBalanceResponse{
+				BlockIdentifier: block,
+				Balances: []*types.Amount{
+					{Value: "100", Currency: &types.Currency{Symbol: "ETH", Decimals: 18}},
+				},
+			},
+			expected: &results.EndpointParityResults{
+				SecondaryFailures: 1,
+				Findings:          []*results.EndpointParityFinding{},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			localStore, err := storage.NewBadgerStorage(
+				ctx,
+				dir,
+				storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+			)
+			assert.NoError(t, err)
+			defer localStore.Close(ctx)
+
+			counterStorage := storage.NewCounterStorage(localStore)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, accountBalanceEndpoint, r.URL.Path)
+
+				var request types.AccountBalanceRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				assert.Equal(t, network, request.NetworkIdentifier)
+				assert.Equal(t, account, request.AccountIdentifier)
+				if test.lookupBlock != nil {
+					assert.Equal(
+						t,
+						types.ConstructPartialBlockIdentifier(test.lookupBlock),
+						request.BlockIdentifier,
+					)
+				} else {
+					assert.Nil(t, request.BlockIdentifier)
+				}
+
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.WriteHeader(http.StatusOK)
+				assert.NoError(t, json.NewEncoder(w).Encode(test.secondary))
+			}))
+			defer server.Close()
+
+			parity := NewEndpointParity(network, fetcher.New(server.URL), counterStorage)
+			assert.Nil(t, parity.Results())
+
+			parity.Check(ctx, account, currency, test.lookupBlock, primaryBalance, block)
+			parity.Reconciled(account, currency, block, "100")
+			assert.Equal(t, test.expected, parity.Results())
+
+			disagreements, err := counterStorage.Get(ctx, results.EndpointDisagreementCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.endpointDisagreements, disagreements.Int64())
+		})
+	}
+}
+
+func TestEndpointParity_Nil(t *testing.T) {
+	var parity *EndpointParity
+	parity.Check(context.Background(), nil, nil, nil, nil, nil)
+	parity.Reconciled(nil, nil, nil, "100")
+	assert.Nil(t, parity.Results())
+}
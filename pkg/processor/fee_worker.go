@@ -0,0 +1,463 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// feeBlockNamespace is prepended to the index
+	// of each block to get its *feeBlockRecord.
+	feeBlockNamespace = "fee-block"
+
+	// feeCurrencyNamespace is prepended to the hash
+	// of each currency to get its *feeCurrencyRecord.
+	feeCurrencyNamespace = "fee-currency"
+)
+
+var _ storage.BlockWorker = (*FeeWorker)(nil)
+
+func getFeeBlockKey(index int64) []byte {
+	return []byte(fmt.Sprintf("%s/%d", feeBlockNamespace, index))
+}
+
+func getFeeCurrencyKey(currencyHash string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", feeCurrencyNamespace, currencyHash))
+}
+
+// feeBlockRecord is stored for each added block so that
+// its fees can be subtracted if the block is orphaned.
+// Fees is keyed by the hash of each currency.
+type feeBlockRecord struct {
+	Fees    map[string]string `json:"fees"`
+	ZeroFee bool              `json:"zero_fee"`
+}
+
+// feeCurrencyRecord contains the fees paid
+// in a currency over all canonical blocks.
+type feeCurrencyRecord struct {
+	Currency          *types.Currency `json:"currency"`
+	Total             string          `json:"total"`
+	LargestBlockTotal string          `json:"largest_block_total"`
+	LargestBlockIndex int64           `json:"largest_block_index"`
+}
+
+// FeeWorker implements the storage.BlockWorker interface.
+// It sums the amounts debited by operations of the fee
+// operation types in each added block (per currency) and
+// counts blocks with transactions but no fees.
+type FeeWorker struct {
+	counterStorage    *storage.CounterStorage
+	database          storage.Database
+	feeOperationTypes []string
+	feeTypes          map[string]struct{}
+}
+
+// NewFeeWorker returns a new *FeeWorker.
+func NewFeeWorker(
+	counterStorage *storage.CounterStorage,
+	database storage.Database,
+	feeOperationTypes []string,
+) *FeeWorker {
+	feeTypes := make(map[string]struct{}, len(feeOperationTypes))
+	for _, feeType := range feeOperationTypes {
+		feeTypes[feeType] = struct{}{}
+	}
+
+	return &FeeWorker{
+		counterStorage:    counterStorage,
+		database:          database,
+		feeOperationTypes: feeOperationTypes,
+		feeTypes:          feeTypes,
+	}
+}
+
+// blockFees returns the fees paid in block
+// (keyed by currency hash) and each currency.
+func (w *FeeWorker) blockFees(
+	block *types.Block,
+) (map[string]*big.Int, map[string]*types.Currency, error) {
+	fees := map[string]*big.Int{}
+	currencies := map[string]*types.Currency{}
+	for _, txn := range block.Transactions {
+		for _, op := range txn.Operations {
+			if _, ok := w.feeTypes[op.Type]; !ok || op.Amount == nil {
+				continue
+			}
+
+			value, ok := new(big.Int).SetString(op.Amount.Value, 10)
+			if !ok {
+				return nil, nil, fmt.Errorf(
+					"%s is not an integer in block %d",
+					op.Amount.Value,
+					block.BlockIdentifier.Index,
+				)
+			}
+
+			// Only debits are counted so fees credited to
+			// a block producer are not counted twice.
+			if value.Sign() >= 0 {
+				continue
+			}
+
+			key := types.Hash(op.Amount.Currency)
+			if _, ok := fees[key]; !ok {
+				fees[key] = new(big.Int)
+				currencies[key] = op.Amount.Currency
+			}
+			fees[key].Sub(fees[key], value)
+		}
+	}
+
+	return fees, currencies, nil
+}
+
+func (w *FeeWorker) getBlockRecord(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	index int64,
+) (*feeBlockRecord, error) {
+	exists, val, err := transaction.Get(ctx, getFeeBlockKey(index))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get fees for block %d", err, index)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	var record feeBlockRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode fees for block %d", err, index)
+	}
+
+	return &record, nil
+}
+
+func (w *FeeWorker) getCurrencyRecord(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	currencyHash string,
+) (*feeCurrencyRecord, error) {
+	exists, val, err := transaction.Get(ctx, getFeeCurrencyKey(currencyHash))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get fees for currency %s", err, currencyHash)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	var record feeCurrencyRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode fees for currency %s", err, currencyHash)
+	}
+
+	return &record, nil
+}
+
+func (w *FeeWorker) set(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	key []byte,
+	record interface{},
+) error {
+	val, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode %s", err, string(key))
+	}
+
+	if err := transaction.Set(ctx, key, val, true); err != nil {
+		return fmt.Errorf("%w: unable to store %s", err, string(key))
+	}
+
+	return nil
+}
+
+// parseAmount parses an amount stored by the FeeWorker.
+func parseAmount(value string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("unable to parse stored fee %s", value)
+	}
+
+	return amount, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// The fees are updated in the same database transaction as
+// the addition.
+func (w *FeeWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	fees, currencies, err := w.blockFees(block)
+	if err != nil {
+		return nil, err
+	}
+
+	index := block.BlockIdentifier.Index
+	record := &feeBlockRecord{
+		Fees:    make(map[string]string, len(fees)),
+		ZeroFee: len(block.Transactions) > 0 && len(fees) == 0,
+	}
+
+	for key, fee := range fees {
+		record.Fees[key] = fee.String()
+
+		currencyRecord, err := w.getCurrencyRecord(ctx, transaction, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if currencyRecord == nil {
+			currencyRecord = &feeCurrencyRecord{
+				Currency:          currencies[key],
+				Total:             "0",
+				LargestBlockTotal: "0",
+			}
+		}
+
+		total, err := parseAmount(currencyRecord.Total)
+		if err != nil {
+			return nil, err
+		}
+		currencyRecord.Total = new(big.Int).Add(total, fee).String()
+
+		largest, err := parseAmount(currencyRecord.LargestBlockTotal)
+		if err != nil {
+			return nil, err
+		}
+
+		if fee.Cmp(largest) > 0 {
+			currencyRecord.LargestBlockTotal = fee.String()
+			currencyRecord.LargestBlockIndex = index
+		}
+
+		if err := w.set(ctx, transaction, getFeeCurrencyKey(key), currencyRecord); err != nil {
+			return nil, err
+		}
+	}
+
+	if record.ZeroFee {
+		_, err := w.counterStorage.UpdateTransactional(
+			ctx,
+			transaction,
+			results.ZeroFeeBlockCounter,
+			big.NewInt(1),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to update zero fee block counter", err)
+		}
+	}
+
+	return nil, w.set(ctx, transaction, getFeeBlockKey(index), record)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// The fees of the block are subtracted in the same database
+// transaction as the removal.
+func (w *FeeWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	index := block.BlockIdentifier.Index
+	record, err := w.getBlockRecord(ctx, transaction, index)
+	if err != nil {
+		return nil, err
+	}
+
+	// The block was added before fees were tracked.
+	if record == nil {
+		return nil, nil
+	}
+
+	if err := transaction.Delete(ctx, getFeeBlockKey(index)); err != nil {
+		return nil, fmt.Errorf("%w: unable to delete fees for block %d", err, index)
+	}
+
+	if record.ZeroFee {
+		_, err := w.counterStorage.UpdateTransactional(
+			ctx,
+			transaction,
+			results.ZeroFeeBlockCounter,
+			big.NewInt(-1),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to update zero fee block counter", err)
+		}
+	}
+
+	for key, value := range record.Fees {
+		fee, err := parseAmount(value)
+		if err != nil {
+			return nil, err
+		}
+
+		currencyRecord, err := w.getCurrencyRecord(ctx, transaction, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if currencyRecord == nil {
+			return nil, fmt.Errorf("fees for currency %s not found", key)
+		}
+
+		total, err := parseAmount(currencyRecord.Total)
+		if err != nil {
+			return nil, err
+		}
+		currencyRecord.Total = new(big.Int).Sub(total, fee).String()
+
+		// The largest block total was in the removed block,
+		// so we find the largest remaining block total.
+		if currencyRecord.LargestBlockIndex == index {
+			largest, largestIndex, err := w.largestBlockTotal(ctx, transaction, key, index)
+			if err != nil {
+				return nil, err
+			}
+
+			currencyRecord.LargestBlockTotal = largest.String()
+			currencyRecord.LargestBlockIndex = largestIndex
+		}
+
+		if err := w.set(ctx, transaction, getFeeCurrencyKey(key), currencyRecord); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// largestBlockTotal returns the largest total paid in a
+// currency in any block other than excludedIndex (and the
+// index of that block).
+func (w *FeeWorker) largestBlockTotal(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	currencyHash string,
+	excludedIndex int64,
+) (*big.Int, int64, error) {
+	largest := new(big.Int)
+	var largestIndex int64
+	_, err := transaction.Scan(
+		ctx,
+		[]byte(feeBlockNamespace+"/"),
+		func(k []byte, v []byte) error {
+			var index int64
+			if _, err := fmt.Sscanf(string(k), feeBlockNamespace+"/%d", &index); err != nil {
+				return fmt.Errorf("%w: unable to parse fee key %s", err, string(k))
+			}
+
+			if index == excludedIndex {
+				return nil
+			}
+
+			var record feeBlockRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("%w: unable to decode fees for block %d", err, index)
+			}
+
+			value, ok := record.Fees[currencyHash]
+			if !ok {
+				return nil
+			}
+
+			fee, err := parseAmount(value)
+			if err != nil {
+				return err
+			}
+
+			cmp := fee.Cmp(largest)
+			if cmp > 0 || (cmp == 0 && fee.Sign() > 0 && index < largestIndex) {
+				largest = fee
+				largestIndex = index
+			}
+
+			return nil
+		},
+		false,
+	)
+	if err != nil {
+		return nil, -1, fmt.Errorf("%w: unable to scan block fees", err)
+	}
+
+	return largest, largestIndex, nil
+}
+
+// Results returns the *results.FeeStats of all
+// canonical blocks (sorted by currency).
+func (w *FeeWorker) Results(ctx context.Context) (*results.FeeStats, error) {
+	zeroFeeBlocks, err := w.counterStorage.Get(ctx, results.ZeroFeeBlockCounter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get zero fee block counter", err)
+	}
+
+	stats := &results.FeeStats{
+		FeeOperationTypes: w.feeOperationTypes,
+		Currencies:        []*results.FeeCurrencyStats{},
+		ZeroFeeBlocks:     zeroFeeBlocks.Int64(),
+	}
+
+	transaction := w.database.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	_, err = transaction.Scan(
+		ctx,
+		[]byte(feeCurrencyNamespace+"/"),
+		func(k []byte, v []byte) error {
+			var record feeCurrencyRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("%w: unable to decode currency fees", err)
+			}
+
+			stats.Currencies = append(stats.Currencies, &results.FeeCurrencyStats{
+				Currency:          record.Currency,
+				Total:             record.Total,
+				LargestBlockTotal: record.LargestBlockTotal,
+				LargestBlockIndex: record.LargestBlockIndex,
+			})
+
+			return nil
+		},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan currency fees", err)
+	}
+
+	sort.Slice(stats.Currencies, func(i, j int) bool {
+		a, b := stats.Currencies[i].Currency, stats.Currencies[j].Currency
+		if a.Symbol == b.Symbol {
+			return a.Decimals < b.Decimals
+		}
+
+		return a.Symbol < b.Symbol
+	})
+
+	return stats, nil
+}
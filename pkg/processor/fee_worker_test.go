@@ -0,0 +1,151 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	feeTestBTC = &types.Currency{Symbol: "BTC", Decimals: 8}
+	feeTestETH = &types.Currency{Symbol: "ETH", Decimals: 18}
+)
+
+// feeTestBlock returns a block with a transaction for each
+// fee. Each fee is paid by a "Fee" operation that debits the
+// fee and a "Fee" operation that credits it to the block
+// producer. An empty fee is a transaction without fees.
+func feeTestBlock(
+	index int64,
+	hash string,
+	parentHash string,
+	currency *types.Currency,
+	fees ...string,
+) *types.Block {
+	opCounts := make([]int, len(fees))
+	for i := range fees {
+		opCounts[i] = 2
+	}
+
+	block := orphanTestBlock(index, hash, parentHash, opCounts...)
+	for i, fee := range fees {
+		if len(fee) == 0 {
+			continue
+		}
+
+		debit, credit := block.Transactions[i].Operations[0], block.Transactions[i].Operations[1]
+		debit.Type, credit.Type = "Fee", "Fee"
+		debit.Amount = &types.Amount{Value: "-" + fee, Currency: currency}
+		credit.Amount = &types.Amount{Value: fee, Currency: currency}
+	}
+
+	return block
+}
+
+func TestFeeWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	worker := NewFeeWorker(counterStorage, localStore, []string{"Fee"})
+	blockStorage.Initialize([]storage.BlockWorker{worker})
+
+	feeStats := func() *results.FeeStats {
+		stats, err := worker.Results(ctx)
+		assert.NoError(t, err)
+
+		return stats
+	}
+
+	// A block without transactions is not a zero fee block.
+	assert.NoError(t, blockStorage.AddBlock(ctx, feeTestBlock(0, "0", "0", feeTestBTC)))
+	assert.Equal(t, &results.FeeStats{
+		FeeOperationTypes: []string{"Fee"},
+		Currencies:        []*results.FeeCurrencyStats{},
+	}, feeStats())
+
+	assert.NoError(t, blockStorage.AddBlock(ctx, feeTestBlock(1, "1", "0", feeTestBTC, "10", "5")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, feeTestBlock(2, "2", "1", feeTestETH, "7")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, feeTestBlock(3, "3", "2", feeTestBTC, "")))
+	assert.Equal(t, &results.FeeStats{
+		FeeOperationTypes: []string{"Fee"},
+		Currencies: []*results.FeeCurrencyStats{
+			{
+				Currency:          feeTestBTC,
+				Total:             "15",
+				LargestBlockTotal: "15",
+				LargestBlockIndex: 1,
+			},
+			{
+				Currency:          feeTestETH,
+				Total:             "7",
+				LargestBlockTotal: "7",
+				LargestBlockIndex: 2,
+			},
+		},
+		ZeroFeeBlocks: 1,
+	}, feeStats())
+
+	// Orphaning the block with the largest total
+	// restores the previous largest block total.
+	block4a := feeTestBlock(4, "4a", "3", feeTestBTC, "20")
+	assert.NoError(t, blockStorage.AddBlock(ctx, block4a))
+	assert.Equal(t, "35", feeStats().Currencies[0].Total)
+	assert.Equal(t, int64(4), feeStats().Currencies[0].LargestBlockIndex)
+
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block4a.BlockIdentifier))
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, &types.BlockIdentifier{Index: 3, Hash: "3"}))
+	assert.Equal(t, &results.FeeStats{
+		FeeOperationTypes: []string{"Fee"},
+		Currencies: []*results.FeeCurrencyStats{
+			{
+				Currency:          feeTestBTC,
+				Total:             "15",
+				LargestBlockTotal: "15",
+				LargestBlockIndex: 1,
+			},
+			{
+				Currency:          feeTestETH,
+				Total:             "7",
+				LargestBlockTotal: "7",
+				LargestBlockIndex: 2,
+			},
+		},
+	}, feeStats())
+
+	// Fees that are not integers are rejected.
+	assert.Error(t, blockStorage.AddBlock(ctx, feeTestBlock(3, "3", "2", feeTestBTC, "1.5")))
+	assert.Equal(t, "15", feeStats().Currencies[0].Total)
+}
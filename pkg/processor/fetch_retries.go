@@ -0,0 +1,168 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// maxPendingRetries is the most failed requests
+// remembered while waiting for them to be retried.
+// Once exceeded, all are forgotten (failed requests
+// the fetcher gave up on are otherwise never removed).
+const maxPendingRetries = 10000
+
+var _ http.RoundTripper = (*FetchRetries)(nil)
+
+// FetchRetries is an http.RoundTripper that counts the
+// requests that failed (a transport error or a non-200
+// response) and the requests that repeated a failed request
+// (the same endpoint and body). The fetcher retries failed
+// requests internally, so these counts are the only record
+// of an unstable node in a run that ultimately passes.
+//
+// Requests are only counted once a *storage.CounterStorage
+// is provided with SetCounterStorage.
+type FetchRetries struct {
+	transport http.RoundTripper
+
+	counterStorage *storage.CounterStorage
+	pending        map[string]int
+	mutex          sync.Mutex
+}
+
+// NewFetchRetries returns a new *FetchRetries
+// that wraps transport. If transport is nil,
+// http.DefaultTransport is used.
+func NewFetchRetries(transport http.RoundTripper) *FetchRetries {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &FetchRetries{
+		transport: transport,
+		pending:   map[string]int{},
+	}
+}
+
+// SetCounterStorage sets the *storage.CounterStorage
+// updated by all subsequent requests.
+func (f *FetchRetries) SetCounterStorage(counterStorage *storage.CounterStorage) {
+	if f == nil {
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.counterStorage = counterStorage
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (f *FetchRetries) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mutex.Lock()
+	counterStorage := f.counterStorage
+	f.mutex.Unlock()
+
+	if counterStorage == nil {
+		return f.transport.RoundTrip(req)
+	}
+
+	key := requestKey(req)
+	if f.retried(key) {
+		_, _ = counterStorage.Update(req.Context(), results.FetchRetryCounter, big.NewInt(1))
+	}
+
+	resp, err := f.transport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		f.succeeded(key)
+		return resp, err
+	}
+
+	f.failed(key)
+	_, _ = counterStorage.Update(req.Context(), results.FetchFailureCounter, big.NewInt(1))
+
+	return resp, err
+}
+
+// retried returns a boolean indicating if a request
+// with key repeats a failed request (which is then
+// no longer pending).
+func (f *FetchRetries) retried(key string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.pending[key] == 0 {
+		return false
+	}
+
+	f.pending[key]--
+	if f.pending[key] == 0 {
+		delete(f.pending, key)
+	}
+
+	return true
+}
+
+// succeeded forgets any failed
+// requests with key.
+func (f *FetchRetries) succeeded(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.pending, key)
+}
+
+// failed records a failed request with key
+// that may be retried.
+func (f *FetchRetries) failed(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.pending) >= maxPendingRetries {
+		f.pending = map[string]int{}
+	}
+
+	f.pending[key]++
+}
+
+// requestKey returns a key that is the same for
+// requests to the same endpoint with the same body.
+func requestKey(req *http.Request) string {
+	if req.GetBody == nil {
+		return req.URL.Path
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return req.URL.Path
+	}
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return req.URL.Path
+	}
+
+	return req.URL.Path + ":" + types.Hash(string(b))
+}
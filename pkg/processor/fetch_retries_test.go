@@ -0,0 +1,109 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchRetries(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+	counterStorage := storage.NewCounterStorage(localStore)
+
+	// The first request for each body fails.
+	seen := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !seen[string(body)] {
+			seen[string(body)] = true
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f := NewFetchRetries(nil)
+	client := &http.Client{Transport: f}
+	post := func(body string) {
+		resp, err := client.Post(server.URL+"/block", "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	// Requests are not counted before
+	// counter storage is set.
+	post(`{"index":0}`)
+	post(`{"index":0}`)
+
+	f.SetCounterStorage(counterStorage)
+	post(`{"index":1}`) // fails
+	post(`{"index":2}`) // fails
+	post(`{"index":1}`) // retry
+	post(`{"index":1}`) // not a retry (previous request succeeded)
+	post(`{"index":2}`) // retry
+
+	retries, err := counterStorage.Get(ctx, results.FetchRetryCounter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), retries.Int64())
+
+	failures, err := counterStorage.Get(ctx, results.FetchFailureCounter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), failures.Int64())
+
+	// Transport errors are failures.
+	f = NewFetchRetries(&failingTransport{})
+	f.SetCounterStorage(counterStorage)
+	client = &http.Client{Transport: f}
+	for i := 0; i < 2; i++ {
+		_, err = client.Post("http://localhost/network/status", "application/json", nil)
+		assert.Error(t, err)
+	}
+
+	retries, err = counterStorage.Get(ctx, results.FetchRetryCounter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), retries.Int64())
+
+	failures, err = counterStorage.Get(ctx, results.FetchFailureCounter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), failures.Int64())
+
+	var nilRetries *FetchRetries
+	nilRetries.SetCounterStorage(counterStorage) // make sure doesn't panic
+}
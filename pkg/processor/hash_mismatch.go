@@ -0,0 +1,142 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// ErrHashMismatch is returned when a broadcast transaction
+// is found on-chain under a different hash than the one
+// returned by /construction/hash.
+var ErrHashMismatch = errors.New(
+	"transaction was found on-chain under a different hash than /construction/hash returned",
+)
+
+// HashMismatchFinder searches recent blocks for a transaction
+// matching the intent of a broadcast that was not found by
+// its predicted hash. Transactions are matched with the same
+// logic used to verify confirmed transactions against their
+// intent.
+type HashMismatchFinder struct {
+	blockStorage     *storage.BlockStorage
+	broadcastStorage *storage.BroadcastStorage
+	parser           *parser.Parser
+	depth            int64
+}
+
+// NewHashMismatchFinder returns a new *HashMismatchFinder
+// that searches the last depth blocks.
+func NewHashMismatchFinder(
+	blockStorage *storage.BlockStorage,
+	broadcastStorage *storage.BroadcastStorage,
+	parser *parser.Parser,
+	depth int64,
+) *HashMismatchFinder {
+	return &HashMismatchFinder{
+		blockStorage:     blockStorage,
+		broadcastStorage: broadcastStorage,
+		parser:           parser,
+		depth:            depth,
+	}
+}
+
+// Find returns the identifier of a transaction in the last
+// depth blocks that matches the intent of the broadcast
+// created by the job identifier but that does not have the
+// predicted hash (or nil if there is no such transaction).
+func (h *HashMismatchFinder) Find(
+	ctx context.Context,
+	identifier string,
+	predicted *types.TransactionIdentifier,
+) (*types.BlockIdentifier, *types.TransactionIdentifier, error) {
+	intent, err := h.intent(ctx, identifier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(intent) == 0 {
+		return nil, nil, nil
+	}
+
+	head, err := h.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	for index := head.Index; index >= 0 && index > head.Index-h.depth; index-- {
+		lookupIndex := index
+		block, err := h.blockStorage.GetBlock(
+			ctx,
+			&types.PartialBlockIdentifier{Index: &lookupIndex},
+		)
+		if errors.Is(err, storage.ErrBlockNotFound) {
+			// Blocks below this one may have been pruned.
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: unable to get block %d", err, index)
+		}
+
+		for _, txn := range block.Transactions {
+			if txn.TransactionIdentifier.Hash == predicted.Hash {
+				continue
+			}
+
+			if err := h.parser.ExpectedOperations(
+				intent,
+				txn.Operations,
+				false,
+				true,
+			); err != nil {
+				continue
+			}
+
+			return block.BlockIdentifier, txn.TransactionIdentifier, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// intent returns the intent of the broadcast created by
+// the job identifier (or nil if it is no longer
+// broadcasting).
+func (h *HashMismatchFinder) intent(
+	ctx context.Context,
+	identifier string,
+) ([]*types.Operation, error) {
+	broadcasts, err := h.broadcastStorage.GetAllBroadcasts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get broadcasts", err)
+	}
+
+	for _, broadcast := range broadcasts {
+		if broadcast.Identifier == identifier {
+			return broadcast.Intent, nil
+		}
+	}
+
+	return nil, nil
+}
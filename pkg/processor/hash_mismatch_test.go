@@ -0,0 +1,130 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashMismatchFinder(t *testing.T) {
+	ctx := context.Background()
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	run := newRecoveryTestRun(ctx, t, dir, network, nil)
+	defer run.database.Close(ctx)
+
+	networkAsserter, err := asserter.NewClientWithOptions(
+		network,
+		&types.BlockIdentifier{Index: 0, Hash: "0"},
+		[]string{"Transfer"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+		},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	finder := NewHashMismatchFinder(
+		run.blockStorage,
+		run.broadcastStorage,
+		parser.New(networkAsserter, nil),
+		2,
+	)
+
+	identifier := run.queue(ctx, t, network, "predicted", "addr 1", true)
+	predicted := &types.TransactionIdentifier{Hash: "predicted"}
+
+	// No blocks have been synced.
+	block, found, err := finder.Find(ctx, identifier, predicted)
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.Nil(t, found)
+
+	// The transaction matching the intent lands in block 1
+	// under a different hash.
+	onChain := &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "actual"},
+		Operations: []*types.Operation{
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 0},
+				Type:                "Transfer",
+				Status:              types.String("SUCCESS"),
+				Account:             &types.AccountIdentifier{Address: "addr 1"},
+				Amount: &types.Amount{
+					Value:    "-10",
+					Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+				},
+				CoinChange: &types.CoinChange{
+					CoinIdentifier: &types.CoinIdentifier{Identifier: "addr 1 coin"},
+					CoinAction:     types.CoinSpent,
+				},
+			},
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 1},
+				Type:                "Transfer",
+				Status:              types.String("SUCCESS"),
+				Account:             &types.AccountIdentifier{Address: "recipient"},
+				Amount: &types.Amount{
+					Value:    "10",
+					Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+				},
+			},
+		},
+	}
+	block1 := orphanTestBlock(1, "1", "0", 1)
+	block1.Transactions = append(block1.Transactions, onChain)
+
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, orphanTestBlock(0, "0", "0")))
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, block1))
+
+	block, found, err = finder.Find(ctx, identifier, predicted)
+	assert.NoError(t, err)
+	assert.Equal(t, block1.BlockIdentifier, block)
+	assert.Equal(t, onChain.TransactionIdentifier, found)
+
+	// A transaction with the predicted hash
+	// is not a mismatch.
+	block, found, err = finder.Find(ctx, identifier, onChain.TransactionIdentifier)
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.Nil(t, found)
+
+	// Block 1 is outside of the search depth.
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, orphanTestBlock(2, "2", "1")))
+	assert.NoError(t, run.blockStorage.AddBlock(ctx, orphanTestBlock(3, "3", "2")))
+
+	block, found, err = finder.Find(ctx, identifier, predicted)
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.Nil(t, found)
+
+	// Broadcasts that are no longer tracked
+	// are not searched for.
+	block, found, err = finder.Find(ctx, "unknown", predicted)
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.Nil(t, found)
+}
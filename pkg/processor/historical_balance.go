@@ -0,0 +1,274 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// maxHistoricalBalanceSamples is the number of
+	// (account, block) pairs kept to be checked (a
+	// uniform sample of all balance changes).
+	maxHistoricalBalanceSamples = 1000
+
+	// maxHistoricalBalanceFindings is the maximum number
+	// of mismatches to record (all mismatches are still
+	// counted).
+	maxHistoricalBalanceFindings = 100
+)
+
+// historicalBalanceSample is the balance of an
+// account computed at a processed block.
+type historicalBalanceSample struct {
+	account  *types.AccountIdentifier
+	currency *types.Currency
+	block    *types.BlockIdentifier
+	balance  string
+}
+
+// HistoricalBalanceChecker verifies that the historical balances
+// returned by the implementation match the balances computed at
+// the same blocks.
+//
+// Balance storage only keeps the latest computed balance of each
+// account, so the computed balance of a sampled balance change
+// is recorded when its block is added. Every interval, a random
+// sample is removed and compared with the balance returned by
+// /account/balance at its block.
+type HistoricalBalanceChecker struct {
+	network        *types.NetworkIdentifier
+	fetcher        *fetcher.Fetcher
+	blockStorage   *storage.BlockStorage
+	balanceStorage *storage.BalanceStorage
+	interval       time.Duration
+
+	samples []*historicalBalanceSample
+	seen    int64
+	results *results.HistoricalBalanceResults
+	mutex   sync.Mutex
+}
+
+// NewHistoricalBalanceChecker returns a new *HistoricalBalanceChecker
+// that checks a historical balance every interval. It should only
+// be used if historical balance lookup is supported.
+func NewHistoricalBalanceChecker(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	blockStorage *storage.BlockStorage,
+	balanceStorage *storage.BalanceStorage,
+	interval time.Duration,
+) *HistoricalBalanceChecker {
+	return &HistoricalBalanceChecker{
+		network:        network,
+		fetcher:        fetcher,
+		blockStorage:   blockStorage,
+		balanceStorage: balanceStorage,
+		interval:       interval,
+		samples:        []*historicalBalanceSample{},
+		results: &results.HistoricalBalanceResults{
+			Findings: []*results.HistoricalBalanceMismatch{},
+		},
+	}
+}
+
+// Sample records the computed balance of a balance change in
+// block (if it is selected for the sample). It must be called
+// after block is committed to BalanceStorage and before any
+// later block is.
+func (h *HistoricalBalanceChecker) Sample(
+	ctx context.Context,
+	block *types.BlockIdentifier,
+	changes []*parser.BalanceChange,
+) error {
+	if h == nil {
+		return nil
+	}
+
+	for _, change := range changes {
+		// Reservoir sampling keeps a uniform sample of all
+		// balance changes without storing all of them.
+		h.mutex.Lock()
+		h.seen++
+		index := len(h.samples)
+		if index >= maxHistoricalBalanceSamples {
+			index = int(rand.Int63n(h.seen)) // nolint:gosec
+		}
+		h.mutex.Unlock()
+
+		if index >= maxHistoricalBalanceSamples {
+			continue
+		}
+
+		amount, _, err := h.balanceStorage.GetBalance(ctx, change.Account, change.Currency, block)
+		if err != nil {
+			return fmt.Errorf(
+				"%w: unable to get computed balance of %s",
+				err,
+				types.PrintStruct(change.Account),
+			)
+		}
+
+		sample := &historicalBalanceSample{
+			account:  change.Account,
+			currency: change.Currency,
+			block:    block,
+			balance:  amount.Value,
+		}
+
+		h.mutex.Lock()
+		if index < len(h.samples) {
+			h.samples[index] = sample
+		} else {
+			h.samples = append(h.samples, sample)
+		}
+		h.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// Results returns a copy of the *results.HistoricalBalanceResults.
+func (h *HistoricalBalanceChecker) Results() *results.HistoricalBalanceResults {
+	if h == nil {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return &results.HistoricalBalanceResults{
+		Checks:     h.results.Checks,
+		Mismatches: h.results.Mismatches,
+		Findings:   append([]*results.HistoricalBalanceMismatch{}, h.results.Findings...),
+	}
+}
+
+// Loop checks a historical balance every
+// interval until the context is canceled.
+func (h *HistoricalBalanceChecker) Loop(ctx context.Context) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := h.Check(ctx); err != nil {
+				log.Printf("%s: unable to check historical balance\n", err.Error())
+			}
+		}
+	}
+}
+
+// Check removes a random sample and compares its computed
+// balance with the balance returned by the implementation
+// at its block. Samples in blocks that are no longer in
+// block storage (ex: orphaned) are discarded.
+func (h *HistoricalBalanceChecker) Check(ctx context.Context) error {
+	h.mutex.Lock()
+	if len(h.samples) == 0 {
+		h.mutex.Unlock()
+		return nil
+	}
+
+	index := rand.Intn(len(h.samples)) // nolint:gosec
+	sample := h.samples[index]
+	last := len(h.samples) - 1
+	h.samples[index] = h.samples[last]
+	h.samples = h.samples[:last]
+	h.mutex.Unlock()
+
+	lookup := types.ConstructPartialBlockIdentifier(sample.block)
+	_, err := h.blockStorage.GetBlock(ctx, lookup)
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to get block %d", err, sample.block.Index)
+	}
+
+	_, amounts, _, _, fetchErr := h.fetcher.AccountBalance(ctx, h.network, sample.account, lookup)
+	if fetchErr != nil {
+		return fmt.Errorf(
+			"%w: unable to fetch balance of %s at %d",
+			fetchErr.Err,
+			types.PrintStruct(sample.account),
+			sample.block.Index,
+		)
+	}
+
+	// A currency missing from the response
+	// has a balance of 0.
+	liveBalance := "0"
+	for _, amount := range amounts {
+		if types.Hash(amount.Currency) == types.Hash(sample.currency) {
+			liveBalance = amount.Value
+			break
+		}
+	}
+
+	h.compare(sample, liveBalance)
+	return nil
+}
+
+// compare records the outcome of a check
+// and any mismatch.
+func (h *HistoricalBalanceChecker) compare(sample *historicalBalanceSample, liveBalance string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.results.Checks++
+	if liveBalance == sample.balance {
+		return
+	}
+
+	h.results.Mismatches++
+	log.Printf(
+		"historical balance of %s at %d does not match computed balance (computed: %s%s, live: %s%s)\n",
+		sample.account.Address,
+		sample.block.Index,
+		sample.balance,
+		sample.currency.Symbol,
+		liveBalance,
+		sample.currency.Symbol,
+	)
+
+	if len(h.results.Findings) >= maxHistoricalBalanceFindings {
+		return
+	}
+
+	h.results.Findings = append(h.results.Findings, &results.HistoricalBalanceMismatch{
+		Account:         sample.account,
+		Currency:        sample.currency,
+		Block:           sample.block,
+		ComputedBalance: sample.balance,
+		LiveBalance:     liveBalance,
+	})
+}
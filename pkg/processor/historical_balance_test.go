@@ -0,0 +1,122 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoricalBalanceChecker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	account := &types.AccountIdentifier{Address: "addr"}
+	block := orphanTestBlock(10, "10", "9", 1)
+
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{})
+	assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+	balanceStorage := storage.NewBalanceStorage(localStore)
+	dbTx := localStore.NewDatabaseTransaction(ctx, true)
+	assert.NoError(t, balanceStorage.SetBalance(
+		ctx,
+		dbTx,
+		account,
+		&types.Amount{Value: "100", Currency: currency},
+		block.BlockIdentifier,
+	))
+	assert.NoError(t, dbTx.Commit(ctx))
+
+	// The implementation returns a different
+	// balance at the sampled block.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request types.AccountBalanceRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, block.BlockIdentifier.Index, *request.BlockIdentifier.Index)
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		assert.NoError(t, json.NewEncoder(w).Encode(&types.AccountBalanceResponse{
+			BlockIdentifier: block.BlockIdentifier,
+			Balances:        []*types.Amount{{Value: "90", Currency: currency}},
+		}))
+	}))
+	defer server.Close()
+
+	// A nil *HistoricalBalanceChecker samples nothing.
+	var nilChecker *HistoricalBalanceChecker
+	changes := []*parser.BalanceChange{{Account: account, Currency: currency, Difference: "100"}}
+	assert.NoError(t, nilChecker.Sample(ctx, block.BlockIdentifier, changes))
+	assert.Nil(t, nilChecker.Results())
+
+	checker := NewHistoricalBalanceChecker(
+		network,
+		fetcher.New(server.URL),
+		blockStorage,
+		balanceStorage,
+		time.Second,
+	)
+	assert.NoError(t, checker.Check(ctx)) // nothing sampled yet
+	assert.NoError(t, checker.Sample(ctx, block.BlockIdentifier, changes))
+	assert.NoError(t, checker.Check(ctx))
+
+	// Samples in blocks no longer in block
+	// storage are discarded.
+	orphaned := &types.BlockIdentifier{Index: 11, Hash: "orphaned"}
+	assert.NoError(t, checker.Sample(ctx, orphaned, changes))
+	assert.NoError(t, checker.Check(ctx))
+
+	assert.Equal(t, &results.HistoricalBalanceResults{
+		Checks:     1,
+		Mismatches: 1,
+		Findings: []*results.HistoricalBalanceMismatch{
+			{
+				Account:         account,
+				Currency:        currency,
+				Block:           block.BlockIdentifier,
+				ComputedBalance: "100",
+				LiveBalance:     "90",
+			},
+		},
+	}, checker.Results())
+}
@@ -0,0 +1,297 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// mempoolPollInterval is how often /mempool is polled
+// for submitted transactions. Transactions that are added
+// to the mempool and included in a block between polls
+// are counted as skipping the mempool.
+const mempoolPollInterval = 1 * time.Second
+
+// ErrTransactionNotVisible is returned when a transaction
+// accepted by /construction/submit is not seen in the mempool
+// or in a block before the mempool visibility timeout.
+var ErrTransactionNotVisible = errors.New("submitted transaction was not seen in the mempool or a block")
+
+// submittedTransaction is a transaction accepted by
+// /construction/submit that has not yet been seen.
+type submittedTransaction struct {
+	response  *types.TransactionIdentifierResponse
+	submitted time.Time
+}
+
+// MempoolWatcher polls /mempool (and /mempool/transaction)
+// for transactions accepted by /construction/submit until
+// each transaction is seen in the mempool, is seen in a
+// synced block, or the timeout expires.
+type MempoolWatcher struct {
+	network      *types.NetworkIdentifier
+	fetcher      *fetcher.Fetcher
+	database     storage.Database
+	blockStorage *storage.BlockStorage
+	timeout      time.Duration
+
+	disabled     bool
+	pending      map[string]*submittedTransaction
+	seen         map[string]struct{}
+	transactions []*results.TransactionVisibility
+	mutex        sync.Mutex
+}
+
+// NewMempoolWatcher returns a new *MempoolWatcher.
+func NewMempoolWatcher(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	database storage.Database,
+	blockStorage *storage.BlockStorage,
+	timeout time.Duration,
+) *MempoolWatcher {
+	return &MempoolWatcher{
+		network:      network,
+		fetcher:      fetcher,
+		database:     database,
+		blockStorage: blockStorage,
+		timeout:      timeout,
+		pending:      map[string]*submittedTransaction{},
+		seen:         map[string]struct{}{},
+	}
+}
+
+// Submitted is called when /construction/submit accepts
+// a transaction. Rebroadcasts of a transaction that is
+// already being watched (or was already seen) are ignored.
+func (m *MempoolWatcher) Submitted(response *types.TransactionIdentifierResponse) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.disabled {
+		return
+	}
+
+	hash := response.TransactionIdentifier.Hash
+	if _, ok := m.pending[hash]; ok {
+		return
+	}
+
+	if _, ok := m.seen[hash]; ok {
+		return
+	}
+
+	m.pending[hash] = &submittedTransaction{
+		response:  response,
+		submitted: time.Now(),
+	}
+}
+
+// Watch polls for submitted transactions until ctx is canceled
+// or a transaction is not seen before the timeout. If the Rosetta
+// implementation does not support /mempool, Watch returns nil
+// immediately and submitted transactions are not watched.
+func (m *MempoolWatcher) Watch(ctx context.Context) error {
+	if _, fetchErr := m.fetcher.Mempool(ctx, m.network); fetchErr != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf(
+			"%s: /mempool is not supported, not checking mempool visibility\n",
+			fetchErr.Err.Error(),
+		)
+
+		m.mutex.Lock()
+		m.disabled = true
+		m.pending = map[string]*submittedTransaction{}
+		m.mutex.Unlock()
+
+		return nil
+	}
+
+	tc := time.NewTicker(mempoolPollInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+			if err := m.poll(ctx, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// poll checks if any pending transaction is in the mempool
+// or in a synced block. It returns an error if a pending
+// transaction was submitted more than timeout before now.
+func (m *MempoolWatcher) poll(ctx context.Context, now time.Time) error {
+	m.mutex.Lock()
+	pending := make(map[string]*submittedTransaction, len(m.pending))
+	for hash, submitted := range m.pending {
+		pending[hash] = submitted
+	}
+	m.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	mempool, fetchErr := m.fetcher.Mempool(ctx, m.network)
+	if fetchErr != nil {
+		// The mempool may be temporarily unavailable, so
+		// we only fail if a transaction times out.
+		log.Printf("%s: unable to fetch mempool\n", fetchErr.Err.Error())
+	}
+
+	inMempool := make(map[string]struct{}, len(mempool))
+	for _, identifier := range mempool {
+		inMempool[identifier.Hash] = struct{}{}
+	}
+
+	for hash, submitted := range pending {
+		identifier := submitted.response.TransactionIdentifier
+		path, err := m.find(ctx, identifier, inMempool)
+		if err != nil {
+			return err
+		}
+
+		if len(path) > 0 {
+			m.observe(hash, path, now.Sub(submitted.submitted))
+			continue
+		}
+
+		if now.Sub(submitted.submitted) > m.timeout {
+			return fmt.Errorf(
+				"%w: %s not seen %s after submission (submit response: %s)",
+				ErrTransactionNotVisible,
+				hash,
+				m.timeout,
+				types.PrintStruct(submitted.response),
+			)
+		}
+	}
+
+	return nil
+}
+
+// find returns the path of a transaction if it is in
+// the mempool or in a synced block (or "" otherwise).
+func (m *MempoolWatcher) find(
+	ctx context.Context,
+	identifier *types.TransactionIdentifier,
+	inMempool map[string]struct{},
+) (results.MempoolVisibilityPath, error) {
+	if _, ok := inMempool[identifier.Hash]; ok {
+		return results.MempoolPath, nil
+	}
+
+	// Some implementations only return a subset of the
+	// mempool in /mempool, so we also look up the
+	// transaction directly.
+	if _, _, fetchErr := m.fetcher.MempoolTransaction(ctx, m.network, identifier); fetchErr == nil {
+		return results.MempoolPath, nil
+	}
+
+	txn := m.database.NewDatabaseTransaction(ctx, false)
+	defer txn.Discard(ctx)
+
+	block, _, err := m.blockStorage.FindTransaction(ctx, identifier, txn)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to find transaction %s", err, identifier.Hash)
+	}
+
+	if block != nil {
+		return results.BlockPath, nil
+	}
+
+	return "", nil
+}
+
+// observe records that a pending transaction was
+// seen on path after latency.
+func (m *MempoolWatcher) observe(
+	hash string,
+	path results.MempoolVisibilityPath,
+	latency time.Duration,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.pending, hash)
+	m.seen[hash] = struct{}{}
+	m.transactions = append(m.transactions, &results.TransactionVisibility{
+		Hash:    hash,
+		Path:    path,
+		Latency: milliseconds(latency),
+	})
+}
+
+// Results returns *results.MempoolVisibilityResults (or nil
+// if the Rosetta implementation does not support /mempool).
+func (m *MempoolWatcher) Results() *results.MempoolVisibilityResults {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.disabled {
+		return nil
+	}
+
+	visibility := &results.MempoolVisibilityResults{
+		Pending:      int64(len(m.pending)),
+		Transactions: make([]*results.TransactionVisibility, len(m.transactions)),
+	}
+	copy(visibility.Transactions, m.transactions)
+
+	var mempoolLatency, blockLatency float64
+	for _, transaction := range m.transactions {
+		switch transaction.Path {
+		case results.MempoolPath:
+			visibility.Mempool++
+			mempoolLatency += transaction.Latency
+		case results.BlockPath:
+			visibility.SkippedMempool++
+			blockLatency += transaction.Latency
+		}
+	}
+
+	if visibility.Mempool > 0 {
+		visibility.MeanMempoolLatency = mempoolLatency / float64(visibility.Mempool)
+	}
+
+	if visibility.SkippedMempool > 0 {
+		visibility.MeanBlockLatency = blockLatency / float64(visibility.SkippedMempool)
+	}
+
+	return visibility
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func mempoolTestServer(t *testing.T, supported bool, mempool ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if !supported || r.URL.Path != "/mempool" {
+			w.WriteHeader(http.StatusInternalServerError)
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.Error{
+				Code:    1,
+				Message: "not found",
+			}))
+			return
+		}
+
+		identifiers := make([]*types.TransactionIdentifier, len(mempool))
+		for i, hash := range mempool {
+			identifiers[i] = &types.TransactionIdentifier{Hash: hash}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		assert.NoError(t, json.NewEncoder(w).Encode(&types.MempoolResponse{
+			TransactionIdentifiers: identifiers,
+		}))
+	}))
+}
+
+func submittedResponse(hash string) *types.TransactionIdentifierResponse {
+	return &types.TransactionIdentifierResponse{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		Metadata:              map[string]interface{}{"accepted": true},
+	}
+}
+
+func TestMempoolWatcher(t *testing.T) {
+	ctx := context.Background()
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	timeout := 10 * time.Second
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{})
+
+	// "0 tx 0" is included in a block without
+	// ever being added to the mempool.
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(0, "0", "0", 1)))
+
+	t.Run("mempool supported", func(t *testing.T) {
+		server := mempoolTestServer(t, true, "in mempool")
+		defer server.Close()
+
+		watcher := NewMempoolWatcher(network, fetcher.New(server.URL), localStore, blockStorage, timeout)
+		watcher.Submitted(submittedResponse("in mempool"))
+		watcher.Submitted(submittedResponse("0 tx 0"))
+		watcher.Submitted(submittedResponse("missing"))
+
+		submitted := time.Now()
+		assert.NoError(t, watcher.poll(ctx, submitted))
+
+		visibility := watcher.Results()
+		assert.Equal(t, int64(1), visibility.Mempool)
+		assert.Equal(t, int64(1), visibility.SkippedMempool)
+		assert.Equal(t, int64(1), visibility.Pending)
+		assert.Len(t, visibility.Transactions, 2)
+
+		paths := map[string]results.MempoolVisibilityPath{}
+		for _, transaction := range visibility.Transactions {
+			paths[transaction.Hash] = transaction.Path
+		}
+		assert.Equal(t, map[string]results.MempoolVisibilityPath{
+			"in mempool": results.MempoolPath,
+			"0 tx 0":     results.BlockPath,
+		}, paths)
+
+		// Rebroadcasting a transaction that was
+		// already seen does not watch it again.
+		watcher.Submitted(submittedResponse("in mempool"))
+		assert.Equal(t, int64(1), watcher.Results().Pending)
+
+		err := watcher.poll(ctx, submitted.Add(timeout+time.Second))
+		assert.True(t, errors.Is(err, ErrTransactionNotVisible))
+		assert.Contains(t, err.Error(), "missing")
+		assert.Contains(t, err.Error(), "accepted")
+	})
+
+	t.Run("mempool unsupported", func(t *testing.T) {
+		server := mempoolTestServer(t, false)
+		defer server.Close()
+
+		watcher := NewMempoolWatcher(network, fetcher.New(server.URL), localStore, blockStorage, timeout)
+		assert.NoError(t, watcher.Watch(ctx))
+
+		watcher.Submitted(submittedResponse("missing"))
+		assert.Nil(t, watcher.Results())
+	})
+}
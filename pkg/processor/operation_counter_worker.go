@@ -0,0 +1,92 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*OperationCounterWorker)(nil)
+
+// OperationCounterWorker implements the storage.BlockWorker
+// interface. It counts the operations of each type and
+// with each status in each added block.
+type OperationCounterWorker struct {
+	counterStorage *storage.CounterStorage
+}
+
+// NewOperationCounterWorker returns a new *OperationCounterWorker.
+func NewOperationCounterWorker(counterStorage *storage.CounterStorage) *OperationCounterWorker {
+	return &OperationCounterWorker{counterStorage: counterStorage}
+}
+
+// updateCounters increments the counter for each key
+// in counts by its value.
+func (w *OperationCounterWorker) updateCounters(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	counts map[string]int64,
+) error {
+	for counter, count := range counts {
+		_, err := w.counterStorage.UpdateTransactional(
+			ctx,
+			transaction,
+			counter,
+			big.NewInt(count),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// The counters are updated in the same database transaction
+// as the addition.
+func (w *OperationCounterWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	counts := map[string]int64{}
+	for _, txn := range block.Transactions {
+		for _, op := range txn.Operations {
+			counts[results.OperationTypeCounter(op.Type)]++
+			counts[results.OperationStatusCounter(op.Status)]++
+		}
+	}
+
+	return nil, w.updateCounters(ctx, transaction, counts)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// Like the operation counter, operations in orphaned blocks
+// remain counted.
+func (w *OperationCounterWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
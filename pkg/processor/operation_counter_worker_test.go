@@ -0,0 +1,91 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationCounterWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{NewOperationCounterWorker(counterStorage)})
+
+	genesis := orphanTestBlock(0, "0", "0", 2)
+	block1 := orphanTestBlock(1, "1", "0", 3)
+	block1.Transactions[0].Operations[0].Type = "Fee"
+	block1.Transactions[0].Operations[1].Status = "FAILURE"
+	for _, block := range []*types.Block{genesis, block1} {
+		for _, txn := range block.Transactions {
+			for _, op := range txn.Operations {
+				if len(op.Status) == 0 {
+					op.Status = "SUCCESS"
+				}
+			}
+		}
+
+		assert.NoError(t, blockStorage.AddBlock(ctx, block))
+	}
+
+	// Operations in orphaned blocks remain counted.
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block1.BlockIdentifier))
+
+	networkAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "0"},
+		[]string{"Transfer", "Fee", "Reward"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	cfg := configuration.DefaultConfiguration()
+	stats := results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, networkAsserter)
+	assert.Equal(t, map[string]int64{"Transfer": 4, "Fee": 1, "Reward": 0}, stats.OperationTypes)
+	assert.Equal(t, map[string]int64{"SUCCESS": 4, "FAILURE": 1}, stats.OperationStatuses)
+
+	// Without an initialized asserter, the breakdown
+	// is not populated.
+	stats = results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil)
+	assert.Nil(t, stats.OperationTypes)
+	assert.Nil(t, stats.OperationStatuses)
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*OrphanCounterWorker)(nil)
+
+// OrphanCounterWorker implements the storage.BlockWorker
+// interface. It counts the transactions and operations
+// in each orphaned block and the orphans in the last
+// orphanRateWindow blocks (used to compute the recent
+// orphan rate).
+type OrphanCounterWorker struct {
+	counterStorage   *storage.CounterStorage
+	orphanRateWindow int64
+}
+
+// NewOrphanCounterWorker returns a new *OrphanCounterWorker.
+func NewOrphanCounterWorker(
+	counterStorage *storage.CounterStorage,
+	orphanRateWindow int64,
+) *OrphanCounterWorker {
+	return &OrphanCounterWorker{
+		counterStorage:   counterStorage,
+		orphanRateWindow: orphanRateWindow,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// Transactions and operations in added blocks are already
+// counted by the syncer. Orphans at the index that is no
+// longer in the orphan rate window are no longer counted
+// as recent orphans.
+func (w *OrphanCounterWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	expiredIndex := block.BlockIdentifier.Index - w.orphanRateWindow
+	if w.orphanRateWindow <= 0 || expiredIndex < 0 {
+		return nil, nil
+	}
+
+	// Reading a counter with UpdateTransactional ensures
+	// the read is in the same database transaction.
+	indexCounter := results.RecentOrphanIndexCounter(expiredIndex)
+	expired, err := w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		indexCounter,
+		big.NewInt(0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get %s counter", err, indexCounter)
+	}
+
+	if expired.Sign() == 0 {
+		return nil, nil
+	}
+
+	for counter, amount := range map[string]*big.Int{
+		indexCounter:                new(big.Int).Neg(expired),
+		results.RecentOrphanCounter: new(big.Int).Neg(expired),
+	} {
+		if _, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, amount); err != nil {
+			return nil, fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// The counters are updated in the same database transaction
+// as the removal.
+func (w *OrphanCounterWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	opCount := int64(0)
+	for _, txn := range block.Transactions {
+		opCount += int64(len(txn.Operations))
+	}
+
+	_, err := w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		results.OrphanedTransactionCounter,
+		big.NewInt(int64(len(block.Transactions))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to update orphaned transaction counter", err)
+	}
+
+	_, err = w.counterStorage.UpdateTransactional(
+		ctx,
+		transaction,
+		results.OrphanedOperationCounter,
+		big.NewInt(opCount),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to update orphaned operation counter", err)
+	}
+
+	if w.orphanRateWindow <= 0 {
+		return nil, nil
+	}
+
+	for _, counter := range []string{
+		results.RecentOrphanIndexCounter(block.BlockIdentifier.Index),
+		results.RecentOrphanCounter,
+	} {
+		if _, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, big.NewInt(1)); err != nil {
+			return nil, fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func orphanTestBlock(index int64, hash string, parentHash string, opCounts ...int) *types.Block {
+	transactions := make([]*types.Transaction, len(opCounts))
+	for i, opCount := range opCounts {
+		transactions[i] = &types.Transaction{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: fmt.Sprintf("%s tx %d", hash, i),
+			},
+			Operations: make([]*types.Operation, opCount),
+		}
+		for j := range transactions[i].Operations {
+			transactions[i].Operations[j] = &types.Operation{
+				OperationIdentifier: &types.OperationIdentifier{Index: int64(j)},
+				Type:                "Transfer",
+			}
+		}
+	}
+
+	parentIndex := index - 1
+	if parentIndex < 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: hash},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parentIndex,
+			Hash:  parentHash,
+		},
+		Transactions: transactions,
+	}
+}
+
+func TestOrphanCounterWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{NewOrphanCounterWorker(counterStorage, 2)})
+
+	// addBlock mirrors the counter updates performed by
+	// the statefulsyncer when a block is added.
+	addBlock := func(block *types.Block) {
+		assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+		opCount := int64(0)
+		for _, txn := range block.Transactions {
+			opCount += int64(len(txn.Operations))
+		}
+
+		_, err := counterStorage.Update(ctx, storage.BlockCounter, big.NewInt(1))
+		assert.NoError(t, err)
+		_, err = counterStorage.Update(
+			ctx,
+			storage.TransactionCounter,
+			big.NewInt(int64(len(block.Transactions))),
+		)
+		assert.NoError(t, err)
+		_, err = counterStorage.Update(ctx, storage.OperationCounter, big.NewInt(opCount))
+		assert.NoError(t, err)
+	}
+
+	removeBlock := func(block *types.Block) {
+		assert.NoError(t, blockStorage.RemoveBlock(ctx, block.BlockIdentifier))
+
+		_, err := counterStorage.Update(ctx, storage.OrphanCounter, big.NewInt(1))
+		assert.NoError(t, err)
+	}
+
+	// Sync genesis and block 1a, orphan block 1a, and then
+	// sync the new canonical chain (1b, 2).
+	genesis := orphanTestBlock(0, "0", "0", 2)
+	block1a := orphanTestBlock(1, "1a", "0", 1, 2)
+	block1b := orphanTestBlock(1, "1b", "0", 1)
+	block2 := orphanTestBlock(2, "2", "1b", 1)
+
+	addBlock(genesis)
+	addBlock(block1a)
+	removeBlock(block1a)
+	addBlock(block1b)
+	addBlock(block2)
+
+	var tests = map[string]struct {
+		excluded bool
+
+		expected *results.CheckDataStats
+	}{
+		"orphaned operations included": {
+			expected: &results.CheckDataStats{
+				Blocks:               4,
+				Orphans:              1,
+				Transactions:         5,
+				Operations:           7,
+				OrphanedTransactions: 2,
+				OrphanedOperations:   3,
+				OrphanRate:           500,
+				OrphanRateWindow:     2,
+			},
+		},
+		"orphaned operations excluded": {
+			excluded: true,
+			expected: &results.CheckDataStats{
+				Blocks:                     4,
+				Orphans:                    1,
+				Transactions:               3,
+				Operations:                 4,
+				OrphanedTransactions:       2,
+				OrphanedOperations:         3,
+				OrphanedOperationsExcluded: true,
+				OrphanRate:                 500,
+				OrphanRateWindow:           2,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := configuration.DefaultConfiguration()
+			cfg.Data.OrphanedOperationsExcluded = test.excluded
+			cfg.Data.OrphanRateWindow = 2
+
+			assert.Equal(
+				t,
+				test.expected,
+				results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil),
+			)
+		})
+	}
+
+	// The orphan at index 1 is no longer recent
+	// once index 3 is synced.
+	cfg := configuration.DefaultConfiguration()
+	cfg.Data.OrphanRateWindow = 2
+	cfg.Data.OrphanRateThreshold = 100
+	assert.True(t, results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil).HighOrphanRate())
+
+	addBlock(orphanTestBlock(3, "3", "2", 1))
+	stats := results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil)
+	assert.Equal(t, int64(1), stats.Orphans)
+	assert.Equal(t, float64(0), stats.OrphanRate)
+	assert.False(t, stats.HighOrphanRate())
+}
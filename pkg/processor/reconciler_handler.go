@@ -17,9 +17,14 @@ package processor
 import (
 	"context"
 	"fmt"
+	"log"
 	"math/big"
+	"strings"
 
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/events"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
@@ -32,6 +37,14 @@ type ReconcilerHandler struct {
 	logger                    *logger.Logger
 	counterStorage            *storage.CounterStorage
 	balanceStorage            *storage.BalanceStorage
+	reconciliationCursors     *ReconciliationCursorStorage
+	assertionCatalog          *results.AssertionCatalog
+	cacheProbe                *CacheProbe
+	endpointParity            *EndpointParity
+	tagReconciliation         *TagReconciliation
+	reconciliationFailures    *results.ReconciliationFailureRecorder
+	events                    *events.Dispatcher
+	errorPolicy               *results.ErrorPolicyRecorder
 	haltOnReconciliationError bool
 
 	InactiveFailure      *reconciler.AccountCurrency
@@ -45,12 +58,28 @@ func NewReconcilerHandler(
 	logger *logger.Logger,
 	counterStorage *storage.CounterStorage,
 	balanceStorage *storage.BalanceStorage,
+	reconciliationCursors *ReconciliationCursorStorage,
+	assertionCatalog *results.AssertionCatalog,
+	cacheProbe *CacheProbe,
+	endpointParity *EndpointParity,
+	tagReconciliation *TagReconciliation,
+	reconciliationFailures *results.ReconciliationFailureRecorder,
+	events *events.Dispatcher,
+	errorPolicy *results.ErrorPolicyRecorder,
 	haltOnReconciliationError bool,
 ) *ReconcilerHandler {
 	return &ReconcilerHandler{
 		logger:                    logger,
 		counterStorage:            counterStorage,
 		balanceStorage:            balanceStorage,
+		reconciliationCursors:     reconciliationCursors,
+		assertionCatalog:          assertionCatalog,
+		cacheProbe:                cacheProbe,
+		endpointParity:            endpointParity,
+		tagReconciliation:         tagReconciliation,
+		reconciliationFailures:    reconciliationFailures,
+		events:                    events,
+		errorPolicy:               errorPolicy,
 		haltOnReconciliationError: haltOnReconciliationError,
 	}
 }
@@ -67,6 +96,10 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 	nodeBalance string,
 	block *types.BlockIdentifier,
 ) error {
+	h.cacheProbe.Observe(account)
+	h.endpointParity.Reconciled(account, currency, block, computedBalance)
+	h.countProcessed(ctx, reconciliationType)
+
 	err := h.logger.ReconcileFailureStream(
 		ctx,
 		reconciliationType,
@@ -80,42 +113,91 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 		return err
 	}
 
-	if h.haltOnReconciliationError {
-		if reconciliationType == reconciler.InactiveReconciliation {
-			// Populate inactive failure information so we can try to find block with
-			// missing ops.
-			h.InactiveFailure = &reconciler.AccountCurrency{
-				Account:  account,
-				Currency: currency,
-			}
-			h.InactiveFailureBlock = block
-			return fmt.Errorf(
-				"%w: inactive reconciliation error for %s at %d (computed: %s%s, live: %s%s)",
-				results.ErrReconciliationFailure,
-				account.Address,
-				block.Index,
-				computedBalance,
-				currency.Symbol,
-				nodeBalance,
-				currency.Symbol,
-			)
-		}
+	// Balance changes in transactions that failed assertion
+	// were skipped, so we expect reconciliation to fail for
+	// any account they touched.
+	if h.assertionCatalog.Tainted(account) {
+		return nil
+	}
 
-		// If we halt on an active reconciliation error, store in the handler.
-		h.ActiveFailureBlock = block
-		return fmt.Errorf(
-			"%w: active reconciliation error for %s at %d (computed: %s%s, live: %s%s)",
-			results.ErrReconciliationFailure,
+	_, _ = h.counterStorage.Update(ctx, results.ReconciliationFailureCounter, big.NewInt(1))
+	h.tagReconciliation.Reconciled(account, currency, false)
+	err = h.reconciliationFailures.Add(ctx, &results.ReconciliationFailure{
+		Type:            reconciliationType,
+		Account:         account,
+		Currency:        currency,
+		ComputedBalance: computedBalance,
+		LiveBalance:     nodeBalance,
+		Difference:      results.BalanceDifference(computedBalance, nodeBalance),
+		Block:           block,
+	})
+	if err != nil {
+		log.Printf("%s: unable to record reconciliation failure\n", err.Error())
+	}
+	h.events.Emit(
+		configuration.ReconciliationFailureEvent,
+		fmt.Sprintf(
+			"%s reconciliation failed for %s at %d",
+			reconciliationType,
 			account.Address,
 			block.Index,
-			computedBalance,
-			currency.Symbol,
-			nodeBalance,
-			currency.Symbol,
-		)
+		),
+		logging.Fields{
+			"account":          account,
+			"currency":         currency,
+			"block":            block,
+			"computed_balance": computedBalance,
+			"live_balance":     nodeBalance,
+		},
+	)
+
+	if !h.haltOnReconciliationError {
+		return nil
+	}
+
+	failureErr := fmt.Errorf(
+		"%w: %s reconciliation error for %s at %d (computed: %s%s, live: %s%s)",
+		results.ErrReconciliationFailure,
+		strings.ToLower(reconciliationType),
+		account.Address,
+		block.Index,
+		computedBalance,
+		currency.Symbol,
+		nodeBalance,
+		currency.Symbol,
+	)
+
+	// Once at tip, the error policy may record
+	// the failure and continue reconciling.
+	if _, action := h.errorPolicy.Handle(failureErr); action != configuration.AbortErrorAction {
+		return nil
 	}
 
-	return nil
+	if reconciliationType == reconciler.InactiveReconciliation {
+		// Populate inactive failure information so we can try to find block with
+		// missing ops.
+		h.InactiveFailure = &reconciler.AccountCurrency{
+			Account:  account,
+			Currency: currency,
+		}
+		h.InactiveFailureBlock = block
+		return failureErr
+	}
+
+	// If we halt on an active reconciliation error, store in the handler.
+	h.ActiveFailureBlock = block
+	return failureErr
+}
+
+// countProcessed counts an active reconciliation (successful
+// or not) so that it can be compared with the number of balance
+// changes enqueued for active reconciliation.
+func (h *ReconcilerHandler) countProcessed(ctx context.Context, reconciliationType string) {
+	if reconciliationType == reconciler.InactiveReconciliation {
+		return
+	}
+
+	_, _ = h.counterStorage.Update(ctx, results.ReconciliationsProcessedCounter, big.NewInt(1))
 }
 
 // ReconciliationSucceeded is called each time a reconciliation succeeds.
@@ -127,6 +209,10 @@ func (h *ReconcilerHandler) ReconciliationSucceeded(
 	balance string,
 	block *types.BlockIdentifier,
 ) error {
+	h.cacheProbe.Observe(account)
+	h.endpointParity.Reconciled(account, currency, block, balance)
+	h.countProcessed(ctx, reconciliationType)
+
 	// Update counters
 	if reconciliationType == reconciler.InactiveReconciliation {
 		_, _ = h.counterStorage.Update(
@@ -138,10 +224,16 @@ func (h *ReconcilerHandler) ReconciliationSucceeded(
 		_, _ = h.counterStorage.Update(ctx, storage.ActiveReconciliationCounter, big.NewInt(1))
 	}
 
+	h.tagReconciliation.Reconciled(account, currency, true)
+
 	if err := h.balanceStorage.Reconciled(ctx, account, currency, block); err != nil {
 		return fmt.Errorf("%w: unable to store updated reconciliation", err)
 	}
 
+	if err := h.reconciliationCursors.Set(ctx, account, currency, block); err != nil {
+		return fmt.Errorf("%w: unable to store reconciliation cursor", err)
+	}
+
 	return h.logger.ReconcileSuccessStream(
 		ctx,
 		reconciliationType,
@@ -17,13 +17,43 @@ package processor
 import (
 	"context"
 	"errors"
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/storage"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
 )
 
+// reconciliationBacklogSize is the number of balance changes
+// the reconciler can hold before it drops new changes. It
+// mirrors the reconciler's (unexported) default backlog size.
+const reconciliationBacklogSize = 250000
+
+// maxPendingReconciliations is the most accounts (each
+// AccountIdentifier/Currency pair) tracked while their
+// balance changes wait for active reconciliation. Once
+// exceeded, balance changes for untracked accounts are
+// queued without deduplication (instead of growing the
+// index without bound).
+const maxPendingReconciliations = 100000
+
+// pendingReconciliation is a balance change waiting
+// for active reconciliation. If the account changed
+// again before it was reconciled, the newest balance
+// change is kept in superseding (to be queued once
+// the pending balance change is reconciled).
+type pendingReconciliation struct {
+	superseding      *parser.BalanceChange
+	supersedingBlock *types.BlockIdentifier
+}
+
 // ReconcilerHelper implements the Reconciler.Helper
 // interface.
 type ReconcilerHelper struct {
@@ -32,6 +62,28 @@ type ReconcilerHelper struct {
 
 	blockStorage   *storage.BlockStorage
 	balanceStorage *storage.BalanceStorage
+	counterStorage *storage.CounterStorage
+	endpointParity *EndpointParity
+
+	// pruningDepth is the number of blocks below the head
+	// block that are kept in block storage (0 if pruning
+	// is disabled).
+	pruningDepth int64
+
+	// liveBlocks is the block of the last live balance
+	// fetched for each account and currency (until its
+	// computed balance is fetched).
+	liveBlocks      map[string]*types.BlockIdentifier
+	liveBlocksMutex sync.Mutex
+
+	// pending is the balance change (if any) waiting for
+	// active reconciliation for each account and currency
+	// in reconciler. It is used to avoid queueing a balance
+	// change for an account that is already queued.
+	reconciler   *reconciler.Reconciler
+	pending      map[string]*pendingReconciliation
+	maxPending   int
+	pendingMutex sync.Mutex
 }
 
 // NewReconcilerHelper returns a new ReconcilerHelper.
@@ -40,19 +92,206 @@ func NewReconcilerHelper(
 	fetcher *fetcher.Fetcher,
 	blockStorage *storage.BlockStorage,
 	balanceStorage *storage.BalanceStorage,
+	counterStorage *storage.CounterStorage,
+	endpointParity *EndpointParity,
+	pruningDepth int64,
 ) *ReconcilerHelper {
 	return &ReconcilerHelper{
 		network:        network,
 		fetcher:        fetcher,
 		blockStorage:   blockStorage,
 		balanceStorage: balanceStorage,
+		counterStorage: counterStorage,
+		endpointParity: endpointParity,
+		pruningDepth:   pruningDepth,
+		liveBlocks:     map[string]*types.BlockIdentifier{},
+		pending:        map[string]*pendingReconciliation{},
+		maxPending:     maxPendingReconciliations,
+	}
+}
+
+// QueueChanges queues balance changes for active reconciliation
+// in r. The reconciler drops balance changes (without any
+// notification) when its backlog is full, so we estimate how
+// many were dropped from the size of the backlog before queueing
+// and count them separately from the balance changes enqueued.
+//
+// A balance change for an account that is already waiting for
+// active reconciliation is not queued. Instead, it is held
+// (replacing any balance change held for the account) and
+// queued once the reconciler starts reconciling the pending
+// balance change, so an account that changes in every block
+// occupies at most one entry in the backlog. Superseded
+// balance changes are covered by the reconciliation of the
+// newer balance change, so they are counted separately (and
+// not as skipped).
+func (h *ReconcilerHelper) QueueChanges(
+	ctx context.Context,
+	r *reconciler.Reconciler,
+	block *types.BlockIdentifier,
+	changes []*parser.BalanceChange,
+) error {
+	if h == nil || h.counterStorage == nil || len(changes) == 0 {
+		return r.QueueChanges(ctx, block, changes)
+	}
+
+	queued, superseded := h.deduplicate(r, block, changes)
+	h.countReconciliations(ctx, results.ReconciliationsSupersededCounter, superseded)
+	if len(queued) == 0 {
+		return nil
+	}
+
+	return h.queue(ctx, r, block, queued)
+}
+
+// queue queues changes in r, counting the
+// balance changes enqueued and skipped. The
+// reconciler drops the changes that don't fit
+// in its backlog, so they are no longer pending.
+func (h *ReconcilerHelper) queue(
+	ctx context.Context,
+	r *reconciler.Reconciler,
+	block *types.BlockIdentifier,
+	changes []*parser.BalanceChange,
+) error {
+	available := reconciliationBacklogSize - r.QueueSize()
+	if available < 0 {
+		available = 0
+	}
+
+	skipped := len(changes) - available
+	if skipped < 0 {
+		skipped = 0
+	}
+
+	if err := r.QueueChanges(ctx, block, changes); err != nil {
+		h.forget(changes)
+		return err
+	}
+
+	h.forget(changes[len(changes)-skipped:])
+	h.countReconciliations(ctx, results.ReconciliationsEnqueuedCounter, len(changes)-skipped)
+	h.countReconciliations(ctx, results.ReconciliationsSkippedBacklogCounter, skipped)
+
+	return nil
+}
+
+// deduplicate returns the changes that should be queued in r
+// and the number of changes that superseded a pending balance
+// change (which are held until it is reconciled).
+func (h *ReconcilerHelper) deduplicate(
+	r *reconciler.Reconciler,
+	block *types.BlockIdentifier,
+	changes []*parser.BalanceChange,
+) ([]*parser.BalanceChange, int) {
+	h.pendingMutex.Lock()
+	defer h.pendingMutex.Unlock()
+
+	// Pending balance changes are only tracked
+	// for a single reconciler.
+	if h.reconciler != r {
+		h.reconciler = r
+		h.pending = map[string]*pendingReconciliation{}
+	}
+
+	queued := []*parser.BalanceChange{}
+	superseded := 0
+	for _, change := range changes {
+		key := types.Hash(&reconciler.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		})
+
+		if pending, ok := h.pending[key]; ok {
+			pending.superseding = change
+			pending.supersedingBlock = block
+			superseded++
+			continue
+		}
+
+		if len(h.pending) < h.maxPending {
+			h.pending[key] = &pendingReconciliation{}
+		}
+
+		queued = append(queued, change)
+	}
+
+	return queued, superseded
+}
+
+// forget removes the pending entries of changes
+// that were not queued in the reconciler (so the
+// next balance change for each account is queued
+// instead of waiting on a reconciliation that
+// will never happen).
+func (h *ReconcilerHelper) forget(changes []*parser.BalanceChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	h.pendingMutex.Lock()
+	defer h.pendingMutex.Unlock()
+
+	for _, change := range changes {
+		delete(h.pending, types.Hash(&reconciler.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		}))
+	}
+}
+
+// dequeued is called once the reconciler starts reconciling
+// an account. If a newer balance change for the account
+// superseded the pending balance change, it is queued.
+func (h *ReconcilerHelper) dequeued(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) {
+	key := types.Hash(&reconciler.AccountCurrency{Account: account, Currency: currency})
+
+	h.pendingMutex.Lock()
+	pending, ok := h.pending[key]
+	delete(h.pending, key)
+	r := h.reconciler
+	h.pendingMutex.Unlock()
+
+	if !ok || pending.superseding == nil {
+		return
+	}
+
+	if err := h.QueueChanges(
+		ctx,
+		r,
+		pending.supersedingBlock,
+		[]*parser.BalanceChange{pending.superseding},
+	); err != nil {
+		log.Printf("%s: unable to queue superseding balance change\n", err.Error())
+	}
+}
+
+// countReconciliations adds count to counter (if count
+// is positive), logging any error.
+func (h *ReconcilerHelper) countReconciliations(
+	ctx context.Context,
+	counter string,
+	count int,
+) {
+	if count <= 0 {
+		return
+	}
+
+	if _, err := h.counterStorage.Update(ctx, counter, big.NewInt(int64(count))); err != nil {
+		log.Printf("%s: unable to update %s counter\n", err.Error(), counter)
 	}
 }
 
 // BlockExists returns a boolean indicating if block_storage
 // contains a block. This is necessary to reconcile across
 // reorgs. If the block returned on an account balance fetch
-// does not exist, reconciliation will be skipped.
+// does not exist, reconciliation will be skipped. If the
+// block is missing because it was already pruned (instead
+// of orphaned), the skipped reconciliation is counted.
 func (h *ReconcilerHelper) BlockExists(
 	ctx context.Context,
 	block *types.BlockIdentifier,
@@ -62,11 +301,18 @@ func (h *ReconcilerHelper) BlockExists(
 		return true, nil
 	}
 
-	if errors.Is(err, storage.ErrBlockNotFound) {
-		return false, nil
+	if !errors.Is(err, storage.ErrBlockNotFound) {
+		return false, err
+	}
+
+	if h.counterStorage != nil && h.pruningDepth > 0 {
+		head, err := h.blockStorage.GetHeadBlockIdentifier(ctx)
+		if err == nil && head.Index-block.Index >= h.pruningDepth {
+			h.countReconciliations(ctx, results.ReconciliationsSkippedPrunedCounter, 1)
+		}
 	}
 
-	return false, err
+	return false, nil
 }
 
 // CurrentBlock returns the last processed block and is used
@@ -81,22 +327,52 @@ func (h *ReconcilerHelper) CurrentBlock(
 // ComputedBalance returns the balance of an account in block storage.
 // It is necessary to perform this check outside of the Reconciler
 // package to allow for separation from a default storage backend.
+//
+// Only the latest computed balance is stored, so if the account
+// was updated after the block of its live balance, the Reconciler
+// defers the reconciliation (instead of comparing balances at
+// different blocks). We count these deferred reconciliations
+// because a large number means most accounts change faster than
+// they can be reconciled without historical balance lookup.
 func (h *ReconcilerHelper) ComputedBalance(
 	ctx context.Context,
 	account *types.AccountIdentifier,
 	currency *types.Currency,
 	headBlock *types.BlockIdentifier,
 ) (*types.Amount, *types.BlockIdentifier, error) {
-	return h.balanceStorage.GetBalance(ctx, account, currency, headBlock)
+	amount, block, err := h.balanceStorage.GetBalance(ctx, account, currency, headBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := types.Hash(&reconciler.AccountCurrency{Account: account, Currency: currency})
+	h.liveBlocksMutex.Lock()
+	liveBlock, ok := h.liveBlocks[key]
+	delete(h.liveBlocks, key)
+	h.liveBlocksMutex.Unlock()
+
+	if ok && h.counterStorage != nil && liveBlock.Index < block.Index {
+		_, _ = h.counterStorage.Update(ctx, results.DeferredReconciliationCounter, big.NewInt(1))
+	}
+
+	return amount, block, nil
 }
 
-// LiveBalance returns the live balance of an account.
+// LiveBalance returns the live balance of an account. If
+// endpoint parity is enabled, the live balance is also
+// compared with the balance from the secondary endpoint.
+//
+// The live balance is fetched once the reconciler starts
+// reconciling an account, so any balance change that
+// superseded its pending balance change is queued.
 func (h *ReconcilerHelper) LiveBalance(
 	ctx context.Context,
 	account *types.AccountIdentifier,
 	currency *types.Currency,
 	headBlock *types.BlockIdentifier,
 ) (*types.Amount, *types.BlockIdentifier, error) {
+	h.dequeued(ctx, account, currency)
+
 	amt, block, _, err := utils.CurrencyBalance(
 		ctx,
 		h.network,
@@ -108,5 +384,13 @@ func (h *ReconcilerHelper) LiveBalance(
 	if err != nil {
 		return nil, nil, err
 	}
+
+	h.endpointParity.Check(ctx, account, currency, headBlock, amt, block)
+
+	key := types.Hash(&reconciler.AccountCurrency{Account: account, Currency: currency})
+	h.liveBlocksMutex.Lock()
+	h.liveBlocks[key] = block
+	h.liveBlocksMutex.Unlock()
+
 	return amt, block, nil
 }
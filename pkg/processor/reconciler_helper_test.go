@@ -0,0 +1,283 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilerHelper_DeferredReconciliations(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	liveBlock := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+
+	var tests = map[string]struct {
+		computedBlock *types.BlockIdentifier
+
+		deferred int64
+	}{
+		"updated before live block": {
+			computedBlock: &types.BlockIdentifier{Index: 8, Hash: "block 8"},
+		},
+		"updated at live block": {
+			computedBlock: liveBlock,
+		},
+		"updated after live block": {
+			computedBlock: &types.BlockIdentifier{Index: 12, Hash: "block 12"},
+			deferred:      1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			localStore, err := storage.NewBadgerStorage(
+				ctx,
+				dir,
+				storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+			)
+			assert.NoError(t, err)
+			defer localStore.Close(ctx)
+
+			counterStorage := storage.NewCounterStorage(localStore)
+			balanceStorage := storage.NewBalanceStorage(localStore)
+
+			account := &types.AccountIdentifier{Address: "addr"}
+			dbTx := localStore.NewDatabaseTransaction(ctx, true)
+			assert.NoError(t, balanceStorage.SetBalance(
+				ctx,
+				dbTx,
+				account,
+				&types.Amount{Value: "100", Currency: currency},
+				test.computedBlock,
+			))
+			assert.NoError(t, dbTx.Commit(ctx))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.WriteHeader(http.StatusOK)
+				assert.NoError(t, json.NewEncoder(w).Encode(&types.AccountBalanceResponse{
+					BlockIdentifier: liveBlock,
+					Balances:        []*types.Amount{{Value: "100", Currency: currency}},
+				}))
+			}))
+			defer server.Close()
+
+			helper := NewReconcilerHelper(
+				network,
+				fetcher.New(server.URL),
+				nil,
+				balanceStorage,
+				counterStorage,
+				nil,
+				0,
+			)
+
+			_, block, err := helper.LiveBalance(ctx, account, currency, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, liveBlock, block)
+
+			_, block, err = helper.ComputedBalance(ctx, account, currency, liveBlock)
+			assert.NoError(t, err)
+			assert.Equal(t, test.computedBlock, block)
+
+			// Only the computed balance fetched after
+			// a live balance is compared.
+			_, _, err = helper.ComputedBalance(ctx, account, currency, liveBlock)
+			assert.NoError(t, err)
+
+			deferred, err := counterStorage.Get(ctx, results.DeferredReconciliationCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.deferred, deferred.Int64())
+		})
+	}
+}
+
+func TestReconcilerHelper_SkippedReconciliations(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{})
+	for i := int64(0); i <= 4; i++ {
+		assert.NoError(t, blockStorage.AddBlock(
+			ctx,
+			orphanTestBlock(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i-1), 1),
+		))
+	}
+
+	helper := NewReconcilerHelper(nil, nil, blockStorage, nil, counterStorage, nil, 2)
+	counter := func(name string) int64 {
+		value, err := counterStorage.Get(ctx, name)
+		assert.NoError(t, err)
+		return value.Int64()
+	}
+
+	// Balance changes queued while the backlog
+	// has room are counted as enqueued.
+	r := reconciler.New(nil, nil, reconciler.WithLookupBalanceByBlock(true))
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	changes := []*parser.BalanceChange{
+		{Account: &types.AccountIdentifier{Address: "addr1"}, Currency: btc, Difference: "1"},
+		{Account: &types.AccountIdentifier{Address: "addr2"}, Currency: btc, Difference: "-1"},
+	}
+	head := &types.BlockIdentifier{Index: 4, Hash: "4"}
+	assert.NoError(t, helper.QueueChanges(ctx, r, head, changes))
+	assert.Equal(t, int64(2), counter(results.ReconciliationsEnqueuedCounter))
+	assert.Equal(t, int64(0), counter(results.ReconciliationsSkippedBacklogCounter))
+
+	// A nil *ReconcilerHelper still queues changes.
+	var nilHelper *ReconcilerHelper
+	assert.NoError(t, nilHelper.QueueChanges(ctx, r, head, changes))
+	assert.Equal(t, 4, r.QueueSize())
+	assert.Equal(t, int64(2), counter(results.ReconciliationsEnqueuedCounter))
+
+	// Only missing blocks at or below the pruning
+	// depth are counted as pruned (missing blocks
+	// above it were orphaned).
+	exists, err := helper.BlockExists(ctx, &types.BlockIdentifier{Index: 3, Hash: "3"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = helper.BlockExists(ctx, &types.BlockIdentifier{Index: 4, Hash: "orphaned"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, int64(0), counter(results.ReconciliationsSkippedPrunedCounter))
+
+	exists, err = helper.BlockExists(ctx, &types.BlockIdentifier{Index: 1, Hash: "pruned"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, int64(1), counter(results.ReconciliationsSkippedPrunedCounter))
+}
+
+func TestReconcilerHelper_SupersededReconciliations(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	helper := NewReconcilerHelper(nil, nil, nil, nil, counterStorage, nil, 0)
+	counter := func(name string) int64 {
+		value, err := counterStorage.Get(ctx, name)
+		assert.NoError(t, err)
+		return value.Int64()
+	}
+
+	r := reconciler.New(nil, nil, reconciler.WithLookupBalanceByBlock(true))
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	hot := &types.AccountIdentifier{Address: "hot"}
+	change := func(index int64, account *types.AccountIdentifier) *parser.BalanceChange {
+		return &parser.BalanceChange{
+			Account:    account,
+			Currency:   btc,
+			Block:      &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("%d", index)},
+			Difference: "1",
+		}
+	}
+
+	// Sync a chain where the hot account
+	// changes in every block.
+	for i := int64(0); i < 100; i++ {
+		c := change(i, hot)
+		assert.NoError(t, helper.QueueChanges(ctx, r, c.Block, []*parser.BalanceChange{c}))
+		assert.Equal(t, 1, r.QueueSize())
+	}
+	assert.Equal(t, int64(1), counter(results.ReconciliationsEnqueuedCounter))
+	assert.Equal(t, int64(99), counter(results.ReconciliationsSupersededCounter))
+
+	// Once the pending balance change is reconciled,
+	// the newest balance change is queued.
+	helper.dequeued(ctx, hot, btc)
+	assert.Equal(t, 2, r.QueueSize())
+	assert.Equal(t, int64(2), counter(results.ReconciliationsEnqueuedCounter))
+
+	c := change(100, hot)
+	assert.NoError(t, helper.QueueChanges(ctx, r, c.Block, []*parser.BalanceChange{c}))
+	assert.Equal(t, 2, r.QueueSize())
+	assert.Equal(t, int64(100), counter(results.ReconciliationsSupersededCounter))
+
+	// Once the index is full, balance changes for
+	// untracked accounts are queued without
+	// deduplication.
+	helper.maxPending = 1
+	other := &types.AccountIdentifier{Address: "other"}
+	for i := int64(101); i < 103; i++ {
+		c := change(i, other)
+		assert.NoError(t, helper.QueueChanges(ctx, r, c.Block, []*parser.BalanceChange{c}))
+	}
+	assert.Equal(t, 4, r.QueueSize())
+	assert.Equal(t, int64(4), counter(results.ReconciliationsEnqueuedCounter))
+	assert.Equal(t, int64(100), counter(results.ReconciliationsSupersededCounter))
+
+	// Balance changes dropped because the backlog is
+	// full are not pending (so the next balance change
+	// for the account is queued instead of held).
+	helper.maxPending = maxPendingReconciliations
+	filler := make([]*parser.BalanceChange, reconciliationBacklogSize-r.QueueSize())
+	for i := range filler {
+		filler[i] = change(103, &types.AccountIdentifier{Address: fmt.Sprintf("filler %d", i)})
+	}
+	assert.NoError(t, r.QueueChanges(ctx, c.Block, filler))
+	assert.Equal(t, reconciliationBacklogSize, r.QueueSize())
+
+	dropped := &types.AccountIdentifier{Address: "dropped"}
+	c = change(104, dropped)
+	assert.NoError(t, helper.QueueChanges(ctx, r, c.Block, []*parser.BalanceChange{c}))
+	assert.Equal(t, int64(1), counter(results.ReconciliationsSkippedBacklogCounter))
+	_, ok := helper.pending[types.Hash(&reconciler.AccountCurrency{Account: dropped, Currency: btc})]
+	assert.False(t, ok)
+}
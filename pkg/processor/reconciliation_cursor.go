@@ -0,0 +1,133 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// reconciliationCursorNamespace is prepended to the
+// hash of the account currency of each ReconciliationCursor.
+const reconciliationCursorNamespace = "reconciliation-cursor"
+
+func getReconciliationCursorKey(account *types.AccountIdentifier, currency *types.Currency) []byte {
+	return []byte(fmt.Sprintf(
+		"%s/%s",
+		reconciliationCursorNamespace,
+		types.Hash(&reconciler.AccountCurrency{Account: account, Currency: currency}),
+	))
+}
+
+// ReconciliationCursor is the last block the balance of
+// an account currency was successfully reconciled at.
+type ReconciliationCursor struct {
+	Account  *types.AccountIdentifier `json:"account"`
+	Currency *types.Currency          `json:"currency"`
+	Block    *types.BlockIdentifier   `json:"block"`
+}
+
+// ReconciliationCursorStorage stores a ReconciliationCursor
+// for each reconciled account currency. storage.BalanceStorage
+// does not expose the block each balance was last reconciled
+// at, so we record it separately to be able to export it
+// (in a checkpoint). All methods are safe to call on a nil
+// *ReconciliationCursorStorage.
+type ReconciliationCursorStorage struct {
+	database storage.Database
+}
+
+// NewReconciliationCursorStorage returns a new
+// *ReconciliationCursorStorage.
+func NewReconciliationCursorStorage(database storage.Database) *ReconciliationCursorStorage {
+	return &ReconciliationCursorStorage{database: database}
+}
+
+// Set stores block as the ReconciliationCursor
+// of account and currency.
+func (s *ReconciliationCursorStorage) Set(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) error {
+	if s == nil {
+		return nil
+	}
+
+	val, err := json.Marshal(&ReconciliationCursor{
+		Account:  account,
+		Currency: currency,
+		Block:    block,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode reconciliation cursor", err)
+	}
+
+	dbTx := s.database.NewDatabaseTransaction(ctx, true)
+	defer dbTx.Discard(ctx)
+
+	if err := dbTx.Set(ctx, getReconciliationCursorKey(account, currency), val, true); err != nil {
+		return fmt.Errorf("%w: unable to store reconciliation cursor", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: unable to commit reconciliation cursor", err)
+	}
+
+	return nil
+}
+
+// GetAll returns all stored ReconciliationCursors
+// keyed by the hash of their account currency.
+func (s *ReconciliationCursorStorage) GetAll(
+	ctx context.Context,
+) (map[string]*ReconciliationCursor, error) {
+	cursors := map[string]*ReconciliationCursor{}
+	if s == nil {
+		return cursors, nil
+	}
+
+	dbTx := s.database.NewDatabaseTransaction(ctx, false)
+	defer dbTx.Discard(ctx)
+
+	_, err := dbTx.Scan(
+		ctx,
+		[]byte(reconciliationCursorNamespace+"/"),
+		func(k []byte, v []byte) error {
+			var cursor ReconciliationCursor
+			if err := json.Unmarshal(v, &cursor); err != nil {
+				return fmt.Errorf("%w: unable to decode reconciliation cursor", err)
+			}
+
+			cursors[types.Hash(&reconciler.AccountCurrency{
+				Account:  cursor.Account,
+				Currency: cursor.Currency,
+			})] = &cursor
+			return nil
+		},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan reconciliation cursors", err)
+	}
+
+	return cursors, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/events"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*ReorgDepthWorker)(nil)
+
+// ReorgDepthWorker implements the storage.BlockWorker
+// interface. It measures the depth of each reorg (the
+// number of blocks removed before a block is added) and
+// emits a ReorgBeyondDepthEvent for any reorg deeper
+// than depth. Blocks removed before the first block is
+// added (ex: when start_index is set on a restart) are
+// not part of a reorg.
+type ReorgDepthWorker struct {
+	events *events.Dispatcher
+	depth  int64
+
+	added       bool
+	removed     int64
+	firstRemove *types.BlockIdentifier
+	mutex       sync.Mutex
+}
+
+// NewReorgDepthWorker returns a new *ReorgDepthWorker.
+func NewReorgDepthWorker(events *events.Dispatcher, depth int64) *ReorgDepthWorker {
+	return &ReorgDepthWorker{
+		events: events,
+		depth:  depth,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// Adding a block ends any reorg in progress.
+func (w *ReorgDepthWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.added = true
+	removed, firstRemove := w.removed, w.firstRemove
+	w.removed, w.firstRemove = 0, nil
+	if removed <= w.depth {
+		return nil, nil
+	}
+
+	// The event is only emitted once the new block
+	// is committed, so a failed commit is not reported.
+	return func(ctx context.Context) error {
+		w.events.Emit(
+			configuration.ReorgBeyondDepthEvent,
+			fmt.Sprintf(
+				"reorg removed %d blocks (more than %d) from %d",
+				removed,
+				w.depth,
+				firstRemove.Index,
+			),
+			logging.Fields{
+				"depth":     removed,
+				"orphaned":  firstRemove,
+				"new_block": block.BlockIdentifier,
+				"max_depth": w.depth,
+			},
+		)
+
+		return nil
+	}, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+func (w *ReorgDepthWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.added {
+		return nil, nil
+	}
+
+	w.removed++
+	if w.firstRemove == nil {
+		w.firstRemove = block.BlockIdentifier
+	}
+
+	return nil, nil
+}
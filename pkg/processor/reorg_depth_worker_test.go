@@ -0,0 +1,114 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/events"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorgDepthWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	eventsPath := path.Join(dir, "events.jsonl")
+	dispatcher, err := events.NewDispatcher(&configuration.EventsConfiguration{
+		Sinks: []*configuration.EventSinkConfiguration{
+			{
+				Name:    "file",
+				Type:    configuration.FileEventSink,
+				Path:    eventsPath,
+				Timeout: 1,
+			},
+		},
+	}, &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"})
+	assert.NoError(t, err)
+
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{NewReorgDepthWorker(dispatcher, 1)})
+
+	removeBlock := func(index int64, hash string) {
+		assert.NoError(t, blockStorage.RemoveBlock(ctx, &types.BlockIdentifier{
+			Index: index,
+			Hash:  hash,
+		}))
+	}
+
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(0, "block 0", "block 0")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(1, "block 1", "block 0")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(2, "block 2", "block 1")))
+
+	// A reorg at the depth is not reported.
+	removeBlock(2, "block 2")
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(2, "block 2a", "block 1")))
+
+	// A reorg beyond the depth is reported.
+	removeBlock(2, "block 2a")
+	removeBlock(1, "block 1")
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(1, "block 1b", "block 0")))
+
+	closeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	dispatcher.Close(closeCtx)
+
+	output, err := ioutil.ReadFile(eventsPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Len(t, lines, 1)
+
+	var event events.Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, configuration.ReorgBeyondDepthEvent, event.Type)
+	assert.Equal(t, "reorg removed 2 blocks (more than 1) from 2", event.Message)
+	assert.Equal(t, float64(2), event.Fields["depth"])
+}
+
+func TestReorgDepthWorkerIgnoresStartupRemovals(t *testing.T) {
+	ctx := context.Background()
+	w := NewReorgDepthWorker(nil, 0)
+
+	// Blocks removed before any block is added (ex: when
+	// setting a new start index) are not a reorg.
+	_, err := w.RemovingBlock(ctx, orphanTestBlock(1, "block 1", "block 0"), nil)
+	assert.NoError(t, err)
+
+	commitWorker, err := w.AddingBlock(ctx, orphanTestBlock(1, "block 1a", "block 0"), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, commitWorker)
+}
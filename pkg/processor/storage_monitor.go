@@ -0,0 +1,222 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+// Names of the statistics badger publishes with expvar. Counts
+// are cumulative for all databases opened by the process and
+// sizes are keyed by the directory of each database.
+const (
+	badgerDiskReads      = "badger_v2_disk_reads_total"
+	badgerDiskWrites     = "badger_v2_disk_writes_total"
+	badgerBytesRead      = "badger_v2_read_bytes"
+	badgerBytesWritten   = "badger_v2_written_bytes"
+	badgerGets           = "badger_v2_gets_total"
+	badgerPuts           = "badger_v2_puts_total"
+	badgerBlockedPuts    = "badger_v2_blocked_puts_total"
+	badgerLevelGets      = "badger_v2_lsm_level_gets_total"
+	badgerLevelBloomHits = "badger_v2_lsm_bloom_hits_total"
+	badgerLSMSize        = "badger_v2_lsm_size_bytes"
+	badgerVlogSize       = "badger_v2_vlog_size_bytes"
+	badgerPendingWrites  = "badger_v2_pending_writes_total"
+)
+
+// StorageMonitor samples the statistics badger publishes
+// for the database in a directory. Because badger only
+// publishes counts for the entire process, counts are
+// reported relative to when the StorageMonitor was created.
+// Reading these statistics does not touch the database, so
+// sampling is cheap.
+type StorageMonitor struct {
+	dir string
+
+	baseline          map[string]int64
+	baselineGets      map[string]int64
+	baselineBloomHits map[string]int64
+
+	stats *results.StorageStats
+	mutex sync.Mutex
+}
+
+// NewStorageMonitor returns a new *StorageMonitor for
+// the database in dir.
+func NewStorageMonitor(dir string) *StorageMonitor {
+	baseline := map[string]int64{}
+	for _, name := range []string{
+		badgerDiskReads,
+		badgerDiskWrites,
+		badgerBytesRead,
+		badgerBytesWritten,
+		badgerGets,
+		badgerPuts,
+		badgerBlockedPuts,
+	} {
+		if value, ok := expvarInt(name); ok {
+			baseline[name] = value
+		}
+	}
+
+	return &StorageMonitor{
+		dir:               dir,
+		baseline:          baseline,
+		baselineGets:      expvarMap(badgerLevelGets),
+		baselineBloomHits: expvarMap(badgerLevelBloomHits),
+		stats:             &results.StorageStats{},
+	}
+}
+
+// Sample records the current storage statistics.
+func (m *StorageMonitor) Sample() {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.stats
+	s.Samples++
+
+	for name, field := range map[string]**int64{
+		badgerDiskReads:    &s.DiskReads,
+		badgerDiskWrites:   &s.DiskWrites,
+		badgerBytesRead:    &s.BytesRead,
+		badgerBytesWritten: &s.BytesWritten,
+		badgerGets:         &s.Gets,
+		badgerPuts:         &s.Puts,
+		badgerBlockedPuts:  &s.BlockedPuts,
+	} {
+		if value, ok := expvarInt(name); ok {
+			count := value - m.baseline[name]
+			*field = &count
+		}
+	}
+
+	s.LevelGets = levelDeltas(expvarMap(badgerLevelGets), m.baselineGets)
+	s.LevelBloomHits = levelDeltas(expvarMap(badgerLevelBloomHits), m.baselineBloomHits)
+
+	if s.DiskReads != nil && s.Gets != nil && *s.Gets > 0 {
+		readAmplification := float64(*s.DiskReads) / float64(*s.Gets)
+		s.ReadAmplification = &readAmplification
+	}
+
+	var levelGets, bloomHits int64
+	for _, count := range s.LevelGets {
+		levelGets += count
+	}
+	for _, count := range s.LevelBloomHits {
+		bloomHits += count
+	}
+	if levelGets+bloomHits > 0 {
+		hitRate := float64(bloomHits) / float64(levelGets+bloomHits)
+		s.BloomFilterHitRate = &hitRate
+	}
+
+	if size, ok := expvarMap(badgerLSMSize)[m.dir]; ok {
+		s.LSMSizeBytes = &size
+		s.MaxLSMSizeBytes = maxInt64(s.MaxLSMSizeBytes, size)
+	}
+
+	if size, ok := expvarMap(badgerVlogSize)[m.dir]; ok {
+		s.VlogSizeBytes = &size
+		s.MaxVlogSizeBytes = maxInt64(s.MaxVlogSizeBytes, size)
+	}
+
+	if pending, ok := expvarMap(badgerPendingWrites)[m.dir]; ok {
+		s.MaxPendingWrites = maxInt64(s.MaxPendingWrites, pending)
+	}
+}
+
+// Results returns a copy of the *results.StorageStats
+// or nil if no samples were recorded.
+func (m *StorageMonitor) Results() *results.StorageStats {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stats.Samples == 0 {
+		return nil
+	}
+
+	// Sample always replaces (instead of modifying)
+	// the values of pointer and map fields, so they
+	// can be shared with the copy.
+	stats := *m.stats
+	return &stats
+}
+
+// expvarInt returns the value of the *expvar.Int
+// published as name, if there is one.
+func expvarInt(name string) (int64, bool) {
+	value, ok := expvar.Get(name).(*expvar.Int)
+	if !ok {
+		return 0, false
+	}
+
+	return value.Value(), true
+}
+
+// expvarMap returns the value of each *expvar.Int in
+// the *expvar.Map published as name (or nil if there
+// is no such map).
+func expvarMap(name string) map[string]int64 {
+	value, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		return nil
+	}
+
+	values := map[string]int64{}
+	value.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			values[kv.Key] = v.Value()
+		}
+	})
+
+	return values
+}
+
+// levelDeltas returns the change in each count in
+// current since baseline (or nil if current is nil).
+func levelDeltas(current map[string]int64, baseline map[string]int64) map[string]int64 {
+	if current == nil {
+		return nil
+	}
+
+	deltas := make(map[string]int64, len(current))
+	for level, count := range current {
+		deltas[level] = count - baseline[level]
+	}
+
+	return deltas
+}
+
+// maxInt64 returns a pointer to the larger of
+// *current and value (or value if current is nil).
+func maxInt64(current *int64, value int64) *int64 {
+	if current != nil && *current >= value {
+		return current
+	}
+
+	return &value
+}
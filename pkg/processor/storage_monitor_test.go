@@ -0,0 +1,93 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	m := NewStorageMonitor(dir)
+	assert.Nil(t, m.Results())
+
+	keys := 10
+	dbTx := localStore.NewDatabaseTransaction(ctx, true)
+	for i := 0; i < keys; i++ {
+		assert.NoError(t, dbTx.Set(ctx, []byte(fmt.Sprintf("key %d", i)), []byte("value"), false))
+	}
+	assert.NoError(t, dbTx.Commit(ctx))
+
+	readTx := localStore.NewDatabaseTransaction(ctx, false)
+	for i := 0; i < keys; i++ {
+		exists, _, err := readTx.Get(ctx, []byte(fmt.Sprintf("key %d", i)))
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+	readTx.Discard(ctx)
+
+	m.Sample()
+	stats := m.Results()
+	assert.Equal(t, int64(1), stats.Samples)
+	assert.GreaterOrEqual(t, *stats.Puts, int64(keys))
+	assert.GreaterOrEqual(t, *stats.Gets, int64(keys))
+	assert.NotNil(t, stats.LSMSizeBytes)
+	assert.Equal(t, stats.LSMSizeBytes, stats.MaxLSMSizeBytes)
+	assert.NotNil(t, stats.VlogSizeBytes)
+	assert.NotNil(t, stats.DataSizeBytes())
+
+	// Results is a copy.
+	m.Sample()
+	assert.Equal(t, int64(1), stats.Samples)
+	assert.Equal(t, int64(2), m.Results().Samples)
+}
+
+func TestStorageMonitor_UnknownDirectory(t *testing.T) {
+	m := NewStorageMonitor(path.Join("not", "a", "database"))
+	m.Sample()
+
+	stats := m.Results()
+	assert.Equal(t, int64(1), stats.Samples)
+	assert.Nil(t, stats.LSMSizeBytes)
+	assert.Nil(t, stats.VlogSizeBytes)
+	assert.Nil(t, stats.MaxPendingWrites)
+	assert.Nil(t, stats.DataSizeBytes())
+}
+
+func TestStorageMonitor_Nil(t *testing.T) {
+	var m *StorageMonitor
+	m.Sample()
+	assert.Nil(t, m.Results())
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// TrackedAccounts counts the distinct accounts (each
+// AccountIdentifier/Currency pair) and currencies under
+// balance tracking in results.AccountsTrackedCounter and
+// results.CurrenciesSeenCounter. All methods may be called
+// on a nil *TrackedAccounts.
+type TrackedAccounts struct {
+	counterStorage *storage.CounterStorage
+
+	accounts   map[string]struct{}
+	currencies map[string]struct{}
+	mutex      sync.Mutex
+}
+
+// NewTrackedAccounts returns a new *TrackedAccounts.
+func NewTrackedAccounts(counterStorage *storage.CounterStorage) *TrackedAccounts {
+	return &TrackedAccounts{
+		counterStorage: counterStorage,
+		accounts:       map[string]struct{}{},
+		currencies:     map[string]struct{}{},
+	}
+}
+
+// Seed adds accounts already in BalanceStorage (ex: when
+// restarting from an existing data directory) and sets the
+// counters to the number of accounts and currencies tracked.
+// The counters are set (instead of incremented) so that they
+// are correct for data directories created before they were
+// maintained.
+func (t *TrackedAccounts) Seed(
+	ctx context.Context,
+	accounts []*reconciler.AccountCurrency,
+) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, account := range accounts {
+		t.add(account.Account, account.Currency)
+	}
+
+	if err := t.setCounter(ctx, results.AccountsTrackedCounter, len(t.accounts)); err != nil {
+		return err
+	}
+
+	return t.setCounter(ctx, results.CurrenciesSeenCounter, len(t.currencies))
+}
+
+// Add adds the accounts of changes and increments
+// the counters by the number of new accounts and
+// currencies.
+func (t *TrackedAccounts) Add(
+	ctx context.Context,
+	changes []*parser.BalanceChange,
+) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	newAccounts, newCurrencies := 0, 0
+	for _, change := range changes {
+		accountAdded, currencyAdded := t.add(change.Account, change.Currency)
+		if accountAdded {
+			newAccounts++
+		}
+		if currencyAdded {
+			newCurrencies++
+		}
+	}
+
+	counts := map[string]int{
+		results.AccountsTrackedCounter: newAccounts,
+		results.CurrenciesSeenCounter:  newCurrencies,
+	}
+	for counter, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		if _, err := t.counterStorage.Update(ctx, counter, big.NewInt(int64(count))); err != nil {
+			return fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return nil
+}
+
+// add returns booleans indicating if the account
+// and its currency were not already tracked.
+func (t *TrackedAccounts) add(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) (bool, bool) {
+	accountKey := types.Hash(&reconciler.AccountCurrency{
+		Account:  account,
+		Currency: currency,
+	})
+	if _, ok := t.accounts[accountKey]; ok {
+		return false, false
+	}
+	t.accounts[accountKey] = struct{}{}
+
+	currencyKey := types.Hash(currency)
+	if _, ok := t.currencies[currencyKey]; ok {
+		return true, false
+	}
+	t.currencies[currencyKey] = struct{}{}
+
+	return true, true
+}
+
+// setCounter updates counter so that its value is value.
+func (t *TrackedAccounts) setCounter(ctx context.Context, counter string, value int) error {
+	current, err := t.counterStorage.Get(ctx, counter)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get %s counter", err, counter)
+	}
+
+	delta := new(big.Int).Sub(big.NewInt(int64(value)), current)
+	if delta.Sign() == 0 {
+		return nil
+	}
+
+	if _, err := t.counterStorage.Update(ctx, counter, delta); err != nil {
+		return fmt.Errorf("%w: unable to update %s counter", err, counter)
+	}
+
+	return nil
+}
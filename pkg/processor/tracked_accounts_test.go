@@ -0,0 +1,100 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackedAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	cfg := configuration.DefaultConfiguration()
+
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	eth := &types.Currency{Symbol: "ETH", Decimals: 18}
+	addr1 := &types.AccountIdentifier{Address: "addr1"}
+	addr2 := &types.AccountIdentifier{Address: "addr2"}
+
+	// A nil *TrackedAccounts counts nothing.
+	var nilTracked *TrackedAccounts
+	assert.NoError(t, nilTracked.Seed(ctx, nil))
+	assert.NoError(t, nilTracked.Add(ctx, []*parser.BalanceChange{{Account: addr1, Currency: btc}}))
+
+	tracked := NewTrackedAccounts(counterStorage)
+	assert.NoError(t, tracked.Seed(ctx, []*reconciler.AccountCurrency{}))
+	assert.NoError(t, tracked.Add(ctx, []*parser.BalanceChange{
+		{Account: addr1, Currency: btc},
+		{Account: addr1, Currency: btc},
+		{Account: addr2, Currency: btc},
+	}))
+	assert.NoError(t, tracked.Add(ctx, []*parser.BalanceChange{
+		{Account: addr2, Currency: btc},
+		{Account: addr2, Currency: eth},
+	}))
+
+	stats := results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil)
+	assert.Equal(t, int64(3), stats.AccountsTracked)
+	assert.Equal(t, int64(2), stats.CurrenciesSeen)
+
+	var b bytes.Buffer
+	stats.Fprint(&b)
+	assert.Contains(t, b.String(), "Accounts Tracked")
+	assert.Contains(t, b.String(), "Currencies Seen")
+
+	// When restarting, the counters are set to the
+	// number of accounts already in balance storage
+	// (so they are correct even if they were not
+	// maintained before).
+	restarted := NewTrackedAccounts(counterStorage)
+	assert.NoError(t, restarted.Seed(ctx, []*reconciler.AccountCurrency{
+		{Account: addr1, Currency: btc},
+		{Account: addr2, Currency: btc},
+		{Account: addr2, Currency: eth},
+		{Account: addr1, Currency: eth},
+	}))
+	assert.NoError(t, restarted.Add(ctx, []*parser.BalanceChange{
+		{Account: addr1, Currency: btc},
+	}))
+
+	stats = results.ComputeCheckDataStats(ctx, cfg, counterStorage, nil, nil)
+	assert.Equal(t, int64(4), stats.AccountsTracked)
+	assert.Equal(t, int64(2), stats.CurrenciesSeen)
+}
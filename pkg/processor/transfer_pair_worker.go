@@ -0,0 +1,494 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// transferPairKey is the key of the
+	// stored *transferPairRecord.
+	transferPairKey = "transfer-pairs"
+
+	// transferPairPrecision is the number of hash bits used
+	// to select a HyperLogLog register. 2^12 registers have
+	// a standard error of 1.04/sqrt(2^12) (~1.6%).
+	transferPairPrecision = 12
+
+	// transferPairRegisters is the number
+	// of HyperLogLog registers.
+	transferPairRegisters = 1 << transferPairPrecision
+
+	// transferPairCapacity is the number of pairs counted
+	// to find the most frequent pairs. Tracking more pairs
+	// than are reported makes the top pairs more accurate.
+	transferPairCapacity = 100
+
+	// transferPairTopPairs is the number
+	// of most frequent pairs reported.
+	transferPairTopPairs = 10
+
+	// maxTransferPairsPerTransaction bounds the pairs sketched
+	// for a single transaction so that transactions with many
+	// inputs and outputs do not dominate the cost of a block.
+	maxTransferPairsPerTransaction = 64
+)
+
+var _ storage.BlockWorker = (*TransferPairWorker)(nil)
+
+// transferPair is a sender and recipient
+// of value in a transaction.
+type transferPair struct {
+	sender    string
+	recipient string
+}
+
+// hash returns a 64-bit hash of the pair. The FNV-1a hash is
+// mixed (with the SplitMix64 finalizer) so that its high bits
+// are uniformly distributed, as HyperLogLog requires.
+func (p transferPair) hash() uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(p.sender))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(p.recipient))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// transferPairRecord is the stored state of the transfer
+// pair sketch. Registers are the HyperLogLog registers of
+// all pairs and Pairs are the pairs counted (with the
+// Space-Saving algorithm) to find the most frequent pairs.
+type transferPairRecord struct {
+	Transfers int64                   `json:"transfers"`
+	Registers []byte                  `json:"registers"`
+	Pairs     []*results.TransferPair `json:"pairs"`
+}
+
+// transferPairSketch estimates the number of distinct
+// transfer pairs and the most frequent pairs in bounded
+// memory (regardless of the number of pairs).
+type transferPairSketch struct {
+	transfers int64
+	registers []byte
+	pairs     map[transferPair]*results.TransferPair
+}
+
+func newTransferPairSketch() *transferPairSketch {
+	return &transferPairSketch{
+		registers: make([]byte, transferPairRegisters),
+		pairs:     map[transferPair]*results.TransferPair{},
+	}
+}
+
+// clone returns a deep copy of the sketch so that
+// it can be updated without modifying the original.
+func (s *transferPairSketch) clone() *transferPairSketch {
+	c := &transferPairSketch{
+		transfers: s.transfers,
+		registers: make([]byte, len(s.registers)),
+		pairs:     make(map[transferPair]*results.TransferPair, len(s.pairs)),
+	}
+	copy(c.registers, s.registers)
+	for key, pair := range s.pairs {
+		pairCopy := *pair
+		c.pairs[key] = &pairCopy
+	}
+
+	return c
+}
+
+// add records a transfer between pair.
+func (s *transferPairSketch) add(pair transferPair) {
+	s.transfers++
+
+	hash := pair.hash()
+	register := hash >> (64 - transferPairPrecision)
+	rank := byte(bits.LeadingZeros64(hash<<transferPairPrecision|1<<(transferPairPrecision-1)) + 1)
+	if rank > s.registers[register] {
+		s.registers[register] = rank
+	}
+
+	if counted, ok := s.pairs[pair]; ok {
+		counted.Count++
+		return
+	}
+
+	if len(s.pairs) < transferPairCapacity {
+		s.pairs[pair] = &results.TransferPair{
+			Sender:    pair.sender,
+			Recipient: pair.recipient,
+			Count:     1,
+		}
+		return
+	}
+
+	// The least frequent pair is replaced and the new pair
+	// inherits its count (which bounds the overestimate).
+	var minKey transferPair
+	var minPair *results.TransferPair
+	for key, counted := range s.pairs {
+		if minPair == nil || lessFrequent(counted, minPair) {
+			minKey, minPair = key, counted
+		}
+	}
+
+	delete(s.pairs, minKey)
+	s.pairs[pair] = &results.TransferPair{
+		Sender:    pair.sender,
+		Recipient: pair.recipient,
+		Count:     minPair.Count + 1,
+		Error:     minPair.Count,
+	}
+}
+
+// remove reverts a transfer between pair in an orphaned block.
+// HyperLogLog registers cannot be decremented, so orphaned pairs
+// remain in the distinct pair estimate.
+func (s *transferPairSketch) remove(pair transferPair) {
+	// The block may have been added before
+	// transfer pairs were sketched.
+	if s.transfers > 0 {
+		s.transfers--
+	}
+
+	counted, ok := s.pairs[pair]
+	if !ok {
+		return
+	}
+
+	counted.Count--
+	if counted.Count <= 0 {
+		delete(s.pairs, pair)
+		return
+	}
+
+	if counted.Error > counted.Count {
+		counted.Error = counted.Count
+	}
+}
+
+// distinct returns the HyperLogLog estimate of
+// the number of distinct pairs.
+func (s *transferPairSketch) distinct() int64 {
+	m := float64(len(s.registers))
+	sum := 0.0
+	zeros := 0
+	for _, rank := range s.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return int64(math.Round(estimate))
+}
+
+// sortedPairs returns the counted pairs from
+// the most frequent to the least frequent.
+func (s *transferPairSketch) sortedPairs() []*results.TransferPair {
+	pairs := make([]*results.TransferPair, 0, len(s.pairs))
+	for _, pair := range s.pairs {
+		pairCopy := *pair
+		pairs = append(pairs, &pairCopy)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessFrequent(pairs[j], pairs[i])
+	})
+
+	return pairs
+}
+
+// lessFrequent returns a boolean indicating if a was counted
+// fewer times than b. Ties are broken by sender and recipient
+// so that the order does not depend on map iteration.
+func lessFrequent(a *results.TransferPair, b *results.TransferPair) bool {
+	if a.Count != b.Count {
+		return a.Count < b.Count
+	}
+
+	if a.Sender != b.Sender {
+		return a.Sender > b.Sender
+	}
+
+	return a.Recipient > b.Recipient
+}
+
+// TransferPairWorker implements the storage.BlockWorker
+// interface. It sketches the sender and recipient pairs
+// of transfers in each added block to estimate how much
+// of the transaction graph was exercised.
+//
+// The sketch is stored in a single key (updated in the
+// same database transaction as each block) so that it
+// survives restarts. The committed sketch is cached so
+// that it is only read from the database once.
+type TransferPairWorker struct {
+	database storage.Database
+	asserter *asserter.Asserter
+
+	sketchMutex sync.Mutex
+	sketch      *transferPairSketch
+}
+
+// NewTransferPairWorker returns a new *TransferPairWorker.
+func NewTransferPairWorker(
+	database storage.Database,
+	asserter *asserter.Asserter,
+) *TransferPairWorker {
+	return &TransferPairWorker{
+		database: database,
+		asserter: asserter,
+	}
+}
+
+// transferPairs returns the pairs of accounts debited and
+// credited (in the same currency) by successful operations
+// in each transaction of block. A pair is only returned once
+// per transaction.
+func transferPairs(asserter *asserter.Asserter, block *types.Block) ([]transferPair, error) {
+	type transferAccount struct {
+		account  string
+		currency string
+	}
+
+	pairs := []transferPair{}
+	for _, txn := range block.Transactions {
+		senders := []transferAccount{}
+		recipients := []transferAccount{}
+		for _, op := range txn.Operations {
+			if op.Account == nil || op.Amount == nil {
+				continue
+			}
+
+			success, err := asserter.OperationSuccessful(op)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to determine if operation is successful", err)
+			}
+
+			if !success {
+				continue
+			}
+
+			value, err := types.AmountValue(op.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to parse amount", err)
+			}
+
+			account := transferAccount{
+				account:  types.AccountString(op.Account),
+				currency: types.Hash(op.Amount.Currency),
+			}
+			switch value.Sign() {
+			case -1:
+				senders = append(senders, account)
+			case 1:
+				recipients = append(recipients, account)
+			}
+		}
+
+		seen := map[transferPair]struct{}{}
+		for _, sender := range senders {
+			for _, recipient := range recipients {
+				if len(seen) == maxTransferPairsPerTransaction {
+					break
+				}
+
+				if sender.currency != recipient.currency || sender.account == recipient.account {
+					continue
+				}
+
+				pair := transferPair{sender: sender.account, recipient: recipient.account}
+				if _, ok := seen[pair]; ok {
+					continue
+				}
+
+				seen[pair] = struct{}{}
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+// getSketch returns a copy of the committed sketch (reading
+// it from transaction if it has not been cached).
+func (w *TransferPairWorker) getSketch(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+) (*transferPairSketch, error) {
+	w.sketchMutex.Lock()
+	defer w.sketchMutex.Unlock()
+
+	if w.sketch != nil {
+		return w.sketch.clone(), nil
+	}
+
+	exists, val, err := transaction.Get(ctx, []byte(transferPairKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get transfer pairs", err)
+	}
+
+	if !exists {
+		return newTransferPairSketch(), nil
+	}
+
+	var record transferPairRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode transfer pairs", err)
+	}
+
+	if len(record.Registers) != transferPairRegisters {
+		return nil, fmt.Errorf(
+			"stored transfer pairs have %d registers (expected %d)",
+			len(record.Registers),
+			transferPairRegisters,
+		)
+	}
+
+	sketch := &transferPairSketch{
+		transfers: record.Transfers,
+		registers: record.Registers,
+		pairs:     make(map[transferPair]*results.TransferPair, len(record.Pairs)),
+	}
+	for _, pair := range record.Pairs {
+		sketch.pairs[transferPair{sender: pair.Sender, recipient: pair.Recipient}] = pair
+	}
+
+	return sketch, nil
+}
+
+// update applies apply to each transfer pair in block and
+// stores the updated sketch in transaction. The cached sketch
+// is only replaced once transaction is committed.
+func (w *TransferPairWorker) update(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+	apply func(*transferPairSketch, transferPair),
+) (storage.CommitWorker, error) {
+	pairs, err := transferPairs(w.asserter, block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	sketch, err := w.getSketch(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pair := range pairs {
+		apply(sketch, pair)
+	}
+
+	val, err := json.Marshal(&transferPairRecord{
+		Transfers: sketch.transfers,
+		Registers: sketch.registers,
+		Pairs:     sketch.sortedPairs(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to encode transfer pairs", err)
+	}
+
+	if err := transaction.Set(ctx, []byte(transferPairKey), val, true); err != nil {
+		return nil, fmt.Errorf("%w: unable to store transfer pairs", err)
+	}
+
+	return func(ctx context.Context) error {
+		w.sketchMutex.Lock()
+		defer w.sketchMutex.Unlock()
+
+		w.sketch = sketch
+		return nil
+	}, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+func (w *TransferPairWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return w.update(ctx, block, transaction, (*transferPairSketch).add)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// The transfers of the block are subtracted from the transfer
+// and pair counts, but the distinct pair estimate is only
+// approximately reverted (it cannot forget a pair).
+func (w *TransferPairWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return w.update(ctx, block, transaction, (*transferPairSketch).remove)
+}
+
+// Results returns the *results.TransferPairStats
+// of all canonical blocks.
+func (w *TransferPairWorker) Results(ctx context.Context) (*results.TransferPairStats, error) {
+	transaction := w.database.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	sketch, err := w.getSketch(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	topPairs := sketch.sortedPairs()
+	if len(topPairs) > transferPairTopPairs {
+		topPairs = topPairs[:transferPairTopPairs]
+	}
+
+	return &results.TransferPairStats{
+		Transfers:              sketch.transfers,
+		EstimatedDistinctPairs: sketch.distinct(),
+		TopPairs:               topPairs,
+	}, nil
+}
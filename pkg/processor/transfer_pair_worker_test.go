@@ -0,0 +1,181 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var transferPairTestCurrency = &types.Currency{Symbol: "BTC", Decimals: 8}
+
+// transferPairTestBlock returns a block with a transaction
+// for each transfer. Each transfer is "sender>recipient".
+func transferPairTestBlock(
+	index int64,
+	hash string,
+	parentHash string,
+	transfers ...string,
+) *types.Block {
+	opCounts := make([]int, len(transfers))
+	for i := range transfers {
+		opCounts[i] = 2
+	}
+
+	block := orphanTestBlock(index, hash, parentHash, opCounts...)
+	for i, transfer := range transfers {
+		accounts := strings.Split(transfer, ">")
+		sender, recipient := accounts[0], accounts[1]
+
+		debit, credit := block.Transactions[i].Operations[0], block.Transactions[i].Operations[1]
+		debit.Status = types.String("SUCCESS")
+		debit.Account = &types.AccountIdentifier{Address: sender}
+		debit.Amount = &types.Amount{Value: "-10", Currency: transferPairTestCurrency}
+		credit.Status = types.String("SUCCESS")
+		credit.Account = &types.AccountIdentifier{Address: recipient}
+		credit.Amount = &types.Amount{Value: "10", Currency: transferPairTestCurrency}
+	}
+
+	return block
+}
+
+func TestTransferPairWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	networkAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "0"},
+		[]string{"Transfer"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	blockStorage := storage.NewBlockStorage(localStore)
+	worker := NewTransferPairWorker(localStore, networkAsserter)
+	blockStorage.Initialize([]storage.BlockWorker{worker})
+
+	transferPairStats := func(worker *TransferPairWorker) *results.TransferPairStats {
+		stats, err := worker.Results(ctx)
+		assert.NoError(t, err)
+
+		return stats
+	}
+
+	assert.Equal(t, &results.TransferPairStats{
+		TopPairs: []*results.TransferPair{},
+	}, transferPairStats(worker))
+
+	// Failed operations are not transfers.
+	failed := transferPairTestBlock(1, "1", "0", "a>b", "c>d")
+	failed.Transactions[1].Operations[0].Status = types.String("FAILURE")
+	assert.NoError(t, blockStorage.AddBlock(ctx, transferPairTestBlock(0, "0", "0")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, failed))
+	assert.NoError(t, blockStorage.AddBlock(ctx, transferPairTestBlock(2, "2", "1", "a>b", "b>a")))
+	assert.Equal(t, &results.TransferPairStats{
+		Transfers:              3,
+		EstimatedDistinctPairs: 2,
+		TopPairs: []*results.TransferPair{
+			{Sender: "a", Recipient: "b", Count: 2},
+			{Sender: "b", Recipient: "a", Count: 1},
+		},
+	}, transferPairStats(worker))
+
+	// Orphaned transfers are removed from the counts, but
+	// remain in the distinct pair estimate.
+	block3a := transferPairTestBlock(3, "3a", "2", "a>b", "c>d")
+	assert.NoError(t, blockStorage.AddBlock(ctx, block3a))
+	assert.Equal(t, int64(5), transferPairStats(worker).Transfers)
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, block3a.BlockIdentifier))
+	assert.Equal(t, &results.TransferPairStats{
+		Transfers:              3,
+		EstimatedDistinctPairs: 3,
+		TopPairs: []*results.TransferPair{
+			{Sender: "a", Recipient: "b", Count: 2},
+			{Sender: "b", Recipient: "a", Count: 1},
+		},
+	}, transferPairStats(worker))
+
+	// The sketch is loaded from storage when resuming.
+	assert.Equal(
+		t,
+		transferPairStats(worker),
+		transferPairStats(NewTransferPairWorker(localStore, networkAsserter)),
+	)
+}
+
+func TestTransferPairSketchDistinct(t *testing.T) {
+	for _, distinct := range []int{100, 10000, 200000} {
+		sketch := newTransferPairSketch()
+		for i := 0; i < distinct; i++ {
+			pair := transferPair{
+				sender:    fmt.Sprintf("sender %d", i),
+				recipient: fmt.Sprintf("recipient %d", i%7),
+			}
+
+			// Repeated pairs are only counted once.
+			sketch.add(pair)
+			sketch.add(pair)
+		}
+
+		estimate := float64(sketch.distinct())
+		assert.InDelta(t, float64(distinct), estimate, math.Max(1, 0.05*float64(distinct)))
+		assert.Equal(t, int64(2*distinct), sketch.transfers)
+		assert.Len(t, sketch.pairs, int(math.Min(float64(distinct), transferPairCapacity)))
+	}
+}
+
+func TestTransferPairSketchTopPairs(t *testing.T) {
+	sketch := newTransferPairSketch()
+	frequent := transferPair{sender: "exchange", recipient: "customer"}
+	for i := 0; i < 10*transferPairCapacity; i++ {
+		sketch.add(transferPair{sender: fmt.Sprintf("sender %d", i), recipient: "recipient"})
+		if i%2 == 0 {
+			sketch.add(frequent)
+		}
+	}
+
+	top := sketch.sortedPairs()[0]
+	assert.Equal(t, "exchange", top.Sender)
+	assert.Equal(t, "customer", top.Recipient)
+	assert.True(t, top.Count >= 5*transferPairCapacity)
+	assert.True(t, top.Count-top.Error <= 5*transferPairCapacity)
+}
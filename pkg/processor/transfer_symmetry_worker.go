@@ -0,0 +1,319 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// transferAsymmetriesKey is the database key of
+// the recorded unbalanced transactions.
+const transferAsymmetriesKey = "transfer-asymmetries"
+
+var _ storage.BlockWorker = (*TransferSymmetryWorker)(nil)
+
+// TransferSymmetryWorker implements the storage.BlockWorker
+// interface. It counts the transactions in each added block
+// with checked operations and the transactions where the sum
+// of the debits does not equal the sum of the credits (in any
+// currency). The first results.TransferAsymmetryLimit
+// unbalanced transactions are logged and recorded (or the
+// first is returned as an error if the check is fatal).
+//
+// Transactions in orphaned blocks are still counted because
+// they were seen while syncing.
+type TransferSymmetryWorker struct {
+	counterStorage *storage.CounterStorage
+	database       storage.Database
+	asserter       *asserter.Asserter
+
+	// operationTypes are the checked operation
+	// types (all types if empty).
+	operationTypes map[string]struct{}
+	excludedTypes  map[string]struct{}
+	fatal          bool
+}
+
+// NewTransferSymmetryWorker returns a new *TransferSymmetryWorker.
+// Operations of the feeOperationTypes are never checked.
+func NewTransferSymmetryWorker(
+	counterStorage *storage.CounterStorage,
+	database storage.Database,
+	asserter *asserter.Asserter,
+	config *configuration.TransferSymmetryConfiguration,
+	feeOperationTypes []string,
+) *TransferSymmetryWorker {
+	operationTypes := make(map[string]struct{}, len(config.OperationTypes))
+	for _, operationType := range config.OperationTypes {
+		operationTypes[operationType] = struct{}{}
+	}
+
+	excludedTypes := map[string]struct{}{}
+	for _, operationType := range config.ExcludedOperationTypes {
+		excludedTypes[operationType] = struct{}{}
+	}
+	for _, operationType := range feeOperationTypes {
+		excludedTypes[operationType] = struct{}{}
+	}
+
+	return &TransferSymmetryWorker{
+		counterStorage: counterStorage,
+		database:       database,
+		asserter:       asserter,
+		operationTypes: operationTypes,
+		excludedTypes:  excludedTypes,
+		fatal:          config.Fatal,
+	}
+}
+
+// checked returns a boolean indicating if
+// operationType is checked.
+func (w *TransferSymmetryWorker) checked(operationType string) bool {
+	if _, ok := w.excludedTypes[operationType]; ok {
+		return false
+	}
+
+	if len(w.operationTypes) == 0 {
+		return true
+	}
+
+	_, ok := w.operationTypes[operationType]
+	return ok
+}
+
+// imbalances returns the sum of the amounts of the checked
+// successful operations in txn for each currency where it
+// is not zero (sorted by currency symbol) and a boolean
+// indicating if txn has any checked operations.
+func (w *TransferSymmetryWorker) imbalances(
+	txn *types.Transaction,
+) ([]*types.Amount, bool, error) {
+	sums := map[string]*big.Int{}
+	currencies := map[string]*types.Currency{}
+	for _, op := range txn.Operations {
+		if op.Amount == nil || !w.checked(op.Type) {
+			continue
+		}
+
+		success, err := w.asserter.OperationSuccessful(op)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: unable to determine if operation is successful", err)
+		}
+
+		if !success {
+			continue
+		}
+
+		value, err := types.AmountValue(op.Amount)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: unable to parse amount", err)
+		}
+
+		key := types.Hash(op.Amount.Currency)
+		if _, ok := sums[key]; !ok {
+			sums[key] = new(big.Int)
+			currencies[key] = op.Amount.Currency
+		}
+		sums[key].Add(sums[key], value)
+	}
+
+	imbalances := []*types.Amount{}
+	for key, sum := range sums {
+		if sum.Sign() == 0 {
+			continue
+		}
+
+		imbalances = append(imbalances, &types.Amount{
+			Value:    sum.String(),
+			Currency: currencies[key],
+		})
+	}
+
+	sort.Slice(imbalances, func(i, j int) bool {
+		if imbalances[i].Currency.Symbol != imbalances[j].Currency.Symbol {
+			return imbalances[i].Currency.Symbol < imbalances[j].Currency.Symbol
+		}
+
+		return imbalances[i].Currency.Decimals < imbalances[j].Currency.Decimals
+	})
+
+	return imbalances, len(sums) > 0, nil
+}
+
+// getAsymmetries returns the unbalanced
+// transactions recorded in transaction.
+func getAsymmetries(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+) ([]*results.TransferAsymmetry, error) {
+	exists, val, err := transaction.Get(ctx, []byte(transferAsymmetriesKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get transfer asymmetries", err)
+	}
+
+	asymmetries := []*results.TransferAsymmetry{}
+	if !exists {
+		return asymmetries, nil
+	}
+
+	if err := json.Unmarshal(val, &asymmetries); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal transfer asymmetries", err)
+	}
+
+	return asymmetries, nil
+}
+
+// recordAsymmetries logs and records the unbalanced
+// transactions of a block in transaction (unless
+// results.TransferAsymmetryLimit transactions were
+// already recorded).
+func recordAsymmetries(
+	ctx context.Context,
+	transaction storage.DatabaseTransaction,
+	asymmetries []*results.TransferAsymmetry,
+) error {
+	recorded, err := getAsymmetries(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if len(recorded) >= results.TransferAsymmetryLimit {
+		return nil
+	}
+
+	if remaining := results.TransferAsymmetryLimit - len(recorded); len(asymmetries) > remaining {
+		asymmetries = asymmetries[:remaining]
+	}
+
+	for _, asymmetry := range asymmetries {
+		logging.Warn(
+			"debits and credits of transaction are not balanced",
+			logging.Fields{
+				"block":       asymmetry.Block,
+				"transaction": asymmetry.Transaction.Hash,
+				"imbalance":   results.FormatImbalances(asymmetry.Imbalances),
+			},
+		)
+	}
+
+	val, err := json.Marshal(append(recorded, asymmetries...))
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal transfer asymmetries", err)
+	}
+
+	if err := transaction.Set(ctx, []byte(transferAsymmetriesKey), val, true); err != nil {
+		return fmt.Errorf("%w: unable to store transfer asymmetries", err)
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+// The counters and recorded unbalanced transactions are
+// updated in the same database transaction as the addition.
+func (w *TransferSymmetryWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	checked := int64(0)
+	asymmetries := []*results.TransferAsymmetry{}
+	for _, txn := range block.Transactions {
+		imbalances, ok, err := w.imbalances(txn)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		checked++
+		if len(imbalances) == 0 {
+			continue
+		}
+
+		if w.fatal {
+			return nil, fmt.Errorf(
+				"%w: transaction %s in block %d is unbalanced by %s",
+				results.ErrTransferAsymmetry,
+				txn.TransactionIdentifier.Hash,
+				block.BlockIdentifier.Index,
+				results.FormatImbalances(imbalances),
+			)
+		}
+
+		asymmetries = append(asymmetries, &results.TransferAsymmetry{
+			Block:       block.BlockIdentifier,
+			Transaction: txn.TransactionIdentifier,
+			Imbalances:  imbalances,
+		})
+	}
+
+	if len(asymmetries) > 0 {
+		if err := recordAsymmetries(ctx, transaction, asymmetries); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := map[string]int64{
+		results.TransferSymmetryCheckedCounter:   checked,
+		results.TransferSymmetryViolationCounter: int64(len(asymmetries)),
+	}
+	for counter, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		_, err := w.counterStorage.UpdateTransactional(ctx, transaction, counter, big.NewInt(count))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+func (w *TransferSymmetryWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
+
+// Results returns the first results.TransferAsymmetryLimit
+// unbalanced transactions.
+func (w *TransferSymmetryWorker) Results(
+	ctx context.Context,
+) ([]*results.TransferAsymmetry, error) {
+	transaction := w.database.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	return getAsymmetries(ctx, transaction)
+}
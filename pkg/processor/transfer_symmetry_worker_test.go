@@ -0,0 +1,245 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// symmetryTestOp is the type, status,
+// and amount of an operation.
+type symmetryTestOp struct {
+	opType   string
+	status   string
+	value    string
+	currency *types.Currency
+}
+
+// symmetryTestBlock returns a block with a
+// transaction for each slice of operations.
+func symmetryTestBlock(
+	index int64,
+	hash string,
+	parentHash string,
+	txns ...[]symmetryTestOp,
+) *types.Block {
+	opCounts := make([]int, len(txns))
+	for i, ops := range txns {
+		opCounts[i] = len(ops)
+	}
+
+	block := orphanTestBlock(index, hash, parentHash, opCounts...)
+	for i, ops := range txns {
+		for j, op := range ops {
+			operation := block.Transactions[i].Operations[j]
+			operation.Type = op.opType
+			operation.Status = types.String(op.status)
+			operation.Account = &types.AccountIdentifier{Address: "addr"}
+			operation.Amount = &types.Amount{Value: op.value, Currency: op.currency}
+		}
+	}
+
+	return block
+}
+
+func TestTransferSymmetryWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	networkAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "0"},
+		[]string{"Transfer", "Fee", "Mint"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	worker := NewTransferSymmetryWorker(
+		counterStorage,
+		localStore,
+		networkAsserter,
+		&configuration.TransferSymmetryConfiguration{
+			ExcludedOperationTypes: []string{"Mint"},
+		},
+		[]string{"Fee"},
+	)
+	blockStorage.Initialize([]storage.BlockWorker{worker})
+
+	counter := func(name string) int64 {
+		value, err := counterStorage.Get(ctx, name)
+		assert.NoError(t, err)
+		return value.Int64()
+	}
+
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	eth := &types.Currency{Symbol: "ETH", Decimals: 18}
+	assert.NoError(t, blockStorage.AddBlock(ctx, symmetryTestBlock(0, "0", "0")))
+	assert.NoError(t, blockStorage.AddBlock(ctx, symmetryTestBlock(
+		1,
+		"1",
+		"0",
+		// Balanced (fees and failed operations are not checked).
+		[]symmetryTestOp{
+			{"Transfer", "SUCCESS", "-10", btc},
+			{"Transfer", "SUCCESS", "10", btc},
+			{"Fee", "SUCCESS", "-1", btc},
+			{"Transfer", "FAILURE", "5", btc},
+		},
+		// Balanced in each currency.
+		[]symmetryTestOp{
+			{"Transfer", "SUCCESS", "-10", btc},
+			{"Transfer", "SUCCESS", "-3", eth},
+			{"Transfer", "SUCCESS", "10", btc},
+			{"Transfer", "SUCCESS", "3", eth},
+		},
+		// Only excluded operations.
+		[]symmetryTestOp{
+			{"Mint", "SUCCESS", "50", btc},
+		},
+		// The recipient was credited but the
+		// sender was not debited.
+		[]symmetryTestOp{
+			{"Transfer", "SUCCESS", "10", btc},
+			{"Transfer", "SUCCESS", "-3", eth},
+			{"Transfer", "SUCCESS", "3", eth},
+		},
+	)))
+	assert.Equal(t, int64(3), counter(results.TransferSymmetryCheckedCounter))
+	assert.Equal(t, int64(1), counter(results.TransferSymmetryViolationCounter))
+
+	// The unbalanced transaction is recorded
+	// with its imbalance in each currency.
+	asymmetries, err := worker.Results(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*results.TransferAsymmetry{
+		{
+			Block:       &types.BlockIdentifier{Index: 1, Hash: "1"},
+			Transaction: &types.TransactionIdentifier{Hash: "1 tx 3"},
+			Imbalances:  []*types.Amount{{Value: "10", Currency: btc}},
+		},
+	}, asymmetries)
+
+	imbalances, checked, err := worker.imbalances(symmetryTestBlock(
+		3,
+		"3",
+		"2",
+		[]symmetryTestOp{
+			{"Transfer", "SUCCESS", "-2", eth},
+			{"Transfer", "SUCCESS", "7", btc},
+		},
+	).Transactions[0])
+	assert.NoError(t, err)
+	assert.True(t, checked)
+	assert.Equal(t, "7 BTC, -2 ETH", results.FormatImbalances(imbalances))
+
+	// Only the configured operation types are checked.
+	worker = NewTransferSymmetryWorker(
+		counterStorage,
+		localStore,
+		networkAsserter,
+		&configuration.TransferSymmetryConfiguration{
+			OperationTypes: []string{"Fee"},
+		},
+		nil,
+	)
+	assert.True(t, worker.checked("Fee"))
+	assert.False(t, worker.checked("Transfer"))
+
+	// A fatal check returns an error for the
+	// first unbalanced transaction.
+	worker = NewTransferSymmetryWorker(
+		counterStorage,
+		localStore,
+		networkAsserter,
+		&configuration.TransferSymmetryConfiguration{Fatal: true},
+		nil,
+	)
+	txn := localStore.NewDatabaseTransaction(ctx, true)
+	defer txn.Discard(ctx)
+	_, err = worker.AddingBlock(ctx, symmetryTestBlock(
+		2,
+		"2",
+		"1",
+		[]symmetryTestOp{{"Transfer", "SUCCESS", "-4", btc}},
+	), txn)
+	assert.True(t, errors.Is(err, results.ErrTransferAsymmetry))
+	assert.Contains(t, err.Error(), "transaction 2 tx 0 in block 2 is unbalanced by -4 BTC")
+}
+
+func TestRecordAsymmetries_Limit(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	asymmetries := make([]*results.TransferAsymmetry, results.TransferAsymmetryLimit-1)
+	for i := range asymmetries {
+		asymmetries[i] = &results.TransferAsymmetry{
+			Block:       &types.BlockIdentifier{Index: 1, Hash: "1"},
+			Transaction: &types.TransactionIdentifier{Hash: fmt.Sprintf("1 tx %d", i)},
+		}
+	}
+
+	// Only the first TransferAsymmetryLimit
+	// unbalanced transactions are recorded.
+	txn := localStore.NewDatabaseTransaction(ctx, true)
+	assert.NoError(t, recordAsymmetries(ctx, txn, asymmetries))
+	assert.NoError(t, recordAsymmetries(ctx, txn, asymmetries))
+	assert.NoError(t, txn.Commit(ctx))
+
+	worker := &TransferSymmetryWorker{database: localStore}
+	recorded, err := worker.Results(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, recorded, results.TransferAsymmetryLimit)
+	assert.Equal(t, asymmetries[0], recorded[results.TransferAsymmetryLimit-1])
+}
@@ -0,0 +1,273 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/statefulsyncer"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// trustedBlockRetryDelay is the time waited
+	// before retrying a failed fetch of a block at
+	// or below the trusted checkpoint.
+	trustedBlockRetryDelay = 1 * time.Second
+)
+
+var (
+	// ErrTrustedCheckpointMismatch is returned when the block
+	// at the index of the trusted checkpoint does not have
+	// the hash of the trusted checkpoint.
+	ErrTrustedCheckpointMismatch = errors.New("block does not match trusted checkpoint")
+
+	_ syncer.Helper       = (*CheckpointSyncer)(nil)
+	_ syncer.Handler      = (*CheckpointSyncer)(nil)
+	_ storage.BlockWorker = (*TrustedCheckpointWorker)(nil)
+)
+
+// CheckpointSyncer wraps a *statefulsyncer.StatefulSyncer
+// so that blocks at or below a trusted checkpoint are
+// fetched without asserting them. The block at the index
+// of the checkpoint must have the hash of the checkpoint.
+// Blocks after the checkpoint are fetched (and asserted)
+// by the *statefulsyncer.StatefulSyncer.
+type CheckpointSyncer struct {
+	*statefulsyncer.StatefulSyncer
+
+	network        *types.NetworkIdentifier
+	fetcher        *fetcher.Fetcher
+	blockStorage   *storage.BlockStorage
+	cancel         context.CancelFunc
+	workers        []storage.BlockWorker
+	cacheSize      int
+	maxConcurrency int64
+	maxRetries     uint64
+	checkpoint     *configuration.TrustedCheckpoint
+}
+
+// NewCheckpointSyncer returns a new *CheckpointSyncer. The
+// arguments must match those provided to statefulsyncer.New
+// when creating statefulSyncer.
+func NewCheckpointSyncer(
+	statefulSyncer *statefulsyncer.StatefulSyncer,
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	blockStorage *storage.BlockStorage,
+	cancel context.CancelFunc,
+	workers []storage.BlockWorker,
+	cacheSize int,
+	maxConcurrency int64,
+	maxRetries uint64,
+	checkpoint *configuration.TrustedCheckpoint,
+) *CheckpointSyncer {
+	return &CheckpointSyncer{
+		StatefulSyncer: statefulSyncer,
+		network:        network,
+		fetcher:        fetcher,
+		blockStorage:   blockStorage,
+		cancel:         cancel,
+		workers:        workers,
+		cacheSize:      cacheSize,
+		maxConcurrency: maxConcurrency,
+		maxRetries:     maxRetries,
+		checkpoint:     checkpoint,
+	}
+}
+
+// Sync starts a new sync run. It mirrors
+// (*statefulsyncer.StatefulSyncer).Sync but fetches
+// blocks with the *CheckpointSyncer.
+func (c *CheckpointSyncer) Sync(ctx context.Context, startIndex int64, endIndex int64) error {
+	c.blockStorage.Initialize(c.workers)
+
+	// Ensure storage is in correct state for starting at index
+	if startIndex != -1 { // attempt to remove blocks from storage (without handling)
+		if err := c.blockStorage.SetNewStartIndex(ctx, startIndex); err != nil {
+			return fmt.Errorf("%w: unable to set new start index", err)
+		}
+	} else { // attempt to load last processed index
+		head, err := c.blockStorage.GetHeadBlockIdentifier(ctx)
+		if err == nil {
+			startIndex = head.Index + 1
+		}
+	}
+
+	s := syncer.New(
+		c.network,
+		c,
+		c,
+		c.cancel,
+		syncer.WithPastBlocks(c.blockStorage.CreateBlockCache(ctx)),
+		syncer.WithCacheSize(c.cacheSize),
+		syncer.WithMaxConcurrency(c.maxConcurrency),
+	)
+
+	return s.Sync(ctx, startIndex, endIndex)
+}
+
+// Block is called by the syncer to fetch a block. Blocks at
+// or below the trusted checkpoint are only checked to have
+// valid block identifiers (so they can be synced).
+func (c *CheckpointSyncer) Block(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	block *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	if block.Index == nil || *block.Index > c.checkpoint.Index {
+		return c.StatefulSyncer.Block(ctx, network, block)
+	}
+
+	var fetchErr *fetcher.Error
+	for attempt := uint64(0); attempt <= c.maxRetries; attempt++ {
+		var blockResponse *types.Block
+		blockResponse, fetchErr = c.fetcher.UnsafeBlock(ctx, network, block)
+		if fetchErr == nil {
+			return c.checkTrustedBlock(blockResponse)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(trustedBlockRetryDelay):
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unable to fetch trusted block %d", fetchErr.Err, *block.Index)
+}
+
+// checkTrustedBlock ensures a block at or below the
+// trusted checkpoint can be synced and, if it is at the
+// index of the checkpoint, that it has the checkpoint hash.
+func (c *CheckpointSyncer) checkTrustedBlock(block *types.Block) (*types.Block, error) {
+	// If a block is omitted, it will return a non-error
+	// response with block equal to nil.
+	if block == nil {
+		return nil, nil
+	}
+
+	if err := asserter.BlockIdentifier(block.BlockIdentifier); err != nil {
+		return nil, fmt.Errorf("%w: invalid trusted block identifier", err)
+	}
+
+	if err := asserter.BlockIdentifier(block.ParentBlockIdentifier); err != nil {
+		return nil, fmt.Errorf("%w: invalid trusted parent block identifier", err)
+	}
+
+	if block.BlockIdentifier.Index == c.checkpoint.Index &&
+		block.BlockIdentifier.Hash != c.checkpoint.Hash {
+		return nil, fmt.Errorf(
+			"%w: expected hash %s at index %d but got %s",
+			ErrTrustedCheckpointMismatch,
+			c.checkpoint.Hash,
+			c.checkpoint.Index,
+			block.BlockIdentifier.Hash,
+		)
+	}
+
+	return block, nil
+}
+
+// TrustedCheckpointWorker implements the storage.BlockWorker
+// interface. It counts the canonical blocks (and their
+// transactions and operations) at or below the trusted
+// checkpoint separately from those after it.
+type TrustedCheckpointWorker struct {
+	counterStorage *storage.CounterStorage
+	checkpoint     *configuration.TrustedCheckpoint
+}
+
+// NewTrustedCheckpointWorker returns a new *TrustedCheckpointWorker.
+func NewTrustedCheckpointWorker(
+	counterStorage *storage.CounterStorage,
+	checkpoint *configuration.TrustedCheckpoint,
+) *TrustedCheckpointWorker {
+	return &TrustedCheckpointWorker{
+		counterStorage: counterStorage,
+		checkpoint:     checkpoint,
+	}
+}
+
+// updateCounters adds direction times the number of
+// blocks, transactions, and operations in block to the
+// counters for the range the block is in.
+func (w *TrustedCheckpointWorker) updateCounters(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+	direction int64,
+) error {
+	opCount := int64(0)
+	for _, txn := range block.Transactions {
+		opCount += int64(len(txn.Operations))
+	}
+
+	counters := []string{
+		results.ValidatedBlockCounter,
+		results.ValidatedTransactionCounter,
+		results.ValidatedOperationCounter,
+	}
+	if block.BlockIdentifier.Index <= w.checkpoint.Index {
+		counters = []string{
+			results.TrustedBlockCounter,
+			results.TrustedTransactionCounter,
+			results.TrustedOperationCounter,
+		}
+	}
+
+	for i, amount := range []int64{1, int64(len(block.Transactions)), opCount} {
+		_, err := w.counterStorage.UpdateTransactional(
+			ctx,
+			transaction,
+			counters[i],
+			big.NewInt(direction*amount),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: unable to update %s counter", err, counters[i])
+		}
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+func (w *TrustedCheckpointWorker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, w.updateCounters(ctx, block, transaction, 1)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+func (w *TrustedCheckpointWorker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, w.updateCounters(ctx, block, transaction, -1)
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedCheckpointWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	checkpoint := &configuration.TrustedCheckpoint{Index: 1, Hash: "block 1"}
+	counterStorage := storage.NewCounterStorage(localStore)
+	blockStorage := storage.NewBlockStorage(localStore)
+	blockStorage.Initialize([]storage.BlockWorker{
+		NewTrustedCheckpointWorker(counterStorage, checkpoint),
+	})
+
+	assertCounters := func(expected map[string]int64) {
+		for counter, value := range expected {
+			actual, err := counterStorage.Get(ctx, counter)
+			assert.NoError(t, err)
+			assert.Equal(t, value, actual.Int64(), counter)
+		}
+	}
+
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(0, "block 0", "block 0", 1)))
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(1, "block 1", "block 0", 2, 3)))
+	assert.NoError(t, blockStorage.AddBlock(ctx, orphanTestBlock(2, "block 2", "block 1", 4)))
+	assertCounters(map[string]int64{
+		results.TrustedBlockCounter:         2,
+		results.TrustedTransactionCounter:   3,
+		results.TrustedOperationCounter:     6,
+		results.ValidatedBlockCounter:       1,
+		results.ValidatedTransactionCounter: 1,
+		results.ValidatedOperationCounter:   4,
+	})
+
+	// Reorg out the validated block
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, &types.BlockIdentifier{
+		Index: 2,
+		Hash:  "block 2",
+	}))
+	assertCounters(map[string]int64{
+		results.TrustedBlockCounter:         2,
+		results.ValidatedBlockCounter:       0,
+		results.ValidatedTransactionCounter: 0,
+		results.ValidatedOperationCounter:   0,
+	})
+}
+
+func TestCheckTrustedBlock(t *testing.T) {
+	c := &CheckpointSyncer{
+		checkpoint: &configuration.TrustedCheckpoint{Index: 1, Hash: "block 1"},
+	}
+
+	var tests = map[string]struct {
+		block *types.Block
+		err   error
+	}{
+		"omitted block": {},
+		"below checkpoint": {
+			block: orphanTestBlock(0, "block 0", "block 0"),
+		},
+		"matches checkpoint": {
+			block: orphanTestBlock(1, "block 1", "block 0"),
+		},
+		"does not match checkpoint": {
+			block: orphanTestBlock(1, "other block 1", "block 0"),
+			err:   ErrTrustedCheckpointMismatch,
+		},
+		"invalid block identifier": {
+			block: orphanTestBlock(0, "", "block 0"),
+			err:   asserter.ErrBlockIdentifierHashMissing,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			block, err := c.checkTrustedBlock(test.block)
+			if test.err != nil {
+				assert.Nil(t, block)
+				assert.True(t, errors.Is(err, test.err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.block, block)
+		})
+	}
+}
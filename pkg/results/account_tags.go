@@ -0,0 +1,68 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+// TagReconciliationResult contains the reconciliation
+// outcomes of all accounts with a tag.
+type TagReconciliationResult struct {
+	Tag             string `json:"tag"`
+	Accounts        int64  `json:"accounts"`
+	Reconciliations int64  `json:"reconciliations"`
+	Failures        int64  `json:"failures"`
+
+	// Coverage is the proportion of Accounts
+	// successfully reconciled during the run.
+	Coverage float64 `json:"coverage"`
+}
+
+// TagReconciliationResults contains a
+// *TagReconciliationResult for each tag.
+type TagReconciliationResults []*TagReconciliationResult
+
+// Print logs TagReconciliationResults to the console.
+func (t TagReconciliationResults) Print() {
+	t.Fprint(color.Output)
+}
+
+// Fprint writes TagReconciliationResults to w.
+func (t TagReconciliationResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{
+		"check:data Account Tags",
+		"Accounts",
+		"Reconciliations",
+		"Failures",
+		"Coverage",
+	})
+	for _, result := range t {
+		table.Append([]string{
+			result.Tag,
+			strconv.FormatInt(result.Accounts, 10),
+			strconv.FormatInt(result.Reconciliations, 10),
+			strconv.FormatInt(result.Failures, 10),
+			fmt.Sprintf("%f%%", result.Coverage*utils.OneHundred),
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// AssertionFinding is a single assertion failure
+// observed while running check:data with
+// assertion_soft_fail enabled.
+type AssertionFinding struct {
+	Block       *types.BlockIdentifier       `json:"block_identifier"`
+	Transaction *types.TransactionIdentifier `json:"transaction_identifier"`
+	Rule        string                       `json:"rule"`
+	Message     string                       `json:"message"`
+}
+
+// AssertionFindings contains each AssertionFinding
+// recorded by an AssertionCatalog and the number of
+// findings for each rule.
+type AssertionFindings struct {
+	Findings   []*AssertionFinding `json:"findings"`
+	RuleCounts map[string]int64    `json:"rule_counts"`
+	Truncated  bool                `json:"truncated"`
+}
+
+// Print logs AssertionFindings to the console.
+func (a *AssertionFindings) Print() {
+	a.Fprint(color.Output)
+}
+
+// Fprint writes the number of findings for each
+// rule in AssertionFindings to w.
+func (a *AssertionFindings) Fprint(w io.Writer) {
+	rules := make([]string, 0, len(a.RuleCounts))
+	for rule := range a.RuleCounts {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	table := newTable(w, []string{"Assertion Findings", "Count"})
+	for _, rule := range rules {
+		table.Append([]string{rule, strconv.FormatInt(a.RuleCounts[rule], 10)})
+	}
+
+	table.Render()
+}
+
+// AssertionCatalog collects AssertionFindings (up to
+// some limit) and the number of findings for each rule.
+// Any account touched by a transaction that failed
+// assertion is marked as tainted.
+type AssertionCatalog struct {
+	findings *AssertionFindings
+	limit    int
+	seen     map[string]struct{}
+	tainted  map[string]struct{}
+	mutex    sync.Mutex
+}
+
+// NewAssertionCatalog returns a new *AssertionCatalog
+// that records at most limit findings.
+func NewAssertionCatalog(limit int) *AssertionCatalog {
+	return &AssertionCatalog{
+		findings: &AssertionFindings{
+			Findings:   []*AssertionFinding{},
+			RuleCounts: map[string]int64{},
+		},
+		limit:   limit,
+		seen:    map[string]struct{}{},
+		tainted: map[string]struct{}{},
+	}
+}
+
+// assertionRule returns the innermost wrapped error
+// message, which is the asserter rule that was violated.
+func assertionRule(err error) string {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return err.Error()
+		}
+
+		err = unwrapped
+	}
+}
+
+// Add records an assertion failure for a transaction
+// in a block and taints all provided accounts. A
+// transaction that fails assertion more than once (i.e.
+// if the block is fetched again) is only recorded once.
+func (c *AssertionCatalog) Add(
+	block *types.BlockIdentifier,
+	transaction *types.TransactionIdentifier,
+	err error,
+	accounts []*types.AccountIdentifier,
+) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, account := range accounts {
+		c.tainted[types.Hash(account)] = struct{}{}
+	}
+
+	rule := assertionRule(err)
+	key := fmt.Sprintf("%s:%s:%s", types.Hash(block), types.Hash(transaction), rule)
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+	c.seen[key] = struct{}{}
+
+	c.findings.RuleCounts[rule]++
+	if len(c.findings.Findings) >= c.limit {
+		c.findings.Truncated = true
+		return
+	}
+
+	c.findings.Findings = append(c.findings.Findings, &AssertionFinding{
+		Block:       block,
+		Transaction: transaction,
+		Rule:        rule,
+		Message:     err.Error(),
+	})
+}
+
+// Tainted returns a boolean indicating if an account
+// was touched by a transaction that failed assertion.
+func (c *AssertionCatalog) Tainted(account *types.AccountIdentifier) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, ok := c.tainted[types.Hash(account)]
+	return ok
+}
+
+// Findings returns a copy of the recorded *AssertionFindings
+// or nil if no assertion failures were recorded.
+func (c *AssertionCatalog) Findings() *AssertionFindings {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.findings.RuleCounts) == 0 {
+		return nil
+	}
+
+	ruleCounts := map[string]int64{}
+	for rule, count := range c.findings.RuleCounts {
+		ruleCounts[rule] = count
+	}
+
+	return &AssertionFindings{
+		Findings:   append([]*AssertionFinding{}, c.findings.Findings...),
+		RuleCounts: ruleCounts,
+		Truncated:  c.findings.Truncated,
+	}
+}
+
+// Empty returns a boolean indicating if no
+// assertion failures were recorded.
+func (c *AssertionCatalog) Empty() bool {
+	return c.Findings() == nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertionCatalog(t *testing.T) {
+	var nilCatalog *AssertionCatalog
+	assert.True(t, nilCatalog.Empty())
+	assert.Nil(t, nilCatalog.Findings())
+	assert.False(t, nilCatalog.Tainted(&types.AccountIdentifier{Address: "addr 1"}))
+
+	catalog := NewAssertionCatalog(10)
+	assert.True(t, catalog.Empty())
+
+	block := &types.BlockIdentifier{Index: 1, Hash: "block 1"}
+	tx := &types.TransactionIdentifier{Hash: "tx 1"}
+	err := fmt.Errorf(
+		"%w: invalid operation",
+		fmt.Errorf("%w: missing value", asserter.ErrAmountValueMissing),
+	)
+	accounts := []*types.AccountIdentifier{{Address: "addr 1"}}
+
+	// Adding the same finding twice (when a block is
+	// re-fetched) should only record it once.
+	catalog.Add(block, tx, err, accounts)
+	catalog.Add(block, tx, err, accounts)
+
+	assert.False(t, catalog.Empty())
+	assert.True(t, catalog.Tainted(&types.AccountIdentifier{Address: "addr 1"}))
+	assert.False(t, catalog.Tainted(&types.AccountIdentifier{Address: "addr 2"}))
+	assert.Equal(t, &AssertionFindings{
+		Findings: []*AssertionFinding{
+			{
+				Block:       block,
+				Transaction: tx,
+				Rule:        asserter.ErrAmountValueMissing.Error(),
+				Message:     err.Error(),
+			},
+		},
+		RuleCounts: map[string]int64{
+			asserter.ErrAmountValueMissing.Error(): 1,
+		},
+	}, catalog.Findings())
+}
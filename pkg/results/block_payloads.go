@@ -0,0 +1,129 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// BlockPayload is the size of a single /block response. Block is
+// the block requested (syncing requests blocks by index, so the
+// hash is usually not populated).
+type BlockPayload struct {
+	Block *types.PartialBlockIdentifier `json:"block"`
+
+	// Bytes is the size of the response body (after any gzip
+	// decompression) and WireBytes is the number of bytes of
+	// the response body read from the connection.
+	Bytes     int64 `json:"bytes"`
+	WireBytes int64 `json:"wire_bytes"`
+}
+
+// BlockPayloadStats summarizes the size (in bytes) of /block
+// responses. Sizes are measured when responses are read from the
+// connection (not by re-serializing each block), so Bytes includes
+// every field returned by the implementation. Each retry of a
+// request is counted separately.
+type BlockPayloadStats struct {
+	Count     int64   `json:"count"`
+	Mean      float64 `json:"mean_bytes"`
+	P99       int64   `json:"p99_bytes"`
+	Max       int64   `json:"max_bytes"`
+	WireBytes int64   `json:"wire_bytes"`
+
+	// Largest are the largest responses (largest first).
+	Largest []*BlockPayload `json:"largest"`
+
+	// MaxBlockPayloadBytes is the configured max_block_payload_bytes.
+	// Violations are the first 100 responses larger than it and
+	// ViolationCount is the number of responses larger than it.
+	MaxBlockPayloadBytes int64           `json:"max_block_payload_bytes,omitempty"`
+	ViolationCount       int64           `json:"violation_count"`
+	Violations           []*BlockPayload `json:"violations,omitempty"`
+}
+
+// describeBlock returns a description of a requested
+// block for printing.
+func describeBlock(block *types.PartialBlockIdentifier) string {
+	switch {
+	case block == nil:
+		return "unknown"
+	case block.Index != nil && block.Hash != nil:
+		return fmt.Sprintf("%d (%s)", *block.Index, *block.Hash)
+	case block.Index != nil:
+		return strconv.FormatInt(*block.Index, 10)
+	case block.Hash != nil:
+		return *block.Hash
+	default:
+		return "current"
+	}
+}
+
+// printBlockPayloads writes *BlockPayloadStats
+// to w.
+func printBlockPayloads(w io.Writer, stats *BlockPayloadStats) {
+	table := newTable(w, []string{
+		"check:data Block Payloads",
+		"Responses",
+		"Mean (bytes)",
+		"P99 (bytes)",
+		"Max (bytes)",
+		"Wire (bytes)",
+	})
+	table.Append([]string{
+		"/block",
+		strconv.FormatInt(stats.Count, 10),
+		strconv.FormatFloat(stats.Mean, 'f', 0, 64),
+		strconv.FormatInt(stats.P99, 10),
+		strconv.FormatInt(stats.Max, 10),
+		strconv.FormatInt(stats.WireBytes, 10),
+	})
+	table.Render()
+
+	if len(stats.Largest) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	table = newTable(w, []string{"Largest Blocks", "Bytes", "Wire (bytes)"})
+
+	// Colored cells are not detected as numbers, so
+	// numeric columns are explicitly aligned right.
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_DEFAULT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+	for _, payload := range stats.Largest {
+		row := []string{
+			describeBlock(payload.Block),
+			strconv.FormatInt(payload.Bytes, 10),
+			strconv.FormatInt(payload.WireBytes, 10),
+		}
+
+		if stats.MaxBlockPayloadBytes > 0 && payload.Bytes > stats.MaxBlockPayloadBytes {
+			appendRow(table, row, []tablewriter.Colors{{}, {tablewriter.FgRedColor}, {}})
+			continue
+		}
+
+		table.Append(row)
+	}
+	table.Render()
+}
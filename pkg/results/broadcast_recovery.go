@@ -0,0 +1,77 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/fatih/color"
+)
+
+// BroadcastRecoveryResults contains the outcome of reconciling
+// transactions that were in flight when a previous run of
+// check:construction stopped against the chain.
+type BroadcastRecoveryResults struct {
+	// Confirmed is the number of in-flight transactions
+	// found in a synced block.
+	Confirmed int64 `json:"confirmed"`
+
+	// Pending is the number of in-flight transactions
+	// that are still in the mempool (or that are left
+	// to the usual stale broadcast handling).
+	Pending int64 `json:"pending"`
+
+	// Rebroadcast is the number of in-flight transactions
+	// the node no longer knew about that were rebroadcast.
+	Rebroadcast int64 `json:"rebroadcast"`
+
+	// Released is the number of in-flight transactions that
+	// are definitively gone. The jobs that created them are
+	// marked as failed so that their funds can be used again.
+	Released int64 `json:"released"`
+}
+
+// Print logs BroadcastRecoveryResults to the console.
+func (b *BroadcastRecoveryResults) Print() {
+	b.Fprint(color.Output)
+}
+
+// Fprint writes BroadcastRecoveryResults to w.
+func (b *BroadcastRecoveryResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:construction Recovery", "Description", "Value"})
+	table.Append([]string{
+		"Confirmed",
+		"# of in-flight transactions found on-chain after restart",
+		strconv.FormatInt(b.Confirmed, 10),
+	})
+	table.Append([]string{
+		"Pending",
+		"# of in-flight transactions still awaiting confirmation after restart",
+		strconv.FormatInt(b.Pending, 10),
+	})
+	table.Append([]string{
+		"Rebroadcast",
+		"# of in-flight transactions lost by the node and rebroadcast after restart",
+		strconv.FormatInt(b.Rebroadcast, 10),
+	})
+	table.Append([]string{
+		"Released",
+		"# of in-flight transactions that were gone after restart",
+		strconv.FormatInt(b.Released, 10),
+	})
+
+	table.Render()
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// CacheProbeFinding is a /account/balance response that
+// was staler than a cache-busting request for the same
+// account made before it.
+type CacheProbeFinding struct {
+	Account     *types.AccountIdentifier `json:"account_identifier"`
+	CachedBlock *types.BlockIdentifier   `json:"cached_block_identifier"`
+	FreshBlock  *types.BlockIdentifier   `json:"fresh_block_identifier"`
+
+	// Staleness is the number of blocks the cached
+	// response lagged behind the fresh response.
+	Staleness int64 `json:"staleness"`
+
+	// BalancesDiffer is true if both responses were at the
+	// same block but returned different balances.
+	BalancesDiffer bool `json:"balances_differ"`
+}
+
+// CacheProbeResults contains the outcome of all cache
+// probes performed while running check:data.
+type CacheProbeResults struct {
+	Probes         int64                `json:"probes"`
+	StaleResponses int64                `json:"stale_responses"`
+	MaxStaleness   int64                `json:"max_staleness"`
+	Findings       []*CacheProbeFinding `json:"findings"`
+}
+
+// Print logs CacheProbeResults to the console.
+func (c *CacheProbeResults) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CacheProbeResults to w.
+func (c *CacheProbeResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Cache Probes", "Description", "Value"})
+	table.Append([]string{
+		"Probes",
+		"# of balance requests compared with a cache-busting request",
+		strconv.FormatInt(c.Probes, 10),
+	})
+	table.Append([]string{
+		"Stale Responses",
+		"# of balance responses staler than the cache-busting response",
+		strconv.FormatInt(c.StaleResponses, 10),
+	})
+	table.Append([]string{
+		"Max Staleness",
+		"Most blocks a balance response lagged behind",
+		strconv.FormatInt(c.MaxStaleness, 10),
+	})
+
+	table.Render()
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// CompletionPayload is POSTed to a completion webhook
+// when check:data or check:construction exits. Text is
+// populated so that the payload can be POSTed to a Slack
+// incoming webhook.
+type CompletionPayload struct {
+	Text     string                   `json:"text"`
+	Command  string                   `json:"command"`
+	Summary  string                   `json:"summary"`
+	Network  *types.NetworkIdentifier `json:"network"`
+	Hostname string                   `json:"hostname"`
+	Results  interface{}              `json:"results"`
+}
+
+// NewCompletionPayload returns a new *CompletionPayload
+// for the results (with summary) of command on network.
+func NewCompletionPayload(
+	command string,
+	network *types.NetworkIdentifier,
+	summary string,
+	results interface{},
+) *CompletionPayload {
+	hostname, err := os.Hostname()
+	if err != nil {
+		logging.Warn("unable to get hostname", logging.Fields{"error": err})
+	}
+
+	text := fmt.Sprintf("%s finished: %s", command, summary)
+	if len(hostname) > 0 {
+		text = fmt.Sprintf("%s finished on %s: %s", command, hostname, summary)
+	}
+
+	return &CompletionPayload{
+		Text:     text,
+		Command:  command,
+		Summary:  summary,
+		Network:  network,
+		Hostname: hostname,
+		Results:  results,
+	}
+}
+
+// PostCompletionWebhook POSTs payload (as JSON) to url.
+// Unlike PostResultsWebhook, the request is not retried.
+func PostCompletionWebhook(
+	ctx context.Context,
+	url string,
+	timeout time.Duration,
+	payload *CompletionPayload,
+) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal completion webhook payload", err)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if err := postResultsWebhook(ctx, client, url, "", body); err != nil {
+		return fmt.Errorf("%w: unable to POST to completion webhook", err)
+	}
+
+	return nil
+}
+
+// notifyCompletion POSTs payload to url (if populated). A
+// completion webhook never fails a run, so any error is
+// only logged.
+func notifyCompletion(url string, timeout uint64, payload *CompletionPayload) {
+	if len(url) == 0 {
+		return
+	}
+
+	err := PostCompletionWebhook(
+		context.Background(),
+		url,
+		time.Duration(timeout)*time.Second,
+		payload,
+	)
+	if err != nil {
+		logging.Warn("unable to notify completion webhook", logging.Fields{"error": err})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostCompletionWebhook(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "Bitcoin",
+		Network:    "Mainnet",
+	}
+	results := &CheckConstructionResults{
+		Error: "unable to broadcast",
+		Stats: &CheckConstructionStats{TransactionsConfirmed: 20},
+	}
+	summary := results.Summary()
+	assert.Equal(t, "RESULT=FAIL transactions_confirmed=20 elapsed=0s", summary)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "check:construction", payload["command"])
+		assert.Equal(t, summary, payload["summary"])
+		assert.True(t, strings.HasPrefix(payload["text"].(string), "check:construction finished"))
+		assert.True(t, strings.HasSuffix(payload["text"].(string), summary))
+		assert.Equal(
+			t,
+			"unable to broadcast",
+			payload["results"].(map[string]interface{})["error"],
+		)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	payload := NewCompletionPayload("check:construction", network, summary, results)
+	assert.NoError(t, PostCompletionWebhook(
+		context.Background(),
+		server.URL,
+		time.Second,
+		payload,
+	))
+	assert.Equal(t, 1, attempts)
+
+	// Failed requests are not retried.
+	attempts = 0
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	assert.Error(t, PostCompletionWebhook(
+		context.Background(),
+		failing.URL,
+		time.Second,
+		payload,
+	))
+	assert.Equal(t, 1, attempts)
+
+	// An unreachable webhook is only logged.
+	notifyCompletion(failing.URL, 1, payload)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestPostCompletionWebhookTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	err := PostCompletionWebhook(
+		context.Background(),
+		server.URL,
+		10*time.Millisecond,
+		NewCompletionPayload("check:data", nil, "RESULT=PASS", &CheckDataResults{}),
+	)
+	assert.Error(t, err)
+}
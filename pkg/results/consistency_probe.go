@@ -0,0 +1,98 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// ConsistencyDivergence is a set of concurrent identical
+// requests that did not all return the same response.
+type ConsistencyDivergence struct {
+	Endpoint string                   `json:"endpoint"`
+	Block    *types.BlockIdentifier   `json:"block_identifier"`
+	Account  *types.AccountIdentifier `json:"account_identifier,omitempty"`
+
+	// DistinctResponses is the number of different
+	// responses returned to the concurrent requests.
+	DistinctResponses int `json:"distinct_responses"`
+
+	// Fields are the paths of the fields (ex:
+	// transactions[0].operations[1].amount.value)
+	// that differed from the first response.
+	Fields []string `json:"fields"`
+}
+
+// ConsistencyProbeResults contains the outcome of all
+// consistency probes performed while running check:data.
+type ConsistencyProbeResults struct {
+	Probes      int64                    `json:"probes"`
+	Requests    int64                    `json:"requests"`
+	Divergences int64                    `json:"divergences"`
+	Findings    []*ConsistencyDivergence `json:"findings"`
+}
+
+// Print logs ConsistencyProbeResults to the console.
+func (c *ConsistencyProbeResults) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes ConsistencyProbeResults to w.
+func (c *ConsistencyProbeResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Consistency Probes", "Description", "Value"})
+	table.Append([]string{
+		"Probes",
+		"# of sets of concurrent identical requests compared",
+		strconv.FormatInt(c.Probes, 10),
+	})
+	table.Append([]string{
+		"Requests",
+		"# of requests made by consistency probes",
+		strconv.FormatInt(c.Requests, 10),
+	})
+	table.Append([]string{
+		"Divergences",
+		"# of sets of concurrent identical requests with different responses",
+		strconv.FormatInt(c.Divergences, 10),
+	})
+	table.Render()
+
+	if len(c.Findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	table = newTable(w, []string{"Consistency Divergences", "Block", "Account", "Differing Fields"})
+	for _, finding := range c.Findings {
+		account := ""
+		if finding.Account != nil {
+			account = finding.Account.Address
+		}
+
+		table.Append([]string{
+			finding.Endpoint,
+			fmt.Sprintf("%d (%s)", finding.Block.Index, finding.Block.Hash),
+			account,
+			strings.Join(finding.Fields, "\n"),
+		})
+	}
+	table.Render()
+}
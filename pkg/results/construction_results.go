@@ -17,17 +17,18 @@ package results
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"io"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
 
 	"github.com/coinbase/rosetta-sdk-go/storage"
 	"github.com/coinbase/rosetta-sdk-go/types"
-	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
 )
 
 // CheckConstructionResults contains any error that
@@ -38,46 +39,158 @@ type CheckConstructionResults struct {
 	EndConditions map[string]int          `json:"end_conditions"`
 	Stats         *CheckConstructionStats `json:"stats"`
 	// TODO: add test output (like check data)
+
+	// MempoolVisibility is omitted if the Rosetta
+	// implementation does not support /mempool.
+	MempoolVisibility *MempoolVisibilityResults `json:"mempool_visibility,omitempty"`
+
+	// Recovery is omitted if no transactions were in
+	// flight when check:construction was started.
+	Recovery *BroadcastRecoveryResults `json:"recovery,omitempty"`
+
+	// Network is the network the run checked. It is
+	// omitted in results written by older versions.
+	Network *types.NetworkIdentifier `json:"network,omitempty"`
+
+	*RunTiming
 }
 
 // Print logs CheckConstructionResults to the console.
 func (c *CheckConstructionResults) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckConstructionResults to w.
+func (c *CheckConstructionResults) Fprint(w io.Writer) {
 	if len(c.Error) > 0 {
-		fmt.Printf("\n")
-		color.Red("Error: %s", c.Error)
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgRed, "Error: %s", c.Error)
 	} else {
-		fmt.Printf("\n")
-		color.Green("Success: %s", types.PrintStruct(c.EndConditions))
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgGreen, "Success: %s", types.PrintStruct(c.EndConditions))
+	}
+
+	fmt.Fprintf(w, "\n")
+	if c.Stats != nil {
+		c.Stats.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+
+	if c.MempoolVisibility != nil {
+		c.MempoolVisibility.Fprint(w)
+		fmt.Fprintf(w, "\n")
 	}
 
-	fmt.Printf("\n")
-	c.Stats.Print()
-	fmt.Printf("\n")
+	if c.Recovery != nil {
+		c.Recovery.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+
+	if c.RunTiming != nil {
+		c.RunTiming.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// Summary returns *CheckConstructionResults as a single
+// line of space-separated key=value pairs (like
+// CheckDataResults.Summary), for example:
+//
+//	RESULT=PASS transactions_confirmed=20 elapsed=12m3s
+func (c *CheckConstructionResults) Summary() string {
+	fields := []string{"RESULT=PASS"}
+	if len(c.Error) > 0 {
+		fields = []string{"RESULT=FAIL"}
+	}
+
+	stats := c.Stats
+	if stats == nil {
+		stats = &CheckConstructionStats{}
+	}
+
+	var elapsed float64
+	if c.RunTiming != nil {
+		elapsed = c.RunTiming.Duration
+	}
+
+	fields = append(
+		fields,
+		fmt.Sprintf("transactions_confirmed=%d", stats.TransactionsConfirmed),
+		"elapsed="+strings.Replace(HumanizeSeconds(elapsed), " ", "", -1),
+	)
+
+	return strings.Join(fields, " ")
 }
 
 // Output writes CheckConstructionResults to the provided
-// path.
-func (c *CheckConstructionResults) Output(path string) {
-	if len(path) > 0 {
-		writeErr := utils.SerializeAndWrite(path, c)
-		if writeErr != nil {
-			log.Printf("%s: unable to save results\n", writeErr.Error())
-		}
+// path in the provided format. If the format is ndjson
+// and no path is provided, results are written to stdout.
+// Otherwise, nothing is written if no path is provided.
+func (c *CheckConstructionResults) Output(
+	path string,
+	format configuration.ResultsOutputFormat,
+) error {
+	if len(path) == 0 && !writesToStdout(path, format) {
+		return nil
 	}
+
+	return writeResults(context.Background(), path, format, c, c.JUnit)
+}
+
+// JUnit returns CheckConstructionResults as a *JUnitTestSuite
+// with a test case for each satisfied end condition. If
+// check:construction exited with an error, the suite only
+// contains a single failed test case.
+func (c *CheckConstructionResults) JUnit() *JUnitTestSuite {
+	suiteName := "check:construction"
+	passed := len(c.Error) == 0
+	failure := newJUnitFailure([]string{fmt.Sprintf("Error: %s", c.Error)})
+	if !passed || len(c.EndConditions) == 0 {
+		return newJUnitTestSuite(suiteName, []*JUnitTestCase{
+			newJUnitTestCase(suiteName, suiteName, testStatus(&passed, false), failure),
+		})
+	}
+
+	workflows := make([]string, 0, len(c.EndConditions))
+	for workflow := range c.EndConditions {
+		workflows = append(workflows, workflow)
+	}
+	sort.Strings(workflows)
+
+	testCases := make([]*JUnitTestCase, len(workflows))
+	for i, workflow := range workflows {
+		testCases[i] = newJUnitTestCase(
+			suiteName,
+			fmt.Sprintf("%s (%d)", workflow, c.EndConditions[workflow]),
+			testStatus(&passed, false),
+			failure,
+		)
+	}
+
+	return newJUnitTestSuite(suiteName, testCases)
 }
 
 // ComputeCheckConstructionResults returns a populated
-// CheckConstructionResults.
+// CheckConstructionResults for a run that started at
+// startedAt and ended at endedAt.
 func ComputeCheckConstructionResults(
 	cfg *configuration.Configuration,
 	err error,
 	counterStorage *storage.CounterStorage,
 	jobStorage *storage.JobStorage,
+	mempoolVisibility *MempoolVisibilityResults,
+	recovery *BroadcastRecoveryResults,
+	startedAt time.Time,
+	endedAt time.Time,
 ) *CheckConstructionResults {
 	ctx := context.Background()
 	stats := ComputeCheckConstructionStats(ctx, cfg, counterStorage, jobStorage)
 	results := &CheckConstructionResults{
-		Stats: stats,
+		Stats:             stats,
+		MempoolVisibility: mempoolVisibility,
+		Recovery:          recovery,
+		Network:           cfg.Network,
+		RunTiming:         NewRunTiming(startedAt, endedAt),
 	}
 
 	if err != nil {
@@ -102,15 +215,17 @@ type CheckConstructionStats struct {
 	FailedBroadcasts      int64 `json:"failed_broadcasts"`
 	AddressesCreated      int64 `json:"addresses_created"`
 
+	// HashMismatches is the number of broadcast transactions
+	// found on-chain under a different hash than the one
+	// returned by /construction/hash.
+	HashMismatches int64 `json:"hash_mismatches,omitempty"`
+
 	WorkflowsCompleted map[string]int64 `json:"workflows_completed"`
 }
 
-// PrintCounts logs counter-related stats to the console.
-func (c *CheckConstructionStats) PrintCounts() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetRowLine(true)
-	table.SetRowSeparator("-")
-	table.SetHeader([]string{"check:construction Stats", "Description", "Value"})
+// FprintCounts writes counter-related stats to w.
+func (c *CheckConstructionStats) FprintCounts(w io.Writer) {
+	table := newTable(w, []string{"check:construction Stats", "Description", "Value"})
 	table.Append([]string{
 		"Addresses Created",
 		"# of addresses created",
@@ -136,30 +251,46 @@ func (c *CheckConstructionStats) PrintCounts() {
 		"# of transactions that exceeded broadcast limit",
 		strconv.FormatInt(c.FailedBroadcasts, 10),
 	})
+	if c.HashMismatches > 0 {
+		table.Append([]string{
+			"Hash Mismatches",
+			"# of transactions found on-chain under a different hash than /construction/hash",
+			strconv.FormatInt(c.HashMismatches, 10),
+		})
+	}
 
 	table.Render()
 }
 
-// PrintWorkflows logs workflow counts to the console.
-func (c *CheckConstructionStats) PrintWorkflows() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetRowLine(true)
-	table.SetRowSeparator("-")
-	table.SetHeader([]string{"check:construction Workflows", "Count"})
-	for workflow, count := range c.WorkflowsCompleted {
+// FprintWorkflows writes workflow counts (sorted
+// by workflow) to w.
+func (c *CheckConstructionStats) FprintWorkflows(w io.Writer) {
+	workflows := make([]string, 0, len(c.WorkflowsCompleted))
+	for workflow := range c.WorkflowsCompleted {
+		workflows = append(workflows, workflow)
+	}
+	sort.Strings(workflows)
+
+	table := newTable(w, []string{"check:construction Workflows", "Count"})
+	for _, workflow := range workflows {
 		table.Append([]string{
 			workflow,
-			strconv.FormatInt(count, 10),
+			strconv.FormatInt(c.WorkflowsCompleted[workflow], 10),
 		})
 	}
 
 	table.Render()
 }
 
-// Print calls PrintCounts and PrintWorkflows.
+// Print logs CheckConstructionStats to the console.
 func (c *CheckConstructionStats) Print() {
-	c.PrintCounts()
-	c.PrintWorkflows()
+	c.Fprint(color.Output)
+}
+
+// Fprint calls FprintCounts and FprintWorkflows.
+func (c *CheckConstructionStats) Fprint(w io.Writer) {
+	c.FprintCounts(w)
+	c.FprintWorkflows(w)
 }
 
 // ComputeCheckConstructionStats returns a populated
@@ -176,31 +307,37 @@ func ComputeCheckConstructionStats(
 
 	transactionsCreated, err := counters.Get(ctx, storage.TransactionsCreatedCounter)
 	if err != nil {
-		log.Printf("%s cannot get transactions created counter\n", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "transactions created", "error": err})
 		return nil
 	}
 
 	transactionsConfirmed, err := counters.Get(ctx, storage.TransactionsConfirmedCounter)
 	if err != nil {
-		log.Printf("%s cannot get transactions confirmed counter\n", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "transactions confirmed", "error": err})
 		return nil
 	}
 
 	staleBroadcasts, err := counters.Get(ctx, storage.StaleBroadcastsCounter)
 	if err != nil {
-		log.Printf("%s cannot get stale broadcasts counter\n", err)
+		logging.Warn("cannot get counter", logging.Fields{"counter": "stale broadcasts", "error": err})
 		return nil
 	}
 
 	failedBroadcasts, err := counters.Get(ctx, storage.FailedBroadcastsCounter)
 	if err != nil {
-		log.Printf("%s cannot get failed broadcasts counter\n", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "failed broadcasts", "error": err})
 		return nil
 	}
 
 	addressesCreated, err := counters.Get(ctx, storage.AddressesCreatedCounter)
 	if err != nil {
-		log.Printf("%s cannot get addresses created counter\n", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "addresses created", "error": err})
+		return nil
+	}
+
+	hashMismatches, err := counters.Get(ctx, HashMismatchCounter)
+	if err != nil {
+		logging.Warn("cannot get counter", logging.Fields{"counter": "hash mismatches", "error": err})
 		return nil
 	}
 
@@ -208,7 +345,10 @@ func ComputeCheckConstructionStats(
 	for _, workflow := range config.Construction.Workflows {
 		completed, err := jobs.Completed(ctx, workflow.Name)
 		if err != nil {
-			log.Printf("%s cannot get completed count for %s\n", err.Error(), workflow.Name)
+			logging.Warn(
+				"cannot get completed count",
+				logging.Fields{"workflow": workflow.Name, "error": err},
+			)
 			return nil
 		}
 
@@ -221,6 +361,7 @@ func ComputeCheckConstructionStats(
 		StaleBroadcasts:       staleBroadcasts.Int64(),
 		FailedBroadcasts:      failedBroadcasts.Int64(),
 		AddressesCreated:      addressesCreated.Int64(),
+		HashMismatches:        hashMismatches.Int64(),
 		WorkflowsCompleted:    workflowsCompleted,
 	}
 }
@@ -242,13 +383,13 @@ func ComputeCheckConstructionProgress(
 ) *CheckConstructionProgress {
 	inflight, err := broadcasts.GetAllBroadcasts(ctx)
 	if err != nil {
-		log.Printf("%s cannot get all broadcasts\n", err.Error())
+		logging.Warn("cannot get all broadcasts", logging.Fields{"error": err})
 		return nil
 	}
 
 	processing, err := jobs.AllProcessing(ctx)
 	if err != nil {
-		log.Printf("%s cannot get all jobs\n", err.Error())
+		logging.Warn("cannot get all jobs", logging.Fields{"error": err})
 		return nil
 	}
 
@@ -279,33 +420,68 @@ func ComputeCheckConstructionStatus(
 	}
 }
 
-// FetchCheckConstructionStatus fetches *CheckConstructionStatus.
-func FetchCheckConstructionStatus(url string) (*CheckConstructionStatus, error) {
+// FetchCheckConstructionStatus fetches *CheckConstructionStatus
+// (opts can be nil).
+func FetchCheckConstructionStatus(url string, opts *JSONFetchOptions) (*CheckConstructionStatus, error) {
 	var status CheckConstructionStatus
-	if err := JSONFetch(url, &status); err != nil {
+	if err := JSONFetch(url, &status, opts); err != nil {
 		return nil, fmt.Errorf("%w: unable to fetch construction status", err)
 	}
 
 	return &status, nil
 }
 
-// ExitConstruction exits check:data, logs the test results to the console,
-// and to a provided output path.
+// ExitConstruction exits check:data, logs the test results (and the
+// time elapsed since startedAt) to the console, and to a provided
+// output path.
 func ExitConstruction(
 	config *configuration.Configuration,
 	counterStorage *storage.CounterStorage,
 	jobStorage *storage.JobStorage,
+	mempoolVisibility *MempoolVisibilityResults,
+	recovery *BroadcastRecoveryResults,
 	err error,
+	startedAt time.Time,
 ) error {
 	results := ComputeCheckConstructionResults(
 		config,
 		err,
 		counterStorage,
 		jobStorage,
+		mempoolVisibility,
+		recovery,
+		startedAt,
+		time.Now(),
 	)
 	if results != nil {
-		results.Print()
-		results.Output(config.Construction.ResultsOutputFile)
+		// The results table is not printed when results
+		// are written to stdout so that stdout can be parsed.
+		if !writesToStdout(
+			config.Construction.ResultsOutputFile,
+			config.Construction.ResultsOutputFormat,
+		) {
+			results.Print()
+		}
+
+		outputErr := results.Output(
+			config.Construction.ResultsOutputFile,
+			config.Construction.ResultsOutputFormat,
+		)
+		if outputErr != nil {
+			logging.Error("unable to save results", logging.Fields{"error": outputErr})
+
+			// If the run otherwise succeeded, failing to
+			// save the results fails the run.
+			if err == nil {
+				err = fmt.Errorf("%w: unable to save results", outputErr)
+			}
+		}
+
+		notifyCompletion(
+			config.Construction.CompletionWebhook,
+			config.Construction.CompletionWebhookTimeout,
+			NewCompletionPayload("check:construction", config.Network, results.Summary(), results),
+		)
 	}
 
 	return err
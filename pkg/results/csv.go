@@ -0,0 +1,298 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// csvColumn is a column of the CSV export of
+// *CheckDataResults.
+type csvColumn struct {
+	name  string
+	value func(c *CheckDataResults, tests *CheckDataTests, stats *CheckDataStats) string
+}
+
+// csvTestColumn returns a column with the outcome of
+// a test (NOT TESTED if tests were not computed).
+func csvTestColumn(name string, outcome func(t *CheckDataTests) *bool) *csvColumn {
+	return &csvColumn{name, func(_ *CheckDataResults, t *CheckDataTests, _ *CheckDataStats) string {
+		if t == nil {
+			return convertBool(nil)
+		}
+
+		return string(t.Status(name, outcome(t)))
+	}}
+}
+
+func formatCSVInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// csvColumns are the columns of the CSV export in the order
+// they are written. Spreadsheets that collect the exports of
+// many runs rely on this order, so columns must only ever be
+// appended to the end (TestCSVHeader enforces this).
+var csvColumns = []*csvColumn{
+	{"run_id", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.RunTiming == nil {
+			return ""
+		}
+
+		return c.RunTiming.StartedAt
+	}},
+	{"blockchain", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.Network == nil {
+			return ""
+		}
+
+		return c.Network.Blockchain
+	}},
+	{"network", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.Network == nil {
+			return ""
+		}
+
+		return c.Network.Network
+	}},
+	csvTestColumn("request_response", func(t *CheckDataTests) *bool { return &t.RequestResponse }),
+	csvTestColumn("response_assertion", func(t *CheckDataTests) *bool { return &t.ResponseAssertion }),
+	csvTestColumn("block_syncing", func(t *CheckDataTests) *bool { return t.BlockSyncing }),
+	csvTestColumn("balance_tracking", func(t *CheckDataTests) *bool { return t.BalanceTracking }),
+	csvTestColumn("coin_tracking", func(t *CheckDataTests) *bool { return t.CoinTracking }),
+	csvTestColumn("reconciliation", func(t *CheckDataTests) *bool { return t.Reconciliation }),
+	csvTestColumn("negative_request", func(t *CheckDataTests) *bool { return t.NegativeRequest }),
+	{"blocks", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.Blocks)
+	}},
+	{"orphans", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.Orphans)
+	}},
+	{"transactions", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.Transactions)
+	}},
+	{"operations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.Operations)
+	}},
+	{"active_reconciliations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ActiveReconciliations)
+	}},
+	{"inactive_reconciliations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.InactiveReconciliations)
+	}},
+	{"reconciliation_failures", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationFailures)
+	}},
+	{"reconciliation_coverage", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVFloat(s.ReconciliationCoverage)
+	}},
+	{"deferred_reconciliations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.DeferredReconciliations)
+	}},
+	{"orphaned_transactions", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.OrphanedTransactions)
+	}},
+	{"orphaned_operations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.OrphanedOperations)
+	}},
+	{"orphan_rate", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVFloat(s.OrphanRate)
+	}},
+	{"storage_size_bytes", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.StorageSizeBytes)
+	}},
+	{"peak_memory_bytes", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.PeakMemoryBytes)
+	}},
+	{"endpoint_disagreements", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		if s.EndpointDisagreements == nil {
+			return ""
+		}
+
+		return formatCSVInt(*s.EndpointDisagreements)
+	}},
+	{"storage_data_size_bytes", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		if s.StorageDataSizeBytes == nil {
+			return ""
+		}
+
+		return formatCSVInt(*s.StorageDataSizeBytes)
+	}},
+	{"bloom_filter_hit_rate", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		if s.BloomFilterHitRate == nil {
+			return ""
+		}
+
+		return formatCSVFloat(*s.BloomFilterHitRate)
+	}},
+	{"end_condition", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.EndCondition == nil {
+			return ""
+		}
+
+		return string(c.EndCondition.Type)
+	}},
+	{"end_condition_detail", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.EndCondition == nil {
+			return ""
+		}
+
+		return c.EndCondition.Detail
+	}},
+	{"error_class", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		return c.ErrorClass()
+	}},
+	{"duration", func(c *CheckDataResults, _ *CheckDataTests, _ *CheckDataStats) string {
+		if c.RunTiming == nil {
+			return ""
+		}
+
+		return formatCSVFloat(c.RunTiming.Duration)
+	}},
+	{"reversed_coins", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReversedCoins)
+	}},
+	{"recent_reconciliation_coverage", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		if s.RecentReconciliationCoverage == nil {
+			return ""
+		}
+
+		return formatCSVFloat(*s.RecentReconciliationCoverage)
+	}},
+	{"accounts_tracked", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.AccountsTracked)
+	}},
+	{"currencies_seen", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.CurrenciesSeen)
+	}},
+	{"reconciliations_enqueued", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationsEnqueued)
+	}},
+	{"reconciliations_processed", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationsProcessed)
+	}},
+	{"reconciliations_skipped_backlog", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationsSkippedBacklog)
+	}},
+	{"reconciliations_skipped_pruned", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationsSkippedPruned)
+	}},
+	csvTestColumn("historical_balance_tracking", func(t *CheckDataTests) *bool {
+		return t.HistoricalBalanceTracking
+	}),
+	{"fetch_retries", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.FetchRetries)
+	}},
+	{"fetch_failures", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.FetchFailures)
+	}},
+	{"reconciliations_superseded", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.ReconciliationsSuperseded)
+	}},
+	{"transfer_symmetry_checked", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.TransferSymmetryChecked)
+	}},
+	{"transfer_symmetry_violations", func(_ *CheckDataResults, _ *CheckDataTests, s *CheckDataStats) string {
+		return formatCSVInt(s.TransferSymmetryViolations)
+	}},
+	csvTestColumn("transfer_symmetry", func(t *CheckDataTests) *bool { return t.TransferSymmetry }),
+	csvTestColumn("block_payload_size", func(t *CheckDataTests) *bool { return t.BlockPayloadSize }),
+}
+
+// ErrorClass returns the JSON name of the earliest test
+// that failed in the run (ex: reconciliation), "error" if
+// the run failed without failing a test, or "" if the run
+// did not fail.
+func (c *CheckDataResults) ErrorClass() string {
+	if name, _ := failedTest(c.Tests); len(name) > 0 {
+		return name
+	}
+
+	if len(c.Error) > 0 {
+		return "error"
+	}
+
+	return ""
+}
+
+// CSVHeader returns the header of the CSV export
+// of *CheckDataResults.
+func CSVHeader() []string {
+	header := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		header[i] = column.name
+	}
+
+	return header
+}
+
+// CSV returns *CheckDataResults as a CSV with a
+// header and a single row. Tests that were not
+// computed are reported as NOT TESTED and stats
+// that were not computed are reported as 0.
+func (c *CheckDataResults) CSV() ([]byte, error) {
+	stats := c.Stats
+	if stats == nil {
+		stats = &CheckDataStats{}
+	}
+
+	row := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		row[i] = column.value(c, c.Tests, stats)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll([][]string{CSVHeader(), row}); err != nil {
+		return nil, fmt.Errorf("%w: unable to encode CSV", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// OutputCSV writes *CheckDataResults as a CSV (see CSV)
+// to path. Nothing is written if no path is provided.
+func (c *CheckDataResults) OutputCSV(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+
+	output, err := c.CSV()
+	if err != nil {
+		return err
+	}
+
+	if err := utils.EnsurePathExists(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("%w: unable to create results directory", err)
+	}
+
+	if err := ioutil.WriteFile(path, output, os.FileMode(utils.DefaultFilePermissions)); err != nil {
+		return fmt.Errorf("%w: unable to write to file path %s", err, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCSVHeader ensures columns are only ever appended to
+// the CSV header (so that the rows written by different
+// versions can be combined in one spreadsheet). When adding
+// a column, append it to the golden header.
+func TestCSVHeader(t *testing.T) {
+	goldenPath := path.Join("testdata", "check_data_results_header.csv.golden")
+	golden, err := ioutil.ReadFile(goldenPath)
+	assert.NoError(t, err)
+
+	goldenHeader := strings.Split(strings.TrimSpace(string(golden)), ",")
+	header := CSVHeader()
+	assert.True(t, len(header) >= len(goldenHeader))
+	assert.Equal(t, goldenHeader, header[:len(goldenHeader)])
+}
+
+func TestOutputCSV(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	tested := true
+	failed := false
+	disagreements := int64(3)
+	results := &CheckDataResults{
+		Error: "reconciliation failed",
+		EndCondition: &EndCondition{
+			Type:   configuration.TipEndCondition,
+			Detail: "Tip: 100",
+		},
+		Tests: &CheckDataTests{
+			RequestResponse:   true,
+			ResponseAssertion: true,
+			BlockSyncing:      &tested,
+			Reconciliation:    &failed,
+		},
+		Stats: &CheckDataStats{
+			Blocks:                 100,
+			Orphans:                2,
+			ReconciliationCoverage: 0.5,
+			EndpointDisagreements:  &disagreements,
+		},
+		Network: &types.NetworkIdentifier{
+			Blockchain: "Bitcoin",
+			Network:    "Mainnet",
+		},
+		RunTiming: &RunTiming{
+			StartedAt: "2020-10-16T12:00:00Z",
+			EndedAt:   "2020-10-16T13:00:00Z",
+			Duration:  3600,
+		},
+	}
+
+	// Nothing is written when no path is provided.
+	assert.NoError(t, results.OutputCSV(""))
+
+	csvPath := path.Join(dir, "nested", "results.csv")
+	assert.NoError(t, results.OutputCSV(csvPath))
+
+	output, err := ioutil.ReadFile(csvPath)
+	assert.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, CSVHeader(), records[0])
+
+	row := map[string]string{}
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+
+	assert.Equal(t, "2020-10-16T12:00:00Z", row["run_id"])
+	assert.Equal(t, "Bitcoin", row["blockchain"])
+	assert.Equal(t, "Mainnet", row["network"])
+	assert.Equal(t, "PASSED", row["request_response"])
+	assert.Equal(t, "PASSED", row["block_syncing"])
+	assert.Equal(t, "NOT TESTED", row["balance_tracking"])
+	assert.Equal(t, "FAILED", row["reconciliation"])
+	assert.Equal(t, "100", row["blocks"])
+	assert.Equal(t, "2", row["orphans"])
+	assert.Equal(t, "0.5", row["reconciliation_coverage"])
+	assert.Equal(t, "3", row["endpoint_disagreements"])
+	assert.Equal(t, "", row["storage_data_size_bytes"])
+	assert.Equal(t, string(configuration.TipEndCondition), row["end_condition"])
+	assert.Equal(t, "Tip: 100", row["end_condition_detail"])
+	assert.Equal(t, "reconciliation", row["error_class"])
+	assert.Equal(t, "3600", row["duration"])
+
+	// Tests that were not computed are not tested.
+	output, err = (&CheckDataResults{Error: "unable to sync"}).CSV()
+	assert.NoError(t, err)
+	records, err = csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT TESTED", records[1][3])
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	assert.Equal(t, "error", row["error_class"])
+	assert.Equal(t, "", row["recent_reconciliation_coverage"])
+}
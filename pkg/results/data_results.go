@@ -18,12 +18,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"math/big"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
@@ -32,7 +37,6 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
 )
 
 // EndCondition contains the type of
@@ -47,44 +51,479 @@ type EndCondition struct {
 // on a check:data run, the outcome of certain tests,
 // and a collection of interesting stats.
 type CheckDataResults struct {
-	Error        string          `json:"error"`
-	EndCondition *EndCondition   `json:"end_condition"`
-	Tests        *CheckDataTests `json:"tests"`
-	Stats        *CheckDataStats `json:"stats"`
+	Error             string             `json:"error"`
+	EndCondition      *EndCondition      `json:"end_condition"`
+	Tests             *CheckDataTests    `json:"tests"`
+	Stats             *CheckDataStats    `json:"stats"`
+	AssertionFindings *AssertionFindings `json:"assertion_findings,omitempty"`
+
+	NegativeRequests []*NegativeRequestResult `json:"negative_requests,omitempty"`
+	CacheProbe       *CacheProbeResults       `json:"cache_probe,omitempty"`
+	ConsistencyProbe *ConsistencyProbeResults `json:"consistency_probe,omitempty"`
+	EndpointParity   *EndpointParityResults   `json:"endpoint_parity,omitempty"`
+	StorageStats     *StorageStats            `json:"storage_stats,omitempty"`
+	AccountTags      TagReconciliationResults `json:"account_tags,omitempty"`
+
+	// HistoricalBalances are the historical balance checks
+	// of the run. They are only populated if historical
+	// balance checks are enabled (and supported).
+	HistoricalBalances *HistoricalBalanceResults `json:"historical_balances,omitempty"`
+
+	// ReconciliationFailures are the first reconciliation
+	// failures of the run (up to reconciliation_failure_limit).
+	ReconciliationFailures []*ReconciliationFailure `json:"reconciliation_failures,omitempty"`
+
+	// TransferAsymmetries are the first unbalanced transactions
+	// of the run (up to TransferAsymmetryLimit). They are only
+	// populated if transfer symmetry is checked.
+	TransferAsymmetries []*TransferAsymmetry `json:"transfer_asymmetries,omitempty"`
+
+	// ErrorPolicy is the error policy in effect and the
+	// errors it continued once check:data reached tip.
+	ErrorPolicy *ErrorPolicyResults `json:"error_policy,omitempty"`
+
+	// EventSinks are the delivery counts of each
+	// configured event sink.
+	EventSinks []*EventSinkResults `json:"event_sinks,omitempty"`
+
+	// Recommendations are the configuration changes
+	// suggested for the next run by the stats of this run.
+	Recommendations []*Recommendation `json:"recommendations,omitempty"`
+
+	// Configuration is a sanitized copy of the configuration
+	// used for the run (only populated if include_configuration
+	// is enabled).
+	Configuration *configuration.Configuration `json:"configuration,omitempty"`
+
+	// Partial is true if the results were written during
+	// the run (instead of at the end of the run) and
+	// SnapshotAt is when they were computed. Progress is
+	// only populated in partial results.
+	Partial    bool               `json:"partial,omitempty"`
+	SnapshotAt string             `json:"snapshot_at,omitempty"`
+	Progress   *CheckDataProgress `json:"progress,omitempty"`
+
+	// Network is the network the run checked. It is
+	// omitted in results written by older versions.
+	Network *types.NetworkIdentifier `json:"network,omitempty"`
+
+	// InactiveReconciliationSeed is the seed used to order the
+	// accounts sampled by inactive reconciliation (if one was
+	// configured). It is omitted if the accounts were not shuffled.
+	InactiveReconciliationSeed int64 `json:"inactive_reconciliation_seed,omitempty"`
+
+	*RunTiming
 }
 
 // Print logs CheckDataResults to the console.
 func (c *CheckDataResults) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataResults to w.
+func (c *CheckDataResults) Fprint(w io.Writer) {
 	if len(c.Error) > 0 {
-		fmt.Printf("\n")
-		color.Red("Error: %s", c.Error)
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgRed, "Error: %s", c.Error)
 	}
 
-	if c.EndCondition != nil {
-		fmt.Printf("\n")
-		color.Green("Success: %s [%s]", c.EndCondition.Type, c.EndCondition.Detail)
+	switch {
+	case c.EndCondition != nil && c.EndCondition.Type == configuration.InterruptedEndCondition:
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgYellow, "Interrupted: %s", c.EndCondition.Detail)
+	case c.EndCondition != nil &&
+		c.EndCondition.Type == configuration.ReconciliationFailuresEndCondition:
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgRed, "Failed: %s [%s]", c.EndCondition.Type, c.EndCondition.Detail)
+	case c.EndCondition != nil:
+		fmt.Fprintf(w, "\n")
+		fprintColor(w, color.FgGreen, "Success: %s [%s]", c.EndCondition.Type, c.EndCondition.Detail)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 	if c.Tests != nil {
-		c.Tests.Print()
-		fmt.Printf("\n")
+		c.Tests.Fprint(w)
+		fmt.Fprintf(w, "\n")
 	}
 	if c.Stats != nil {
-		c.Stats.Print()
-		fmt.Printf("\n")
+		c.Stats.Fprint(w)
+		fmt.Fprintf(w, "\n")
+
+		for _, warning := range c.Stats.Warnings {
+			fprintColor(w, color.FgYellow, "Warning: %s\n", warning)
+		}
+
+		if c.Stats.TrustedCheckpoint != nil {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: blocks at or below the trusted checkpoint (index %d) were not asserted. Response Assertion only covers blocks after the checkpoint.\n", // nolint:lll
+				c.Stats.TrustedCheckpoint.Index,
+			)
+		}
+
+		if c.Stats.HighOrphanRate() {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: %.2f blocks per 1000 were orphaned in the last %d blocks (more than orphan_rate_threshold %.2f). This may indicate a reorg handling bug.\n", // nolint:lll
+				c.Stats.OrphanRate,
+				c.Stats.OrphanRateWindow,
+				c.Stats.OrphanRateThreshold,
+			)
+		}
+
+		if c.Stats.HighReconciliationSkipRatio() {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: %.2f%% of balance changes were never reconciled (%d dropped from a full backlog, %d skipped at pruned blocks), more than reconciliation_skip_ratio_threshold %.2f%%. Reconciliation coverage may overstate how much was checked.\n", // nolint:lll
+				c.Stats.ReconciliationSkipRatio()*utils.OneHundred,
+				c.Stats.ReconciliationsSkippedBacklog,
+				c.Stats.ReconciliationsSkippedPruned,
+				c.Stats.ReconciliationSkipRatioThreshold*utils.OneHundred,
+			)
+		}
+
+		if c.Stats.TransferSymmetryViolations > 0 {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: the debits and credits of %d transactions were not balanced (see transfer_asymmetries in the results). This may indicate an operation missing its counterpart.\n", // nolint:lll
+				c.Stats.TransferSymmetryViolations,
+			)
+		}
+
+		if c.Stats.FrequentlyDeferred() {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: %d reconciliations were deferred because accounts changed before they could be reconciled. Enable historical balance lookup (if supported) to reconcile frequently updated accounts.\n", // nolint:lll
+				c.Stats.DeferredReconciliations,
+			)
+		}
+
+		if payloads := c.Stats.BlockPayloads; payloads != nil && payloads.ViolationCount > 0 {
+			fprintColor(
+				w,
+				color.FgYellow,
+				"Warning: %d /block responses were larger than max_block_payload_bytes (%d bytes). The largest was %d bytes.\n", // nolint:lll
+				payloads.ViolationCount,
+				payloads.MaxBlockPayloadBytes,
+				payloads.Max,
+			)
+		}
+	}
+	if len(c.ReconciliationFailures) > 0 {
+		total := int64(len(c.ReconciliationFailures))
+		if c.Stats != nil && c.Stats.ReconciliationFailures > total {
+			total = c.Stats.ReconciliationFailures
+		}
+
+		FprintReconciliationFailures(w, c.ReconciliationFailures, total)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.TransferAsymmetries) > 0 {
+		total := int64(len(c.TransferAsymmetries))
+		if c.Stats != nil && c.Stats.TransferSymmetryViolations > total {
+			total = c.Stats.TransferSymmetryViolations
+		}
+
+		FprintTransferAsymmetries(w, c.TransferAsymmetries, total)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ErrorPolicy != nil && len(c.ErrorPolicy.Counts) > 0 {
+		printErrorPolicy(w, c.ErrorPolicy)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.AssertionFindings != nil {
+		c.AssertionFindings.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.CacheProbe != nil {
+		c.CacheProbe.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ConsistencyProbe != nil {
+		c.ConsistencyProbe.Fprint(w)
+		fmt.Fprintf(w, "\n")
+
+		if c.ConsistencyProbe.Divergences > 0 {
+			fprintColor(
+				w,
+				color.FgRed,
+				"Warning: %d sets of concurrent identical requests returned different responses. This indicates a race in the implementation (ex: in its cache layer).\n", // nolint:lll
+				c.ConsistencyProbe.Divergences,
+			)
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	if c.HistoricalBalances != nil {
+		c.HistoricalBalances.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.EndpointParity != nil {
+		c.EndpointParity.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.StorageStats != nil {
+		c.StorageStats.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.AccountTags) > 0 {
+		c.AccountTags.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.EventSinks) > 0 {
+		FprintEventSinks(w, c.EventSinks)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.RunTiming != nil {
+		c.RunTiming.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.Recommendations) > 0 {
+		FprintRecommendations(w, c.Recommendations)
+		fmt.Fprintf(w, "\n")
 	}
 }
 
-// Output writes *CheckDataResults to the provided
-// path.
-func (c *CheckDataResults) Output(path string) {
-	if len(path) > 0 {
-		writeErr := utils.SerializeAndWrite(path, c)
-		if writeErr != nil {
-			log.Printf("%s: unable to save results\n", writeErr.Error())
+// Summary returns *CheckDataResults as a single line
+// of space-separated key=value pairs that is easy to
+// grep, for example:
+//
+//	RESULT=PASS blocks=12345 orphans=2 coverage=0.87 elapsed=3h12m
+//
+// If check:data failed, RESULT=FAIL is followed by
+// failed=<test> (the JSON name of the earliest failed
+// test, or "error" if no test failed). Keys are always
+// in this order, so new keys are only ever appended.
+func (c *CheckDataResults) Summary() string {
+	fields := []string{"RESULT=PASS"}
+	if name, _ := failedTest(c.Tests); len(name) > 0 || len(c.Error) > 0 {
+		if len(name) == 0 {
+			name = "error"
 		}
+
+		fields = []string{"RESULT=FAIL", "failed=" + name}
+	} else if c.EndCondition != nil && c.EndCondition.Type == configuration.InterruptedEndCondition {
+		fields = []string{"RESULT=INTERRUPTED"}
 	}
+
+	stats := c.Stats
+	if stats == nil {
+		stats = &CheckDataStats{}
+	}
+
+	var elapsed float64
+	if c.RunTiming != nil {
+		elapsed = c.RunTiming.Duration
+	}
+
+	fields = append(
+		fields,
+		fmt.Sprintf("blocks=%d", stats.Blocks),
+		fmt.Sprintf("orphans=%d", stats.Orphans),
+		fmt.Sprintf("coverage=%.2f", stats.ReconciliationCoverage),
+		"elapsed="+strings.Replace(HumanizeSeconds(elapsed), " ", "", -1),
+	)
+
+	return strings.Join(fields, " ")
+}
+
+// Output writes *CheckDataResults to the provided
+// path in the provided format. If the format is ndjson
+// and no path is provided, results are written to stdout.
+// Otherwise, nothing is written if no path is provided.
+func (c *CheckDataResults) Output(path string, format configuration.ResultsOutputFormat) error {
+	if len(path) == 0 && !writesToStdout(path, format) {
+		return nil
+	}
+
+	return writeResults(context.Background(), path, format, c, c.JUnit)
+}
+
+// OutputAll writes *CheckDataResults to each of the provided
+// destinations (filepaths or URLs with a ResultsSink) in the
+// provided format. Results are written to every destination
+// even if writing to an earlier destination fails. Saving to
+// a ResultsSink fails if it takes longer than sinkTimeout.
+func (c *CheckDataResults) OutputAll(
+	destinations []string,
+	format configuration.ResultsOutputFormat,
+	sinkTimeout time.Duration,
+) error {
+	return outputAll(
+		destinations,
+		format,
+		func(path string, format configuration.ResultsOutputFormat) error {
+			if len(path) == 0 && !writesToStdout(path, format) {
+				return nil
+			}
+
+			if sinkTimeout <= 0 {
+				sinkTimeout = configuration.DefaultResultsSinkTimeout * time.Second
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+			defer cancel()
+
+			return writeResults(ctx, path, format, c, c.JUnit)
+		},
+	)
+}
+
+const (
+	// partialResultsSuffix replaces the extension of the
+	// results output file to get the partial results path.
+	partialResultsSuffix = ".partial.json"
+
+	// partialResultsTmpSuffix is appended to the partial
+	// results path while partial results are written.
+	partialResultsTmpSuffix = ".tmp"
+)
+
+// PartialResultsPath returns the path partial
+// results are written to when results are
+// written to path (ex: results.partial.json
+// for results.json).
+func PartialResultsPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + partialResultsSuffix
+}
+
+// OutputPartial marks *CheckDataResults as partial results
+// computed at snapshotAt and writes them (as JSON) to the
+// PartialResultsPath of path. Any existing partial results
+// are replaced atomically, so the file is always valid.
+func (c *CheckDataResults) OutputPartial(path string, snapshotAt time.Time) error {
+	c.Partial = true
+	c.SnapshotAt = snapshotAt.UTC().Format(time.RFC3339)
+
+	partialPath := PartialResultsPath(path)
+	tmpPath := partialPath + partialResultsTmpSuffix
+	if err := utils.SerializeAndWrite(tmpPath, c); err != nil {
+		return fmt.Errorf("%w: unable to write partial results", err)
+	}
+
+	if err := os.Rename(tmpPath, partialPath); err != nil {
+		return fmt.Errorf("%w: unable to replace partial results", err)
+	}
+
+	return nil
+}
+
+// removePartialResults removes any partial results
+// written for results written to path.
+func removePartialResults(path string) {
+	err := os.Remove(PartialResultsPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		logging.Warn("unable to remove partial results", logging.Fields{"error": err})
+	}
+}
+
+// JUnit returns *CheckDataResults as a *JUnitTestSuite
+// with a test case for each of CheckDataTests. Any test
+// that failed includes the error and end condition in
+// its failure message.
+func (c *CheckDataResults) JUnit() *JUnitTestSuite {
+	suiteName := "check:data"
+
+	messages := []string{}
+	if len(c.Error) > 0 {
+		messages = append(messages, fmt.Sprintf("Error: %s", c.Error))
+	}
+	if c.EndCondition != nil {
+		messages = append(
+			messages,
+			fmt.Sprintf("End Condition: %s [%s]", c.EndCondition.Type, c.EndCondition.Detail),
+		)
+	}
+	failure := newJUnitFailure(messages)
+
+	// If tests could not be computed, we only report
+	// whether there was an error.
+	if c.Tests == nil {
+		passed := len(c.Error) == 0
+		return newJUnitTestSuite(suiteName, []*JUnitTestCase{
+			newJUnitTestCase(suiteName, suiteName, testStatus(&passed, false), failure),
+		})
+	}
+
+	tests := c.Tests
+	testCases := []*JUnitTestCase{
+		newJUnitTestCase(
+			suiteName,
+			"Request/Response",
+			testStatus(&tests.RequestResponse, false),
+			failure,
+		),
+		newJUnitTestCase(
+			suiteName,
+			"Response Assertion",
+			testStatus(&tests.ResponseAssertion, false),
+			failure,
+		),
+		newJUnitTestCase(suiteName, "Block Syncing", testStatus(tests.BlockSyncing, false), failure),
+		newJUnitTestCase(
+			suiteName,
+			"Balance Tracking",
+			tests.Status(BalanceTrackingTestName, tests.BalanceTracking),
+			failure,
+		),
+		newJUnitTestCase(
+			suiteName,
+			"Coin Tracking",
+			tests.Status(CoinTrackingTestName, tests.CoinTracking),
+			failure,
+		),
+		newJUnitTestCase(
+			suiteName,
+			"Reconciliation",
+			tests.Status(ReconciliationTestName, tests.Reconciliation),
+			failure,
+		),
+		newJUnitTestCase(
+			suiteName,
+			"Negative Request",
+			tests.Status(NegativeRequestTestName, tests.NegativeRequest),
+			failure,
+		),
+	}
+
+	// Historical balance tracking is only
+	// reported when it was tested.
+	if tests.HistoricalBalanceTracking != nil {
+		testCases = append(testCases, newJUnitTestCase(
+			suiteName,
+			"Historical Balance Tracking",
+			testStatus(tests.HistoricalBalanceTracking, false),
+			failure,
+		))
+	}
+
+	// Transfer symmetry is only reported when
+	// the check is fatal.
+	if tests.TransferSymmetry != nil {
+		testCases = append(testCases, newJUnitTestCase(
+			suiteName,
+			"Transfer Symmetry",
+			testStatus(tests.TransferSymmetry, false),
+			failure,
+		))
+	}
+
+	// Block payload size is only reported when
+	// violations fail the run.
+	if tests.BlockPayloadSize != nil {
+		testCases = append(testCases, newJUnitTestCase(
+			suiteName,
+			"Block Payload Size",
+			testStatus(tests.BlockPayloadSize, false),
+			failure,
+		))
+	}
+
+	return newJUnitTestSuite(suiteName, testCases)
 }
 
 // CheckDataStats contains interesting stats that
@@ -96,27 +535,279 @@ type CheckDataStats struct {
 	Operations              int64   `json:"operations"`
 	ActiveReconciliations   int64   `json:"active_reconciliations"`
 	InactiveReconciliations int64   `json:"inactive_reconciliations"`
+	ReconciliationFailures  int64   `json:"reconciliation_failures"`
 	ReconciliationCoverage  float64 `json:"reconciliation_coverage"`
+
+	// RecentReconciliationCoverage is the reconciliation coverage
+	// counting only reconciliations in the last CoverageLookbackBlocks
+	// blocks. It is only populated if coverage_lookback_blocks is
+	// populated.
+	RecentReconciliationCoverage *float64 `json:"recent_reconciliation_coverage,omitempty"`
+	CoverageLookbackBlocks       int64    `json:"coverage_lookback_blocks,omitempty"`
+
+	// AccountsTracked and CurrenciesSeen are the number of
+	// distinct accounts (each AccountIdentifier/Currency pair)
+	// and currencies under balance tracking (the denominator
+	// of the reconciliation coverage).
+	AccountsTracked int64 `json:"accounts_tracked"`
+	CurrenciesSeen  int64 `json:"currencies_seen"`
+
+	// DeferredReconciliations is the number of reconciliations
+	// deferred because the account was updated after the block
+	// of its live balance.
+	DeferredReconciliations int64 `json:"deferred_reconciliations"`
+
+	// ReconciliationsEnqueued is the number of balance changes queued
+	// for active reconciliation and ReconciliationsProcessed is the
+	// number of active reconciliations performed. Balance changes
+	// dropped because the reconciler's backlog was full are counted
+	// in ReconciliationsSkippedBacklog (an estimate) and reconciliations
+	// skipped because their block was pruned are counted in
+	// ReconciliationsSkippedPruned. ReconciliationSkipRatioThreshold
+	// is the skip ratio above which a warning is printed (it is only
+	// populated once balance changes have been queued).
+	ReconciliationsEnqueued          int64   `json:"reconciliations_enqueued"`
+	ReconciliationsProcessed         int64   `json:"reconciliations_processed"`
+	ReconciliationsSkippedBacklog    int64   `json:"reconciliations_skipped_backlog"`
+	ReconciliationsSkippedPruned     int64   `json:"reconciliations_skipped_pruned"`
+	ReconciliationSkipRatioThreshold float64 `json:"reconciliation_skip_ratio_threshold,omitempty"`
+
+	// ReconciliationsSuperseded is the number of balance changes
+	// that were not queued for active reconciliation because the
+	// account was already queued. They are covered by the next
+	// active reconciliation of the account, so they are not
+	// counted as skipped.
+	ReconciliationsSuperseded int64 `json:"reconciliations_superseded"`
+
+	// FetchRetries is the number of requests that repeated a
+	// failed request and FetchFailures is the number of requests
+	// that failed (a transport error or a non-200 response). The
+	// fetcher retries failed requests internally, so a high count
+	// indicates an unstable node even if the run passes.
+	FetchRetries  int64 `json:"fetch_retries"`
+	FetchFailures int64 `json:"fetch_failures"`
+
+	// TransferSymmetryChecked is the number of transactions checked
+	// for balanced debits and credits (if transfer_symmetry is
+	// configured) and TransferSymmetryViolations is the number of
+	// those that were not balanced.
+	TransferSymmetryChecked    int64 `json:"transfer_symmetry_checked,omitempty"`
+	TransferSymmetryViolations int64 `json:"transfer_symmetry_violations,omitempty"`
+
+	// OrphanedTransactions and OrphanedOperations are always
+	// counted. OrphanedOperationsExcluded indicates if they
+	// are excluded from Transactions and Operations.
+	OrphanedTransactions       int64 `json:"orphaned_transactions"`
+	OrphanedOperations         int64 `json:"orphaned_operations"`
+	OrphanedOperationsExcluded bool  `json:"orphaned_operations_excluded"`
+
+	// ReversedCoins is the number of coins created or
+	// spent in orphaned blocks (each of which was verified
+	// to be reversed in the coin index).
+	ReversedCoins int64 `json:"reversed_coins,omitempty"`
+
+	// OrphanRate is the number of orphans per 1000 blocks at
+	// the last OrphanRateWindow indices synced (or at all
+	// indices synced, if fewer). A spike in orphans late in a
+	// run (which a cumulative total hides) often indicates a
+	// reorg handling bug. OrphanRateThreshold is the rate above
+	// which a warning is printed (0 if there is no threshold).
+	OrphanRate          float64 `json:"orphan_rate"`
+	OrphanRateWindow    int64   `json:"orphan_rate_window"`
+	OrphanRateThreshold float64 `json:"orphan_rate_threshold,omitempty"`
+
+	// OperationTypes and OperationStatuses are the number of
+	// operations processed (including orphaned blocks) of
+	// each type and with each status allowed by the network.
+	OperationTypes    map[string]int64 `json:"operation_types,omitempty"`
+	OperationStatuses map[string]int64 `json:"operation_statuses,omitempty"`
+
+	// StorageSizeBytes is the size of the data directory
+	// and PeakMemoryBytes is the most memory obtained from
+	// the OS by any run using the data directory.
+	StorageSizeBytes int64 `json:"storage_size_bytes"`
+	PeakMemoryBytes  int64 `json:"peak_memory_bytes"`
+
+	// EndpointDisagreements is the number of live balances
+	// that differed between the primary and secondary
+	// endpoints. It is only populated if a secondary
+	// endpoint is configured.
+	EndpointDisagreements *int64 `json:"endpoint_disagreements,omitempty"`
+
+	// StorageDataSizeBytes and BloomFilterHitRate summarize
+	// the StorageStats of a run. They are only populated in
+	// the results of a run.
+	StorageDataSizeBytes *int64   `json:"storage_data_size_bytes,omitempty"`
+	BloomFilterHitRate   *float64 `json:"bloom_filter_hit_rate,omitempty"`
+
+	// EndpointLatency is the latency of requests to each
+	// Rosetta endpoint (ex: /block). It is only populated
+	// in the results of a run.
+	EndpointLatency map[string]*EndpointStats `json:"endpoint_latency,omitempty"`
+
+	// BlockPayloads is the size of /block responses. It
+	// is only populated in the results of a run.
+	BlockPayloads *BlockPayloadStats `json:"block_payloads,omitempty"`
+
+	// Fees are the fees paid in canonical blocks. They are only
+	// populated in the results of a run (and only if fee
+	// operation types are configured).
+	Fees *FeeStats `json:"fees,omitempty"`
+
+	// TransferPairs characterizes the sender and recipient
+	// pairs of transfers in canonical blocks. It is only
+	// populated in the results of a run (with transfer
+	// pairs enabled).
+	TransferPairs *TransferPairStats `json:"transfer_pairs,omitempty"`
+
+	// ChainActivity is the activity of the network over
+	// the synced range. It is only populated once blocks
+	// with different timestamps have been synced.
+	ChainActivity *ChainActivity `json:"chain_activity,omitempty"`
+
+	// TrustedCheckpoint splits the synced blocks, transactions,
+	// and operations into those at or below the trusted
+	// checkpoint (which were not asserted) and those after
+	// it. It is only populated if a trusted checkpoint
+	// is configured.
+	TrustedCheckpoint *TrustedCheckpointStats `json:"trusted_checkpoint,omitempty"`
+
+	// Warnings contains an error for each stat that
+	// could not be computed (and is reported as 0).
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// millisecondsPerSecond converts block
+// timestamps (in milliseconds) to seconds.
+const millisecondsPerSecond = 1000
+
+// ChainActivity is the number of transactions and
+// operations per second of chain time (measured with
+// block timestamps instead of wall clock time) over
+// the synced range. PeakTransactionsPerSecond is the
+// most transactions per second between the timestamps
+// of consecutive blocks (blocks with the same timestamp
+// are combined) and PeakStartIndex and PeakEndIndex
+// are the indexes of the blocks in that interval.
+type ChainActivity struct {
+	TransactionsPerSecond     float64 `json:"transactions_per_second"`
+	OperationsPerSecond       float64 `json:"operations_per_second"`
+	PeakTransactionsPerSecond float64 `json:"peak_transactions_per_second"`
+	PeakStartIndex            int64   `json:"peak_start_index"`
+	PeakEndIndex              int64   `json:"peak_end_index"`
+}
+
+// TrustedCheckpointStats is the number of canonical blocks,
+// transactions, and operations synced at or below the
+// trusted checkpoint (Trusted*) and after it (Validated*).
+type TrustedCheckpointStats struct {
+	Index                 int64  `json:"index"`
+	Hash                  string `json:"hash"`
+	TrustedBlocks         int64  `json:"trusted_blocks"`
+	TrustedTransactions   int64  `json:"trusted_transactions"`
+	TrustedOperations     int64  `json:"trusted_operations"`
+	ValidatedBlocks       int64  `json:"validated_blocks"`
+	ValidatedTransactions int64  `json:"validated_transactions"`
+	ValidatedOperations   int64  `json:"validated_operations"`
+}
+
+// appendCountRows appends a row to table for each
+// entry in counts, sorted by count descending.
+func appendCountRows(table *columnTable, label string, counts map[string]int64) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] == counts[keys[j]] {
+			return keys[i] < keys[j]
+		}
+
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	for _, key := range keys {
+		table.Append(
+			[]string{
+				fmt.Sprintf("%s %s", label, key),
+				fmt.Sprintf("# of operations processed with %s %s", strings.ToLower(label), key),
+				strconv.FormatInt(counts[key], 10),
+			},
+		)
+	}
 }
 
 // Print logs CheckDataStats to the console.
 func (c *CheckDataStats) Print() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetRowLine(true)
-	table.SetRowSeparator("-")
-	table.SetHeader([]string{"check:data Stats", "Description", "Value"})
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataStats to w.
+func (c *CheckDataStats) Fprint(w io.Writer) {
+	table := newColumnTable(
+		w,
+		[]string{"check:data Stats", "Description", "Value"},
+		[]configuration.ResultsColumn{
+			configuration.NameResultsColumn,
+			configuration.DescriptionResultsColumn,
+			configuration.ValueResultsColumn,
+		},
+	)
 	table.Append([]string{"Blocks", "# of blocks synced", strconv.FormatInt(c.Blocks, 10)})
 	table.Append([]string{"Orphans", "# of blocks orphaned", strconv.FormatInt(c.Orphans, 10)})
+
+	orphanedDescription := "including orphaned blocks"
+	if c.OrphanedOperationsExcluded {
+		orphanedDescription = "excluding orphaned blocks"
+	}
 	table.Append(
 		[]string{
 			"Transactions",
-			"# of transaction processed",
+			fmt.Sprintf("# of transaction processed (%s)", orphanedDescription),
 			strconv.FormatInt(c.Transactions, 10),
 		},
 	)
 	table.Append(
-		[]string{"Operations", "# of operations processed", strconv.FormatInt(c.Operations, 10)},
+		[]string{
+			"Operations",
+			fmt.Sprintf("# of operations processed (%s)", orphanedDescription),
+			strconv.FormatInt(c.Operations, 10),
+		},
+	)
+	if c.OrphanRateWindow > 0 {
+		table.Append(
+			[]string{
+				"Orphan Rate",
+				fmt.Sprintf("# of blocks orphaned per 1000 blocks (last %d blocks)", c.OrphanRateWindow),
+				strconv.FormatFloat(c.OrphanRate, 'f', 2, 64),
+			},
+		)
+	}
+	table.Append(
+		[]string{
+			"Orphaned Transactions",
+			"# of transactions in orphaned blocks",
+			strconv.FormatInt(c.OrphanedTransactions, 10),
+		},
+	)
+	table.Append(
+		[]string{
+			"Orphaned Operations",
+			"# of operations in orphaned blocks",
+			strconv.FormatInt(c.OrphanedOperations, 10),
+		},
 	)
+	if c.ReversedCoins > 0 {
+		table.Append(
+			[]string{
+				"Reversed Coins",
+				"# of coins created or spent in orphaned blocks (and reversed)",
+				strconv.FormatInt(c.ReversedCoins, 10),
+			},
+		)
+	}
+	appendCountRows(table, "Operation Type", c.OperationTypes)
+	appendCountRows(table, "Operation Status", c.OperationStatuses)
 	table.Append(
 		[]string{
 			"Active Reconciliations",
@@ -131,6 +822,59 @@ func (c *CheckDataStats) Print() {
 			strconv.FormatInt(c.InactiveReconciliations, 10),
 		},
 	)
+	table.Append(
+		[]string{
+			"Reconciliation Failures",
+			"# of reconciliations that failed",
+			strconv.FormatInt(c.ReconciliationFailures, 10),
+		},
+	)
+	table.Append(
+		[]string{
+			"Deferred Reconciliations",
+			"# of reconciliations deferred because the account changed after its live balance",
+			strconv.FormatInt(c.DeferredReconciliations, 10),
+		},
+	)
+	if c.ReconciliationsEnqueued > 0 || c.ReconciliationsSkippedBacklog > 0 {
+		table.Append(
+			[]string{
+				"Reconciliations Enqueued",
+				"# of balance changes queued for active reconciliation",
+				strconv.FormatInt(c.ReconciliationsEnqueued, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Reconciliations Processed",
+				"# of active reconciliations performed (successful or not)",
+				strconv.FormatInt(c.ReconciliationsProcessed, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Skipped Reconciliations (Backlog)",
+				"# of balance changes dropped because the reconciliation backlog was full (estimated)",
+				strconv.FormatInt(c.ReconciliationsSkippedBacklog, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Skipped Reconciliations (Pruned)",
+				"# of reconciliations skipped because their block was pruned",
+				strconv.FormatInt(c.ReconciliationsSkippedPruned, 10),
+			},
+		)
+	}
+	if c.ReconciliationsSuperseded > 0 {
+		table.Append(
+			[]string{
+				"Superseded Reconciliations",
+				"# of balance changes not queued because the account was already queued",
+				strconv.FormatInt(c.ReconciliationsSuperseded, 10),
+			},
+		)
+	}
 	table.Append(
 		[]string{
 			"Reconciliation Coverage",
@@ -138,106 +882,635 @@ func (c *CheckDataStats) Print() {
 			fmt.Sprintf("%f%%", c.ReconciliationCoverage*utils.OneHundred),
 		},
 	)
+	if c.RecentReconciliationCoverage != nil {
+		table.Append(
+			[]string{
+				"Recent Reconciliation Coverage",
+				fmt.Sprintf(
+					"%% of accounts that have been reconciled in the last %d blocks",
+					c.CoverageLookbackBlocks,
+				),
+				fmt.Sprintf("%f%%", *c.RecentReconciliationCoverage*utils.OneHundred),
+			},
+		)
+	}
+	if c.AccountsTracked > 0 {
+		table.Append(
+			[]string{
+				"Accounts Tracked",
+				"# of accounts (each account and currency) under balance tracking",
+				strconv.FormatInt(c.AccountsTracked, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Currencies Seen",
+				"# of currencies under balance tracking",
+				strconv.FormatInt(c.CurrenciesSeen, 10),
+			},
+		)
+	}
+	if c.FetchRetries > 0 || c.FetchFailures > 0 {
+		table.Append(
+			[]string{
+				"Fetch Retries",
+				"# of requests that repeated a failed request",
+				strconv.FormatInt(c.FetchRetries, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Fetch Failures",
+				"# of requests that failed (including those later retried)",
+				strconv.FormatInt(c.FetchFailures, 10),
+			},
+		)
+	}
+	if c.TransferSymmetryChecked > 0 {
+		table.Append(
+			[]string{
+				"Transfer Symmetry Checked",
+				"# of transactions checked for balanced debits and credits",
+				strconv.FormatInt(c.TransferSymmetryChecked, 10),
+			},
+		)
+		table.Append(
+			[]string{
+				"Transfer Symmetry Violations",
+				"# of transactions with unbalanced debits and credits",
+				strconv.FormatInt(c.TransferSymmetryViolations, 10),
+			},
+		)
+	}
+	table.Append(
+		[]string{
+			"Storage Size",
+			"Size of the data directory",
+			formatBytes(c.StorageSizeBytes),
+		},
+	)
+	table.Append(
+		[]string{
+			"Peak Memory",
+			"Most memory obtained from the OS while syncing",
+			formatBytes(c.PeakMemoryBytes),
+		},
+	)
+	if c.EndpointDisagreements != nil {
+		table.Append(
+			[]string{
+				"Endpoint Disagreements",
+				"# of live balances that differed between the primary and secondary endpoints",
+				strconv.FormatInt(*c.EndpointDisagreements, 10),
+			},
+		)
+	}
+	if c.ChainActivity != nil {
+		table.Append(
+			[]string{
+				"Chain Transactions",
+				"# of transactions per second of chain time",
+				fmt.Sprintf("%f/second", c.ChainActivity.TransactionsPerSecond),
+			},
+		)
+		table.Append(
+			[]string{
+				"Chain Operations",
+				"# of operations per second of chain time",
+				fmt.Sprintf("%f/second", c.ChainActivity.OperationsPerSecond),
+			},
+		)
+		table.Append(
+			[]string{
+				"Peak Chain Transactions",
+				fmt.Sprintf(
+					"Most transactions per second of chain time (blocks %d to %d)",
+					c.ChainActivity.PeakStartIndex,
+					c.ChainActivity.PeakEndIndex,
+				),
+				fmt.Sprintf("%f/second", c.ChainActivity.PeakTransactionsPerSecond),
+			},
+		)
+	}
+	if c.TrustedCheckpoint != nil {
+		table.Append(
+			[]string{
+				"Trusted Blocks",
+				fmt.Sprintf(
+					"# of blocks synced at or below the trusted checkpoint (index %d)",
+					c.TrustedCheckpoint.Index,
+				),
+				fmt.Sprintf(
+					"%d (%d transactions, %d operations)",
+					c.TrustedCheckpoint.TrustedBlocks,
+					c.TrustedCheckpoint.TrustedTransactions,
+					c.TrustedCheckpoint.TrustedOperations,
+				),
+			},
+		)
+		table.Append(
+			[]string{
+				"Validated Blocks",
+				"# of blocks synced and asserted after the trusted checkpoint",
+				fmt.Sprintf(
+					"%d (%d transactions, %d operations)",
+					c.TrustedCheckpoint.ValidatedBlocks,
+					c.TrustedCheckpoint.ValidatedTransactions,
+					c.TrustedCheckpoint.ValidatedOperations,
+				),
+			},
+		)
+	}
+	if c.StorageDataSizeBytes != nil {
+		table.Append(
+			[]string{
+				"Storage Data Size",
+				"Size of the LSM tree and value log",
+				formatBytes(*c.StorageDataSizeBytes),
+			},
+		)
+	}
+	if c.BloomFilterHitRate != nil {
+		table.Append(
+			[]string{
+				"Bloom Filter Hit Rate",
+				"% of storage table reads avoided by a bloom filter",
+				fmt.Sprintf("%f%%", *c.BloomFilterHitRate*utils.OneHundred),
+			},
+		)
+	}
 
 	table.Render()
+
+	if len(c.EndpointLatency) > 0 {
+		fmt.Fprintf(w, "\n")
+		printEndpointLatency(w, c.EndpointLatency)
+	}
+
+	if c.BlockPayloads != nil {
+		fmt.Fprintf(w, "\n")
+		printBlockPayloads(w, c.BlockPayloads)
+	}
+
+	if c.Fees != nil {
+		fmt.Fprintf(w, "\n")
+		printFees(w, c.Fees)
+	}
+
+	if c.TransferPairs != nil {
+		fmt.Fprintf(w, "\n")
+		printTransferPairs(w, c.TransferPairs)
+	}
+}
+
+// FrequentlyDeferred returns a boolean indicating if more
+// reconciliations were deferred than performed. This usually
+// means the network is too active to reconcile accounts
+// without historical balance lookup.
+func (c *CheckDataStats) FrequentlyDeferred() bool {
+	return c.DeferredReconciliations > c.ActiveReconciliations+c.InactiveReconciliations
+}
+
+// ReconciliationSkipRatio returns the fraction of balance
+// changes that were never reconciled because the reconciler's
+// backlog was full or their block was pruned (or 0 if no
+// balance changes were queued).
+func (c *CheckDataStats) ReconciliationSkipRatio() float64 {
+	attempted := c.ReconciliationsEnqueued + c.ReconciliationsSkippedBacklog
+	if attempted == 0 {
+		return 0
+	}
+
+	skipped := c.ReconciliationsSkippedBacklog + c.ReconciliationsSkippedPruned
+	return float64(skipped) / float64(attempted)
+}
+
+// HighReconciliationSkipRatio returns a boolean indicating if
+// the reconciliation skip ratio exceeds the configured threshold.
+func (c *CheckDataStats) HighReconciliationSkipRatio() bool {
+	return c.ReconciliationSkipRatioThreshold > 0 &&
+		c.ReconciliationSkipRatio() > c.ReconciliationSkipRatioThreshold
+}
+
+// orphanRateBlocks is the number of blocks
+// the orphan rate is expressed per.
+const orphanRateBlocks = 1000
+
+// HighOrphanRate returns a boolean indicating if the recent
+// orphan rate exceeds the configured orphan rate threshold.
+func (c *CheckDataStats) HighOrphanRate() bool {
+	return c.OrphanRateThreshold > 0 && c.OrphanRate > c.OrphanRateThreshold
+}
+
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// counterGetter is the subset of *storage.CounterStorage
+// used to compute CheckDataStats.
+type counterGetter interface {
+	Get(ctx context.Context, counter string) (*big.Int, error)
+}
+
+// statsCounters gets counters for CheckDataStats. Any
+// counter that cannot be fetched is treated as 0 and
+// the error is recorded in warnings.
+type statsCounters struct {
+	counters counterGetter
+	warnings []string
+}
+
+// warn logs a warning and records it in warnings.
+func (s *statsCounters) warn(err error, description string) {
+	logging.Warn("cannot get stat", logging.Fields{"stat": description, "error": err})
+	s.warnings = append(s.warnings, fmt.Sprintf("%s: cannot get %s", err.Error(), description))
+}
+
+// get returns the value of counter (or 0 if
+// it cannot be fetched).
+func (s *statsCounters) get(ctx context.Context, counter string, description string) int64 {
+	value, err := s.counters.Get(ctx, counter)
+	if err != nil {
+		s.warn(err, description)
+		return 0
+	}
+
+	return value.Int64()
+}
+
+// getChainActivity returns the *ChainActivity computed
+// from the chain counters (or nil if blocks with different
+// timestamps have not been synced).
+func (s *statsCounters) getChainActivity(ctx context.Context) *ChainActivity {
+	start := s.get(ctx, ChainStartTimestampCounter, "chain start timestamp counter")
+	end := s.get(ctx, ChainEndTimestampCounter, "chain end timestamp counter")
+	if end <= start {
+		return nil
+	}
+
+	seconds := float64(end-start) / millisecondsPerSecond
+	activity := &ChainActivity{
+		TransactionsPerSecond: float64(
+			s.get(ctx, ChainTransactionCounter, "chain transactions counter"),
+		) / seconds,
+		OperationsPerSecond: float64(
+			s.get(ctx, ChainOperationCounter, "chain operations counter"),
+		) / seconds,
+		PeakStartIndex: s.get(ctx, ChainPeakStartCounter, "chain peak start counter"),
+		PeakEndIndex:   s.get(ctx, ChainPeakEndCounter, "chain peak end counter"),
+	}
+
+	peakDuration := s.get(ctx, ChainPeakDurationCounter, "chain peak duration counter")
+	if peakDuration > 0 {
+		activity.PeakTransactionsPerSecond = float64(
+			s.get(ctx, ChainPeakTransactionCounter, "chain peak transactions counter"),
+		) / (float64(peakDuration) / millisecondsPerSecond)
+	}
+
+	return activity
+}
+
+// getTrustedCheckpoint returns the *TrustedCheckpointStats
+// for checkpoint (or nil if checkpoint is nil).
+func (s *statsCounters) getTrustedCheckpoint(
+	ctx context.Context,
+	checkpoint *configuration.TrustedCheckpoint,
+) *TrustedCheckpointStats {
+	if checkpoint == nil {
+		return nil
+	}
+
+	return &TrustedCheckpointStats{
+		Index:               checkpoint.Index,
+		Hash:                checkpoint.Hash,
+		TrustedBlocks:       s.get(ctx, TrustedBlockCounter, "trusted blocks counter"),
+		TrustedTransactions: s.get(ctx, TrustedTransactionCounter, "trusted transactions counter"),
+		TrustedOperations:   s.get(ctx, TrustedOperationCounter, "trusted operations counter"),
+		ValidatedBlocks:     s.get(ctx, ValidatedBlockCounter, "validated blocks counter"),
+		ValidatedTransactions: s.get(
+			ctx,
+			ValidatedTransactionCounter,
+			"validated transactions counter",
+		),
+		ValidatedOperations: s.get(ctx, ValidatedOperationCounter, "validated operations counter"),
+	}
+}
+
+// getOperationCounts returns the value of the counter
+// for each name in names (or nil if names is empty).
+func (s *statsCounters) getOperationCounts(
+	ctx context.Context,
+	names []string,
+	counter func(string) string,
+	description string,
+) map[string]int64 {
+	if len(names) == 0 {
+		return nil
+	}
+
+	counts := map[string]int64{}
+	for _, name := range names {
+		counts[name] = s.get(ctx, counter(name), fmt.Sprintf("%s %s counter", name, description))
+	}
+
+	return counts
 }
 
 // ComputeCheckDataStats returns a populated CheckDataStats.
+// OperationTypes and OperationStatuses are only populated
+// if networkAsserter is initialized. If any stat cannot be
+// computed, it is treated as 0 and the error is included in
+// Warnings (so the remaining stats are still reported).
 func ComputeCheckDataStats(
 	ctx context.Context,
+	config *configuration.Configuration,
 	counters *storage.CounterStorage,
 	balances *storage.BalanceStorage,
+	networkAsserter *asserter.Asserter,
 ) *CheckDataStats {
 	if counters == nil {
 		return nil
 	}
 
-	blocks, err := counters.Get(ctx, storage.BlockCounter)
-	if err != nil {
-		log.Printf("%s: cannot get block counter", err.Error())
-		return nil
+	var coverage reconciliationCoverageGetter
+	if balances != nil {
+		coverage = balances
 	}
 
-	orphans, err := counters.Get(ctx, storage.OrphanCounter)
-	if err != nil {
-		log.Printf("%s: cannot get orphan counter", err.Error())
-		return nil
+	return computeCheckDataStats(ctx, config, counters, coverage, networkAsserter)
+}
+
+// CoverageMinIndex returns the minimum block index an account
+// must be reconciled at to count towards the reconciliation
+// coverage when the head block is at headIndex. This is the
+// larger of ReconciliationCoverageMinIndex and (if
+// CoverageLookbackBlocks is populated) headIndex minus
+// CoverageLookbackBlocks.
+func CoverageMinIndex(config *configuration.Configuration, headIndex int64) int64 {
+	minIndex := config.Data.ReconciliationCoverageMinIndex
+	if config.Data.CoverageLookbackBlocks > 0 &&
+		headIndex-config.Data.CoverageLookbackBlocks > minIndex {
+		minIndex = headIndex - config.Data.CoverageLookbackBlocks
 	}
 
-	txs, err := counters.Get(ctx, storage.TransactionCounter)
-	if err != nil {
-		log.Printf("%s: cannot get transaction counter", err.Error())
-		return nil
+	return minIndex
+}
+
+// reconciliationCoverageGetter is the subset of
+// *storage.BalanceStorage used to compute CheckDataStats.
+type reconciliationCoverageGetter interface {
+	ReconciliationCoverage(ctx context.Context, minimumIndex int64) (float64, error)
+}
+
+func computeCheckDataStats(
+	ctx context.Context,
+	config *configuration.Configuration,
+	counters counterGetter,
+	balances reconciliationCoverageGetter,
+	networkAsserter *asserter.Asserter,
+) *CheckDataStats {
+	s := &statsCounters{counters: counters}
+	stats := &CheckDataStats{
+		Blocks:                  s.get(ctx, storage.BlockCounter, "block counter"),
+		Orphans:                 s.get(ctx, storage.OrphanCounter, "orphan counter"),
+		Transactions:            s.get(ctx, storage.TransactionCounter, "transaction counter"),
+		Operations:              s.get(ctx, storage.OperationCounter, "operations counter"),
+		ActiveReconciliations:   s.get(ctx, storage.ActiveReconciliationCounter, "active reconciliations counter"),
+		InactiveReconciliations: s.get(ctx, storage.InactiveReconciliationCounter, "inactive reconciliations counter"),
+		ReconciliationFailures:  s.get(ctx, ReconciliationFailureCounter, "reconciliation failures counter"),
+		DeferredReconciliations: s.get(ctx, DeferredReconciliationCounter, "deferred reconciliations counter"),
+		OrphanedTransactions:    s.get(ctx, OrphanedTransactionCounter, "orphaned transactions counter"),
+		OrphanedOperations:      s.get(ctx, OrphanedOperationCounter, "orphaned operations counter"),
+		ReversedCoins:           s.get(ctx, ReversedCoinCounter, "reversed coins counter"),
+		PeakMemoryBytes:         s.get(ctx, PeakMemoryCounter, "peak memory counter"),
+		AccountsTracked:         s.get(ctx, AccountsTrackedCounter, "accounts tracked counter"),
+		CurrenciesSeen:          s.get(ctx, CurrenciesSeenCounter, "currencies seen counter"),
+		ReconciliationsEnqueued: s.get(
+			ctx,
+			ReconciliationsEnqueuedCounter,
+			"reconciliations enqueued counter",
+		),
+		ReconciliationsProcessed: s.get(
+			ctx,
+			ReconciliationsProcessedCounter,
+			"reconciliations processed counter",
+		),
+		ReconciliationsSkippedBacklog: s.get(
+			ctx,
+			ReconciliationsSkippedBacklogCounter,
+			"reconciliations skipped (backlog) counter",
+		),
+		ReconciliationsSkippedPruned: s.get(
+			ctx,
+			ReconciliationsSkippedPrunedCounter,
+			"reconciliations skipped (pruned) counter",
+		),
+		ReconciliationsSuperseded: s.get(
+			ctx,
+			ReconciliationsSupersededCounter,
+			"reconciliations superseded counter",
+		),
+		FetchRetries:  s.get(ctx, FetchRetryCounter, "fetch retries counter"),
+		FetchFailures: s.get(ctx, FetchFailureCounter, "fetch failures counter"),
+		TransferSymmetryChecked: s.get(
+			ctx,
+			TransferSymmetryCheckedCounter,
+			"transfer symmetry checked counter",
+		),
+		TransferSymmetryViolations: s.get(
+			ctx,
+			TransferSymmetryViolationCounter,
+			"transfer symmetry violations counter",
+		),
 	}
 
-	ops, err := counters.Get(ctx, storage.OperationCounter)
-	if err != nil {
-		log.Printf("%s: cannot get operations counter", err.Error())
-		return nil
+	if stats.ReconciliationsEnqueued > 0 || stats.ReconciliationsSkippedBacklog > 0 {
+		stats.ReconciliationSkipRatioThreshold = config.Data.ReconciliationSkipRatioThreshold
+		if stats.ReconciliationSkipRatioThreshold == 0 {
+			stats.ReconciliationSkipRatioThreshold = configuration.DefaultReconciliationSkipRatioThreshold
+		}
 	}
 
-	activeReconciliations, err := counters.Get(ctx, storage.ActiveReconciliationCounter)
-	if err != nil {
-		log.Printf("%s: cannot get active reconciliations counter", err.Error())
-		return nil
+	if len(config.DataDirectory) > 0 {
+		storageSize, err := DirectorySize(config.DataDirectory)
+		if err != nil {
+			s.warn(err, "storage size")
+		} else {
+			stats.StorageSizeBytes = storageSize
+		}
 	}
 
-	inactiveReconciliations, err := counters.Get(ctx, storage.InactiveReconciliationCounter)
-	if err != nil {
-		log.Printf("%s: cannot get inactive reconciliations counter", err.Error())
-		return nil
+	// Orphans are only counted as recent while their
+	// index is in the window, so the rate is computed
+	// over the smaller of the window and the synced
+	// range.
+	stats.OrphanRateWindow = config.Data.OrphanRateWindow
+	stats.OrphanRateThreshold = config.Data.OrphanRateThreshold
+	if window := minInt64(stats.OrphanRateWindow, stats.Blocks); window > 0 {
+		recentOrphans := s.get(ctx, RecentOrphanCounter, "recent orphans counter")
+		stats.OrphanRate = float64(recentOrphans) * orphanRateBlocks / float64(window)
 	}
 
-	stats := &CheckDataStats{
-		Blocks:                  blocks.Int64(),
-		Orphans:                 orphans.Int64(),
-		Transactions:            txs.Int64(),
-		Operations:              ops.Int64(),
-		ActiveReconciliations:   activeReconciliations.Int64(),
-		InactiveReconciliations: inactiveReconciliations.Int64(),
+	// Transactions and operations in orphaned blocks are
+	// counted when the block is added, so we subtract them
+	// if they should be excluded.
+	if config.Data.OrphanedOperationsExcluded {
+		stats.OrphanedOperationsExcluded = true
+		stats.Transactions -= stats.OrphanedTransactions
+		stats.Operations -= stats.OrphanedOperations
+	}
+
+	if len(config.Data.SecondaryOnlineURL) > 0 {
+		endpointDisagreements := s.get(
+			ctx,
+			EndpointDisagreementCounter,
+			"endpoint disagreements counter",
+		)
+		stats.EndpointDisagreements = &endpointDisagreements
+	}
+
+	if clientConfig, err := networkAsserter.ClientConfiguration(); err == nil {
+		stats.OperationTypes = s.getOperationCounts(
+			ctx,
+			clientConfig.AllowedOperationTypes,
+			OperationTypeCounter,
+			"operation type",
+		)
+
+		statuses := make([]string, len(clientConfig.AllowedOperationStatuses))
+		for i, status := range clientConfig.AllowedOperationStatuses {
+			statuses[i] = status.Status
+		}
+
+		stats.OperationStatuses = s.getOperationCounts(
+			ctx,
+			statuses,
+			OperationStatusCounter,
+			"operation status",
+		)
 	}
 
 	if balances != nil {
-		coverage, err := balances.ReconciliationCoverage(ctx, 0)
+		coverage, err := balances.ReconciliationCoverage(
+			ctx,
+			config.Data.ReconciliationCoverageMinIndex,
+		)
 		if err != nil {
-			log.Printf("%s: cannot get reconcile coverage", err.Error())
-			return nil
+			s.warn(err, "reconcile coverage")
+		} else {
+			stats.ReconciliationCoverage = coverage
 		}
+	}
 
-		stats.ReconciliationCoverage = coverage
+	if balances != nil && config.Data.CoverageLookbackBlocks > 0 {
+		headIndex := s.get(ctx, ChainHeadIndexCounter, "chain head index counter")
+		coverage, err := balances.ReconciliationCoverage(
+			ctx,
+			CoverageMinIndex(config, headIndex),
+		)
+		if err != nil {
+			s.warn(err, "recent reconcile coverage")
+		} else {
+			stats.RecentReconciliationCoverage = &coverage
+			stats.CoverageLookbackBlocks = config.Data.CoverageLookbackBlocks
+		}
 	}
 
-	return stats
+	stats.ChainActivity = s.getChainActivity(ctx)
+	stats.TrustedCheckpoint = s.getTrustedCheckpoint(ctx, config.Data.TrustedCheckpoint)
+	stats.Warnings = s.warnings
+
+	return stats
+}
+
+// CheckDataProgress contains information
+// about check:data's syncing progress. Rate is
+// the average sync rate since the start of the
+// run and RecentRate is the sync rate over the
+// configured sync rate window (or Rate until
+// check:data has synced for that long).
+//
+// TimeRemaining (in seconds) is computed from
+// RecentRate. MinTimeRemaining and MaxTimeRemaining
+// are computed from the fastest and slowest rates
+// between samples in the window (and are only
+// populated once the window is covered).
+// EstimatedCompletion is only populated when
+// TimeRemaining is at most HumanizedDurationLimit.
+type CheckDataProgress struct {
+	Blocks              int64   `json:"blocks"`
+	Tip                 int64   `json:"tip"`
+	Completed           float64 `json:"completed"`
+	Rate                float64 `json:"rate"`
+	RecentRate          float64 `json:"recent_rate"`
+	TimeRemaining       float64 `json:"time_remaining"`
+	MinTimeRemaining    float64 `json:"min_time_remaining,omitempty"`
+	MaxTimeRemaining    float64 `json:"max_time_remaining,omitempty"`
+	EstimatedCompletion string  `json:"estimated_completion,omitempty"`
+
+	// ReconciliationQueueDepth is the number of balance changes
+	// waiting to be reconciled. If it keeps growing, reconciliation
+	// can't keep up with syncing. ReconciliationQueueDepthThreshold
+	// is the depth above which a warning is logged (0 if there is
+	// no threshold).
+	ReconciliationQueueDepth          int64 `json:"reconciliation_queue_depth"`
+	ReconciliationQueueDepthThreshold int64 `json:"reconciliation_queue_depth_threshold,omitempty"`
+}
+
+// ReconciliationQueue is implemented by *reconciler.Reconciler.
+type ReconciliationQueue interface {
+	QueueSize() int
+}
+
+// ReconciliationFallingBehind returns a boolean indicating if
+// ReconciliationQueueDepth is above its threshold.
+func (c *CheckDataProgress) ReconciliationFallingBehind() bool {
+	return c.ReconciliationQueueDepthThreshold > 0 &&
+		c.ReconciliationQueueDepth > c.ReconciliationQueueDepthThreshold
 }
 
-// CheckDataProgress contains information
-// about check:data's syncing progress.
-type CheckDataProgress struct {
-	Blocks        int64   `json:"blocks"`
-	Tip           int64   `json:"tip"`
-	Completed     float64 `json:"completed"`
-	Rate          float64 `json:"rate"`
-	TimeRemaining string  `json:"time_remaining"`
+// HumanTimeRemaining returns TimeRemaining
+// rendered by HumanizeSeconds.
+func (c *CheckDataProgress) HumanTimeRemaining() string {
+	return HumanizeSeconds(c.TimeRemaining)
 }
 
 // ComputeCheckDataProgress returns
-// a populated *CheckDataProgress.
+// a populated *CheckDataProgress. The recent
+// sync rate is computed over the window of
+// syncRate. Progress is measured from startIndex
+// (or from the genesis block if startIndex is nil).
+// The reconciliation queue depth is read from
+// reconciliationQueue (if it is not nil).
 func ComputeCheckDataProgress(
 	ctx context.Context,
 	fetcher *fetcher.Fetcher,
 	network *types.NetworkIdentifier,
 	counters *storage.CounterStorage,
+	startIndex *int64,
+	startedAt time.Time,
+	syncRate *SyncRate,
+	reconciliationQueue ReconciliationQueue,
 ) *CheckDataProgress {
 	networkStatus, fetchErr := fetcher.NetworkStatusRetry(ctx, network, nil)
 	if fetchErr != nil {
-		fmt.Printf("%s: cannot get network status", fetchErr.Err.Error())
+		logging.Warn("cannot get network status", logging.Fields{"error": fetchErr.Err})
 		return nil
 	}
 	tipIndex := networkStatus.CurrentBlockIdentifier.Index
 
+	firstIndex := networkStatus.GenesisBlockIdentifier.Index
+	if startIndex != nil {
+		firstIndex = *startIndex
+	}
+
 	blocks, err := counters.Get(ctx, storage.BlockCounter)
 	if err != nil {
-		fmt.Printf("%s: cannot get block counter", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "block", "error": err})
 		return nil
 	}
 
@@ -247,37 +1520,58 @@ func ComputeCheckDataProgress(
 
 	orphans, err := counters.Get(ctx, storage.OrphanCounter)
 	if err != nil {
-		fmt.Printf("%s: cannot get orphan counter", err.Error())
+		logging.Warn("cannot get counter", logging.Fields{"counter": "orphan", "error": err})
 		return nil
 	}
 
 	adjustedBlocks := blocks.Int64() - orphans.Int64()
-	if tipIndex-adjustedBlocks <= 0 { // return if no blocks to sync
-		return nil
-	}
+	now := time.Now()
+	estimate := syncRate.Add(now, adjustedBlocks)
 
-	elapsedTime, err := counters.Get(ctx, TimeElapsedCounter)
-	if err != nil {
-		fmt.Printf("%s: cannot get elapsed time", err.Error())
+	// Blocks are synced from firstIndex,
+	// so we only consider blocks after it.
+	syncedIndex := firstIndex + adjustedBlocks
+	if tipIndex-syncedIndex <= 0 { // return if no blocks to sync
 		return nil
 	}
 
-	if elapsedTime.Sign() == 0 { // wait for at least some elapsed time
+	elapsedTime := now.Sub(startedAt).Seconds()
+	if elapsedTime <= 0 { // wait for at least some elapsed time
 		return nil
 	}
 
-	blocksPerSecond := new(big.Float).Quo(new(big.Float).SetInt64(adjustedBlocks), new(big.Float).SetInt(elapsedTime))
-	blocksPerSecondFloat, _ := blocksPerSecond.Float64()
-	blocksSynced := new(big.Float).Quo(new(big.Float).SetInt64(adjustedBlocks), new(big.Float).SetInt64(tipIndex))
+	blocksPerSecondFloat := float64(adjustedBlocks) / elapsedTime
+	blocksSynced := new(big.Float).Quo(
+		new(big.Float).SetInt64(adjustedBlocks),
+		new(big.Float).SetInt64(tipIndex-firstIndex),
+	)
 	blocksSyncedFloat, _ := blocksSynced.Float64()
 
-	return &CheckDataProgress{
-		Blocks:        adjustedBlocks,
-		Tip:           tipIndex,
-		Completed:     blocksSyncedFloat * utils.OneHundred,
-		Rate:          blocksPerSecondFloat,
-		TimeRemaining: utils.TimeToTip(blocksPerSecondFloat, adjustedBlocks, tipIndex).String(),
+	progress := &CheckDataProgress{
+		Blocks:     adjustedBlocks,
+		Tip:        tipIndex,
+		Completed:  blocksSyncedFloat * utils.OneHundred,
+		Rate:       blocksPerSecondFloat,
+		RecentRate: blocksPerSecondFloat,
+	}
+
+	if reconciliationQueue != nil {
+		progress.ReconciliationQueueDepth = int64(reconciliationQueue.QueueSize())
+	}
+
+	if estimate != nil {
+		progress.RecentRate = estimate.Rate
+		progress.MinTimeRemaining = utils.TimeToTip(estimate.Fastest, syncedIndex, tipIndex).Seconds()
+		progress.MaxTimeRemaining = utils.TimeToTip(estimate.Slowest, syncedIndex, tipIndex).Seconds()
+	}
+
+	timeRemaining := utils.TimeToTip(progress.RecentRate, syncedIndex, tipIndex)
+	progress.TimeRemaining = timeRemaining.Seconds()
+	if timeRemaining <= HumanizedDurationLimit {
+		progress.EstimatedCompletion = now.Add(timeRemaining).UTC().Format(time.RFC3339)
 	}
+
+	return progress
 }
 
 // CheckDataStatus contains both CheckDataStats
@@ -291,73 +1585,210 @@ type CheckDataStatus struct {
 // *CheckDataStatus.
 func ComputeCheckDataStatus(
 	ctx context.Context,
+	config *configuration.Configuration,
 	counters *storage.CounterStorage,
 	balances *storage.BalanceStorage,
 	fetcher *fetcher.Fetcher,
 	network *types.NetworkIdentifier,
+	startedAt time.Time,
+	syncRate *SyncRate,
+	reconciliationQueue ReconciliationQueue,
 ) *CheckDataStatus {
+	stats := ComputeCheckDataStats(
+		ctx,
+		config,
+		counters,
+		balances,
+		fetcher.Asserter,
+	)
+	progress := ComputeCheckDataProgress(
+		ctx,
+		fetcher,
+		network,
+		counters,
+		config.Data.StartIndex,
+		startedAt,
+		syncRate,
+		reconciliationQueue,
+	)
+	if progress != nil {
+		progress.ReconciliationQueueDepthThreshold = config.Data.ReconciliationQueueDepthThreshold
+	}
+
 	return &CheckDataStatus{
-		Stats: ComputeCheckDataStats(
-			ctx,
-			counters,
-			balances,
-		),
-		Progress: ComputeCheckDataProgress(
-			ctx,
-			fetcher,
-			network,
-			counters,
-		),
+		Stats:    stats,
+		Progress: progress,
 	}
 }
 
-// FetchCheckDataStatus fetches *CheckDataStatus.
-func FetchCheckDataStatus(url string) (*CheckDataStatus, error) {
+// FetchCheckDataStatus fetches *CheckDataStatus
+// (opts can be nil).
+func FetchCheckDataStatus(url string, opts *JSONFetchOptions) (*CheckDataStatus, error) {
 	var status CheckDataStatus
-	if err := JSONFetch(url, &status); err != nil {
-		return nil, fmt.Errorf("%w: unable to fetch construction status", err)
+	if err := JSONFetch(url, &status, opts); err != nil {
+		return nil, fmt.Errorf("%w: unable to fetch check:data status", err)
 	}
 
 	return &status, nil
 }
 
+// NegativeRequestResult describes how an implementation
+// responded to a request for an invalid block.
+type NegativeRequestResult struct {
+	BlockIdentifier *types.PartialBlockIdentifier `json:"block_identifier"`
+	Passed          bool                          `json:"passed"`
+	Detail          string                        `json:"detail"`
+}
+
 // CheckDataTests indicates which tests passed.
 // If a test is nil, it did not apply to the run.
-//
-// TODO: add CoinTracking
 type CheckDataTests struct {
 	RequestResponse   bool  `json:"request_response"`
 	ResponseAssertion bool  `json:"response_assertion"`
 	BlockSyncing      *bool `json:"block_syncing"`
 	BalanceTracking   *bool `json:"balance_tracking"`
+	CoinTracking      *bool `json:"coin_tracking"`
 	Reconciliation    *bool `json:"reconciliation"`
+	NegativeRequest   *bool `json:"negative_request"`
+
+	// HistoricalBalanceTracking indicates if historical balances
+	// returned by the implementation matched the balances computed
+	// at the same blocks. It is nil unless historical balance
+	// checks are enabled and historical balance lookup is supported.
+	HistoricalBalanceTracking *bool `json:"historical_balance_tracking,omitempty"`
+
+	// TransferSymmetry indicates if the debits and credits of
+	// every checked transaction were balanced. It is nil unless
+	// transfer symmetry is checked with fatal enabled (otherwise
+	// unbalanced transactions are only reported).
+	TransferSymmetry *bool `json:"transfer_symmetry,omitempty"`
+
+	// BlockPayloadSize indicates if every /block response was at
+	// most max_block_payload_bytes. It is nil unless violations
+	// fail the run (fail_on_block_payload_violation).
+	BlockPayloadSize *bool `json:"block_payload_size,omitempty"`
+
+	// Skipped contains the names of the tests that were
+	// disabled by configuration (these tests are nil). It
+	// is used to distinguish a test that was skipped from
+	// a test that the run never got far enough to exercise.
+	Skipped []string `json:"skipped,omitempty"`
+
+	// Details is only populated if
+	// at least one test failed.
+	Details *CheckDataTestDetails `json:"details,omitempty"`
+}
+
+// CheckDataTestDetails contains the reason each
+// test in CheckDataTests failed. The detail of a test
+// that passed (or was not tested) is empty.
+type CheckDataTestDetails struct {
+	RequestResponse   string `json:"request_response,omitempty"`
+	ResponseAssertion string `json:"response_assertion,omitempty"`
+	BlockSyncing      string `json:"block_syncing,omitempty"`
+	BalanceTracking   string `json:"balance_tracking,omitempty"`
+	CoinTracking      string `json:"coin_tracking,omitempty"`
+	Reconciliation    string `json:"reconciliation,omitempty"`
+	NegativeRequest   string `json:"negative_request,omitempty"`
+
+	HistoricalBalanceTracking string `json:"historical_balance_tracking,omitempty"`
+	TransferSymmetry          string `json:"transfer_symmetry,omitempty"`
+	BlockPayloadSize          string `json:"block_payload_size,omitempty"`
+}
+
+// Names of the check:data tests that can be
+// disabled by configuration (see SkippedTests).
+const (
+	BalanceTrackingTestName = "balance_tracking"
+	CoinTrackingTestName    = "coin_tracking"
+	ReconciliationTestName  = "reconciliation"
+	NegativeRequestTestName = "negative_request"
+)
+
+// TestStatus is the outcome of a check:data test.
+type TestStatus string
+
+const (
+	// TestPassed is the status of a test that passed.
+	TestPassed TestStatus = "PASSED"
+
+	// TestFailed is the status of a test that failed.
+	TestFailed TestStatus = "FAILED"
+
+	// TestSkipped is the status of a test that
+	// was disabled by configuration.
+	TestSkipped TestStatus = "SKIPPED"
+
+	// TestNotReached is the status of a test that
+	// the run never got far enough to exercise.
+	TestNotReached TestStatus = "NOT TESTED"
+)
+
+// testStatus returns the TestStatus of a test
+// that passed (true), failed (false), or was
+// not tested (nil). A test that was not tested
+// is TestSkipped if skipped is true.
+func testStatus(v *bool, skipped bool) TestStatus {
+	switch {
+	case v == nil && skipped:
+		return TestSkipped
+	case v == nil:
+		return TestNotReached
+	case *v:
+		return TestPassed
+	default:
+		return TestFailed
+	}
 }
 
 // convertBool converts a *bool
 // to a test result.
 func convertBool(v *bool) string {
-	if v == nil {
-		return "NOT TESTED"
-	}
+	return string(testStatus(v, false))
+}
 
-	if *v {
-		return "PASSED"
+// Status returns the TestStatus of the test
+// with name (with outcome v).
+func (c *CheckDataTests) Status(name string, v *bool) TestStatus {
+	skipped := false
+	for _, skippedName := range c.Skipped {
+		if skippedName == name {
+			skipped = true
+			break
+		}
 	}
 
-	return "FAILED"
+	return testStatus(v, skipped)
 }
 
 // Print logs CheckDataTests to the console.
 func (c *CheckDataTests) Print() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetRowLine(true)
-	table.SetRowSeparator("-")
-	table.SetHeader([]string{"check:data Tests", "Description", "Status"})
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataTests to w.
+func (c *CheckDataTests) Fprint(w io.Writer) {
+	details := c.Details
+	if details == nil {
+		details = &CheckDataTestDetails{}
+	}
+
+	table := newColumnTable(
+		w,
+		[]string{"check:data Tests", "Description", "Status", "Detail"},
+		[]configuration.ResultsColumn{
+			configuration.NameResultsColumn,
+			configuration.DescriptionResultsColumn,
+			configuration.StatusResultsColumn,
+			configuration.DetailResultsColumn,
+		},
+	)
 	table.Append(
 		[]string{
 			"Request/Response",
 			"Rosetta implementation serviced all requests",
 			convertBool(&c.RequestResponse),
+			details.RequestResponse,
 		},
 	)
 	table.Append(
@@ -365,6 +1796,7 @@ func (c *CheckDataTests) Print() {
 			"Response Assertion",
 			"All responses are correctly formatted",
 			convertBool(&c.ResponseAssertion),
+			details.ResponseAssertion,
 		},
 	)
 	table.Append(
@@ -372,22 +1804,71 @@ func (c *CheckDataTests) Print() {
 			"Block Syncing",
 			"Blocks are connected into a single canonical chain",
 			convertBool(c.BlockSyncing),
+			details.BlockSyncing,
 		},
 	)
 	table.Append(
 		[]string{
 			"Balance Tracking",
 			"Account balances did not go negative",
-			convertBool(c.BalanceTracking),
+			string(c.Status(BalanceTrackingTestName, c.BalanceTracking)),
+			details.BalanceTracking,
+		},
+	)
+	table.Append(
+		[]string{
+			"Coin Tracking",
+			"No inconsistent coin creations or spends were found",
+			string(c.Status(CoinTrackingTestName, c.CoinTracking)),
+			details.CoinTracking,
 		},
 	)
 	table.Append(
 		[]string{
 			"Reconciliation",
 			"No balance discrepencies were found between computed and live balances",
-			convertBool(c.Reconciliation),
+			string(c.Status(ReconciliationTestName, c.Reconciliation)),
+			details.Reconciliation,
+		},
+	)
+	table.Append(
+		[]string{
+			"Negative Request",
+			"Requests for invalid blocks were rejected with a Rosetta error",
+			string(c.Status(NegativeRequestTestName, c.NegativeRequest)),
+			details.NegativeRequest,
 		},
 	)
+	if c.HistoricalBalanceTracking != nil {
+		table.Append(
+			[]string{
+				"Historical Balance Tracking",
+				"Historical balances matched the balances computed at the same blocks",
+				convertBool(c.HistoricalBalanceTracking),
+				details.HistoricalBalanceTracking,
+			},
+		)
+	}
+	if c.TransferSymmetry != nil {
+		table.Append(
+			[]string{
+				"Transfer Symmetry",
+				"The debits and credits of every transaction were balanced",
+				convertBool(c.TransferSymmetry),
+				details.TransferSymmetry,
+			},
+		)
+	}
+	if c.BlockPayloadSize != nil {
+		table.Append(
+			[]string{
+				"Block Payload Size",
+				"No /block response was larger than max_block_payload_bytes",
+				convertBool(c.BlockPayloadSize),
+				details.BlockPayloadSize,
+			},
+		)
+	}
 
 	table.Render()
 }
@@ -417,7 +1898,7 @@ func BlockSyncingTest(err error, blocksSynced bool) *bool {
 	syncPass := true
 	storageFailed, _ := storage.Err(err)
 	if syncer.Err(err) ||
-		(storageFailed && !errors.Is(err, storage.ErrNegativeBalance)) {
+		(storageFailed && !errors.Is(err, storage.ErrNegativeBalance) && !coinStorageErr(err)) {
 		syncPass = false
 	}
 
@@ -447,6 +1928,37 @@ func BalanceTrackingTest(cfg *configuration.Configuration, err error, operations
 	return &balancePass
 }
 
+// coinStorageErr returns a boolean indicating
+// if err is a storage.CoinStorage error.
+func coinStorageErr(err error) bool {
+	for _, coinStorageErr := range storage.CoinStorageErrs {
+		if errors.Is(err, coinStorageErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CoinTrackingTest returns a boolean
+// indicating if any coins were created or
+// spent inconsistently (ex: a coin created
+// twice, a coin spent that was never created,
+// a coin created with a negative amount, or a
+// coin change not reversed in an orphaned block)
+// while syncing.
+func CoinTrackingTest(cfg *configuration.Configuration, err error, coinsSeen bool) *bool {
+	coinPass := !coinStorageErr(err) &&
+		!errors.Is(err, ErrCoinAmountInvalid) &&
+		!errors.Is(err, ErrCoinSpentBeforeCreated) &&
+		!errors.Is(err, ErrOrphanedCoinNotReversed)
+	if (cfg.Data.CoinTrackingDisabled || !coinsSeen) && coinPass {
+		return nil
+	}
+
+	return &coinPass
+}
+
 // ReconciliationTest returns a boolean
 // if no reconciliation errors were received.
 func ReconciliationTest(
@@ -474,15 +1986,250 @@ func ReconciliationTest(
 	return &reconciliationPass
 }
 
+// failureBudget returns a boolean indicating if cfg
+// tolerates reconciliation failures until a number of
+// them have occurred (MaxReconciliationFailures).
+func failureBudget(cfg *configuration.Configuration) bool {
+	return cfg.Data.EndConditions != nil &&
+		cfg.Data.EndConditions.MaxReconciliationFailures != nil
+}
+
+// SkippedTests returns the names of the tests
+// disabled by cfg.
+func SkippedTests(cfg *configuration.Configuration) []string {
+	skipped := []string{}
+	if cfg.Data.BalanceTrackingDisabled {
+		skipped = append(skipped, BalanceTrackingTestName)
+	}
+
+	if cfg.Data.CoinTrackingDisabled {
+		skipped = append(skipped, CoinTrackingTestName)
+	}
+
+	if cfg.Data.BalanceTrackingDisabled || cfg.Data.ReconciliationDisabled ||
+		cfg.Data.IgnoreReconciliationError {
+		skipped = append(skipped, ReconciliationTestName)
+	}
+
+	if cfg.Data.NegativeRequestDisabled {
+		skipped = append(skipped, NegativeRequestTestName)
+	}
+
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	return skipped
+}
+
+// NegativeRequestTest returns a boolean
+// indicating if all requests for invalid
+// blocks were correctly rejected.
+func NegativeRequestTest(negativeRequests []*NegativeRequestResult) *bool {
+	if len(negativeRequests) == 0 {
+		return nil
+	}
+
+	negativePass := true
+	for _, result := range negativeRequests {
+		if !result.Passed {
+			negativePass = false
+			break
+		}
+	}
+
+	return &negativePass
+}
+
+// TransferSymmetryTest returns a boolean indicating if
+// the debits and credits of every checked transaction were
+// balanced. Unbalanced transactions only fail the test if
+// the check is fatal (otherwise nil is returned).
+func TransferSymmetryTest(
+	cfg *configuration.Configuration,
+	err error,
+	transactionsChecked bool,
+) *bool {
+	if cfg.Data.TransferSymmetry == nil || !cfg.Data.TransferSymmetry.Fatal {
+		return nil
+	}
+
+	symmetryPass := !errors.Is(err, ErrTransferAsymmetry)
+	if !transactionsChecked && symmetryPass {
+		return nil
+	}
+
+	return &symmetryPass
+}
+
+// BlockPayloadSizeTest returns a boolean indicating if
+// every /block response was at most max_block_payload_bytes.
+// Violations only fail the test if the run fails on them
+// (otherwise nil is returned).
+func BlockPayloadSizeTest(
+	cfg *configuration.Configuration,
+	blockPayloads *BlockPayloadStats,
+) *bool {
+	if !cfg.Data.FailOnBlockPayloadViolation || blockPayloads == nil || blockPayloads.Count == 0 {
+		return nil
+	}
+
+	payloadPass := blockPayloads.ViolationCount == 0
+	return &payloadPass
+}
+
+// blockPayloadViolations returns a description
+// of the block payload violations in blockPayloads.
+func blockPayloadViolations(blockPayloads *BlockPayloadStats) string {
+	return fmt.Sprintf(
+		"%d /block responses were larger than %d bytes (largest: %d bytes)",
+		blockPayloads.ViolationCount,
+		blockPayloads.MaxBlockPayloadBytes,
+		blockPayloads.Max,
+	)
+}
+
+// failed returns a boolean indicating
+// if a test was run and did not pass.
+func failed(v *bool) bool {
+	return v != nil && !*v
+}
+
+// ComputeCheckDataTestDetails returns the reason each
+// failed test in tests failed. If no test failed, nil
+// is returned.
+func ComputeCheckDataTestDetails(
+	tests *CheckDataTests,
+	err error,
+	reconciliationFailures int64,
+	assertionCatalog *AssertionCatalog,
+	negativeRequests []*NegativeRequestResult,
+	historicalBalances *HistoricalBalanceResults,
+	blockPayloads *BlockPayloadStats,
+) *CheckDataTestDetails {
+	details := &CheckDataTestDetails{}
+	if !tests.RequestResponse {
+		details.RequestResponse = err.Error()
+	}
+
+	if !tests.ResponseAssertion {
+		if !ResponseAssertionTest(err) {
+			details.ResponseAssertion = err.Error()
+		} else if findings := assertionCatalog.Findings(); findings != nil {
+			failures := int64(0)
+			for _, count := range findings.RuleCounts {
+				failures += count
+			}
+
+			details.ResponseAssertion = fmt.Sprintf("%d responses failed assertion", failures)
+			if len(findings.Findings) > 0 {
+				details.ResponseAssertion = fmt.Sprintf(
+					"%s (first: %s)",
+					details.ResponseAssertion,
+					findings.Findings[0].Message,
+				)
+			}
+		}
+	}
+
+	if failed(tests.BlockSyncing) {
+		details.BlockSyncing = err.Error()
+	}
+
+	if failed(tests.BalanceTracking) {
+		details.BalanceTracking = err.Error()
+	}
+
+	if failed(tests.CoinTracking) {
+		details.CoinTracking = err.Error()
+	}
+
+	if failed(tests.Reconciliation) {
+		// With a reconciliation failure budget, failures do
+		// not stop the run with an error.
+		if err != nil {
+			details.Reconciliation = err.Error()
+		} else {
+			details.Reconciliation = "reconciliation failures were tolerated by the failure budget"
+		}
+		if reconciliationFailures > 0 {
+			details.Reconciliation = fmt.Sprintf(
+				"%s (reconciliation failures: %d)",
+				details.Reconciliation,
+				reconciliationFailures,
+			)
+		}
+	}
+
+	if failed(tests.NegativeRequest) {
+		rejected := 0
+		var firstFailure *NegativeRequestResult
+		for _, result := range negativeRequests {
+			if result.Passed {
+				rejected++
+				continue
+			}
+
+			if firstFailure == nil {
+				firstFailure = result
+			}
+		}
+
+		details.NegativeRequest = fmt.Sprintf(
+			"%d of %d requests for invalid blocks were not rejected (first: %s %s)",
+			len(negativeRequests)-rejected,
+			len(negativeRequests),
+			types.PrintStruct(firstFailure.BlockIdentifier),
+			firstFailure.Detail,
+		)
+	}
+
+	if failed(tests.HistoricalBalanceTracking) {
+		first := historicalBalances.Findings[0]
+		details.HistoricalBalanceTracking = fmt.Sprintf(
+			"%d of %d historical balances did not match computed balances (first: %s at %d, computed: %s%s, live: %s%s)", // nolint:lll
+			historicalBalances.Mismatches,
+			historicalBalances.Checks,
+			first.Account.Address,
+			first.Block.Index,
+			first.ComputedBalance,
+			first.Currency.Symbol,
+			first.LiveBalance,
+			first.Currency.Symbol,
+		)
+	}
+
+	if failed(tests.TransferSymmetry) {
+		details.TransferSymmetry = err.Error()
+	}
+
+	if failed(tests.BlockPayloadSize) {
+		details.BlockPayloadSize = blockPayloadViolations(blockPayloads)
+	}
+
+	if *details == (CheckDataTestDetails{}) {
+		return nil
+	}
+
+	return details
+}
+
 // ComputeCheckDataTests returns a populated CheckDataTests.
 func ComputeCheckDataTests(
 	ctx context.Context,
 	cfg *configuration.Configuration,
 	err error,
 	counterStorage *storage.CounterStorage,
+	assertionCatalog *AssertionCatalog,
+	negativeRequests []*NegativeRequestResult,
+	historicalBalances *HistoricalBalanceResults,
+	blockPayloads *BlockPayloadStats,
 ) *CheckDataTests {
 	operationsSeen := false
+	coinsSeen := false
 	reconciliationsPerformed := false
+	reconciliationFailures := int64(0)
+	transactionsChecked := false
 	blocksSynced := false
 	if counterStorage != nil {
 		blocks, err := counterStorage.Get(ctx, storage.BlockCounter)
@@ -490,11 +2237,24 @@ func ComputeCheckDataTests(
 			blocksSynced = true
 		}
 
-		ops, err := counterStorage.Get(ctx, storage.OperationCounter)
+		// If only some currencies are tracked, operations
+		// only count as seen if they changed the balance
+		// of a tracked currency.
+		opsCounter := storage.OperationCounter
+		if len(cfg.Data.Currencies) > 0 {
+			opsCounter = TrackedBalanceChangeCounter
+		}
+
+		ops, err := counterStorage.Get(ctx, opsCounter)
 		if err == nil && ops.Int64() > 0 {
 			operationsSeen = true
 		}
 
+		coins, err := counterStorage.Get(ctx, CoinCounter)
+		if err == nil && coins.Int64() > 0 {
+			coinsSeen = true
+		}
+
 		activeReconciliations, err := counterStorage.Get(ctx, storage.ActiveReconciliationCounter)
 		if err == nil && activeReconciliations.Int64() > 0 {
 			reconciliationsPerformed = true
@@ -507,34 +2267,167 @@ func ComputeCheckDataTests(
 		if err == nil && inactiveReconciliations.Int64() > 0 {
 			reconciliationsPerformed = true
 		}
+
+		failures, err := counterStorage.Get(ctx, ReconciliationFailureCounter)
+		if err == nil {
+			reconciliationFailures = failures.Int64()
+		}
+
+		checked, err := counterStorage.Get(ctx, TransferSymmetryCheckedCounter)
+		if err == nil && checked.Int64() > 0 {
+			transactionsChecked = true
+		}
 	}
 
-	return &CheckDataTests{
+	tests := &CheckDataTests{
 		RequestResponse:   RequestResponseTest(err),
-		ResponseAssertion: ResponseAssertionTest(err),
+		ResponseAssertion: ResponseAssertionTest(err) && assertionCatalog.Empty(),
 		BlockSyncing:      BlockSyncingTest(err, blocksSynced),
 		BalanceTracking:   BalanceTrackingTest(cfg, err, operationsSeen),
+		CoinTracking:      CoinTrackingTest(cfg, err, coinsSeen),
 		Reconciliation:    ReconciliationTest(cfg, err, reconciliationsPerformed),
+		NegativeRequest:   NegativeRequestTest(negativeRequests),
+		Skipped:           SkippedTests(cfg),
+
+		HistoricalBalanceTracking: HistoricalBalanceTest(historicalBalances),
+		TransferSymmetry:          TransferSymmetryTest(cfg, err, transactionsChecked),
+		BlockPayloadSize:          BlockPayloadSizeTest(cfg, blockPayloads),
 	}
+
+	// Failures tolerated by the reconciliation failure
+	// budget do not stop the run with an error, but they
+	// still fail the reconciliation test.
+	if failureBudget(cfg) && reconciliationFailures > 0 {
+		reconciliationPass := false
+		tests.Reconciliation = &reconciliationPass
+	}
+
+	tests.Details = ComputeCheckDataTestDetails(
+		tests,
+		err,
+		reconciliationFailures,
+		assertionCatalog,
+		negativeRequests,
+		historicalBalances,
+		blockPayloads,
+	)
+
+	return tests
+}
+
+// CheckDataInputs are everything (other than the configuration
+// and the error that ended the run) that the results of a
+// check:data run are computed from. Any field that was not
+// collected during the run may be omitted.
+type CheckDataInputs struct {
+	CounterStorage         *storage.CounterStorage
+	BalanceStorage         *storage.BalanceStorage
+	NetworkAsserter        *asserter.Asserter
+	AssertionCatalog       *AssertionCatalog
+	NegativeRequests       []*NegativeRequestResult
+	CacheProbe             *CacheProbeResults
+	ConsistencyProbe       *ConsistencyProbeResults
+	HistoricalBalances     *HistoricalBalanceResults
+	EndpointParity         *EndpointParityResults
+	StorageStats           *StorageStats
+	AccountTags            TagReconciliationResults
+	EndpointLatency        map[string]*EndpointStats
+	BlockPayloads          *BlockPayloadStats
+	Fees                   *FeeStats
+	TransferPairs          *TransferPairStats
+	TransferAsymmetries    []*TransferAsymmetry
+	EventSinks             []*EventSinkResults
+	ReconciliationFailures []*ReconciliationFailure
+	ErrorPolicy            *ErrorPolicyResults
+
+	EndCondition       configuration.CheckDataEndCondition
+	EndConditionDetail string
+	StartedAt          time.Time
+
+	// Iteration is the iteration of the run recorded in
+	// the results history (when running with --watch). If
+	// it is not populated, the run is iteration 1.
+	Iteration int
 }
 
-// ComputeCheckDataResults returns a populated CheckDataResults.
+// ComputeCheckDataResults returns a populated CheckDataResults
+// for a run that ended at endedAt. If inputs is nil, the
+// results are computed as if nothing was collected.
 func ComputeCheckDataResults(
 	cfg *configuration.Configuration,
 	err error,
-	counterStorage *storage.CounterStorage,
-	balanceStorage *storage.BalanceStorage,
-	endCondition configuration.CheckDataEndCondition,
-	endConditionDetail string,
+	inputs *CheckDataInputs,
+	endedAt time.Time,
 ) *CheckDataResults {
+	if inputs == nil {
+		inputs = &CheckDataInputs{}
+	}
+
 	ctx := context.Background()
-	tests := ComputeCheckDataTests(ctx, cfg, err, counterStorage)
-	stats := ComputeCheckDataStats(ctx, counterStorage, balanceStorage)
+	tests := ComputeCheckDataTests(
+		ctx,
+		cfg,
+		err,
+		inputs.CounterStorage,
+		inputs.AssertionCatalog,
+		inputs.NegativeRequests,
+		inputs.HistoricalBalances,
+		inputs.BlockPayloads,
+	)
+	stats := ComputeCheckDataStats(
+		ctx,
+		cfg,
+		inputs.CounterStorage,
+		inputs.BalanceStorage,
+		inputs.NetworkAsserter,
+	)
 	results := &CheckDataResults{
-		Tests: tests,
-		Stats: stats,
+		Tests:                  tests,
+		Stats:                  stats,
+		AssertionFindings:      inputs.AssertionCatalog.Findings(),
+		NegativeRequests:       inputs.NegativeRequests,
+		CacheProbe:             inputs.CacheProbe,
+		ConsistencyProbe:       inputs.ConsistencyProbe,
+		HistoricalBalances:     inputs.HistoricalBalances,
+		EndpointParity:         inputs.EndpointParity,
+		StorageStats:           inputs.StorageStats,
+		AccountTags:            inputs.AccountTags,
+		EventSinks:             inputs.EventSinks,
+		ReconciliationFailures: inputs.ReconciliationFailures,
+		TransferAsymmetries:    inputs.TransferAsymmetries,
+		ErrorPolicy:            inputs.ErrorPolicy,
+		Network:                cfg.Network,
+		RunTiming:              NewRunTiming(inputs.StartedAt, endedAt),
+
+		InactiveReconciliationSeed: cfg.Data.InactiveReconciliationSeed,
+	}
+
+	if inputs.StorageStats != nil && stats != nil {
+		stats.StorageDataSizeBytes = inputs.StorageStats.DataSizeBytes()
+		stats.BloomFilterHitRate = inputs.StorageStats.BloomFilterHitRate
 	}
 
+	if stats != nil {
+		stats.EndpointLatency = inputs.EndpointLatency
+		stats.BlockPayloads = inputs.BlockPayloads
+		stats.Fees = inputs.Fees
+		stats.TransferPairs = inputs.TransferPairs
+	}
+
+	if cfg.Data.IncludeConfiguration {
+		sanitized, sanitizeErr := configuration.SanitizeConfiguration(cfg)
+		if sanitizeErr != nil {
+			logging.Warn(
+				"unable to include configuration in results",
+				logging.Fields{"error": sanitizeErr},
+			)
+		}
+
+		results.Configuration = sanitized
+	}
+
+	results.Recommendations = ComputeRecommendations(cfg, results)
+
 	if err != nil {
 		results.Error = err.Error()
 
@@ -545,7 +2438,12 @@ func ComputeCheckDataResults(
 			tests.ResponseAssertion &&
 			(tests.BlockSyncing == nil || *tests.BlockSyncing) &&
 			(tests.BalanceTracking == nil || *tests.BalanceTracking) &&
-			(tests.Reconciliation == nil || *tests.Reconciliation) {
+			(tests.CoinTracking == nil || *tests.CoinTracking) &&
+			(tests.Reconciliation == nil || *tests.Reconciliation) &&
+			(tests.NegativeRequest == nil || *tests.NegativeRequest) &&
+			(tests.HistoricalBalanceTracking == nil || *tests.HistoricalBalanceTracking) &&
+			(tests.TransferSymmetry == nil || *tests.TransferSymmetry) &&
+			(tests.BlockPayloadSize == nil || *tests.BlockPayloadSize) {
 			results.Tests = nil
 		}
 
@@ -554,38 +2452,289 @@ func ComputeCheckDataResults(
 		return results
 	}
 
-	if len(endCondition) > 0 {
+	if len(inputs.EndCondition) > 0 {
 		results.EndCondition = &EndCondition{
-			Type:   endCondition,
-			Detail: endConditionDetail,
+			Type:   inputs.EndCondition,
+			Detail: inputs.EndConditionDetail,
 		}
 	}
 
 	return results
 }
 
-// ExitData exits check:data, logs the test results to the console,
-// and to a provided output path.
+// Exit codes returned by ExitCode. Codes are stable so that
+// callers (ex: CI) can branch on which test failed.
+const (
+	// ExitCodeSuccess is used when no test failed.
+	ExitCodeSuccess = 0
+
+	// ExitCodeRequestResponse is used when the request/response
+	// test failed. It is also used when check:data fails with an
+	// error that is not attributed to any test (the same exit
+	// code as any other failed command).
+	ExitCodeRequestResponse = 1
+
+	// ExitCodeResponseAssertion is used when the response
+	// assertion test failed.
+	ExitCodeResponseAssertion = 2
+
+	// ExitCodeBlockSyncing is used when the block syncing
+	// test failed.
+	ExitCodeBlockSyncing = 3
+
+	// ExitCodeBalanceTracking is used when the balance
+	// tracking test failed.
+	ExitCodeBalanceTracking = 4
+
+	// ExitCodeReconciliation is used when the reconciliation
+	// test failed.
+	ExitCodeReconciliation = 5
+
+	// ExitCodeCoinTracking is used when the coin tracking
+	// test failed.
+	ExitCodeCoinTracking = 6
+
+	// ExitCodeNegativeRequest is used when the negative
+	// request test failed.
+	ExitCodeNegativeRequest = 7
+
+	// ExitCodeHistoricalBalanceTracking is used when the
+	// historical balance tracking test failed.
+	ExitCodeHistoricalBalanceTracking = 8
+
+	// ExitCodeTransferSymmetry is used when the transfer
+	// symmetry test failed.
+	ExitCodeTransferSymmetry = 9
+
+	// ExitCodeBlockPayloadSize is used when the block
+	// payload size test failed.
+	ExitCodeBlockPayloadSize = 10
+)
+
+// ExitCode returns the exit code of the earliest failed test
+// in *CheckDataResults (in the order tests are run: request/response,
+// response assertion, block syncing, balance tracking, coin tracking,
+// reconciliation, negative request, historical balance tracking,
+// transfer symmetry, and block payload size).
+// If no test failed but check:data exited with an error,
+// ExitCodeRequestResponse is returned.
+func ExitCode(results *CheckDataResults) int {
+	if results == nil {
+		return ExitCodeSuccess
+	}
+
+	if _, exitCode := failedTest(results.Tests); exitCode != ExitCodeSuccess {
+		return exitCode
+	}
+
+	if len(results.Error) > 0 {
+		return ExitCodeRequestResponse
+	}
+
+	return ExitCodeSuccess
+}
+
+// failedTest returns the JSON name and exit code of
+// the earliest failed test in tests (in the order tests
+// are run). If no test failed, "" and ExitCodeSuccess
+// are returned.
+func failedTest(tests *CheckDataTests) (string, int) {
+	if tests == nil {
+		return "", ExitCodeSuccess
+	}
+
+	switch {
+	case !tests.RequestResponse:
+		return "request_response", ExitCodeRequestResponse
+	case !tests.ResponseAssertion:
+		return "response_assertion", ExitCodeResponseAssertion
+	case failed(tests.BlockSyncing):
+		return "block_syncing", ExitCodeBlockSyncing
+	case failed(tests.BalanceTracking):
+		return "balance_tracking", ExitCodeBalanceTracking
+	case failed(tests.CoinTracking):
+		return "coin_tracking", ExitCodeCoinTracking
+	case failed(tests.Reconciliation):
+		return "reconciliation", ExitCodeReconciliation
+	case failed(tests.NegativeRequest):
+		return "negative_request", ExitCodeNegativeRequest
+	case failed(tests.HistoricalBalanceTracking):
+		return "historical_balance_tracking", ExitCodeHistoricalBalanceTracking
+	case failed(tests.TransferSymmetry):
+		return "transfer_symmetry", ExitCodeTransferSymmetry
+	case failed(tests.BlockPayloadSize):
+		return "block_payload_size", ExitCodeBlockPayloadSize
+	default:
+		return "", ExitCodeSuccess
+	}
+}
+
+// ExitCodeError is returned by ExitData when check:data
+// fails so that the process can exit with Code (see
+// ExitCode).
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the error message of the wrapped error.
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// ExitData exits check:data, logs the test results (and the time
+// elapsed since inputs.StartedAt) to the console, and to a provided
+// output path. If any assertion failures were cataloged (with
+// assertion_soft_fail enabled), an error is returned even if
+// check:data reached an end condition. Any error returned is
+// an *ExitCodeError.
 func ExitData(
 	config *configuration.Configuration,
-	counterStorage *storage.CounterStorage,
-	balanceStorage *storage.BalanceStorage,
 	err error,
-	endCondition configuration.CheckDataEndCondition,
-	endConditionDetail string,
+	inputs *CheckDataInputs,
 ) error {
-	results := ComputeCheckDataResults(
-		config,
-		err,
-		counterStorage,
-		balanceStorage,
-		endCondition,
-		endConditionDetail,
-	)
+	if inputs == nil {
+		inputs = &CheckDataInputs{}
+	}
+
+	// Block payload violations fail the run before the
+	// results are computed so that the saved results
+	// include the error and the failed test.
+	if err == nil && failed(BlockPayloadSizeTest(config, inputs.BlockPayloads)) {
+		err = fmt.Errorf(
+			"%w: %s",
+			ErrBlockPayloadViolations,
+			blockPayloadViolations(inputs.BlockPayloads),
+		)
+	}
+
+	results := ComputeCheckDataResults(config, err, inputs, time.Now())
+
+	var outputErr, webhookErr error
+	if results != nil {
+		// The results table is not printed when results
+		// are written to stdout so that stdout can be parsed.
+		// In quiet mode, results are written to stdout as
+		// JSON instead of printing the results table.
+		toStdout := writesToStdout(config.Data.ResultsOutputFile, config.Data.ResultsOutputFormat)
+		switch {
+		case config.Data.Quiet && !toStdout:
+			if err := writeNDJSON("", results); err != nil {
+				logging.Error("unable to write results", logging.Fields{"error": err})
+			}
+		case !toStdout:
+			results.Print()
+		}
+
+		if len(config.Data.LogResultsTo) > 0 {
+			if err := logResults(config.Data.LogResultsTo, results.Fprint); err != nil {
+				logging.Warn("unable to log results", logging.Fields{"error": err})
+			}
+		}
+
+		// Partial results are only removed once
+		// the final results are saved everywhere.
+		path := config.Data.ResultsOutputFile
+		outputErr = results.OutputAll(
+			append([]string{path}, config.Data.ResultsOutputFiles...),
+			config.Data.ResultsOutputFormat,
+			time.Duration(config.Data.ResultsSinkTimeout)*time.Second,
+		)
+		if csvErr := results.OutputCSV(config.Data.ResultsOutputCSV); csvErr != nil {
+			if outputErr == nil {
+				outputErr = csvErr
+			} else {
+				outputErr = fmt.Errorf("%w (%s)", outputErr, csvErr.Error())
+			}
+		}
+		if outputErr != nil {
+			logging.Error("unable to save results", logging.Fields{"error": outputErr})
+		} else if len(path) > 0 {
+			removePartialResults(path)
+		}
+
+		if webhookErr = outputWebhook(config, results); webhookErr != nil {
+			logging.Error("unable to POST results to webhook", logging.Fields{"error": webhookErr})
+		}
+	}
+
+	if err == nil && !inputs.AssertionCatalog.Empty() {
+		err = ErrAssertionFindings
+	}
+
+	// Reconciliation failures tolerated by the failure
+	// budget still fail the run.
+	if err == nil && results != nil && results.Tests != nil &&
+		failed(results.Tests.Reconciliation) {
+		err = fmt.Errorf(
+			"%w: %s",
+			ErrReconciliationFailure,
+			results.Tests.Details.Reconciliation,
+		)
+	}
+
+	// Unless disabled, failing to save the results fails
+	// the run. If the run already failed, the output error
+	// is included with the error that ended the run.
+	if outputErr != nil &&
+		(config.Data.FailOnOutputError == nil || *config.Data.FailOnOutputError) {
+		if err == nil {
+			err = fmt.Errorf("%w: unable to save results", outputErr)
+		} else {
+			err = fmt.Errorf("%w (unable to save results: %s)", err, outputErr.Error())
+		}
+	}
+
+	// Failing to POST results to the results webhook
+	// only fails the run if the webhook is required.
+	if webhookErr != nil && config.Data.ResultsWebhookRequired {
+		if err == nil {
+			err = fmt.Errorf("%w: unable to POST results to webhook", webhookErr)
+		} else {
+			err = fmt.Errorf("%w (unable to POST results to webhook: %s)", err, webhookErr.Error())
+		}
+	}
+
+	// The summary is always printed last (with the
+	// error that failed the run, if there was one).
 	if results != nil {
-		results.Print()
-		results.Output(config.Data.ResultsOutputFile)
+		if err != nil && len(results.Error) == 0 {
+			results.Error = err.Error()
+		}
+
+		if len(config.Data.ResultsHistoryFile) > 0 {
+			iteration := inputs.Iteration
+			if iteration == 0 {
+				iteration = 1
+			}
+
+			entry := NewCheckDataHistoryEntry(iteration, results, time.Now())
+			if err := AppendHistory(config.Data.ResultsHistoryFile, entry); err != nil {
+				logging.Warn("unable to append results to history", logging.Fields{"error": err})
+			}
+		}
+
+		fmt.Fprintln(color.Output, results.Summary())
+		notifyCompletion(
+			config.Data.CompletionWebhook,
+			config.Data.CompletionWebhookTimeout,
+			NewCompletionPayload("check:data", config.Network, results.Summary(), results),
+		)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	exitCode := ExitCode(results)
+	if exitCode == ExitCodeSuccess {
+		exitCode = ExitCodeRequestResponse
 	}
 
-	return err
+	return &ExitCodeError{Code: exitCode, Err: err}
 }
@@ -0,0 +1,297 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	// ErrNotCheckDataResults is returned when a results
+	// file does not contain check:data results.
+	ErrNotCheckDataResults = errors.New("results file does not contain check:data results")
+
+	// ErrTestRegressed is returned when a test that did
+	// not fail in the old results fails in the new results.
+	ErrTestRegressed = errors.New("test regressed")
+)
+
+// TestTransition is the status of a check:data
+// test in two runs. Regressed is true if the
+// test failed in the new run but not the old.
+type TestTransition struct {
+	Test      string `json:"test"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+	Regressed bool   `json:"regressed"`
+}
+
+// StatDelta is the value of a check:data
+// stat in two runs and the change between them.
+type StatDelta struct {
+	Stat  string  `json:"stat"`
+	Old   float64 `json:"old"`
+	New   float64 `json:"new"`
+	Delta float64 `json:"delta"`
+}
+
+// CheckDataDiff compares the results of two check:data runs.
+type CheckDataDiff struct {
+	Tests               []*TestTransition `json:"tests"`
+	Stats               []*StatDelta      `json:"stats"`
+	OldEndCondition     *EndCondition     `json:"old_end_condition"`
+	NewEndCondition     *EndCondition     `json:"new_end_condition"`
+	EndConditionChanged bool              `json:"end_condition_changed"`
+}
+
+// testStatuses returns the status of each test in
+// tests (in the order they are printed). If tests
+// is nil, every test is not tested.
+func testStatuses(tests *CheckDataTests) [][2]string {
+	if tests == nil {
+		return [][2]string{
+			{"Request/Response", convertBool(nil)},
+			{"Response Assertion", convertBool(nil)},
+			{"Block Syncing", convertBool(nil)},
+			{"Balance Tracking", convertBool(nil)},
+			{"Coin Tracking", convertBool(nil)},
+			{"Reconciliation", convertBool(nil)},
+			{"Negative Request", convertBool(nil)},
+			{"Historical Balance Tracking", convertBool(nil)},
+			{"Transfer Symmetry", convertBool(nil)},
+			{"Block Payload Size", convertBool(nil)},
+		}
+	}
+
+	return [][2]string{
+		{"Request/Response", convertBool(&tests.RequestResponse)},
+		{"Response Assertion", convertBool(&tests.ResponseAssertion)},
+		{"Block Syncing", convertBool(tests.BlockSyncing)},
+		{"Balance Tracking", string(tests.Status(BalanceTrackingTestName, tests.BalanceTracking))},
+		{"Coin Tracking", string(tests.Status(CoinTrackingTestName, tests.CoinTracking))},
+		{"Reconciliation", string(tests.Status(ReconciliationTestName, tests.Reconciliation))},
+		{"Negative Request", string(tests.Status(NegativeRequestTestName, tests.NegativeRequest))},
+		{"Historical Balance Tracking", convertBool(tests.HistoricalBalanceTracking)},
+		{"Transfer Symmetry", convertBool(tests.TransferSymmetry)},
+		{"Block Payload Size", convertBool(tests.BlockPayloadSize)},
+	}
+}
+
+// diffedStats are the check:data stats compared
+// by DiffCheckDataResults (in the order they are printed).
+var diffedStats = []struct {
+	name  string
+	value func(*CheckDataStats) float64
+}{
+	{"Blocks", func(s *CheckDataStats) float64 { return float64(s.Blocks) }},
+	{"Orphans", func(s *CheckDataStats) float64 { return float64(s.Orphans) }},
+	{"Transactions", func(s *CheckDataStats) float64 { return float64(s.Transactions) }},
+	{"Operations", func(s *CheckDataStats) float64 { return float64(s.Operations) }},
+	{"Orphan Rate", func(s *CheckDataStats) float64 { return s.OrphanRate }},
+	{"Orphaned Transactions", func(s *CheckDataStats) float64 { return float64(s.OrphanedTransactions) }},
+	{"Orphaned Operations", func(s *CheckDataStats) float64 { return float64(s.OrphanedOperations) }},
+	{"Reversed Coins", func(s *CheckDataStats) float64 { return float64(s.ReversedCoins) }},
+	{"Active Reconciliations", func(s *CheckDataStats) float64 { return float64(s.ActiveReconciliations) }},
+	{"Inactive Reconciliations", func(s *CheckDataStats) float64 { return float64(s.InactiveReconciliations) }},
+	{"Reconciliation Failures", func(s *CheckDataStats) float64 { return float64(s.ReconciliationFailures) }},
+	{"Deferred Reconciliations", func(s *CheckDataStats) float64 { return float64(s.DeferredReconciliations) }},
+	{"Reconciliations Enqueued", func(s *CheckDataStats) float64 { return float64(s.ReconciliationsEnqueued) }},
+	{"Reconciliations Processed", func(s *CheckDataStats) float64 { return float64(s.ReconciliationsProcessed) }},
+	{"Skipped Reconciliations (Backlog)", func(s *CheckDataStats) float64 {
+		return float64(s.ReconciliationsSkippedBacklog)
+	}},
+	{"Skipped Reconciliations (Pruned)", func(s *CheckDataStats) float64 {
+		return float64(s.ReconciliationsSkippedPruned)
+	}},
+	{"Superseded Reconciliations", func(s *CheckDataStats) float64 {
+		return float64(s.ReconciliationsSuperseded)
+	}},
+	{"Reconciliation Coverage", func(s *CheckDataStats) float64 { return s.ReconciliationCoverage }},
+	{"Accounts Tracked", func(s *CheckDataStats) float64 { return float64(s.AccountsTracked) }},
+	{"Currencies Seen", func(s *CheckDataStats) float64 { return float64(s.CurrenciesSeen) }},
+	{"Fetch Retries", func(s *CheckDataStats) float64 { return float64(s.FetchRetries) }},
+	{"Fetch Failures", func(s *CheckDataStats) float64 { return float64(s.FetchFailures) }},
+	{"Transfer Symmetry Checked", func(s *CheckDataStats) float64 {
+		return float64(s.TransferSymmetryChecked)
+	}},
+	{"Transfer Symmetry Violations", func(s *CheckDataStats) float64 {
+		return float64(s.TransferSymmetryViolations)
+	}},
+	{"Storage Size", func(s *CheckDataStats) float64 { return float64(s.StorageSizeBytes) }},
+	{"Peak Memory", func(s *CheckDataStats) float64 { return float64(s.PeakMemoryBytes) }},
+}
+
+// diffStats returns a StatDelta for each compared stat.
+// Missing stats are treated as 0.
+func diffStats(oldStats *CheckDataStats, newStats *CheckDataStats) []*StatDelta {
+	if oldStats == nil {
+		oldStats = &CheckDataStats{}
+	}
+	if newStats == nil {
+		newStats = &CheckDataStats{}
+	}
+
+	deltas := make([]*StatDelta, len(diffedStats))
+	for i, stat := range diffedStats {
+		oldValue, newValue := stat.value(oldStats), stat.value(newStats)
+		deltas[i] = &StatDelta{
+			Stat:  stat.name,
+			Old:   oldValue,
+			New:   newValue,
+			Delta: newValue - oldValue,
+		}
+	}
+
+	return deltas
+}
+
+// endConditionsEqual returns a boolean indicating
+// if a and b are the same end condition.
+func endConditionsEqual(a *EndCondition, b *EndCondition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Type == b.Type && a.Detail == b.Detail
+}
+
+// DiffCheckDataResults compares the results of
+// two check:data runs.
+func DiffCheckDataResults(oldResults *CheckDataResults, newResults *CheckDataResults) *CheckDataDiff {
+	diff := &CheckDataDiff{
+		Stats:               diffStats(oldResults.Stats, newResults.Stats),
+		OldEndCondition:     oldResults.EndCondition,
+		NewEndCondition:     newResults.EndCondition,
+		EndConditionChanged: !endConditionsEqual(oldResults.EndCondition, newResults.EndCondition),
+	}
+
+	oldStatuses := testStatuses(oldResults.Tests)
+	newStatuses := testStatuses(newResults.Tests)
+	for i, oldStatus := range oldStatuses {
+		newStatus := newStatuses[i]
+		diff.Tests = append(diff.Tests, &TestTransition{
+			Test:      oldStatus[0],
+			Old:       oldStatus[1],
+			New:       newStatus[1],
+			Regressed: newStatus[1] == string(TestFailed) && oldStatus[1] != string(TestFailed),
+		})
+	}
+
+	return diff
+}
+
+// Regressed returns an error wrapping ErrTestRegressed if
+// any test regressed (stats that differ are not an error).
+func (d *CheckDataDiff) Regressed() error {
+	regressed := []string{}
+	for _, transition := range d.Tests {
+		if transition.Regressed {
+			regressed = append(regressed, transition.Test)
+		}
+	}
+
+	if len(regressed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %v", ErrTestRegressed, regressed)
+}
+
+// describeEndCondition returns a description of
+// endCondition for printing.
+func describeEndCondition(endCondition *EndCondition) string {
+	if endCondition == nil {
+		return "none"
+	}
+
+	return fmt.Sprintf("%s [%s]", endCondition.Type, endCondition.Detail)
+}
+
+// Print logs CheckDataDiff to the console.
+func (d *CheckDataDiff) Print() {
+	d.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataDiff to w. Regressed tests
+// are highlighted in red and fixed tests in green.
+func (d *CheckDataDiff) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Tests", "Old", "New"})
+	for _, transition := range d.Tests {
+		row := []string{transition.Test, transition.Old, transition.New}
+		switch {
+		case transition.Regressed:
+			appendRow(table, row, []tablewriter.Colors{
+				{tablewriter.FgRedColor},
+				{},
+				{tablewriter.Bold, tablewriter.FgRedColor},
+			})
+		case transition.Old == string(TestFailed) && transition.New == string(TestPassed):
+			appendRow(table, row, []tablewriter.Colors{
+				{tablewriter.FgGreenColor},
+				{},
+				{tablewriter.Bold, tablewriter.FgGreenColor},
+			})
+		default:
+			table.Append(row)
+		}
+	}
+	table.Render()
+	fmt.Fprintf(w, "\n")
+
+	table = newTable(w, []string{"check:data Stats", "Old", "New", "Delta"})
+	for _, delta := range d.Stats {
+		table.Append([]string{
+			delta.Stat,
+			strconv.FormatFloat(delta.Old, 'f', -1, 64),
+			strconv.FormatFloat(delta.New, 'f', -1, 64),
+			strconv.FormatFloat(delta.Delta, 'f', -1, 64),
+		})
+	}
+	table.Render()
+	fmt.Fprintf(w, "\n")
+
+	if d.EndConditionChanged {
+		fprintColor(
+			w,
+			color.FgYellow,
+			"End condition changed: %s -> %s\n",
+			describeEndCondition(d.OldEndCondition),
+			describeEndCondition(d.NewEndCondition),
+		)
+	} else {
+		fmt.Fprintf(w, "End condition unchanged: %s\n", describeEndCondition(d.NewEndCondition))
+	}
+}
+
+// LoadCheckDataResultsFile loads the check:data
+// results file at filePath (see LoadResultsFile).
+func LoadCheckDataResultsFile(filePath string) (*CheckDataResults, error) {
+	file, err := LoadResultsFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Data == nil {
+		return nil, fmt.Errorf("%w: found %s results", ErrNotCheckDataResults, file.Kind)
+	}
+
+	return file.Data, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCheckDataResults(t *testing.T) {
+	tested := true
+	failed := false
+
+	var tests = map[string]struct {
+		old *CheckDataResults
+		new *CheckDataResults
+
+		transitions         map[string][2]string
+		regressed           []string
+		endConditionChanged bool
+	}{
+		"unchanged": {
+			old: &CheckDataResults{
+				Tests:        &CheckDataTests{RequestResponse: true, BlockSyncing: &tested},
+				Stats:        &CheckDataStats{Blocks: 10},
+				EndCondition: &EndCondition{Type: configuration.IndexEndCondition, Detail: "10"},
+			},
+			new: &CheckDataResults{
+				Tests:        &CheckDataTests{RequestResponse: true, BlockSyncing: &tested},
+				Stats:        &CheckDataStats{Blocks: 20},
+				EndCondition: &EndCondition{Type: configuration.IndexEndCondition, Detail: "10"},
+			},
+			transitions: map[string][2]string{
+				"Request/Response": {"PASSED", "PASSED"},
+				"Block Syncing":    {"PASSED", "PASSED"},
+				"Reconciliation":   {"NOT TESTED", "NOT TESTED"},
+			},
+		},
+		"regressed": {
+			old: &CheckDataResults{
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: true,
+					Reconciliation:    &tested,
+				},
+				Stats: &CheckDataStats{Blocks: 10},
+			},
+			new: &CheckDataResults{
+				Tests: &CheckDataTests{
+					RequestResponse: true,
+					Reconciliation:  &failed,
+					CoinTracking:    &failed,
+				},
+				Stats:        &CheckDataStats{Blocks: 5},
+				EndCondition: &EndCondition{Type: configuration.TipEndCondition, Detail: "5"},
+			},
+			transitions: map[string][2]string{
+				"Response Assertion": {"PASSED", "FAILED"},
+				"Reconciliation":     {"PASSED", "FAILED"},
+				"Coin Tracking":      {"NOT TESTED", "FAILED"},
+			},
+			regressed:           []string{"Response Assertion", "Coin Tracking", "Reconciliation"},
+			endConditionChanged: true,
+		},
+		"fixed": {
+			old: &CheckDataResults{
+				Tests: &CheckDataTests{RequestResponse: true, Reconciliation: &failed},
+			},
+			new: &CheckDataResults{
+				Tests: &CheckDataTests{RequestResponse: true, Reconciliation: &tested},
+			},
+			transitions: map[string][2]string{
+				"Reconciliation": {"FAILED", "PASSED"},
+			},
+		},
+		"missing tests": {
+			old: &CheckDataResults{},
+			new: &CheckDataResults{
+				Tests: &CheckDataTests{RequestResponse: true},
+			},
+			transitions: map[string][2]string{
+				"Request/Response":   {"NOT TESTED", "PASSED"},
+				"Response Assertion": {"NOT TESTED", "FAILED"},
+			},
+			regressed: []string{"Response Assertion"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := DiffCheckDataResults(test.old, test.new)
+			assert.Len(t, diff.Tests, 8)
+			assert.Equal(t, test.endConditionChanged, diff.EndConditionChanged)
+
+			regressed := []string{}
+			for _, transition := range diff.Tests {
+				if expected, ok := test.transitions[transition.Test]; ok {
+					assert.Equal(t, expected[0], transition.Old, transition.Test)
+					assert.Equal(t, expected[1], transition.New, transition.Test)
+				}
+
+				if transition.Regressed {
+					regressed = append(regressed, transition.Test)
+				}
+			}
+
+			if len(test.regressed) == 0 {
+				assert.Empty(t, regressed)
+				assert.NoError(t, diff.Regressed())
+			} else {
+				assert.ElementsMatch(t, test.regressed, regressed)
+				assert.True(t, errors.Is(diff.Regressed(), ErrTestRegressed))
+			}
+
+			// Ensure printing does not panic
+			diff.Print()
+		})
+	}
+}
+
+func TestDiffCheckDataStats(t *testing.T) {
+	diff := DiffCheckDataResults(
+		&CheckDataResults{
+			Stats: &CheckDataStats{
+				Blocks:                 10,
+				Orphans:                2,
+				Operations:             30,
+				ReconciliationCoverage: 0.5,
+			},
+		},
+		&CheckDataResults{
+			Stats: &CheckDataStats{
+				Blocks:                 25,
+				Orphans:                17,
+				Operations:             20,
+				ReconciliationCoverage: 0.75,
+			},
+		},
+	)
+
+	deltas := map[string]*StatDelta{}
+	for _, delta := range diff.Stats {
+		deltas[delta.Stat] = delta
+	}
+
+	assert.Len(t, deltas, len(diffedStats))
+	assert.Equal(t, &StatDelta{Stat: "Blocks", Old: 10, New: 25, Delta: 15}, deltas["Blocks"])
+	assert.Equal(t, &StatDelta{Stat: "Orphans", Old: 2, New: 17, Delta: 15}, deltas["Orphans"])
+	assert.Equal(t, &StatDelta{Stat: "Peak Memory"}, deltas["Peak Memory"])
+	assert.Equal(
+		t,
+		&StatDelta{Stat: "Operations", Old: 30, New: 20, Delta: -10},
+		deltas["Operations"],
+	)
+	assert.Equal(
+		t,
+		&StatDelta{
+			Stat:  "Reconciliation Coverage",
+			Old:   0.5,
+			New:   0.75,
+			Delta: 0.25,
+		},
+		deltas["Reconciliation Coverage"],
+	)
+
+	// Missing stats are treated as 0
+	diff = DiffCheckDataResults(
+		&CheckDataResults{},
+		&CheckDataResults{Stats: &CheckDataStats{Blocks: 5}},
+	)
+	assert.Equal(t, float64(5), diff.Stats[0].Delta)
+}
+
+func TestLoadCheckDataResultsFile(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	dataPath := path.Join(dir, "data.json")
+	assert.NoError(t, writeResults(
+		context.Background(),
+		dataPath,
+		configuration.JSONResultsOutputFormat,
+		&CheckDataResults{
+			Tests: &CheckDataTests{RequestResponse: true},
+			Stats: &CheckDataStats{Blocks: 10},
+		},
+		nil,
+	))
+	dataResults, err := LoadCheckDataResultsFile(dataPath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), dataResults.Stats.Blocks)
+
+	constructionPath := path.Join(dir, "construction.json")
+	assert.NoError(t, writeResults(
+		context.Background(),
+		constructionPath,
+		configuration.JSONResultsOutputFormat,
+		&CheckConstructionResults{
+			EndConditions: map[string]int{"transfer": 1},
+			Stats:         &CheckConstructionStats{},
+		},
+		nil,
+	))
+	dataResults, err = LoadCheckDataResultsFile(constructionPath)
+	assert.Nil(t, dataResults)
+	assert.True(t, errors.Is(err, ErrNotCheckDataResults))
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"sort"
+	"strconv"
+)
+
+// EndpointStats summarizes the latency (in milliseconds) of
+// requests to a Rosetta endpoint (ex: /account/balance). Each
+// retry of a request is counted separately and Errors is the
+// number of requests that failed without a response.
+type EndpointStats struct {
+	Count  int64   `json:"count"`
+	Errors int64   `json:"errors"`
+	Mean   float64 `json:"mean_ms"`
+	P95    float64 `json:"p95_ms"`
+	Max    float64 `json:"max_ms"`
+}
+
+// printEndpointLatency writes the *EndpointStats of
+// each endpoint (sorted by endpoint) to w.
+func printEndpointLatency(w io.Writer, latency map[string]*EndpointStats) {
+	endpoints := make([]string, 0, len(latency))
+	for endpoint := range latency {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	formatMilliseconds := func(milliseconds float64) string {
+		return strconv.FormatFloat(milliseconds, 'f', 2, 64)
+	}
+
+	table := newTable(w, []string{
+		"check:data Endpoint Latency",
+		"Requests",
+		"Errors",
+		"Mean (ms)",
+		"P95 (ms)",
+		"Max (ms)",
+	})
+	for _, endpoint := range endpoints {
+		stats := latency[endpoint]
+		table.Append([]string{
+			endpoint,
+			strconv.FormatInt(stats.Count, 10),
+			strconv.FormatInt(stats.Errors, 10),
+			formatMilliseconds(stats.Mean),
+			formatMilliseconds(stats.P95),
+			formatMilliseconds(stats.Max),
+		})
+	}
+
+	table.Render()
+}
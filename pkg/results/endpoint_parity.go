@@ -0,0 +1,102 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// EndpointParityFinding is a reconciliation where the
+// primary and secondary Rosetta endpoints returned
+// different live balances at the same block.
+type EndpointParityFinding struct {
+	Account  *types.AccountIdentifier `json:"account_identifier"`
+	Currency *types.Currency          `json:"currency"`
+	Block    *types.BlockIdentifier   `json:"block_identifier"`
+
+	// ComputedBalance is empty if the primary live
+	// balance was not reconciled (ex: if the account
+	// was updated after Block).
+	ComputedBalance  string `json:"computed_balance,omitempty"`
+	PrimaryBalance   string `json:"primary_balance"`
+	SecondaryBalance string `json:"secondary_balance"`
+}
+
+// EndpointParityResults contains the outcome of comparing
+// the live balances returned by the primary and secondary
+// Rosetta endpoints while running check:data. Disagreements
+// indicate node divergence (not an issue with the computed
+// balance), so they do not fail the Reconciliation test.
+type EndpointParityResults struct {
+	Checks            int64                    `json:"checks"`
+	Disagreements     int64                    `json:"disagreements"`
+	Skipped           int64                    `json:"skipped"`
+	SecondaryFailures int64                    `json:"secondary_failures"`
+	Findings          []*EndpointParityFinding `json:"findings"`
+}
+
+// Print logs EndpointParityResults to the console.
+func (e *EndpointParityResults) Print() {
+	e.Fprint(color.Output)
+}
+
+// Fprint writes EndpointParityResults to w.
+func (e *EndpointParityResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Endpoint Parity", "Description", "Value"})
+	table.Append([]string{
+		"Checks",
+		"# of live balances compared between the primary and secondary endpoints",
+		strconv.FormatInt(e.Checks, 10),
+	})
+	table.Append([]string{
+		"Disagreements",
+		"# of live balances that differed between the primary and secondary endpoints",
+		strconv.FormatInt(e.Disagreements, 10),
+	})
+	table.Append([]string{
+		"Skipped",
+		"# of live balances the endpoints returned at different blocks",
+		strconv.FormatInt(e.Skipped, 10),
+	})
+	table.Append([]string{
+		"Secondary Failures",
+		"# of live balances that could not be fetched from the secondary endpoint",
+		strconv.FormatInt(e.SecondaryFailures, 10),
+	})
+
+	table.Render()
+
+	if len(e.Findings) == 0 {
+		return
+	}
+
+	findings := newTable(w, []string{"Account", "Currency", "Block", "Computed", "Primary", "Secondary"})
+	for _, finding := range e.Findings {
+		findings.Append([]string{
+			finding.Account.Address,
+			finding.Currency.Symbol,
+			strconv.FormatInt(finding.Block.Index, 10),
+			finding.ComputedBalance,
+			finding.PrimaryBalance,
+			finding.SecondaryBalance,
+		})
+	}
+
+	findings.Render()
+}
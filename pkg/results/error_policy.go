@@ -0,0 +1,212 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+)
+
+const (
+	// errorPolicyEventLimit is the number of continued
+	// errors saved in the results (all continued errors
+	// are counted).
+	errorPolicyEventLimit = 100
+)
+
+// ClassifyError returns the configuration.ErrorCategory
+// of err (or "" if err does not belong to a category).
+func ClassifyError(err error) configuration.ErrorCategory {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+
+	if errors.Is(err, ErrReconciliationFailure) {
+		return configuration.ReconciliationErrorCategory
+	}
+
+	for _, balanceStorageErr := range storage.BalanceStorageErrs {
+		if errors.Is(err, balanceStorageErr) {
+			return configuration.NegativeBalanceErrorCategory
+		}
+	}
+
+	if !ResponseAssertionTest(err) {
+		return configuration.AssertionErrorCategory
+	}
+
+	if !RequestResponseTest(err) {
+		return configuration.FetchErrorCategory
+	}
+
+	if storageFailed, _ := storage.Err(err); storageFailed || syncer.Err(err) || coinStorageErr(err) {
+		return configuration.StorageErrorCategory
+	}
+
+	return ""
+}
+
+// ErrorPolicyEvent is an error that was retried or
+// recorded (instead of aborting check:data) because
+// of the error policy.
+type ErrorPolicyEvent struct {
+	Category  configuration.ErrorCategory `json:"category"`
+	Action    configuration.ErrorAction   `json:"action"`
+	Error     string                      `json:"error"`
+	Timestamp string                      `json:"timestamp"`
+}
+
+// ErrorPolicyResults echoes the error policy in effect
+// (with every category, so that a strict run can be
+// proven) and counts the errors continued in each category.
+// ReachedTip indicates if the policy was ever applied
+// (before tip, every error aborts check:data). Events
+// are the first continued errors.
+type ErrorPolicyResults struct {
+	Policy     map[configuration.ErrorCategory]configuration.ErrorAction `json:"policy"`
+	ReachedTip bool                                                      `json:"reached_tip"`
+	Counts     map[configuration.ErrorCategory]int64                     `json:"counts"`
+	Events     []*ErrorPolicyEvent                                       `json:"events,omitempty"`
+}
+
+// printErrorPolicy writes the counts of *ErrorPolicyResults to w.
+func printErrorPolicy(w io.Writer, results *ErrorPolicyResults) {
+	table := newTable(w, []string{"check:data Error Policy", "Action", "Continued Errors"})
+	for _, category := range configuration.ErrorCategories {
+		table.Append([]string{
+			string(category),
+			string(results.Policy[category]),
+			strconv.FormatInt(results.Counts[category], 10),
+		})
+	}
+	table.Render()
+}
+
+// ErrorPolicyRecorder applies the error policy of a
+// check:data run and records every error it continues.
+// All methods may be called on a nil *ErrorPolicyRecorder
+// (in which case every error aborts).
+type ErrorPolicyRecorder struct {
+	policy     map[configuration.ErrorCategory]configuration.ErrorAction
+	reachedTip bool
+	counts     map[configuration.ErrorCategory]int64
+	events     []*ErrorPolicyEvent
+	mutex      sync.Mutex
+}
+
+// NewErrorPolicyRecorder returns a new *ErrorPolicyRecorder
+// for policy. Categories not in policy abort.
+func NewErrorPolicyRecorder(
+	policy map[configuration.ErrorCategory]configuration.ErrorAction,
+) *ErrorPolicyRecorder {
+	resolved := make(
+		map[configuration.ErrorCategory]configuration.ErrorAction,
+		len(configuration.ErrorCategories),
+	)
+	for _, category := range configuration.ErrorCategories {
+		resolved[category] = configuration.AbortErrorAction
+		if action, ok := policy[category]; ok {
+			resolved[category] = action
+		}
+	}
+
+	return &ErrorPolicyRecorder{
+		policy: resolved,
+		counts: map[configuration.ErrorCategory]int64{},
+		events: []*ErrorPolicyEvent{},
+	}
+}
+
+// ReachedTip applies the error policy to
+// all errors handled from now on.
+func (r *ErrorPolicyRecorder) ReachedTip() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.reachedTip = true
+}
+
+// Handle returns the category of err and the action
+// to take. Errors are only continued (and recorded)
+// once check:data has reached tip.
+func (r *ErrorPolicyRecorder) Handle(
+	err error,
+) (configuration.ErrorCategory, configuration.ErrorAction) {
+	category := ClassifyError(err)
+	if r == nil || len(category) == 0 {
+		return category, configuration.AbortErrorAction
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	action := r.policy[category]
+	if !r.reachedTip || action == configuration.AbortErrorAction {
+		return category, configuration.AbortErrorAction
+	}
+
+	r.counts[category]++
+	if len(r.events) < errorPolicyEventLimit {
+		r.events = append(r.events, &ErrorPolicyEvent{
+			Category:  category,
+			Action:    action,
+			Error:     err.Error(),
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+
+	return category, action
+}
+
+// Results returns the *ErrorPolicyResults
+// recorded so far (or nil if r is nil).
+func (r *ErrorPolicyRecorder) Results() *ErrorPolicyResults {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	results := &ErrorPolicyResults{
+		Policy:     make(map[configuration.ErrorCategory]configuration.ErrorAction, len(r.policy)),
+		ReachedTip: r.reachedTip,
+		Counts:     make(map[configuration.ErrorCategory]int64, len(r.counts)),
+	}
+	for category, action := range r.policy {
+		results.Policy[category] = action
+	}
+	for category, count := range r.counts {
+		results.Counts[category] = count
+	}
+	if len(r.events) > 0 {
+		results.Events = append([]*ErrorPolicyEvent{}, r.events...)
+	}
+
+	return results
+}
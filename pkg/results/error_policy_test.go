@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	var tests = map[string]struct {
+		err error
+
+		expected configuration.ErrorCategory
+	}{
+		"nil": {},
+		"canceled": {
+			err: context.Canceled,
+		},
+		"unknown": {
+			err: errors.New("unknown"),
+		},
+		"fetch": {
+			err: fmt.Errorf("%w: 502", syncer.ErrGetNetworkStatusFailed),
+
+			expected: configuration.FetchErrorCategory,
+		},
+		"assertion": {
+			err: fmt.Errorf("%w: block 10", asserter.ErrAmountValueMissing),
+
+			expected: configuration.AssertionErrorCategory,
+		},
+		"storage": {
+			err: fmt.Errorf("%w: block 10", storage.ErrDuplicateTransactionHash),
+
+			expected: configuration.StorageErrorCategory,
+		},
+		"reconciliation": {
+			err: fmt.Errorf("%w: account 1", ErrReconciliationFailure),
+
+			expected: configuration.ReconciliationErrorCategory,
+		},
+		"negative balance": {
+			err: fmt.Errorf("%w: account 1", storage.ErrNegativeBalance),
+
+			expected: configuration.NegativeBalanceErrorCategory,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ClassifyError(test.err))
+		})
+	}
+}
+
+func TestErrorPolicyRecorder(t *testing.T) {
+	fetchErr := fmt.Errorf("%w: 502", syncer.ErrGetNetworkStatusFailed)
+	reconciliationErr := fmt.Errorf("%w: account 1", ErrReconciliationFailure)
+	assertionErr := fmt.Errorf("%w: block 10", asserter.ErrAmountValueMissing)
+
+	recorder := NewErrorPolicyRecorder(map[configuration.ErrorCategory]configuration.ErrorAction{
+		configuration.FetchErrorCategory:          configuration.RetryErrorAction,
+		configuration.ReconciliationErrorCategory: configuration.RecordErrorAction,
+	})
+
+	// Every error aborts before tip.
+	category, action := recorder.Handle(fetchErr)
+	assert.Equal(t, configuration.FetchErrorCategory, category)
+	assert.Equal(t, configuration.AbortErrorAction, action)
+
+	recorder.ReachedTip()
+	_, action = recorder.Handle(fetchErr)
+	assert.Equal(t, configuration.RetryErrorAction, action)
+	_, action = recorder.Handle(fetchErr)
+	assert.Equal(t, configuration.RetryErrorAction, action)
+	_, action = recorder.Handle(reconciliationErr)
+	assert.Equal(t, configuration.RecordErrorAction, action)
+
+	// Categories that are not in the policy abort.
+	category, action = recorder.Handle(assertionErr)
+	assert.Equal(t, configuration.AssertionErrorCategory, category)
+	assert.Equal(t, configuration.AbortErrorAction, action)
+	_, action = recorder.Handle(errors.New("unknown"))
+	assert.Equal(t, configuration.AbortErrorAction, action)
+
+	results := recorder.Results()
+	assert.Equal(t, map[configuration.ErrorCategory]configuration.ErrorAction{
+		configuration.FetchErrorCategory:           configuration.RetryErrorAction,
+		configuration.AssertionErrorCategory:       configuration.AbortErrorAction,
+		configuration.StorageErrorCategory:         configuration.AbortErrorAction,
+		configuration.ReconciliationErrorCategory:  configuration.RecordErrorAction,
+		configuration.NegativeBalanceErrorCategory: configuration.AbortErrorAction,
+	}, results.Policy)
+	assert.True(t, results.ReachedTip)
+	assert.Equal(t, map[configuration.ErrorCategory]int64{
+		configuration.FetchErrorCategory:          2,
+		configuration.ReconciliationErrorCategory: 1,
+	}, results.Counts)
+	assert.Len(t, results.Events, 3)
+	assert.Equal(t, configuration.ReconciliationErrorCategory, results.Events[2].Category)
+	assert.Equal(t, configuration.RecordErrorAction, results.Events[2].Action)
+	assert.Equal(t, reconciliationErr.Error(), results.Events[2].Error)
+
+	var b bytes.Buffer
+	(&CheckDataResults{ErrorPolicy: results}).Fprint(&b)
+	assert.Contains(t, b.String(), "CHECK:DATA ERROR POLICY")
+	assert.Contains(t, b.String(), string(configuration.RetryErrorAction))
+
+	// A nil recorder aborts every error.
+	var nilRecorder *ErrorPolicyRecorder
+	nilRecorder.ReachedTip()
+	_, action = nilRecorder.Handle(fetchErr)
+	assert.Equal(t, configuration.AbortErrorAction, action)
+	assert.Nil(t, nilRecorder.Results())
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+)
+
+// EventSinkResults are the delivery counts of
+// an event sink during a check:data run. Dropped
+// events were never attempted because the sink
+// fell too far behind.
+type EventSinkResults struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Delivered int64  `json:"delivered"`
+	Failed    int64  `json:"failed"`
+	Dropped   int64  `json:"dropped"`
+}
+
+// FprintEventSinks writes the results of
+// each event sink to w.
+func FprintEventSinks(w io.Writer, sinks []*EventSinkResults) {
+	table := newTable(w, []string{"check:data Event Sinks", "Type", "Delivered", "Failed", "Dropped"})
+	for _, sink := range sinks {
+		table.Append([]string{
+			sink.Name,
+			sink.Type,
+			strconv.FormatInt(sink.Delivered, 10),
+			strconv.FormatInt(sink.Failed, 10),
+			strconv.FormatInt(sink.Dropped, 10),
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// FeeCurrencyStats contains the fees paid in a single currency
+// over all canonical blocks. Total and LargestBlockTotal are
+// in atomic units. LargestBlockIndex is the index of the block
+// with the largest total (the earliest block if there is a tie).
+type FeeCurrencyStats struct {
+	Currency          *types.Currency `json:"currency"`
+	Total             string          `json:"total"`
+	LargestBlockTotal string          `json:"largest_block_total"`
+	LargestBlockIndex int64           `json:"largest_block_index"`
+}
+
+// FeeStats summarizes the operations of the configured fee
+// operation types in canonical blocks. The fees of a block are
+// the amounts debited by its fee operations (so fees credited
+// to a block producer are not counted twice). ZeroFeeBlocks is
+// the number of blocks with transactions but no fees, which is
+// suspicious on most networks.
+type FeeStats struct {
+	FeeOperationTypes []string            `json:"fee_operation_types"`
+	Currencies        []*FeeCurrencyStats `json:"currencies"`
+	ZeroFeeBlocks     int64               `json:"zero_fee_blocks"`
+}
+
+// printFees writes *FeeStats to w.
+func printFees(w io.Writer, stats *FeeStats) {
+	table := newTable(w, []string{"check:data Fees", "Description", "Value"})
+	table.Append([]string{
+		"Fee Operation Types",
+		"Operation types counted as fees",
+		strings.Join(stats.FeeOperationTypes, ", "),
+	})
+	table.Append([]string{
+		"Zero Fee Blocks",
+		"# of blocks with transactions but no fees",
+		strconv.FormatInt(stats.ZeroFeeBlocks, 10),
+	})
+	table.Render()
+
+	if len(stats.Currencies) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	table = newTable(w, []string{
+		"Fee Currency",
+		"Total",
+		"Largest Block Total",
+		"Largest Block Index",
+	})
+	for _, currency := range stats.Currencies {
+		table.Append([]string{
+			fmt.Sprintf("%s (%d decimals)", currency.Currency.Symbol, currency.Currency.Decimals),
+			currency.Total,
+			currency.LargestBlockTotal,
+			strconv.FormatInt(currency.LargestBlockIndex, 10),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// HistoricalBalanceMismatch is a historical balance returned
+// by the implementation that differed from the balance computed
+// at the same block.
+type HistoricalBalanceMismatch struct {
+	Account         *types.AccountIdentifier `json:"account_identifier"`
+	Currency        *types.Currency          `json:"currency"`
+	Block           *types.BlockIdentifier   `json:"block_identifier"`
+	ComputedBalance string                   `json:"computed_balance"`
+	LiveBalance     string                   `json:"live_balance"`
+}
+
+// HistoricalBalanceResults contains the outcome of all
+// historical balance checks performed while running
+// check:data.
+type HistoricalBalanceResults struct {
+	Checks     int64                        `json:"checks"`
+	Mismatches int64                        `json:"mismatches"`
+	Findings   []*HistoricalBalanceMismatch `json:"findings"`
+}
+
+// HistoricalBalanceTest returns a boolean indicating
+// if every historical balance checked matched the
+// computed balance at the same block. If no historical
+// balances were checked (ex: historical balance lookup
+// is not supported), nil is returned.
+func HistoricalBalanceTest(historicalBalances *HistoricalBalanceResults) *bool {
+	if historicalBalances == nil || historicalBalances.Checks == 0 {
+		return nil
+	}
+
+	historicalPass := historicalBalances.Mismatches == 0
+	return &historicalPass
+}
+
+// Print logs HistoricalBalanceResults to the console.
+func (h *HistoricalBalanceResults) Print() {
+	h.Fprint(color.Output)
+}
+
+// Fprint writes HistoricalBalanceResults to w.
+func (h *HistoricalBalanceResults) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Historical Balances", "Description", "Value"})
+	table.Append([]string{
+		"Checks",
+		"# of historical balances compared with computed balances",
+		strconv.FormatInt(h.Checks, 10),
+	})
+	table.Append([]string{
+		"Mismatches",
+		"# of historical balances that differed from computed balances",
+		strconv.FormatInt(h.Mismatches, 10),
+	})
+	table.Render()
+
+	if len(h.Findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	table = newTable(
+		w,
+		[]string{"Historical Balance Mismatches", "Currency", "Block", "Computed", "Live"},
+	)
+	for _, finding := range h.Findings {
+		table.Append([]string{
+			finding.Account.Address,
+			finding.Currency.Symbol,
+			fmt.Sprintf("%d (%s)", finding.Block.Index, finding.Block.Hash),
+			finding.ComputedBalance,
+			finding.LiveBalance,
+		})
+	}
+	table.Render()
+}
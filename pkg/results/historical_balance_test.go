@@ -0,0 +1,89 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoricalBalanceTest(t *testing.T) {
+	mismatch := &HistoricalBalanceMismatch{
+		Account:         &types.AccountIdentifier{Address: "addr1"},
+		Currency:        &types.Currency{Symbol: "BTC", Decimals: 8},
+		Block:           &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		ComputedBalance: "100",
+		LiveBalance:     "90",
+	}
+
+	var tests = map[string]struct {
+		historicalBalances *HistoricalBalanceResults
+
+		expected *bool
+		detail   string
+	}{
+		"not supported": {},
+		"no checks": {
+			historicalBalances: &HistoricalBalanceResults{},
+		},
+		"all matched": {
+			historicalBalances: &HistoricalBalanceResults{Checks: 5},
+			expected:           &tr,
+		},
+		"mismatch": {
+			historicalBalances: &HistoricalBalanceResults{
+				Checks:     5,
+				Mismatches: 2,
+				Findings:   []*HistoricalBalanceMismatch{mismatch},
+			},
+			expected: &f,
+			detail:   "2 of 5 historical balances did not match computed balances (first: addr1 at 10, computed: 100BTC, live: 90BTC)", // nolint:lll
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, HistoricalBalanceTest(test.historicalBalances))
+
+			tests := ComputeCheckDataTests(
+				context.Background(),
+				configuration.DefaultConfiguration(),
+				nil,
+				nil,
+				nil,
+				nil,
+				test.historicalBalances,
+				nil,
+			)
+			assert.Equal(t, test.expected, tests.HistoricalBalanceTracking)
+			if len(test.detail) == 0 {
+				assert.Nil(t, tests.Details)
+				return
+			}
+
+			assert.Equal(t, test.detail, tests.Details.HistoricalBalanceTracking)
+
+			var b bytes.Buffer
+			tests.Fprint(&b)
+			assert.Contains(t, b.String(), "Historical Balance Tracking")
+		})
+	}
+}
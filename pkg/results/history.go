@@ -0,0 +1,75 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// CheckDataHistoryEntry is the results of a single
+// check:data run appended to a results history file.
+type CheckDataHistoryEntry struct {
+	Iteration int               `json:"iteration"`
+	Timestamp string            `json:"timestamp"`
+	Results   *CheckDataResults `json:"results"`
+}
+
+// NewCheckDataHistoryEntry returns a new
+// *CheckDataHistoryEntry recorded at timestamp.
+func NewCheckDataHistoryEntry(
+	iteration int,
+	results *CheckDataResults,
+	timestamp time.Time,
+) *CheckDataHistoryEntry {
+	return &CheckDataHistoryEntry{
+		Iteration: iteration,
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+		Results:   results,
+	}
+}
+
+// AppendHistory appends entry to the file at path (creating
+// it if it does not exist) as a single line of JSON, so the
+// history of many runs can be read line by line.
+func AppendHistory(path string, entry *CheckDataHistoryEntry) error {
+	output, err := encodeNDJSON(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(
+		path,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open %s", err, path)
+	}
+
+	if _, err := f.Write(output); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("%w: unable to write to %s", err, path)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("%w: unable to close %s", err, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// JUnitTestSuite is a JUnit XML <testsuite>.
+type JUnitTestSuite struct {
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	TestCases []*JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a JUnit XML <testcase>.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+// JUnitFailure is a JUnit XML <failure>.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Detail  string `xml:",chardata"`
+}
+
+// JUnitSkipped is a JUnit XML <skipped>.
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// newJUnitTestSuite returns a *JUnitTestSuite containing
+// testCases with all counts populated.
+func newJUnitTestSuite(name string, testCases []*JUnitTestCase) *JUnitTestSuite {
+	suite := &JUnitTestSuite{
+		Name:      name,
+		Tests:     len(testCases),
+		TestCases: testCases,
+	}
+
+	for _, testCase := range testCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+
+	return suite
+}
+
+// newJUnitFailure returns a *JUnitFailure with the first
+// message as its message and all messages as its detail.
+func newJUnitFailure(messages []string) *JUnitFailure {
+	failure := &JUnitFailure{
+		Message: "FAILED",
+		Detail:  strings.Join(messages, "\n"),
+	}
+
+	if len(messages) > 0 {
+		failure.Message = messages[0]
+	}
+
+	return failure
+}
+
+// newJUnitTestCase returns a *JUnitTestCase for a test
+// with status (a test that was skipped or not tested is
+// reported as skipped with its status as the message).
+func newJUnitTestCase(
+	suiteName string,
+	name string,
+	status TestStatus,
+	failure *JUnitFailure,
+) *JUnitTestCase {
+	testCase := &JUnitTestCase{
+		Name:      name,
+		ClassName: suiteName,
+	}
+
+	switch status {
+	case TestSkipped, TestNotReached:
+		testCase.Skipped = &JUnitSkipped{Message: string(status)}
+	case TestFailed:
+		testCase.Failure = failure
+	}
+
+	return testCase
+}
+
+// encodeJUnit returns a *JUnitTestSuite as an XML document.
+func encodeJUnit(suite *JUnitTestSuite) ([]byte, error) {
+	output, err := xml.MarshalIndent(suite, "", " ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal JUnit results", err)
+	}
+
+	return append([]byte(xml.Header), output...), nil
+}
+
+// writeJUnit writes a *JUnitTestSuite to the provided path.
+func writeJUnit(path string, suite *JUnitTestSuite) error {
+	output, err := encodeJUnit(suite)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(
+		path,
+		output,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+}
+
+// encodeResults returns results encoded in the
+// provided format (as they are written by writeResults).
+func encodeResults(
+	format configuration.ResultsOutputFormat,
+	results interface{},
+	suite func() *JUnitTestSuite,
+) ([]byte, error) {
+	switch format {
+	case configuration.JUnitResultsOutputFormat:
+		return encodeJUnit(suite())
+	case configuration.NDJSONResultsOutputFormat:
+		return encodeNDJSON(results)
+	default:
+		return []byte(types.PrettyPrintStruct(results)), nil
+	}
+}
+
+// writeResults writes results to the provided path
+// in the provided format. Any missing parent directories
+// of path are created. If path is a URL with a scheme
+// that has a ResultsSink (ex: s3://bucket/key), results
+// are saved with the ResultsSink (until ctx is done) instead.
+func writeResults(
+	ctx context.Context,
+	path string,
+	format configuration.ResultsOutputFormat,
+	results interface{},
+	suite func() *JUnitTestSuite,
+) error {
+	sink, err := resultsSinkFor(path)
+	if err != nil {
+		return err
+	}
+
+	if sink != nil {
+		output, err := encodeResults(format, results, suite)
+		if err != nil {
+			return err
+		}
+
+		return sink.Save(ctx, path, output)
+	}
+
+	if len(path) > 0 {
+		if err := utils.EnsurePathExists(filepath.Dir(path)); err != nil {
+			return fmt.Errorf("%w: unable to create results directory", err)
+		}
+	}
+
+	switch format {
+	case configuration.JUnitResultsOutputFormat:
+		return writeJUnit(path, suite())
+	case configuration.NDJSONResultsOutputFormat:
+		return writeNDJSON(path, results)
+	default:
+		return utils.SerializeAndWrite(path, results)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDataResultsJUnit(t *testing.T) {
+	tr := true
+	f := false
+	var tests = map[string]struct {
+		results *CheckDataResults
+
+		expected *JUnitTestSuite
+	}{
+		"no tests": {
+			results: &CheckDataResults{
+				Error: "unsure how to handle this error",
+			},
+			expected: &JUnitTestSuite{
+				Name:     "check:data",
+				Tests:    1,
+				Failures: 1,
+				TestCases: []*JUnitTestCase{
+					{
+						Name:      "check:data",
+						ClassName: "check:data",
+						Failure: &JUnitFailure{
+							Message: "Error: unsure how to handle this error",
+							Detail:  "Error: unsure how to handle this error",
+						},
+					},
+				},
+			},
+		},
+		"passed and not tested": {
+			results: &CheckDataResults{
+				EndCondition: &EndCondition{
+					Type:   configuration.IndexEndCondition,
+					Detail: "index 100",
+				},
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: true,
+					BlockSyncing:      &tr,
+				},
+			},
+			expected: &JUnitTestSuite{
+				Name:    "check:data",
+				Tests:   7,
+				Skipped: 4,
+				TestCases: []*JUnitTestCase{
+					{Name: "Request/Response", ClassName: "check:data"},
+					{Name: "Response Assertion", ClassName: "check:data"},
+					{Name: "Block Syncing", ClassName: "check:data"},
+					{
+						Name:      "Balance Tracking",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Coin Tracking",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Reconciliation",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Negative Request",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+				},
+			},
+		},
+		"failed": {
+			results: &CheckDataResults{
+				Error: "reconciliation failure",
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: true,
+					BlockSyncing:      &tr,
+					BalanceTracking:   &tr,
+					CoinTracking:      &tr,
+					Reconciliation:    &f,
+					NegativeRequest:   &tr,
+				},
+			},
+			expected: &JUnitTestSuite{
+				Name:     "check:data",
+				Tests:    7,
+				Failures: 1,
+				TestCases: []*JUnitTestCase{
+					{Name: "Request/Response", ClassName: "check:data"},
+					{Name: "Response Assertion", ClassName: "check:data"},
+					{Name: "Block Syncing", ClassName: "check:data"},
+					{Name: "Balance Tracking", ClassName: "check:data"},
+					{Name: "Coin Tracking", ClassName: "check:data"},
+					{
+						Name:      "Reconciliation",
+						ClassName: "check:data",
+						Failure: &JUnitFailure{
+							Message: "Error: reconciliation failure",
+							Detail:  "Error: reconciliation failure",
+						},
+					},
+					{Name: "Negative Request", ClassName: "check:data"},
+				},
+			},
+		},
+		"failed with end condition": {
+			results: &CheckDataResults{
+				EndCondition: &EndCondition{
+					Type:   configuration.TipEndCondition,
+					Detail: "tip 100",
+				},
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: false,
+				},
+			},
+			expected: &JUnitTestSuite{
+				Name:     "check:data",
+				Tests:    7,
+				Failures: 1,
+				Skipped:  5,
+				TestCases: []*JUnitTestCase{
+					{Name: "Request/Response", ClassName: "check:data"},
+					{
+						Name:      "Response Assertion",
+						ClassName: "check:data",
+						Failure: &JUnitFailure{
+							Message: "End Condition: Tip End Condition [tip 100]",
+							Detail:  "End Condition: Tip End Condition [tip 100]",
+						},
+					},
+					{
+						Name:      "Block Syncing",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Balance Tracking",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Coin Tracking",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Reconciliation",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+					{
+						Name:      "Negative Request",
+						ClassName: "check:data",
+						Skipped:   &JUnitSkipped{Message: "NOT TESTED"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.results.JUnit())
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			// Ensure the written file can be parsed
+			// as a JUnit testsuite.
+			outputPath := path.Join(dir, "results.xml")
+			assert.NoError(t, test.results.Output(outputPath, configuration.JUnitResultsOutputFormat))
+
+			contents, err := ioutil.ReadFile(outputPath)
+			assert.NoError(t, err)
+
+			var suite JUnitTestSuite
+			assert.NoError(t, xml.Unmarshal(contents, &suite))
+			assert.Equal(t, "testsuite", suite.XMLName.Local)
+			suite.XMLName = xml.Name{}
+			assert.Equal(t, test.expected, &suite)
+		})
+	}
+}
+
+func TestCheckConstructionResultsJUnit(t *testing.T) {
+	var tests = map[string]struct {
+		results *CheckConstructionResults
+
+		expected *JUnitTestSuite
+	}{
+		"error": {
+			results: &CheckConstructionResults{
+				Error: "broadcast failed",
+			},
+			expected: &JUnitTestSuite{
+				Name:     "check:construction",
+				Tests:    1,
+				Failures: 1,
+				TestCases: []*JUnitTestCase{
+					{
+						Name:      "check:construction",
+						ClassName: "check:construction",
+						Failure: &JUnitFailure{
+							Message: "Error: broadcast failed",
+							Detail:  "Error: broadcast failed",
+						},
+					},
+				},
+			},
+		},
+		"end conditions": {
+			results: &CheckConstructionResults{
+				EndConditions: map[string]int{
+					"transfer":       10,
+					"create_account": 5,
+				},
+			},
+			expected: &JUnitTestSuite{
+				Name:  "check:construction",
+				Tests: 2,
+				TestCases: []*JUnitTestCase{
+					{Name: "create_account (5)", ClassName: "check:construction"},
+					{Name: "transfer (10)", ClassName: "check:construction"},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.results.JUnit())
+		})
+	}
+}
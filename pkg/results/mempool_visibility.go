@@ -0,0 +1,103 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/fatih/color"
+)
+
+// MempoolVisibilityPath is where a transaction accepted
+// by /construction/submit was first seen.
+type MempoolVisibilityPath string
+
+const (
+	// MempoolPath is used when a transaction was seen
+	// in /mempool (or /mempool/transaction) before it
+	// was seen in a block.
+	MempoolPath MempoolVisibilityPath = "mempool"
+
+	// BlockPath is used when a transaction was seen
+	// in a block without ever being seen in the mempool.
+	BlockPath MempoolVisibilityPath = "block"
+)
+
+// TransactionVisibility is the path a submitted transaction
+// took and how long (in milliseconds) it took to be seen.
+type TransactionVisibility struct {
+	Hash    string                `json:"hash"`
+	Path    MempoolVisibilityPath `json:"path"`
+	Latency float64               `json:"latency_ms"`
+}
+
+// MempoolVisibilityResults contains the outcome of watching
+// /mempool for transactions accepted by /construction/submit
+// while running check:construction. Some blockchains never
+// add transactions to the mempool, so transactions that skip
+// the mempool are counted separately instead of failing
+// the run.
+type MempoolVisibilityResults struct {
+	Mempool        int64 `json:"mempool"`
+	SkippedMempool int64 `json:"skipped_mempool"`
+	Pending        int64 `json:"pending"`
+
+	MeanMempoolLatency float64 `json:"mean_mempool_latency_ms"`
+	MeanBlockLatency   float64 `json:"mean_block_latency_ms"`
+
+	Transactions []*TransactionVisibility `json:"transactions"`
+}
+
+// Print logs MempoolVisibilityResults to the console.
+func (m *MempoolVisibilityResults) Print() {
+	m.Fprint(color.Output)
+}
+
+// Fprint writes MempoolVisibilityResults to w.
+func (m *MempoolVisibilityResults) Fprint(w io.Writer) {
+	formatMilliseconds := func(milliseconds float64) string {
+		return strconv.FormatFloat(milliseconds, 'f', 2, 64)
+	}
+
+	table := newTable(w, []string{"check:construction Mempool Visibility", "Description", "Value"})
+	table.Append([]string{
+		"Seen In Mempool",
+		"# of submitted transactions seen in the mempool before a block",
+		strconv.FormatInt(m.Mempool, 10),
+	})
+	table.Append([]string{
+		"Skipped Mempool",
+		"# of submitted transactions seen in a block without being seen in the mempool",
+		strconv.FormatInt(m.SkippedMempool, 10),
+	})
+	table.Append([]string{
+		"Pending",
+		"# of submitted transactions not yet seen in the mempool or a block",
+		strconv.FormatInt(m.Pending, 10),
+	})
+	table.Append([]string{
+		"Mean Mempool Latency (ms)",
+		"Mean time from submission until a transaction was seen in the mempool",
+		formatMilliseconds(m.MeanMempoolLatency),
+	})
+	table.Append([]string{
+		"Mean Block Latency (ms)",
+		"Mean time from submission until a transaction that skipped the mempool was seen in a block",
+		formatMilliseconds(m.MeanBlockLatency),
+	})
+
+	table.Render()
+}
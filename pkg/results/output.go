@@ -0,0 +1,248 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	// noColorEnv is the environment variable that disables
+	// colored output when populated (see https://no-color.org).
+	noColorEnv = "NO_COLOR"
+)
+
+var (
+	// plainTables is true if tables are printed
+	// in a plain style (see ConfigureOutput).
+	plainTables bool
+
+	// resultsColumns are the columns printed in
+	// the check:data stats and tests tables (all
+	// columns if empty, see ConfigureColumns).
+	resultsColumns []configuration.ResultsColumn
+
+	// ansiEscapes matches the ANSI escape
+	// sequences used to color output.
+	ansiEscapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// ConfigureOutput disables colored output and prints tables in a
+// plain ASCII style (without borders or row separators, which
+// render badly in many log viewers) if plain is true or NO_COLOR
+// is populated. Colored output is also disabled (but tables are
+// unchanged) when stdout is not a terminal (detected by
+// fatih/color).
+//
+// All colored output (including colored table rows) obeys
+// color.NoColor and all tables are created with newTable,
+// so this should be called before anything is printed.
+func ConfigureOutput(plain bool) {
+	if plain || len(os.Getenv(noColorEnv)) > 0 {
+		color.NoColor = true
+		plainTables = true
+	}
+}
+
+// ConfigureColumns sets the columns printed in the
+// check:data stats and tests tables. The name column is
+// always printed and all columns are printed if columns
+// is empty.
+func ConfigureColumns(columns []configuration.ResultsColumn) {
+	resultsColumns = columns
+}
+
+// columnTable is a table that only prints the
+// configured results columns.
+type columnTable struct {
+	table   *tablewriter.Table
+	columns []int
+}
+
+// newColumnTable returns a *columnTable that writes to
+// w with header, where columns are the ResultsColumn of
+// each header (the first of which is the name).
+func newColumnTable(
+	w io.Writer,
+	header []string,
+	columns []configuration.ResultsColumn,
+) *columnTable {
+	printed := []int{}
+	for i, column := range columns {
+		if i == 0 || len(resultsColumns) == 0 || containsColumn(resultsColumns, column) {
+			printed = append(printed, i)
+		}
+	}
+
+	return &columnTable{
+		table:   newTable(w, selectColumns(header, printed)),
+		columns: printed,
+	}
+}
+
+// Append appends the printed columns of row.
+func (t *columnTable) Append(row []string) {
+	t.table.Append(selectColumns(row, t.columns))
+}
+
+// Render writes the table.
+func (t *columnTable) Render() {
+	t.table.Render()
+}
+
+func containsColumn(
+	columns []configuration.ResultsColumn,
+	column configuration.ResultsColumn,
+) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectColumns(row []string, columns []int) []string {
+	selected := make([]string, 0, len(columns))
+	for _, i := range columns {
+		if i < len(row) {
+			selected = append(selected, row[i])
+		}
+	}
+
+	return selected
+}
+
+// newTable returns a *tablewriter.Table that writes
+// to w with header in the configured style.
+func newTable(w io.Writer, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	if plainTables {
+		table.SetBorder(false)
+		table.SetCenterSeparator(" ")
+		table.SetColumnSeparator(" ")
+		table.SetRowSeparator("-")
+		return table
+	}
+
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	return table
+}
+
+// appendRow appends row to table with each cell in
+// the corresponding colors unless colored output is
+// disabled. tablewriter ignores escape sequences when
+// measuring cells, so colored columns remain aligned.
+func appendRow(table *tablewriter.Table, row []string, colors []tablewriter.Colors) {
+	if color.NoColor {
+		table.Append(row)
+		return
+	}
+
+	colored := make([]string, len(row))
+	for i, cell := range row {
+		colored[i] = cell
+		if i >= len(colors) || len(colors[i]) == 0 {
+			continue
+		}
+
+		attributes := make([]color.Attribute, len(colors[i]))
+		for j, code := range colors[i] {
+			attributes[j] = color.Attribute(code)
+		}
+
+		colored[i] = color.New(attributes...).Sprint(cell)
+	}
+
+	table.Append(colored)
+}
+
+// fprintColor writes format to w in the color of
+// attribute (unless colored output is disabled). Like
+// color.Red, a newline is added if format does not
+// end with one.
+func fprintColor(w io.Writer, attribute color.Attribute, format string, a ...interface{}) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+
+	_, _ = color.New(attribute).Fprintf(w, format, a...)
+}
+
+// uncoloredWriter removes all ANSI escape sequences
+// from output before writing it to w. Escape sequences
+// are always written in a single call to Write, so
+// they are never split across calls.
+type uncoloredWriter struct {
+	w io.Writer
+
+	// err is the first error returned by w (tables
+	// do not return errors from Render).
+	err error
+}
+
+// Write writes p to w without any escape sequences.
+func (u *uncoloredWriter) Write(p []byte) (int, error) {
+	if _, err := u.w.Write(ansiEscapes.ReplaceAll(p, nil)); err != nil {
+		if u.err == nil {
+			u.err = err
+		}
+
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// logResults appends everything fprint writes to the
+// file at path (creating it if it does not exist) without
+// colors, so that the tables printed to the console can
+// be kept in a log file.
+func logResults(path string, fprint func(w io.Writer)) error {
+	f, err := os.OpenFile(
+		path,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open %s", err, path)
+	}
+
+	writer := &uncoloredWriter{w: f}
+	fprint(writer)
+	if writer.err != nil {
+		_ = f.Close()
+		return fmt.Errorf("%w: unable to write to %s", writer.err, path)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("%w: unable to close %s", err, path)
+	}
+
+	return nil
+}
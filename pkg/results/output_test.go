@@ -0,0 +1,389 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+// updateGolden overwrites the golden files in testdata
+// with the current output (go test -run Golden -update).
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// escapeSequence starts every ANSI escape sequence.
+const escapeSequence = "\x1b["
+
+// capturePrint returns everything print writes to
+// stdout (including colored output).
+func capturePrint(t *testing.T, print func()) string {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	defer func() {
+		os.Stdout, color.Output = stdout, output
+	}()
+
+	captured := make(chan string)
+	go func() {
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		captured <- string(b)
+	}()
+
+	print()
+	assert.NoError(t, w.Close())
+	return <-captured
+}
+
+func TestConfigureOutput(t *testing.T) {
+	noColor, plain := color.NoColor, plainTables
+	noColorValue, noColorSet := os.LookupEnv(noColorEnv)
+	assert.NoError(t, os.Unsetenv(noColorEnv))
+	defer func() {
+		color.NoColor, plainTables = noColor, plain
+		if noColorSet {
+			_ = os.Setenv(noColorEnv, noColorValue)
+		}
+	}()
+
+	failed := false
+	tested := true
+	results := &CheckDataResults{
+		Error: "reconciliation failed",
+		Tests: &CheckDataTests{
+			RequestResponse: true,
+			Reconciliation:  &failed,
+		},
+		Stats: &CheckDataStats{
+			Blocks: 10,
+			BlockPayloads: &BlockPayloadStats{
+				Count:                1,
+				Max:                  200,
+				MaxBlockPayloadBytes: 100,
+				ViolationCount:       1,
+				Largest:              []*BlockPayload{{Bytes: 200}},
+			},
+		},
+	}
+	diff := DiffCheckDataResults(
+		&CheckDataResults{Tests: &CheckDataTests{RequestResponse: true, Reconciliation: &tested}},
+		results,
+	)
+	printAll := func() {
+		results.Print()
+		diff.Print()
+	}
+
+	var tests = map[string]struct {
+		plain      bool
+		setNoColor bool
+		noColor    string
+
+		colored bool
+	}{
+		"enabled": {
+			colored: true,
+		},
+		"plain": {
+			plain: true,
+		},
+		"NO_COLOR": {
+			setNoColor: true,
+			noColor:    "1",
+		},
+		"empty NO_COLOR": {
+			setNoColor: true,
+			colored:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Tests never run in a terminal, so color
+			// must be enabled explicitly.
+			color.NoColor, plainTables = false, false
+			if test.setNoColor {
+				assert.NoError(t, os.Setenv(noColorEnv, test.noColor))
+				defer os.Unsetenv(noColorEnv)
+			}
+
+			ConfigureOutput(test.plain)
+			assert.Equal(t, !test.colored, color.NoColor)
+			assert.Equal(t, !test.colored, plainTables)
+
+			output := capturePrint(t, printAll)
+			assert.Contains(t, output, "reconciliation failed")
+			assert.Contains(t, output, "Reconciliation")
+			assert.Equal(t, test.colored, strings.Contains(output, escapeSequence))
+
+			// Plain tables have no borders.
+			assert.Equal(t, test.colored, strings.Contains(output, "|"))
+		})
+	}
+
+	// Construction results obey the same setting.
+	color.NoColor, plainTables = false, false
+	ConfigureOutput(true)
+	output := capturePrint(t, func() {
+		(&CheckConstructionResults{Error: "broadcast failed"}).Print()
+		(&CheckConstructionResults{
+			EndConditions: map[string]int{"transfer": 1},
+			Stats:         &CheckConstructionStats{},
+		}).Print()
+	})
+	assert.Contains(t, output, "broadcast failed")
+	assert.NotContains(t, output, escapeSequence)
+	assert.NotContains(t, output, "|")
+}
+
+func TestConfigureColumns(t *testing.T) {
+	defer ConfigureColumns(nil)
+
+	passed := true
+	stats := &CheckDataStats{Blocks: 100}
+	tests := &CheckDataTests{
+		RequestResponse:   true,
+		ResponseAssertion: true,
+		BlockSyncing:      &passed,
+	}
+
+	var b bytes.Buffer
+	stats.Fprint(&b)
+	tests.Fprint(&b)
+	assert.Contains(t, b.String(), "DESCRIPTION")
+	assert.Contains(t, b.String(), "# of blocks synced")
+	assert.Contains(t, b.String(), "Rosetta implementation serviced all requests")
+
+	// The name column is always printed.
+	ConfigureColumns([]configuration.ResultsColumn{
+		configuration.ValueResultsColumn,
+		configuration.StatusResultsColumn,
+	})
+	b.Reset()
+	stats.Fprint(&b)
+	tests.Fprint(&b)
+	assert.NotContains(t, b.String(), "DESCRIPTION")
+	assert.NotContains(t, b.String(), "DETAIL")
+	assert.NotContains(t, b.String(), "# of blocks synced")
+	assert.NotContains(t, b.String(), "Rosetta implementation serviced all requests")
+	assert.Contains(t, b.String(), "Blocks")
+	assert.Contains(t, b.String(), "100")
+	assert.Contains(t, b.String(), "Request/Response")
+	assert.Contains(t, b.String(), "PASSED")
+}
+
+// goldenCheckDataResults returns *CheckDataResults
+// with every section populated.
+func goldenCheckDataResults() *CheckDataResults {
+	passed := true
+	failed := false
+	blockIndex := int64(100)
+	account := &types.AccountIdentifier{Address: "addr1"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	block := &types.BlockIdentifier{Index: 100, Hash: "block 100"}
+
+	return &CheckDataResults{
+		EndCondition: &EndCondition{
+			Type:   configuration.TipEndCondition,
+			Detail: "Tip: 100",
+		},
+		Tests: &CheckDataTests{
+			RequestResponse:   true,
+			ResponseAssertion: true,
+			BlockSyncing:      &passed,
+			BalanceTracking:   &passed,
+			Reconciliation:    &failed,
+		},
+		Stats: &CheckDataStats{
+			Blocks:                  100,
+			Orphans:                 2,
+			Transactions:            400,
+			Operations:              1200,
+			ActiveReconciliations:   50,
+			InactiveReconciliations: 25,
+			ReconciliationFailures:  1,
+			ReconciliationCoverage:  0.5,
+			OperationTypes:          map[string]int64{"Transfer": 1000, "Fee": 200},
+			EndpointLatency: map[string]*EndpointStats{
+				"/block":           {Count: 100, Mean: 12.5, P95: 20, Max: 40},
+				"/account/balance": {Count: 75, Errors: 1, Mean: 5, P95: 8, Max: 10},
+			},
+			BlockPayloads: &BlockPayloadStats{
+				Count:     100,
+				Mean:      1000,
+				P99:       4000,
+				Max:       5000,
+				WireBytes: 50000,
+				Largest: []*BlockPayload{
+					{
+						Block:     &types.PartialBlockIdentifier{Index: &blockIndex},
+						Bytes:     5000,
+						WireBytes: 2500,
+					},
+				},
+				MaxBlockPayloadBytes: 4500,
+				ViolationCount:       1,
+			},
+			Warnings: []string{"unable to get storage size"},
+		},
+		ReconciliationFailures: []*ReconciliationFailure{
+			{
+				Type:            "ACTIVE",
+				Account:         account,
+				Currency:        currency,
+				ComputedBalance: "100",
+				LiveBalance:     "90",
+				Difference:      "-10",
+				Block:           block,
+			},
+		},
+		AssertionFindings: &AssertionFindings{
+			RuleCounts: map[string]int64{"operation_status": 2},
+		},
+		CacheProbe: &CacheProbeResults{Probes: 10, StaleResponses: 1, MaxStaleness: 2},
+		EndpointParity: &EndpointParityResults{
+			Checks:        10,
+			Disagreements: 1,
+			Findings: []*EndpointParityFinding{
+				{
+					Account:          account,
+					Currency:         currency,
+					Block:            block,
+					PrimaryBalance:   "100",
+					SecondaryBalance: "90",
+				},
+			},
+		},
+		AccountTags: TagReconciliationResults{
+			{Tag: "exchange", Accounts: 2, Reconciliations: 10, Failures: 1, Coverage: 0.5},
+		},
+		EventSinks: []*EventSinkResults{
+			{Name: "slack", Type: "webhook", Delivered: 3, Failed: 1},
+		},
+		RunTiming: &RunTiming{Duration: 3725},
+	}
+}
+
+func TestFprintGolden(t *testing.T) {
+	noColor, plain := color.NoColor, plainTables
+	defer func() {
+		color.NoColor, plainTables = noColor, plain
+	}()
+
+	var tests = map[string]struct {
+		plain  bool
+		fprint func(io.Writer)
+	}{
+		"check_data_results.golden": {
+			fprint: goldenCheckDataResults().Fprint,
+		},
+		"check_data_results_plain.golden": {
+			plain:  true,
+			fprint: goldenCheckDataResults().Fprint,
+		},
+		"check_construction_results.golden": {
+			fprint: (&CheckConstructionResults{
+				EndConditions: map[string]int{"transfer": 1},
+				Stats: &CheckConstructionStats{
+					TransactionsConfirmed: 10,
+					TransactionsCreated:   12,
+					StaleBroadcasts:       1,
+					FailedBroadcasts:      1,
+					AddressesCreated:      5,
+					WorkflowsCompleted:    map[string]int64{"transfer": 10, "create_account": 5},
+				},
+				MempoolVisibility: &MempoolVisibilityResults{
+					Mempool:            8,
+					SkippedMempool:     1,
+					Pending:            1,
+					MeanMempoolLatency: 1500,
+					MeanBlockLatency:   12000,
+				},
+				RunTiming: &RunTiming{Duration: 90},
+			}).Fprint,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			color.NoColor, plainTables = true, test.plain
+
+			var output bytes.Buffer
+			test.fprint(&output)
+
+			goldenPath := path.Join("testdata", name)
+			if *updateGolden {
+				assert.NoError(t, ioutil.WriteFile(
+					goldenPath,
+					output.Bytes(),
+					os.FileMode(utils.DefaultFilePermissions),
+				))
+			}
+
+			expected, err := ioutil.ReadFile(goldenPath)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), output.String())
+		})
+	}
+}
+
+func TestLogResults(t *testing.T) {
+	noColor := color.NoColor
+	defer func() {
+		color.NoColor = noColor
+	}()
+	color.NoColor = false
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	results := goldenCheckDataResults()
+	var colored bytes.Buffer
+	results.Fprint(&colored)
+	assert.Contains(t, colored.String(), escapeSequence)
+
+	// Results are appended without colors.
+	logPath := path.Join(dir, "results.log")
+	assert.NoError(t, logResults(logPath, results.Fprint))
+	assert.NoError(t, logResults(logPath, results.Fprint))
+
+	logged, err := ioutil.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(logged), escapeSequence)
+
+	color.NoColor = true
+	var uncolored bytes.Buffer
+	results.Fprint(&uncolored)
+	assert.Equal(t, strings.Repeat(uncolored.String(), 2), string(logged))
+
+	assert.Error(t, logResults(path.Join(dir, "missing", "results.log"), results.Fprint))
+}
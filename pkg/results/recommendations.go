@@ -0,0 +1,264 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// recommendationMinRequests is the fewest requests to an
+	// endpoint before its latency is used to recommend settings.
+	recommendationMinRequests = 100
+
+	// recommendationLowLatency is the /block p95 latency (in
+	// milliseconds) below which the node is considered to have
+	// spare capacity.
+	recommendationLowLatency = 100
+
+	// recommendationErrorRate is the fraction of failed /block
+	// requests above which the node is considered overloaded.
+	recommendationErrorRate = 0.01
+
+	// recommendationPeakMemoryBytes is the peak memory above
+	// which we recommend syncing fewer blocks at once.
+	recommendationPeakMemoryBytes = 8 * 1024 * 1024 * 1024
+
+	// recommendationStorageSizeBytes is the storage size above
+	// which we recommend enabling pruning.
+	recommendationStorageSizeBytes = 100 * 1024 * 1024 * 1024
+
+	// recommendationDeferredFraction is the fraction of
+	// reconciliations deferred above which we recommend
+	// reconciling more accounts at once.
+	recommendationDeferredFraction = 0.1
+
+	// recommendationMinBlocks is the fewest blocks synced before
+	// the reconciliation coverage is used to recommend settings.
+	recommendationMinBlocks = 1000
+
+	// recommendationLowCoverage is the reconciliation coverage
+	// below which we recommend more inactive reconciliations.
+	recommendationLowCoverage = 0.5
+
+	// blockEndpoint is the key of /block
+	// requests in the endpoint latency stats.
+	blockEndpoint = "/block"
+)
+
+// Recommendation is a suggested change to the configuration
+// of the next run. Reason references the observed values
+// that justify the suggestion.
+type Recommendation struct {
+	Setting    string `json:"setting"`
+	Suggestion string `json:"suggestion"`
+	Reason     string `json:"reason"`
+}
+
+// String returns the Recommendation as a single line.
+func (r *Recommendation) String() string {
+	return fmt.Sprintf("%s: %s", r.Suggestion, r.Reason)
+}
+
+// ComputeRecommendations returns the configuration changes
+// suggested by the stats of a run that used cfg (or nil if
+// no change is suggested). It only inspects cfg and results.
+func ComputeRecommendations(
+	cfg *configuration.Configuration,
+	results *CheckDataResults,
+) []*Recommendation {
+	if cfg == nil || results == nil || results.Stats == nil {
+		return nil
+	}
+
+	recommendations := []*Recommendation{}
+	for _, recommend := range []func(
+		*configuration.Configuration,
+		*CheckDataResults,
+	) *Recommendation{
+		recommendSyncConcurrency,
+		recommendActiveReconciliationConcurrency,
+		recommendInactiveReconciliationConcurrency,
+		recommendPruning,
+	} {
+		if recommendation := recommend(cfg, results); recommendation != nil {
+			recommendations = append(recommendations, recommendation)
+		}
+	}
+
+	if len(recommendations) == 0 {
+		return nil
+	}
+
+	return recommendations
+}
+
+// recommendSyncConcurrency recommends a lower max_sync_concurrency
+// if /block requests failed or memory peaked and a higher
+// max_sync_concurrency if the node responded quickly to a
+// max_sync_concurrency lower than the default.
+func recommendSyncConcurrency(
+	cfg *configuration.Configuration,
+	results *CheckDataResults,
+) *Recommendation {
+	stats := results.Stats
+	block := stats.EndpointLatency[blockEndpoint]
+	if block != nil && block.Count >= recommendationMinRequests &&
+		float64(block.Errors)/float64(block.Count) > recommendationErrorRate &&
+		cfg.MaxSyncConcurrency > 1 {
+		return &Recommendation{
+			Setting: "max_sync_concurrency",
+			Suggestion: fmt.Sprintf(
+				"decrease max_sync_concurrency to ~%d",
+				cfg.MaxSyncConcurrency/2,
+			),
+			Reason: fmt.Sprintf(
+				"%d of %d /block requests failed (p95 latency %.2fms)",
+				block.Errors,
+				block.Count,
+				block.P95,
+			),
+		}
+	}
+
+	if stats.PeakMemoryBytes > recommendationPeakMemoryBytes && cfg.MaxSyncConcurrency > 1 {
+		return &Recommendation{
+			Setting: "max_sync_concurrency",
+			Suggestion: fmt.Sprintf(
+				"decrease max_sync_concurrency to ~%d",
+				cfg.MaxSyncConcurrency/2,
+			),
+			Reason: fmt.Sprintf(
+				"peak memory was %s (more than %s)",
+				formatBytes(stats.PeakMemoryBytes),
+				formatBytes(recommendationPeakMemoryBytes),
+			),
+		}
+	}
+
+	if block != nil && block.Count >= recommendationMinRequests && block.Errors == 0 &&
+		block.P95 < recommendationLowLatency &&
+		cfg.MaxSyncConcurrency < configuration.DefaultMaxSyncConcurrency {
+		suggested := minInt64(cfg.MaxSyncConcurrency*2, configuration.DefaultMaxSyncConcurrency)
+		return &Recommendation{
+			Setting:    "max_sync_concurrency",
+			Suggestion: fmt.Sprintf("increase max_sync_concurrency to ~%d", suggested),
+			Reason: fmt.Sprintf(
+				"/block p95 latency was %.2fms with no errors in %d requests",
+				block.P95,
+				block.Count,
+			),
+		}
+	}
+
+	return nil
+}
+
+// recommendActiveReconciliationConcurrency recommends a higher
+// active_reconciliation_concurrency if many reconciliations were
+// deferred because accounts changed before they were reconciled.
+func recommendActiveReconciliationConcurrency(
+	cfg *configuration.Configuration,
+	results *CheckDataResults,
+) *Recommendation {
+	stats := results.Stats
+	if cfg.Data.ReconciliationDisabled || stats.DeferredReconciliations == 0 {
+		return nil
+	}
+
+	total := stats.ActiveReconciliations + stats.InactiveReconciliations +
+		stats.DeferredReconciliations
+	fraction := float64(stats.DeferredReconciliations) / float64(total)
+	if fraction <= recommendationDeferredFraction {
+		return nil
+	}
+
+	return &Recommendation{
+		Setting: "active_reconciliation_concurrency",
+		Suggestion: fmt.Sprintf(
+			"increase active_reconciliation_concurrency to ~%d",
+			cfg.Data.ActiveReconciliationConcurrency*2,
+		),
+		Reason: fmt.Sprintf(
+			"%d of %d reconciliations (%.2f%%) were deferred",
+			stats.DeferredReconciliations,
+			total,
+			fraction*100,
+		),
+	}
+}
+
+// recommendInactiveReconciliationConcurrency recommends a higher
+// inactive_reconciliation_concurrency if reconciliation coverage
+// stayed low over a long run.
+func recommendInactiveReconciliationConcurrency(
+	cfg *configuration.Configuration,
+	results *CheckDataResults,
+) *Recommendation {
+	stats := results.Stats
+	if cfg.Data.ReconciliationDisabled || cfg.Data.BalanceTrackingDisabled ||
+		stats.Blocks < recommendationMinBlocks ||
+		stats.ReconciliationCoverage >= recommendationLowCoverage {
+		return nil
+	}
+
+	return &Recommendation{
+		Setting: "inactive_reconciliation_concurrency",
+		Suggestion: fmt.Sprintf(
+			"increase inactive_reconciliation_concurrency to ~%d",
+			cfg.Data.InactiveReconciliationConcurrency*2,
+		),
+		Reason: fmt.Sprintf(
+			"reconciliation coverage was %.2f%% after %d blocks (%d inactive reconciliations)",
+			stats.ReconciliationCoverage*100,
+			stats.Blocks,
+			stats.InactiveReconciliations,
+		),
+	}
+}
+
+// recommendPruning recommends enabling pruning if
+// pruning is disabled and storage grew large.
+func recommendPruning(
+	cfg *configuration.Configuration,
+	results *CheckDataResults,
+) *Recommendation {
+	stats := results.Stats
+	if !cfg.Data.PruningDisabled || stats.StorageSizeBytes <= recommendationStorageSizeBytes {
+		return nil
+	}
+
+	return &Recommendation{
+		Setting:    "pruning_disabled",
+		Suggestion: "enable pruning (set pruning_disabled to false)",
+		Reason: fmt.Sprintf(
+			"storage grew to %s with pruning disabled",
+			formatBytes(stats.StorageSizeBytes),
+		),
+	}
+}
+
+// FprintRecommendations writes recommendations to w.
+func FprintRecommendations(w io.Writer, recommendations []*Recommendation) {
+	fprintColor(w, color.FgCyan, "Recommendations:\n")
+	for _, recommendation := range recommendations {
+		fprintColor(w, color.FgCyan, "- %s\n", recommendation.String())
+	}
+}
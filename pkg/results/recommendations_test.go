@@ -0,0 +1,207 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeRecommendations(t *testing.T) {
+	var tests = map[string]struct {
+		cfg     func(cfg *configuration.Configuration)
+		results *CheckDataResults
+
+		expected []*Recommendation
+	}{
+		"no stats": {
+			results: &CheckDataResults{},
+		},
+		"nothing to recommend": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					Blocks:                 5000,
+					ActiveReconciliations:  100,
+					ReconciliationCoverage: 0.9,
+					EndpointLatency: map[string]*EndpointStats{
+						"/block": {Count: 5000, P95: 12},
+					},
+				},
+			},
+		},
+		"block errors": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					EndpointLatency: map[string]*EndpointStats{
+						"/block": {Count: 1000, Errors: 50, P95: 800},
+					},
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "max_sync_concurrency",
+					Suggestion: "decrease max_sync_concurrency to ~32",
+					Reason:     "50 of 1000 /block requests failed (p95 latency 800.00ms)",
+				},
+			},
+		},
+		"peak memory": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					PeakMemoryBytes: 10 * 1024 * 1024 * 1024,
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "max_sync_concurrency",
+					Suggestion: "decrease max_sync_concurrency to ~32",
+					Reason:     "peak memory was 10.0 GiB (more than 8.0 GiB)",
+				},
+			},
+		},
+		"low latency with lowered concurrency": {
+			cfg: func(cfg *configuration.Configuration) {
+				cfg.MaxSyncConcurrency = 6
+			},
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					EndpointLatency: map[string]*EndpointStats{
+						"/block": {Count: 5000, P95: 12.5},
+					},
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "max_sync_concurrency",
+					Suggestion: "increase max_sync_concurrency to ~12",
+					Reason:     "/block p95 latency was 12.50ms with no errors in 5000 requests",
+				},
+			},
+		},
+		"low latency with too few requests": {
+			cfg: func(cfg *configuration.Configuration) {
+				cfg.MaxSyncConcurrency = 6
+			},
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					EndpointLatency: map[string]*EndpointStats{
+						"/block": {Count: 10, P95: 12.5},
+					},
+				},
+			},
+		},
+		"deferred reconciliations": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					ActiveReconciliations:   60,
+					InactiveReconciliations: 20,
+					DeferredReconciliations: 20,
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "active_reconciliation_concurrency",
+					Suggestion: "increase active_reconciliation_concurrency to ~32",
+					Reason:     "20 of 100 reconciliations (20.00%) were deferred",
+				},
+			},
+		},
+		"deferred reconciliations with reconciliation disabled": {
+			cfg: func(cfg *configuration.Configuration) {
+				cfg.Data.ReconciliationDisabled = true
+			},
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					DeferredReconciliations: 20,
+				},
+			},
+		},
+		"low coverage": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					Blocks:                  2000,
+					InactiveReconciliations: 15,
+					ReconciliationCoverage:  0.25,
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "inactive_reconciliation_concurrency",
+					Suggestion: "increase inactive_reconciliation_concurrency to ~8",
+					Reason:     "reconciliation coverage was 25.00% after 2000 blocks (15 inactive reconciliations)", // nolint:lll
+				},
+			},
+		},
+		"pruning disabled": {
+			cfg: func(cfg *configuration.Configuration) {
+				cfg.Data.PruningDisabled = true
+			},
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					StorageSizeBytes: 200 * 1024 * 1024 * 1024,
+				},
+			},
+			expected: []*Recommendation{
+				{
+					Setting:    "pruning_disabled",
+					Suggestion: "enable pruning (set pruning_disabled to false)",
+					Reason:     "storage grew to 200.0 GiB with pruning disabled",
+				},
+			},
+		},
+		"pruning enabled": {
+			results: &CheckDataResults{
+				Stats: &CheckDataStats{
+					StorageSizeBytes: 200 * 1024 * 1024 * 1024,
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := configuration.DefaultConfiguration()
+			if test.cfg != nil {
+				test.cfg(cfg)
+			}
+
+			assert.Equal(t, test.expected, ComputeRecommendations(cfg, test.results))
+		})
+	}
+}
+
+func TestFprintRecommendations(t *testing.T) {
+	var b bytes.Buffer
+	(&CheckDataResults{
+		Recommendations: []*Recommendation{
+			{
+				Setting:    "pruning_disabled",
+				Suggestion: "enable pruning (set pruning_disabled to false)",
+				Reason:     "storage grew to 200.0 GiB with pruning disabled",
+			},
+		},
+	}).Fprint(&b)
+
+	assert.Contains(t, b.String(), "Recommendations:")
+	assert.Contains(
+		t,
+		b.String(),
+		"- enable pruning (set pruning_disabled to false): storage grew to 200.0 GiB",
+	)
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// printedReconciliationFailures is the number of
+	// reconciliation failures printed to the console
+	// (all recorded failures are saved in the results).
+	printedReconciliationFailures = 5
+
+	// reconciliationFailuresKey is the database
+	// key of the recorded reconciliation failures.
+	reconciliationFailuresKey = "reconciliation-failures"
+)
+
+// ReconciliationFailure is a reconciliation that failed
+// while running check:data. Difference is the live
+// balance minus the computed balance.
+type ReconciliationFailure struct {
+	Type            string                   `json:"type"`
+	Account         *types.AccountIdentifier `json:"account_identifier"`
+	Currency        *types.Currency          `json:"currency"`
+	ComputedBalance string                   `json:"computed_balance"`
+	LiveBalance     string                   `json:"live_balance"`
+	Difference      string                   `json:"difference,omitempty"`
+	Block           *types.BlockIdentifier   `json:"block_identifier"`
+}
+
+// BalanceDifference returns liveBalance minus
+// computedBalance (or "" if either is not an integer).
+func BalanceDifference(computedBalance string, liveBalance string) string {
+	computed, ok := new(big.Int).SetString(computedBalance, 10)
+	if !ok {
+		return ""
+	}
+
+	live, ok := new(big.Int).SetString(liveBalance, 10)
+	if !ok {
+		return ""
+	}
+
+	return new(big.Int).Sub(live, computed).String()
+}
+
+// FprintReconciliationFailures writes the first
+// few failures (and the total number of failures)
+// to w.
+func FprintReconciliationFailures(w io.Writer, failures []*ReconciliationFailure, total int64) {
+	table := newTable(w, []string{
+		"Reconciliation Failures",
+		"Account",
+		"Currency",
+		"Computed Balance",
+		"Live Balance",
+		"Difference",
+		"Block",
+	})
+	shown := failures
+	if len(shown) > printedReconciliationFailures {
+		shown = shown[:printedReconciliationFailures]
+	}
+
+	for _, failure := range shown {
+		table.Append([]string{
+			failure.Type,
+			types.PrettyPrintStruct(failure.Account),
+			failure.Currency.Symbol,
+			failure.ComputedBalance,
+			failure.LiveBalance,
+			failure.Difference,
+			fmt.Sprintf("%d (%s)", failure.Block.Index, failure.Block.Hash),
+		})
+	}
+
+	if total > int64(len(shown)) {
+		table.SetFooter([]string{
+			fmt.Sprintf("showing %d of %d", len(shown), total),
+			"", "", "", "", "", "",
+		})
+	}
+
+	table.Render()
+}
+
+// ReconciliationFailureRecorder records the first limit
+// reconciliation failures of a check:data run. If it has
+// a database, recorded failures are persisted as they occur
+// (so that they are included in the results even if the run
+// exits on the first failure or is restarted from the same
+// data directory). All methods may be called on a nil
+// *ReconciliationFailureRecorder (in which case nothing is
+// recorded).
+type ReconciliationFailureRecorder struct {
+	database storage.Database
+	failures []*ReconciliationFailure
+	limit    int
+	mutex    sync.Mutex
+}
+
+// NewReconciliationFailureRecorder returns a new
+// *ReconciliationFailureRecorder that records at
+// most limit failures. If database is not nil, any
+// failures already persisted in it are loaded.
+func NewReconciliationFailureRecorder(
+	ctx context.Context,
+	database storage.Database,
+	limit int,
+) (*ReconciliationFailureRecorder, error) {
+	r := &ReconciliationFailureRecorder{
+		database: database,
+		failures: []*ReconciliationFailure{},
+		limit:    limit,
+	}
+	if database == nil {
+		return r, nil
+	}
+
+	transaction := database.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	exists, val, err := transaction.Get(ctx, []byte(reconciliationFailuresKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get reconciliation failures", err)
+	}
+
+	if exists {
+		if err := json.Unmarshal(val, &r.failures); err != nil {
+			return nil, fmt.Errorf("%w: unable to unmarshal reconciliation failures", err)
+		}
+	}
+
+	return r, nil
+}
+
+// Add records failure (unless the limit has
+// already been reached) and persists it.
+func (r *ReconciliationFailureRecorder) Add(
+	ctx context.Context,
+	failure *ReconciliationFailure,
+) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.failures) >= r.limit {
+		return nil
+	}
+
+	r.failures = append(r.failures, failure)
+	if r.database == nil {
+		return nil
+	}
+
+	val, err := json.Marshal(r.failures)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal reconciliation failures", err)
+	}
+
+	transaction := r.database.NewDatabaseTransaction(ctx, true)
+	defer transaction.Discard(ctx)
+
+	if err := transaction.Set(ctx, []byte(reconciliationFailuresKey), val, true); err != nil {
+		return fmt.Errorf("%w: unable to store reconciliation failures", err)
+	}
+
+	if err := transaction.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: unable to commit reconciliation failures", err)
+	}
+
+	return nil
+}
+
+// Failures returns a copy of the recorded failures
+// or nil if no failures were recorded.
+func (r *ReconciliationFailureRecorder) Failures() []*ReconciliationFailure {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.failures) == 0 {
+		return nil
+	}
+
+	return append([]*ReconciliationFailure{}, r.failures...)
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciliationFailureRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	var nilRecorder *ReconciliationFailureRecorder
+	assert.NoError(t, nilRecorder.Add(ctx, &ReconciliationFailure{}))
+	assert.Nil(t, nilRecorder.Failures())
+
+	recorder, err := NewReconciliationFailureRecorder(ctx, nil, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, recorder.Failures())
+
+	failures := []*ReconciliationFailure{}
+	for i := 0; i < 3; i++ {
+		failure := &ReconciliationFailure{
+			Type:            "ACTIVE",
+			Account:         &types.AccountIdentifier{Address: fmt.Sprintf("addr%d", i)},
+			Currency:        &types.Currency{Symbol: "BTC", Decimals: 8},
+			ComputedBalance: "100",
+			LiveBalance:     "90",
+			Difference:      "-10",
+			Block:           &types.BlockIdentifier{Index: int64(i), Hash: fmt.Sprintf("block %d", i)},
+		}
+		failures = append(failures, failure)
+		assert.NoError(t, recorder.Add(ctx, failure))
+	}
+
+	// Failures beyond the limit are not recorded.
+	recorded := recorder.Failures()
+	assert.Equal(t, failures[:2], recorded)
+
+	// The returned slice is a copy.
+	recorded[0] = nil
+	assert.Equal(t, failures[:2], recorder.Failures())
+
+	var output bytes.Buffer
+	FprintReconciliationFailures(&output, recorder.Failures(), 3)
+	assert.Contains(t, output.String(), "addr1")
+	assert.NotContains(t, output.String(), "addr2")
+	assert.Contains(t, output.String(), "SHOWING 2 OF 3")
+}
+
+func TestReconciliationFailureRecorderPersisted(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	recorder, err := NewReconciliationFailureRecorder(ctx, localStore, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, recorder.Failures())
+
+	failure := &ReconciliationFailure{
+		Type:            "INACTIVE",
+		Account:         &types.AccountIdentifier{Address: "addr1"},
+		Currency:        &types.Currency{Symbol: "BTC", Decimals: 8},
+		ComputedBalance: "100",
+		LiveBalance:     "150",
+		Difference:      BalanceDifference("100", "150"),
+		Block:           &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+	}
+	assert.NoError(t, recorder.Add(ctx, failure))
+
+	// Failures recorded by a previous run are loaded
+	// and count towards the limit.
+	restarted, err := NewReconciliationFailureRecorder(ctx, localStore, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ReconciliationFailure{failure}, restarted.Failures())
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, restarted.Add(ctx, failure))
+	}
+	assert.Len(t, restarted.Failures(), 2)
+}
+
+func TestBalanceDifference(t *testing.T) {
+	assert.Equal(t, "50", BalanceDifference("100", "150"))
+	assert.Equal(t, "-10", BalanceDifference("100", "90"))
+	assert.Equal(t, "", BalanceDifference("100", "not a balance"))
+}
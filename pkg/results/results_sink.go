@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+)
+
+const (
+	// schemeSeparator separates the scheme of a
+	// results destination from the rest of it.
+	schemeSeparator = "://"
+
+	// maxSinkOutput is the most bytes of the output of
+	// a failed results sink command included in its error.
+	maxSinkOutput = 512
+)
+
+var (
+	// ErrUnsupportedResultsDestination is returned when
+	// results are saved to a URL with a scheme that
+	// does not have a ResultsSink.
+	ErrUnsupportedResultsDestination = errors.New("unsupported results destination")
+
+	_ ResultsSink = (*CommandResultsSink)(nil)
+
+	// resultsSinks are the ResultsSink of each scheme.
+	resultsSinks = map[string]ResultsSink{
+		// Uploading to S3 requires the AWS CLI to
+		// be installed and configured.
+		"s3": &CommandResultsSink{Command: []string{"aws", "s3", "cp", "-"}},
+	}
+)
+
+// ResultsSink saves results (encoded in the configured
+// results output format) to a destination that is not a
+// filepath (ex: s3://bucket/key). Save should return
+// once ctx is done.
+type ResultsSink interface {
+	Save(ctx context.Context, destination string, output []byte) error
+}
+
+// RegisterResultsSink saves results to destinations with
+// scheme (ex: "s3" for s3://bucket/key) using sink. If
+// scheme already has a ResultsSink, it is replaced. This
+// should be called before any results are saved.
+func RegisterResultsSink(scheme string, sink ResultsSink) {
+	resultsSinks[scheme] = sink
+}
+
+// resultsSinkFor returns the ResultsSink for destination
+// (or nil if destination is a filepath).
+func resultsSinkFor(destination string) (ResultsSink, error) {
+	i := strings.Index(destination, schemeSeparator)
+	if i < 0 {
+		return nil, nil
+	}
+
+	sink, ok := resultsSinks[destination[:i]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedResultsDestination, destination)
+	}
+
+	return sink, nil
+}
+
+// CommandResultsSink saves results by running Command
+// with the destination as its last argument and the
+// results as its standard input.
+type CommandResultsSink struct {
+	Command []string
+}
+
+// Save runs Command to save output to destination.
+// Command is killed if it is still running once
+// ctx is done.
+func (s *CommandResultsSink) Save(ctx context.Context, destination string, output []byte) error {
+	args := append(append([]string{}, s.Command[1:]...), destination)
+	cmd := exec.CommandContext(ctx, s.Command[0], args...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(output)
+
+	if commandOutput, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w: %s", ctx.Err(), err.Error())
+		}
+
+		if len(commandOutput) > maxSinkOutput {
+			commandOutput = commandOutput[:maxSinkOutput]
+		}
+
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(commandOutput))
+	}
+
+	return nil
+}
+
+// outputAll calls output for each destination (even if
+// saving to an earlier destination fails) and returns an
+// error describing every destination that could not be
+// saved. The returned error wraps the first error.
+func outputAll(
+	destinations []string,
+	format configuration.ResultsOutputFormat,
+	output func(string, configuration.ResultsOutputFormat) error,
+) error {
+	var firstErr error
+	otherErrs := []string{}
+	for _, destination := range destinations {
+		err := output(destination, format)
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%w: unable to save results to %s", err, destination)
+		if firstErr == nil {
+			firstErr = err
+			continue
+		}
+
+		otherErrs = append(otherErrs, err.Error())
+	}
+
+	if len(otherErrs) == 0 {
+		return firstErr
+	}
+
+	return fmt.Errorf("%w; %s", firstErr, strings.Join(otherErrs, "; "))
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink records each output
+// saved by destination.
+type recordingSink struct {
+	saved map[string][]byte
+}
+
+func (s *recordingSink) Save(ctx context.Context, destination string, output []byte) error {
+	s.saved[destination] = output
+	return nil
+}
+
+func TestResultsSinkFor(t *testing.T) {
+	sink, err := resultsSinkFor("/tmp/results.json")
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+
+	sink, err = resultsSinkFor("s3://bucket/results.json")
+	assert.NoError(t, err)
+	assert.NotNil(t, sink)
+
+	sink, err = resultsSinkFor("gs://bucket/results.json")
+	assert.True(t, errors.Is(err, ErrUnsupportedResultsDestination))
+	assert.Nil(t, sink)
+}
+
+func TestCommandResultsSink(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	resultsPath := path.Join(dir, "results.json")
+	sink := &CommandResultsSink{Command: []string{"tee"}}
+	assert.NoError(t, sink.Save(context.Background(), resultsPath, []byte("results")))
+
+	saved, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "results", string(saved))
+
+	// The output of a failed command is
+	// included in the error.
+	sink = &CommandResultsSink{Command: []string{"sh", "-c", "echo upload failed; exit 1"}}
+	err = sink.Save(context.Background(), resultsPath, []byte("results"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "upload failed")
+
+	// A command that does not finish before
+	// ctx is done is killed.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	sink = &CommandResultsSink{Command: []string{"sleep", "10"}}
+	err = sink.Save(ctx, resultsPath, []byte("results"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestOutputAll(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	sink := &recordingSink{saved: map[string][]byte{}}
+	RegisterResultsSink("test", sink)
+	defer delete(resultsSinks, "test")
+
+	results := &CheckDataResults{Stats: &CheckDataStats{Blocks: 10}}
+	localPath := path.Join(dir, "archive", "results.json")
+	remotePath := "test://bucket/results.json"
+	assert.NoError(t, results.OutputAll(
+		[]string{localPath, remotePath},
+		configuration.JSONResultsOutputFormat,
+		time.Second,
+	))
+
+	saved, err := ioutil.ReadFile(localPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(saved), string(sink.saved[remotePath]))
+
+	// Results are saved to every destination
+	// and all errors are returned.
+	blockedPath := path.Join(localPath, "results.json")
+	unsupportedPath := "gs://bucket/results.json"
+	otherPath := path.Join(dir, "nfs", "results.json")
+	err = results.OutputAll(
+		[]string{blockedPath, unsupportedPath, otherPath},
+		configuration.JSONResultsOutputFormat,
+		time.Second,
+	)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), blockedPath)
+	assert.Contains(t, err.Error(), unsupportedPath)
+	assert.NotContains(t, err.Error(), otherPath)
+	assert.FileExists(t, otherPath)
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// snapshotsTmpSuffix is appended to the snapshots
+// path while the snapshots document is written.
+const snapshotsTmpSuffix = ".tmp"
+
+// CheckDataSnapshot is a point-in-time record of the
+// stats and tests of a check:data run.
+type CheckDataSnapshot struct {
+	SnapshotAt string          `json:"snapshot_at"`
+	Tests      *CheckDataTests `json:"tests"`
+	Stats      *CheckDataStats `json:"stats"`
+}
+
+// CheckDataSnapshots is the JSON document that
+// CheckDataSnapshot are appended to (oldest first).
+type CheckDataSnapshots struct {
+	Snapshots []*CheckDataSnapshot `json:"snapshots"`
+}
+
+// NewCheckDataSnapshot returns a new *CheckDataSnapshot
+// computed at snapshotAt.
+func NewCheckDataSnapshot(
+	tests *CheckDataTests,
+	stats *CheckDataStats,
+	snapshotAt time.Time,
+) *CheckDataSnapshot {
+	return &CheckDataSnapshot{
+		SnapshotAt: snapshotAt.UTC().Format(time.RFC3339),
+		Tests:      tests,
+		Stats:      stats,
+	}
+}
+
+// AppendSnapshot appends snapshot to the snapshots array
+// of the JSON document at path (creating it if it does
+// not exist). The document is replaced atomically, so it
+// is never left partially written.
+func AppendSnapshot(path string, snapshot *CheckDataSnapshot) error {
+	snapshots := &CheckDataSnapshots{}
+	if _, err := os.Stat(path); err == nil {
+		if err := utils.LoadAndParse(path, snapshots); err != nil {
+			return fmt.Errorf("%w: unable to load snapshots", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("%w: unable to check for snapshots", err)
+	}
+
+	snapshots.Snapshots = append(snapshots.Snapshots, snapshot)
+
+	tmpPath := path + snapshotsTmpSuffix
+	if err := utils.SerializeAndWrite(tmpPath, snapshots); err != nil {
+		return fmt.Errorf("%w: unable to write snapshots", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("%w: unable to replace snapshots", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+// StorageStats contains statistics published by the
+// storage engine (badger) while running check:data. Any
+// statistic the storage engine does not publish is nil.
+//
+// Counts are totals since the start of the run, sizes
+// are from the last sample, and Max* fields are the worst
+// values observed in any sample.
+type StorageStats struct {
+	Samples int64 `json:"samples"`
+
+	DiskReads    *int64 `json:"disk_reads,omitempty"`
+	DiskWrites   *int64 `json:"disk_writes,omitempty"`
+	BytesRead    *int64 `json:"bytes_read,omitempty"`
+	BytesWritten *int64 `json:"bytes_written,omitempty"`
+	Gets         *int64 `json:"gets,omitempty"`
+	Puts         *int64 `json:"puts,omitempty"`
+	BlockedPuts  *int64 `json:"blocked_puts,omitempty"`
+
+	// LevelGets and LevelBloomHits are the number of table
+	// reads and the number of table reads avoided by a bloom
+	// filter in each level of the LSM tree (ex: "l0").
+	LevelGets      map[string]int64 `json:"level_gets,omitempty"`
+	LevelBloomHits map[string]int64 `json:"level_bloom_hits,omitempty"`
+
+	// ReadAmplification is the number of disk reads per get
+	// and BloomFilterHitRate is the proportion of table reads
+	// avoided by a bloom filter.
+	ReadAmplification  *float64 `json:"read_amplification,omitempty"`
+	BloomFilterHitRate *float64 `json:"bloom_filter_hit_rate,omitempty"`
+
+	LSMSizeBytes     *int64 `json:"lsm_size_bytes,omitempty"`
+	MaxLSMSizeBytes  *int64 `json:"max_lsm_size_bytes,omitempty"`
+	VlogSizeBytes    *int64 `json:"vlog_size_bytes,omitempty"`
+	MaxVlogSizeBytes *int64 `json:"max_vlog_size_bytes,omitempty"`
+	MaxPendingWrites *int64 `json:"max_pending_writes,omitempty"`
+}
+
+// DataSizeBytes returns the size of the LSM tree
+// and value log or nil if neither is known.
+func (s *StorageStats) DataSizeBytes() *int64 {
+	if s == nil || (s.LSMSizeBytes == nil && s.VlogSizeBytes == nil) {
+		return nil
+	}
+
+	var size int64
+	if s.LSMSizeBytes != nil {
+		size += *s.LSMSizeBytes
+	}
+	if s.VlogSizeBytes != nil {
+		size += *s.VlogSizeBytes
+	}
+
+	return &size
+}
+
+// Print logs StorageStats to the console.
+func (s *StorageStats) Print() {
+	s.Fprint(color.Output)
+}
+
+// Fprint writes StorageStats to w.
+func (s *StorageStats) Fprint(w io.Writer) {
+	table := newTable(w, []string{"check:data Storage Stats", "Description", "Value"})
+	table.Append([]string{
+		"Samples",
+		"# of times storage stats were sampled",
+		strconv.FormatInt(s.Samples, 10),
+	})
+
+	appendCount := func(name string, description string, value *int64) {
+		if value != nil {
+			table.Append([]string{name, description, strconv.FormatInt(*value, 10)})
+		}
+	}
+	appendBytes := func(name string, description string, value *int64) {
+		if value != nil {
+			table.Append([]string{name, description, formatBytes(*value)})
+		}
+	}
+	appendLevels := func(name string, description string, values map[string]int64) {
+		levels := make([]string, 0, len(values))
+		for level := range values {
+			levels = append(levels, level)
+		}
+		sort.Strings(levels)
+
+		for _, level := range levels {
+			table.Append([]string{
+				fmt.Sprintf("%s (%s)", name, level),
+				description,
+				strconv.FormatInt(values[level], 10),
+			})
+		}
+	}
+
+	appendCount("Disk Reads", "# of reads from disk", s.DiskReads)
+	appendCount("Disk Writes", "# of writes to disk", s.DiskWrites)
+	appendBytes("Bytes Read", "Bytes read from disk", s.BytesRead)
+	appendBytes("Bytes Written", "Bytes written to disk", s.BytesWritten)
+	appendCount("Gets", "# of keys read", s.Gets)
+	appendCount("Puts", "# of keys written", s.Puts)
+	appendCount("Blocked Puts", "# of writes blocked by compaction", s.BlockedPuts)
+	appendLevels("Level Gets", "# of table reads in the level", s.LevelGets)
+	appendLevels(
+		"Level Bloom Hits",
+		"# of table reads in the level avoided by a bloom filter",
+		s.LevelBloomHits,
+	)
+	if s.ReadAmplification != nil {
+		table.Append([]string{
+			"Read Amplification",
+			"# of disk reads per key read",
+			strconv.FormatFloat(*s.ReadAmplification, 'f', 2, 64),
+		})
+	}
+	if s.BloomFilterHitRate != nil {
+		table.Append([]string{
+			"Bloom Filter Hit Rate",
+			"% of table reads avoided by a bloom filter",
+			fmt.Sprintf("%f%%", *s.BloomFilterHitRate*utils.OneHundred),
+		})
+	}
+	appendBytes("LSM Size", "Size of the LSM tree", s.LSMSizeBytes)
+	appendBytes("Max LSM Size", "Largest observed size of the LSM tree", s.MaxLSMSizeBytes)
+	appendBytes("Value Log Size", "Size of the value log", s.VlogSizeBytes)
+	appendBytes("Max Value Log Size", "Largest observed size of the value log", s.MaxVlogSizeBytes)
+	appendCount("Max Pending Writes", "Most writes observed waiting to be applied", s.MaxPendingWrites)
+
+	table.Render()
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"sync"
+	"time"
+)
+
+// syncSample is the number of blocks
+// synced at a point in time.
+type syncSample struct {
+	at     time.Time
+	blocks int64
+}
+
+// SyncRate computes the sync rate over a moving window
+// of samples. Samples older than the window are dropped
+// (except the newest of them, which starts the window),
+// so only a few samples are ever kept.
+type SyncRate struct {
+	window time.Duration
+
+	samples []*syncSample
+	mutex   sync.Mutex
+}
+
+// SyncRateEstimate is the sync rate (in blocks per
+// second) over the window of a *SyncRate and the
+// slowest and fastest rates between consecutive
+// samples in the window.
+type SyncRateEstimate struct {
+	Rate    float64
+	Slowest float64
+	Fastest float64
+}
+
+// NewSyncRate returns a new *SyncRate
+// that computes the rate over window.
+func NewSyncRate(window time.Duration) *SyncRate {
+	return &SyncRate{window: window}
+}
+
+// Add records that blocks were synced at at and returns
+// the *SyncRateEstimate over the window. If samples don't
+// yet cover the window, nil is returned.
+func (s *SyncRate) Add(at time.Time, blocks int64) *SyncRateEstimate {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Samples are recorded from multiple goroutines
+	// (ex: the periodic logger and the status server),
+	// so we ignore any that arrive out of order.
+	if len(s.samples) > 0 && !at.After(s.samples[len(s.samples)-1].at) {
+		return s.estimate()
+	}
+
+	s.samples = append(s.samples, &syncSample{at: at, blocks: blocks})
+
+	// We drop every sample that is older than the
+	// window and isn't needed to start it.
+	windowStart := at.Add(-s.window)
+	drop := 0
+	for drop+1 < len(s.samples) && !s.samples[drop+1].at.After(windowStart) {
+		drop++
+	}
+	s.samples = s.samples[drop:]
+
+	return s.estimate()
+}
+
+// estimate returns the *SyncRateEstimate of the
+// samples if they span the window.
+func (s *SyncRate) estimate() *SyncRateEstimate {
+	oldest := s.samples[0]
+	newest := s.samples[len(s.samples)-1]
+	elapsed := newest.at.Sub(oldest.at)
+	if elapsed <= 0 || elapsed < s.window {
+		return nil
+	}
+
+	estimate := &SyncRateEstimate{
+		Rate: float64(newest.blocks-oldest.blocks) / elapsed.Seconds(),
+	}
+	for i := 1; i < len(s.samples); i++ {
+		previous, current := s.samples[i-1], s.samples[i]
+		rate := float64(current.blocks-previous.blocks) / current.at.Sub(previous.at).Seconds()
+		if i == 1 || rate < estimate.Slowest {
+			estimate.Slowest = rate
+		}
+		if i == 1 || rate > estimate.Fastest {
+			estimate.Fastest = rate
+		}
+	}
+
+	return estimate
+}
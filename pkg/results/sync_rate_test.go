@@ -0,0 +1,55 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncRate(t *testing.T) {
+	start := time.Date(2020, time.October, 16, 12, 0, 0, 0, time.UTC)
+	s := NewSyncRate(time.Minute)
+
+	// Samples don't cover the window yet.
+	assert.Nil(t, s.Add(start, 0))
+	assert.Nil(t, s.Add(start.Add(30*time.Second), 30))
+
+	// 1 block per second for the first 30 seconds
+	// and 2 blocks per second for the next 30 seconds.
+	expected := &SyncRateEstimate{Rate: 1.5, Slowest: 1, Fastest: 2}
+	assert.Equal(t, expected, s.Add(start.Add(time.Minute), 90))
+
+	// Out of order samples are ignored.
+	assert.Equal(t, expected, s.Add(start.Add(45*time.Second), 1000))
+
+	// 10 blocks per second after the first minute.
+	var estimate *SyncRateEstimate
+	for i := 1; i <= 6; i++ {
+		estimate = s.Add(start.Add(time.Minute+time.Duration(i)*10*time.Second), int64(90+i*100))
+		assert.NotNil(t, estimate)
+	}
+	assert.Equal(t, &SyncRateEstimate{Rate: 10, Slowest: 10, Fastest: 10}, estimate)
+
+	// Only samples needed to cover the window are kept.
+	assert.Len(t, s.samples, 7)
+}
+
+func TestSyncRate_Nil(t *testing.T) {
+	var s *SyncRate
+	assert.Nil(t, s.Add(time.Now(), 10))
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// TransferAsymmetryLimit is the most unbalanced
+	// transactions recorded in the results (all are
+	// counted).
+	TransferAsymmetryLimit = 100
+
+	// printedTransferAsymmetries is the number of
+	// unbalanced transactions printed to the console.
+	printedTransferAsymmetries = 5
+)
+
+// TransferAsymmetry is a transaction where the sum of the
+// debits did not equal the sum of the credits. Imbalances
+// is the net amount of each unbalanced currency.
+type TransferAsymmetry struct {
+	Block       *types.BlockIdentifier       `json:"block_identifier"`
+	Transaction *types.TransactionIdentifier `json:"transaction_identifier"`
+	Imbalances  []*types.Amount              `json:"imbalances"`
+}
+
+// FormatImbalances returns a string
+// describing each imbalance.
+func FormatImbalances(imbalances []*types.Amount) string {
+	formatted := make([]string, len(imbalances))
+	for i, imbalance := range imbalances {
+		formatted[i] = fmt.Sprintf("%s %s", imbalance.Value, imbalance.Currency.Symbol)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// FprintTransferAsymmetries writes the first few
+// unbalanced transactions (and the total number of
+// unbalanced transactions) to w.
+func FprintTransferAsymmetries(w io.Writer, asymmetries []*TransferAsymmetry, total int64) {
+	table := newTable(w, []string{
+		"Unbalanced Transactions",
+		"Block",
+		"Imbalance",
+	})
+	shown := asymmetries
+	if len(shown) > printedTransferAsymmetries {
+		shown = shown[:printedTransferAsymmetries]
+	}
+
+	for _, asymmetry := range shown {
+		table.Append([]string{
+			asymmetry.Transaction.Hash,
+			fmt.Sprintf("%d (%s)", asymmetry.Block.Index, asymmetry.Block.Hash),
+			FormatImbalances(asymmetry.Imbalances),
+		})
+	}
+
+	if total > int64(len(shown)) {
+		table.SetFooter([]string{
+			fmt.Sprintf("showing %d of %d", len(shown), total),
+			"", "",
+		})
+	}
+
+	table.Render()
+}
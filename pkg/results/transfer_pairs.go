@@ -0,0 +1,62 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+)
+
+// TransferPair is a sender and recipient of value and the
+// number of transfers between them. Count may overestimate
+// the number of transfers by at most Error.
+type TransferPair struct {
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Count     int64  `json:"count"`
+	Error     int64  `json:"error,omitempty"`
+}
+
+// TransferPairStats characterizes how much of the transaction
+// graph was exercised by the synced blocks. A transfer is a pair
+// of successful operations in the same transaction (and currency)
+// where one account is debited and the other is credited.
+//
+// EstimatedDistinctPairs is an estimate (with a standard error
+// of ~1.6%) of the number of distinct sender and recipient pairs.
+// Pairs in orphaned blocks are not removed from the estimate, so
+// it may slightly overestimate pairs on networks with reorgs.
+// TopPairs are the most frequent pairs (approximately).
+type TransferPairStats struct {
+	Transfers              int64           `json:"transfers"`
+	EstimatedDistinctPairs int64           `json:"estimated_distinct_pairs"`
+	TopPairs               []*TransferPair `json:"top_pairs,omitempty"`
+}
+
+// printTransferPairs writes *TransferPairStats to w.
+func printTransferPairs(w io.Writer, stats *TransferPairStats) {
+	table := newTable(w, []string{"check:data Transfer Pairs", "Description", "Value"})
+	table.Append([]string{
+		"Transfers",
+		"# of sender and recipient transfers",
+		strconv.FormatInt(stats.Transfers, 10),
+	})
+	table.Append([]string{
+		"Distinct Pairs",
+		"Estimated # of distinct sender and recipient pairs",
+		strconv.FormatInt(stats.EstimatedDistinctPairs, 10),
+	})
+	table.Render()
+}
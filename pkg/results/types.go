@@ -16,16 +16,222 @@ package results
 
 import (
 	"errors"
+	"fmt"
 )
 
 const (
 	// TimeElapsedCounter tracks the total time elapsed in seconds.
 	TimeElapsedCounter = "time_elapsed"
+
+	// ReconciliationFailureCounter tracks the number of
+	// reconciliations that failed.
+	ReconciliationFailureCounter = "reconciliation_failures"
+
+	// OrphanedTransactionCounter tracks the number of
+	// transactions in orphaned blocks.
+	OrphanedTransactionCounter = "orphaned_transactions"
+
+	// OrphanedOperationCounter tracks the number of
+	// operations in orphaned blocks.
+	OrphanedOperationCounter = "orphaned_operations"
+
+	// CoinCounter tracks the number of
+	// coins created or spent.
+	CoinCounter = "coins"
+
+	// ReversedCoinCounter tracks the number of coins
+	// created or spent in orphaned blocks (each of
+	// which was reversed when the block was removed).
+	ReversedCoinCounter = "reversed_coins"
+
+	// EndpointDisagreementCounter tracks the number of
+	// live balances that differed between the primary
+	// and secondary Rosetta endpoints.
+	EndpointDisagreementCounter = "endpoint_disagreements"
+
+	// DeferredReconciliationCounter tracks the number of
+	// reconciliations deferred because the account was
+	// updated after the block of its live balance.
+	DeferredReconciliationCounter = "deferred_reconciliations"
+
+	// PeakMemoryCounter tracks the most memory
+	// (in bytes) obtained from the OS by any
+	// check:data run sampled with SampleMemory.
+	PeakMemoryCounter = "peak_memory"
+
+	// ChainTransactionCounter and ChainOperationCounter track
+	// the number of transactions and operations in canonical
+	// blocks after the first block synced (used to compute the
+	// chain's average activity).
+	ChainTransactionCounter = "chain_transactions"
+	ChainOperationCounter   = "chain_operations"
+
+	// ChainStartTimestampCounter and ChainEndTimestampCounter
+	// track the timestamps (in milliseconds) of the first block
+	// synced and of the head block.
+	ChainStartTimestampCounter = "chain_start_timestamp"
+	ChainEndTimestampCounter   = "chain_end_timestamp"
+
+	// ChainHeadIndexCounter tracks the index of the head
+	// block (used to compute the reconciliation coverage
+	// over the last coverage_lookback_blocks blocks).
+	ChainHeadIndexCounter = "chain_head_index"
+
+	// ChainPeakTransactionCounter and ChainPeakDurationCounter
+	// track the number of transactions and the duration (in
+	// milliseconds) of the busiest interval between block
+	// timestamps. ChainPeakStartCounter and ChainPeakEndCounter
+	// track the indexes of the blocks in that interval.
+	ChainPeakTransactionCounter = "chain_peak_transactions"
+	ChainPeakDurationCounter    = "chain_peak_duration"
+	ChainPeakStartCounter       = "chain_peak_start"
+	ChainPeakEndCounter         = "chain_peak_end"
+
+	// TrustedBlockCounter, TrustedTransactionCounter, and
+	// TrustedOperationCounter track the canonical blocks (and
+	// their transactions and operations) synced at or below the
+	// trusted checkpoint (which are not asserted).
+	TrustedBlockCounter       = "trusted_blocks"
+	TrustedTransactionCounter = "trusted_transactions"
+	TrustedOperationCounter   = "trusted_operations"
+
+	// ValidatedBlockCounter, ValidatedTransactionCounter, and
+	// ValidatedOperationCounter track the canonical blocks (and
+	// their transactions and operations) synced after the
+	// trusted checkpoint.
+	ValidatedBlockCounter       = "validated_blocks"
+	ValidatedTransactionCounter = "validated_transactions"
+	ValidatedOperationCounter   = "validated_operations"
+
+	// RecentOrphanCounter tracks the number of blocks orphaned
+	// at indices in the orphan rate window (the last
+	// orphan_rate_window indices synced).
+	RecentOrphanCounter = "recent_orphans"
+
+	// TrackedBalanceChangeCounter tracks the number of
+	// balance changes in tracked currencies (all currencies
+	// if no currencies are configured).
+	TrackedBalanceChangeCounter = "tracked_balance_changes"
+
+	// AccountsTrackedCounter and CurrenciesSeenCounter track
+	// the number of distinct accounts (each AccountIdentifier/Currency
+	// pair) and currencies under balance tracking.
+	AccountsTrackedCounter = "accounts_tracked"
+	CurrenciesSeenCounter  = "currencies_seen"
+
+	// ReconciliationsEnqueuedCounter tracks the number of balance
+	// changes queued for active reconciliation and
+	// ReconciliationsProcessedCounter tracks the number of active
+	// reconciliations performed (successful or not).
+	ReconciliationsEnqueuedCounter  = "reconciliations_enqueued"
+	ReconciliationsProcessedCounter = "reconciliations_processed"
+
+	// ReconciliationsSkippedBacklogCounter tracks the (estimated)
+	// number of balance changes dropped by the reconciler because
+	// its backlog was full and ReconciliationsSkippedPrunedCounter
+	// tracks the number of reconciliations skipped because the block
+	// of the live balance was already pruned.
+	ReconciliationsSkippedBacklogCounter = "reconciliations_skipped_backlog"
+	ReconciliationsSkippedPrunedCounter  = "reconciliations_skipped_pruned"
+
+	// ReconciliationsSupersededCounter tracks the number of
+	// balance changes that were not queued for active
+	// reconciliation because the account was already queued
+	// (they are covered by its next active reconciliation).
+	ReconciliationsSupersededCounter = "reconciliations_superseded"
+
+	// FetchFailureCounter tracks the number of requests to the
+	// implementation that failed (including those later retried)
+	// and FetchRetryCounter tracks the number of requests that
+	// repeated a failed request.
+	FetchFailureCounter = "fetch_failures"
+	FetchRetryCounter   = "fetch_retries"
+
+	// HashMismatchCounter tracks the number of broadcast
+	// transactions found on-chain under a different hash
+	// than the one returned by /construction/hash.
+	HashMismatchCounter = "hash_mismatches"
+
+	// TransferSymmetryCheckedCounter tracks the number of
+	// transactions checked for balanced debits and credits
+	// and TransferSymmetryViolationCounter tracks the number
+	// of those that were not balanced.
+	TransferSymmetryCheckedCounter   = "transfer_symmetry_checked"
+	TransferSymmetryViolationCounter = "transfer_symmetry_violations"
+
+	// ZeroFeeBlockCounter tracks the number of canonical
+	// blocks with transactions but no fee operations.
+	ZeroFeeBlockCounter = "zero_fee_blocks"
+
+	// recentOrphanIndexCounterPrefix is prepended to
+	// an index to get its recent orphan counter.
+	recentOrphanIndexCounterPrefix = "recent_orphan_index"
+
+	// operationTypeCounterPrefix is prepended to the
+	// type of an operation to get its counter.
+	operationTypeCounterPrefix = "operation_type"
+
+	// operationStatusCounterPrefix is prepended to the
+	// status of an operation to get its counter.
+	operationStatusCounterPrefix = "operation_status"
 )
 
+// RecentOrphanIndexCounter returns the name of the
+// counter that tracks the number of blocks orphaned at
+// index while index is in the orphan rate window.
+func RecentOrphanIndexCounter(index int64) string {
+	return fmt.Sprintf("%s/%d", recentOrphanIndexCounterPrefix, index)
+}
+
+// OperationTypeCounter returns the name of the
+// counter that tracks the number of operations
+// of operationType.
+func OperationTypeCounter(operationType string) string {
+	return fmt.Sprintf("%s/%s", operationTypeCounterPrefix, operationType)
+}
+
+// OperationStatusCounter returns the name of the
+// counter that tracks the number of operations
+// with operationStatus.
+func OperationStatusCounter(operationStatus string) string {
+	return fmt.Sprintf("%s/%s", operationStatusCounterPrefix, operationStatus)
+}
+
 var (
 	// ErrReconciliationFailure is returned if reconciliation fails.
 	// TODO: Move to reconciler package (had to remove from processor
 	// to prevent circular dependency)
 	ErrReconciliationFailure = errors.New("reconciliation failure")
+
+	// ErrCoinAmountInvalid is returned if a coin is created
+	// with a negative amount or spent with a positive amount.
+	ErrCoinAmountInvalid = errors.New("invalid coin amount")
+
+	// ErrCoinSpentBeforeCreated is returned if a coin is
+	// spent that was never created.
+	ErrCoinSpentBeforeCreated = errors.New("coin spent before it was created")
+
+	// ErrOrphanedCoinNotReversed is returned if a coin created
+	// in an orphaned block is still spendable or a coin spent
+	// in an orphaned block is not spendable again once the
+	// block is removed.
+	ErrOrphanedCoinNotReversed = errors.New("coin change in orphaned block not reversed")
+
+	// ErrFetchTimeout is returned by JSONFetch if a
+	// request does not complete within its timeout.
+	ErrFetchTimeout = errors.New("fetch timed out")
+
+	// ErrAssertionFindings is returned if any transactions failed
+	// assertion while running with assertion_soft_fail enabled.
+	ErrAssertionFindings = errors.New("assertion failures found")
+
+	// ErrBlockPayloadViolations is returned if any /block response
+	// was larger than max_block_payload_bytes while running with
+	// fail_on_block_payload_violation enabled.
+	ErrBlockPayloadViolations = errors.New("block payload violations found")
+
+	// ErrTransferAsymmetry is returned if the debits and credits
+	// of a transaction are not balanced while running with a
+	// fatal transfer symmetry check.
+	ErrTransferAsymmetry = errors.New("debits and credits are not balanced")
 )
@@ -0,0 +1,96 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+)
+
+// bytesUnit is the number of bytes
+// in each unit used by formatBytes.
+const bytesUnit = 1024
+
+// DirectorySize returns the total size (in bytes)
+// of all files in dir and its subdirectories.
+func DirectorySize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to walk %s", err, dir)
+	}
+
+	return size, nil
+}
+
+// SampleMemory updates PeakMemoryCounter if the memory
+// currently obtained from the OS (excluding memory that
+// has been released back to the OS) exceeds it.
+func SampleMemory(ctx context.Context, counters *storage.CounterStorage) error {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	current := int64(memStats.Sys - memStats.HeapReleased)
+
+	peak, err := counters.Get(ctx, PeakMemoryCounter)
+	if err != nil {
+		return fmt.Errorf("%w: cannot get peak memory counter", err)
+	}
+
+	if current <= peak.Int64() {
+		return nil
+	}
+
+	if _, err := counters.Update(
+		ctx,
+		PeakMemoryCounter,
+		new(big.Int).Sub(big.NewInt(current), peak),
+	); err != nil {
+		return fmt.Errorf("%w: cannot update peak memory counter", err)
+	}
+
+	return nil
+}
+
+// formatBytes returns a human-readable
+// representation of bytes (ex: 1.5 GiB).
+func formatBytes(bytes int64) string {
+	if bytes < bytesUnit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(bytesUnit), 0
+	for n := bytes / bytesUnit; n >= bytesUnit; n /= bytesUnit {
+		div *= bytesUnit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectorySize(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "a"), make([]byte, 100), os.FileMode(0600)))
+	assert.NoError(t, os.Mkdir(path.Join(dir, "sub"), os.FileMode(0700)))
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "sub", "b"), make([]byte, 50), os.FileMode(0600)))
+
+	size, err := DirectorySize(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), size)
+
+	size, err = DirectorySize(path.Join(dir, "missing"))
+	assert.Error(t, err)
+	assert.Equal(t, int64(-1), size)
+}
+
+func TestSampleMemory(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	localStore, err := storage.NewBadgerStorage(
+		ctx,
+		dir,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+	defer localStore.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(localStore)
+	assert.NoError(t, SampleMemory(ctx, counterStorage))
+
+	peak, err := counterStorage.Get(ctx, PeakMemoryCounter)
+	assert.NoError(t, err)
+	assert.True(t, peak.Int64() > 0)
+
+	// The peak should never decrease.
+	assert.NoError(t, SampleMemory(ctx, counterStorage))
+	newPeak, err := counterStorage.Get(ctx, PeakMemoryCounter)
+	assert.NoError(t, err)
+	assert.True(t, newPeak.Cmp(peak) >= 0)
+}
+
+func TestFormatBytes(t *testing.T) {
+	var tests = map[string]struct {
+		bytes    int64
+		expected string
+	}{
+		"zero": {
+			expected: "0 B",
+		},
+		"bytes": {
+			bytes:    1023,
+			expected: "1023 B",
+		},
+		"kibibytes": {
+			bytes:    1536,
+			expected: "1.5 KiB",
+		},
+		"gibibytes": {
+			bytes:    5 * 1024 * 1024 * 1024,
+			expected: "5.0 GiB",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, formatBytes(test.bytes))
+		})
+	}
+}
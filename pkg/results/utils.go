@@ -15,33 +15,237 @@
 package results
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
 )
 
+// DefaultJSONFetchTimeout is the timeout used by
+// JSONFetch if no timeout is provided.
+const DefaultJSONFetchTimeout = 30 * time.Second
+
+// JSONFetchBasicAuth is the username and password
+// sent with a JSONFetch request.
+type JSONFetchBasicAuth struct {
+	Username string
+	Password string
+}
+
+// JSONFetchOptions configures a JSONFetch request.
+// All fields are optional.
+type JSONFetchOptions struct {
+	// Context is used to cancel the request. If it is
+	// not populated, context.Background() is used.
+	Context context.Context
+
+	// Timeout is the most time the request (including
+	// reading the response) may take. If it is not
+	// populated, DefaultJSONFetchTimeout is used.
+	Timeout time.Duration
+
+	// Headers are added to the request (ex: for a
+	// status endpoint behind an auth proxy).
+	Headers map[string]string
+
+	// BasicAuth is sent with the request if populated.
+	BasicAuth *JSONFetchBasicAuth
+}
+
 // JSONFetch makes a GET request to the URL and marshals
-// the response into output.
-func JSONFetch(url string, output interface{}) error {
-	resp, err := http.Get(url) // #nosec
+// the response into output. If the request does not complete
+// within the timeout, an error wrapping ErrFetchTimeout is
+// returned. opts can be nil.
+func JSONFetch(url string, output interface{}, opts *JSONFetchOptions) error {
+	if opts == nil {
+		opts = &JSONFetchOptions{}
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultJSONFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, status, err := jsonFetchBody(ctx, url, opts)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: GET %s did not complete within %s", ErrFetchTimeout, url, timeout)
+		}
+
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("received %d status with body %s", status, body)
+	}
+
+	if err := json.Unmarshal(body, output); err != nil {
+		return fmt.Errorf("%w: unable to unmarshal JSON", err)
+	}
+
+	return nil
+}
+
+// jsonFetchBody returns the body and status code
+// of a GET request to url.
+func jsonFetchBody(ctx context.Context, url string, opts *JSONFetchOptions) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: unable to create GET %s", err, url)
+	}
+
+	for key, value := range opts.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if opts.BasicAuth != nil {
+		req.SetBasicAuth(opts.BasicAuth.Username, opts.BasicAuth.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req) // #nosec
 	if err != nil {
-		return fmt.Errorf("%w: unable to fetch GET %s", err, url)
+		return nil, 0, fmt.Errorf("%w: unable to fetch GET %s", err, url)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("%w: unable to read body", err)
+		return nil, 0, fmt.Errorf("%w: unable to read body", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("received %d status with body %s", resp.StatusCode, body)
+	return body, resp.StatusCode, nil
+}
+
+// writesToStdout returns a boolean indicating if
+// results are written to stdout (instead of to path).
+func writesToStdout(path string, format configuration.ResultsOutputFormat) bool {
+	return len(path) == 0 && format == configuration.NDJSONResultsOutputFormat
+}
+
+// encodeNDJSON returns results as a single line of JSON.
+func encodeNDJSON(results interface{}) ([]byte, error) {
+	output, err := json.Marshal(results)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal results", err)
 	}
 
-	if err := json.Unmarshal(body, output); err != nil {
-		return fmt.Errorf("%w: unable to unmarshal JSON", err)
+	return append(output, '\n'), nil
+}
+
+// writeNDJSON writes results as a single line of JSON
+// to the provided path (or to stdout if path is empty).
+// Because encoding/json sorts map keys, the same results
+// are always written identically.
+func writeNDJSON(path string, results interface{}) error {
+	output, err := encodeNDJSON(results)
+	if err != nil {
+		return err
+	}
+
+	if len(path) == 0 {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("%w: unable to write results to stdout", err)
+		}
+
+		return nil
+	}
+
+	if err := ioutil.WriteFile(path, output, os.FileMode(utils.DefaultFilePermissions)); err != nil {
+		return fmt.Errorf("%w: unable to write to file path %s", err, path)
 	}
 
 	return nil
 }
+
+// RunTiming is the start time, end time, and duration
+// (in seconds) of a check:data or check:construction run.
+type RunTiming struct {
+	StartedAt string  `json:"started_at"`
+	EndedAt   string  `json:"ended_at"`
+	Duration  float64 `json:"duration"`
+}
+
+// NewRunTiming returns a *RunTiming for a run that
+// started at startedAt and ended at endedAt.
+func NewRunTiming(startedAt time.Time, endedAt time.Time) *RunTiming {
+	return &RunTiming{
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+		EndedAt:   endedAt.UTC().Format(time.RFC3339),
+		Duration:  endedAt.Sub(startedAt).Seconds(),
+	}
+}
+
+// Print logs RunTiming to the console.
+func (r *RunTiming) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes the elapsed time of the run to w.
+func (r *RunTiming) Fprint(w io.Writer) {
+	elapsed := time.Duration(r.Duration * float64(time.Second))
+	fmt.Fprintf(w, "Elapsed Time: %s\n", elapsed.Round(time.Second))
+}
+
+// HumanizedDurationLimit is the longest duration
+// rendered by HumanizeSeconds. Longer durations (ex:
+// the time remaining early in a sync, when the sync
+// rate is tiny) are rendered as ">30d".
+const HumanizedDurationLimit = 30 * 24 * time.Hour
+
+// HumanizeSeconds renders a number of seconds as
+// days, hours, and minutes (ex: "3d 4h 12m"). Seconds
+// are only included for durations under an hour.
+func HumanizeSeconds(seconds float64) string {
+	// We compare seconds before converting to a time.Duration
+	// because very large values overflow a time.Duration.
+	if seconds > HumanizedDurationLimit.Seconds() {
+		return fmt.Sprintf(">%dd", HumanizedDurationLimit/(24*time.Hour))
+	}
+
+	remaining := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	if remaining <= 0 {
+		return "0s"
+	}
+
+	parts := []string{}
+	days := remaining / (24 * time.Hour)
+	remaining -= days * 24 * time.Hour
+	hours := remaining / time.Hour
+	remaining -= hours * time.Hour
+	minutes := remaining / time.Minute
+	remaining -= minutes * time.Minute
+
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	if days == 0 && hours == 0 && remaining > 0 {
+		parts = append(parts, fmt.Sprintf("%ds", remaining/time.Second))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -15,11 +15,21 @@
 package results
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,7 +72,7 @@ func TestJSONFetch(t *testing.T) {
 			defer ts.Close()
 
 			var obj map[string]interface{}
-			err := JSONFetch(ts.URL, &obj)
+			err := JSONFetch(ts.URL, &obj, nil)
 			if len(test.expectedError) > 0 {
 				assert.EqualError(t, err, test.expectedError)
 				assert.Len(t, obj, 0)
@@ -73,3 +83,216 @@ func TestJSONFetch(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONFetchOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+		assert.Equal(t, "token", r.Header.Get("X-Auth-Token"))
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, `{"test":"123"}`)
+	}))
+	defer ts.Close()
+
+	var obj map[string]interface{}
+	assert.NoError(t, JSONFetch(ts.URL, &obj, &JSONFetchOptions{
+		Headers:   map[string]string{"X-Auth-Token": "token"},
+		BasicAuth: &JSONFetchBasicAuth{Username: "user", Password: "pass"},
+	}))
+	assert.Equal(t, map[string]interface{}{"test": "123"}, obj)
+}
+
+func TestJSONFetchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A wedged server never responds.
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	var obj map[string]interface{}
+	err := JSONFetch(ts.URL, &obj, &JSONFetchOptions{Timeout: 10 * time.Millisecond})
+	assert.True(t, errors.Is(err, ErrFetchTimeout))
+	assert.Contains(t, err.Error(), "did not complete within 10ms")
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	resultsPath := path.Join(dir, "results.ndjson")
+	assert.NoError(t, writeNDJSON(resultsPath, map[string]interface{}{
+		"b": []int{1, 2},
+		"a": map[string]string{"d": "4", "c": "3"},
+	}))
+
+	output, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"a\":{\"c\":\"3\",\"d\":\"4\"},\"b\":[1,2]}\n", string(output))
+}
+
+func TestWriteResultsDeterministic(t *testing.T) {
+	keys := make([]string, 20)
+	counts := map[string]int64{}
+	latency := map[string]*EndpointStats{}
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key %d", i)
+		counts[keys[i]] = int64(i)
+		latency[keys[i]] = &EndpointStats{Count: int64(i), Mean: float64(i) / 3}
+	}
+	sort.Strings(keys)
+
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	blockIndex := int64(10)
+	stats := &CheckDataStats{
+		Blocks:            10,
+		OperationTypes:    counts,
+		OperationStatuses: counts,
+		EndpointLatency:   latency,
+		BlockPayloads: &BlockPayloadStats{
+			Count: 1,
+			Largest: []*BlockPayload{
+				{Block: &types.PartialBlockIdentifier{Index: &blockIndex}, Bytes: 10},
+			},
+		},
+	}
+	progress := &CheckDataProgress{Blocks: 10, Tip: 100}
+	constructionStats := &CheckConstructionStats{WorkflowsCompleted: counts}
+
+	var tests = map[string]interface{}{
+		"check:data results": &CheckDataResults{
+			Tests:             &CheckDataTests{RequestResponse: true},
+			Stats:             stats,
+			AssertionFindings: &AssertionFindings{RuleCounts: counts},
+			StorageStats:      &StorageStats{LevelGets: counts, LevelBloomHits: counts},
+			EndCondition:      &EndCondition{Type: configuration.TipEndCondition},
+			Network:           network,
+			RunTiming:         &RunTiming{Duration: 10},
+		},
+		"check:data status": &CheckDataStatus{
+			Stats:    stats,
+			Progress: progress,
+		},
+		"check:construction results": &CheckConstructionResults{
+			EndConditions: map[string]int{"transfer": 1, "create_account": 2, "return_funds": 3},
+			Stats:         constructionStats,
+			Network:       network,
+			RunTiming:     &RunTiming{Duration: 10},
+		},
+		"check:construction status": &CheckConstructionStatus{
+			Stats:    constructionStats,
+			Progress: &CheckConstructionProgress{Broadcasting: 1},
+		},
+	}
+
+	for name, results := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			// Map iteration order is random, so serializing the
+			// same results many times would surface any map that
+			// is not written with sorted keys.
+			var ndjson string
+			var pretty string
+			for i := 0; i < 20; i++ {
+				ndjsonPath := path.Join(dir, fmt.Sprintf("results-%d.ndjson", i))
+				assert.NoError(t, writeNDJSON(ndjsonPath, results))
+				output, err := ioutil.ReadFile(ndjsonPath)
+				assert.NoError(t, err)
+
+				prettyPath := path.Join(dir, fmt.Sprintf("results-%d.json", i))
+				assert.NoError(t, utils.SerializeAndWrite(prettyPath, results))
+				prettyOutput, err := ioutil.ReadFile(prettyPath)
+				assert.NoError(t, err)
+
+				if i == 0 {
+					ndjson = string(output)
+					pretty = string(prettyOutput)
+					continue
+				}
+
+				assert.Equal(t, ndjson, string(output))
+				assert.Equal(t, pretty, string(prettyOutput))
+			}
+
+			// Keys of each map are written in sorted order.
+			last := -1
+			for _, key := range keys {
+				index := strings.Index(ndjson, fmt.Sprintf("%q", key))
+				assert.Greater(t, index, last)
+				last = index
+			}
+		})
+	}
+}
+
+func TestWritesToStdout(t *testing.T) {
+	assert.True(t, writesToStdout("", configuration.NDJSONResultsOutputFormat))
+	assert.False(t, writesToStdout("results.ndjson", configuration.NDJSONResultsOutputFormat))
+	assert.False(t, writesToStdout("", configuration.JSONResultsOutputFormat))
+}
+
+func TestNewRunTiming(t *testing.T) {
+	startedAt := time.Date(2020, time.October, 16, 12, 0, 0, 0, time.FixedZone("PDT", -7*60*60))
+	endedAt := startedAt.Add(2*time.Hour + 1500*time.Millisecond)
+
+	runTiming := NewRunTiming(startedAt, endedAt)
+	assert.Equal(t, &RunTiming{
+		StartedAt: "2020-10-16T19:00:00Z",
+		EndedAt:   "2020-10-16T21:00:01Z",
+		Duration:  7201.5,
+	}, runTiming)
+	runTiming.Print() // make sure doesn't panic
+}
+
+func TestHumanizeSeconds(t *testing.T) {
+	var tests = map[string]struct {
+		seconds  float64
+		expected string
+	}{
+		"zero": {
+			expected: "0s",
+		},
+		"seconds": {
+			seconds:  45,
+			expected: "45s",
+		},
+		"minutes and seconds": {
+			seconds:  90,
+			expected: "1m 30s",
+		},
+		"days, hours, and minutes": {
+			seconds:  (3*24*60*60 + 4*60*60 + 12*60 + 5),
+			expected: "3d 4h 12m",
+		},
+		"limit": {
+			seconds:  HumanizedDurationLimit.Seconds(),
+			expected: "30d",
+		},
+		"over limit": {
+			seconds:  HumanizedDurationLimit.Seconds() + 1,
+			expected: ">30d",
+		},
+		"overflows duration": {
+			seconds:  (2562047 * time.Hour).Seconds() * 10,
+			expected: ">30d",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, HumanizeSeconds(test.seconds))
+		})
+	}
+}
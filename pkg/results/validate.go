@@ -0,0 +1,353 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// CheckDataResultsKind is the kind of
+	// results file saved by check:data.
+	CheckDataResultsKind = "check:data"
+
+	// CheckConstructionResultsKind is the kind of
+	// results file saved by check:construction.
+	CheckConstructionResultsKind = "check:construction"
+)
+
+var (
+	// ErrInvalidResultsFile is returned when a results
+	// file is not a JSON object.
+	ErrInvalidResultsFile = errors.New("results file is not a JSON object")
+
+	// ErrResultsFailed is returned when a results
+	// file contains a failed test (or an error).
+	ErrResultsFailed = errors.New("results contain a failure")
+
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// ResultsValidation is the outcome of validating a results
+// file against the results of the closest matching kind.
+type ResultsValidation struct {
+	Kind string
+
+	// Partial is true if the file contains partial
+	// results written during a check:data run.
+	Partial bool
+
+	// Problems contains a description of each unknown
+	// field, missing field, or field with the wrong type
+	// (ex: "unknown field stats.foo"). If the file is
+	// valid, Problems is empty.
+	Problems []string
+}
+
+// ValidateResultsFile validates the JSON results file at
+// filePath (saved by check:data or check:construction).
+func ValidateResultsFile(filePath string) (*ResultsValidation, error) {
+	contents, err := ioutil.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read results file", err)
+	}
+
+	return ValidateResults(contents)
+}
+
+// ValidateResults validates the JSON contents of a results
+// file. Each field must be known, have the correct type, and
+// be present unless it is omitted when empty. Because the
+// kind of results is not recorded in the file, contents is
+// validated as each kind and the kind with the fewest
+// problems is returned.
+func ValidateResults(contents []byte) (*ResultsValidation, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(contents, &raw); err != nil || raw == nil {
+		return nil, ErrInvalidResultsFile
+	}
+
+	partial, _ := raw["partial"].(bool)
+
+	var best *ResultsValidation
+	for _, kind := range []struct {
+		name   string
+		target interface{}
+	}{
+		{name: CheckDataResultsKind, target: &CheckDataResults{}},
+		{name: CheckConstructionResultsKind, target: &CheckConstructionResults{}},
+	} {
+		validation := &ResultsValidation{
+			Kind:     kind.name,
+			Partial:  partial,
+			Problems: compareFields("", raw, reflect.TypeOf(kind.target)),
+		}
+
+		// Unknown fields are already reported (with their
+		// path), so we only report other decoding errors
+		// (ex: a field with the wrong type).
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(kind.target); err != nil &&
+			!strings.HasPrefix(err.Error(), "json: unknown field") {
+			validation.Problems = append(validation.Problems, err.Error())
+		}
+
+		if best == nil || len(validation.Problems) < len(best.Problems) {
+			best = validation
+		}
+	}
+
+	return best, nil
+}
+
+// ResultsFile is a results file saved by check:data or
+// check:construction. Only the results of Kind are populated.
+type ResultsFile struct {
+	Kind         string
+	Data         *CheckDataResults
+	Construction *CheckConstructionResults
+}
+
+// LoadResultsFile decodes the JSON results file at filePath
+// as the kind of results it most closely matches (see
+// ValidateResults).
+func LoadResultsFile(filePath string) (*ResultsFile, error) {
+	contents, err := ioutil.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read results file", err)
+	}
+
+	validation, err := ValidateResults(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ResultsFile{Kind: validation.Kind}
+	var target interface{}
+	switch validation.Kind {
+	case CheckDataResultsKind:
+		file.Data = &CheckDataResults{}
+		target = file.Data
+	default:
+		file.Construction = &CheckConstructionResults{}
+		target = file.Construction
+	}
+
+	if err := json.Unmarshal(contents, target); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode %s results", err, validation.Kind)
+	}
+
+	return file, nil
+}
+
+// Print logs ResultsFile to the console.
+func (r *ResultsFile) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes the results in the file to w.
+func (r *ResultsFile) Fprint(w io.Writer) {
+	if r.Data != nil {
+		r.Data.Fprint(w)
+	}
+
+	if r.Construction != nil {
+		r.Construction.Fprint(w)
+	}
+}
+
+// Failure returns an error if any test in the file
+// failed (or the run ended with an error). Failed
+// check:data results return an *ExitCodeError with
+// the exit code of the run (see ExitCode).
+func (r *ResultsFile) Failure() error {
+	if r.Data != nil {
+		if code := ExitCode(r.Data); code != ExitCodeSuccess {
+			return &ExitCodeError{
+				Code: code,
+				Err:  fmt.Errorf("%w: %s", ErrResultsFailed, r.Kind),
+			}
+		}
+	}
+
+	if r.Construction != nil && len(r.Construction.Error) > 0 {
+		return fmt.Errorf("%w: %s", ErrResultsFailed, r.Kind)
+	}
+
+	return nil
+}
+
+// jsonField is a field of a struct
+// as it is encoded in JSON.
+type jsonField struct {
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields returns the fields of the struct t by
+// JSON name. Fields of embedded structs are promoted
+// (and are not required if the struct is embedded by
+// pointer because a nil pointer is omitted).
+func jsonFields(t reflect.Type) map[string]*jsonField {
+	fields := map[string]*jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (len(field.PkgPath) > 0 && !field.Anonymous) {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		name := options[0]
+		omitEmpty := false
+		for _, option := range options[1:] {
+			if option == "omitempty" {
+				omitEmpty = true
+			}
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && len(name) == 0 {
+			embedded := fieldType
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedField := range jsonFields(embedded) {
+					if fieldType.Kind() == reflect.Ptr {
+						embeddedField.required = false
+					}
+
+					fields[embeddedName] = embeddedField
+				}
+
+				continue
+			}
+		}
+
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		fields[name] = &jsonField{typ: fieldType, required: !omitEmpty}
+	}
+
+	return fields
+}
+
+// compareFields returns a description of each field in
+// value (decoded from JSON) that is not in t and each
+// required field in t that is not in value. Values of
+// types that decode themselves are not inspected.
+func compareFields(path string, value interface{}, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if value == nil || t.Implements(unmarshalerType) ||
+		reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var problems []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		fields := jsonFields(t)
+		for _, name := range sortedKeys(fields) {
+			fieldPath := joinFieldPath(path, name)
+			fieldValue, ok := object[name]
+			if !ok {
+				if fields[name].required {
+					problems = append(problems, fmt.Sprintf("missing field %s", fieldPath))
+				}
+
+				continue
+			}
+
+			problems = append(problems, compareFields(fieldPath, fieldValue, fields[name].typ)...)
+		}
+
+		for _, name := range sortedKeys(object) {
+			if _, ok := fields[name]; !ok {
+				problems = append(problems, fmt.Sprintf("unknown field %s", joinFieldPath(path, name)))
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		for _, key := range sortedKeys(object) {
+			keyPath := fmt.Sprintf("%s[%q]", path, key)
+			problems = append(problems, compareFields(keyPath, object[key], t.Elem())...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+
+		for i, item := range items {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			problems = append(problems, compareFields(itemPath, item, t.Elem())...)
+		}
+	}
+
+	return problems
+}
+
+// joinFieldPath returns the path of
+// field name in the object at path.
+func joinFieldPath(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// sortedKeys returns the keys of m (which
+// must be a map with string keys) in order.
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,310 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateResults(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+		SubNetworkIdentifier: &types.SubNetworkIdentifier{
+			Network: "shard 1",
+		},
+	}
+	dataResults := &CheckDataResults{
+		Tests: &CheckDataTests{RequestResponse: true},
+		Stats: &CheckDataStats{
+			Blocks:          10,
+			EndpointLatency: map[string]*EndpointStats{"/block": {Count: 10}},
+		},
+		Network:   network,
+		RunTiming: &RunTiming{Duration: 10},
+	}
+	constructionResults := &CheckConstructionResults{
+		EndConditions: map[string]int{"transfer": 1},
+		Stats:         &CheckConstructionStats{},
+		Network:       network,
+		RunTiming:     &RunTiming{Duration: 10},
+	}
+
+	var tests = map[string]struct {
+		results interface{}
+		modify  func(map[string]interface{})
+
+		kind     string
+		partial  bool
+		problems []string
+
+		// problem is a substring of the only
+		// problem (when the exact error message
+		// depends on the Go version).
+		problem string
+	}{
+		"check:data": {
+			results: dataResults,
+			kind:    CheckDataResultsKind,
+		},
+		"check:data without run timing": {
+			results: &CheckDataResults{},
+			kind:    CheckDataResultsKind,
+		},
+		"partial check:data": {
+			results: &CheckDataResults{
+				Stats:      &CheckDataStats{Blocks: 10},
+				Partial:    true,
+				SnapshotAt: "2020-10-16T12:00:00Z",
+				Progress:   &CheckDataProgress{Blocks: 10, Tip: 100},
+				RunTiming:  &RunTiming{Duration: 10},
+			},
+			kind:    CheckDataResultsKind,
+			partial: true,
+		},
+		"check:construction": {
+			results: constructionResults,
+			kind:    CheckConstructionResultsKind,
+		},
+		"missing network": {
+			results: dataResults,
+			modify: func(raw map[string]interface{}) {
+				delete(raw, "network")
+			},
+			kind: CheckDataResultsKind,
+		},
+		"check:construction missing network": {
+			results: constructionResults,
+			modify: func(raw map[string]interface{}) {
+				delete(raw, "network")
+			},
+			kind: CheckConstructionResultsKind,
+		},
+		"unknown fields": {
+			results: dataResults,
+			modify: func(raw map[string]interface{}) {
+				raw["blocks"] = 10
+				stats := raw["stats"].(map[string]interface{})
+				latency := stats["endpoint_latency"].(map[string]interface{})
+				latency["/block"].(map[string]interface{})["median_ms"] = 10
+			},
+			kind: CheckDataResultsKind,
+			problems: []string{
+				"unknown field stats.endpoint_latency[\"/block\"].median_ms",
+				"unknown field blocks",
+			},
+		},
+		"missing fields": {
+			results: dataResults,
+			modify: func(raw map[string]interface{}) {
+				delete(raw, "error")
+				delete(raw["tests"].(map[string]interface{}), "request_response")
+			},
+			kind: CheckDataResultsKind,
+			problems: []string{
+				"missing field error",
+				"missing field tests.request_response",
+			},
+		},
+		"wrong type": {
+			results: dataResults,
+			modify: func(raw map[string]interface{}) {
+				raw["stats"].(map[string]interface{})["blocks"] = "10"
+			},
+			kind:    CheckDataResultsKind,
+			problem: "cannot unmarshal string into Go struct field",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			contents, err := json.Marshal(test.results)
+			assert.NoError(t, err)
+
+			if test.modify != nil {
+				var raw map[string]interface{}
+				assert.NoError(t, json.Unmarshal(contents, &raw))
+				test.modify(raw)
+
+				contents, err = json.Marshal(raw)
+				assert.NoError(t, err)
+			}
+
+			validation, err := ValidateResults(contents)
+			assert.NoError(t, err)
+			assert.Equal(t, test.kind, validation.Kind)
+			assert.Equal(t, test.partial, validation.Partial)
+			if len(test.problem) > 0 {
+				assert.Len(t, validation.Problems, 1)
+				assert.Contains(t, validation.Problems[0], test.problem)
+				return
+			}
+
+			assert.Equal(t, test.problems, validation.Problems)
+		})
+	}
+}
+
+func TestValidateResults_Invalid(t *testing.T) {
+	for _, contents := range []string{"", "[]", "null", "<testsuites></testsuites>"} {
+		validation, err := ValidateResults([]byte(contents))
+		assert.Nil(t, validation)
+		assert.Equal(t, ErrInvalidResultsFile, err)
+	}
+}
+
+func TestValidateResultsFile(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	results := &CheckDataResults{Stats: &CheckDataStats{Blocks: 10}}
+	resultsPath := path.Join(dir, "results.json")
+	assert.NoError(t, results.Output(resultsPath, configuration.JSONResultsOutputFormat))
+
+	validation, err := ValidateResultsFile(resultsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, CheckDataResultsKind, validation.Kind)
+	assert.Empty(t, validation.Problems)
+
+	// Add an unknown field to the saved results.
+	var raw map[string]interface{}
+	assert.NoError(t, utils.LoadAndParse(resultsPath, &raw))
+	raw["blocks"] = 10
+	contents, err := json.Marshal(raw)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(
+		resultsPath,
+		contents,
+		os.FileMode(utils.DefaultFilePermissions),
+	))
+	validation, err = ValidateResultsFile(resultsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, CheckDataResultsKind, validation.Kind)
+	assert.NotEmpty(t, validation.Problems)
+
+	validation, err = ValidateResultsFile(path.Join(dir, "missing.json"))
+	assert.Nil(t, validation)
+	assert.Error(t, err)
+}
+
+func TestLoadResultsFile(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	tested := true
+	var tests = map[string]struct {
+		results interface{}
+		format  configuration.ResultsOutputFormat
+
+		kind     string
+		exitCode int
+		failed   bool
+	}{
+		"passing check:data": {
+			results: &CheckDataResults{
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: true,
+					BlockSyncing:      &tested,
+				},
+				Stats: &CheckDataStats{Blocks: 10},
+			},
+			format: configuration.JSONResultsOutputFormat,
+			kind:   CheckDataResultsKind,
+		},
+		"failing check:data": {
+			results: &CheckDataResults{
+				Tests: &CheckDataTests{RequestResponse: true},
+				Stats: &CheckDataStats{Blocks: 10},
+			},
+			format:   configuration.NDJSONResultsOutputFormat,
+			kind:     CheckDataResultsKind,
+			exitCode: ExitCodeResponseAssertion,
+			failed:   true,
+		},
+		"passing check:construction": {
+			results: &CheckConstructionResults{
+				EndConditions: map[string]int{"transfer": 1},
+				Stats:         &CheckConstructionStats{},
+			},
+			format: configuration.JSONResultsOutputFormat,
+			kind:   CheckConstructionResultsKind,
+		},
+		"failing check:construction": {
+			results: &CheckConstructionResults{
+				Error: "broadcast failed",
+				Stats: &CheckConstructionStats{},
+			},
+			format: configuration.JSONResultsOutputFormat,
+			kind:   CheckConstructionResultsKind,
+			failed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resultsPath := path.Join(dir, "results.json")
+			assert.NoError(t, writeResults(
+				context.Background(),
+				resultsPath,
+				test.format,
+				test.results,
+				nil,
+			))
+
+			file, err := LoadResultsFile(resultsPath)
+			assert.NoError(t, err)
+			assert.Equal(t, test.kind, file.Kind)
+			if test.kind == CheckDataResultsKind {
+				assert.Equal(t, test.results, file.Data)
+				assert.Nil(t, file.Construction)
+			} else {
+				assert.Equal(t, test.results, file.Construction)
+				assert.Nil(t, file.Data)
+			}
+
+			err = file.Failure()
+			if !test.failed {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrResultsFailed))
+			var exitErr *ExitCodeError
+			assert.Equal(t, test.exitCode != 0, errors.As(err, &exitErr))
+			if exitErr != nil {
+				assert.Equal(t, test.exitCode, exitErr.Code)
+			}
+		})
+	}
+
+	file, err := LoadResultsFile(path.Join(dir, "missing.json"))
+	assert.Nil(t, file)
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/version"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// webhookMaxAttempts is the most times results
+	// are POSTed to the results webhook.
+	webhookMaxAttempts = 5
+
+	// maxWebhookResponse is the most bytes of a failed
+	// webhook response included in its error.
+	maxWebhookResponse = 512
+)
+
+// webhookBackoff is how long to wait before the first
+// retry of a failed webhook request. It doubles after
+// each failed retry.
+var webhookBackoff = 1 * time.Second
+
+// ResultsWebhookPayload is POSTed to the results webhook
+// when check:data exits.
+type ResultsWebhookPayload struct {
+	Network  *types.NetworkIdentifier `json:"network"`
+	Version  string                   `json:"version"`
+	Hostname string                   `json:"hostname"`
+	Results  *CheckDataResults        `json:"results"`
+}
+
+// NewResultsWebhookPayload returns a new *ResultsWebhookPayload
+// for results of a run on network.
+func NewResultsWebhookPayload(
+	network *types.NetworkIdentifier,
+	results *CheckDataResults,
+) *ResultsWebhookPayload {
+	hostname, err := os.Hostname()
+	if err != nil {
+		logging.Warn("unable to get hostname", logging.Fields{"error": err})
+	}
+
+	return &ResultsWebhookPayload{
+		Network:  network,
+		Version:  version.Version,
+		Hostname: hostname,
+		Results:  results,
+	}
+}
+
+// PostResultsWebhook POSTs payload (as JSON) to url. Failed
+// requests (including responses without a 2xx status code)
+// are retried with exponential backoff. If token is
+// populated, it is sent as a bearer token.
+func PostResultsWebhook(
+	ctx context.Context,
+	url string,
+	token string,
+	timeout time.Duration,
+	payload *ResultsWebhookPayload,
+) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal results webhook payload", err)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err = postResultsWebhook(ctx, client, url, token, body)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == webhookMaxAttempts {
+			return fmt.Errorf("%w: unable to POST results after %d attempts", err, attempt)
+		}
+
+		logging.Warn("unable to POST results to webhook, retrying", logging.Fields{
+			"attempt": attempt,
+			"backoff": backoff.String(),
+			"error":   err,
+		})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func postResultsWebhook(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	token string,
+	body []byte,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: unable to create results webhook request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: unable to send results webhook request", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		if len(respBody) > maxWebhookResponse {
+			respBody = respBody[:maxWebhookResponse]
+		}
+
+		return fmt.Errorf("results webhook returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+// outputWebhook POSTs results to the configured results
+// webhook (if there is one).
+func outputWebhook(config *configuration.Configuration, results *CheckDataResults) error {
+	if len(config.Data.ResultsWebhookURL) == 0 {
+		return nil
+	}
+
+	return PostResultsWebhook(
+		context.Background(),
+		config.Data.ResultsWebhookURL,
+		config.Data.ResultsWebhookToken,
+		time.Duration(config.Data.ResultsWebhookTimeout)*time.Second,
+		NewResultsWebhookPayload(config.Network, results),
+	)
+}
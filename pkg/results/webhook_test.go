@@ -0,0 +1,92 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/version"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostResultsWebhook(t *testing.T) {
+	defaultBackoff := webhookBackoff
+	webhookBackoff = time.Millisecond
+	defer func() { webhookBackoff = defaultBackoff }()
+
+	network := &types.NetworkIdentifier{
+		Blockchain: "Bitcoin",
+		Network:    "Mainnet",
+	}
+	results := &CheckDataResults{Error: "reconciliation failed"}
+
+	// The first request fails and is retried.
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var payload ResultsWebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, network, payload.Network)
+		assert.Equal(t, version.Version, payload.Version)
+		assert.Equal(t, results.Error, payload.Results.Error)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	payload := NewResultsWebhookPayload(network, results)
+	assert.NoError(t, PostResultsWebhook(
+		context.Background(),
+		server.URL,
+		"token",
+		time.Second,
+		payload,
+	))
+	assert.Equal(t, 2, attempts)
+
+	// Requests are not retried more than webhookMaxAttempts.
+	attempts = 0
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	assert.Error(t, PostResultsWebhook(
+		context.Background(),
+		failing.URL,
+		"",
+		time.Second,
+		payload,
+	))
+	assert.Equal(t, webhookMaxAttempts, attempts)
+}
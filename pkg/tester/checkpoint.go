@@ -0,0 +1,378 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// checkpointBalanceBatchSize is the number of balances
+// imported in each database transaction (importing all
+// balances in a single transaction could exceed the
+// maximum transaction size).
+const checkpointBalanceBatchSize = 10000
+
+var (
+	// ErrCheckpointNoBlocks is returned when exporting a
+	// checkpoint before any blocks have been synced.
+	ErrCheckpointNoBlocks = errors.New("no blocks have been synced")
+
+	// ErrCheckpointNetworkMismatch is returned when importing
+	// a checkpoint exported for a different network.
+	ErrCheckpointNetworkMismatch = errors.New("checkpoint network does not match configured network")
+
+	// ErrCheckpointBlockMismatch is returned when importing a
+	// checkpoint whose block does not match the block returned
+	// by the configured implementation.
+	ErrCheckpointBlockMismatch = errors.New("checkpoint block does not match fetched block")
+
+	// ErrCheckpointDataExists is returned when importing a
+	// checkpoint into a data directory that has already
+	// synced blocks.
+	ErrCheckpointDataExists = errors.New("data directory has already synced blocks")
+)
+
+// Checkpoint is a portable snapshot of the check:data sync
+// state for a network. Importing a Checkpoint into an empty
+// data directory allows check:data to resume syncing at the
+// block after BlockIdentifier.
+type Checkpoint struct {
+	Network *types.NetworkIdentifier `json:"network_identifier"`
+
+	// BlockIdentifier is the last block synced. Balances are
+	// always updated in the same database transaction as the
+	// synced block, so this is also the head of balance storage.
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier"`
+
+	Balances []*CheckpointBalance `json:"balances"`
+
+	// Coins are the unspent coins of each account. Coins
+	// are only exported when coin tracking is enabled.
+	Coins []*CheckpointCoins `json:"coins,omitempty"`
+}
+
+// CheckpointBalance is a computed balance in a Checkpoint.
+// LastReconciled is the block the balance was last reconciled
+// at (the reconciliation cursor for the balance), if it has
+// been reconciled.
+type CheckpointBalance struct {
+	Account        *types.AccountIdentifier `json:"account"`
+	Amount         *types.Amount            `json:"amount"`
+	Block          *types.BlockIdentifier   `json:"block"`
+	LastReconciled *types.BlockIdentifier   `json:"last_reconciled,omitempty"`
+}
+
+// CheckpointCoins are the unspent coins
+// of an account in a Checkpoint.
+type CheckpointCoins struct {
+	Account *types.AccountIdentifier `json:"account"`
+	Coins   []*types.Coin            `json:"coins"`
+}
+
+// openDataDatabase opens the check:data database
+// for network in config.DataDirectory.
+func openDataDatabase(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+) (storage.Database, error) {
+	if len(config.DataDirectory) == 0 {
+		return nil, errors.New("data_directory must be populated")
+	}
+
+	dataPath, err := utils.CreateCommandPath(config.DataDirectory, dataCmdName, network)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot create command path", err)
+	}
+
+	localStore, err := storage.NewBadgerStorage(ctx, dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to initialize database", err)
+	}
+
+	return localStore, nil
+}
+
+// ExportCheckpoint returns a *Checkpoint of the check:data
+// database for network in config.DataDirectory. The checkpoint
+// is exported from a snapshot (see OpenDataSnapshot), so
+// check:data can be running on the data directory.
+func ExportCheckpoint(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+) (*Checkpoint, error) {
+	db, err := OpenDataSnapshot(ctx, config, network)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close(ctx)
+
+	return exportCheckpoint(ctx, db, network, !config.Data.CoinTrackingDisabled)
+}
+
+func exportCheckpoint(
+	ctx context.Context,
+	db storage.Database,
+	network *types.NetworkIdentifier,
+	coinTracking bool,
+) (*Checkpoint, error) {
+	blockStorage := storage.NewBlockStorage(db)
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return nil, ErrCheckpointNoBlocks
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	checkpoint := &Checkpoint{
+		Network:         network,
+		BlockIdentifier: head,
+		Balances:        []*CheckpointBalance{},
+	}
+
+	cursors, err := processor.NewReconciliationCursorStorage(db).GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get reconciliation cursors", err)
+	}
+
+	balanceStorage := storage.NewBalanceStorage(db)
+	accounts, err := balanceStorage.GetAllAccountCurrency(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get accounts", err)
+	}
+
+	for _, account := range accounts {
+		amount, block, err := balanceStorage.GetBalance(ctx, account.Account, account.Currency, head)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to get balance of %s",
+				err,
+				types.PrintStruct(account),
+			)
+		}
+
+		balance := &CheckpointBalance{
+			Account: account.Account,
+			Amount:  amount,
+			Block:   block,
+		}
+		if cursor, ok := cursors[types.Hash(account)]; ok {
+			balance.LastReconciled = cursor.Block
+		}
+
+		checkpoint.Balances = append(checkpoint.Balances, balance)
+	}
+
+	if !coinTracking {
+		return checkpoint, nil
+	}
+
+	coinStorage := storage.NewCoinStorage(db, processor.NewCoinStorageHelper(blockStorage), nil)
+	seen := map[string]struct{}{}
+	for _, account := range accounts {
+		key := types.Hash(account.Account)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		coins, _, err := coinStorage.GetCoins(ctx, account.Account)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to get coins of %s",
+				err,
+				types.PrintStruct(account.Account),
+			)
+		}
+
+		if len(coins) == 0 {
+			continue
+		}
+
+		checkpoint.Coins = append(checkpoint.Coins, &CheckpointCoins{
+			Account: account.Account,
+			Coins:   coins,
+		})
+	}
+
+	return checkpoint, nil
+}
+
+// ValidateCheckpoint ensures checkpoint was exported for
+// network and that block (fetched from the configured
+// implementation at the checkpoint index) is the
+// checkpoint block.
+func ValidateCheckpoint(
+	checkpoint *Checkpoint,
+	network *types.NetworkIdentifier,
+	block *types.Block,
+) error {
+	if types.Hash(checkpoint.Network) != types.Hash(network) {
+		return fmt.Errorf(
+			"%w: checkpoint network is %s but configured network is %s",
+			ErrCheckpointNetworkMismatch,
+			types.PrintStruct(checkpoint.Network),
+			types.PrintStruct(network),
+		)
+	}
+
+	if types.Hash(checkpoint.BlockIdentifier) != types.Hash(block.BlockIdentifier) {
+		return fmt.Errorf(
+			"%w: checkpoint block is %s but fetched block is %s",
+			ErrCheckpointBlockMismatch,
+			types.PrintStruct(checkpoint.BlockIdentifier),
+			types.PrintStruct(block.BlockIdentifier),
+		)
+	}
+
+	return nil
+}
+
+// ImportCheckpoint validates checkpoint (see ValidateCheckpoint)
+// and imports it into the check:data database for network in
+// config.DataDirectory. The check:data database must not
+// have synced any blocks.
+func ImportCheckpoint(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+	checkpoint *Checkpoint,
+	block *types.Block,
+) error {
+	if err := ValidateCheckpoint(checkpoint, network, block); err != nil {
+		return err
+	}
+
+	db, err := openDataDatabase(ctx, config, network)
+	if err != nil {
+		return err
+	}
+	defer db.Close(ctx)
+
+	return importCheckpoint(ctx, db, checkpoint, block)
+}
+
+func importCheckpoint(
+	ctx context.Context,
+	db storage.Database,
+	checkpoint *Checkpoint,
+	block *types.Block,
+) error {
+	blockStorage := storage.NewBlockStorage(db)
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	if err == nil {
+		return fmt.Errorf("%w: head block is %s", ErrCheckpointDataExists, types.PrintStruct(head))
+	}
+	if !errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	balanceStorage := storage.NewBalanceStorage(db)
+	for start := 0; start < len(checkpoint.Balances); start += checkpointBalanceBatchSize {
+		end := start + checkpointBalanceBatchSize
+		if end > len(checkpoint.Balances) {
+			end = len(checkpoint.Balances)
+		}
+
+		if err := importBalances(ctx, db, balanceStorage, checkpoint.Balances[start:end]); err != nil {
+			return err
+		}
+	}
+
+	cursors := processor.NewReconciliationCursorStorage(db)
+	for _, balance := range checkpoint.Balances {
+		if balance.LastReconciled == nil {
+			continue
+		}
+
+		currency := balance.Amount.Currency
+		if err := balanceStorage.Reconciled(
+			ctx,
+			balance.Account,
+			currency,
+			balance.LastReconciled,
+		); err != nil {
+			return fmt.Errorf("%w: unable to import reconciliation", err)
+		}
+
+		if err := cursors.Set(ctx, balance.Account, currency, balance.LastReconciled); err != nil {
+			return fmt.Errorf("%w: unable to import reconciliation cursor", err)
+		}
+	}
+
+	if len(checkpoint.Coins) > 0 {
+		accountCoins := make([]*utils.AccountBalance, len(checkpoint.Coins))
+		for i, coins := range checkpoint.Coins {
+			accountCoins[i] = &utils.AccountBalance{
+				Account: coins.Account,
+				Coins:   coins.Coins,
+			}
+		}
+
+		coinStorage := storage.NewCoinStorage(db, processor.NewCoinStorageHelper(blockStorage), nil)
+		if err := coinStorage.SetCoinsImported(ctx, accountCoins); err != nil {
+			return fmt.Errorf("%w: unable to import coins", err)
+		}
+	}
+
+	// Syncing resumes at the block after the head block, so we
+	// store the checkpoint block last. If the import fails before
+	// the checkpoint block is stored, it can be retried.
+	if err := blockStorage.AddBlock(ctx, block); err != nil {
+		return fmt.Errorf("%w: unable to add checkpoint block", err)
+	}
+
+	return nil
+}
+
+// importBalances stores balances in a single
+// database transaction.
+func importBalances(
+	ctx context.Context,
+	db storage.Database,
+	balanceStorage *storage.BalanceStorage,
+	balances []*CheckpointBalance,
+) error {
+	txn := db.NewDatabaseTransaction(ctx, true)
+	defer txn.Discard(ctx)
+	for _, balance := range balances {
+		if err := balanceStorage.SetBalance(
+			ctx,
+			txn,
+			balance.Account,
+			balance.Amount,
+			balance.Block,
+		); err != nil {
+			return fmt.Errorf("%w: unable to set balance", err)
+		}
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: unable to commit balances", err)
+	}
+
+	return nil
+}
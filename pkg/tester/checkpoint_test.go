@@ -0,0 +1,213 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	checkpointNetwork = &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+
+	checkpointBlock = &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 9, Hash: "block 9"},
+		Timestamp:             1,
+	}
+
+	checkpointCurrency = &types.Currency{Symbol: "BTC", Decimals: 8}
+)
+
+func newCheckpointDatabase(ctx context.Context, t *testing.T, dir string, name string) storage.Database {
+	db, err := storage.NewBadgerStorage(
+		ctx,
+		path.Join(dir, name),
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// Exporting before syncing any blocks fails
+	source := newCheckpointDatabase(ctx, t, dir, "source")
+	defer source.Close(ctx)
+	checkpoint, err := exportCheckpoint(ctx, source, checkpointNetwork, true)
+	assert.Nil(t, checkpoint)
+	assert.True(t, errors.Is(err, ErrCheckpointNoBlocks))
+
+	// Populate a synced block and balances
+	blockStorage := storage.NewBlockStorage(source)
+	assert.NoError(t, blockStorage.AddBlock(ctx, checkpointBlock))
+
+	balanceStorage := storage.NewBalanceStorage(source)
+	reconciled := &types.AccountIdentifier{Address: "reconciled"}
+	unreconciled := &types.AccountIdentifier{Address: "unreconciled"}
+	txn := source.NewDatabaseTransaction(ctx, true)
+	for _, account := range []*types.AccountIdentifier{reconciled, unreconciled} {
+		assert.NoError(t, balanceStorage.SetBalance(
+			ctx,
+			txn,
+			account,
+			&types.Amount{Value: "100", Currency: checkpointCurrency},
+			&types.BlockIdentifier{Index: 8, Hash: "block 8"},
+		))
+	}
+	assert.NoError(t, txn.Commit(ctx))
+	assert.NoError(t, balanceStorage.Reconciled(
+		ctx,
+		reconciled,
+		checkpointCurrency,
+		&types.BlockIdentifier{Index: 9, Hash: "block 9"},
+	))
+	assert.NoError(t, processor.NewReconciliationCursorStorage(source).Set(
+		ctx,
+		reconciled,
+		checkpointCurrency,
+		&types.BlockIdentifier{Index: 9, Hash: "block 9"},
+	))
+
+	coin := &types.Coin{
+		CoinIdentifier: &types.CoinIdentifier{Identifier: "coin 1"},
+		Amount:         &types.Amount{Value: "100", Currency: checkpointCurrency},
+	}
+	coinStorage := storage.NewCoinStorage(source, processor.NewCoinStorageHelper(blockStorage), nil)
+	assert.NoError(t, coinStorage.SetCoinsImported(ctx, []*utils.AccountBalance{
+		{Account: unreconciled, Coins: []*types.Coin{coin}},
+	}))
+
+	checkpoint, err = exportCheckpoint(ctx, source, checkpointNetwork, true)
+	assert.NoError(t, err)
+	assert.Equal(t, checkpointNetwork, checkpoint.Network)
+	assert.Equal(t, checkpointBlock.BlockIdentifier, checkpoint.BlockIdentifier)
+	assert.Len(t, checkpoint.Balances, 2)
+	for _, balance := range checkpoint.Balances {
+		assert.Equal(t, "100", balance.Amount.Value)
+		assert.Equal(t, int64(8), balance.Block.Index)
+		if balance.Account.Address == reconciled.Address {
+			assert.Equal(t, &types.BlockIdentifier{Index: 9, Hash: "block 9"}, balance.LastReconciled)
+		} else {
+			assert.Nil(t, balance.LastReconciled)
+		}
+	}
+	assert.Equal(t, []*CheckpointCoins{
+		{Account: unreconciled, Coins: []*types.Coin{coin}},
+	}, checkpoint.Coins)
+
+	// Coins are only exported when coin tracking is enabled
+	withoutCoins, err := exportCheckpoint(ctx, source, checkpointNetwork, false)
+	assert.NoError(t, err)
+	assert.Len(t, withoutCoins.Balances, 2)
+	assert.Nil(t, withoutCoins.Coins)
+
+	// Ensure the checkpoint survives a round trip through a file
+	checkpointPath := path.Join(dir, "checkpoint.json")
+	assert.NoError(t, utils.SerializeAndWrite(checkpointPath, checkpoint))
+	var loaded Checkpoint
+	assert.NoError(t, utils.LoadAndParse(checkpointPath, &loaded))
+	assert.Equal(t, checkpoint, &loaded)
+
+	// Import into an empty database
+	destination := newCheckpointDatabase(ctx, t, dir, "destination")
+	defer destination.Close(ctx)
+	assert.NoError(t, importCheckpoint(ctx, destination, &loaded, checkpointBlock))
+
+	head, err := storage.NewBlockStorage(destination).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, checkpointBlock.BlockIdentifier, head)
+
+	coverage, err := storage.NewBalanceStorage(destination).ReconciliationCoverage(ctx, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, coverage)
+
+	imported, err := exportCheckpoint(ctx, destination, checkpointNetwork, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, checkpoint.Balances, imported.Balances)
+	assert.Equal(t, checkpoint.Coins, imported.Coins)
+
+	// Importing into a database that has synced blocks fails
+	err = importCheckpoint(ctx, destination, &loaded, checkpointBlock)
+	assert.True(t, errors.Is(err, ErrCheckpointDataExists))
+}
+
+func TestValidateCheckpoint(t *testing.T) {
+	checkpoint := &Checkpoint{
+		Network:         checkpointNetwork,
+		BlockIdentifier: checkpointBlock.BlockIdentifier,
+	}
+
+	var tests = map[string]struct {
+		network *types.NetworkIdentifier
+		block   *types.Block
+
+		err error
+	}{
+		"valid": {
+			network: &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+			block:   checkpointBlock,
+		},
+		"network mismatch": {
+			network: &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "testnet3"},
+			block:   checkpointBlock,
+			err:     ErrCheckpointNetworkMismatch,
+		},
+		"subnetwork mismatch": {
+			network: &types.NetworkIdentifier{
+				Blockchain:           "bitcoin",
+				Network:              "mainnet",
+				SubNetworkIdentifier: &types.SubNetworkIdentifier{Network: "shard 1"},
+			},
+			block: checkpointBlock,
+			err:   ErrCheckpointNetworkMismatch,
+		},
+		"block mismatch": {
+			network: checkpointNetwork,
+			block: &types.Block{
+				BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "other block 10"},
+			},
+			err: ErrCheckpointBlockMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateCheckpoint(checkpoint, test.network, test.block)
+			if test.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.err))
+			}
+		})
+	}
+}
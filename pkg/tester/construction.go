@@ -61,11 +61,19 @@ type ConstructionTester struct {
 	onlineFetcher    *fetcher.Fetcher
 	broadcastStorage *storage.BroadcastStorage
 	blockStorage     *storage.BlockStorage
+	mempoolWatcher   *processor.MempoolWatcher
+	recovery         *processor.BroadcastRecovery
 	jobStorage       *storage.JobStorage
 	counterStorage   *storage.CounterStorage
 	coordinator      *coordinator.Coordinator
 	cancel           context.CancelFunc
 	signalReceived   *bool
+	startedAt        time.Time
+
+	// recovered is populated once the transactions that
+	// were in flight when the last run stopped have been
+	// reconciled against the chain.
+	recovered *results.BroadcastRecoveryResults
 
 	reachedEndConditions bool
 }
@@ -96,6 +104,8 @@ func InitializeConstruction(
 		false,
 		false,
 		false,
+		nil,
+		nil,
 	)
 
 	blockStorage := storage.NewBlockStorage(localStore)
@@ -109,12 +119,18 @@ func InitializeConstruction(
 		onlineFetcher,
 		false,
 		nil,
+		nil,
 		true,
+		nil,
 	)
 
 	balanceStorageHandler := processor.NewBalanceStorageHandler(
 		logger,
 		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 		false,
 		nil,
 	)
@@ -131,9 +147,27 @@ func InitializeConstruction(
 	)
 
 	parser := parser.New(onlineFetcher.Asserter, nil)
+	mempoolWatcher := processor.NewMempoolWatcher(
+		network,
+		onlineFetcher,
+		localStore,
+		blockStorage,
+		time.Duration(config.Construction.MempoolVisibilityTimeout)*time.Second,
+	)
+	jobStorage := storage.NewJobStorage(localStore)
+	recovery := processor.NewBroadcastRecovery(
+		network,
+		onlineFetcher,
+		localStore,
+		blockStorage,
+		broadcastStorage,
+		jobStorage,
+	)
 	broadcastHelper := processor.NewBroadcastStorageHelper(
 		blockStorage,
 		onlineFetcher,
+		mempoolWatcher,
+		recovery,
 	)
 	offlineFetcher := fetcher.New(
 		config.Construction.OfflineURL,
@@ -189,7 +223,6 @@ func InitializeConstruction(
 		return nil, fmt.Errorf("%w: unable to set coin balances", err)
 	}
 
-	jobStorage := storage.NewJobStorage(localStore)
 	coordinatorHelper := processor.NewCoordinatorHelper(
 		offlineFetcher,
 		onlineFetcher,
@@ -201,6 +234,7 @@ func InitializeConstruction(
 		broadcastStorage,
 		balanceStorageHelper,
 		counterStorage,
+		recovery,
 		config.Construction.Quiet,
 	)
 
@@ -218,11 +252,23 @@ func InitializeConstruction(
 		log.Fatalf("%s: unable to create coordinator", err.Error())
 	}
 
+	hashMismatchSearchDepth := config.Construction.HashMismatchSearchDepth
+	if hashMismatchSearchDepth == 0 {
+		hashMismatchSearchDepth = config.Construction.StaleDepth
+	}
+
 	broadcastHandler := processor.NewBroadcastStorageHandler(
 		config,
 		counterStorage,
 		coordinator,
 		parser,
+		recovery,
+		processor.NewHashMismatchFinder(
+			blockStorage,
+			broadcastStorage,
+			parser,
+			hashMismatchSearchDepth,
+		),
 	)
 
 	broadcastStorage.Initialize(broadcastHelper, broadcastHandler)
@@ -249,11 +295,14 @@ func InitializeConstruction(
 		coordinator:      coordinator,
 		broadcastStorage: broadcastStorage,
 		blockStorage:     blockStorage,
+		mempoolWatcher:   mempoolWatcher,
+		recovery:         recovery,
 		jobStorage:       jobStorage,
 		counterStorage:   counterStorage,
 		onlineFetcher:    onlineFetcher,
 		cancel:           cancel,
 		signalReceived:   signalReceived,
+		startedAt:        time.Now(),
 	}, nil
 }
 
@@ -362,9 +411,78 @@ func (t *ConstructionTester) StartConstructor(
 		log.Printf("cleared %d broadcasts\n", len(broadcasts))
 	}
 
+	if err := t.recoverBroadcasts(ctx); err != nil {
+		return fmt.Errorf("%w: unable to recover in-flight broadcasts", err)
+	}
+
 	return t.coordinator.Process(ctx)
 }
 
+// recoverBroadcasts reconciles the transactions that were
+// in flight when the last run of check:construction stopped
+// against the chain before any new transactions are created.
+// We wait to sync to tip first so that transactions that landed
+// while check:construction was stopped are found on-chain.
+func (t *ConstructionTester) recoverBroadcasts(ctx context.Context) error {
+	inflight, err := t.recovery.Inflight(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(inflight) == 0 {
+		return nil
+	}
+
+	tc := time.NewTicker(tipWaitInterval)
+	defer tc.Stop()
+
+	for {
+		atTip, _, err := t.blockStorage.AtTip(ctx, t.config.TipDelay)
+		if err != nil {
+			return fmt.Errorf("%w: unable to determine if at tip", err)
+		}
+
+		if atTip {
+			break
+		}
+
+		log.Printf("waiting to reach tip before recovering %d in-flight broadcasts...\n", len(inflight))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+		}
+	}
+
+	recovered, err := t.recovery.Recover(
+		ctx,
+		t.coordinator,
+		t.config.Construction.RebroadcastLost,
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Printf(
+		"recovered in-flight broadcasts (confirmed: %d, pending: %d, rebroadcast: %d, released: %d)\n",
+		recovered.Confirmed,
+		recovered.Pending,
+		recovered.Rebroadcast,
+		recovered.Released,
+	)
+	t.recovered = recovered
+
+	return nil
+}
+
+// WatchMempool checks that each transaction accepted by
+// /construction/submit is seen in the mempool (or in a
+// block) before the mempool visibility timeout.
+func (t *ConstructionTester) WatchMempool(ctx context.Context) error {
+	return t.mempoolWatcher.Watch(ctx)
+}
+
 // ServeHTTP serves a CheckDataStatus response on all paths.
 func (t *ConstructionTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
@@ -491,12 +609,23 @@ func (t *ConstructionTester) HandleErr(
 			t.config,
 			t.counterStorage,
 			t.jobStorage,
+			t.mempoolWatcher.Results(),
+			t.recovered,
 			errors.New("check halted"),
+			t.startedAt,
 		)
 	}
 
 	if !t.reachedEndConditions {
-		return results.ExitConstruction(t.config, t.counterStorage, t.jobStorage, err)
+		return results.ExitConstruction(
+			t.config,
+			t.counterStorage,
+			t.jobStorage,
+			t.mempoolWatcher.Results(),
+			t.recovered,
+			err,
+			t.startedAt,
+		)
 	}
 
 	// We optimistically run the ReturnFunds function on the coordinator
@@ -507,5 +636,13 @@ func (t *ConstructionTester) HandleErr(
 		sigListeners,
 	)
 
-	return results.ExitConstruction(t.config, t.counterStorage, t.jobStorage, nil)
+	return results.ExitConstruction(
+		t.config,
+		t.counterStorage,
+		t.jobStorage,
+		t.mempoolWatcher.Results(),
+		t.recovered,
+		nil,
+		t.startedAt,
+	)
 }
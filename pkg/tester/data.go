@@ -15,20 +15,28 @@
 package tester
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/events"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/logging"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
 	"github.com/coinbase/rosetta-cli/pkg/processor"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
+	"github.com/coinbase/rosetta-sdk-go/client"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/statefulsyncer"
@@ -62,30 +70,108 @@ const (
 	// EndAtTipCheckInterval is the frequency that EndAtTip condition
 	// is evaludated
 	EndAtTipCheckInterval = 10 * time.Second
+
+	// EventsCloseTimeout is the longest check:data waits
+	// for queued events to be delivered before exiting.
+	EventsCloseTimeout = 30 * time.Second
+
+	// ErrorPolicyMinBackoff is how long check:data waits before
+	// resuming syncing after an error continued by the error
+	// policy. Retried errors double the wait (up to
+	// ErrorPolicyMaxBackoff) until syncing makes progress.
+	ErrorPolicyMinBackoff = 1 * time.Second
+
+	// ErrorPolicyMaxBackoff is the longest check:data waits
+	// before resuming syncing after a retried error.
+	ErrorPolicyMaxBackoff = 1 * time.Minute
 )
 
 var _ http.Handler = (*ConstructionTester)(nil)
 
+// dataSyncer is the syncer used by the DataTester. It is
+// a *statefulsyncer.StatefulSyncer unless a trusted
+// checkpoint is configured.
+type dataSyncer interface {
+	Sync(ctx context.Context, startIndex int64, endIndex int64) error
+	Prune(ctx context.Context, depth int64) error
+}
+
 // DataTester coordinates the `check:data` test.
 type DataTester struct {
 	network                  *types.NetworkIdentifier
 	database                 storage.Database
 	config                   *configuration.Configuration
-	syncer                   *statefulsyncer.StatefulSyncer
+	syncer                   dataSyncer
 	reconciler               *reconciler.Reconciler
 	logger                   *logger.Logger
 	balanceStorage           *storage.BalanceStorage
 	blockStorage             *storage.BlockStorage
 	counterStorage           *storage.CounterStorage
 	reconcilerHandler        *processor.ReconcilerHandler
+	assertionCatalog         *results.AssertionCatalog
+	negativeRequests         []*results.NegativeRequestResult
+	endpointLatency          *processor.EndpointLatency
+	blockPayloads            *processor.BlockPayloads
+	feeWorker                *processor.FeeWorker
+	transferSymmetryWorker   *processor.TransferSymmetryWorker
+	transferPairWorker       *processor.TransferPairWorker
+	cacheProbe               *processor.CacheProbe
+	consistencyProbe         *processor.ConsistencyProbe
+	historicalBalances       *processor.HistoricalBalanceChecker
+	endpointParity           *processor.EndpointParity
+	tagReconciliation        *processor.TagReconciliation
+	reconciliationFailures   *results.ReconciliationFailureRecorder
+	errorPolicy              *results.ErrorPolicyRecorder
+	events                   *events.Dispatcher
+	storageMonitor           *processor.StorageMonitor
+	secondaryClient          *http.Client
+	blockResults             *logger.BlockResultsWriter
+	resultsDatabase          *logger.ResultsDatabase
 	fetcher                  *fetcher.Fetcher
 	signalReceived           *bool
 	genesisBlock             *types.BlockIdentifier
 	cancel                   context.CancelFunc
 	historicalBalanceEnabled bool
 
+	// endCondition and endConditionDetail are set by
+	// the end condition loops (see setEndCondition).
 	endCondition       configuration.CheckDataEndCondition
 	endConditionDetail string
+	endConditionMutex  sync.Mutex
+
+	// statusServer serves the status and
+	// health of the run until it exits.
+	statusServer *StatusServer
+
+	// status is the *results.CheckDataStatus most recently
+	// computed by the periodic logger.
+	status      *results.CheckDataStatus
+	statusMutex sync.Mutex
+
+	// startedAt is when the DataTester was
+	// initialized and is used to compute both
+	// the sync rate and the run duration.
+	startedAt time.Time
+
+	// iteration is the iteration of the run
+	// (when running with --watch).
+	iteration int
+
+	// syncRate computes the sync rate over
+	// the configured sync rate window.
+	syncRate *results.SyncRate
+
+	// lastSyncedIndex is the most recently synced index seen
+	// by the periodic logger and lastSyncedAt is when it first
+	// saw it. degraded is true if a DegradedPeriodEvent was
+	// sent since lastSyncedIndex changed.
+	lastSyncedIndex int64
+	lastSyncedAt    time.Time
+	degraded        bool
+
+	// running is used by Close to wait
+	// for any run in progress to return.
+	running sync.WaitGroup
 }
 
 func shouldReconcile(config *configuration.Configuration) bool {
@@ -100,6 +186,17 @@ func shouldReconcile(config *configuration.Configuration) bool {
 	return true
 }
 
+// pruningDepth returns the number of blocks kept below
+// the head block in block storage (0 if pruning is
+// disabled).
+func pruningDepth(config *configuration.Configuration) int64 {
+	if config.Data.PruningDisabled {
+		return 0
+	}
+
+	return statefulsyncer.DefaultPruningDepth
+}
+
 // loadAccounts is a utility function to parse the []*reconciler.AccountCurrency
 // in a file.
 func loadAccounts(filePath string) ([]*reconciler.AccountCurrency, error) {
@@ -122,11 +219,144 @@ func loadAccounts(filePath string) ([]*reconciler.AccountCurrency, error) {
 	return accounts, nil
 }
 
-// CloseDatabase closes the database used by DataTester.
-func (t *DataTester) CloseDatabase(ctx context.Context) {
+// filterCurrencies returns the accounts with a balance in one
+// of currencies (or all accounts if currencies is empty).
+func filterCurrencies(
+	accounts []*reconciler.AccountCurrency,
+	currencies []*types.Currency,
+) []*reconciler.AccountCurrency {
+	if len(currencies) == 0 {
+		return accounts
+	}
+
+	tracked := map[string]struct{}{}
+	for _, currency := range currencies {
+		tracked[types.Hash(currency)] = struct{}{}
+	}
+
+	filtered := []*reconciler.AccountCurrency{}
+	for _, account := range accounts {
+		if _, ok := tracked[types.Hash(account.Currency)]; ok {
+			filtered = append(filtered, account)
+		}
+	}
+
+	return filtered
+}
+
+// Close cancels any run in progress, blocks until all
+// of its goroutines have returned, and then closes the
+// database (and the block results file and results
+// database, if either is open). The fetcher provided
+// to InitializeData is owned by the caller and is not
+// closed.
+func (t *DataTester) Close(ctx context.Context) error {
+	t.cancel()
+	t.running.Wait()
+	t.statusServer.Shutdown()
+
+	t.cacheProbe.Close()
+	t.events.Close(ctx)
+	if t.secondaryClient != nil {
+		t.secondaryClient.CloseIdleConnections()
+	}
+
+	if t.blockResults != nil {
+		if err := t.blockResults.Close(); err != nil {
+			log.Printf("%s: error closing block results file\n", err.Error())
+		}
+	}
+
+	if err := t.resultsDatabase.Close(); err != nil {
+		log.Printf("%s: error closing results database\n", err.Error())
+	}
+
 	if err := t.database.Close(ctx); err != nil {
-		log.Fatalf("%s: error closing database", err.Error())
+		return fmt.Errorf("%w: unable to close database", err)
+	}
+
+	return nil
+}
+
+// loadBlockResultsWriter returns a *logger.BlockResultsWriter if
+// a block results file is configured. Any results at or after the
+// index where syncing will resume are discarded.
+func loadBlockResultsWriter(
+	ctx context.Context,
+	config *configuration.Configuration,
+	blockStorage *storage.BlockStorage,
+	genesisBlock *types.BlockIdentifier,
+) (*logger.BlockResultsWriter, error) {
+	if len(config.Data.BlockResultsFile) == 0 {
+		return nil, nil
+	}
+
+	startIndex := genesisBlock.Index
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	switch {
+	case config.Data.StartIndex != nil:
+		startIndex = *config.Data.StartIndex
+	case err == nil:
+		startIndex = head.Index + 1
+	case !errors.Is(err, storage.ErrHeadBlockNotFound):
+		return nil, fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	return logger.NewBlockResultsWriter(
+		config.Data.BlockResultsFile,
+		time.Duration(config.Data.BlockResultsFlushInterval)*time.Second,
+		startIndex,
+	)
+}
+
+// loadResultsDatabase returns a *logger.ResultsDatabase if a
+// results database file is configured. If the results database
+// can't be opened, check:data continues without it.
+func loadResultsDatabase(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+) *logger.ResultsDatabase {
+	if len(config.Data.ResultsDatabaseFile) == 0 {
+		return nil
+	}
+
+	resultsDatabase, err := logger.NewResultsDatabase(
+		ctx,
+		config.Data.ResultsDatabaseFile,
+		network,
+		config,
+	)
+	if err != nil {
+		color.Yellow("%s: continuing without results database", err.Error())
+		return nil
 	}
+
+	return resultsDatabase
+}
+
+// newSecondaryFetcher returns a *fetcher.Fetcher for the
+// secondary Rosetta endpoint and the *http.Client it uses
+// (so that its connections can be closed). Responses from
+// the secondary endpoint are asserted without a
+// network-specific asserter because the secondary endpoint
+// is never synced.
+func newSecondaryFetcher(config *configuration.Configuration) (*fetcher.Fetcher, *http.Client) {
+	httpClient := &http.Client{}
+	apiClient := client.NewAPIClient(client.NewConfiguration(
+		config.Data.SecondaryOnlineURL,
+		fetcher.DefaultUserAgent,
+		httpClient,
+	))
+
+	return fetcher.New(
+		config.Data.SecondaryOnlineURL,
+		fetcher.WithClient(apiClient),
+		fetcher.WithMaxConnections(config.MaxOnlineConnections),
+		fetcher.WithRetryElapsedTime(time.Duration(config.RetryElapsedTime)*time.Second),
+		fetcher.WithTimeout(time.Duration(config.HTTPTimeout)*time.Second),
+		fetcher.WithMaxRetries(config.MaxRetries),
+	), httpClient
 }
 
 // InitializeData returns a new *DataTester.
@@ -135,10 +365,16 @@ func InitializeData(
 	config *configuration.Configuration,
 	network *types.NetworkIdentifier,
 	fetcher *fetcher.Fetcher,
+	assertionCatalog *results.AssertionCatalog,
+	negativeRequests []*results.NegativeRequestResult,
+	endpointLatency *processor.EndpointLatency,
+	blockPayloads *processor.BlockPayloads,
+	fetchRetries *processor.FetchRetries,
 	cancel context.CancelFunc,
 	genesisBlock *types.BlockIdentifier,
 	interestingAccount *reconciler.AccountCurrency,
 	signalReceived *bool,
+	iteration int,
 ) *DataTester {
 	dataPath, err := utils.CreateCommandPath(config.DataDirectory, dataCmdName, network)
 	if err != nil {
@@ -159,31 +395,116 @@ func InitializeData(
 	if err != nil {
 		log.Fatalf("%s: unable to load interesting accounts", err.Error())
 	}
+	interestingAccounts = filterCurrencies(interestingAccounts, config.Data.Currencies)
 
 	counterStorage := storage.NewCounterStorage(localStore)
 	blockStorage := storage.NewBlockStorage(localStore)
 	balanceStorage := storage.NewBalanceStorage(localStore)
 
+	// Failed and retried requests are only counted
+	// once they can be persisted (so the requests made
+	// before syncing, like negative requests, which
+	// are expected to fail, are excluded).
+	fetchRetries.SetCounterStorage(counterStorage)
+
+	blockResults, err := loadBlockResultsWriter(ctx, config, blockStorage, genesisBlock)
+	if err != nil {
+		log.Fatalf("%s: unable to load block results writer", err.Error())
+	}
+
+	resultsDatabase := loadResultsDatabase(ctx, config, network)
+
 	logger := logger.NewLogger(
 		dataPath,
 		config.Data.LogBlocks,
 		config.Data.LogTransactions,
 		config.Data.LogBalanceChanges,
 		config.Data.LogReconciliations,
+		blockResults,
+		resultsDatabase,
 	)
 
+	// color.Output is the console (unless it was
+	// redirected so that stdout only contains results).
+	if config.Data.ProgressBar {
+		logger.EnableProgressBar(color.Output)
+	}
+
+	var endpointParity *processor.EndpointParity
+	var secondaryClient *http.Client
+	if len(config.Data.SecondaryOnlineURL) > 0 {
+		secondaryFetcher, httpClient := newSecondaryFetcher(config)
+		secondaryClient = httpClient
+		endpointParity = processor.NewEndpointParity(
+			network,
+			secondaryFetcher,
+			counterStorage,
+		)
+	}
+
 	reconcilerHelper := processor.NewReconcilerHelper(
 		network,
 		fetcher,
 		blockStorage,
 		balanceStorage,
+		counterStorage,
+		endpointParity,
+		pruningDepth(config),
+	)
+
+	var cacheProbe *processor.CacheProbe
+	if config.Data.CacheProbeInterval > 0 {
+		cacheProbe = processor.NewCacheProbe(
+			config.OnlineURL,
+			network,
+			time.Duration(config.HTTPTimeout)*time.Second,
+			time.Duration(config.Data.CacheProbeInterval)*time.Second,
+		)
+	}
+
+	var tagReconciliation *processor.TagReconciliation
+	if len(config.Data.AccountTagsFile) > 0 {
+		accountTags, err := processor.LoadAccountTags(config.Data.AccountTagsFile)
+		if err != nil {
+			log.Fatalf("%s: unable to load account tags", err.Error())
+		}
+
+		tagReconciliation = processor.NewTagReconciliation(accountTags)
+	}
+
+	// Reconciliation failures are persisted so they are included
+	// in the results of runs restarted from the data directory.
+	reconciliationFailures, err := results.NewReconciliationFailureRecorder(
+		ctx,
+		localStore,
+		config.Data.ReconciliationFailureLimit,
 	)
+	if err != nil {
+		log.Fatalf("%s: unable to load reconciliation failures", err.Error())
+	}
+
+	eventDispatcher, err := events.NewDispatcher(config.Data.Events, network)
+	if err != nil {
+		log.Fatalf("%s: unable to initialize event sinks", err.Error())
+	}
+
+	// The error policy only applies once check:data
+	// has reached tip (see WatchTip).
+	errorPolicy := results.NewErrorPolicyRecorder(config.Data.ErrorPolicy)
 
 	reconcilerHandler := processor.NewReconcilerHandler(
 		logger,
 		counterStorage,
 		balanceStorage,
-		!config.Data.IgnoreReconciliationError,
+		processor.NewReconciliationCursorStorage(localStore),
+		assertionCatalog,
+		cacheProbe,
+		endpointParity,
+		tagReconciliation,
+		reconciliationFailures,
+		eventDispatcher,
+		errorPolicy,
+		haltOnReconciliationError(config),
 	)
 
 	// Get all previously seen accounts
@@ -191,6 +512,16 @@ func InitializeData(
 	if err != nil {
 		log.Fatalf("%s: unable to get previously seen accounts", err.Error())
 	}
+	seenAccounts = filterCurrencies(seenAccounts, config.Data.Currencies)
+
+	// Inactive reconciliation samples accounts in the order
+	// they are queued, so previously seen accounts are
+	// shuffled with the configured seed (if any) to be able
+	// to replay the order of another run.
+	if seed := config.Data.InactiveReconciliationSeed; seed != 0 {
+		color.Cyan("inactive reconciliation seed: %d", seed)
+		shuffleAccounts(seenAccounts, seed)
+	}
 
 	// Determine if we should perform historical balance lookups
 	var historicalBalanceEnabled bool
@@ -205,6 +536,43 @@ func InitializeData(
 		historicalBalanceEnabled = networkOptions.Allow.HistoricalBalanceLookup
 	}
 
+	var consistencyProbe *processor.ConsistencyProbe
+	if config.Data.ConsistencyProbeInterval > 0 {
+		consistencyProbeRequests := config.Data.ConsistencyProbeRequests
+		if consistencyProbeRequests == 0 {
+			consistencyProbeRequests = configuration.DefaultConsistencyProbeRequests
+		}
+
+		consistencyProbe = processor.NewConsistencyProbe(
+			network,
+			fetcher,
+			blockStorage,
+			consistencyProbeRequests,
+			time.Duration(config.Data.ConsistencyProbeInterval)*time.Second,
+			historicalBalanceEnabled,
+		)
+	}
+
+	// Historical balances can only be checked if
+	// historical balance lookup is supported (otherwise,
+	// the historical balance tracking test is not run).
+	var historicalBalances *processor.HistoricalBalanceChecker
+	if config.Data.HistoricalBalanceCheckInterval > 0 {
+		if historicalBalanceEnabled {
+			historicalBalances = processor.NewHistoricalBalanceChecker(
+				network,
+				fetcher,
+				blockStorage,
+				balanceStorage,
+				time.Duration(config.Data.HistoricalBalanceCheckInterval)*time.Second,
+			)
+		} else {
+			color.Yellow(
+				"historical balance lookup is not supported, skipping historical balance checks",
+			)
+		}
+	}
+
 	r := reconciler.New(
 		reconcilerHelper,
 		reconcilerHandler,
@@ -217,19 +585,39 @@ func InitializeData(
 		reconciler.WithInactiveFrequency(int64(config.Data.InactiveReconciliationFrequency)),
 	)
 
+	// Block results are registered first so that the
+	// processing latency of each block includes the
+	// other block workers.
 	blockWorkers := []storage.BlockWorker{}
+	if blockResults != nil {
+		blockWorkers = append(blockWorkers, blockResults)
+	}
+
 	if !config.Data.BalanceTrackingDisabled {
 		balanceStorageHelper := processor.NewBalanceStorageHelper(
 			network,
 			fetcher,
 			historicalBalanceEnabled,
 			exemptAccounts,
+			config.Data.Currencies,
 			false,
+			assertionCatalog,
 		)
 
+		// Previously seen accounts are counted so the number
+		// of accounts tracked survives restarts.
+		trackedAccounts := processor.NewTrackedAccounts(counterStorage)
+		if err := trackedAccounts.Seed(ctx, seenAccounts); err != nil {
+			log.Fatalf("%s: unable to count previously seen accounts", err.Error())
+		}
+
 		balanceStorageHandler := processor.NewBalanceStorageHandler(
 			logger,
 			r,
+			reconcilerHelper,
+			counterStorage,
+			trackedAccounts,
+			historicalBalances,
 			shouldReconcile(config),
 			interestingAccount,
 		)
@@ -248,6 +636,21 @@ func InitializeData(
 				if err != nil {
 					log.Fatalf("%s: unable to bootstrap balances", err.Error())
 				}
+
+				// Bootstrapped accounts are not in any block, so
+				// they must be counted separately.
+				bootstrappedAccounts, err := balanceStorage.GetAllAccountCurrency(ctx)
+				if err != nil {
+					log.Fatalf("%s: unable to get bootstrapped accounts", err.Error())
+				}
+
+				err = trackedAccounts.Seed(
+					ctx,
+					filterCurrencies(bootstrappedAccounts, config.Data.Currencies),
+				)
+				if err != nil {
+					log.Fatalf("%s: unable to count bootstrapped accounts", err.Error())
+				}
 			} else {
 				log.Println("Skipping balance bootstrapping because already started syncing")
 			}
@@ -256,14 +659,96 @@ func InitializeData(
 		blockWorkers = append(blockWorkers, balanceStorage)
 	}
 
+	// Transactions and operations in orphaned blocks are
+	// always counted so they can be reported separately.
+	blockWorkers = append(blockWorkers, processor.NewOrphanCounterWorker(
+		counterStorage,
+		config.Data.OrphanRateWindow,
+	))
+
+	// Operations are counted by type and status so the
+	// breakdown can be reported with the stats.
+	blockWorkers = append(blockWorkers, processor.NewOperationCounterWorker(counterStorage))
+
+	// Transactions and operations are tracked against block
+	// timestamps to report the activity of the chain.
+	blockWorkers = append(blockWorkers, processor.NewChainActivityWorker(counterStorage))
+
+	// Fees are only summed if the fee operation
+	// types of the network are configured.
+	var feeWorker *processor.FeeWorker
+	if len(config.Data.FeeOperationTypes) > 0 {
+		feeWorker = processor.NewFeeWorker(
+			counterStorage,
+			localStore,
+			config.Data.FeeOperationTypes,
+		)
+		blockWorkers = append(blockWorkers, feeWorker)
+	}
+
+	// Transactions are only checked for balanced debits
+	// and credits if transfer symmetry is configured.
+	var transferSymmetryWorker *processor.TransferSymmetryWorker
+	if config.Data.TransferSymmetry != nil {
+		transferSymmetryWorker = processor.NewTransferSymmetryWorker(
+			counterStorage,
+			localStore,
+			fetcher.Asserter,
+			config.Data.TransferSymmetry,
+			config.Data.FeeOperationTypes,
+		)
+		blockWorkers = append(blockWorkers, transferSymmetryWorker)
+	}
+
+	// The sender and recipient pairs of transfers are only
+	// sketched (to report transaction graph coverage) if
+	// transfer pairs are enabled.
+	var transferPairWorker *processor.TransferPairWorker
+	if config.Data.TransferPairsEnabled {
+		transferPairWorker = processor.NewTransferPairWorker(localStore, fetcher.Asserter)
+		blockWorkers = append(blockWorkers, transferPairWorker)
+	}
+
+	// Reorgs deeper than the configured depth are sent
+	// to the event sinks.
+	if config.Data.Events != nil {
+		blockWorkers = append(
+			blockWorkers,
+			processor.NewReorgDepthWorker(eventDispatcher, config.Data.Events.ReorgDepth),
+		)
+	}
+
+	// Blocks at or below a trusted checkpoint are counted
+	// separately from those that are asserted.
+	if config.Data.TrustedCheckpoint != nil {
+		blockWorkers = append(
+			blockWorkers,
+			processor.NewTrustedCheckpointWorker(counterStorage, config.Data.TrustedCheckpoint),
+		)
+	}
+
 	if !config.Data.CoinTrackingDisabled {
 		coinStorageHelper := processor.NewCoinStorageHelper(blockStorage)
 		coinStorage := storage.NewCoinStorage(localStore, coinStorageHelper, fetcher.Asserter)
 
-		blockWorkers = append(blockWorkers, coinStorage)
+		// Coins are verified before they are applied to coin
+		// storage. Spends can only be verified if syncing
+		// starts at genesis. Orphaned blocks are verified
+		// after they are reversed in coin storage.
+		blockWorkers = append(
+			blockWorkers,
+			processor.NewCoinTrackingWorker(
+				counterStorage,
+				coinStorage,
+				fetcher.Asserter,
+				config.Data.StartIndex == nil,
+			),
+			coinStorage,
+			processor.NewCoinReorgWorker(counterStorage, coinStorage, fetcher.Asserter),
+		)
 	}
 
-	syncer := statefulsyncer.New(
+	statefulSyncer := statefulsyncer.New(
 		ctx,
 		network,
 		fetcher,
@@ -276,11 +761,27 @@ func InitializeData(
 		config.MaxSyncConcurrency,
 	)
 
-	return &DataTester{
+	var blockSyncer dataSyncer = statefulSyncer
+	if config.Data.TrustedCheckpoint != nil {
+		blockSyncer = processor.NewCheckpointSyncer(
+			statefulSyncer,
+			network,
+			fetcher,
+			blockStorage,
+			cancel,
+			blockWorkers,
+			syncer.DefaultCacheSize,
+			config.MaxSyncConcurrency,
+			config.MaxRetries,
+			config.Data.TrustedCheckpoint,
+		)
+	}
+
+	dataTester := &DataTester{
 		network:                  network,
 		database:                 localStore,
 		config:                   config,
-		syncer:                   syncer,
+		syncer:                   blockSyncer,
 		cancel:                   cancel,
 		reconciler:               r,
 		logger:                   logger,
@@ -288,11 +789,127 @@ func InitializeData(
 		blockStorage:             blockStorage,
 		counterStorage:           counterStorage,
 		reconcilerHandler:        reconcilerHandler,
+		assertionCatalog:         assertionCatalog,
+		negativeRequests:         negativeRequests,
+		endpointLatency:          endpointLatency,
+		blockPayloads:            blockPayloads,
+		feeWorker:                feeWorker,
+		transferSymmetryWorker:   transferSymmetryWorker,
+		transferPairWorker:       transferPairWorker,
+		cacheProbe:               cacheProbe,
+		consistencyProbe:         consistencyProbe,
+		historicalBalances:       historicalBalances,
+		endpointParity:           endpointParity,
+		tagReconciliation:        tagReconciliation,
+		reconciliationFailures:   reconciliationFailures,
+		errorPolicy:              errorPolicy,
+		events:                   eventDispatcher,
+		storageMonitor:           processor.NewStorageMonitor(dataPath),
+		secondaryClient:          secondaryClient,
+		blockResults:             blockResults,
+		resultsDatabase:          resultsDatabase,
 		fetcher:                  fetcher,
 		signalReceived:           signalReceived,
 		genesisBlock:             genesisBlock,
 		historicalBalanceEnabled: historicalBalanceEnabled,
+		startedAt:                time.Now(),
+		iteration:                iteration,
+		lastSyncedIndex:          -1,
+		syncRate: results.NewSyncRate(
+			time.Duration(config.Data.SyncRateWindow) * time.Second,
+		),
+	}
+	dataTester.statusServer = NewStatusServer(dataTester, nil)
+
+	return dataTester
+}
+
+// Run starts syncing, reconciliation, and all other
+// check:data loops (and servers) and blocks until they
+// return. All goroutines started by Run (except the status
+// server, which is shut down by exitData or Close) have
+// returned by the time Run returns.
+func (t *DataTester) Run(ctx context.Context) error {
+	t.running.Add(1)
+	defer t.running.Done()
+
+	t.events.Emit(
+		configuration.RunStartedEvent,
+		"check:data started",
+		logging.Fields{"start_index": t.config.Data.StartIndex},
+	)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return t.StartPeriodicLogger(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartReconciler(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartSyncing(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartPruning(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartBlockResultsFlusher(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartCacheProbe(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartConsistencyProbe(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartHistoricalBalanceChecks(ctx)
+	})
+
+	g.Go(func() error {
+		return t.StartResultsSnapshots(ctx)
+	})
+
+	g.Go(func() error {
+		return t.WatchEndConditions(ctx)
+	})
+
+	g.Go(func() error {
+		return t.WatchTip(ctx)
+	})
+
+	g.Go(func() error {
+		return LogMemoryLoop(ctx)
+	})
+
+	// The status server keeps running after Run returns so
+	// that its health check reports any fatal error while
+	// the results are computed (see exitData).
+	t.statusServer.Start("check:data status", t.config.Data.StatusPort)
+
+	if t.config.Data.MetricsPort > 0 {
+		g.Go(func() error {
+			return StartServer(
+				ctx,
+				"check:data metrics",
+				http.HandlerFunc(t.ServeMetrics),
+				t.config.Data.MetricsPort,
+			)
+		})
+	}
+
+	err := g.Wait()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.statusServer.RecordFatalError(err)
 	}
+
+	return err
 }
 
 // StartSyncing syncs from startIndex to endIndex.
@@ -312,116 +929,550 @@ func (t *DataTester) StartSyncing(
 		endIndex = *t.config.Data.EndConditions.Index
 	}
 
-	return t.syncer.Sync(ctx, startIndex, endIndex)
-}
-
-// StartPruning attempts to prune block storage
-// every 10 seconds.
-func (t *DataTester) StartPruning(
-	ctx context.Context,
-) error {
-	if t.config.Data.PruningDisabled {
-		return nil
+	if t.config.Data.EndConditions != nil && t.config.Data.EndConditions.EndBlockIdentifier != nil {
+		endIndex = t.config.Data.EndConditions.EndBlockIdentifier.Index
 	}
 
-	return t.syncer.Prune(ctx, statefulsyncer.DefaultPruningDepth)
-}
+	failures := 0
+	lastHead := int64(-1)
+	for {
+		err := t.syncer.Sync(ctx, startIndex, endIndex)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
 
-// StartReconciler starts the reconciler if
-// reconciliation is enabled.
-func (t *DataTester) StartReconciler(
-	ctx context.Context,
-) error {
-	if !shouldReconcile(t.config) {
-		return nil
-	}
+		category, action := t.errorPolicy.Handle(err)
+		if action == configuration.AbortErrorAction {
+			return err
+		}
 
-	return t.reconciler.Reconcile(ctx)
-}
+		// The backoff is reset once syncing makes progress.
+		head, headErr := t.blockStorage.GetHeadBlockIdentifier(ctx)
+		if headErr == nil && head.Index != lastHead {
+			lastHead = head.Index
+			failures = 0
+		}
+		failures++
 
-// StartPeriodicLogger prints out periodic
-// stats about a run of `check:data`.
-func (t *DataTester) StartPeriodicLogger(
-	ctx context.Context,
-) error {
-	tc := time.NewTicker(PeriodicLoggingFrequency)
-	defer tc.Stop()
+		backoff := errorPolicyBackoff(action, failures)
+		color.Yellow(
+			"[ERROR POLICY] %s error (%s), resuming syncing in %s: %s",
+			category,
+			action,
+			backoff,
+			err.Error(),
+		)
 
-	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-tc.C:
-			// Update the elapsed time in counter storage so that
-			// we can log metrics about the current check:data run.
-			_, _ = t.counterStorage.Update(
-				ctx,
-				results.TimeElapsedCounter,
-				big.NewInt(periodicLoggingSeconds),
-			)
-
-			status := results.ComputeCheckDataStatus(ctx, t.counterStorage, t.balanceStorage, t.fetcher, t.config.Network)
-			t.logger.LogDataStatus(ctx, status)
+		case <-time.After(backoff):
 		}
+
+		// Syncing resumes from the last synced block.
+		startIndex = -1
 	}
 }
 
-// ServeHTTP serves a CheckDataStatus response on all paths.
-func (t *DataTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusOK)
+// errorPolicyBackoff returns how long to wait before
+// resuming syncing after the failures-th consecutive
+// error continued with action.
+func errorPolicyBackoff(action configuration.ErrorAction, failures int) time.Duration {
+	if action != configuration.RetryErrorAction {
+		return ErrorPolicyMinBackoff
+	}
 
-	status := results.ComputeCheckDataStatus(
-		r.Context(),
-		t.counterStorage,
-		t.balanceStorage,
-		t.fetcher,
-		t.network,
-	)
+	backoff := ErrorPolicyMinBackoff
+	for i := 1; i < failures && backoff < ErrorPolicyMaxBackoff; i++ {
+		backoff *= 2
+	}
 
-	if err := json.NewEncoder(w).Encode(status); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if backoff > ErrorPolicyMaxBackoff {
+		return ErrorPolicyMaxBackoff
 	}
+
+	return backoff
 }
 
-// EndAtTipLoop runs a loop that evaluates end condition EndAtTip
-func (t *DataTester) EndAtTipLoop(
+// WatchTip applies the error policy once check:data
+// has reached tip (if an error policy is configured).
+func (t *DataTester) WatchTip(
 	ctx context.Context,
-	minReconciliationCoverage float64,
-) {
+) error {
+	if len(t.config.Data.ErrorPolicy) == 0 {
+		return nil
+	}
+
 	tc := time.NewTicker(EndAtTipCheckInterval)
 	defer tc.Stop()
 
-	firstTipIndex := int64(-1)
-
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 
 		case <-tc.C:
 			atTip, blockIdentifier, err := t.blockStorage.AtTip(ctx, t.config.TipDelay)
 			if err != nil {
 				log.Printf(
-					"%s: unable to evaluate if syncer is at tip",
+					"%s: unable to evaluate if syncer is at tip\n",
 					err.Error(),
 				)
 				continue
 			}
 
-			// If we fall behind tip, we must reset the firstTipIndex.
 			if !atTip {
-				firstTipIndex = int64(-1)
 				continue
 			}
 
-			// If minReconciliationCoverage is less than 0,
-			// we should just stop at tip.
+			t.errorPolicy.ReachedTip()
+			color.Cyan(
+				"[ERROR POLICY] reached tip at block %d, error policy is now in effect",
+				blockIdentifier.Index,
+			)
+			return nil
+		}
+	}
+}
+
+// StartPruning attempts to prune block storage
+// every 10 seconds.
+func (t *DataTester) StartPruning(
+	ctx context.Context,
+) error {
+	if t.config.Data.PruningDisabled {
+		return nil
+	}
+
+	return t.syncer.Prune(ctx, statefulsyncer.DefaultPruningDepth)
+}
+
+// StartBlockResultsFlusher periodically flushes
+// per-block results if a block results file
+// is configured.
+func (t *DataTester) StartBlockResultsFlusher(
+	ctx context.Context,
+) error {
+	if t.blockResults == nil {
+		return nil
+	}
+
+	return t.blockResults.FlushLoop(ctx)
+}
+
+// StartCacheProbe starts the cache probe if
+// cache_probe_interval is populated.
+func (t *DataTester) StartCacheProbe(
+	ctx context.Context,
+) error {
+	if t.cacheProbe == nil {
+		return nil
+	}
+
+	return t.cacheProbe.Loop(ctx)
+}
+
+// StartConsistencyProbe starts the consistency probe
+// if consistency_probe_interval is populated.
+func (t *DataTester) StartConsistencyProbe(
+	ctx context.Context,
+) error {
+	if t.consistencyProbe == nil {
+		return nil
+	}
+
+	return t.consistencyProbe.Loop(ctx)
+}
+
+// StartHistoricalBalanceChecks starts checking historical
+// balances if historical_balance_check_interval is populated
+// (and historical balance lookup is supported).
+func (t *DataTester) StartHistoricalBalanceChecks(
+	ctx context.Context,
+) error {
+	if t.historicalBalances == nil {
+		return nil
+	}
+
+	return t.historicalBalances.Loop(ctx)
+}
+
+// StartReconciler starts the reconciler if
+// reconciliation is enabled.
+func (t *DataTester) StartReconciler(
+	ctx context.Context,
+) error {
+	if !shouldReconcile(t.config) {
+		return nil
+	}
+
+	return t.reconciler.Reconcile(ctx)
+}
+
+// reconciliationQueue returns the reconciler (as a
+// results.ReconciliationQueue) if reconciliation is
+// enabled (or nil if it is not).
+func (t *DataTester) reconciliationQueue() results.ReconciliationQueue {
+	if !shouldReconcile(t.config) {
+		return nil
+	}
+
+	return t.reconciler
+}
+
+// StartPeriodicLogger prints out periodic
+// stats about a run of `check:data`.
+func (t *DataTester) StartPeriodicLogger(
+	ctx context.Context,
+) error {
+	tc := time.NewTicker(PeriodicLoggingFrequency)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.logger.ClearProgressBar()
+			return ctx.Err()
+		case <-tc.C:
+			// Update the elapsed time in counter storage so that
+			// we can log metrics about the current check:data run.
+			_, _ = t.counterStorage.Update(
+				ctx,
+				results.TimeElapsedCounter,
+				big.NewInt(periodicLoggingSeconds),
+			)
+
+			if err := results.SampleMemory(ctx, t.counterStorage); err != nil {
+				log.Printf("%s: unable to sample memory\n", err.Error())
+			}
+			t.storageMonitor.Sample()
+
+			status := results.ComputeCheckDataStatus(
+				ctx,
+				t.config,
+				t.counterStorage,
+				t.balanceStorage,
+				t.fetcher,
+				t.config.Network,
+				t.startedAt,
+				t.syncRate,
+				t.reconciliationQueue(),
+			)
+			t.logger.LogDataStatus(ctx, status)
+			t.checkDegraded(status.Progress, time.Now())
+
+			t.statusMutex.Lock()
+			t.status = status
+			t.statusMutex.Unlock()
+
+			if len(t.config.Data.ResultsOutputFile) > 0 {
+				t.outputPartialResults(ctx, status.Progress)
+			}
+		}
+	}
+}
+
+// StartResultsSnapshots periodically appends a snapshot
+// of the stats and tests of the run to the results snapshot
+// file if results_snapshot_interval is populated. Run waits
+// for this loop to return, so a snapshot is never written
+// while the final results are output.
+func (t *DataTester) StartResultsSnapshots(
+	ctx context.Context,
+) error {
+	if t.config.Data.ResultsSnapshotInterval == 0 {
+		return nil
+	}
+
+	tc := time.NewTicker(time.Duration(t.config.Data.ResultsSnapshotInterval) * time.Second)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+			t.outputSnapshot(ctx)
+		}
+	}
+}
+
+// outputSnapshot appends a snapshot of the stats
+// and tests of the run to the results snapshot file.
+func (t *DataTester) outputSnapshot(ctx context.Context) {
+	snapshotAt := time.Now()
+	tests := results.ComputeCheckDataTests(
+		ctx,
+		t.config,
+		nil,
+		t.counterStorage,
+		t.assertionCatalog,
+		t.negativeRequests,
+		t.historicalBalances.Results(),
+		t.blockPayloads.Results(),
+	)
+	stats := results.ComputeCheckDataStats(
+		ctx,
+		t.config,
+		t.counterStorage,
+		t.balanceStorage,
+		t.fetcher.Asserter,
+	)
+
+	// Stats computed while the run is stopping may be
+	// incomplete, so they are not saved.
+	if ctx.Err() != nil {
+		return
+	}
+
+	snapshot := results.NewCheckDataSnapshot(tests, stats, snapshotAt)
+	if err := results.AppendSnapshot(t.config.Data.ResultsSnapshotFile, snapshot); err != nil {
+		log.Printf("%s: unable to output results snapshot\n", err.Error())
+	}
+}
+
+// checkDegraded sends a DegradedPeriodEvent when syncing
+// has not made progress (while behind tip) for the
+// configured degraded period. Only one event is sent
+// until syncing makes progress again.
+func (t *DataTester) checkDegraded(progress *results.CheckDataProgress, now time.Time) {
+	if t.config.Data.Events == nil || progress == nil {
+		return
+	}
+
+	if progress.Blocks != t.lastSyncedIndex || progress.Blocks >= progress.Tip {
+		t.lastSyncedIndex = progress.Blocks
+		t.lastSyncedAt = now
+		t.degraded = false
+		return
+	}
+
+	stalled := now.Sub(t.lastSyncedAt)
+	if t.degraded || stalled < time.Duration(t.config.Data.Events.DegradedPeriod)*time.Second {
+		return
+	}
+
+	t.degraded = true
+	t.events.Emit(
+		configuration.DegradedPeriodEvent,
+		fmt.Sprintf(
+			"syncing has not progressed past block %d (tip %d) for %s",
+			progress.Blocks,
+			progress.Tip,
+			stalled.Round(time.Second),
+		),
+		logging.Fields{
+			"blocks":          progress.Blocks,
+			"tip":             progress.Tip,
+			"stalled_seconds": int64(stalled.Seconds()),
+		},
+	)
+}
+
+// feeStats returns the *results.FeeStats of the synced
+// blocks (or nil if fee operation types are not configured).
+func (t *DataTester) feeStats(ctx context.Context) *results.FeeStats {
+	if t.feeWorker == nil {
+		return nil
+	}
+
+	fees, err := t.feeWorker.Results(ctx)
+	if err != nil {
+		log.Printf("%s: unable to compute fee results\n", err.Error())
+		return nil
+	}
+
+	return fees
+}
+
+// transferAsymmetries returns the unbalanced transactions
+// recorded in the synced blocks (or nil if transfer
+// symmetry is not configured).
+func (t *DataTester) transferAsymmetries(ctx context.Context) []*results.TransferAsymmetry {
+	if t.transferSymmetryWorker == nil {
+		return nil
+	}
+
+	asymmetries, err := t.transferSymmetryWorker.Results(ctx)
+	if err != nil {
+		log.Printf("%s: unable to get transfer asymmetries\n", err.Error())
+		return nil
+	}
+
+	return asymmetries
+}
+
+// transferPairStats returns the *results.TransferPairStats
+// of the synced blocks (or nil if transfer pairs are not
+// enabled or they cannot be computed).
+func (t *DataTester) transferPairStats(ctx context.Context) *results.TransferPairStats {
+	if t.transferPairWorker == nil {
+		return nil
+	}
+
+	transferPairs, err := t.transferPairWorker.Results(ctx)
+	if err != nil {
+		log.Printf("%s: unable to compute transfer pair results\n", err.Error())
+		return nil
+	}
+
+	return transferPairs
+}
+
+// outputPartialResults writes everything computed so far
+// in the run to the partial results file so that some
+// results are available if check:data is killed.
+func (t *DataTester) outputPartialResults(
+	ctx context.Context,
+	progress *results.CheckDataProgress,
+) {
+	snapshotAt := time.Now()
+	partialResults := results.ComputeCheckDataResults(
+		t.config,
+		nil,
+		t.resultsInputs(ctx, "", ""),
+		snapshotAt,
+	)
+	partialResults.Progress = progress
+
+	if err := partialResults.OutputPartial(t.config.Data.ResultsOutputFile, snapshotAt); err != nil {
+		log.Printf("%s: unable to output partial results\n", err.Error())
+	}
+}
+
+// ServeHTTP serves a CheckDataStatus response on all paths.
+func (t *DataTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	status := results.ComputeCheckDataStatus(
+		r.Context(),
+		t.config,
+		t.counterStorage,
+		t.balanceStorage,
+		t.fetcher,
+		t.network,
+		t.startedAt,
+		t.syncRate,
+		t.reconciliationQueue(),
+	)
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ServeMetrics serves the CheckDataStatus most recently
+// computed by the periodic logger in the Prometheus text
+// exposition format on all paths. Metrics are only updated
+// every PeriodicLoggingFrequency so that scrapes don't
+// trigger additional requests to the implementation.
+func (t *DataTester) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	t.statusMutex.Lock()
+	status := t.status
+	t.statusMutex.Unlock()
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf, t.network, metrics.Values(status)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// tipHold tracks how long the syncer has remained at
+// tip (as of each check of the EndAtTip condition).
+type tipHold struct {
+	period time.Duration
+
+	start      time.Time
+	startIndex int64
+}
+
+// observe records if the syncer is at tip (at index) at now and
+// returns a boolean indicating if it has remained at tip for the
+// hold period. Falling behind tip restarts the hold period.
+func (h *tipHold) observe(atTip bool, index int64, now time.Time) bool {
+	if !atTip {
+		h.start = time.Time{}
+		return false
+	}
+
+	if h.start.IsZero() {
+		h.start = now
+		h.startIndex = index
+	}
+
+	return now.Sub(h.start) >= h.period
+}
+
+// detail returns the detail of the Tip end condition
+// once the syncer has remained at tip (at index) for
+// the hold period.
+func (h *tipHold) detail(index int64, now time.Time) string {
+	if h.period == 0 {
+		return fmt.Sprintf("Tip: %d", index)
+	}
+
+	return fmt.Sprintf(
+		"Tip: %d (Held: %s, New Blocks: %d)",
+		index,
+		now.Sub(h.start).Round(time.Second),
+		index-h.startIndex,
+	)
+}
+
+// EndAtTipLoop runs a loop that evaluates end condition EndAtTip.
+// If holdPeriod is positive, the end condition is only met once
+// the syncer has remained at tip for holdPeriod.
+func (t *DataTester) EndAtTipLoop(
+	ctx context.Context,
+	minReconciliationCoverage float64,
+	holdPeriod time.Duration,
+) {
+	tc := time.NewTicker(EndAtTipCheckInterval)
+	defer tc.Stop()
+
+	firstTipIndex := int64(-1)
+	hold := &tipHold{period: holdPeriod}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tc.C:
+			atTip, blockIdentifier, err := t.blockStorage.AtTip(ctx, t.config.TipDelay)
+			if err != nil {
+				log.Printf(
+					"%s: unable to evaluate if syncer is at tip",
+					err.Error(),
+				)
+				continue
+			}
+
+			// If we fall behind tip, we must reset the firstTipIndex
+			// (and restart the hold period).
+			if !atTip {
+				hold.observe(false, 0, time.Now())
+				firstTipIndex = int64(-1)
+				continue
+			}
+
+			// If minReconciliationCoverage is less than 0,
+			// we should just stop at tip (once we have
+			// remained there for the hold period).
 			if minReconciliationCoverage < 0 {
-				t.endCondition = configuration.TipEndCondition
-				t.endConditionDetail = fmt.Sprintf(
-					"Tip: %d",
-					blockIdentifier.Index,
+				if !hold.observe(true, blockIdentifier.Index, time.Now()) {
+					continue
+				}
+
+				t.setEndCondition(
+					configuration.TipEndCondition,
+					hold.detail(blockIdentifier.Index, time.Now()),
 				)
 				t.cancel()
 				return
@@ -436,7 +1487,18 @@ func (t *DataTester) EndAtTipLoop(
 				firstTipIndex = blockIdentifier.Index
 			}
 
-			coverage, err := t.balanceStorage.ReconciliationCoverage(ctx, firstTipIndex)
+			// Reconciliations before ReconciliationCoverageMinIndex
+			// (or more than CoverageLookbackBlocks before the
+			// current block) never count towards coverage.
+			minIndex := firstTipIndex
+			if coverageMinIndex := results.CoverageMinIndex(
+				t.config,
+				blockIdentifier.Index,
+			); coverageMinIndex > minIndex {
+				minIndex = coverageMinIndex
+			}
+
+			coverage, err := t.balanceStorage.ReconciliationCoverage(ctx, minIndex)
 			if err != nil {
 				log.Printf(
 					"%s: unable to get reconciliations coverage",
@@ -446,10 +1508,114 @@ func (t *DataTester) EndAtTipLoop(
 			}
 
 			if coverage >= minReconciliationCoverage {
-				t.endCondition = configuration.ReconciliationCoverageEndCondition
-				t.endConditionDetail = fmt.Sprintf(
-					"Coverage: %f%%",
-					coverage*utils.OneHundred,
+				t.setEndCondition(
+					configuration.ReconciliationCoverageEndCondition,
+					fmt.Sprintf(
+						"Coverage: %f%%",
+						coverage*utils.OneHundred,
+					),
+				)
+				t.cancel()
+				return
+			}
+		}
+	}
+}
+
+// EndAtHaltLoop runs a loop that evaluates end condition
+// ExpectedHaltIndex. An error is returned if a block after
+// haltIndex is synced or reported as the tip.
+func (t *DataTester) EndAtHaltLoop(
+	ctx context.Context,
+	haltIndex int64,
+	confirmationPeriod time.Duration,
+) error {
+	tc := time.NewTicker(EndAtTipCheckInterval)
+	defer tc.Stop()
+
+	tracker := newHaltTracker(haltIndex, confirmationPeriod)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-tc.C:
+			head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+			if err != nil {
+				log.Printf("%s: unable to get head block", err.Error())
+				continue
+			}
+
+			status, fetchErr := t.fetcher.NetworkStatusRetry(ctx, t.network, nil)
+			if fetchErr != nil {
+				log.Printf("%s: unable to get network status", fetchErr.Err.Error())
+				continue
+			}
+
+			halted, err := tracker.Observe(
+				time.Now(),
+				head.Index,
+				status.CurrentBlockIdentifier.Index,
+			)
+			if err != nil {
+				return err
+			}
+
+			if halted {
+				t.setEndCondition(
+					configuration.HaltEndCondition,
+					fmt.Sprintf(
+						"Halt Index: %d",
+						haltIndex,
+					),
+				)
+				t.cancel()
+				return nil
+			}
+		}
+	}
+}
+
+// EndAtTipLagLoop runs a loop that evaluates end condition
+// TipLagBlocks.
+func (t *DataTester) EndAtTipLagLoop(
+	ctx context.Context,
+	maxLag int64,
+	period time.Duration,
+) {
+	tc := time.NewTicker(EndAtTipCheckInterval)
+	defer tc.Stop()
+
+	tracker := newTipLagTracker(maxLag, period)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tc.C:
+			head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+			if err != nil {
+				log.Printf("%s: unable to get head block", err.Error())
+				continue
+			}
+
+			status, fetchErr := t.fetcher.NetworkStatusRetry(ctx, t.network, nil)
+			if fetchErr != nil {
+				log.Printf("%s: unable to get network status", fetchErr.Err.Error())
+				continue
+			}
+
+			tipIndex := status.CurrentBlockIdentifier.Index
+			if tracker.Observe(time.Now(), head.Index, tipIndex) {
+				t.setEndCondition(
+					configuration.TipLagEndCondition,
+					fmt.Sprintf(
+						"Lag: %d blocks (Synced: %d, Tip: %d) for %d seconds",
+						tipIndex-head.Index,
+						head.Index,
+						tipIndex,
+						int(period.Seconds()),
+					),
 				)
 				t.cancel()
 				return
@@ -459,6 +1625,9 @@ func (t *DataTester) EndAtTipLoop(
 }
 
 // EndDurationLoop runs a loop that evaluates end condition EndDuration.
+// The elapsed time and the last block synced are recorded in the
+// end condition detail (the number of blocks synced in a fixed
+// duration is usually what a smoke test is looking for).
 func (t *DataTester) EndDurationLoop(
 	ctx context.Context,
 	duration time.Duration,
@@ -472,10 +1641,14 @@ func (t *DataTester) EndDurationLoop(
 			return
 
 		case <-timer.C:
-			t.endCondition = configuration.DurationEndCondition
-			t.endConditionDetail = fmt.Sprintf(
-				"Seconds: %d",
-				int(duration.Seconds()),
+			t.setEndCondition(
+				configuration.DurationEndCondition,
+				fmt.Sprintf(
+					"Seconds: %d (Elapsed: %s, %s)",
+					int(duration.Seconds()),
+					time.Since(t.startedAt).Round(time.Second),
+					t.lastSyncedBlock(ctx),
+				),
 			)
 			t.cancel()
 			return
@@ -483,7 +1656,84 @@ func (t *DataTester) EndDurationLoop(
 	}
 }
 
+// EndReconciliationFailuresLoop runs a loop that evaluates end
+// condition MaxReconciliationFailures.
+func (t *DataTester) EndReconciliationFailuresLoop(
+	ctx context.Context,
+	maxFailures int64,
+) {
+	tc := time.NewTicker(EndAtTipCheckInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tc.C:
+			failures, err := t.counterStorage.Get(ctx, results.ReconciliationFailureCounter)
+			if err != nil {
+				log.Printf("%s: unable to get reconciliation failures", err.Error())
+				continue
+			}
+
+			if failures.Int64() >= maxFailures {
+				t.setEndCondition(
+					configuration.ReconciliationFailuresEndCondition,
+					fmt.Sprintf(
+						"Failures: %d (Accounts: %s)",
+						failures.Int64(),
+						strings.Join(failingAccounts(t.reconciliationFailures.Failures()), ", "),
+					),
+				)
+				t.cancel()
+				return
+			}
+		}
+	}
+}
+
+// failingAccounts returns the distinct addresses
+// of failures (in the order they first failed).
+func failingAccounts(failures []*results.ReconciliationFailure) []string {
+	seen := map[string]struct{}{}
+	accounts := []string{}
+	for _, failure := range failures {
+		if _, ok := seen[failure.Account.Address]; ok {
+			continue
+		}
+
+		seen[failure.Account.Address] = struct{}{}
+		accounts = append(accounts, failure.Account.Address)
+	}
+
+	return accounts
+}
+
+// shuffleAccounts shuffles accounts in
+// an order determined by seed.
+func shuffleAccounts(accounts []*reconciler.AccountCurrency, seed int64) {
+	random := rand.New(rand.NewSource(seed)) // #nosec G404
+	random.Shuffle(len(accounts), func(i, j int) {
+		accounts[i], accounts[j] = accounts[j], accounts[i]
+	})
+}
+
+// haltOnReconciliationError returns a boolean indicating
+// if check:data should exit on the first reconciliation
+// failure. Failures are logged (and counted) instead when
+// they are ignored or tolerated by a failure budget.
+func haltOnReconciliationError(config *configuration.Configuration) bool {
+	if config.Data.IgnoreReconciliationError {
+		return false
+	}
+
+	return config.Data.EndConditions == nil ||
+		config.Data.EndConditions.MaxReconciliationFailures == nil
+}
+
 // WatchEndConditions starts go routines to watch the end conditions
+// and blocks until they return.
 func (t *DataTester) WatchEndConditions(
 	ctx context.Context,
 ) error {
@@ -492,100 +1742,331 @@ func (t *DataTester) WatchEndConditions(
 		return nil
 	}
 
+	g, ctx := errgroup.WithContext(ctx)
 	if endConds.Tip != nil && *endConds.Tip {
 		// runs a go routine that ends when reaching tip
-		go t.EndAtTipLoop(ctx, -1)
+		var holdPeriod time.Duration
+		if endConds.TipHoldSeconds != nil {
+			holdPeriod = time.Duration(*endConds.TipHoldSeconds) * time.Second
+		}
+
+		g.Go(func() error {
+			t.EndAtTipLoop(ctx, -1, holdPeriod)
+			return nil
+		})
 	}
 
 	if endConds.Duration != nil && *endConds.Duration != 0 {
 		// runs a go routine that ends after a duration
-		go t.EndDurationLoop(ctx, time.Duration(*endConds.Duration)*time.Second)
+		g.Go(func() error {
+			t.EndDurationLoop(ctx, time.Duration(*endConds.Duration)*time.Second)
+			return nil
+		})
 	}
 
 	if endConds.ReconciliationCoverage != nil {
-		go t.EndAtTipLoop(ctx, *endConds.ReconciliationCoverage)
+		g.Go(func() error {
+			t.EndAtTipLoop(ctx, *endConds.ReconciliationCoverage, 0)
+			return nil
+		})
 	}
 
-	return nil
+	if endConds.ExpectedHaltIndex != nil {
+		confirmationPeriod := uint64(configuration.DefaultHaltConfirmationPeriod)
+		if endConds.HaltConfirmationPeriod != nil {
+			confirmationPeriod = *endConds.HaltConfirmationPeriod
+		}
+
+		// Unlike the other end conditions, a chain that does
+		// not halt as expected is an error.
+		g.Go(func() error {
+			return t.EndAtHaltLoop(
+				ctx,
+				*endConds.ExpectedHaltIndex,
+				time.Duration(confirmationPeriod)*time.Second,
+			)
+		})
+	}
+
+	if endConds.MaxReconciliationFailures != nil {
+		// runs a go routine that ends once the reconciliation
+		// failure budget is exhausted
+		g.Go(func() error {
+			t.EndReconciliationFailuresLoop(ctx, *endConds.MaxReconciliationFailures)
+			return nil
+		})
+	}
+
+	if endConds.TipLagBlocks != nil {
+		period := uint64(configuration.DefaultTipLagPeriod)
+		if endConds.TipLagPeriod != nil {
+			period = *endConds.TipLagPeriod
+		}
+
+		// runs a go routine that ends when the syncer falls
+		// too far behind tip for a sustained period
+		g.Go(func() error {
+			t.EndAtTipLagLoop(
+				ctx,
+				*endConds.TipLagBlocks,
+				time.Duration(period)*time.Second,
+			)
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// setEndCondition records the end condition that ended the run
+// and its detail. End conditions are evaluated concurrently (and
+// another may be met while the run is stopping), so only the
+// first end condition set is recorded.
+func (t *DataTester) setEndCondition(
+	endCondition configuration.CheckDataEndCondition,
+	detail string,
+) {
+	t.endConditionMutex.Lock()
+	defer t.endConditionMutex.Unlock()
+
+	if len(t.endCondition) != 0 {
+		return
+	}
+
+	t.endCondition = endCondition
+	t.endConditionDetail = detail
+}
+
+// getEndCondition returns the end condition that ended
+// the run and its detail (if any end condition was met).
+func (t *DataTester) getEndCondition() (configuration.CheckDataEndCondition, string) {
+	t.endConditionMutex.Lock()
+	defer t.endConditionMutex.Unlock()
+
+	return t.endCondition, t.endConditionDetail
+}
+
+// hasEndCondition returns a boolean indicating
+// if any end condition was met.
+func (t *DataTester) hasEndCondition() bool {
+	endCondition, _ := t.getEndCondition()
+	return len(endCondition) != 0
 }
 
 // HandleErr is called when `check:data` returns an error.
 // If historical balance lookups are enabled, HandleErr will attempt to
 // automatically find any missing balance-changing operations.
 func (t *DataTester) HandleErr(ctx context.Context, err error, sigListeners *[]context.CancelFunc) error {
+	// When a signal is received, the context of the run is canceled
+	// and Run only returns once all blocks being processed are committed.
+	// Any error other than context.Canceled occurred before the signal
+	// (or while stopping) and is still reported.
 	if *t.signalReceived {
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			errors.New("check halted"),
-			"",
-			"",
-		)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return t.exitData(err, "", "")
+		}
+
+		return t.exitData(nil, configuration.InterruptedEndCondition, t.lastSyncedBlock(ctx))
 	}
 
 	if (err == nil || errors.Is(err, context.Canceled)) &&
-		len(t.endCondition) == 0 && t.config.Data.EndConditions != nil &&
+		!t.hasEndCondition() && t.config.Data.EndConditions != nil &&
 		t.config.Data.EndConditions.Index != nil { // occurs at syncer end
-		t.endCondition = configuration.IndexEndCondition
-		t.endConditionDetail = fmt.Sprintf(
-			"Index: %d",
-			*t.config.Data.EndConditions.Index,
+		t.setEndCondition(
+			configuration.IndexEndCondition,
+			fmt.Sprintf(
+				"Index: %d",
+				*t.config.Data.EndConditions.Index,
+			),
 		)
 	}
 
-	if len(t.endCondition) != 0 {
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			nil,
-			t.endCondition,
-			t.endConditionDetail,
-		)
+	if (err == nil || errors.Is(err, context.Canceled)) &&
+		!t.hasEndCondition() && t.config.Data.EndConditions != nil &&
+		t.config.Data.EndConditions.EndBlockIdentifier != nil { // occurs at syncer end
+		detail, endErr := t.endBlock(ctx, t.config.Data.EndConditions.EndBlockIdentifier)
+		if endErr != nil {
+			return t.exitData(endErr, "", "")
+		}
+
+		if len(detail) > 0 {
+			t.setEndCondition(configuration.BlockEndCondition, detail)
+		}
+	}
+
+	if endCondition, detail := t.getEndCondition(); len(endCondition) != 0 {
+		return t.exitData(nil, endCondition, detail)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(color.Output, "\n")
 	if t.reconcilerHandler.InactiveFailure == nil {
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			err,
-			"",
-			"",
-		)
+		return t.exitData(err, "", "")
+	}
+
+	// IgnoreReconciliationError takes precedence over ReconciliationFailFast
+	// (no reconciliation error is returned when it is set).
+	if t.config.Data.ReconciliationFailFast && !t.config.Data.IgnoreReconciliationError {
+		color.Yellow("Search for inactive reconciliation discrepency is skipped (fail fast)")
+		return t.exitData(t.inactiveFailureErr(err), "", "")
 	}
 
 	if !t.historicalBalanceEnabled {
 		color.Yellow(
 			"Can't find the block missing operations automatically, please enable historical balance lookup",
 		)
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			err,
-			"",
-			"",
-		)
+		return t.exitData(err, "", "")
 	}
 
 	if t.config.Data.InactiveDiscrepencySearchDisabled {
 		color.Yellow("Search for inactive reconciliation discrepency is disabled")
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			err,
-			"",
-			"",
-		)
+		return t.exitData(err, "", "")
 	}
 
 	return t.FindMissingOps(ctx, err, sigListeners)
 }
 
+// inactiveFailureErr returns err annotated with the
+// account, currency, and block of the inactive
+// reconciliation failure.
+func (t *DataTester) inactiveFailureErr(err error) error {
+	if err == nil {
+		err = results.ErrReconciliationFailure
+	}
+
+	failure := t.reconcilerHandler.InactiveFailure
+	block := t.reconcilerHandler.InactiveFailureBlock
+	return fmt.Errorf(
+		"%w: inactive reconciliation failed for %s (%s) in block %d:%s",
+		err,
+		types.AccountString(failure.Account),
+		failure.Currency.Symbol,
+		block.Index,
+		block.Hash,
+	)
+}
+
+// endBlock returns the detail of the block end condition
+// if the block synced at the index of expected has its hash
+// (or an empty detail if that block has not been synced).
+func (t *DataTester) endBlock(
+	ctx context.Context,
+	expected *types.BlockIdentifier,
+) (string, error) {
+	index := expected.Index
+	block, err := t.blockStorage.GetBlock(ctx, &types.PartialBlockIdentifier{Index: &index})
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to get block %d", err, index)
+	}
+
+	return matchEndBlock(expected, block.BlockIdentifier)
+}
+
+// lastSyncedBlock returns a description of the
+// last block synced (for InterruptedEndCondition).
+func (t *DataTester) lastSyncedBlock(ctx context.Context) string {
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return "no blocks synced"
+	}
+
+	if err != nil {
+		log.Printf("%s: unable to get last block synced\n", err.Error())
+		return "last synced block unknown"
+	}
+
+	return fmt.Sprintf("last synced block: %d (%s)", head.Index, head.Hash)
+}
+
+// exitData records the results of check:data in the
+// results database (if one is open) and then calls
+// results.ExitData. The results are recorded with a new
+// context because the context of the run may already be
+// canceled.
+func (t *DataTester) exitData(
+	err error,
+	endCondition configuration.CheckDataEndCondition,
+	endConditionDetail string,
+) error {
+	// The status server is only shut down once the
+	// results have been saved.
+	defer t.statusServer.Shutdown()
+	if err != nil {
+		t.statusServer.RecordFatalError(err)
+	}
+
+	// Record the storage statistics at the end of the run.
+	t.storageMonitor.Sample()
+
+	// The final event is delivered before computing the
+	// results so that its delivery is counted.
+	switch {
+	case err != nil:
+		t.events.Emit(configuration.FatalErrorEvent, err.Error(), nil)
+	case len(endCondition) > 0:
+		t.events.Emit(
+			configuration.EndConditionEvent,
+			fmt.Sprintf("%s [%s]", endCondition, endConditionDetail),
+			logging.Fields{"end_condition": endCondition, "detail": endConditionDetail},
+		)
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), EventsCloseTimeout)
+	t.events.Close(closeCtx)
+	cancel()
+
+	inputs := t.resultsInputs(context.Background(), endCondition, endConditionDetail)
+	if t.resultsDatabase != nil {
+		checkDataResults := results.ComputeCheckDataResults(t.config, err, inputs, time.Now())
+		if dbErr := t.resultsDatabase.FinishRun(context.Background(), checkDataResults); dbErr != nil {
+			log.Printf("%s: unable to record results\n", dbErr.Error())
+		}
+	}
+
+	return results.ExitData(t.config, err, inputs)
+}
+
+// resultsInputs returns the *results.CheckDataInputs
+// collected by the run so far.
+func (t *DataTester) resultsInputs(
+	ctx context.Context,
+	endCondition configuration.CheckDataEndCondition,
+	endConditionDetail string,
+) *results.CheckDataInputs {
+	accountTags, err := t.tagReconciliation.Results(ctx, t.balanceStorage)
+	if err != nil {
+		log.Printf("%s: unable to compute account tag results\n", err.Error())
+	}
+
+	return &results.CheckDataInputs{
+		CounterStorage:         t.counterStorage,
+		BalanceStorage:         t.balanceStorage,
+		NetworkAsserter:        t.fetcher.Asserter,
+		AssertionCatalog:       t.assertionCatalog,
+		NegativeRequests:       t.negativeRequests,
+		CacheProbe:             t.cacheProbe.Results(),
+		ConsistencyProbe:       t.consistencyProbe.Results(),
+		HistoricalBalances:     t.historicalBalances.Results(),
+		EndpointParity:         t.endpointParity.Results(),
+		StorageStats:           t.storageMonitor.Results(),
+		AccountTags:            accountTags,
+		EndpointLatency:        t.endpointLatency.Results(),
+		BlockPayloads:          t.blockPayloads.Results(),
+		Fees:                   t.feeStats(ctx),
+		TransferPairs:          t.transferPairStats(ctx),
+		TransferAsymmetries:    t.transferAsymmetries(ctx),
+		EventSinks:             t.events.Results(),
+		ReconciliationFailures: t.reconciliationFailures.Failures(),
+		ErrorPolicy:            t.errorPolicy.Results(),
+		EndCondition:           endCondition,
+		EndConditionDetail:     endConditionDetail,
+		StartedAt:              t.startedAt,
+		Iteration:              t.iteration,
+	}
+}
+
 // FindMissingOps logs the types.BlockIdentifier of a block
 // that is missing balance-changing operations for a
 // *reconciler.AccountCurrency.
@@ -604,14 +2085,7 @@ func (t *DataTester) FindMissingOps(
 	)
 	if err != nil {
 		color.Yellow("%s: could not find block with missing ops", err.Error())
-		return results.ExitData(
-			t.config,
-			t.counterStorage,
-			t.balanceStorage,
-			originalErr,
-			"",
-			"",
-		)
+		return t.exitData(originalErr, "", "")
 	}
 
 	color.Yellow(
@@ -621,14 +2095,7 @@ func (t *DataTester) FindMissingOps(
 		badBlock.Hash,
 	)
 
-	return results.ExitData(
-		t.config,
-		t.counterStorage,
-		t.balanceStorage,
-		originalErr,
-		"",
-		"",
-	)
+	return t.exitData(originalErr, "", "")
 }
 
 func (t *DataTester) recursiveOpSearch(
@@ -664,6 +2131,8 @@ func (t *DataTester) recursiveOpSearch(
 		false,
 		false,
 		false,
+		nil,
+		nil,
 	)
 
 	reconcilerHelper := processor.NewReconcilerHelper(
@@ -671,12 +2140,23 @@ func (t *DataTester) recursiveOpSearch(
 		t.fetcher,
 		blockStorage,
 		balanceStorage,
+		nil, // counters are not updated while finding missing ops
+		nil, // endpoint parity is not checked while finding missing ops
+		0,   // skipped reconciliations are not counted while finding missing ops
 	)
 
 	reconcilerHandler := processor.NewReconcilerHandler(
 		logger,
 		counterStorage,
 		balanceStorage,
+		nil, // reconciliation cursors are not exported while finding missing ops
+		t.assertionCatalog,
+		nil,  // cache probe is not run while finding missing ops
+		nil,  // endpoint parity is not checked while finding missing ops
+		nil,  // account tags are not reported while finding missing ops
+		nil,  // reconciliation failures are not reported while finding missing ops
+		nil,  // events are not sent while finding missing ops
+		nil,  // every reconciliation error aborts while finding missing ops
 		true, // halt on reconciliation error
 	)
 
@@ -701,12 +2181,18 @@ func (t *DataTester) recursiveOpSearch(
 		t.fetcher,
 		t.historicalBalanceEnabled,
 		nil,
+		nil,
 		false,
+		t.assertionCatalog,
 	)
 
 	balanceStorageHandler := processor.NewBalanceStorageHandler(
 		logger,
 		r,
+		reconcilerHelper,
+		nil,
+		nil,
+		nil,
 		true,
 		accountCurrency,
 	)
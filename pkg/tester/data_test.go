@@ -0,0 +1,668 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/client"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBlockResultsWriter(t *testing.T) {
+	startIndex := int64(3)
+	genesisBlock := &types.BlockIdentifier{Index: 1, Hash: "block 1"}
+
+	var tests = map[string]struct {
+		disabled   bool
+		head       *types.BlockIdentifier
+		startIndex *int64
+
+		expectedIndexes []int64
+	}{
+		"disabled": {
+			disabled: true,
+		},
+		"genesis": {
+			expectedIndexes: []int64{0},
+		},
+		"head block": {
+			head:            &types.BlockIdentifier{Index: 4, Hash: "block 4"},
+			expectedIndexes: []int64{0, 1, 2, 3, 4},
+		},
+		"start index": {
+			head:            &types.BlockIdentifier{Index: 4, Hash: "block 4"},
+			startIndex:      &startIndex,
+			expectedIndexes: []int64{0, 1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			ctx := context.Background()
+			localStore, err := storage.NewBadgerStorage(
+				ctx,
+				path.Join(dir, "db"),
+				storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+			)
+			assert.NoError(t, err)
+			defer localStore.Close(ctx)
+
+			blockStorage := storage.NewBlockStorage(localStore)
+			if test.head != nil {
+				dbTransaction := localStore.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, blockStorage.StoreHeadBlockIdentifier(
+					ctx,
+					dbTransaction,
+					test.head,
+				))
+				assert.NoError(t, dbTransaction.Commit(ctx))
+			}
+
+			// Populate results for blocks 0-5 from a previous run.
+			filePath := path.Join(dir, "block_results.jsonl")
+			var existing strings.Builder
+			for i := 0; i <= 5; i++ {
+				existing.WriteString(fmt.Sprintf(
+					"{\"index\":%d,\"hash\":\"block %d\",\"transactions\":0,"+
+						"\"operations\":0,\"orphaned\":false}\n",
+					i,
+					i,
+				))
+			}
+			assert.NoError(t, ioutil.WriteFile(
+				filePath,
+				[]byte(existing.String()),
+				os.FileMode(utils.DefaultFilePermissions),
+			))
+
+			config := configuration.DefaultConfiguration()
+			config.Data.StartIndex = test.startIndex
+			if !test.disabled {
+				config.Data.BlockResultsFile = filePath
+			}
+
+			w, err := loadBlockResultsWriter(ctx, config, blockStorage, genesisBlock)
+			assert.NoError(t, err)
+			if test.disabled {
+				assert.Nil(t, w)
+				return
+			}
+			assert.NoError(t, w.Close())
+
+			contents, err := ioutil.ReadFile(filePath)
+			assert.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+			indexes := []int64{}
+			for i := range lines {
+				indexes = append(indexes, int64(i))
+			}
+			assert.Equal(t, test.expectedIndexes, indexes)
+		})
+	}
+}
+
+// mockDataServer returns an *httptest.Server that implements
+// the subset of the Rosetta Data API used by check:data for
+// a network with empty blocks 0 through tip.
+func mockDataServer(t *testing.T, tip int64) *httptest.Server {
+	blockIdentifier := func(index int64) *types.BlockIdentifier {
+		return &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		}
+	}
+
+	respond := func(w http.ResponseWriter, response interface{}) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/network/list", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, &types.NetworkListResponse{
+			NetworkIdentifiers: []*types.NetworkIdentifier{
+				configuration.DefaultConfiguration().Network,
+			},
+		})
+	})
+	mux.HandleFunc("/network/status", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, &types.NetworkStatusResponse{
+			CurrentBlockIdentifier: blockIdentifier(tip),
+			CurrentBlockTimestamp:  1600000000000,
+			GenesisBlockIdentifier: blockIdentifier(0),
+			Peers:                  []*types.Peer{},
+		})
+	})
+	mux.HandleFunc("/network/options", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, &types.NetworkOptionsResponse{
+			Version: &types.Version{
+				RosettaVersion: "1.4.4",
+				NodeVersion:    "1.0",
+			},
+			Allow: &types.Allow{
+				OperationStatuses: []*types.OperationStatus{
+					{Status: "SUCCESS", Successful: true},
+				},
+				OperationTypes: []string{"Transfer"},
+				Errors:         []*types.Error{},
+			},
+		})
+	})
+	mux.HandleFunc("/block", func(w http.ResponseWriter, r *http.Request) {
+		var request types.BlockRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		index := *request.BlockIdentifier.Index
+		parentIndex := index - 1
+		if index == 0 {
+			parentIndex = 0
+		}
+
+		respond(w, &types.BlockResponse{
+			Block: &types.Block{
+				BlockIdentifier:       blockIdentifier(index),
+				ParentBlockIdentifier: blockIdentifier(parentIndex),
+				Timestamp:             1600000000000 + index,
+				Transactions:          []*types.Transaction{},
+			},
+		})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// initializeMockData initializes a *DataTester that syncs
+// from the mock Rosetta server at config.OnlineURL.
+func initializeMockData(
+	ctx context.Context,
+	t *testing.T,
+	config *configuration.Configuration,
+	httpClient *http.Client,
+	cancel context.CancelFunc,
+	signalReceived *bool,
+) *DataTester {
+	f := fetcher.New(
+		config.OnlineURL,
+		fetcher.WithClient(client.NewAPIClient(client.NewConfiguration(
+			config.OnlineURL,
+			fetcher.DefaultUserAgent,
+			httpClient,
+		))),
+	)
+	_, networkStatus, fetchErr := f.InitializeAsserter(ctx, config.Network)
+	assert.Nil(t, fetchErr)
+
+	return InitializeData(
+		ctx,
+		config,
+		config.Network,
+		f,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		cancel,
+		networkStatus.GenesisBlockIdentifier,
+		nil,
+		signalReceived,
+		1,
+	)
+}
+
+// runMockData performs a single check:data run against
+// a mock Rosetta server and closes everything it opened.
+func runMockData(t *testing.T, dir string) {
+	server := mockDataServer(t, 2)
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	defer httpClient.CloseIdleConnections()
+
+	endIndex := int64(2)
+	config := configuration.DefaultConfiguration()
+	config.OnlineURL = server.URL
+	config.DataDirectory = dir
+	config.Data.StatusPort = 0
+	config.Data.EndConditions = &configuration.DataEndConditions{
+		Index: &endIndex,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalReceived := false
+	dataTester := initializeMockData(ctx, t, config, httpClient, cancel, &signalReceived)
+
+	err := dataTester.Run(ctx)
+	assert.NoError(t, dataTester.HandleErr(context.Background(), err, nil))
+	assert.NoError(t, dataTester.Close(context.Background()))
+}
+
+func TestHandleErrInterrupted(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// The tip is never reached, so the
+	// run only ends when it is interrupted.
+	server := mockDataServer(t, 1000000)
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	defer httpClient.CloseIdleConnections()
+
+	resultsPath := path.Join(dir, "results.json")
+	config := configuration.DefaultConfiguration()
+	config.OnlineURL = server.URL
+	config.DataDirectory = dir
+	config.Data.StatusPort = 0
+	config.Data.ResultsOutputFile = resultsPath
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalReceived := false
+	dataTester := initializeMockData(ctx, t, config, httpClient, cancel, &signalReceived)
+
+	// Interrupt the run (like handleSignals) once
+	// some blocks have been synced.
+	go func() {
+		for {
+			head, err := dataTester.blockStorage.GetHeadBlockIdentifier(ctx)
+			if err == nil && head.Index >= 2 {
+				break
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		signalReceived = true
+		cancel()
+	}()
+
+	runErr := dataTester.Run(ctx)
+	assert.NoError(t, dataTester.HandleErr(context.Background(), runErr, nil))
+
+	head, err := dataTester.blockStorage.GetHeadBlockIdentifier(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, dataTester.Close(context.Background()))
+
+	contents, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+
+	var checkDataResults results.CheckDataResults
+	assert.NoError(t, json.Unmarshal(contents, &checkDataResults))
+	assert.Empty(t, checkDataResults.Error)
+	assert.Equal(t, &results.EndCondition{
+		Type:   configuration.InterruptedEndCondition,
+		Detail: fmt.Sprintf("last synced block: %d (%s)", head.Index, head.Hash),
+	}, checkDataResults.EndCondition)
+}
+
+func TestHandleErrReconciliationFailFast(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	server := mockDataServer(t, 2)
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	defer httpClient.CloseIdleConnections()
+
+	historicalBalanceEnabled := true
+	resultsPath := path.Join(dir, "results.json")
+	config := configuration.DefaultConfiguration()
+	config.OnlineURL = server.URL
+	config.DataDirectory = dir
+	config.Data.StatusPort = 0
+	config.Data.ResultsOutputFile = resultsPath
+	config.Data.HistoricalBalanceEnabled = &historicalBalanceEnabled
+	config.Data.ReconciliationFailFast = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalReceived := false
+	dataTester := initializeMockData(ctx, t, config, httpClient, cancel, &signalReceived)
+	defer func() {
+		assert.NoError(t, dataTester.Close(context.Background()))
+	}()
+
+	// Populate the inactive failure like the
+	// reconciler handler would.
+	dataTester.reconcilerHandler.InactiveFailure = &reconciler.AccountCurrency{
+		Account:  &types.AccountIdentifier{Address: "addr1"},
+		Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+	}
+	dataTester.reconcilerHandler.InactiveFailureBlock = &types.BlockIdentifier{
+		Index: 2,
+		Hash:  "block 2",
+	}
+
+	// The historical search would register a listener
+	// to cancel it.
+	sigListeners := []context.CancelFunc{}
+	runErr := fmt.Errorf("%w: inactive reconciliation error", results.ErrReconciliationFailure)
+	exitErr := dataTester.HandleErr(context.Background(), runErr, &sigListeners)
+	assert.Empty(t, sigListeners)
+	assert.True(t, errors.Is(exitErr, results.ErrReconciliationFailure))
+	assert.Contains(
+		t,
+		exitErr.Error(),
+		"inactive reconciliation failed for addr1 (BTC) in block 2:block 2",
+	)
+
+	contents, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+
+	var checkDataResults results.CheckDataResults
+	assert.NoError(t, json.Unmarshal(contents, &checkDataResults))
+	assert.Contains(t, checkDataResults.Error, "addr1 (BTC) in block 2:block 2")
+	assert.False(t, *checkDataResults.Tests.Reconciliation)
+}
+
+// runMockDataEndConditions performs a single check:data run
+// against a mock Rosetta server with tip that ends with
+// endConditions and returns its results.
+func runMockDataEndConditions(
+	t *testing.T,
+	tip int64,
+	endConditions *configuration.DataEndConditions,
+) (*results.CheckDataResults, error) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	server := mockDataServer(t, tip)
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	defer httpClient.CloseIdleConnections()
+
+	resultsPath := path.Join(dir, "results.json")
+	config := configuration.DefaultConfiguration()
+	config.OnlineURL = server.URL
+	config.DataDirectory = dir
+	config.Data.StatusPort = 0
+	config.Data.ResultsOutputFile = resultsPath
+	config.Data.EndConditions = endConditions
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signalReceived := false
+	dataTester := initializeMockData(ctx, t, config, httpClient, cancel, &signalReceived)
+
+	runErr := dataTester.Run(ctx)
+	exitErr := dataTester.HandleErr(context.Background(), runErr, nil)
+	assert.NoError(t, dataTester.Close(context.Background()))
+
+	contents, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+
+	var checkDataResults results.CheckDataResults
+	assert.NoError(t, json.Unmarshal(contents, &checkDataResults))
+	return &checkDataResults, exitErr
+}
+
+func TestEndDuration(t *testing.T) {
+	duration := uint64(1)
+	farIndex := int64(1000000)
+	nearIndex := int64(2)
+	longDuration := uint64(600)
+
+	t.Run("duration reached first", func(t *testing.T) {
+		// The tip and index are never reached, so
+		// the run ends once the duration elapses.
+		checkDataResults, err := runMockDataEndConditions(t, 1000000, &configuration.DataEndConditions{
+			Duration: &duration,
+			Index:    &farIndex,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, checkDataResults.Error)
+		assert.Equal(t, configuration.DurationEndCondition, checkDataResults.EndCondition.Type)
+		assert.Contains(t, checkDataResults.EndCondition.Detail, "Seconds: 1 (Elapsed: ")
+		assert.Contains(t, checkDataResults.EndCondition.Detail, "last synced block: ")
+	})
+
+	t.Run("index reached first", func(t *testing.T) {
+		checkDataResults, err := runMockDataEndConditions(t, 1000000, &configuration.DataEndConditions{
+			Duration: &longDuration,
+			Index:    &nearIndex,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, checkDataResults.Error)
+		assert.Equal(t, &results.EndCondition{
+			Type:   configuration.IndexEndCondition,
+			Detail: "Index: 2",
+		}, checkDataResults.EndCondition)
+	})
+}
+
+func TestEndBlockIdentifier(t *testing.T) {
+	t.Run("matching block", func(t *testing.T) {
+		checkDataResults, err := runMockDataEndConditions(t, 1000000, &configuration.DataEndConditions{
+			EndBlockIdentifier: &types.BlockIdentifier{Index: 2, Hash: "block 2"},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, checkDataResults.Error)
+		assert.Equal(t, &results.EndCondition{
+			Type:   configuration.BlockEndCondition,
+			Detail: "Index: 2, Hash: block 2",
+		}, checkDataResults.EndCondition)
+	})
+
+	t.Run("different block", func(t *testing.T) {
+		// The synced block at index 2 has a different
+		// hash, so the run fails.
+		checkDataResults, err := runMockDataEndConditions(t, 1000000, &configuration.DataEndConditions{
+			EndBlockIdentifier: &types.BlockIdentifier{Index: 2, Hash: "reorged block 2"},
+		})
+		assert.True(t, errors.Is(err, ErrEndBlockMismatch))
+		assert.Contains(t, checkDataResults.Error, "expected hash reorged block 2 at 2")
+		assert.Nil(t, checkDataResults.EndCondition)
+	})
+}
+
+func TestRunDoesNotLeakGoroutines(t *testing.T) {
+	var baseline int
+	for i := 0; i < 3; i++ {
+		dir, err := utils.CreateTempDir()
+		assert.NoError(t, err)
+
+		runMockData(t, dir)
+		utils.RemoveTempDir(dir)
+
+		// Goroutines started once per process (ex: by
+		// package-level state) are only counted after the
+		// first run.
+		if i == 0 {
+			baseline = waitForGoroutines(0)
+			continue
+		}
+
+		if count := waitForGoroutines(baseline); count > baseline {
+			var stacks strings.Builder
+			_ = pprof.Lookup("goroutine").WriteTo(&stacks, 1)
+			t.Fatalf(
+				"run %d: %d goroutines still running (expected at most %d):\n%s",
+				i+1,
+				count,
+				baseline,
+				stacks.String(),
+			)
+		}
+	}
+}
+
+// waitForGoroutines waits up to 5 seconds for the number
+// of running goroutines to fall to at most limit (or to
+// stop changing, if limit is 0) and returns the number
+// of running goroutines.
+func waitForGoroutines(limit int) int {
+	count := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+
+		next := runtime.NumGoroutine()
+		if (limit > 0 && next <= limit) || (limit == 0 && next == count) {
+			return next
+		}
+
+		count = next
+	}
+
+	return count
+}
+
+func TestReconciliationFailureBudget(t *testing.T) {
+	cfg := configuration.DefaultConfiguration()
+	assert.True(t, haltOnReconciliationError(cfg))
+
+	maxFailures := int64(5)
+	cfg.Data.EndConditions = &configuration.DataEndConditions{
+		MaxReconciliationFailures: &maxFailures,
+	}
+	assert.False(t, haltOnReconciliationError(cfg))
+
+	cfg.Data.EndConditions = nil
+	cfg.Data.IgnoreReconciliationError = true
+	assert.False(t, haltOnReconciliationError(cfg))
+
+	assert.Equal(t, []string{}, failingAccounts(nil))
+	assert.Equal(t, []string{"addr1", "addr2"}, failingAccounts([]*results.ReconciliationFailure{
+		{Account: &types.AccountIdentifier{Address: "addr1"}},
+		{Account: &types.AccountIdentifier{Address: "addr2"}},
+		{Account: &types.AccountIdentifier{Address: "addr1"}},
+	}))
+}
+
+func TestShuffleAccounts(t *testing.T) {
+	accounts := func() []*reconciler.AccountCurrency {
+		accounts := []*reconciler.AccountCurrency{}
+		for i := 0; i < 20; i++ {
+			accounts = append(accounts, &reconciler.AccountCurrency{
+				Account:  &types.AccountIdentifier{Address: fmt.Sprintf("addr%d", i)},
+				Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			})
+		}
+
+		return accounts
+	}
+
+	// The same seed always produces the same order.
+	first := accounts()
+	shuffleAccounts(first, 42)
+	second := accounts()
+	shuffleAccounts(second, 42)
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, accounts(), first)
+	assert.ElementsMatch(t, accounts(), first)
+
+	other := accounts()
+	shuffleAccounts(other, 43)
+	assert.NotEqual(t, first, other)
+}
+
+func TestTipHold(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	hold := &tipHold{period: 10 * time.Minute}
+
+	// The hold period starts once at tip.
+	assert.False(t, hold.observe(false, 90, start))
+	assert.False(t, hold.observe(true, 100, start))
+	assert.False(t, hold.observe(true, 105, start.Add(9*time.Minute)))
+
+	// Falling behind tip restarts the hold period.
+	assert.False(t, hold.observe(false, 106, start.Add(10*time.Minute)))
+	assert.False(t, hold.observe(true, 110, start.Add(11*time.Minute)))
+	assert.False(t, hold.observe(true, 115, start.Add(20*time.Minute)))
+
+	now := start.Add(21 * time.Minute)
+	assert.True(t, hold.observe(true, 120, now))
+	assert.Equal(t, "Tip: 120 (Held: 10m0s, New Blocks: 10)", hold.detail(120, now))
+
+	// Without a hold period, the syncer only
+	// needs to reach tip.
+	hold = &tipHold{}
+	assert.False(t, hold.observe(false, 90, start))
+	assert.True(t, hold.observe(true, 100, start))
+	assert.Equal(t, "Tip: 100", hold.detail(100, start))
+}
+
+func TestSetEndCondition(t *testing.T) {
+	tester := &DataTester{}
+	assert.False(t, tester.hasEndCondition())
+
+	// End conditions are set concurrently by the
+	// end condition loops, and the first one wins.
+	tester.setEndCondition(configuration.DurationEndCondition, "Seconds: 10")
+
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			tester.setEndCondition(configuration.TipEndCondition, fmt.Sprintf("Tip: %d", i))
+			done <- struct{}{}
+		}(i)
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+
+	assert.True(t, tester.hasEndCondition())
+	endCondition, detail := tester.getEndCondition()
+	assert.Equal(t, configuration.DurationEndCondition, endCondition)
+	assert.Equal(t, "Seconds: 10", detail)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// ErrEndBlockMismatch is returned when the block synced at
+// the index of the end block identifier has a different hash.
+var ErrEndBlockMismatch = errors.New("synced block does not match end block identifier")
+
+// matchEndBlock returns the detail of the block end condition
+// if synced (the block synced at the index of expected) has
+// the hash of expected or an error if it does not.
+func matchEndBlock(expected *types.BlockIdentifier, synced *types.BlockIdentifier) (string, error) {
+	if synced.Index != expected.Index {
+		return "", fmt.Errorf(
+			"%w: expected block at %d but synced block at %d",
+			ErrEndBlockMismatch,
+			expected.Index,
+			synced.Index,
+		)
+	}
+
+	if synced.Hash != expected.Hash {
+		return "", fmt.Errorf(
+			"%w: expected hash %s at %d but synced hash %s",
+			ErrEndBlockMismatch,
+			expected.Hash,
+			expected.Index,
+			synced.Hash,
+		)
+	}
+
+	return fmt.Sprintf("Index: %d, Hash: %s", synced.Index, synced.Hash), nil
+}
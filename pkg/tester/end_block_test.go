@@ -0,0 +1,55 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEndBlock(t *testing.T) {
+	expected := &types.BlockIdentifier{Index: 100, Hash: "block 100"}
+
+	var tests = map[string]struct {
+		synced *types.BlockIdentifier
+
+		detail      string
+		expectedErr error
+	}{
+		"matching block": {
+			synced: &types.BlockIdentifier{Index: 100, Hash: "block 100"},
+			detail: "Index: 100, Hash: block 100",
+		},
+		"reorged block": {
+			synced:      &types.BlockIdentifier{Index: 100, Hash: "other block 100"},
+			expectedErr: ErrEndBlockMismatch,
+		},
+		"different index": {
+			synced:      &types.BlockIdentifier{Index: 101, Hash: "block 100"},
+			expectedErr: ErrEndBlockMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			detail, err := matchEndBlock(expected, test.synced)
+			assert.Equal(t, test.detail, detail)
+			assert.True(t, errors.Is(err, test.expectedErr))
+		})
+	}
+}
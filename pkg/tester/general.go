@@ -28,6 +28,11 @@ const (
 	// MemoryLoggingFrequency is the frequency that memory
 	// usage stats are logged to the terminal.
 	MemoryLoggingFrequency = 10 * time.Second
+
+	// ServerShutdownTimeout is the maximum amount of
+	// time to wait for open connections to close when
+	// shutting down a server.
+	ServerShutdownTimeout = 5 * time.Second
 )
 
 // LogMemoryLoop runs a loop that logs memory usage.
@@ -50,6 +55,8 @@ func LogMemoryLoop(
 
 // StartServer stats a server at a port with a particular handler.
 // This is often used to support a status endpoint for a particular test.
+// StartServer blocks until the context is canceled and the server has
+// shut down.
 func StartServer(
 	ctx context.Context,
 	name string,
@@ -61,20 +68,30 @@ func StartServer(
 		Handler: handler,
 	}
 
+	serverErr := make(chan error, 1)
 	go func() {
 		log.Printf("%s server running on port %d\n", name, port)
-		_ = server.ListenAndServe()
+		serverErr <- server.ListenAndServe()
 	}()
 
-	go func() {
-		// If we don't shutdown server, it will
-		// never stop because server.ListenAndServe doesn't
-		// take any context.
-		<-ctx.Done()
-		log.Printf("%s server shutting down", name)
+	select {
+	case err := <-serverErr:
+		// A server that can't be started (ex: if the port is
+		// already in use) should not stop the test.
+		log.Printf("%s: %s server stopped\n", err.Error(), name)
+		return nil
+	case <-ctx.Done():
+	}
 
-		_ = server.Shutdown(ctx)
-	}()
+	// If we don't shutdown server, it will
+	// never stop because server.ListenAndServe doesn't
+	// take any context.
+	log.Printf("%s server shutting down", name)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ServerShutdownTimeout)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+	<-serverErr
 
 	return ctx.Err()
 }
@@ -0,0 +1,73 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBlockAfterExpectedHalt is returned when a block after
+// the expected halt index is synced or reported as the tip.
+var ErrBlockAfterExpectedHalt = errors.New("block found after expected halt index")
+
+// haltTracker determines if a chain halted at an expected
+// index by tracking how long the synced block and the tip
+// of the node have both been at that index.
+type haltTracker struct {
+	haltIndex          int64
+	confirmationPeriod time.Duration
+
+	// haltedSince is when the synced block and the tip
+	// were first both observed at haltIndex (or the zero
+	// time if they are not).
+	haltedSince time.Time
+}
+
+// newHaltTracker returns a new *haltTracker.
+func newHaltTracker(haltIndex int64, confirmationPeriod time.Duration) *haltTracker {
+	return &haltTracker{
+		haltIndex:          haltIndex,
+		confirmationPeriod: confirmationPeriod,
+	}
+}
+
+// Observe records the index of the last synced block and
+// the tip of the node at now. It returns a boolean indicating
+// if the chain has halted at haltIndex for the confirmation
+// period or an error if either index is after haltIndex.
+func (h *haltTracker) Observe(now time.Time, syncedIndex int64, tipIndex int64) (bool, error) {
+	if syncedIndex > h.haltIndex || tipIndex > h.haltIndex {
+		return false, fmt.Errorf(
+			"%w: expected halt at %d but synced %d (tip %d)",
+			ErrBlockAfterExpectedHalt,
+			h.haltIndex,
+			syncedIndex,
+			tipIndex,
+		)
+	}
+
+	if syncedIndex < h.haltIndex || tipIndex < h.haltIndex {
+		h.haltedSince = time.Time{}
+		return false, nil
+	}
+
+	if h.haltedSince.IsZero() {
+		h.haltedSince = now
+	}
+
+	return now.Sub(h.haltedSince) >= h.confirmationPeriod, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaltTracker(t *testing.T) {
+	type observation struct {
+		elapsed time.Duration
+		synced  int64
+		tip     int64
+
+		halted      bool
+		expectedErr error
+	}
+
+	var tests = map[string][]observation{
+		"halted after confirmation period": {
+			{elapsed: 0, synced: 90, tip: 100},
+			{elapsed: time.Minute, synced: 100, tip: 100},
+			{elapsed: 5 * time.Minute, synced: 100, tip: 100},
+			{elapsed: 11 * time.Minute, synced: 100, tip: 100, halted: true},
+		},
+		"tip reorg resets confirmation": {
+			{elapsed: 0, synced: 100, tip: 100},
+			{elapsed: 5 * time.Minute, synced: 99, tip: 100},
+			{elapsed: 6 * time.Minute, synced: 100, tip: 100},
+			{elapsed: 11 * time.Minute, synced: 100, tip: 100},
+			{elapsed: 16 * time.Minute, synced: 100, tip: 100, halted: true},
+		},
+		"tip advances past halt": {
+			{elapsed: 0, synced: 100, tip: 100},
+			{elapsed: time.Minute, synced: 100, tip: 101, expectedErr: ErrBlockAfterExpectedHalt},
+		},
+		"block synced past halt": {
+			{elapsed: 0, synced: 101, tip: 101, expectedErr: ErrBlockAfterExpectedHalt},
+		},
+	}
+
+	start := time.Now()
+	for name, observations := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := newHaltTracker(100, 10*time.Minute)
+			for _, o := range observations {
+				halted, err := tracker.Observe(start.Add(o.elapsed), o.synced, o.tip)
+				assert.Equal(t, o.halted, halted)
+				if o.expectedErr != nil {
+					assert.True(t, errors.Is(err, o.expectedErr))
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// negativeRequestTipOffset is how far beyond the current
+	// tip we request a block when no probes are configured.
+	negativeRequestTipOffset = 1000000
+
+	// negativeRequestHash is the block hash we request when
+	// no probes are configured.
+	negativeRequestHash = "rosetta-cli-invalid-block-hash"
+)
+
+// negativeRequestProbes returns the configured negative
+// request probes or the defaults if none are configured.
+func negativeRequestProbes(
+	config *configuration.Configuration,
+	tip *types.BlockIdentifier,
+) []*types.PartialBlockIdentifier {
+	if len(config.Data.NegativeRequestProbes) > 0 {
+		return config.Data.NegativeRequestProbes
+	}
+
+	farIndex := tip.Index + negativeRequestTipOffset
+	garbageHash := negativeRequestHash
+
+	return []*types.PartialBlockIdentifier{
+		{Index: &farIndex},
+		{Hash: &garbageHash},
+	}
+}
+
+// NegativeRequestTest requests each negative request probe
+// and returns how the implementation handled each one. An
+// implementation passes if it returns a valid Rosetta error
+// (or omits the block) for every probe.
+func NegativeRequestTest(
+	ctx context.Context,
+	config *configuration.Configuration,
+	fetcher *fetcher.Fetcher,
+	network *types.NetworkIdentifier,
+	tip *types.BlockIdentifier,
+) []*results.NegativeRequestResult {
+	if config.Data.NegativeRequestDisabled {
+		return nil
+	}
+
+	negativeRequests := []*results.NegativeRequestResult{}
+	for _, probe := range negativeRequestProbes(config, tip) {
+		result := &results.NegativeRequestResult{
+			BlockIdentifier: probe,
+		}
+
+		block, fetchErr := fetcher.UnsafeBlock(ctx, network, probe)
+		switch {
+		case fetchErr == nil && block != nil:
+			result.Detail = fmt.Sprintf(
+				"returned block %s",
+				types.PrintStruct(block.BlockIdentifier),
+			)
+		case fetchErr == nil:
+			result.Passed = true
+			result.Detail = "block omitted"
+		case fetchErr.ClientErr == nil:
+			result.Detail = fmt.Sprintf("no Rosetta error returned: %s", fetchErr.Err.Error())
+		default:
+			if err := fetcher.Asserter.Error(fetchErr.ClientErr); err != nil {
+				result.Detail = fmt.Sprintf(
+					"invalid Rosetta error %s: %s",
+					types.PrintStruct(fetchErr.ClientErr),
+					err.Error(),
+				)
+				break
+			}
+
+			result.Passed = true
+			result.Detail = fmt.Sprintf(
+				"returned error %d: %s",
+				fetchErr.ClientErr.Code,
+				fetchErr.ClientErr.Message,
+			)
+		}
+
+		negativeRequests = append(negativeRequests, result)
+	}
+
+	return negativeRequests
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegativeRequestTest(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	genesis := &types.BlockIdentifier{Index: 0, Hash: "block 0"}
+	tip := &types.BlockIdentifier{Index: 100, Hash: "block 100"}
+	notFound := &types.Error{Code: 1, Message: "block not found"}
+	unknown := &types.Error{Code: 2, Message: "unknown error"}
+
+	// The server rejects index requests with a known error,
+	// rejects the "unknown" hash with an unknown error, and
+	// returns a block for any other hash.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request types.BlockRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		switch {
+		case request.BlockIdentifier.Index != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+			assert.NoError(t, json.NewEncoder(w).Encode(notFound))
+		case *request.BlockIdentifier.Hash == "unknown":
+			w.WriteHeader(http.StatusInternalServerError)
+			assert.NoError(t, json.NewEncoder(w).Encode(unknown))
+		default:
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.BlockResponse{
+				Block: &types.Block{
+					BlockIdentifier:       tip,
+					ParentBlockIdentifier: genesis,
+				},
+			}))
+		}
+	}))
+	defer server.Close()
+
+	a, err := asserter.NewClientWithOptions(
+		network,
+		genesis,
+		[]string{"Transfer"},
+		[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+		[]*types.Error{notFound},
+	)
+	assert.NoError(t, err)
+
+	f := fetcher.New(
+		server.URL,
+		fetcher.WithAsserter(a),
+		fetcher.WithMaxRetries(0),
+	)
+
+	farIndex := tip.Index + negativeRequestTipOffset
+	garbageHash := negativeRequestHash
+	unknownHash := "unknown"
+	var tests = map[string]struct {
+		disabled bool
+		probes   []*types.PartialBlockIdentifier
+
+		expected []*results.NegativeRequestResult
+	}{
+		"disabled": {
+			disabled: true,
+		},
+		"default probes": {
+			expected: []*results.NegativeRequestResult{
+				{
+					BlockIdentifier: &types.PartialBlockIdentifier{Index: &farIndex},
+					Passed:          true,
+					Detail:          "returned error 1: block not found",
+				},
+				{
+					BlockIdentifier: &types.PartialBlockIdentifier{Hash: &garbageHash},
+					Detail:          "returned block " + types.PrintStruct(tip),
+				},
+			},
+		},
+		"configured probes": {
+			probes: []*types.PartialBlockIdentifier{{Hash: &unknownHash}},
+			expected: []*results.NegativeRequestResult{
+				{
+					BlockIdentifier: &types.PartialBlockIdentifier{Hash: &unknownHash},
+					Detail: "invalid Rosetta error " + types.PrintStruct(unknown) +
+						": " + asserter.ErrErrorUnexpectedCode.Error() + ": code 2",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.Data.NegativeRequestDisabled = test.disabled
+			config.Data.NegativeRequestProbes = test.probes
+
+			assert.Equal(
+				t,
+				test.expected,
+				NegativeRequestTest(context.Background(), config, f, network, tip),
+			)
+		})
+	}
+}
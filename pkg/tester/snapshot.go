@@ -0,0 +1,200 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/dgraph-io/badger/v2"
+)
+
+const (
+	// snapshotAttempts is the number of times a snapshot
+	// of a data directory is attempted before giving up
+	// (opening a database read-only fails if check:data is
+	// in the middle of appending to its value log).
+	snapshotAttempts = 5
+
+	// snapshotPendingWrites is the most pending writes
+	// while loading a backup into a snapshot.
+	snapshotPendingWrites = 256
+)
+
+// ErrReadOnlyDatabase is returned when attempting to write
+// to a database opened with OpenDataSnapshot.
+var ErrReadOnlyDatabase = errors.New("database is a read-only snapshot")
+
+// OpenDataSnapshot opens a read-only snapshot of the check:data
+// database for network in config.DataDirectory, so analysis
+// commands can be run during long runs.
+//
+// The database is opened in badger's read-only mode (without
+// taking the lock held by a running check:data) and copied
+// with badger's backup API into a temporary database. The
+// data directory is never written to, and the snapshot is
+// consistent as of when it was taken.
+//
+// Any attempt to write to the snapshot fails with
+// ErrReadOnlyDatabase. The snapshot is deleted when
+// the database is closed.
+func OpenDataSnapshot(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+) (storage.Database, error) {
+	if len(config.DataDirectory) == 0 {
+		return nil, errors.New("data_directory must be populated")
+	}
+
+	dataPath, err := utils.CreateCommandPath(config.DataDirectory, dataCmdName, network)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot create command path", err)
+	}
+
+	var lastErr error
+	for i := 0; i < snapshotAttempts; i++ {
+		db, err := openSnapshot(ctx, dataPath)
+		if err == nil {
+			return db, nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("%w: unable to snapshot %s", lastErr, dataPath)
+}
+
+// openSnapshot backs up the badger database at dataPath
+// into a temporary directory and opens it read-only.
+func openSnapshot(ctx context.Context, dataPath string) (storage.Database, error) {
+	snapshotPath, err := utils.CreateTempDir()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create snapshot directory", err)
+	}
+
+	if err := backupDatabase(dataPath, snapshotPath); err != nil {
+		utils.RemoveTempDir(snapshotPath)
+		return nil, err
+	}
+
+	db, err := storage.NewBadgerStorage(ctx, snapshotPath)
+	if err != nil {
+		utils.RemoveTempDir(snapshotPath)
+		return nil, fmt.Errorf("%w: unable to open snapshot", err)
+	}
+
+	return &readOnlyDatabase{Database: db, path: snapshotPath}, nil
+}
+
+// backupDatabase copies the badger database at src into
+// a new badger database at dst. src is opened read-only
+// and without its directory lock, so it can be copied
+// while check:data is writing to it.
+func backupDatabase(src string, dst string) error {
+	source, err := badger.Open(
+		badger.DefaultOptions(src).
+			WithReadOnly(true).
+			WithBypassLockGuard(true).
+			WithLogger(nil),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open database read-only", err)
+	}
+	defer source.Close()
+
+	destination, err := badger.Open(badger.DefaultOptions(dst).WithLogger(nil))
+	if err != nil {
+		return fmt.Errorf("%w: unable to create snapshot database", err)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := source.Backup(writer, 0)
+		writer.CloseWithError(err)
+	}()
+
+	loadErr := destination.Load(reader, snapshotPendingWrites)
+
+	// Stop the backup if the load failed before
+	// reading all of it.
+	reader.CloseWithError(loadErr)
+	if err := destination.Close(); err != nil && loadErr == nil {
+		loadErr = err
+	}
+
+	if loadErr != nil {
+		return fmt.Errorf("%w: unable to back up database", loadErr)
+	}
+
+	return nil
+}
+
+// readOnlyDatabase is a storage.Database that
+// fails all writes with ErrReadOnlyDatabase.
+type readOnlyDatabase struct {
+	storage.Database
+
+	// path is the snapshot directory, which
+	// is removed when the database is closed.
+	path string
+}
+
+// NewDatabaseTransaction returns a read-only
+// storage.DatabaseTransaction (even if write
+// is true).
+func (d *readOnlyDatabase) NewDatabaseTransaction(
+	ctx context.Context,
+	write bool,
+) storage.DatabaseTransaction {
+	return &readOnlyTransaction{
+		DatabaseTransaction: d.Database.NewDatabaseTransaction(ctx, false),
+	}
+}
+
+// Close closes the database and removes the snapshot.
+func (d *readOnlyDatabase) Close(ctx context.Context) error {
+	defer utils.RemoveTempDir(d.path)
+
+	return d.Database.Close(ctx)
+}
+
+// readOnlyTransaction is a storage.DatabaseTransaction
+// that fails all writes with ErrReadOnlyDatabase.
+type readOnlyTransaction struct {
+	storage.DatabaseTransaction
+}
+
+// Set returns ErrReadOnlyDatabase.
+func (t *readOnlyTransaction) Set(
+	ctx context.Context,
+	key []byte,
+	value []byte,
+	reclaimValue bool,
+) error {
+	return fmt.Errorf("%w: unable to set %s", ErrReadOnlyDatabase, key)
+}
+
+// Delete returns ErrReadOnlyDatabase.
+func (t *readOnlyTransaction) Delete(ctx context.Context, key []byte) error {
+	return fmt.Errorf("%w: unable to delete %s", ErrReadOnlyDatabase, key)
+}
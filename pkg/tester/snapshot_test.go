@@ -0,0 +1,155 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/storage"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshotTestBlock(index int64) *types.Block {
+	parentIndex := index - 1
+	if parentIndex < 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block %d", index)},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parentIndex,
+			Hash:  fmt.Sprintf("block %d", parentIndex),
+		},
+		Timestamp: 1,
+	}
+}
+
+// snapshotTestFiles returns the size and modification
+// time of each file in dir.
+func snapshotTestFiles(t *testing.T, dir string) map[string]string {
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+
+	stats := map[string]string{}
+	for _, file := range files {
+		stats[file.Name()] = fmt.Sprintf("%d %s", file.Size(), file.ModTime())
+	}
+
+	return stats
+}
+
+func TestOpenDataSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	config := configuration.DefaultConfiguration()
+	config.DataDirectory = dir
+	dataPath, err := utils.CreateCommandPath(dir, dataCmdName, checkpointNetwork)
+	assert.NoError(t, err)
+
+	// Opening the database holds the badger lock
+	// (like an active check:data run).
+	active, err := storage.NewBadgerStorage(
+		ctx,
+		dataPath,
+		storage.WithIndexCacheSize(storage.TinyIndexCacheSize),
+	)
+	assert.NoError(t, err)
+
+	blockStorage := storage.NewBlockStorage(active)
+	assert.NoError(t, blockStorage.AddBlock(ctx, snapshotTestBlock(0)))
+
+	// Keep syncing while checkpoints are exported.
+	const syncedBlocks = 200
+	syncErr := make(chan error, 1)
+	go func() {
+		for i := int64(1); i < syncedBlocks; i++ {
+			if err := blockStorage.AddBlock(ctx, snapshotTestBlock(i)); err != nil {
+				syncErr <- err
+				return
+			}
+		}
+
+		syncErr <- nil
+	}()
+
+	for i := 0; i < 3; i++ {
+		checkpoint, err := ExportCheckpoint(ctx, config, checkpointNetwork)
+		assert.NoError(t, err)
+		assert.True(t, checkpoint.BlockIdentifier.Index < syncedBlocks)
+	}
+
+	assert.NoError(t, <-syncErr)
+
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(syncedBlocks-1), head.Index)
+
+	// Once syncing stops, a snapshot has every block.
+	snapshot, err := OpenDataSnapshot(ctx, config, checkpointNetwork)
+	assert.NoError(t, err)
+
+	snapshotHead, err := storage.NewBlockStorage(snapshot).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, head, snapshotHead)
+
+	// Writes to the snapshot fail.
+	err = storage.NewBlockStorage(snapshot).AddBlock(ctx, snapshotTestBlock(syncedBlocks))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrReadOnlyDatabase.Error())
+
+	txn := snapshot.NewDatabaseTransaction(ctx, true)
+	assert.True(t, errors.Is(txn.Set(ctx, []byte("key"), []byte("value"), true), ErrReadOnlyDatabase))
+	assert.True(t, errors.Is(txn.Delete(ctx, []byte("key")), ErrReadOnlyDatabase))
+	txn.Discard(ctx)
+
+	snapshotPath := snapshot.(*readOnlyDatabase).path
+	assert.NoError(t, snapshot.Close(ctx))
+	_, err = os.Stat(snapshotPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// The active database is unaffected.
+	head, err = blockStorage.GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(syncedBlocks-1), head.Index)
+
+	// Once check:data stops, the data
+	// directory is still never written to.
+	assert.NoError(t, active.Close(ctx))
+	files := snapshotTestFiles(t, dataPath)
+
+	snapshot, err = OpenDataSnapshot(ctx, config, checkpointNetwork)
+	assert.NoError(t, err)
+	assert.NotEqual(t, dataPath, snapshot.(*readOnlyDatabase).path)
+
+	snapshotHead, err = storage.NewBlockStorage(snapshot).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, head, snapshotHead)
+	assert.NoError(t, snapshot.Close(ctx))
+
+	assert.Equal(t, files, snapshotTestFiles(t, dataPath))
+}
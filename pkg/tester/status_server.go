@@ -0,0 +1,158 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	// DataStatusPath serves the status of a
+	// running check:data.
+	DataStatusPath = "/data/status"
+
+	// ConstructionStatusPath serves the status of
+	// a running check:construction.
+	ConstructionStatusPath = "/construction/status"
+
+	// HealthzPath returns 200 until a fatal error
+	// is recorded and 503 after.
+	HealthzPath = "/healthz"
+)
+
+// StatusServer serves the status of a running test at
+// DataStatusPath or ConstructionStatusPath (whichever
+// was provided) and its health at HealthzPath. All other
+// paths serve the status of the test (for tools that
+// query the status on any path).
+//
+// Unlike StartServer, a StatusServer is not stopped when
+// the test stops so that HealthzPath reports a failure
+// while the results of a failed test are computed.
+// Callers must call Shutdown once results are saved.
+type StatusServer struct {
+	data         http.Handler
+	construction http.Handler
+
+	mutex    sync.Mutex
+	fatalErr error
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewStatusServer returns a new *StatusServer. Either
+// handler can be nil if that test is not running.
+func NewStatusServer(data http.Handler, construction http.Handler) *StatusServer {
+	return &StatusServer{
+		data:         data,
+		construction: construction,
+	}
+}
+
+// RecordFatalError records an error that stopped the
+// test (causing HealthzPath to return 503).
+func (s *StatusServer) RecordFatalError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.fatalErr == nil {
+		s.fatalErr = err
+	}
+}
+
+// ServeHTTP routes a request to the status of the
+// running test or its health.
+func (s *StatusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case HealthzPath:
+		s.serveHealthz(w)
+	case DataStatusPath:
+		serveStatus(w, r, s.data)
+	case ConstructionStatusPath:
+		serveStatus(w, r, s.construction)
+	default:
+		if s.data != nil {
+			s.data.ServeHTTP(w, r)
+			return
+		}
+
+		serveStatus(w, r, s.construction)
+	}
+}
+
+func (s *StatusServer) serveHealthz(w http.ResponseWriter) {
+	s.mutex.Lock()
+	fatalErr := s.fatalErr
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	if fatalErr != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, fatalErr.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+func serveStatus(w http.ResponseWriter, r *http.Request, handler http.Handler) {
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Start starts serving on port in a goroutine (if the
+// server is not already running).
+func (s *StatusServer) Start(name string, port uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel = cancel
+	s.done = done
+
+	go func() {
+		defer close(done)
+		_ = StartServer(ctx, name, s, port)
+	}()
+}
+
+// Shutdown stops the server (if it is running) and
+// waits for it to shut down. It is safe to call
+// Shutdown multiple times.
+func (s *StatusServer) Shutdown() {
+	s.mutex.Lock()
+	cancel, done := s.cancel, s.done
+	s.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusServer(t *testing.T) {
+	data := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data"))
+	})
+	server := NewStatusServer(data, nil)
+
+	get := func(path string) (int, string) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		return w.Code, string(body)
+	}
+
+	code, body := get(DataStatusPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "data", body)
+
+	// Other paths serve the status of the running test.
+	code, body = get("/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "data", body)
+
+	code, _ = get(ConstructionStatusPath)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = get(HealthzPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	// Only the first fatal error is reported.
+	server.RecordFatalError(errors.New("sync failed"))
+	server.RecordFatalError(errors.New("reconciliation failed"))
+	code, body = get(HealthzPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "sync failed\n", body)
+
+	// Shutdown can be called without Start and more than once.
+	server.Shutdown()
+	server.Start("test status", 0)
+	server.Shutdown()
+	server.Shutdown()
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"time"
+)
+
+// tipLagTracker determines if the syncer has fallen more
+// than some number of blocks behind the tip of the node
+// for a sustained period.
+type tipLagTracker struct {
+	maxLag int64
+	period time.Duration
+
+	// laggingSince is when the syncer was first observed
+	// more than maxLag blocks behind the tip (or the zero
+	// time if it is not).
+	laggingSince time.Time
+}
+
+// newTipLagTracker returns a new *tipLagTracker.
+func newTipLagTracker(maxLag int64, period time.Duration) *tipLagTracker {
+	return &tipLagTracker{
+		maxLag: maxLag,
+		period: period,
+	}
+}
+
+// Observe records the index of the last synced block and
+// the tip of the node at now. It returns a boolean indicating
+// if the syncer has been more than maxLag blocks behind the
+// tip for the entire period.
+func (t *tipLagTracker) Observe(now time.Time, syncedIndex int64, tipIndex int64) bool {
+	if tipIndex-syncedIndex <= t.maxLag {
+		t.laggingSince = time.Time{}
+		return false
+	}
+
+	if t.laggingSince.IsZero() {
+		t.laggingSince = now
+	}
+
+	return now.Sub(t.laggingSince) >= t.period
+}
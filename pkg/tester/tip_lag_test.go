@@ -0,0 +1,62 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTipLagTracker(t *testing.T) {
+	type observation struct {
+		elapsed time.Duration
+		synced  int64
+		tip     int64
+
+		lagging bool
+	}
+
+	var tests = map[string][]observation{
+		"never lagging": {
+			{elapsed: 0, synced: 90, tip: 100},
+			{elapsed: 10 * time.Minute, synced: 100, tip: 110},
+		},
+		"lagging for period": {
+			{elapsed: 0, synced: 80, tip: 100},
+			{elapsed: time.Minute, synced: 80, tip: 101},
+			{elapsed: 6 * time.Minute, synced: 85, tip: 110, lagging: true},
+		},
+		"brief hiccup resets period": {
+			{elapsed: 0, synced: 80, tip: 100},
+			{elapsed: 4 * time.Minute, synced: 95, tip: 100},
+			{elapsed: 5 * time.Minute, synced: 95, tip: 120},
+			{elapsed: 9 * time.Minute, synced: 95, tip: 120},
+			{elapsed: 10 * time.Minute, synced: 95, tip: 120, lagging: true},
+		},
+	}
+
+	start := time.Now()
+	for name, observations := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := newTipLagTracker(10, 5*time.Minute)
+			for _, o := range observations {
+				lagging := tracker.Observe(start.Add(o.elapsed), o.synced, o.tip)
+				assert.Equal(t, o.lagging, lagging)
+			}
+		})
+	}
+}